@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"drift-watcher/pkg/services/provider"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type attributesCmd struct {
+	Provider string
+	Format   string
+	Cmd      *cobra.Command
+}
+
+// newAttributesCmd creates the 'attributes' command, which prints the attribute table
+// of a resource type: the attributes that can be checked, the aliases they are also
+// accepted under and the API fields they are read from.
+func newAttributesCmd() *attributesCmd {
+	ac := &attributesCmd{}
+	ac.Cmd = &cobra.Command{
+		Use:   "attributes RESOURCE_TYPE",
+		Short: "List the attributes of a resource type and the API fields they are read from",
+		Long: `List the attributes driftwatcher can check for a resource type, named after the
+Terraform schema, with the aliases each is also accepted under and the field of the
+provider API its live value is read from, e.g. vpc_security_group_ids read from the
+SecurityGroups[].GroupId of DescribeInstances.
+
+An alias, such as the security_group_ids of aws_instance, is tracked under the
+attribute it is an alias of, so that the state and the live resource are compared
+under the same name. Patterns such as tags.* cover every key of a map.
+
+The table is the attribute registry of the provider; contributors add an attribute
+by adding a row to it and reading it from the live resource. --format markdown prints
+it as the tables of the README.
+
+For example:
+  driftwatcher attributes aws_instance
+  driftwatcher attributes aws_sqs_queue --format json
+  driftwatcher attributes aws_lb --format markdown
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeResource,
+		RunE:              ac.Run,
+	}
+	ac.Cmd.Flags().StringVar(&ac.Provider, "provider", "aws", "Provider of the resource type (aws, kubernetes)")
+	ac.Cmd.Flags().StringVar(&ac.Format, "format", "table", "Output format (table, json, markdown)")
+	ac.Cmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"aws", "kubernetes"}, cobra.ShellCompDirectiveNoFileComp))
+	return ac
+}
+
+func (a *attributesCmd) Run(cmd *cobra.Command, args []string) error {
+	if !slices.Contains([]string{"table", "json", "markdown"}, a.Format) {
+		return fmt.Errorf("%s output format not currently supported", a.Format)
+	}
+	capabilities, ok := providerCapabilities[a.Provider]
+	if !ok {
+		return fmt.Errorf("%s provider has no attribute table", a.Provider)
+	}
+	resourceType := args[0]
+	var fields []provider.Field
+	if capabilities.fields != nil {
+		fields = capabilities.fields(resourceType)
+	} else {
+		for _, attribute := range capabilities.attributes(resourceType) {
+			fields = append(fields, provider.Field{Attribute: attribute})
+		}
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("%s resource not yet supported for %s provider", resourceType, a.Provider)
+	}
+
+	out := cmd.OutOrStdout()
+	switch a.Format {
+	case "json":
+		encoded, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal attributes: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	case "markdown":
+		return printFieldsMarkdown(out, fields)
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ATTRIBUTE\tALIASES\tAPI FIELD")
+	for _, field := range fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", field.Attribute, dash(strings.Join(field.Aliases, ", ")), dash(field.API))
+	}
+	return tw.Flush()
+}
+
+// printFieldsMarkdown prints fields as a Markdown table, as the README documents them.
+func printFieldsMarkdown(out io.Writer, fields []provider.Field) error {
+	code := func(names ...string) string {
+		quoted := make([]string, 0, len(names))
+		for _, name := range names {
+			quoted = append(quoted, "`"+name+"`")
+		}
+		return strings.Join(quoted, ", ")
+	}
+	fmt.Fprintln(out, "| Attribute | Aliases | API field |")
+	fmt.Fprintln(out, "|---|---|---|")
+	for _, field := range fields {
+		if _, err := fmt.Fprintf(out, "| %s | %s | %s |\n", code(field.Attribute), code(field.Aliases...), field.API); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd_test
+
+import (
+	"bytes"
+	"drift-watcher/cmd"
+	"drift-watcher/pkg/services/provider"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributesCmd(t *testing.T) {
+	t.Cleanup(func() {
+		cmd.RootCmd.SetOut(nil)
+		cmd.RootCmd.SetArgs(nil)
+	})
+	var out bytes.Buffer
+	cmd.RootCmd.SetOut(&out)
+
+	cmd.RootCmd.SetArgs([]string{"attributes", "aws_instance", "--provider", "aws", "--format", "table"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	assert.Equal(t, []string{"ATTRIBUTE", "ALIASES", "API", "FIELD"}, rows[0])
+	assert.Contains(t, rows, []string{"vpc_security_group_ids", "security_group_ids", "DescribeInstances:", "SecurityGroups[].GroupId"})
+	assert.Contains(t, rows, []string{"instance_type", "-", "DescribeInstances:", "InstanceType"})
+
+	out.Reset()
+	cmd.RootCmd.SetArgs([]string{"attributes", "aws_instance", "--provider", "aws", "--format", "json"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	var fields []provider.Field
+	require.NoError(t, json.Unmarshal(out.Bytes(), &fields))
+	assert.Contains(t, fields, provider.Field{Attribute: "cpu_threads_per_core", Aliases: []string{"cpu_thread_per_core"}, API: "DescribeInstances: CpuOptions.ThreadsPerCore"})
+
+	out.Reset()
+	cmd.RootCmd.SetArgs([]string{"attributes", "aws_sqs_queue", "--provider", "aws", "--format", "markdown"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	assert.True(t, strings.HasPrefix(out.String(), "| Attribute | Aliases | API field |\n|---|---|---|\n"), out.String())
+	assert.Contains(t, out.String(), "| `arn` |  | GetQueueAttributes: Attributes[QueueArn] |\n")
+
+	out.Reset()
+	cmd.RootCmd.SetArgs([]string{"attributes", "kubernetes_deployment", "--provider", "kubernetes", "--format", "table"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	assert.Contains(t, out.String(), "spec.replicas")
+
+	cmd.RootCmd.SetArgs([]string{"attributes", "aws_s3_bucket", "--provider", "aws", "--format", "table"})
+	assert.EqualError(t, cmd.RootCmd.Execute(), "aws_s3_bucket resource not yet supported for aws provider")
+
+	cmd.RootCmd.SetArgs([]string{"attributes", "aws_instance", "--provider", "aws", "--format", "yaml"})
+	assert.EqualError(t, cmd.RootCmd.Execute(), "yaml output format not currently supported")
+}
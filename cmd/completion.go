@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/provider/kubernetes"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// providerCapabilities lists, per --provider, the resource types it supports, the
+// attributes that can be checked for each and, for providers that document them, the
+// API fields they are read from, for completion and the attributes command. The
+// ansible provider accepts any resource type and is left out.
+var providerCapabilities = map[string]struct {
+	resourceTypes func() []string
+	attributes    func(resourceType string) []string
+	fields        func(resourceType string) []provider.Field
+}{
+	"aws":        {aws.ResourceTypes, aws.ResourceAttributes, aws.Fields},
+	"kubernetes": {kubernetes.ResourceTypes, kubernetes.ResourceAttributes, nil},
+}
+
+// stateManagerTypes are the values of --state-manager.
+var stateManagerTypes = []string{
+	"terraform\tread a single state file",
+	"terragrunt\tscan every stack under the --configfile directory",
+	"discover\tscan every Terraform root under the --configfile directory",
+}
+
+// newCompletionCmd creates the 'completion' command, which prints the completion
+// script of a shell.
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion bash|zsh|fish",
+		Short: "Print the shell completion script of driftwatcher",
+		Long: `Print the script completing driftwatcher commands and flags in bash, zsh or fish.
+Besides commands and flag names, --provider, --resource, --attributes and
+--state-manager complete with the values the providers support.
+
+To load completions in the current shell:
+
+  bash: source <(driftwatcher completion bash)
+  zsh:  source <(driftwatcher completion zsh)
+  fish: driftwatcher completion fish | source
+
+To load them in every session, write the script to the completion directory of the
+shell instead, e.g. /etc/bash_completion.d/driftwatcher, a directory of your $fpath
+named _driftwatcher, or ~/.config/fish/completions/driftwatcher.fish.`,
+		Args:                  cobra.ExactArgs(1),
+		ValidArgs:             []string{"bash", "zsh", "fish"},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, out := cmd.Root(), cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			default:
+				return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", args[0])
+			}
+		},
+	}
+}
+
+// registerDetectCompletions completes the values of the --provider, --resource,
+// --attributes and --state-manager flags of cmd. Commands that share these flags,
+// such as validate, complete them as well.
+func registerDetectCompletions(cmd *cobra.Command) {
+	cmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"aws", "kubernetes", "ansible"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("state-manager", cobra.FixedCompletions(stateManagerTypes, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("resource", completeResource)
+	cmd.RegisterFlagCompletionFunc("attributes", completeAttributes)
+	cmd.RegisterFlagCompletionFunc("notify", cobra.FixedCompletions([]string{"slack", "github", "gitlab"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// completeResource completes --resource with the resource types of the selected
+// --provider.
+func completeResource(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	capabilities, ok := providerCapabilities[flagValue(cmd, "provider")]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return capabilities.resourceTypes(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAttributes completes the last attribute of the comma-separated
+// --attributes with the attributes of the selected --resource. Patterns such as
+// tags.* complete to their prefix, tags., for the key to be typed.
+func completeAttributes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	capabilities, ok := providerCapabilities[flagValue(cmd, "provider")]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	typed := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		typed = toComplete[:i+1]
+	}
+	var completions []string
+	seen := map[string]bool{}
+	for _, attribute := range capabilities.attributes(flagValue(cmd, "resource")) {
+		attribute, _, _ = strings.Cut(attribute, "*")
+		if !seen[attribute] {
+			seen[attribute] = true
+			completions = append(completions, typed+attribute)
+		}
+	}
+	// no space is added, so that another attribute can follow a comma
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// flagValue returns the value of the named flag of cmd, its default when it is not
+// set.
+func flagValue(cmd *cobra.Command, name string) string {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil {
+		return ""
+	}
+	return flag.Value.String()
+}
@@ -0,0 +1,64 @@
+package cmd_test
+
+import (
+	"bytes"
+	"drift-watcher/cmd"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// complete runs the hidden completion command of the shell scripts with args and
+// returns the completions it prints, without the trailing directive.
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	cmd.RootCmd.SetOut(&out)
+	cmd.RootCmd.SetArgs(append([]string{"__complete"}, args...))
+	t.Cleanup(func() {
+		cmd.RootCmd.SetOut(nil)
+		cmd.RootCmd.SetArgs(nil)
+	})
+	require.NoError(t, cmd.RootCmd.Execute())
+
+	var completions []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, ":") {
+			completions = append(completions, line)
+		}
+	}
+	return completions
+}
+
+func TestCompletionCmd_Shells(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var out bytes.Buffer
+		cmd.RootCmd.SetOut(&out)
+		cmd.RootCmd.SetArgs([]string{"completion", shell})
+		require.NoError(t, cmd.RootCmd.Execute(), shell)
+		assert.Contains(t, out.String(), "driftwatcher", shell)
+	}
+	cmd.RootCmd.SetOut(nil)
+	cmd.RootCmd.SetArgs(nil)
+}
+
+func TestCompletion_DetectFlags(t *testing.T) {
+	resources := complete(t, "detect", "--provider", "aws", "--resource", "")
+	assert.Contains(t, resources, "aws_instance")
+	assert.Contains(t, resources, "aws_sqs_queue")
+
+	assert.Contains(t, complete(t, "validate", "--provider", "kubernetes", "--resource", ""), "kubernetes_deployment")
+	assert.Empty(t, complete(t, "detect", "--provider", "ansible", "--resource", ""))
+
+	attributes := complete(t, "detect", "--provider", "aws", "--resource", "aws_eks_node_group", "--attributes", "version,")
+	assert.Contains(t, attributes, "version,scaling_config")
+	assert.Contains(t, attributes, "version,tags.", "glob patterns complete to their prefix")
+
+	assert.Equal(t, []string{
+		"terraform\tread a single state file",
+		"terragrunt\tscan every stack under the --configfile directory",
+		"discover\tscan every Terraform root under the --configfile directory",
+	}, complete(t, "detect", "--state-manager", ""))
+}
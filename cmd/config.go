@@ -24,10 +24,15 @@ func newConfigCmd() *configCmd {
 		Use:   "config",
 		Short: "Manually change the config values for the CLI",
 		Long: `config lets you set and unset specific configuration values for your profile if
-you need more granular control over the configuration.`,
-		Example: `stripe config --list
-  stripe config --set color off
-  stripe config --unset color`,
+you need more granular control over the configuration.
+
+A profile bundles the settings of a scan target (state_path, state_manager, provider,
+resource, attributes, output_file, format, aws_profile) so that it can be selected
+with 'detect --profile <name>' instead of repeating flags each run.`,
+		Example: `driftwatcher config --list
+  driftwatcher config --profile prod-us-east --set state_path ./prod/terraform.tfstate
+  driftwatcher config --profile prod-us-east --set attributes instance_type,tags
+  driftwatcher config --profile prod-us-east --unset output_file`,
 		RunE: cc.runConfigCmd,
 	}
 
@@ -48,7 +53,7 @@ func (cc *configCmd) runConfigCmd(cmd *cobra.Command, args []string) error {
 	case cc.unset != "":
 		return cc.config.Profile.DeleteConfigField(cc.unset)
 	case cc.list:
-		return cc.config.PrintConfig()
+		return cc.config.PrintConfig(cmd.OutOrStdout())
 	case cc.edit:
 		return cc.config.EditConfig()
 	default:
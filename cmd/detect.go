@@ -1,36 +1,144 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"drift-watcher/config"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/alerting"
+	"drift-watcher/pkg/services/cost"
 	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/filter"
+	"drift-watcher/pkg/services/gitcontext"
+	"drift-watcher/pkg/services/hooks"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/incremental"
+	"drift-watcher/pkg/services/maintenance"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/progress"
 	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/ansible"
 	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/provider/kubernetes"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/remediation"
 	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/sample"
+	"drift-watcher/pkg/services/secrets"
+	"drift-watcher/pkg/services/signing"
 	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/remote"
 	"drift-watcher/pkg/services/statemanager/terraform"
+	"drift-watcher/pkg/services/store"
 	"fmt"
-	"log/slog"
+	"math/rand/v2"
 	"os"
-	"sync"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
 type detectCmd struct {
+	storeFlags
 	StateManager      statemanager.StateManagerI
 	PlatformProvider  provider.ProviderI
 	DriftChecker      driftchecker.DriftChecker
 	Reporter          reporter.OutputWriter
+	ReportStore       store.ReportStore
+	Secrets           *secrets.Resolver
 	Profile           string
-	LocalStackRegion  string
+	AWSRegion         string
 	Provider          string
 	Resource          string
 	TfConfigPath      string
 	OutputPath        string
+	OutputCSV         string
+	Notify            []string
+	NotifyURL         string
+	PRRepo            string
+	PRNumber          int
+	PRToken           string
+	PRAPIURL          string
+	OutputTemplate    string
+	Query             string
 	StateManagerType  string
-	LocalStackUrl     string
+	EndpointURL       string
+	Dev               bool
+	Format            string
+	NoColor           bool
+	Record            bool
+	Append            bool
+	AutoRemediate     bool
+	ResolveRefs       bool
+	AMIAdvisory       bool
+	ResolveParams     bool
+	AssumeYes         bool
+	ScanUnmanaged     bool
+	CheckOutputs      bool
+	Concurrency       int
+	Watch             bool
+	Interval          time.Duration
+	RedactPatterns    []string
+	RedactMode        string
+	Comparison        string
+	AttrComparisons   []string
+	StateHeaders      []string
+	StateRetries      int
+	AWSRetryMode      string
+	ProjectFile       string
+	AWSPartition      string
+	AWSFIPS           bool
+	AWSMaxAttempts    int
+	AWSMaxBackoff     time.Duration
+	AWSRequestRate    float64
+	AWSPageSize       int32
+	CacheTTL          time.Duration
+	CacheDir          string
+	Kubeconfig        string
+	KubeContext       string
+	AnsibleFactsDir   string
+	AnsibleInventory  string
+	AnsibleHostAttr   string
+	AnsibleFacts      []string
+	Policies          []string
+	ProviderSchema    string
+	HookConcurrency   int
+	HookTimeout       time.Duration
+	Maintenance       []string
+	CostEstimates     bool
+	SignKey           string
+	SignKMSKey        string
+	SignKMSAlgorithm  string
+	Alert             string
+	AlertKey          string
+	AlertMinSeverity  string
+	AlertStateFile    string
+	AlertURL          string
+	Filters           []string
+	Excludes          []string
+	IgnoreFile        string
+	Progress          bool
+	ShutdownTimeout   time.Duration
+	Timeout           time.Duration
+	ResourceTimeout   time.Duration
+	Sample            string
+	Limit             int
+	SampleSeed        uint64
+	ChangedOnly       bool
+	ChangedOnlyTTL    time.Duration
+	ChangedOnlyCache  string
+	StateCommits      string
 	AttributesToTrack []string
+	AllAttributes     bool
+	DefaultTags       string
+	EquivalenceFile   string
 	ctx               context.Context
 	Cmd               *cobra.Command
 	cfg               *config.Config
@@ -68,171 +176,843 @@ For example:
   # Check multiple attributes and specify an AWS profile
   yourcommand detect --configfile /path/to/your/main.tf --attributes instance_type,ami --awsprofile my-dev-profile
 
+  # Read the state from an artifact server or bucket
+  yourcommand detect --configfile https://artifacts.example.com/prod.tfstate --state-header "Authorization: Bearer $TOKEN"
+  yourcommand detect --configfile s3://my-bucket/prod/terraform.tfstate
+
+  # Read the state from stdin
+  terraform state pull | yourcommand detect --configfile -
+
   # Output the drift report to a file
   yourcommand detect --configfile /path/to/your/main.tf --output-file drift_report.json
+
+  # Output only the ids of the drifted resources
+  yourcommand detect --configfile /path/to/your/main.tf --query '.reports[] | select(.has_drift) | .resource_id'
 `,
 		RunE: dc.Run,
 	}
 
-	dc.Cmd.Flags().StringVar(&dc.TfConfigPath, "configfile", "", "Path to the terraform configuration file")
+	dc.Cmd.Flags().StringVar(&dc.TfConfigPath, "configfile", "", "Path to the terraform configuration file, a remote state URI (https, s3, gs), or - to read the state from stdin")
 	dc.Cmd.Flags().StringSliceVar(&dc.AttributesToTrack, "attributes", []string{"instance_type"}, "Attributes to check for drift")
-	dc.Cmd.Flags().StringVar(&dc.Profile, "awsprofile", "default", "Attributes to check for drift")
-	dc.Cmd.Flags().StringVar(&dc.LocalStackRegion, "localstackregion", "us-east-1", "Attributes to check for drift")
+	dc.Cmd.Flags().BoolVar(&dc.AllAttributes, "all-attributes", false, "Diff every attribute the provider reads from live resources and report those that differ, besides the tracked --attributes")
+	dc.Cmd.Flags().StringVar(&dc.Profile, "awsprofile", "", "AWS profile to authenticate with, AWS_PROFILE or else the default profile when empty")
+	dc.Cmd.Flags().StringVar(&dc.AWSRegion, "aws-region", "", "AWS region to scan, overriding the region of the profile; --localstackregion is an alias")
 	dc.Cmd.Flags().StringVar(&dc.Provider, "provider", "aws", "Name of provider")
 	dc.Cmd.Flags().StringVar(&dc.Resource, "resource", "aws_instance", "Resource to check for drift")
 	dc.Cmd.Flags().StringVar(&dc.OutputPath, "output-file", "", "Resource to check for drift")
-	dc.Cmd.Flags().StringVar(&dc.StateManagerType, "state-manager", "terraform", "Resource to check for drift")
-	dc.Cmd.Flags().StringVar(&dc.LocalStackUrl, "localstack-url", "", "Resource to check for drift")
+	dc.Cmd.Flags().StringVar(&dc.OutputCSV, "output-csv", "", "CSV file the reports are also written to, besides stdout or --output-file")
+	dc.Cmd.Flags().StringArrayVar(&dc.Notify, "notify", nil, "Also post a summary of the drift of the run to this target: slack, or a comment on a github pull request or gitlab merge request (repeatable)")
+	dc.Cmd.Flags().StringVar(&dc.NotifyURL, "notify-url", "", "Incoming webhook URL used by --notify slack (default: $SLACK_WEBHOOK_URL)")
+	dc.Cmd.Flags().StringVar(&dc.PRRepo, "pr-repo", "", "GitHub owner/name or GitLab project id or path commented on by --notify github or gitlab (default: $GITHUB_REPOSITORY or $CI_PROJECT_ID)")
+	dc.Cmd.Flags().IntVar(&dc.PRNumber, "pr-number", 0, "Pull request number or merge request iid commented on by --notify github or gitlab (default: from $GITHUB_REF or $CI_MERGE_REQUEST_IID)")
+	dc.Cmd.Flags().StringVar(&dc.PRToken, "pr-token", "", "Token the pull request comment is posted with (default: $GITHUB_TOKEN or $GITLAB_TOKEN)")
+	dc.Cmd.Flags().StringVar(&dc.PRAPIURL, "pr-api-url", "", "API endpoint of GitHub Enterprise or a self-managed GitLab (default: $GITHUB_API_URL or $CI_API_V4_URL, else the public API)")
+	dc.Cmd.Flags().StringVar(&dc.OutputTemplate, "output-template", "", "Go text/template file the reports of a run are rendered through, written to --output-file or stdout")
+	dc.Cmd.Flags().StringVar(&dc.Query, "query", "", "jq query applied to the aggregated report of a run, e.g. '.reports[] | select(.has_drift) | .resource_id', whose results are written to --output-file or stdout")
+	dc.Cmd.Flags().StringVar(&dc.StateManagerType, "state-manager", "terraform", "State manager used to read the state (terraform, terragrunt to scan every stack under the --configfile directory, or discover to scan every Terraform root under it)")
+	dc.Cmd.Flags().StringVar(&dc.EndpointURL, "endpoint-url", "", "Endpoint every AWS API call is sent to instead of the AWS endpoints, e.g. http://localhost:4566 for LocalStack; --localstack-url is an alias")
+	dc.Cmd.Flags().BoolVar(&dc.Dev, "dev", false, "Scan a local LocalStack container: defaults --endpoint-url to "+aws.LocalStackEndpoint+" and --aws-region to "+aws.LocalStackRegion+", and uses its test credentials")
+	dc.Cmd.Flags().StringVar(&dc.Format, "format", "json", "Format of reports written to stdout (json, diff, ndjson); --output-format is an alias")
+	dc.Cmd.Flags().BoolVar(&dc.NoColor, "no-color", false, "Disable colored output for the diff format")
+	dc.Cmd.Flags().BoolVar(&dc.Append, "append", false, "Append rows to an existing CSV output file instead of replacing it")
+	dc.Cmd.Flags().BoolVar(&dc.AutoRemediate, "auto-remediate", false, "Revert drift on allowlisted attributes (tags, security groups, instance type) to the state file values")
+	dc.Cmd.Flags().BoolVar(&dc.AssumeYes, "yes", false, "Apply every remediation without asking for confirmation")
+	dc.Cmd.Flags().BoolVar(&dc.ResolveRefs, "resolve-references", false, "Name the subnets, security groups, VPCs and AMIs referenced by drifted attributes, at the cost of extra describe calls")
+	dc.Cmd.Flags().BoolVar(&dc.AMIAdvisory, "ami-advisory", false, "Advise when a newer AMI matches the aws_ami data source the AMI of an instance was selected with, at the cost of a DescribeImages call per instance")
+	dc.Cmd.Flags().BoolVar(&dc.ResolveParams, "resolve-parameters", false, "Resolve SSM parameter and Secrets Manager references held by the state, e.g. resolve:ssm:/ami/latest or a secret ARN, and compare their values instead; resolved secrets are redacted from reports")
+	dc.Cmd.Flags().BoolVar(&dc.ScanUnmanaged, "scan-unmanaged", false, "Report live resources that are missing from the state file, with import suggestions")
+	dc.Cmd.Flags().BoolVar(&dc.CheckOutputs, "check-outputs", false, "Report state outputs that no longer match the live attribute of the checked resource they were taken from, e.g. a public_ip")
+	dc.Cmd.Flags().BoolVar(&dc.Record, "record", false, "Persist every drift report to the report store for later 'history' queries")
+	dc.Cmd.Flags().StringArrayVar(&dc.StateHeaders, "state-header", nil, "Header sent when fetching a remote state URI, as 'Name: value' (repeatable)")
+	dc.Cmd.Flags().IntVar(&dc.StateRetries, "state-retries", 3, "Number of times a failed remote state download is retried")
+	dc.Cmd.Flags().StringVar(&dc.Comparison, "comparison", string(driftchecker.CompareAuto), "How values are compared (auto, exact, numeric, boolean, case-insensitive)")
+	dc.Cmd.Flags().StringVar(&dc.DefaultTags, "default-tags", string(driftchecker.DefaultTagsCompare), "How tags inherited from provider default_tags (found only in tags_all) are compared: compare reports them missing as DEFAULT_TAG_MISSING_IN_INFRASTRUCTURE, ignore skips them")
+	dc.Cmd.Flags().StringVar(&dc.EquivalenceFile, "equivalence-file", "", "YAML file of equivalence rules declaring differing attribute values equal, e.g. standard and magnetic volume types")
+	dc.Cmd.Flags().StringSliceVar(&dc.AttrComparisons, "compare-attribute", nil, "Per-attribute comparison override as attribute=comparison, e.g. tags.Env=case-insensitive")
+	dc.Cmd.Flags().StringSliceVar(&dc.RedactPatterns, "redact", redact.DefaultPatterns, "Glob patterns of attribute names whose values are redacted in reports, in addition to attributes marked sensitive in the state")
+	dc.Cmd.Flags().StringVar(&dc.RedactMode, "redact-mode", string(redact.ModeMask), "How sensitive values are redacted (mask, hash, none)")
+	dc.Cmd.Flags().BoolVar(&dc.Watch, "watch", false, "Keep checking for drift every --interval and only report changes in drift")
+	dc.Cmd.Flags().DurationVar(&dc.Interval, "interval", 5*time.Minute, "Time between checks in watch mode")
+	dc.Cmd.Flags().StringArrayVar(&dc.Filters, "filter", nil, "Only check resources matching name=<glob>, module=<glob> or tag:<key>=<glob> (repeatable)")
+	dc.Cmd.Flags().StringArrayVar(&dc.Excludes, "exclude", nil, "Skip resources whose address (type.name) matches this glob pattern (repeatable)")
+	dc.Cmd.Flags().StringVar(&dc.ProjectFile, "project-file", "", "Project file declaring the state, provider, attributes, output and exclusions of the scan (default: driftwatcher.yaml in the working directory or its closest parent holding one)")
+	dc.Cmd.Flags().StringVar(&dc.IgnoreFile, "ignore-file", ignore.DefaultFile, "File of gitignore-style patterns of resource addresses to skip")
+	dc.Cmd.Flags().BoolVar(&dc.Progress, "progress", false, "Report scan progress (resources checked, current resource, ETA) on stderr")
+	dc.Cmd.Flags().DurationVar(&dc.ShutdownTimeout, "shutdown-timeout", driftwatcher.DefaultShutdownTimeout, "How long checks already in progress may finish after an interrupt before the partial results are flushed")
+	dc.Cmd.Flags().DurationVar(&dc.Timeout, "timeout", 0, "Maximum duration of a drift check; resources not checked in time are left out of a partial report (0 for no limit)")
+	dc.Cmd.Flags().DurationVar(&dc.ResourceTimeout, "per-resource-timeout", 0, "Maximum duration of the provider calls for a single resource, so one hung call cannot stall the check (0 for no limit)")
+	dc.Cmd.Flags().StringVar(&dc.Sample, "sample", "", "Quick scan: check a random share of the resources, e.g. 10%; the sample is recorded in the reports and summary")
+	dc.Cmd.Flags().IntVar(&dc.Limit, "limit", 0, "Quick scan: check at most this many resources, the first in state order (after --sample)")
+	dc.Cmd.Flags().Uint64Var(&dc.SampleSeed, "sample-seed", 0, "Seed of the random --sample, to check the same sample again (default: a random seed, recorded in the reports)")
+	dc.Cmd.Flags().BoolVar(&dc.ChangedOnly, "changed-only", false, "Skip resources whose attributes have not changed in state since their last check, tracked per state file with its serial in a local cache")
+	dc.Cmd.Flags().DurationVar(&dc.ChangedOnlyTTL, "changed-only-ttl", incremental.DefaultTTL, "Check a resource unchanged in state again once its last check is older than this duration (0 trusts it forever)")
+	dc.Cmd.Flags().StringVar(&dc.ChangedOnlyCache, "changed-only-cache", "", "File the last check of every resource is recorded in for --changed-only (default: incremental.json in the driftwatcher folder of the user cache directory)")
+	dc.Cmd.Flags().StringVar(&dc.StateCommits, "state-commits", "", "YAML file mapping state serials to the commits they were applied from; warns when the commit of the checked serial is not in the current git branch")
+	dc.Cmd.Flags().IntVar(&dc.Concurrency, "concurrency", driftwatcher.DefaultConcurrency, "Number of resources checked in parallel")
+	dc.Cmd.Flags().StringVar(&dc.AWSRetryMode, "aws-retry-mode", aws.DefaultRetryMode, "Retry strategy for AWS API calls (standard, adaptive)")
+	dc.Cmd.Flags().IntVar(&dc.AWSMaxAttempts, "aws-max-attempts", aws.DefaultMaxAttempts, "Maximum attempts per AWS API call, including the first")
+	dc.Cmd.Flags().DurationVar(&dc.AWSMaxBackoff, "aws-max-backoff", aws.DefaultMaxBackoff, "Maximum delay between retries of an AWS API call")
+	dc.Cmd.Flags().Float64Var(&dc.AWSRequestRate, "aws-request-rate", aws.DefaultRequestRate, "Maximum AWS list and describe calls per second, shared by every resource of the scan (negative for no limit)")
+	dc.Cmd.Flags().Int32Var(&dc.AWSPageSize, "aws-page-size", aws.DefaultPageSize, "Number of results requested per page by AWS list and describe calls (5-1000)")
+	dc.Cmd.Flags().StringVar(&dc.AWSPartition, "aws-partition", "", "AWS partition of the account (aws, aws-us-gov, aws-cn); the region must belong to it (default: the partition of the region)")
+	dc.Cmd.Flags().BoolVar(&dc.AWSFIPS, "aws-fips", false, "Call the FIPS 140 endpoints of AWS services (aws and aws-us-gov partitions only)")
+	dc.Cmd.Flags().DurationVar(&dc.CacheTTL, "cache-ttl", 0, "Reuse live resource metadata fetched within this duration instead of querying the provider again (0 disables the cache)")
+	dc.Cmd.Flags().StringVar(&dc.CacheDir, "cache-dir", "", "Directory the metadata cache is persisted to (default: the driftwatcher folder in the user cache directory)")
+	dc.Cmd.Flags().StringVar(&dc.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file used by the kubernetes provider (default: $KUBECONFIG or ~/.kube/config)")
+	dc.Cmd.Flags().StringVar(&dc.KubeContext, "kube-context", "", "Kubeconfig context used by the kubernetes provider (default: the current context)")
+	dc.Cmd.Flags().StringVar(&dc.AnsibleFactsDir, "ansible-facts-dir", "", "Directory of facts gathered per host (jsonfile fact cache or 'ansible -m setup --tree' output) used by the ansible provider")
+	dc.Cmd.Flags().StringVar(&dc.AnsibleInventory, "ansible-inventory", "", "INI or YAML inventory used by the ansible provider to match hosts addressed by ansible_host")
+	dc.Cmd.Flags().StringVar(&dc.AnsibleHostAttr, "ansible-host-attribute", ansible.DefaultHostAttribute, "State attribute holding the host name or address of a resource for the ansible provider")
+	dc.Cmd.Flags().StringArrayVar(&dc.AnsibleFacts, "ansible-fact", nil, "Compare a state attribute with a fact, as attribute=fact, e.g. memory=ansible_memtotal_mb (repeatable)")
+	dc.Cmd.Flags().StringArrayVar(&dc.Policies, "policy", nil, "Rego policy file, or directory of .rego files, evaluated over every report to flag compliance violations (repeatable)")
+	dc.Cmd.Flags().StringVar(&dc.ProviderSchema, "provider-schema", "", "Output of 'terraform providers schema -json', or an initialized Terraform directory to run it in, the --attributes and --policy files are checked against before scanning; none to skip the check (default: the bundled schemas of the AWS resources)")
+	dc.Cmd.Flags().IntVar(&dc.HookConcurrency, "hook-concurrency", hooks.DefaultConcurrency, "Number of drift hooks from the config profile run at the same time")
+	dc.Cmd.Flags().DurationVar(&dc.HookTimeout, "hook-timeout", hooks.DefaultTimeout, "Time a drift hook may run before it is killed, unless the hook sets its own timeout")
+	dc.Cmd.Flags().StringArrayVar(&dc.Maintenance, "maintenance", nil, "Maintenance window during which drift on matching resources is reported as expected and raises no alert or hook, as PATTERN@START/END[=REASON], e.g. aws_instance.db-*@2026-10-16T22:00:00Z/2h=CHG-1042 (repeatable)")
+	dc.Cmd.Flags().BoolVar(&dc.CostEstimates, "cost-estimates", false, "Annotate drifted instance types, instance classes and storage sizes and types with the approximate monthly cost delta, from bundled us-east-1 on-demand prices")
+	dc.Cmd.Flags().StringVar(&dc.SignKey, "sign-key", "", "PEM private key (Ed25519 or ECDSA P-256) used to sign the --output-file report, written to <output-file>.sig")
+	dc.Cmd.Flags().StringVar(&dc.SignKMSKey, "sign-kms-key", "", "AWS KMS key id, ARN or alias used to sign the --output-file report, written to <output-file>.sig")
+	dc.Cmd.Flags().StringVar(&dc.SignKMSAlgorithm, "sign-kms-algorithm", signing.DefaultKMSAlgorithm, "KMS signing algorithm used with --sign-kms-key")
+	dc.Cmd.Flags().StringVar(&dc.Alert, "alert", "", "Open incidents for severe drift and resolve them once it is gone: pagerduty or opsgenie")
+	dc.Cmd.Flags().StringVar(&dc.AlertKey, "alert-key", "", "PagerDuty integration routing key or Opsgenie API key used by --alert")
+	dc.Cmd.Flags().StringVar(&dc.AlertMinSeverity, "alert-min-severity", policy.SeverityCritical, "Lowest policy severity of a drifted attribute that opens an incident: low, medium, high or critical")
+	dc.Cmd.Flags().StringVar(&dc.AlertStateFile, "alert-state-file", "", "File the incidents opened by --alert are tracked in between runs (default: the driftwatcher folder in the user cache directory)")
+	dc.Cmd.Flags().StringVar(&dc.AlertURL, "alert-url", "", "Alert API endpoint, e.g. https://api.eu.opsgenie.com/v2/alerts for Opsgenie EU accounts (default: the public endpoint of the service)")
+	addStoreFlags(dc.Cmd, &dc.storeFlags)
+	dc.Cmd.Flags().SetNormalizeFunc(flagAliases)
+	registerDetectCompletions(dc.Cmd)
 
 	return dc
 }
 
 func (d *detectCmd) Run(cmd *cobra.Command, args []string) error {
+	// prefer the context the command was executed with, which is cancelled on SIGINT
+	// and SIGTERM
+	if ctx := cmd.Context(); ctx != nil {
+		d.ctx = ctx
+	}
+	if err := d.resolveSettings(cmd); err != nil {
+		return err
+	}
+
 	if d.TfConfigPath == "" {
-		slog.Error("Invalid state file path provided")
+		logging.FromContext(d.ctx).Error("Invalid state file path provided")
 		return fmt.Errorf("A state file is required")
 	}
+	if d.Timeout < 0 || d.ResourceTimeout < 0 {
+		return fmt.Errorf("timeouts cannot be negative")
+	}
+	if d.Watch && d.TfConfigPath == statemanager.StdinStatePath {
+		return fmt.Errorf("--watch cannot read the state from stdin, which the first check consumes; pass a state file")
+	}
+	if d.AutoRemediate && !d.AssumeYes && d.TfConfigPath == statemanager.StdinStatePath {
+		return fmt.Errorf("--auto-remediate cannot confirm changes on stdin, which the state is read from; pass --yes")
+	}
 
-	if d.StateManager == nil {
-		switch d.StateManagerType {
-		case "terraform":
-			d.StateManager = terraform.NewTerraformManager()
-		default:
-			return fmt.Errorf("%s statemanager not currently supported", d.StateManagerType)
+	policies, err := d.loadPolicies()
+	if err != nil {
+		return err
+	}
+	if err := d.checkSchema(policies); err != nil {
+		return err
+	}
+
+	if err := d.setupStateManager(); err != nil {
+		return err
+	}
+
+	if err := d.setupProvider(); err != nil {
+		return err
+	}
+
+	if d.DriftChecker == nil {
+		checkerOpts, err := d.checkerOptions()
+		if err != nil {
+			return err
 		}
+		if d.ResolveParams {
+			resolver, ok := d.PlatformProvider.(provider.ValueResolverI)
+			if !ok {
+				return fmt.Errorf("%s platform does not support resolving parameters", d.Provider)
+			}
+			checkerOpts = append(checkerOpts, driftchecker.WithValueResolver(resolver))
+		}
+		d.DriftChecker = driftchecker.NewDefaultDriftChecker(checkerOpts...)
 	}
 
-	if d.LocalStackUrl != "" {
-		os.Setenv("DRIFT_LOCALSTACK_URL", d.LocalStackUrl)
-		os.Setenv("DRIFT_LOCALSTACK_REGION", d.LocalStackRegion)
-		defer os.Unsetenv("DRIFT_LOCALSTACK_URL")
-		defer os.Unsetenv("DRIFT_LOCALSTACK_REGION")
+	// every report and log line of this invocation carries the id of its run
+	runMeta := d.runMetadata()
+	d.ctx = driftchecker.NewRunContext(d.ctx, runMeta)
+	runId := runMeta.RunId
+
+	if d.Reporter == nil {
+		outputWriter, err := d.outputReporter(runId)
+		if err != nil {
+			return err
+		}
+		d.Reporter = outputWriter
 	}
 
-	if d.PlatformProvider == nil {
-		switch d.Provider {
-		case "aws":
-			config, err := aws.CheckAWSConfig("", d.Profile)
+	if d.SignKey != "" || d.SignKMSKey != "" {
+		signer, err := d.reportSigner()
+		if err != nil {
+			return err
+		}
+		d.Reporter = signing.NewReporter(d.Reporter, d.OutputPath, signer)
+	}
+
+	if d.Alert != "" {
+		notifier, err := alerting.NewNotifier(d.Alert, d.AlertKey, d.AlertURL)
+		if err != nil {
+			return err
+		}
+		stateFile := d.AlertStateFile
+		if stateFile == "" {
+			stateFile = alerting.DefaultStateFile()
+		}
+		alerts, err := alerting.NewReporter(d.Reporter, notifier, d.AlertMinSeverity, stateFile)
+		if err != nil {
+			return err
+		}
+		d.Reporter = alerts
+	}
+
+	if d.Record {
+		if d.ReportStore == nil {
+			reportStore, err := openReportStore(d.ctx, d.cfg, d.storeFlags)
 			if err != nil {
 				return err
 			}
+			defer reportStore.Close()
+			d.ReportStore = reportStore
+		}
 
-			provider, err := aws.NewAWSProvider(&config)
+		run := store.RunMetadata{
+			RunId:        runId,
+			StartedAt:    runMeta.StartedAt,
+			StatePath:    d.TfConfigPath,
+			Provider:     d.Provider,
+			ResourceType: d.Resource,
+			Attributes:   d.AttributesToTrack,
+		}
+		if err := d.ReportStore.StartRun(d.ctx, run); err != nil {
+			return err
+		}
+		d.Reporter = reporter.NewStoreReporter(d.ReportStore, run.RunId, d.Reporter)
+	}
+
+	redactor, err := redact.NewRedactor(d.RedactPatterns, redact.Mode(d.RedactMode))
+	if err != nil {
+		return err
+	}
+
+	filters, exclusions, err := d.scope()
+	if err != nil {
+		return err
+	}
+
+	opts := []driftwatcher.DetectionOption{
+		driftwatcher.WithConcurrency(d.Concurrency),
+		driftwatcher.WithStdin(cmd.InOrStdin()),
+		driftwatcher.WithRedaction(redactor),
+		driftwatcher.WithFilters(filters...),
+		driftwatcher.WithExclusions(exclusions),
+		driftwatcher.WithShutdownTimeout(d.ShutdownTimeout),
+		driftwatcher.WithResourceTimeout(d.ResourceTimeout),
+	}
+	if d.Sample != "" || d.Limit != 0 {
+		sampling, err := d.sampling()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, driftwatcher.WithSampling(sampling))
+	}
+	if d.ChangedOnly {
+		if d.Watch {
+			return fmt.Errorf("--changed-only cannot be used with --watch, which checks every resource on each interval")
+		}
+		cacheFile := cmp.Or(d.ChangedOnlyCache, incremental.DefaultFile())
+		if cacheFile == "" {
+			return fmt.Errorf("--changed-only requires --changed-only-cache when no user cache directory is available")
+		}
+		cache, err := incremental.Open(cacheFile, d.ChangedOnlyTTL)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, driftwatcher.WithChangedOnly(cache))
+	}
+	if d.StateCommits != "" {
+		commits, err := gitcontext.Load(d.StateCommits)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, driftwatcher.WithStateCommits(commits))
+	}
+	if d.Progress {
+		stderr := cmd.ErrOrStderr()
+		tracker := progress.New(stderr, progress.IsTerminal(stderr))
+		tracker.Logger = logging.Module(d.ctx, "progress")
+		opts = append(opts, driftwatcher.WithProgress(tracker))
+	}
+	if d.AutoRemediate {
+		remediator, ok := d.PlatformProvider.(provider.RemediatorI)
+		if !ok {
+			return fmt.Errorf("%s platform does not support remediation", d.Provider)
+		}
+		var confirm remediation.ConfirmFunc
+		if !d.AssumeYes {
+			confirm = remediation.PromptConfirm(cmd.InOrStdin(), cmd.ErrOrStderr(), redactor)
+		}
+		engine := remediation.NewEngine(remediator, confirm)
+		engine.Redactor = redactor
+		opts = append(opts, driftwatcher.WithRemediation(engine))
+	}
+	if policies != nil {
+		opts = append(opts, driftwatcher.WithPolicies(policies))
+	}
+	if d.cfg != nil && len(d.cfg.Profile.Settings.Hooks) > 0 {
+		runner, err := hooks.NewRunner(d.cfg.Profile.Settings.Hooks, d.HookConcurrency, d.HookTimeout)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, driftwatcher.WithHooks(runner))
+	}
+	if schedule, err := d.maintenance(); err != nil {
+		return err
+	} else if schedule != nil {
+		opts = append(opts, driftwatcher.WithMaintenance(schedule))
+	}
+	if d.CostEstimates {
+		opts = append(opts, driftwatcher.WithCostEstimates(cost.NewEstimator(cost.DefaultPrices())))
+	}
+	if d.ResolveRefs {
+		resolver, ok := d.PlatformProvider.(provider.ReferenceResolverI)
+		if !ok {
+			return fmt.Errorf("%s platform does not support resolving references", d.Provider)
+		}
+		opts = append(opts, driftwatcher.WithReferenceResolution(resolver))
+	}
+	if d.AMIAdvisory {
+		advisor, ok := d.PlatformProvider.(provider.AdvisorI)
+		if !ok {
+			return fmt.Errorf("%s platform does not support advisories", d.Provider)
+		}
+		opts = append(opts, driftwatcher.WithAdvisories(advisor))
+	}
+	if d.ScanUnmanaged {
+		lister, ok := d.PlatformProvider.(provider.ResourceListerI)
+		if !ok {
+			return fmt.Errorf("%s platform does not support listing resources", d.Provider)
+		}
+		opts = append(opts, driftwatcher.WithUnmanagedScan(lister))
+	}
+	if d.CheckOutputs {
+		opts = append(opts, driftwatcher.WithOutputs())
+	}
+
+	if d.Watch {
+		err = d.watch(opts)
+	} else {
+		err = d.detect(d.Reporter, opts)
+	}
+	if closeErr := reporter.CloseWriter(d.Reporter); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close reports: %w", closeErr)
+	}
+	return err
+}
+
+// outputReporter returns the writer reports are written to: stdout in --format, or
+// --output-file, rendered through --query or --output-template when set, plus the CSV
+// file of --output-csv and the --notify targets. Several sinks are fanned out to with
+// a MultiReporter.
+func (d *detectCmd) outputReporter(runId string) (reporter.OutputWriter, error) {
+	var primary reporter.OutputWriter
+	if d.OutputTemplate != "" && d.Query != "" {
+		return nil, fmt.Errorf("--output-template and --query are mutually exclusive")
+	}
+	if d.Query != "" {
+		queryReporter, err := reporter.NewQueryReporter(d.Query, os.Stdout, d.OutputPath)
+		if err != nil {
+			return nil, err
+		}
+		primary = queryReporter
+	} else if d.OutputTemplate != "" {
+		templateReporter, err := reporter.NewTemplateReporter(d.OutputTemplate, os.Stdout, d.OutputPath)
+		if err != nil {
+			return nil, err
+		}
+		primary = templateReporter
+	} else if strings.EqualFold(filepath.Ext(d.OutputPath), ".csv") {
+		csvReporter := reporter.NewCsvReporter(d.OutputPath)
+		csvReporter.Append = d.Append
+		csvReporter.RunId = runId
+		primary = csvReporter
+	} else if d.OutputPath != "" {
+		primary = reporter.NewFileReporter(d.OutputPath)
+	} else {
+		switch d.Format {
+		case "json":
+			primary = reporter.NewStdoutReporter()
+		case "diff":
+			primary = reporter.NewDiffReporter(os.Stdout, reporter.ColorEnabled(d.NoColor, os.Stdout))
+		case "ndjson":
+			primary = reporter.NewNDJSONReporter(os.Stdout)
+		default:
+			return nil, fmt.Errorf("%s output format not currently supported", d.Format)
+		}
+	}
+
+	sinks := []reporter.OutputWriter{primary}
+	if d.OutputCSV != "" {
+		csvReporter := reporter.NewCsvReporter(d.OutputCSV)
+		csvReporter.Append = d.Append
+		csvReporter.RunId = runId
+		sinks = append(sinks, csvReporter)
+	}
+	for _, target := range d.Notify {
+		switch target {
+		case "slack":
+			webhookURL := d.NotifyURL
+			if webhookURL == "" {
+				webhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+			}
+			if webhookURL == "" {
+				return nil, fmt.Errorf("--notify slack requires a webhook URL, set with --notify-url or SLACK_WEBHOOK_URL")
+			}
+			sinks = append(sinks, reporter.NewSlackReporter(webhookURL))
+		case reporter.PlatformGitHub, reporter.PlatformGitLab:
+			commenter, err := d.prCommentReporter(target)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			d.PlatformProvider = provider
+			sinks = append(sinks, commenter)
 		default:
-			return fmt.Errorf("%s platform not currently supported", d.Provider)
+			return nil, fmt.Errorf("%s notifications not currently supported", target)
 		}
 	}
-
-	if d.DriftChecker == nil {
-		d.DriftChecker = driftchecker.NewDefaultDriftChecker()
+	if len(sinks) == 1 {
+		return primary, nil
 	}
+	return reporter.NewMultiReporter(sinks...), nil
+}
 
-	if d.Reporter == nil {
-		if d.OutputPath != "" {
-			d.Reporter = reporter.NewFileReporter(d.OutputPath)
-		} else {
-			d.Reporter = reporter.NewStdoutReporter()
+// prCommentReporter creates the reporter commenting on the pull request or merge
+// request of --notify github or gitlab. Settings left unset are read from the
+// variables GitHub Actions and GitLab CI set for pull and merge request pipelines.
+func (d *detectCmd) prCommentReporter(platform string) (*reporter.PRCommentReporter, error) {
+	repo, number, token, apiURL := d.PRRepo, d.PRNumber, d.PRToken, d.PRAPIURL
+	switch platform {
+	case reporter.PlatformGitHub:
+		repo = cmp.Or(repo, os.Getenv("GITHUB_REPOSITORY"))
+		token = cmp.Or(token, os.Getenv("GITHUB_TOKEN"))
+		apiURL = cmp.Or(apiURL, os.Getenv("GITHUB_API_URL"))
+		// pull request workflows check out refs/pull/<number>/merge
+		if ref := strings.Split(os.Getenv("GITHUB_REF"), "/"); number == 0 && len(ref) == 4 && ref[1] == "pull" {
+			number, _ = strconv.Atoi(ref[2])
+		}
+	case reporter.PlatformGitLab:
+		repo = cmp.Or(repo, os.Getenv("CI_PROJECT_ID"))
+		token = cmp.Or(token, os.Getenv("GITLAB_TOKEN"))
+		apiURL = cmp.Or(apiURL, os.Getenv("CI_API_V4_URL"))
+		if number == 0 {
+			number, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
 		}
 	}
+	return reporter.NewPRCommentReporter(platform, apiURL, repo, number, token)
+}
+
+// detect runs a single drift check, once per stack when a Terragrunt project or a
+// directory of Terraform roots is scanned.
+// A check that runs past --timeout is interrupted like a cancelled one and flushes a
+// partial report.
+func (d *detectCmd) detect(outputWriter reporter.OutputWriter, opts []driftwatcher.DetectionOption) error {
+	ctx := d.ctx
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, d.Timeout, fmt.Errorf("drift check timed out after %s", d.Timeout))
+		defer cancel()
+	}
 
-	return RunDriftDetection(d.ctx, d.TfConfigPath, d.Resource, d.AttributesToTrack, d.StateManager, d.PlatformProvider, d.DriftChecker, d.Reporter)
+	if d.StateManagerType == "terragrunt" || d.StateManagerType == "discover" {
+		return d.detectStacks(ctx, outputWriter, opts)
+	}
+	return driftwatcher.RunDriftDetection(ctx, d.TfConfigPath, d.Resource, d.AttributesToTrack, d.StateManager, d.PlatformProvider, d.DriftChecker, outputWriter, opts...)
 }
 
-// RunDriftDetection orchestrates the complete drift detection workflow for infrastructure resources.
-// This function coordinates multiple components to parse IaC state, retrieve live infrastructure
-// data, compare states, and generate drift reports. It processes resources concurrently using a
-// worker pool pattern to improve performance when checking multiple resources.
-//
-// The workflow consists of the following steps:
-//  1. Parse the IaC state file to extract resource definitions
-//  2. Retrieve resources of the specified type from the parsed state
-//  3. For each resource, concurrently:
-//     a. Fetch live infrastructure metadata from the cloud provider
-//     b. Compare the desired state with actual infrastructure state
-//     c. Generate and write drift reports for any detected differences
-//
-// Parameters:
-//   - ctx: Context for cancellation and timeout control across all operations
-//   - tfConfigPath: File system path to the Terraform state file (.tfstate)
-//   - resourceType: Type of resources to check for drift (e.g., "aws_instance" )
-//   - attributesToTrack: List of specific resource attributes to monitor for drift
-//   - stateManager: Interface for parsing and retrieving data from Terraform state files
-//   - platformProvider: Interface for retrieving live infrastructure data from cloud providers
-//   - driftChecker: Interface for comparing desired state with actual infrastructure state
-//   - reporter: Interface for writing drift reports to various output destinations
-//
-// Returns:
-//   - error: Any critical error that prevents the drift detection process from completing
-func RunDriftDetection(
-	ctx context.Context,
-	tfConfigPath string,
-	resourceType string,
-	attributesToTrack []string,
-	stateManager statemanager.StateManagerI,
-	platformProvider provider.ProviderI,
-	driftChecker driftchecker.DriftChecker,
-	reporter reporter.OutputWriter,
-) error {
-	stateContent, err := stateManager.ParseStateFile(ctx, tfConfigPath)
+// scope parses the --filter, --exclude and --ignore-file settings that limit which
+// resources of the state are checked.
+func (d *detectCmd) scope() ([]filter.Filter, *ignore.Matcher, error) {
+	filters, err := filter.ParseAll(d.Filters)
 	if err != nil {
-		slog.Error("Failed to parse desired state information from the state file", "error", err)
-		return fmt.Errorf("failed to parse state file: %w", err)
+		return nil, nil, err
 	}
 
-	resources, err := stateManager.RetrieveResources(ctx, stateContent, resourceType)
+	patterns, err := ignore.Load(d.IgnoreFile)
 	if err != nil {
-		slog.Error("Failed to retrieve resources from state", "error", err)
-		return fmt.Errorf("failed to retrieve resources: %w", err)
+		return nil, nil, err
+	}
+	exclusions, err := ignore.New(append(patterns, d.Excludes...))
+	if err != nil {
+		return nil, nil, err
+	}
+	return filters, exclusions, nil
+}
+
+// maintenance returns the maintenance windows of the config profile and of
+// --maintenance, or nil when none is declared.
+func (d *detectCmd) maintenance() (*maintenance.Schedule, error) {
+	var windows []maintenance.Window
+	if d.cfg != nil {
+		for _, c := range d.cfg.Profile.Settings.Maintenance {
+			windows = append(windows, maintenance.FromConfig(c))
+		}
+	}
+	for _, value := range d.Maintenance {
+		w, err := maintenance.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	return maintenance.New(windows)
+}
+
+// setupStateManager creates the state manager selected with --state-manager unless
+// one was injected.
+func (d *detectCmd) setupStateManager() error {
+	if d.StateManager != nil {
+		return nil
+	}
+	switch d.StateManagerType {
+	case "terraform", "terragrunt", "discover":
+		fetcher, err := d.stateFetcher()
+		if err != nil {
+			return err
+		}
+		d.StateManager = terraform.NewTerraformManager(terraform.WithFetcher(fetcher))
+		return nil
+	default:
+		return fmt.Errorf("%s statemanager not currently supported", d.StateManagerType)
 	}
+}
 
-	if len(resources) == 0 {
-		slog.Error("No resources found to check for drift.")
+// setupProvider creates the platform provider selected with --provider unless one
+// was injected.
+func (d *detectCmd) setupProvider() error {
+	if d.PlatformProvider != nil {
 		return nil
 	}
+	switch d.Provider {
+	case "aws":
+		config, err := aws.CheckAWSConfig(d.ctx, "", d.Profile)
+		if err != nil {
+			return err
+		}
+		config.RetryMode = d.AWSRetryMode
+		config.MaxAttempts = d.AWSMaxAttempts
+		config.MaxBackoff = d.AWSMaxBackoff
+		config.RequestRate = d.AWSRequestRate
+		config.PageSize = d.AWSPageSize
+		config.Partition = d.AWSPartition
+		config.UseFIPS = d.AWSFIPS
+		config.CacheTTL = d.CacheTTL
+		config.CacheDir = d.metadataCacheDir()
 
-	wg := &sync.WaitGroup{}
-	maxWorker := 5
-	channel := make(chan statemanager.StateResource, maxWorker)
-
-	for range maxWorker {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for resource := range channel {
-				infrastructureResource, err := platformProvider.InfrastructreMetadata(ctx, resourceType, resource)
-				if err != nil {
-					slog.Error("Failed to retrieve infrastructure metadata", "resource_id", resource.Name, "error", err)
-					continue
-				}
-
-				// Compare the desired state (from state file) with the actual infrastructure state.
-				report, err := driftChecker.CompareStates(ctx, infrastructureResource, resource, attributesToTrack)
-				if err != nil {
-					slog.Error("Failed to compare states for resource", "resource_id", resource.Name, "error", err)
-					continue
-				}
-
-				// Write the drift report.
-				if err := reporter.WriteReport(ctx, report); err != nil {
-					slog.Error("Failed to write report for resource", "resource_id", resource.Name, "error", err)
-					continue
-				}
+		provider, err := aws.NewAWSProvider(&config, d.awsOptions()...)
+		if err != nil {
+			return err
+		}
+		d.PlatformProvider = provider
+		return nil
+	case "kubernetes":
+		provider, err := kubernetes.NewKubernetesProvider(&config.KubernetesConfig{
+			Kubeconfig: d.Kubeconfig,
+			Context:    d.KubeContext,
+		})
+		if err != nil {
+			return err
+		}
+		d.PlatformProvider = provider
+		return nil
+	case "ansible":
+		mappings, err := parseFactMappings(d.AnsibleFacts)
+		if err != nil {
+			return err
+		}
+		provider, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{
+			FactsDir:      d.AnsibleFactsDir,
+			Inventory:     d.AnsibleInventory,
+			HostAttribute: d.AnsibleHostAttr,
+			FactMappings:  mappings,
+		})
+		if err != nil {
+			return err
+		}
+		d.PlatformProvider = provider
+		return nil
+	default:
+		return fmt.Errorf("%s platform not currently supported", d.Provider)
+	}
+}
+
+// watch runs drift detection every Interval until the context is cancelled. Only
+// changes in drift are reported, so a resource that stays drifted is reported once
+// and again when its drift changes or is resolved. A failed check is logged and
+// retried on the next interval.
+func (d *detectCmd) watch(opts []driftwatcher.DetectionOption) error {
+	if d.Interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", d.Interval)
+	}
+
+	changes := reporter.NewChangeReporter(d.Reporter)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.detect(changes, opts); err != nil {
+			if d.ctx.Err() != nil {
+				return nil
 			}
-		}()
+			logging.FromContext(d.ctx).Error("Drift check failed", "error", err)
+		}
+
+		select {
+		case <-d.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
 	}
+}
 
-	for _, resource := range resources {
-		channel <- resource
+// sampling parses the --sample, --limit and --sample-seed settings of a quick scan. A
+// random sample without a seed is given a random one.
+func (d *detectCmd) sampling() (sample.Sampling, error) {
+	if d.Limit < 0 {
+		return sample.Sampling{}, fmt.Errorf("--limit cannot be negative")
 	}
+	sampling := sample.Sampling{Limit: d.Limit, Seed: d.SampleSeed}
+	if d.Sample != "" {
+		percent, err := sample.ParsePercent(d.Sample)
+		if err != nil {
+			return sample.Sampling{}, err
+		}
+		sampling.Percent = percent
+		if sampling.Seed == 0 {
+			sampling.Seed = rand.Uint64()
+		}
+	}
+	return sampling, nil
+}
 
-	close(channel)
+// checkerOptions builds the drift checker options from the --comparison,
+// --compare-attribute, --all-attributes, --default-tags and --equivalence-file flags.
+func (d *detectCmd) checkerOptions() ([]driftchecker.CheckerOption, error) {
+	comparison, err := driftchecker.ParseComparison(d.Comparison)
+	if err != nil {
+		return nil, err
+	}
+	defaultTags, err := driftchecker.ParseDefaultTagsMode(d.DefaultTags)
+	if err != nil {
+		return nil, err
+	}
+	opts := []driftchecker.CheckerOption{driftchecker.WithDefaultComparison(comparison), driftchecker.WithDefaultTags(defaultTags)}
+	if d.AllAttributes {
+		opts = append(opts, driftchecker.WithAllAttributes())
+	}
+	if d.EquivalenceFile != "" {
+		rules, err := driftchecker.LoadEquivalenceRules(d.EquivalenceFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, driftchecker.WithEquivalenceRules(rules...))
+	}
 
-	wg.Wait()
+	for _, override := range d.AttrComparisons {
+		attribute, name, ok := strings.Cut(override, "=")
+		if !ok || attribute == "" {
+			return nil, fmt.Errorf("invalid attribute comparison %q, expected attribute=comparison", override)
+		}
+		comparison, err := driftchecker.ParseComparison(name)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, driftchecker.WithAttributeComparison(attribute, comparison))
+	}
+	return opts, nil
+}
 
-	slog.Info("Drift detection completed.")
-	return nil
+// runMetadata describes the run of this invocation: a fresh run id and the provider
+// and attribute policy reports are produced with. The state lineage and serial are
+// added by the drift detection once the state file is parsed.
+func (d *detectCmd) runMetadata() *driftchecker.RunMetadata {
+	run := &driftchecker.RunMetadata{
+		RunId:        uuid.NewString(),
+		StartedAt:    time.Now(),
+		Provider:     d.Provider,
+		StatePath:    d.TfConfigPath,
+		ResourceType: d.Resource,
+		Attributes:   d.AttributesToTrack,
+		Comparison:   d.Comparison,
+	}
+	for _, override := range d.AttrComparisons {
+		if attribute, name, ok := strings.Cut(override, "="); ok {
+			if run.AttributeComparisons == nil {
+				run.AttributeComparisons = map[string]string{}
+			}
+			run.AttributeComparisons[attribute] = name
+		}
+	}
+	// drift is related to the code checked out when driftwatcher runs in a checkout
+	git, err := gitcontext.Inspect(d.ctx, "")
+	if err != nil {
+		logging.FromContext(d.ctx).Warn("Failed to inspect the git checkout", "error", err)
+	}
+	run.Git = git
+	return run
+}
+
+// stateFetcher creates the fetcher used for remote state URIs from the --state-header
+// and --state-retries flags. Downloads are cached by ETag in the user cache folder.
+// With --endpoint-url, --aws-region or --dev, s3:// state is read with the same AWS
+// settings as live resources.
+func (d *detectCmd) stateFetcher() (remote.Fetcher, error) {
+	var s3Client remote.S3API
+	if opts := d.awsOptions(); len(opts) > 0 {
+		client, err := newS3Client(d.ctx, d.Profile, opts...)
+		if err != nil {
+			return nil, err
+		}
+		s3Client = client
+	}
+	return newStateFetcher(d.StateHeaders, d.StateRetries, s3Client)
+}
+
+// newStateFetcher creates a remote state fetcher sending the given 'Name: value'
+// headers and retrying failed downloads up to retries times.
+func newStateFetcher(stateHeaders []string, retries int, s3Client remote.S3API) (remote.Fetcher, error) {
+	headers, err := parseHeaders(stateHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := remote.Options{
+		Headers:    headers,
+		MaxRetries: retries,
+		S3Client:   s3Client,
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		opts.CacheDir = filepath.Join(cacheDir, "driftwatcher", "state")
+	}
+	return remote.NewFetcher(opts), nil
+}
+
+// reportSigner creates the signer selected with --sign-key or --sign-kms-key. The
+// signature is written next to the output file, so one is required.
+func (d *detectCmd) reportSigner() (signing.Signer, error) {
+	if d.OutputPath == "" {
+		return nil, fmt.Errorf("signing reports requires --output-file")
+	}
+	if d.SignKey != "" && d.SignKMSKey != "" {
+		return nil, fmt.Errorf("--sign-key and --sign-kms-key are mutually exclusive")
+	}
+	if d.SignKey != "" {
+		return signing.NewLocalSigner(d.SignKey)
+	}
+
+	client, err := newKMSClient(d.ctx, d.Profile, d.awsOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return signing.NewKMSSigner(client, d.SignKMSKey, d.SignKMSAlgorithm)
+}
+
+// newKMSClient creates a KMS client with the credentials of the given AWS profile.
+func newKMSClient(ctx context.Context, profile string, opts ...aws.Option) (*kms.Client, error) {
+	awsConfig, err := aws.CheckAWSConfig(ctx, "", profile)
+	if err != nil {
+		return nil, err
+	}
+	sdkConfig, err := aws.LoadConfig(&awsConfig, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return kms.NewFromConfig(sdkConfig), nil
+}
+
+// newS3Client creates the S3 client remote state is downloaded with. Objects are
+// addressed by path when the client is sent to a custom endpoint, as LocalStack does
+// not resolve bucket subdomains.
+func newS3Client(ctx context.Context, profile string, opts ...aws.Option) (*s3.Client, error) {
+	awsConfig, err := aws.CheckAWSConfig(ctx, "", profile)
+	if err != nil {
+		return nil, err
+	}
+	sdkConfig, err := aws.LoadConfig(&awsConfig, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s3.NewFromConfig(sdkConfig, func(o *s3.Options) {
+		o.UsePathStyle = sdkConfig.BaseEndpoint != nil && *sdkConfig.BaseEndpoint != ""
+	}), nil
+}
+
+// awsOptions returns the options shared by every AWS client: the region set with
+// --aws-region and the endpoint set with --endpoint-url. With --dev they default to
+// those of LocalStack, whose test credentials are used.
+func (d *detectCmd) awsOptions() []aws.Option {
+	endpoint, region := d.EndpointURL, d.AWSRegion
+	var opts []aws.Option
+	if d.Dev {
+		endpoint = cmp.Or(endpoint, aws.LocalStackEndpoint)
+		region = cmp.Or(region, aws.LocalStackRegion)
+		opts = append(opts, aws.WithCredentials(aws.LocalStackAccessKey, aws.LocalStackAccessKey))
+	}
+	if endpoint != "" {
+		opts = append(opts, aws.WithEndpoint(endpoint))
+	}
+	if region != "" {
+		opts = append(opts, aws.WithRegion(region))
+	}
+	return opts
+}
+
+// parseHeaders parses --state-header values written as 'Name: value'.
+func parseHeaders(values []string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, header := range values {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid state header %q, expected 'Name: value'", header)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// parseFactMappings parses --ansible-fact values written as 'attribute=fact'.
+func parseFactMappings(values []string) (map[string]string, error) {
+	mappings := map[string]string{}
+	for _, value := range values {
+		attribute, fact, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(attribute) == "" || strings.TrimSpace(fact) == "" {
+			return nil, fmt.Errorf("invalid fact mapping %q, expected 'attribute=fact'", value)
+		}
+		mappings[strings.TrimSpace(attribute)] = strings.TrimSpace(fact)
+	}
+	return mappings, nil
+}
+
+// metadataCacheDir returns the directory live metadata is cached in, or an empty
+// string to cache it in memory only when no user cache directory is available.
+func (d *detectCmd) metadataCacheDir() string {
+	if d.CacheDir != "" {
+		return d.CacheDir
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, "driftwatcher", "metadata")
+	}
+	return ""
 }
@@ -5,30 +5,30 @@ import (
 	"context"
 	"drift-watcher/cmd"
 	"drift-watcher/config"
+	"drift-watcher/pkg/logging/loggingtest"
 	"drift-watcher/pkg/services/driftchecker"
 	"drift-watcher/pkg/services/driftchecker/driftcheckerfakes"
+	"drift-watcher/pkg/services/provider"
 	"drift-watcher/pkg/services/provider/providerfakes"
 	"drift-watcher/pkg/services/reporter"
 	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"drift-watcher/pkg/services/secrets"
 	"drift-watcher/pkg/services/statemanager" // Import for NewTerraformManager
 	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
-	"errors"
+	"drift-watcher/pkg/services/store/storefakes"
 	"fmt"
-	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// Helper to capture slog output
-func captureSlogOutput() *bytes.Buffer {
-	var buf bytes.Buffer
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
-	return &buf
-}
-
 func TestNewDetectCmd(t *testing.T) {
 	ctx := context.Background()
 	cfg := &config.Config{}
@@ -55,13 +55,47 @@ func TestNewDetectCmd(t *testing.T) {
 	assert.Equal(t, "terraform", dc.StateManagerType)
 }
 
+func TestNewDetectCmd_OutputFormatAlias(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+
+	require.NoError(t, dc.Cmd.ParseFlags([]string{"--output-format", "ndjson"}))
+	assert.Equal(t, "ndjson", dc.Format)
+}
+
+func TestNewDetectCmd_LocalStackAliases(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+
+	require.NoError(t, dc.Cmd.ParseFlags([]string{"--localstack-url", "http://localhost:4566", "--localstackregion", "eu-west-1"}))
+	assert.Equal(t, "http://localhost:4566", dc.EndpointURL)
+	assert.Equal(t, "eu-west-1", dc.AWSRegion)
+}
+
+func TestDetectCmd_Run_DevStateFromS3(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var path, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"version": 4, "terraform_version": "1.8.5", "serial": 1, "lineage": "3f1c1f6a-0000-4000-8000-000000000000", "outputs": {}, "resources": []}`)
+	}))
+	defer server.Close()
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	require.NoError(t, dc.Cmd.ParseFlags([]string{"--dev", "--endpoint-url", server.URL, "--configfile", "s3://states/prod.tfstate"}))
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+	assert.Equal(t, "/states/prod.tfstate", path, "objects are addressed by path on a custom endpoint")
+	assert.Contains(t, authorization, "Credential=test/", "requests are signed with the LocalStack test credentials")
+	assert.Contains(t, authorization, "/us-east-1/s3/")
+}
+
 func TestDetectCmd_Run_MissingConfigFile(t *testing.T) {
-	ctx := context.Background()
+	ctx, buf := loggingtest.Capture()
 	cfg := &config.Config{}
 	dc := cmd.NewDetectCmd(ctx, cfg)
 	// dc.tfConfigPath is empty by default
 
-	buf := captureSlogOutput()
 	err := dc.Run(dc.Cmd, []string{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "A state file is required")
@@ -81,6 +115,29 @@ func TestDetectCmd_Run_UnsupportedStateManager(t *testing.T) {
 	assert.Contains(t, err.Error(), "unsupported-manager statemanager not currently supported")
 }
 
+func TestDetectCmd_Run_QueryWithTemplate(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.OutputTemplate = "report.tmpl"
+	dc.Query = ".reports"
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "--output-template and --query are mutually exclusive")
+}
+
+func TestDetectCmd_Run_InvalidQuery(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Query = ".reports["
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "failed to parse query")
+}
+
 func TestDetectCmd_Run_UnsupportedProvider(t *testing.T) {
 	ctx := context.Background()
 	cfg := &config.Config{}
@@ -178,7 +235,7 @@ func TestDetectCmd_Run_Success_FileReporter(t *testing.T) {
 	dc.StateManagerType = "terraform"
 	dc.Provider = "aws"
 	dc.Resource = "aws_instance"
-	dc.OutputPath = "../assets/output.json" // Ensure file reporter is used
+	dc.OutputPath = filepath.Join(t.TempDir(), "output.json") // Ensure file reporter is used
 
 	err := dc.Run(dc.Cmd, []string{})
 	require.NoError(t, err)
@@ -190,211 +247,582 @@ func TestDetectCmd_Run_Success_FileReporter(t *testing.T) {
 	//assert.Equal(t, mockReporter.WriteReportCallCount(), 1)
 }
 
-func TestRunDriftDetection_ParseStateFileError(t *testing.T) {
-	// Setup mocks
+func TestDetectCmd_Run_MultipleSinks(t *testing.T) {
 	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
 	mockPlatformProvider := &providerfakes.FakeProviderI{}
 	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
-	mockReporter := &reporterfakes.FakeOutputWriter{}
-	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
-	_ = mockInfraResource
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/nonexistent.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
-	assert.NoError(t, err)
-	assert.Equal(t, mockPlatformProvider.InfrastructreMetadataCallCount(), 0)
-	assert.Equal(t, mockDriftChecker.CompareStatesCallCount(), 0)
-	assert.Equal(t, mockReporter.WriteReportCallCount(), 0)
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "No resources found to check for drift")
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "fake-id"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(true), nil)
+
+	dir := t.TempDir()
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.DriftChecker = mockDriftChecker
+	dc.OutputPath = filepath.Join(dir, "report.json")
+	dc.OutputCSV = filepath.Join(dir, "report.csv")
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+	jsonReport, err := os.ReadFile(dc.OutputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonReport), `"bucket_acl"`)
+	csvReport, err := os.ReadFile(dc.OutputCSV)
+	require.NoError(t, err)
+	assert.Contains(t, string(csvReport), "bucket_acl")
 }
 
-func TestRunDriftDetection_RetrieveResourcesError(t *testing.T) {
-	// Setup mocks
+func TestDetectCmd_Run_NotifyWithoutWebhook(t *testing.T) {
+	t.Setenv("SLACK_WEBHOOK_URL", "")
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Notify = []string{"slack"}
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "--notify slack requires a webhook URL")
+}
+
+func TestDetectCmd_Run_NotifyGitHubPullRequest(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		if r.Method == http.MethodGet {
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_REPOSITORY", "acme/infra")
+	t.Setenv("GITHUB_REF", "refs/pull/42/merge")
+	t.Setenv("GITHUB_TOKEN", "ghp_token")
+
 	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "fake-id"}}}},
+	}, nil)
 	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
 	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
-	mockReporter := &reporterfakes.FakeOutputWriter{}
-	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
-	_ = mockInfraResource
-
-	mockStateManager.RetrieveResourcesReturnsOnCall(0, []statemanager.StateResource{}, errors.New("retrieve error"))
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(true), nil)
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to retrieve resources: retrieve error")
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "Failed to retrieve resources from state")
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.DriftChecker = mockDriftChecker
+	dc.OutputPath = filepath.Join(t.TempDir(), "report.json")
+	dc.Notify = []string{"github"}
+	dc.PRAPIURL = server.URL
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+	assert.Equal(t, []string{
+		"GET /repos/acme/infra/issues/42/comments Bearer ghp_token",
+		"POST /repos/acme/infra/issues/42/comments Bearer ghp_token",
+	}, requests)
 }
 
-func TestRunDriftDetection_NoResourcesFound(t *testing.T) {
-	// Setup mocks
+func TestDetectCmd_Run_SecretReferences(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.Method == http.MethodGet {
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_TOKEN", "")
+
 	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "fake-id"}}}},
+	}, nil)
 	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
 	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
-	mockReporter := &reporterfakes.FakeOutputWriter{}
-	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
-	_ = mockInfraResource
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(true), nil)
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
-	require.NoError(t, err)
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "No resources found to check for drift.")
+	resolver := secrets.NewResolver()
+	resolver.Register(secrets.SecretsManagerScheme, secrets.ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		assert.Equal(t, "ci/github#token", ref)
+		return "ghp_from_secrets_manager", nil
+	}))
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.DriftChecker = mockDriftChecker
+	dc.Secrets = resolver
+	dc.OutputPath = filepath.Join(t.TempDir(), "report.json")
+	dc.Notify = []string{"github"}
+	dc.PRRepo = "acme/infra"
+	dc.PRNumber = 42
+	dc.PRAPIURL = server.URL
+	dc.Cmd.SetArgs([]string{"--pr-token", "aws-sm://ci/github#token"})
+
+	require.NoError(t, dc.Cmd.Execute())
+	assert.Equal(t, []string{"Bearer ghp_from_secrets_manager", "Bearer ghp_from_secrets_manager"}, authorization)
 }
 
-func TestRunDriftDetection_SuccessWithDrift(t *testing.T) {
-	// Setup mocks
+func TestDetectCmd_Run_UnresolvedSecretReference(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.NotifyURL = "env://DRIFT_TEST_UNSET_WEBHOOK"
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.EqualError(t, err, "--notify-url: failed to resolve secret env://DRIFT_TEST_UNSET_WEBHOOK: environment variable DRIFT_TEST_UNSET_WEBHOOK is not set")
+}
+
+func TestDetectCmd_Run_Record(t *testing.T) {
 	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
 	mockPlatformProvider := &providerfakes.FakeProviderI{}
 	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
 	mockReporter := &reporterfakes.FakeOutputWriter{}
-	mockInfraResource1 := &providerfakes.FakeInfrastructureResourceI{}
-	mockInfraResource2 := &providerfakes.FakeInfrastructureResourceI{}
-
-	// Prepare dummy resources
-	resource1 := statemanager.StateResource{
-		Name: "res1",
-		Type: "aws_instance",
-		Instances: []statemanager.ResourceInstance{
-			{
-				Attributes: map[string]any{
-					"instance_type": "t2.micro",
-				},
-			},
-		},
-	}
-	resource2 := statemanager.StateResource{
-		Name: "res2",
-		Type: "aws_instance",
-		Instances: []statemanager.ResourceInstance{
-			{
-				Attributes: map[string]any{
-					"instance_type": "t2.micro",
-				},
-			},
-		},
+	fakeStore := &storefakes.FakeReportStore{}
+
+	resource := []statemanager.StateResource{
+		{Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "fake-id"}}}},
 	}
-	resources := []statemanager.StateResource{resource1, resource2}
-	_ = resources
+	mockStateManager.RetrieveResourcesReturns(resource, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(true), nil)
 
-	// Mock behaviors
-	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
-	mockStateManager.RetrieveResourcesReturns(resources, nil)
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.DriftChecker = mockDriftChecker
+	dc.Reporter = mockReporter
+	dc.ReportStore = fakeStore
+	dc.Record = true
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+
+	require.Equal(t, 1, fakeStore.StartRunCallCount())
+	_, run := fakeStore.StartRunArgsForCall(0)
+	assert.NotEmpty(t, run.RunId)
+	assert.Equal(t, "aws_instance", run.ResourceType)
+
+	require.Equal(t, 1, fakeStore.SaveReportCallCount())
+	_, runId, _ := fakeStore.SaveReportArgsForCall(0)
+	assert.Equal(t, run.RunId, runId)
+	assert.Equal(t, 1, mockReporter.WriteReportCallCount(), "reports are still forwarded to the configured reporter")
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.NotNil(t, report.Run)
+	assert.Equal(t, run.RunId, report.Run.RunId, "stored and written reports carry the same run id")
+	assert.Equal(t, "aws", report.Run.Provider)
+	assert.Equal(t, "auto", report.Run.Comparison)
+}
 
-	mockInfraResource1.ResourceTypeReturnsOnCall(0, "aws_instance")
-	mockInfraResource1.AttributeValueReturnsOnCall(0, "t2.medium", nil)
+func TestDetectCmd_Run_Profile(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	t.Cleanup(viper.Reset)
 
-	mockInfraResource2.ResourceTypeReturnsOnCall(0, "aws_instance")
-	mockInfraResource2.AttributeValueReturnsOnCall(0, "t2.micro", nil)
+	cfg := &config.Config{Profile: config.Profile{ProfileName: "prod-us-east"}}
+	require.NoError(t, cfg.Profile.WriteConfigField(config.StatePathField, "../assets/terraform_ec2_state.tfstate"))
+	require.NoError(t, cfg.Profile.WriteConfigField(config.AttributesField, "instance_type,tags"))
+	require.NoError(t, cfg.Profile.WriteConfigField(config.ResourceField, "aws_db_instance"))
 
-	mockPlatformProvider.InfrastructreMetadataReturnsOnCall(0, mockInfraResource1, nil)
-	mockPlatformProvider.InfrastructreMetadataReturnsOnCall(1, mockInfraResource2, nil)
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), cfg)
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	require.NoError(t, dc.Cmd.Flags().Set("resource", "aws_instance"))
 
-	driftReport1 := &driftchecker.DriftReport{
-		HasDrift: true,
-		Status:   driftchecker.Drift,
-		DriftDetails: []driftchecker.DriftItem{
-			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.medium", DriftType: driftchecker.AttributeValueChanged},
-		},
-	}
-	driftReport2 := &driftchecker.DriftReport{
-		HasDrift: false,
-		Status:   driftchecker.Match,
-	}
-	_, _ = driftReport1, driftReport2
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
 
-	mockDriftChecker.CompareStatesReturnsOnCall(0, driftReport1, nil)
-	mockDriftChecker.CompareStatesReturnsOnCall(1, driftReport2, nil)
+	_, file := mockStateManager.ParseStateFileArgsForCall(0)
+	assert.Equal(t, "../assets/terraform_ec2_state.tfstate", file)
+	assert.Equal(t, []string{"instance_type", "tags"}, dc.AttributesToTrack)
+	assert.Equal(t, "aws_instance", dc.Resource, "explicit flags take precedence over the profile")
+}
 
-	mockReporter.WriteReportReturnsOnCall(0, nil)
-	mockReporter.WriteReportReturnsOnCall(1, nil)
+func TestDetectCmd_Run_EnvironmentOverrides(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	t.Cleanup(viper.Reset)
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
-	require.NoError(t, err)
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Profile.WriteConfigField(config.StatePathField, "profile.tfstate"))
+	require.NoError(t, cfg.Profile.WriteConfigField(config.ResourceField, "aws_db_instance"))
+
+	t.Setenv(cmd.EnvVarName("configfile"), "../assets/terraform_ec2_state.tfstate")
+	t.Setenv(cmd.EnvVarName("attributes"), "instance_type, tags")
+	t.Setenv(cmd.EnvVarName("concurrency"), "2")
+	t.Setenv(cmd.EnvVarName("provider"), "env-provider")
 
-	assert.Contains(t, buf.String(), "level=INFO")
-	assert.Contains(t, buf.String(), "Drift detection completed.")
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), cfg)
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	require.NoError(t, dc.Cmd.Flags().Set("provider", "aws"))
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+
+	_, file := mockStateManager.ParseStateFileArgsForCall(0)
+	assert.Equal(t, "../assets/terraform_ec2_state.tfstate", file, "environment takes precedence over the profile")
+	assert.Equal(t, []string{"instance_type", "tags"}, dc.AttributesToTrack)
+	assert.Equal(t, 2, dc.Concurrency)
+	assert.Equal(t, "aws", dc.Provider, "flags take precedence over the environment")
+	assert.Equal(t, "aws_db_instance", dc.Resource, "the profile is used when neither flag nor environment is set")
 }
 
-func TestRunDriftDetection_InfrastructureMetadataError(t *testing.T) {
-	// Setup mocks
+func TestDetectCmd_Run_ProjectFile(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	t.Cleanup(viper.Reset)
+
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Profile.WriteConfigField(config.ResourceField, "aws_db_instance"))
+	require.NoError(t, cfg.Profile.WriteConfigField(config.FormatField, "csv"))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "driftwatcher.yaml"), []byte(`
+state: prod.tfstate
+resource: aws_instance
+attributes: [instance_type, tags.Env]
+exclude: ["aws_instance.scratch*"]
+`), 0o644))
+	t.Chdir(dir)
+	t.Setenv(cmd.EnvVarName("attributes"), "ami")
+
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), cfg)
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+
+	_, file := mockStateManager.ParseStateFileArgsForCall(0)
+	assert.Equal(t, filepath.Join(dir, "prod.tfstate"), file, "paths are relative to the project file")
+	assert.Equal(t, "aws_instance", dc.Resource, "the project file takes precedence over the profile")
+	assert.Equal(t, []string{"ami"}, dc.AttributesToTrack, "the environment takes precedence over the project file")
+	assert.Equal(t, []string{"aws_instance.scratch*"}, dc.Excludes)
+	assert.Equal(t, "csv", dc.Format, "the profile fills what the project file leaves out")
+}
+
+func TestDetectCmd_Run_ProjectFileFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("state: [prod.tfstate]\n"), 0o644))
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	require.NoError(t, dc.Cmd.Flags().Set("project-file", path))
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "failed to parse project file")
+}
+
+func TestDetectCmd_Run_InvalidEnvironmentValue(t *testing.T) {
+	t.Setenv(cmd.EnvVarName("concurrency"), "many")
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "DRIFT_CONCURRENCY")
+}
+
+func TestDetectCmd_Run_StateFromStdin(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "-"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	dc.Cmd.SetIn(bytes.NewBufferString(`{"version": 4}`))
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+
+	assert.Equal(t, 0, mockStateManager.ParseStateFileCallCount())
+	require.Equal(t, 1, mockStateManager.ParseStateCallCount())
+	_, r := mockStateManager.ParseStateArgsForCall(0)
+	assert.Equal(t, dc.Cmd.InOrStdin(), r)
+}
+
+func TestDetectCmd_Run_WatchStateFromStdin(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "-"
+	dc.Watch = true
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Cmd.SetIn(bytes.NewBufferString(`{"version": 4}`))
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "--watch cannot read the state from stdin")
+	assert.Equal(t, 0, mockStateManager.ParseStateCallCount())
+}
+
+func TestDetectCmd_Run_RemediateStateFromStdin(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "-"
+	dc.AutoRemediate = true
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Cmd.SetIn(bytes.NewBufferString(`{"version": 4}`))
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.ErrorContains(t, err, "--auto-remediate cannot confirm changes on stdin")
+	assert.Equal(t, 0, mockStateManager.ParseStateCallCount())
+}
+
+func TestDetectCmd_Run_InvalidComparison(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.AttrComparisons = []string{"tags.Env=fuzzy"}
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown comparison")
+}
+
+func TestDetectCmd_Run_UnknownAttribute(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	policyPath := filepath.Join(t.TempDir(), "tags.rego")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`package driftwatcher
+
+violations contains "untagged" if {
+	input.resource.type == "aws_instance"
+	input.resource.attributes.tag.Env == ""
+}
+
+violations contains "public bucket" if {
+	input.resource.type == "aws_s3_bucket"
+	input.resource.attributes.acl == "public-read"
+}
+
+violations contains "unowned" if {
+	input.resource.attributes.tags.Owner == ""
+	input.resource.attributes.labels.owner == ""
+}
+`), 0644))
+
+	ctx, logs := loggingtest.Capture()
+	dc := cmd.NewDetectCmd(ctx, &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.AttributesToTrack = []string{"instance_tpye", "metadata_options.http_tokens", "security_group_ids"}
+	dc.Policies = []string{policyPath}
+
+	err := dc.Run(dc.Cmd, []string{})
+	require.Error(t, err)
+	assert.Equal(t, "unknown attributes (set --provider-schema=none to skip this check): "+
+		"instance_tpye is not an attribute of aws_instance, did you mean instance_type?; "+
+		policyPath+":5: tag.Env: tag is not an attribute of aws_instance, did you mean tags?", err.Error())
+	assert.Equal(t, 0, mockStateManager.ParseStateFileCallCount(), "nothing is scanned")
+	// a rule that applies to every type may refer to the attributes of another type
+	assert.Contains(t, logs.String(), "labels.owner: labels is not an attribute of aws_instance")
+	assert.NotContains(t, logs.String(), "acl")
+
+	// the check is skipped on request, and for resource types no schema declares
+	dc.ProviderSchema, dc.Policies = "none", nil
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	assert.NoError(t, dc.Run(dc.Cmd, []string{}))
+	dc.ProviderSchema, dc.Resource = "", "kubernetes_deployment"
+	assert.NoError(t, dc.Run(dc.Cmd, []string{}))
+}
+
+func TestDetectCmd_Run_AllAttributes(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{
+		Type:      "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1", "instance_type": "t2.micro", "key_name": "deploy"}}},
+	}}, nil)
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributesReturns(map[string]string{"instance_type": "t2.micro", "key_name": "ops"}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(live, nil)
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.Reporter = mockReporter
+	dc.AllAttributes = true
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.Len(t, report.DriftDetails, 2)
+	assert.Equal(t, "instance_type", report.DriftDetails[0].Field)
+	assert.Equal(t, "key_name", report.DriftDetails[1].Field)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[1].DriftType)
+}
+
+func TestDetectCmd_Run_InvalidFilter(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Filters = []string{"owner=alice"}
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown filter field")
+}
+
+func TestDetectCmd_Run_Watch(t *testing.T) {
 	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
 	mockPlatformProvider := &providerfakes.FakeProviderI{}
 	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
 	mockReporter := &reporterfakes.FakeOutputWriter{}
-	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
-	_ = mockInfraResource
 
-	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
-	resources := []statemanager.StateResource{resource1}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}}, nil)
+	mockDriftChecker.CompareStatesStub = func(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []string) (*driftchecker.DriftReport, error) {
+		return reporter.CreateDummyDriftReport(true), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dc := cmd.NewDetectCmd(ctx, &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.DriftChecker = mockDriftChecker
+	dc.Reporter = mockReporter
+	dc.Watch = true
+	dc.Interval = time.Millisecond
+
+	go func() {
+		for mockStateManager.ParseStateFileCallCount() < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+	assert.GreaterOrEqual(t, mockStateManager.ParseStateFileCallCount(), 3)
+	assert.Equal(t, 1, mockReporter.WriteReportCallCount(), "the same drift is only reported once in watch mode")
+}
+
+func TestDetectCmd_Run_Timeout(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
 
-	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
-	mockStateManager.RetrieveResourcesReturns(resources, nil)
-	mockPlatformProvider.InfrastructreMetadataReturns(nil, fmt.Errorf("infra metadata error"))
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}}, nil)
+	mockPlatformProvider.InfrastructreMetadataStub = func(ctx context.Context, _ string, _ statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
-	require.NoError(t, err) // Function should continue despite worker error
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.Reporter = mockReporter
+	dc.Timeout = 10 * time.Millisecond
+	dc.ShutdownTimeout = 10 * time.Millisecond
 
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "Failed to retrieve infrastructure metadata")
-	assert.Contains(t, buf.String(), "resource_id=res1")
+	err := dc.Run(dc.Cmd, []string{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, partial := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, driftchecker.Partial, partial.Status)
+	assert.Equal(t, "drift check timed out after 10ms", partial.Summary.Reason)
+
+	dc.Timeout = -time.Second
+	assert.ErrorContains(t, dc.Run(dc.Cmd, []string{}), "timeouts cannot be negative")
 }
 
-func TestRunDriftDetection_CompareStatesError(t *testing.T) {
-	mockStateManager := statemanagerfakes.FakeStateManagerI{}
-	mockPlatformProvider := providerfakes.FakeProviderI{}
-	mockDriftChecker := driftcheckerfakes.FakeDriftChecker{}
-	mockReporter := reporterfakes.FakeOutputWriter{}
-	mockInfraResource := providerfakes.FakeInfrastructureResourceI{}
+func TestDetectCmd_Run_AutoRemediateUnsupportedProvider(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	dc.AutoRemediate = true
 
-	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
-	resources := []statemanager.StateResource{resource1}
-	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
-	mockStateManager.RetrieveResourcesReturns(resources, nil)
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "platform does not support remediation")
+}
 
-	mockInfraResource.ResourceTypeReturns("aws_instance")
-	mockPlatformProvider.InfrastructreMetadataReturns(&mockInfraResource, nil)
+func TestDetectCmd_Run_IgnoreFile(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Type: "aws_instance", Name: "scratch"}}, nil)
 
-	mockDriftChecker.CompareStatesReturns(nil, fmt.Errorf("compare states error"))
+	ignoreFile := filepath.Join(t.TempDir(), ".driftignore")
+	require.NoError(t, os.WriteFile(ignoreFile, []byte("# hand managed\naws_instance.scratch\n"), 0600))
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, &mockStateManager, &mockPlatformProvider, &mockDriftChecker, &mockReporter)
-	require.NoError(t, err) // Function should continue despite worker error
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockPlatformProvider
+	dc.Reporter = mockReporter
+	dc.IgnoreFile = ignoreFile
 
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "Failed to compare states for resource")
-	assert.Contains(t, buf.String(), "resource_id=res1")
+	require.NoError(t, dc.Run(dc.Cmd, []string{}))
+	assert.Equal(t, 0, mockPlatformProvider.InfrastructreMetadataCallCount())
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, driftchecker.Skipped, report.Status)
 }
 
-func TestRunDriftDetection_WriteReportError(t *testing.T) {
-	mockStateManager := statemanagerfakes.FakeStateManagerI{}
-	mockPlatformProvider := providerfakes.FakeProviderI{}
-	mockDriftChecker := driftcheckerfakes.FakeDriftChecker{}
-	mockReporter := reporterfakes.FakeOutputWriter{}
-	mockInfraResource := providerfakes.FakeInfrastructureResourceI{}
+func TestDetectCmd_Run_ResolveReferencesUnsupported(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	dc.ResolveRefs = true
+
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "platform does not support resolving references")
+}
 
-	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
-	resources := []statemanager.StateResource{resource1}
+func TestDetectCmd_Run_AMIAdvisoryUnsupported(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	dc.AMIAdvisory = true
 
-	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
-	mockStateManager.RetrieveResourcesReturns(resources, nil)
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "platform does not support advisories")
+}
 
-	mockInfraResource.ResourceTypeReturns("aws")
-	mockPlatformProvider.InfrastructreMetadataReturns(&mockInfraResource, nil)
+func TestDetectCmd_Run_ResolveParametersUnsupported(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Reporter = &reporterfakes.FakeOutputWriter{}
+	dc.ResolveParams = true
 
-	driftReport1 := &driftchecker.DriftReport{HasDrift: true}
-	mockDriftChecker.CompareStatesReturns(driftReport1, nil)
-	mockReporter.WriteReportReturns(fmt.Errorf("write report error"))
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "platform does not support resolving parameters")
+}
 
-	buf := captureSlogOutput()
-	err := cmd.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, &mockStateManager, &mockPlatformProvider, &mockDriftChecker, &mockReporter)
-	require.NoError(t, err) // Function should continue despite worker error
+func TestDetectCmd_Run_InvalidFactMapping(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.TfConfigPath = "../assets/terraform_ec2_state.tfstate"
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.Provider = "ansible"
+	dc.AnsibleFactsDir = t.TempDir()
+	dc.AnsibleFacts = []string{"memory"}
 
-	assert.Contains(t, buf.String(), "level=ERROR")
-	assert.Contains(t, buf.String(), "Failed to write report for resource")
-	assert.Contains(t, buf.String(), "resource_id=res1")
+	err := dc.Run(dc.Cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid fact mapping "memory"`)
 }
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/statediff"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type diffStateCmd struct {
+	ConfigDir      string
+	VarFiles       []string
+	Vars           []string
+	StateHeaders   []string
+	Format         string
+	RedactPatterns []string
+	RedactMode     string
+	ctx            context.Context
+	Cmd            *cobra.Command
+	cfg            *config.Config
+}
+
+// NewDiffStateCmd creates and configures the 'diff-state' Cobra command.
+// This command compares two versions of a state file, e.g. the state before and after
+// an apply, without contacting a provider.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to a diffStateCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewDiffStateCmd(ctx context.Context, cfg *config.Config) *diffStateCmd {
+	dc := &diffStateCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	dc.Cmd = &cobra.Command{
+		Use:   "diff-state <old-state> <new-state> | --config <dir> <state>",
+		Short: "Show the resources and attributes that changed between two state files",
+		Long: `Compare two versions of a state file and list the resources added and removed and
+the attributes changed between them, to review what an apply changed. Attributes are
+compared the way detect compares them, the old state taking the place of the desired
+state and the new one that of the live infrastructure.
+
+With --config, the state is compared with the Terraform configuration in a directory
+instead, listing the changes the configuration would make once applied: resources
+declared but never applied, resources still in the state but no longer declared, and
+arguments edited since the last apply. This is drift between the code and the state,
+as opposed to the drift between the state and the infrastructure reported by detect.
+Variables are read from the variable defaults, terraform.tfvars, *.auto.tfvars,
+--var-file and --var; arguments that are only known after an apply are not compared.
+
+Either state may be a remote state URI (https, s3, gs), and one of them - to read it
+from stdin.
+
+For example:
+  driftwatcher diff-state terraform.tfstate.backup terraform.tfstate
+  terraform state pull | driftwatcher diff-state s3://my-bucket/prod/terraform.tfstate - --format json
+  driftwatcher diff-state --config ./infra --var-file prod.tfvars terraform.tfstate
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dc.ConfigDir != "" {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: dc.Run,
+	}
+
+	dc.Cmd.Flags().StringVar(&dc.ConfigDir, "config", "", "Directory of the Terraform configuration to compare the state with")
+	dc.Cmd.Flags().StringArrayVar(&dc.VarFiles, "var-file", nil, "Variable file of the configuration (repeatable)")
+	dc.Cmd.Flags().StringArrayVar(&dc.Vars, "var", nil, "Variable of the configuration, as NAME=VALUE (repeatable)")
+	dc.Cmd.Flags().StringArrayVar(&dc.StateHeaders, "state-header", nil, "Header sent when fetching a remote state URI, as 'Name: value' (repeatable)")
+	dc.Cmd.Flags().StringVar(&dc.Format, "format", "table", "Output format (table, json)")
+	dc.Cmd.Flags().StringSliceVar(&dc.RedactPatterns, "redact", redact.DefaultPatterns, "Glob patterns of attribute names whose values are redacted, in addition to attributes marked sensitive in the state")
+	dc.Cmd.Flags().StringVar(&dc.RedactMode, "redact-mode", string(redact.ModeMask), "How sensitive values are redacted (mask, hash, none)")
+
+	return dc
+}
+
+func (d *diffStateCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		d.ctx = ctx
+	}
+	if d.Format != "table" && d.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", d.Format)
+	}
+	if len(args) == 2 && args[0] == statemanager.StdinStatePath && args[1] == statemanager.StdinStatePath {
+		return fmt.Errorf("only one of the states can be read from stdin")
+	}
+	headers, err := parseHeaders(d.StateHeaders)
+	if err != nil {
+		return err
+	}
+	redactor, err := redact.NewRedactor(d.RedactPatterns, redact.Mode(d.RedactMode))
+	if err != nil {
+		return err
+	}
+
+	manager := terraform.NewTerraformManager(terraform.WithFetcher(remote.NewFetcher(remote.Options{Headers: headers})))
+	states := make([]statemanager.StateContent, len(args))
+	for i, statePath := range args {
+		states[i], err = loadState(d.ctx, manager, statePath, cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("%s: %w", statePath, err)
+		}
+	}
+
+	var diff *statediff.Diff
+	if d.ConfigDir != "" {
+		diff, err = d.compareConfig(states[0], redactor)
+	} else {
+		diff, err = statediff.Compare(d.ctx, driftchecker.NewDefaultDriftChecker(), states[0], states[1], redactor)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if d.Format == "json" {
+		encoded, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal state diff: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	}
+	return writeStateDiff(out, diff)
+}
+
+// compareConfig compares state with the configuration in ConfigDir.
+func (d *diffStateCmd) compareConfig(state statemanager.StateContent, redactor *redact.Redactor) (*statediff.Diff, error) {
+	vars := map[string]string{}
+	for _, v := range d.Vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected NAME=VALUE", v)
+		}
+		vars[strings.TrimSpace(name)] = value
+	}
+	config, err := terraform.LoadConfigResources(d.ctx, d.ConfigDir, terraform.ConfigOptions{VarFiles: d.VarFiles, Vars: vars})
+	if err != nil {
+		return nil, err
+	}
+	return statediff.CompareConfig(d.ctx, driftchecker.NewDefaultDriftChecker(), config, state, redactor)
+}
+
+// loadState parses the state at statePath, reading it from stdin for "-".
+func loadState(ctx context.Context, manager *terraform.TerraformStateManager, statePath string, stdin io.Reader) (statemanager.StateContent, error) {
+	if statePath == statemanager.StdinStatePath {
+		return manager.ParseState(ctx, stdin)
+	}
+	return manager.ParseStateFile(ctx, statePath)
+}
+
+// writeStateDiff prints diff as a table of the changed resources, with the changed
+// attributes of each below it, followed by a summary line.
+func writeStateDiff(out io.Writer, diff *statediff.Diff) error {
+	if len(diff.Resources) == 0 {
+		_, err := fmt.Fprintln(out, "No changes between the states.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tADDRESS\tATTRIBUTE\tOLD\tNEW")
+	for _, resource := range diff.Resources {
+		fmt.Fprintf(tw, "%s\t%s\t\t\t\n", resource.Change, resource.Address)
+		for _, attribute := range resource.Attributes {
+			fmt.Fprintf(tw, "\t\t%s\t%s\t%s\n", attribute.Attribute, dash(fmt.Sprint(attribute.Old)), dash(fmt.Sprint(attribute.New)))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d added, %d removed, %d changed\n", diff.Added, diff.Removed, diff.Changed)
+	return err
+}
@@ -0,0 +1,128 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runDiffStateCmd(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	dc := cmd.NewDiffStateCmd(context.Background(), &config.Config{})
+	dc.Cmd.SetOut(&out)
+	dc.Cmd.SetIn(strings.NewReader(stdin))
+	dc.Cmd.SetArgs(args)
+	err := dc.Cmd.Execute()
+	return out.String(), err
+}
+
+const appliedState = `{
+  "version": 4,
+  "terraform_version": "1.5.0",
+  "serial": 4,
+  "lineage": "3f1c1f6a-0000-4000-8000-000000000000",
+  "resources": [
+    {
+      "module": "module.network",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "i-0", "tags": {"Name": "web-0"}}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"attributes": {"id": "logs"}}]
+    }
+  ]
+}`
+
+func writeState(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestDiffStateCmd_Table(t *testing.T) {
+	out, err := runDiffStateCmd(t, "", writeState(t, moduleState), writeState(t, appliedState))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, []string{"CHANGE", "ADDRESS", "ATTRIBUTE", "OLD", "NEW"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"ADDED", "aws_s3_bucket.logs"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"REMOVED", "data.aws_ami.ubuntu"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"CHANGED", "module.network.aws_instance.web[0]"}, strings.Fields(lines[3]))
+	assert.Equal(t, []string{"tags.Name", "-", "web-0"}, strings.Fields(lines[4]))
+	assert.Equal(t, []string{"REMOVED", "module.network.aws_instance.web[1]"}, strings.Fields(lines[5]))
+	assert.Equal(t, "1 added, 2 removed, 1 changed", lines[7])
+}
+
+func TestDiffStateCmd_JSONFromStdin(t *testing.T) {
+	out, err := runDiffStateCmd(t, appliedState, writeState(t, appliedState), "-", "--format", "json")
+	require.NoError(t, err)
+
+	var diff map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &diff))
+	assert.Equal(t, map[string]any{"resources": []any{}, "added": 0.0, "removed": 0.0, "changed": 0.0}, diff)
+}
+
+func TestDiffStateCmd_Errors(t *testing.T) {
+	_, err := runDiffStateCmd(t, "", "-", "-")
+	assert.ErrorContains(t, err, "only one of the states can be read from stdin")
+
+	_, err = runDiffStateCmd(t, "", "missing.tfstate", writeState(t, appliedState))
+	assert.ErrorContains(t, err, "missing.tfstate")
+
+	_, err = runDiffStateCmd(t, "", "a", "b", "--format", "csv")
+	assert.ErrorContains(t, err, "csv output format not currently supported")
+}
+
+func TestDiffStateCmd_Config(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`
+variable "bucket" {}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = var.bucket
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name = "jobs"
+}
+`), 0o600))
+
+	out, err := runDiffStateCmd(t, "", "--config", dir, "--var", "bucket=logs-v2", writeState(t, appliedState), "--format", "json")
+	require.NoError(t, err)
+
+	var diff map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &diff))
+	assert.Equal(t, []any{
+		map[string]any{"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "change": "CHANGED", "attributes": []any{
+			map[string]any{"attribute": "bucket", "old": "", "new": "logs-v2"},
+		}},
+		map[string]any{"address": "aws_sqs_queue.jobs", "type": "aws_sqs_queue", "change": "ADDED"},
+	}, diff["resources"])
+
+	_, err = runDiffStateCmd(t, "", "--config", dir, "a.tfstate", "b.tfstate")
+	assert.ErrorContains(t, err, "accepts 1 arg(s)")
+
+	_, err = runDiffStateCmd(t, "", "--config", dir, "--var", "bucket", writeState(t, appliedState))
+	assert.ErrorContains(t, err, `invalid variable "bucket"`)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/statemanager/discover"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type discoverCmd struct {
+	// Detect runs detection over the discovered roots with --run. Its flags, except
+	// --configfile and --state-manager, are flags of discover as well.
+	Detect       *detectCmd
+	Dir          string
+	JSON         bool
+	RunDetection bool
+	ctx          context.Context
+	Cmd          *cobra.Command
+}
+
+// NewDiscoverCmd creates and configures the 'discover' Cobra command.
+// This command walks a repository for Terraform roots and lists the state of each,
+// or runs drift detection for every one of them.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to a discoverCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewDiscoverCmd(ctx context.Context, cfg *config.Config) *discoverCmd {
+	dc := &discoverCmd{
+		Detect: NewDetectCmd(ctx, cfg),
+		ctx:    ctx,
+	}
+	dc.Cmd = &cobra.Command{
+		Use:   "discover",
+		Short: "Find the Terraform roots of a repository and the state of each",
+		Long: `Walk a directory for Terraform roots: directories whose .tf files declare a backend,
+or that hold a .terraform directory. The state of every root is located from the
+backend recorded by 'terraform init', or else from the backend block. Modules,
+hidden directories, .terragrunt-cache and node_modules are not searched.
+
+With --run, drift detection runs for every discovered state, taking the flags of
+detect, and every report is labelled with the path of its root.
+
+For example:
+  # List the roots of a monorepo and their states
+  driftwatcher discover --dir .
+
+  # Check every root for instance type drift
+  driftwatcher discover --dir . --run --attributes instance_type --format diff
+`,
+		RunE: dc.Run,
+	}
+
+	dc.Cmd.Flags().StringVar(&dc.Dir, "dir", ".", "Directory searched for Terraform roots")
+	dc.Cmd.Flags().BoolVar(&dc.JSON, "json", false, "List the discovered roots as JSON")
+	dc.Cmd.Flags().BoolVar(&dc.RunDetection, "run", false, "Run drift detection for every discovered root instead of listing them")
+	dc.Detect.Cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name != "configfile" && f.Name != "state-manager" {
+			dc.Cmd.Flags().AddFlag(f)
+		}
+	})
+	dc.Cmd.Flags().SetNormalizeFunc(flagAliases)
+	registerDetectCompletions(dc.Cmd)
+
+	return dc
+}
+
+func (d *discoverCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		d.ctx = ctx
+	}
+	if d.RunDetection {
+		d.Detect.TfConfigPath = d.Dir
+		d.Detect.StateManagerType = "discover"
+		return d.Detect.Run(cmd, args)
+	}
+
+	roots, err := discover.Discover(d.ctx, d.Dir)
+	if err != nil {
+		return err
+	}
+	if d.JSON {
+		if roots == nil {
+			roots = []discover.Root{}
+		}
+		rootsBytes, err := json.MarshalIndent(roots, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal discovered roots: %w", err)
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(rootsBytes))
+		return err
+	}
+
+	if len(roots) == 0 {
+		_, err := fmt.Fprintf(cmd.OutOrStdout(), "No Terraform roots found under %s\n", d.Dir)
+		return err
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tBACKEND\tSTATE")
+	for _, root := range roots {
+		state := root.StatePath
+		if root.Error != "" {
+			state = "unresolved: " + root.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", root.Path, root.Backend, state)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,107 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// terraformRepo creates a repository with an envs/prod root on S3, an initialized
+// envs/dev root on the local backend, a module and a root with a partial backend.
+func terraformRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"envs/prod/main.tf": `
+terraform {
+  backend "s3" {
+    bucket = "acme-tfstate"
+    key    = "prod.tfstate"
+  }
+}
+`,
+		"envs/dev/main.tf":          `resource "aws_instance" "web" {}`,
+		"envs/dev/.terraform/.keep": "",
+		"modules/web/main.tf":       `resource "aws_instance" "web" {}`,
+		"envs/staging/main.tf":      "terraform {\n  backend \"s3\" {}\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDiscoverCmd_Run_List(t *testing.T) {
+	dir := terraformRepo(t)
+	dc := cmd.NewDiscoverCmd(context.Background(), &config.Config{})
+	out := &bytes.Buffer{}
+	dc.Cmd.SetOut(out)
+	dc.Cmd.SetArgs([]string{"--dir", dir})
+
+	require.NoError(t, dc.Cmd.Execute())
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^PATH\s+BACKEND\s+STATE$`, string(lines[0]))
+	assert.Regexp(t, `^envs/dev\s+local\s+`+filepath.Join(dir, "envs", "dev", "terraform.tfstate")+`$`, string(lines[1]))
+	assert.Regexp(t, `^envs/prod\s+s3\s+s3://acme-tfstate/prod.tfstate$`, string(lines[2]))
+	assert.Regexp(t, `^envs/staging\s+s3\s+unresolved: s3 backend requires bucket and key`, string(lines[3]))
+}
+
+func TestDiscoverCmd_Run_JSON(t *testing.T) {
+	dc := cmd.NewDiscoverCmd(context.Background(), &config.Config{})
+	out := &bytes.Buffer{}
+	dc.Cmd.SetOut(out)
+	dc.Cmd.SetArgs([]string{"--dir", t.TempDir(), "--json"})
+
+	require.NoError(t, dc.Cmd.Execute())
+
+	var roots []any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &roots))
+	assert.Empty(t, roots)
+}
+
+func TestDiscoverCmd_Run_Detection(t *testing.T) {
+	dir := terraformRepo(t)
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockProvider := &providerfakes.FakeProviderI{}
+	mockProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	writer := &flushingWriter{}
+
+	dc := cmd.NewDiscoverCmd(context.Background(), &config.Config{})
+	dc.Detect.StateManager = mockStateManager
+	dc.Detect.PlatformProvider = mockProvider
+	dc.Detect.DriftChecker = &fixedChecker{}
+	dc.Detect.Reporter = writer
+	dc.Cmd.SetArgs([]string{"--dir", dir, "--run", "--attributes", "instance_type"})
+
+	err := dc.Cmd.Execute()
+	assert.EqualError(t, err, "drift detection failed for 1 of 3 stacks: envs/staging", "the root whose state is unknown fails")
+
+	require.Equal(t, 2, mockStateManager.ParseStateFileCallCount())
+	_, first := mockStateManager.ParseStateFileArgsForCall(0)
+	_, second := mockStateManager.ParseStateFileArgsForCall(1)
+	assert.Equal(t, filepath.Join(dir, "envs", "dev", "terraform.tfstate"), first)
+	assert.Equal(t, "s3://acme-tfstate/prod.tfstate", second)
+
+	require.Len(t, writer.reports, 2)
+	assert.Equal(t, "envs/dev", writer.reports[0].Stack)
+	assert.Equal(t, "envs/prod", writer.reports[1].Stack)
+	assert.Equal(t, 1, writer.flushes)
+}
@@ -3,9 +3,15 @@ package main
 import (
 	"context"
 	"drift-watcher/cmd"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	ctx := context.Background()
+	// cancel the context on SIGINT and SIGTERM so a running scan can stop gracefully
+	// and flush its partial results
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	cmd.Execute(ctx)
 }
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type historyCmd struct {
+	storeFlags
+	Store        store.ReportStore
+	Resource     string
+	ResourceType string
+	Since        string
+	FirstDrift   string
+	DriftOnly    bool
+	Limit        int
+	ctx          context.Context
+	Cmd          *cobra.Command
+	cfg          *config.Config
+}
+
+// NewHistoryCmd creates and configures the 'history' Cobra command.
+// This command queries drift reports persisted by previous 'detect --record' runs.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration, used to locate the default report store.
+//
+// Returns:
+//
+//	A pointer to a historyCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewHistoryCmd(ctx context.Context, cfg *config.Config) *historyCmd {
+	hc := &historyCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	hc.Cmd = &cobra.Command{
+		Use:   "history",
+		Short: "Query drift reports recorded by previous detect runs",
+		Long: `Query the drift reports persisted with 'detect --record'.
+
+For example:
+  # Show drift for a resource over the last 30 days
+  driftwatcher history --resource i-0abc123 --since 30d --drift-only
+
+  # Find out when instance_type first drifted for a resource
+  driftwatcher history --resource i-0abc123 --first-drift instance_type
+`,
+		RunE: hc.Run,
+	}
+
+	hc.Cmd.Flags().StringVar(&hc.Resource, "resource", "", "Resource id or name to show history for")
+	hc.Cmd.Flags().StringVar(&hc.ResourceType, "resource-type", "", "Only show reports for this resource type")
+	hc.Cmd.Flags().StringVar(&hc.Since, "since", "", "Only show reports newer than this duration (e.g. 72h, 30d)")
+	hc.Cmd.Flags().StringVar(&hc.FirstDrift, "first-drift", "", "Show when the given attribute first drifted for --resource")
+	hc.Cmd.Flags().BoolVar(&hc.DriftOnly, "drift-only", false, "Only show reports that contain drift")
+	hc.Cmd.Flags().IntVar(&hc.Limit, "limit", 0, "Maximum number of reports to show (0 for no limit)")
+	addStoreFlags(hc.Cmd, &hc.storeFlags)
+
+	return hc
+}
+
+func (h *historyCmd) Run(cmd *cobra.Command, args []string) error {
+	if h.FirstDrift != "" && h.Resource == "" {
+		return fmt.Errorf("--first-drift requires --resource")
+	}
+
+	if h.Store == nil {
+		reportStore, err := openReportStore(h.ctx, h.cfg, h.storeFlags)
+		if err != nil {
+			return err
+		}
+		defer reportStore.Close()
+		h.Store = reportStore
+	}
+
+	var result any
+	if h.FirstDrift != "" {
+		event, err := h.Store.FirstDrift(h.ctx, h.Resource, h.FirstDrift)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "No drift recorded for %s on %s\n", h.FirstDrift, h.Resource)
+			return nil
+		}
+		result = event
+	} else {
+		query := store.HistoryQuery{
+			Resource:     h.Resource,
+			ResourceType: h.ResourceType,
+			OnlyDrift:    h.DriftOnly,
+			Limit:        h.Limit,
+		}
+		if h.Since != "" {
+			since, err := parseSince(h.Since)
+			if err != nil {
+				return err
+			}
+			query.Since = time.Now().Add(-since)
+		}
+
+		reports, err := h.Store.History(h.ctx, query)
+		if err != nil {
+			return err
+		}
+		result = reports
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	return err
+}
+
+// parseSince parses a lookback window. In addition to the units understood by
+// time.ParseDuration it accepts a "d" suffix for whole days.
+func parseSince(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --since value %q: %w", value, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --since value %q: %w", value, err)
+	}
+	return d, nil
+}
+
+// storeFlags are the flags selecting the report store, shared by the commands that
+// read or write it.
+type storeFlags struct {
+	StoreDriver        string
+	StoreDSN           string
+	StoreEncryptionKey string
+	StoreKMSKey        string
+	StoreAWSProfile    string
+}
+
+// addStoreFlags registers the flags that select the report store on cmd.
+func addStoreFlags(cmd *cobra.Command, flags *storeFlags) {
+	cmd.Flags().StringVar(&flags.StoreDriver, "store-driver", string(store.SQLiteDriver), "Report store driver (sqlite, postgres)")
+	cmd.Flags().StringVar(&flags.StoreDSN, "store-dsn", "", "Report store data source; defaults to history.db in the driftwatcher config folder for sqlite")
+	cmd.Flags().StringVar(&flags.StoreEncryptionKey, "store-encryption-key", "", "File holding a base64 encoded 256-bit key the stored reports are encrypted with")
+	cmd.Flags().StringVar(&flags.StoreKMSKey, "store-kms-key", "", "AWS KMS key id, ARN or alias the stored reports are envelope encrypted with")
+	cmd.Flags().StringVar(&flags.StoreAWSProfile, "store-awsprofile", "", "AWS profile used to call KMS for --store-kms-key and read an aws-sm:// --store-dsn, AWS_PROFILE or else the default profile when empty")
+}
+
+// openReportStore opens the report store selected by flags. An empty sqlite dsn
+// resolves to history.db inside the driftwatcher config folder, and a postgres dsn
+// may be a secret reference such as aws-sm://driftwatcher/store#dsn.
+func openReportStore(ctx context.Context, cfg *config.Config, flags storeFlags) (*store.SQLStore, error) {
+	driver, dsn := flags.StoreDriver, flags.StoreDSN
+	if store.Driver(driver) == store.PostgresDriver {
+		// sqlite dsns are paths, and may be file: URIs, so only postgres dsns are resolved
+		resolved, err := newSecretResolver(flags.StoreAWSProfile).Resolve(ctx, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("--store-dsn: %w", err)
+		}
+		dsn = resolved
+	}
+	if dsn == "" {
+		if store.Driver(driver) != store.SQLiteDriver {
+			return nil, fmt.Errorf("--store-dsn is required for the %s store driver", driver)
+		}
+		folder, err := cfg.GetConfigFolder(os.Getenv("XDG_CONFIG_HOME"))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(folder, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create config folder %s: %w", folder, err)
+		}
+		dsn = filepath.Join(folder, "history.db")
+	}
+
+	var opts []store.Option
+	switch {
+	case flags.StoreEncryptionKey != "" && flags.StoreKMSKey != "":
+		return nil, fmt.Errorf("--store-encryption-key and --store-kms-key are mutually exclusive")
+	case flags.StoreEncryptionKey != "":
+		c, err := store.NewLocalCipher(flags.StoreEncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, store.WithCipher(c))
+	case flags.StoreKMSKey != "":
+		client, err := newKMSClient(ctx, flags.StoreAWSProfile)
+		if err != nil {
+			return nil, err
+		}
+		c, err := store.NewKMSCipher(client, flags.StoreKMSKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, store.WithCipher(c))
+	}
+
+	return store.NewSQLStore(ctx, store.Driver(driver), dsn, opts...)
+}
@@ -0,0 +1,122 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/services/store/storefakes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistoryCmd(t *testing.T) {
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+
+	assert.Equal(t, "history", hc.Cmd.Use)
+	for _, flag := range []string{"resource", "resource-type", "since", "first-drift", "drift-only", "limit", "store-driver", "store-dsn", "store-encryption-key", "store-kms-key"} {
+		assert.NotNil(t, hc.Cmd.Flags().Lookup(flag), flag)
+	}
+	assert.Equal(t, "sqlite", hc.StoreDriver)
+}
+
+func TestHistoryCmd_Run_History(t *testing.T) {
+	fakeStore := &storefakes.FakeReportStore{}
+	fakeStore.HistoryReturns([]store.StoredReport{
+		{RunId: "run-1", Report: driftchecker.DriftReport{ResourceId: "i-123", Status: driftchecker.Drift}},
+	}, nil)
+
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Store = fakeStore
+	hc.Resource = "i-123"
+	hc.Since = "30d"
+	hc.DriftOnly = true
+
+	var out bytes.Buffer
+	hc.Cmd.SetOut(&out)
+	require.NoError(t, hc.Run(hc.Cmd, nil))
+
+	require.Equal(t, 1, fakeStore.HistoryCallCount())
+	_, query := fakeStore.HistoryArgsForCall(0)
+	assert.Equal(t, "i-123", query.Resource)
+	assert.True(t, query.OnlyDrift)
+	assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), query.Since, time.Minute)
+	assert.Contains(t, out.String(), `"run_id": "run-1"`)
+}
+
+func TestHistoryCmd_Run_FirstDrift(t *testing.T) {
+	fakeStore := &storefakes.FakeReportStore{}
+	fakeStore.FirstDriftReturns(&store.DriftEvent{RunId: "run-7", Item: driftchecker.DriftItem{Field: "instance_type"}}, nil)
+
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Store = fakeStore
+	hc.Resource = "i-123"
+	hc.FirstDrift = "instance_type"
+
+	var out bytes.Buffer
+	hc.Cmd.SetOut(&out)
+	require.NoError(t, hc.Run(hc.Cmd, nil))
+
+	_, resource, field := fakeStore.FirstDriftArgsForCall(0)
+	assert.Equal(t, "i-123", resource)
+	assert.Equal(t, "instance_type", field)
+	assert.Contains(t, out.String(), "run-7")
+}
+
+func TestHistoryCmd_Run_FirstDriftRequiresResource(t *testing.T) {
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Store = &storefakes.FakeReportStore{}
+	hc.FirstDrift = "instance_type"
+
+	err := hc.Run(hc.Cmd, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--first-drift requires --resource")
+}
+
+func TestHistoryCmd_Run_InvalidSince(t *testing.T) {
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Store = &storefakes.FakeReportStore{}
+	hc.Since = "yesterday"
+
+	err := hc.Run(hc.Cmd, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --since value")
+}
+
+func TestHistoryCmd_Run_EncryptedStore(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "history.db")
+	keyPath := filepath.Join(dir, "store.key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))), 0600))
+
+	c, err := store.NewLocalCipher(keyPath)
+	require.NoError(t, err)
+	s, err := store.NewSQLStore(context.Background(), store.SQLiteDriver, dsn, store.WithCipher(c))
+	require.NoError(t, err)
+	require.NoError(t, s.SaveReport(context.Background(), "run-1", &driftchecker.DriftReport{ResourceId: "i-123", GeneratedAt: time.Now()}))
+	require.NoError(t, s.Close())
+
+	hc := cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	var out bytes.Buffer
+	hc.Cmd.SetOut(&out)
+	hc.Cmd.SetArgs([]string{"--store-dsn", dsn, "--store-encryption-key", keyPath})
+	require.NoError(t, hc.Cmd.Execute())
+	assert.Contains(t, out.String(), `"resource_id": "i-123"`)
+
+	hc = cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Cmd.SetOut(&bytes.Buffer{})
+	hc.Cmd.SetArgs([]string{"--store-dsn", dsn})
+	assert.ErrorIs(t, hc.Cmd.Execute(), store.ErrEncrypted)
+
+	hc = cmd.NewHistoryCmd(context.Background(), &config.Config{})
+	hc.Cmd.SetArgs([]string{"--store-dsn", dsn, "--store-encryption-key", keyPath, "--store-kms-key", "alias/driftwatcher"})
+	assert.EqualError(t, hc.Cmd.Execute(), "--store-encryption-key and --store-kms-key are mutually exclusive")
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/orchestrate"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/provider/kubernetes"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+type orchestrateCmd struct {
+	// NewProvider creates the platform provider of a target. Every target gets its
+	// own provider since credentials and region differ between targets.
+	NewProvider func(target orchestrate.Target) (provider.ProviderI, error)
+	// NewStateManager creates the state manager of a target. A state manager holds
+	// the state it parsed last, so one is never shared by targets scanned at once.
+	NewStateManager func() (statemanager.StateManagerI, error)
+	DriftChecker    driftchecker.DriftChecker
+	PlanPath        string
+	OutputPath      string
+	Concurrency     int
+	StateHeaders    []string
+	StateRetries    int
+	ctx             context.Context
+	Cmd             *cobra.Command
+	cfg             *config.Config
+}
+
+// NewOrchestrateCmd creates and configures the 'orchestrate' Cobra command.
+// This command runs drift detection for every target declared in an orchestration
+// file, several targets at a time, and writes a single report keyed by target.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to an orchestrateCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewOrchestrateCmd(ctx context.Context, cfg *config.Config) *orchestrateCmd {
+	oc := &orchestrateCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	oc.Cmd = &cobra.Command{
+		Use:   "orchestrate",
+		Short: "Detect drift across many accounts, regions and states declared in a YAML file",
+		Long: `Run drift detection for every target declared in an orchestration file. A target
+names the account (through an AWS profile and optionally a role to assume), the region,
+the state file and the resource types to check. Targets are scanned concurrently and
+their reports are merged into a single JSON document keyed by target name.
+
+For example:
+  # Scan every target of the plan, 8 targets at a time
+  driftwatcher orchestrate --plan accounts.yaml --concurrency 8 --output-file drift.json
+`,
+		RunE: oc.Run,
+	}
+
+	oc.Cmd.Flags().StringVar(&oc.PlanPath, "plan", "", "Path to the YAML orchestration file declaring the targets")
+	oc.Cmd.Flags().StringVar(&oc.OutputPath, "output-file", "", "File the merged report is written to (default: stdout)")
+	oc.Cmd.Flags().IntVar(&oc.Concurrency, "concurrency", 0, "Number of targets scanned at the same time (default: the plan's concurrency)")
+	oc.Cmd.Flags().StringArrayVar(&oc.StateHeaders, "state-header", nil, "Header sent when fetching a remote state URI, as 'Name: value' (repeatable)")
+	oc.Cmd.Flags().IntVar(&oc.StateRetries, "state-retries", 3, "Number of times a failed remote state download is retried")
+
+	return oc
+}
+
+func (o *orchestrateCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		o.ctx = ctx
+	}
+	if o.PlanPath == "" {
+		return fmt.Errorf("an orchestration file is required")
+	}
+	if o.Concurrency < 0 {
+		return fmt.Errorf("--concurrency must not be negative")
+	}
+
+	plan, err := orchestrate.Load(o.PlanPath)
+	if err != nil {
+		return err
+	}
+	concurrency := plan.Concurrency
+	if o.Concurrency > 0 {
+		concurrency = o.Concurrency
+	}
+
+	if err := o.setDefaults(); err != nil {
+		return err
+	}
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	if err != nil {
+		return err
+	}
+
+	// the reports of every target share the run of this invocation
+	run := &driftchecker.RunMetadata{RunId: uuid.NewString(), StartedAt: time.Now()}
+	o.ctx = driftchecker.NewRunContext(o.ctx, run)
+
+	logging.FromContext(o.ctx).Info("Starting orchestrated drift detection", "targets", len(plan.Targets), "concurrency", concurrency)
+	result := o.scanTargets(o.ctx, plan.Targets, concurrency, redactor)
+
+	if err := o.writeResult(cmd, result); err != nil {
+		return err
+	}
+	if err := o.ctx.Err(); err != nil {
+		return fmt.Errorf("drift detection interrupted: %w", err)
+	}
+	if failed := result.Failed(); len(failed) > 0 {
+		return fmt.Errorf("drift detection failed for %d of %d targets: %s", len(failed), len(plan.Targets), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// setDefaults creates the state managers, providers and drift checker that were not
+// set.
+func (o *orchestrateCmd) setDefaults() error {
+	if o.NewStateManager == nil {
+		fetcher, err := newStateFetcher(o.StateHeaders, o.StateRetries, nil)
+		if err != nil {
+			return err
+		}
+		o.NewStateManager = func() (statemanager.StateManagerI, error) {
+			return terraform.NewTerraformManager(terraform.WithFetcher(fetcher)), nil
+		}
+	}
+	if o.NewProvider == nil {
+		o.NewProvider = o.newTargetProvider
+	}
+	if o.DriftChecker == nil {
+		o.DriftChecker = driftchecker.NewDefaultDriftChecker()
+	}
+	return nil
+}
+
+// scanTargets scans targets, concurrency of them at a time, under the run carried by
+// ctx, and merges their reports.
+func (o *orchestrateCmd) scanTargets(ctx context.Context, targets []orchestrate.Target, concurrency int, redactor *redact.Redactor) *orchestrate.Result {
+	result := &orchestrate.Result{
+		SchemaVersion: driftchecker.SchemaVersion,
+		GeneratedAt:   time.Now(),
+		RunId:         driftchecker.RunFromContext(ctx).RunId,
+		Targets:       make(map[string]*orchestrate.TargetResult, len(targets)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			targetResult := o.scan(ctx, target, redactor)
+			mu.Lock()
+			result.Targets[target.Name] = targetResult
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// scan runs drift detection for every resource type of a target. A failed target
+// is logged and recorded in its result instead of stopping the other targets.
+func (o *orchestrateCmd) scan(ctx context.Context, target orchestrate.Target, redactor *redact.Redactor) *orchestrate.TargetResult {
+	logging.FromContext(ctx).Info("Checking orchestration target", "target", target.Name, "provider", target.Provider, "state_path", target.State)
+	collector := &collectingWriter{}
+	err := o.scanTarget(ctx, target, redactor, collector)
+
+	result := &orchestrate.TargetResult{
+		Status:  orchestrate.StatusOK,
+		Reports: collector.reports,
+	}
+	if result.Reports == nil {
+		result.Reports = []*driftchecker.DriftReport{}
+	}
+	for _, report := range result.Reports {
+		if report.HasDrift {
+			result.Drifted++
+		}
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Drift detection failed for orchestration target", "target", target.Name, "error", err)
+		result.Status = orchestrate.StatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (o *orchestrateCmd) scanTarget(ctx context.Context, target orchestrate.Target, redactor *redact.Redactor, collector reporter.OutputWriter) error {
+	stateManager, err := o.NewStateManager()
+	if err != nil {
+		return err
+	}
+	platformProvider, err := o.NewProvider(target)
+	if err != nil {
+		return err
+	}
+
+	targetRun := *driftchecker.RunFromContext(ctx)
+	targetRun.Provider = target.Provider
+	targetRun.Target = target.Name
+	ctx = driftchecker.NewRunContext(ctx, &targetRun)
+
+	opts := []driftwatcher.DetectionOption{
+		driftwatcher.WithConcurrency(target.Concurrency),
+		driftwatcher.WithRedaction(redactor),
+	}
+	for _, resource := range target.Resources {
+		err := driftwatcher.RunDriftDetection(ctx, target.State, resource.Type, resource.Attributes, stateManager, platformProvider, o.DriftChecker, collector, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", resource.Type, err)
+		}
+	}
+	return nil
+}
+
+// writeResult writes the merged report to the output file or to stdout.
+func (o *orchestrateCmd) writeResult(cmd *cobra.Command, result *orchestrate.Result) error {
+	resultBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal orchestration report: %w", err)
+	}
+	if o.OutputPath == "" {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), string(resultBytes))
+		return err
+	}
+	if err := os.WriteFile(o.OutputPath, resultBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write orchestration report: %w", err)
+	}
+	logging.FromContext(o.ctx).Info("Orchestration report written", "path", o.OutputPath)
+	return nil
+}
+
+// newTargetProvider creates the platform provider of a target from its provider,
+// profile, role, region and partition settings.
+func (o *orchestrateCmd) newTargetProvider(target orchestrate.Target) (provider.ProviderI, error) {
+	switch target.Provider {
+	case "aws":
+		awsConfig, err := aws.CheckAWSConfig(o.ctx, "", target.AWSProfile)
+		if err != nil {
+			return nil, err
+		}
+		awsConfig.RetryMode = aws.DefaultRetryMode
+		awsConfig.MaxAttempts = aws.DefaultMaxAttempts
+		awsConfig.MaxBackoff = aws.DefaultMaxBackoff
+		awsConfig.Partition = target.Partition
+		awsConfig.UseFIPS = target.FIPS
+		return aws.NewAWSProvider(&awsConfig, aws.WithRegion(target.Region), aws.WithAssumeRole(target.RoleARN))
+	case "kubernetes":
+		return kubernetes.NewKubernetesProvider(&config.KubernetesConfig{
+			Kubeconfig: target.Kubeconfig,
+			Context:    target.KubeContext,
+		})
+	default:
+		return nil, fmt.Errorf("%s platform not currently supported", target.Provider)
+	}
+}
+
+// collectingWriter keeps the reports of a target in memory until every target has
+// been scanned.
+type collectingWriter struct {
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+}
+
+func (c *collectingWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reports = append(c.reports, report)
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/orchestrate"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orchestrationPlan = `
+concurrency: 2
+targets:
+  - name: prod-us-east-1
+    role_arn: arn:aws:iam::111111111111:role/drift-readonly
+    region: us-east-1
+    state: s3://states/prod/terraform.tfstate
+    concurrency: 3
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+  - name: staging-eu-west-1
+    region: eu-west-1
+    state: s3://states/staging/terraform.tfstate
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+  - name: broken
+    state: s3://states/broken/terraform.tfstate
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+`
+
+func newOrchestrateCmd(t *testing.T, plan string) (*bytes.Buffer, *int32, func() error) {
+	t.Helper()
+	planPath := filepath.Join(t.TempDir(), "plan.yaml")
+	require.NoError(t, os.WriteFile(planPath, []byte(plan), 0644))
+
+	var stateManagers int32
+	oc := cmd.NewOrchestrateCmd(context.Background(), &config.Config{})
+	oc.NewStateManager = func() (statemanager.StateManagerI, error) {
+		atomic.AddInt32(&stateManagers, 1)
+		mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+		mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+			{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+		}, nil)
+		return mockStateManager, nil
+	}
+	oc.NewProvider = func(target orchestrate.Target) (provider.ProviderI, error) {
+		if target.Name == "broken" {
+			return nil, errors.New("failed to assume role")
+		}
+		mockProvider := &providerfakes.FakeProviderI{}
+		mockProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+		return mockProvider, nil
+	}
+	oc.DriftChecker = &fixedChecker{}
+
+	out := &bytes.Buffer{}
+	// the root command silences usage, the merged report is the only output
+	oc.Cmd.SilenceUsage = true
+	oc.Cmd.SetOut(out)
+	oc.Cmd.SetArgs([]string{"--plan", planPath})
+	return out, &stateManagers, oc.Cmd.Execute
+}
+
+func TestOrchestrateCmd_Run_MergesReportsByTarget(t *testing.T) {
+	out, stateManagers, run := newOrchestrateCmd(t, orchestrationPlan)
+
+	err := run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "drift detection failed for 1 of 3 targets: broken")
+	assert.Equal(t, int32(3), *stateManagers)
+
+	var result orchestrate.Result
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	require.Len(t, result.Targets, 3)
+
+	for _, name := range []string{"prod-us-east-1", "staging-eu-west-1"} {
+		target := result.Targets[name]
+		require.NotNil(t, target, name)
+		assert.Equal(t, orchestrate.StatusOK, target.Status)
+		assert.Equal(t, 1, target.Drifted)
+		assert.Len(t, target.Reports, 1)
+	}
+
+	broken := result.Targets["broken"]
+	require.NotNil(t, broken)
+	assert.Equal(t, orchestrate.StatusError, broken.Status)
+	assert.Equal(t, "failed to assume role", broken.Error)
+	assert.Empty(t, broken.Reports)
+}
+
+func TestOrchestrateCmd_Run_OutputFile(t *testing.T) {
+	plan := `
+targets:
+  - name: prod
+    state: prod.tfstate
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+`
+	planPath := filepath.Join(t.TempDir(), "plan.yaml")
+	require.NoError(t, os.WriteFile(planPath, []byte(plan), 0644))
+	outputPath := filepath.Join(t.TempDir(), "drift.json")
+
+	oc := cmd.NewOrchestrateCmd(context.Background(), &config.Config{})
+	oc.NewStateManager = func() (statemanager.StateManagerI, error) {
+		return &statemanagerfakes.FakeStateManagerI{}, nil
+	}
+	oc.NewProvider = func(orchestrate.Target) (provider.ProviderI, error) {
+		return &providerfakes.FakeProviderI{}, nil
+	}
+	oc.Cmd.SetArgs([]string{"--plan", planPath, "--output-file", outputPath})
+	require.NoError(t, oc.Cmd.Execute())
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	var result orchestrate.Result
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Contains(t, result.Targets, "prod")
+	assert.Equal(t, orchestrate.StatusOK, result.Targets["prod"].Status)
+	assert.Empty(t, result.Targets["prod"].Reports)
+}
+
+func TestOrchestrateCmd_Run_InvalidPlan(t *testing.T) {
+	_, _, run := newOrchestrateCmd(t, "targets:\n  - name: prod\n    resources: []\n")
+
+	err := run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "target prod: state is required")
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/rundiff"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// reportCmd groups the subcommands that work on the reports of previous runs.
+type reportCmd struct {
+	Cmd *cobra.Command
+}
+
+// NewReportCmd creates the 'report' Cobra command and its subcommands.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration, used to locate the default report store.
+//
+// Returns:
+//
+//	A pointer to a reportCmd struct, which encapsulates the Cobra command.
+func NewReportCmd(ctx context.Context, cfg *config.Config) *reportCmd {
+	rc := &reportCmd{}
+	rc.Cmd = &cobra.Command{
+		Use:   "report",
+		Short: "Work with the reports of previous detect runs",
+	}
+	rc.Cmd.AddCommand(NewReportDiffCmd(ctx, cfg).Cmd)
+	return rc
+}
+
+type reportDiffCmd struct {
+	storeFlags
+	Store  store.ReportStore
+	Format string
+	ctx    context.Context
+	Cmd    *cobra.Command
+	cfg    *config.Config
+}
+
+// NewReportDiffCmd creates the 'report diff' Cobra command, which compares the
+// reports of two runs.
+func NewReportDiffCmd(ctx context.Context, cfg *config.Config) *reportDiffCmd {
+	dc := &reportDiffCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	dc.Cmd = &cobra.Command{
+		Use:   "diff <run-a> <run-b>",
+		Short: "Show how drift changed between two runs",
+		Long: `Compare the reports of two runs and list the resources that newly drifted, those
+whose drift was resolved and those whose drifted attributes changed between them.
+
+A run is either the path of a report file written by detect, as a single report, a
+JSON array or NDJSON, or the id of a run recorded with 'detect --record', read from
+the report store. A resource that drifted in the first run but was not checked by
+the second is left out.
+
+For example:
+  driftwatcher report diff 6f1c2a40-... 9b7e0d13-...
+  driftwatcher report diff last-week.ndjson today.ndjson --format json
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: dc.Run,
+	}
+
+	dc.Cmd.Flags().StringVar(&dc.Format, "format", "table", "Output format (table, json)")
+	addStoreFlags(dc.Cmd, &dc.storeFlags)
+
+	return dc
+}
+
+func (d *reportDiffCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		d.ctx = ctx
+	}
+	if d.Format != "table" && d.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", d.Format)
+	}
+
+	if d.Store == nil && (!isFile(args[0]) || !isFile(args[1])) {
+		reportStore, err := openReportStore(d.ctx, d.cfg, d.storeFlags)
+		if err != nil {
+			return err
+		}
+		defer reportStore.Close()
+		d.Store = reportStore
+	}
+
+	runs := make([][]*driftchecker.DriftReport, len(args))
+	for i, run := range args {
+		reports, err := d.load(run)
+		if err != nil {
+			return err
+		}
+		runs[i] = reports
+	}
+	diff := rundiff.Compare(runs[0], runs[1])
+
+	out := cmd.OutOrStdout()
+	if d.Format == "json" {
+		encoded, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report diff: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	}
+	return writeRunDiff(out, diff)
+}
+
+// load returns the reports of run, read from the file at that path when there is one
+// and from the report store otherwise.
+func (d *reportDiffCmd) load(run string) ([]*driftchecker.DriftReport, error) {
+	if isFile(run) {
+		return rundiff.ReadFile(run)
+	}
+	stored, err := d.Store.History(d.ctx, store.HistoryQuery{RunId: run})
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, fmt.Errorf("no report file or recorded run %s found", run)
+	}
+	reports := make([]*driftchecker.DriftReport, len(stored))
+	for i := range stored {
+		reports[i] = &stored[i].Report
+	}
+	return reports, nil
+}
+
+// writeRunDiff prints diff as a table of the resources whose drift changed, with the
+// changed attributes of each below it, followed by a summary line.
+func writeRunDiff(out io.Writer, diff *rundiff.Diff) error {
+	if len(diff.Resources) == 0 {
+		_, err := fmt.Fprintf(out, "No drift changes between the runs, %d resource(s) still drifted.\n", diff.Unchanged)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tRESOURCE\tATTRIBUTE\tBEFORE\tAFTER")
+	for _, resource := range diff.Resources {
+		fmt.Fprintf(tw, "%s\t%s\t\t%s\t%s\n", resource.Change, resource.Resource, dash(resource.StatusBefore), dash(resource.StatusAfter))
+		for _, attribute := range resource.Attributes {
+			fmt.Fprintf(tw, "\t\t%s\t%s\t%s\n", attribute.Attribute, driftValue(attribute.Before), driftValue(attribute.After))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d newly drifted, %d resolved, %d changed, %d unchanged\n", diff.Drifted, diff.Resolved, diff.Changed, diff.Unchanged)
+	return err
+}
+
+// driftValue renders the live value of a drifted attribute, or - when the attribute
+// did not drift.
+func driftValue(item *driftchecker.DriftItem) string {
+	switch {
+	case item == nil:
+		return "-"
+	case item.ActualValue == nil:
+		return "(missing)"
+	default:
+		return dash(fmt.Sprint(item.ActualValue))
+	}
+}
+
+// isFile reports whether path names a regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,80 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/services/store/storefakes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runReportDiffCmd(t *testing.T, reportStore store.ReportStore, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	dc := cmd.NewReportDiffCmd(context.Background(), &config.Config{})
+	dc.Store = reportStore
+	dc.Cmd.SetOut(&out)
+	dc.Cmd.SetArgs(args)
+	err := dc.Cmd.Execute()
+	return out.String(), err
+}
+
+func TestReportDiffCmd_Files(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.ndjson")
+	require.NoError(t, os.WriteFile(before, []byte(`{"resource_type":"aws_instance","resource_name":"web","has_drift":true,"status":"DRIFT","drift_details":[{"field":"instance_type","terraform_value":"t3.micro","actual_value":"t3.large","drift_type":"VALUE_CHANGED"}]}
+{"resource_type":"aws_instance","resource_name":"db","status":"MATCH"}
+`), 0644))
+	after := filepath.Join(dir, "after.json")
+	require.NoError(t, os.WriteFile(after, []byte(`[
+  {"resource_type":"aws_instance","resource_name":"web","status":"MATCH"},
+  {"resource_type":"aws_instance","resource_name":"db","has_drift":true,"status":"DRIFT","drift_details":[{"field":"tags.Owner","terraform_value":"data","actual_value":null,"drift_type":"MISSING_IN_INFRASTRUCTURE"}]}
+]`), 0644))
+
+	out, err := runReportDiffCmd(t, nil, before, after)
+	require.NoError(t, err)
+	assert.Contains(t, out, "CHANGE    RESOURCE          ATTRIBUTE      BEFORE    AFTER\n")
+	assert.Contains(t, out, "DRIFTED   aws_instance.db                  MATCH     DRIFT\n")
+	assert.Contains(t, out, "                            tags.Owner     -         (missing)\n")
+	assert.Contains(t, out, "RESOLVED  aws_instance.web                 DRIFT     MATCH\n")
+	assert.Contains(t, out, "                            instance_type  t3.large  -\n")
+	assert.Contains(t, out, "1 newly drifted, 1 resolved, 0 changed, 0 unchanged\n")
+
+	out, err = runReportDiffCmd(t, nil, before, before, "--format", "json")
+	require.NoError(t, err)
+	var diff map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &diff))
+	assert.Equal(t, float64(1), diff["unchanged"])
+	assert.Empty(t, diff["resources"])
+}
+
+func TestReportDiffCmd_Store(t *testing.T) {
+	run1 := []store.StoredReport{
+		{RunId: "run-1", Report: driftchecker.DriftReport{ResourceType: "aws_instance", ResourceName: "web", Status: driftchecker.Match}},
+	}
+	fakeStore := &storefakes.FakeReportStore{}
+	fakeStore.HistoryReturnsOnCall(0, run1, nil)
+	fakeStore.HistoryReturnsOnCall(1, []store.StoredReport{
+		{RunId: "run-2", Report: driftchecker.DriftReport{ResourceType: "aws_instance", ResourceName: "web", HasDrift: true, Status: driftchecker.Drift}},
+	}, nil)
+	fakeStore.HistoryReturnsOnCall(2, run1, nil)
+	fakeStore.HistoryReturnsOnCall(3, nil, nil)
+
+	out, err := runReportDiffCmd(t, fakeStore, "run-1", "run-2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "DRIFTED  aws_instance.web")
+	_, query := fakeStore.HistoryArgsForCall(1)
+	assert.Equal(t, store.HistoryQuery{RunId: "run-2"}, query)
+
+	_, err = runReportDiffCmd(t, fakeStore, "run-1", "run-3")
+	assert.ErrorContains(t, err, "no report file or recorded run run-3 found")
+}
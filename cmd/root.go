@@ -3,6 +3,11 @@ package cmd
 import (
 	"context"
 	"drift-watcher/config"
+	"drift-watcher/pkg/buildinfo"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/profiling"
+	"drift-watcher/pkg/telemetry"
+	"io"
 	"log/slog"
 
 	"github.com/spf13/cobra"
@@ -10,21 +15,78 @@ import (
 
 var Config config.Config
 
+// commandLogger is the logger of the executed command and logFile closes the file it
+// writes to, if any. stopProfiling writes the profiles requested for the command.
+var (
+	commandLogger = slog.Default()
+	logFile       io.Closer
+	stopProfiling profiling.StopFunc
+)
+
 var RootCmd = &cobra.Command{
 	Use:           "driftwatcher",
 	Aliases:       []string{"dw"},
 	Short:         "A CLI to help you compare two configurations and detect drift across a list of defined attributes",
 	Long:          "CLI to interact with driftwatcher.",
-	Version:       "1.0",
+	Version:       buildinfo.Get().Version,
 	SilenceErrors: true,
 	SilenceUsage:  true,
-	Run:           func(cmd *cobra.Command, args []string) {},
+	// the logger is carried in the command context rather than set as the slog
+	// default, so commands executed by tests do not change the logging of others
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logger, closer, err := logging.New(logging.Options{
+			Level:        Config.LogLevel,
+			Format:       Config.LogFormat,
+			File:         Config.LogFile,
+			ModuleLevels: Config.LogModuleLevels,
+		})
+		if err != nil {
+			return err
+		}
+		commandLogger, logFile = logger, closer
+		if stopProfiling, err = profiling.Start(Config.CPUProfile, Config.MemProfile); err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		cmd.SetContext(logging.NewContext(ctx, logger))
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {},
 }
 
 func Execute(ctx context.Context) {
-	RootCmd.SetVersionTemplate("1.0")
+	RootCmd.SetVersionTemplate("driftwatcher " + buildinfo.Get().String() + "\n")
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+	// profiles are written once the command returns, also when it failed
+	defer func() {
+		if stopProfiling == nil {
+			return
+		}
+		if err := stopProfiling(); err != nil {
+			commandLogger.Warn("Failed to write profiles", "error", err)
+		}
+	}()
+
+	// the flags are not parsed yet, so tracing setup logs to the default logger
+	shutdown, err := telemetry.Setup(ctx, RootCmd.Version)
+	if err != nil {
+		slog.Warn("Failed to configure OpenTelemetry tracing", "error", err)
+	}
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			commandLogger.Warn("Failed to flush OpenTelemetry spans", "error", err)
+		}
+	}()
+
 	if err := RootCmd.ExecuteContext(ctx); err != nil {
-		slog.Error("Failed to execute command", "error", err)
+		commandLogger.Error("Failed to execute command", "error", err)
 	}
 }
 
@@ -32,8 +94,30 @@ func init() {
 	ctx := context.Background()
 	cobra.OnInitialize(Config.Init)
 	RootCmd.PersistentFlags().StringVar(&Config.LogLevel, "log-level", "info", "log level (debug, info, trace, warn, error)")
+	RootCmd.PersistentFlags().StringVar(&Config.LogFormat, "log-format", logging.FormatText, "log format (text, json)")
+	RootCmd.PersistentFlags().StringVar(&Config.LogFile, "log-file", "", "Append logs to this file instead of writing them to stderr")
+	RootCmd.PersistentFlags().StringSliceVar(&Config.LogModuleLevels, "log-module-level", nil, "Log level of a single module as module=level, e.g. aws=debug (repeatable)")
+	RootCmd.PersistentFlags().StringVar(&Config.CPUProfile, "profile-cpu", "", "Write a pprof CPU profile of the command to this file")
+	RootCmd.PersistentFlags().StringVar(&Config.MemProfile, "profile-mem", "", "Write a pprof memory profile, taken when the command returns, to this file")
+	RootCmd.PersistentFlags().StringVar(&Config.Profile.ProfileName, "profile", config.DefaultProfileName, "Named profile from the config file to read settings from")
 	RootCmd.Flags().BoolP("version", "v", false, "Get the version of the DriftWatcher CLI")
 
 	RootCmd.AddCommand(NewDetectCmd(ctx, &Config).Cmd)
 	RootCmd.AddCommand(newConfigCmd().cmd)
+	RootCmd.AddCommand(NewHistoryCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewReportCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewValidateCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewStateCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewDiffStateCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewOrchestrateCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewServeCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewScheduleCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewVerifyCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(NewDiscoverCmd(ctx, &Config).Cmd)
+	RootCmd.AddCommand(newAttributesCmd().Cmd)
+
+	// the completion command is our own, limited to the shells it is documented for
+	RootCmd.CompletionOptions.DisableDefaultCmd = true
+	RootCmd.AddCommand(newCompletionCmd())
+	RootCmd.AddCommand(newVersionCmd())
 }
@@ -5,6 +5,7 @@ import (
 	// Assuming config package exists
 
 	"drift-watcher/cmd"
+	"drift-watcher/pkg/buildinfo"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +16,7 @@ func TestRootCmd_FlagsAndCommands(t *testing.T) {
 	// We'll just check if the commands and flags are registered correctly.
 
 	assert.Equal(t, "driftwatcher", cmd.RootCmd.Use)
-	assert.Equal(t, "1.0", cmd.RootCmd.Version) // Check static version
+	assert.Equal(t, buildinfo.Get().Version, cmd.RootCmd.Version)
 
 	// Check persistent flags
 	logLevelFlag := cmd.RootCmd.PersistentFlags().Lookup("log-level")
@@ -23,6 +24,13 @@ func TestRootCmd_FlagsAndCommands(t *testing.T) {
 	assert.Equal(t, "log-level", logLevelFlag.Name)
 	assert.Equal(t, "info", logLevelFlag.DefValue)
 
+	for _, name := range []string{"profile-cpu", "profile-mem"} {
+		profileFlag := cmd.RootCmd.PersistentFlags().Lookup(name)
+		if assert.NotNil(t, profileFlag, name) {
+			assert.Empty(t, profileFlag.DefValue)
+		}
+	}
+
 	versionFlag := cmd.RootCmd.Flags().Lookup("version")
 	assert.NotNil(t, versionFlag)
 	assert.Equal(t, "version", versionFlag.Name)
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/orchestrate"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// scheduleCmd groups the subcommands that inspect and pause the schedules of an
+// orchestration file run by 'driftwatcher serve'.
+type scheduleCmd struct {
+	Cmd *cobra.Command
+}
+
+// NewScheduleCmd creates the 'schedule' Cobra command and its subcommands.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration, used to locate the default report store.
+//
+// Returns:
+//
+//	A pointer to a scheduleCmd struct, which encapsulates the Cobra command.
+func NewScheduleCmd(ctx context.Context, cfg *config.Config) *scheduleCmd {
+	sc := &scheduleCmd{}
+	sc.Cmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "List, pause and resume the schedules run by serve",
+		Long: `Inspect the schedules declared in an orchestration file and the runs recorded for
+them in the report store, and pause or resume them. A schedule is paused in the
+report store, so a running 'driftwatcher serve' sharing the store skips it from its
+next due run on.
+
+For example:
+  driftwatcher schedule ls --plan accounts.yaml
+  driftwatcher schedule pause nightly --plan accounts.yaml
+  driftwatcher schedule runs nightly --plan accounts.yaml --limit 10
+`,
+	}
+	sc.Cmd.AddCommand(newScheduleLsCmd(ctx, cfg).Cmd)
+	sc.Cmd.AddCommand(newSchedulePauseCmd(ctx, cfg, true).Cmd)
+	sc.Cmd.AddCommand(newSchedulePauseCmd(ctx, cfg, false).Cmd)
+	sc.Cmd.AddCommand(newScheduleRunsCmd(ctx, cfg).Cmd)
+	return sc
+}
+
+// scheduleSource holds the flags that locate the schedules and their store for the
+// schedule subcommands.
+type scheduleSource struct {
+	storeFlags
+	Store    store.ScheduleStore
+	PlanPath string
+	cfg      *config.Config
+}
+
+func (s *scheduleSource) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.PlanPath, "plan", "", "Path to the YAML orchestration file declaring the schedules")
+	addStoreFlags(cmd, &s.storeFlags)
+}
+
+// open returns a scheduler of the schedules of the plan, without a run function as
+// it is only used to inspect and pause them, and a function closing its store.
+func (s *scheduleSource) open(ctx context.Context) (*schedule.Scheduler, func() error, error) {
+	if s.PlanPath == "" {
+		return nil, nil, fmt.Errorf("an orchestration file is required")
+	}
+	plan, err := orchestrate.Load(s.PlanPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeStore := func() error { return nil }
+	if s.Store == nil {
+		reportStore, err := openReportStore(ctx, s.cfg, s.storeFlags)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.Store, closeStore = reportStore, reportStore.Close
+	}
+	return schedule.New(scheduleEntries(plan), s.Store, nil), closeStore, nil
+}
+
+type scheduleLsCmd struct {
+	scheduleSource
+	Format string
+	ctx    context.Context
+	Cmd    *cobra.Command
+}
+
+func newScheduleLsCmd(ctx context.Context, cfg *config.Config) *scheduleLsCmd {
+	lc := &scheduleLsCmd{scheduleSource: scheduleSource{cfg: cfg}, ctx: ctx}
+	lc.Cmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the schedules with their next and last run",
+		Args:  cobra.NoArgs,
+		RunE:  lc.Run,
+	}
+	lc.addFlags(lc.Cmd)
+	lc.Cmd.Flags().StringVar(&lc.Format, "format", "table", "Output format (table, json)")
+	return lc
+}
+
+func (l *scheduleLsCmd) Run(cmd *cobra.Command, args []string) error {
+	if l.Format != "table" && l.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", l.Format)
+	}
+	ctx := l.ctx
+	if cmd.Context() != nil {
+		ctx = cmd.Context()
+	}
+	scheduler, closeStore, err := l.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	statuses, err := scheduler.Status(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if l.Format == "json" {
+		return writeScheduleJSON(out, statuses)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCRON\tPAUSED\tNEXT RUN\tLAST RUN\tSTATUS")
+	for _, status := range statuses {
+		nextRun := "-"
+		if !status.NextRun.IsZero() {
+			nextRun = status.NextRun.Format(time.RFC3339)
+		}
+		lastRun, lastStatus := "-", "-"
+		if status.LastRun != nil {
+			lastRun, lastStatus = status.LastRun.StartedAt.Format(time.RFC3339), status.LastRun.Status
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%s\n", status.Name, status.Cron, status.Paused, nextRun, lastRun, lastStatus)
+	}
+	return tw.Flush()
+}
+
+type schedulePauseCmd struct {
+	scheduleSource
+	paused bool
+	ctx    context.Context
+	Cmd    *cobra.Command
+}
+
+// newSchedulePauseCmd creates the 'pause' subcommand, or the 'resume' one when paused
+// is false.
+func newSchedulePauseCmd(ctx context.Context, cfg *config.Config, paused bool) *schedulePauseCmd {
+	pc := &schedulePauseCmd{scheduleSource: scheduleSource{cfg: cfg}, paused: paused, ctx: ctx}
+	pc.Cmd = &cobra.Command{
+		Use:   "pause <schedule>",
+		Short: "Stop a schedule from starting runs until it is resumed",
+		Args:  cobra.ExactArgs(1),
+		RunE:  pc.Run,
+	}
+	if !paused {
+		pc.Cmd.Use = "resume <schedule>"
+		pc.Cmd.Short = "Let a paused schedule start runs again"
+	}
+	pc.addFlags(pc.Cmd)
+	return pc
+}
+
+func (p *schedulePauseCmd) Run(cmd *cobra.Command, args []string) error {
+	ctx := p.ctx
+	if cmd.Context() != nil {
+		ctx = cmd.Context()
+	}
+	scheduler, closeStore, err := p.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if p.paused {
+		if err := scheduler.Pause(ctx, args[0]); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(cmd.OutOrStdout(), "Schedule %s paused\n", args[0])
+		return err
+	}
+	if err := scheduler.Resume(ctx, args[0]); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Schedule %s resumed\n", args[0])
+	return err
+}
+
+type scheduleRunsCmd struct {
+	scheduleSource
+	Limit  int
+	Format string
+	ctx    context.Context
+	Cmd    *cobra.Command
+}
+
+func newScheduleRunsCmd(ctx context.Context, cfg *config.Config) *scheduleRunsCmd {
+	rc := &scheduleRunsCmd{scheduleSource: scheduleSource{cfg: cfg}, ctx: ctx}
+	rc.Cmd = &cobra.Command{
+		Use:   "runs <schedule>",
+		Short: "List the recorded runs of a schedule, most recent first",
+		Long: `List the runs of a schedule recorded in the report store, most recent first. The
+reports of a run can be queried with 'driftwatcher history'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: rc.Run,
+	}
+	rc.addFlags(rc.Cmd)
+	rc.Cmd.Flags().IntVar(&rc.Limit, "limit", 20, "Maximum number of runs to show (0 for no limit)")
+	rc.Cmd.Flags().StringVar(&rc.Format, "format", "table", "Output format (table, json)")
+	return rc
+}
+
+func (r *scheduleRunsCmd) Run(cmd *cobra.Command, args []string) error {
+	if r.Format != "table" && r.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", r.Format)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	ctx := r.ctx
+	if cmd.Context() != nil {
+		ctx = cmd.Context()
+	}
+	scheduler, closeStore, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	runs, err := scheduler.Runs(ctx, args[0], r.Limit)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if r.Format == "json" {
+		if runs == nil {
+			runs = []store.ScheduledRun{}
+		}
+		return writeScheduleJSON(out, runs)
+	}
+	if len(runs) == 0 {
+		_, err := fmt.Fprintf(out, "No runs recorded for %s\n", args[0])
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tSTARTED\tDURATION\tSTATUS\tDRIFTED\tERROR")
+	for _, run := range runs {
+		duration := "-"
+		if !run.FinishedAt.IsZero() {
+			duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", run.RunId, run.StartedAt.Format(time.RFC3339), duration, run.Status, run.Drifted, dash(run.Error))
+	}
+	return tw.Flush()
+}
+
+func writeScheduleJSON(out io.Writer, value any) error {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedules: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(encoded))
+	return err
+}
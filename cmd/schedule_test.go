@@ -0,0 +1,98 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runScheduleCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	sc := cmd.NewScheduleCmd(context.Background(), &config.Config{})
+	var out bytes.Buffer
+	sc.Cmd.SilenceUsage = true
+	sc.Cmd.SilenceErrors = true
+	sc.Cmd.SetOut(&out)
+	sc.Cmd.SetArgs(args)
+	err := sc.Cmd.Execute()
+	return out.String(), err
+}
+
+func TestScheduleCmd_PauseResume(t *testing.T) {
+	planPath := writePlan(t, schedulePlan)
+	dsn := filepath.Join(t.TempDir(), "history.db")
+
+	out, err := runScheduleCmd(t, "pause", "nightly", "--plan", planPath, "--store-dsn", dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "Schedule nightly paused\n", out)
+
+	out, err = runScheduleCmd(t, "ls", "--plan", planPath, "--store-dsn", dsn)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"NAME", "CRON", "PAUSED", "NEXT", "RUN", "LAST", "RUN", "STATUS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"hourly", "@hourly", "false"}, strings.Fields(lines[1])[:3])
+	assert.Equal(t, []string{"nightly", "0", "2", "*", "*", "*", "true"}, strings.Fields(lines[2])[:7])
+
+	out, err = runScheduleCmd(t, "resume", "nightly", "--plan", planPath, "--store-dsn", dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "Schedule nightly resumed\n", out)
+
+	out, err = runScheduleCmd(t, "ls", "--plan", planPath, "--store-dsn", dsn, "--format", "json")
+	require.NoError(t, err)
+	var statuses []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &statuses))
+	require.Len(t, statuses, 2)
+	assert.Equal(t, false, statuses[1]["paused"])
+
+	_, err = runScheduleCmd(t, "pause", "weekly", "--plan", planPath, "--store-dsn", dsn)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown schedule: weekly")
+	_, err = runScheduleCmd(t, "pause", "nightly", "--store-dsn", dsn)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "an orchestration file is required")
+}
+
+func TestScheduleCmd_Runs(t *testing.T) {
+	planPath := writePlan(t, schedulePlan)
+	dsn := filepath.Join(t.TempDir(), "history.db")
+
+	out, err := runScheduleCmd(t, "runs", "hourly", "--plan", planPath, "--store-dsn", dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "No runs recorded for hourly\n", out)
+
+	reportStore, err := store.NewSQLStore(context.Background(), store.SQLiteDriver, dsn)
+	require.NoError(t, err)
+	startedAt := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	require.NoError(t, reportStore.RecordScheduledRun(context.Background(), store.ScheduledRun{
+		RunId: "run-1", Schedule: "hourly", StartedAt: startedAt, FinishedAt: startedAt.Add(90 * time.Second), Status: store.RunFailed, Drifted: 2, Error: "failed to assume role",
+	}))
+	require.NoError(t, reportStore.Close())
+
+	out, err = runScheduleCmd(t, "runs", "hourly", "--plan", planPath, "--store-dsn", dsn)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"run-1", "2026-03-02T03:00:00Z", "1m30s", "failed", "2", "failed", "to", "assume", "role"}, strings.Fields(lines[1]))
+
+	out, err = runScheduleCmd(t, "runs", "hourly", "--plan", planPath, "--store-dsn", dsn, "--format", "json")
+	require.NoError(t, err)
+	var runs []store.ScheduledRun
+	require.NoError(t, json.Unmarshal([]byte(out), &runs))
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run-1", runs[0].RunId)
+
+	_, err = runScheduleCmd(t, "runs", "hourly", "--plan", planPath, "--store-dsn", dsn, "--limit", "-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--limit must not be negative")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/tfschema"
+	"fmt"
+	"strings"
+)
+
+// noProviderSchema is the value of --provider-schema that disables the check of the
+// attributes against the provider schema.
+const noProviderSchema = "none"
+
+// providerSchemas returns the provider schemas the attributes are checked against:
+// those of --provider-schema, or else the bundled ones. It returns nil with
+// --provider-schema=none.
+func (d *detectCmd) providerSchemas() (*tfschema.Schemas, error) {
+	switch d.ProviderSchema {
+	case "":
+		return tfschema.Bundled(), nil
+	case noProviderSchema:
+		return nil, nil
+	}
+	return tfschema.Load(d.ctx, d.ProviderSchema)
+}
+
+// loadPolicies compiles the --policy files, if any.
+func (d *detectCmd) loadPolicies() (*policy.Engine, error) {
+	if len(d.Policies) == 0 {
+		return nil, nil
+	}
+	return policy.Load(d.ctx, d.Policies)
+}
+
+// schemaProblems checks the tracked attributes, and the attributes the policies
+// refer to, against the provider schema of the resource type, and returns a problem
+// per attribute the schema does not have. Aliases the provider accepts, such as the
+// security_group_ids of aws_instance, are accepted too. Only the references of policy
+// rules restricted to the resource type by input.resource.type are problems: those of
+// rules that apply to every type are returned as warnings, as they may be meant for
+// another type, and those of rules restricted to another type are not checked.
+// checked is false when there is no schema of the resource type.
+func (d *detectCmd) schemaProblems(schemas *tfschema.Schemas, policies *policy.Engine) (problems, warnings []string, checked bool) {
+	if schemas == nil {
+		return nil, nil, false
+	}
+	if _, ok := schemas.Resource(d.Resource); !ok {
+		return nil, nil, false
+	}
+	var supported []string
+	if capabilities, ok := providerCapabilities[d.Provider]; ok {
+		supported = capabilities.attributes(d.Resource)
+	}
+	check := func(attribute string) error {
+		if attributeSupported(attribute, supported) {
+			return nil
+		}
+		return schemas.Check(d.Resource, attribute)
+	}
+
+	for _, attribute := range d.AttributesToTrack {
+		if err := check(attribute); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if policies != nil {
+		for _, reference := range policies.Attributes() {
+			if reference.ResourceType != "" && reference.ResourceType != d.Resource {
+				continue
+			}
+			err := check(reference.Attribute)
+			switch {
+			case err == nil:
+			case reference.ResourceType == "":
+				warnings = append(warnings, fmt.Sprintf("%s: %v", reference.Location, err))
+			default:
+				problems = append(problems, fmt.Sprintf("%s: %v", reference.Location, err))
+			}
+		}
+	}
+	return problems, warnings, true
+}
+
+// checkSchema fails with the attributes that are not in the provider schema of the
+// resource type, so that a typo stops a detect run before any resource is scanned.
+// The attributes of policy rules that apply to every type are only logged.
+func (d *detectCmd) checkSchema(policies *policy.Engine) error {
+	schemas, err := d.providerSchemas()
+	if err != nil {
+		return err
+	}
+	problems, warnings, _ := d.schemaProblems(schemas, policies)
+	for _, warning := range warnings {
+		logging.FromContext(d.ctx).Warn("Policy attribute not in the provider schema", "resource_type", d.Resource, "problem", warning)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("unknown attributes (set --provider-schema=none to skip this check): %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/secrets"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// newSecretResolver creates the resolver of the secret references in settings, reading
+// aws-sm:// references with the credentials of the given AWS profile.
+func newSecretResolver(profile string, opts ...aws.Option) *secrets.Resolver {
+	resolver := secrets.NewResolver()
+	resolver.Register(secrets.SecretsManagerScheme, secrets.NewSecretsManager(func(ctx context.Context) (secrets.SecretsManagerAPI, error) {
+		awsConfig, err := aws.CheckAWSConfig(ctx, "", profile)
+		if err != nil {
+			return nil, err
+		}
+		sdkConfig, err := aws.LoadConfig(&awsConfig, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return secretsmanager.NewFromConfig(sdkConfig), nil
+	}))
+	return resolver
+}
@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/lock"
+	"drift-watcher/pkg/services/orchestrate"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/scanqueue"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/store"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// serveShutdownTimeout is how long requests in progress may take to complete once
+// the server is stopped.
+const serveShutdownTimeout = 10 * time.Second
+
+// scheduleStore persists the reports of scheduled runs together with the runs and
+// the paused schedules.
+type scheduleStore interface {
+	store.ReportStore
+	store.ScheduleStore
+}
+
+type serveCmd struct {
+	storeFlags
+	// NewProvider and NewStateManager are passed on to the scans of the targets, as
+	// for the orchestrate command.
+	NewProvider     func(target orchestrate.Target) (provider.ProviderI, error)
+	NewStateManager func() (statemanager.StateManagerI, error)
+	Store           scheduleStore
+	// Locker is the lock shared with the other servers running the schedules, if any.
+	Locker       lock.Locker
+	PlanPath     string
+	Listen       string
+	Concurrency  int
+	StateHeaders []string
+	StateRetries int
+	LockURI      string
+	LockTTL      time.Duration
+	AuthConfig   string
+	RateLimit    float64
+	RateBurst    int
+	MaxBodySize  int64
+	MaxRuns      int
+	ScanWorkers  int
+	ScanQueue    int
+	Dashboard    bool
+	ctx          context.Context
+	Cmd          *cobra.Command
+	cfg          *config.Config
+}
+
+// NewServeCmd creates and configures the 'serve' Cobra command.
+// This command runs the schedules of an orchestration file as a long-running server,
+// recording every run in the report store, and serves an HTTP API to list, pause,
+// resume and trigger the schedules.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to a serveCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewServeCmd(ctx context.Context, cfg *config.Config) *serveCmd {
+	sc := &serveCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	sc.Cmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Scan the targets of an orchestration file on cron schedules",
+		Long: `Run the schedules declared in an orchestration file until interrupted. A schedule
+names a cron expression and the targets it scans; the reports of every run are
+recorded in the report store together with the run, so they can be queried with
+'driftwatcher history' and 'driftwatcher schedule runs'.
+
+The server exposes an HTTP API:
+  GET  /healthz                            liveness check
+  GET  /api/v1/whoami                      the caller and its role
+  GET  /api/v1/schedules                   schedules with their next and last run
+  GET  /api/v1/schedules/{name}/runs       runs of a schedule, most recent first (?limit=N)
+  POST /api/v1/schedules/{name}/pause      stop a schedule from starting runs
+  POST /api/v1/schedules/{name}/resume     let a paused schedule start runs again
+  POST /api/v1/schedules/{name}/run        start a run now
+  POST /api/v1/scans                       scan an uploaded state file as a target of the plan
+  GET  /api/v1/scans/{id}                  status and reports of a scan
+  GET  /api/v1/scans/{id}/events           the reports of a scan as server-sent events, as they are produced
+  GET  /api/v1/runs/{id}/reports           reports of a run or scan (?target=NAME)
+  GET  /api/v1/dashboard                   latest drift per target and drift per run (?days=N, default 14)
+
+A scan is uploaded as a multipart form with the state file in its "state" part and
+the name of the target whose resources and credentials are used in its "target"
+field, which may be left out when the plan has a single target. The upload returns a
+scan id at once; the scan runs in the background on one of --scan-workers, and its
+reports are saved in the store under the scan id. Uploads are refused with 503 while
+--scan-queue scans wait for a worker.
+
+Unless --dashboard=false, a web dashboard is served at /dashboard/ (and / redirects
+to it): it shows the latest drift of every target, the drifted resources of every run
+over a period, and the drift details of the resources of a run.
+
+Without --auth-config every caller of the API may use it. With it, callers present an
+API key or an OIDC token as a bearer token, and are granted a role: viewer to read
+schedules and runs, operator to also trigger runs, admin to also pause and resume
+schedules.
+
+Each client, identified by its IP address, may make --rate-limit requests per second
+with bursts of --rate-burst; further requests get 429 Too Many Requests. Request
+bodies are limited to --max-body-size bytes. With --max-runs, runs triggered while as
+many runs are in progress get 503 Service Unavailable, so that a misbehaving client
+cannot exhaust the API quotas of the scanned accounts. Both responses carry a
+Retry-After header.
+
+Paused schedules are kept in the report store, so they stay paused across restarts
+and can be paused from another machine with 'driftwatcher schedule pause'.
+
+Several servers can run the same schedules for high availability when they share a
+lock with --lock, a DynamoDB table (dynamodb://<table>) or a directory on a shared
+file system (file://<dir>): each due run is then executed by a single server, so
+drift is not alerted on twice, and a schedule never runs on two servers at once.
+
+For example:
+  driftwatcher serve --plan accounts.yaml --listen :8080 --store-driver postgres --store-dsn postgres://drift@db/drift
+  driftwatcher serve --plan accounts.yaml --lock "dynamodb://driftwatcher-locks?region=us-east-1"
+  driftwatcher serve --plan accounts.yaml --listen :8080 --auth-config auth.yaml
+`,
+		RunE: sc.Run,
+	}
+
+	sc.Cmd.Flags().StringVar(&sc.PlanPath, "plan", "", "Path to the YAML orchestration file declaring the targets and schedules")
+	sc.Cmd.Flags().StringVar(&sc.Listen, "listen", "127.0.0.1:8080", "Address the HTTP API listens on")
+	sc.Cmd.Flags().IntVar(&sc.Concurrency, "concurrency", 0, "Number of targets of a run scanned at the same time (default: the plan's concurrency)")
+	sc.Cmd.Flags().StringArrayVar(&sc.StateHeaders, "state-header", nil, "Header sent when fetching a remote state URI, as 'Name: value' (repeatable)")
+	sc.Cmd.Flags().IntVar(&sc.StateRetries, "state-retries", 3, "Number of times a failed remote state download is retried")
+	sc.Cmd.Flags().StringVar(&sc.LockURI, "lock", "", "Lock shared by the servers running the schedules, as dynamodb://<table>[?region=<region>] or file://<dir>")
+	sc.Cmd.Flags().DurationVar(&sc.LockTTL, "lock-ttl", time.Minute, "How long a lock is held without being renewed before another server may take it over")
+	sc.Cmd.Flags().StringVar(&sc.AuthConfig, "auth-config", "", "YAML file declaring the API keys and OIDC issuer callers of the API authenticate with, and their roles")
+	sc.Cmd.Flags().Float64Var(&sc.RateLimit, "rate-limit", 5, "Requests per second each client may make to the HTTP API on average, 0 for no limit")
+	sc.Cmd.Flags().IntVar(&sc.RateBurst, "rate-burst", 20, "Requests a client may make to the HTTP API in a burst over --rate-limit")
+	sc.Cmd.Flags().Int64Var(&sc.MaxBodySize, "max-body-size", 32<<20, "Largest request body the HTTP API accepts, in bytes")
+	sc.Cmd.Flags().IntVar(&sc.MaxRuns, "max-runs", 0, "Runs in progress at which triggering another run is refused, 0 for no limit")
+	sc.Cmd.Flags().IntVar(&sc.ScanWorkers, "scan-workers", 2, "Number of uploaded state files scanned at the same time")
+	sc.Cmd.Flags().IntVar(&sc.ScanQueue, "scan-queue", 10, "Uploaded state files waiting to be scanned at which further uploads are refused")
+	sc.Cmd.Flags().BoolVar(&sc.Dashboard, "dashboard", true, "Serve the web dashboard at /dashboard/")
+	addStoreFlags(sc.Cmd, &sc.storeFlags)
+
+	return sc
+}
+
+func (s *serveCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		s.ctx = ctx
+	}
+	plan, err := s.load()
+	if err != nil {
+		return err
+	}
+	if s.Locker == nil && s.LockURI != "" {
+		if s.Locker, err = lock.New(s.LockURI, lock.DefaultOwner()); err != nil {
+			return err
+		}
+	}
+	if s.Store == nil {
+		reportStore, err := openReportStore(s.ctx, s.cfg, s.storeFlags)
+		if err != nil {
+			return err
+		}
+		defer reportStore.Close()
+		s.Store = reportStore
+	}
+	run, err := s.runFunc(plan)
+	if err != nil {
+		return err
+	}
+	var opts []schedule.Option
+	if s.Locker != nil {
+		opts = append(opts, schedule.WithLocker(s.Locker, s.LockTTL))
+	}
+	if s.MaxRuns > 0 {
+		opts = append(opts, schedule.WithMaxRuns(s.MaxRuns))
+	}
+	scheduler := schedule.New(scheduleEntries(plan), s.Store, run, opts...)
+
+	serverOpts, err := s.serverOptions()
+	if err != nil {
+		return err
+	}
+	scan, err := s.scanFunc(plan)
+	if err != nil {
+		return err
+	}
+	scans := scanqueue.New(scanTargetNames(plan), scan, scanqueue.WithWorkers(s.ScanWorkers), scanqueue.WithDepth(s.ScanQueue))
+	serverOpts = append(serverOpts, server.WithScans(scans))
+
+	listener, err := net.Listen("tcp", s.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Listen, err)
+	}
+	if s.AuthConfig == "" && !isLoopback(listener.Addr()) {
+		logging.FromContext(s.ctx).Warn("The HTTP API is reachable from other hosts without authentication, pass --auth-config to require it", "address", listener.Addr().String())
+	}
+	httpServer := &http.Server{
+		Handler:           server.New(scheduler, serverOpts...),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return s.ctx },
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+	logging.FromContext(s.ctx).Info("Serving drift schedules", "address", listener.Addr().String(), "schedules", len(plan.Schedules))
+
+	schedulerCtx, stop := context.WithCancel(s.ctx)
+	defer stop()
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Start(schedulerCtx)
+		close(stopped)
+	}()
+	scansStopped := make(chan struct{})
+	go func() {
+		scans.Start(schedulerCtx)
+		close(scansStopped)
+	}()
+
+	select {
+	case <-s.ctx.Done():
+	case err = <-serveErr:
+	}
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), serveShutdownTimeout)
+	defer cancel()
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+		logging.FromContext(s.ctx).Warn("Failed to shut down the HTTP API", "error", shutdownErr)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP API stopped: %w", err)
+	}
+	<-stopped
+	<-scansStopped
+	return nil
+}
+
+// load reads the plan and checks that it declares schedules.
+func (s *serveCmd) load() (*orchestrate.Plan, error) {
+	if s.PlanPath == "" {
+		return nil, fmt.Errorf("an orchestration file is required")
+	}
+	if s.Concurrency < 0 {
+		return nil, fmt.Errorf("--concurrency must not be negative")
+	}
+	if s.LockTTL <= 0 {
+		return nil, fmt.Errorf("--lock-ttl must be positive")
+	}
+	if s.RateLimit < 0 || s.RateBurst < 1 {
+		return nil, fmt.Errorf("--rate-limit must not be negative and --rate-burst must be positive")
+	}
+	if s.MaxBodySize <= 0 {
+		return nil, fmt.Errorf("--max-body-size must be positive")
+	}
+	if s.MaxRuns < 0 {
+		return nil, fmt.Errorf("--max-runs must not be negative")
+	}
+	if s.ScanWorkers < 1 || s.ScanQueue < 1 {
+		return nil, fmt.Errorf("--scan-workers and --scan-queue must be positive")
+	}
+	plan, err := orchestrate.Load(s.PlanPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Schedules) == 0 {
+		return nil, fmt.Errorf("%s: no schedules declared", s.PlanPath)
+	}
+	return plan, nil
+}
+
+// serverOptions returns the options of the HTTP API: its limits, the reports of the
+// store and the dashboard, and the authenticators declared in --auth-config, whose API
+// keys may be secret references.
+func (s *serveCmd) serverOptions() ([]server.Option, error) {
+	opts := []server.Option{server.WithMaxBodySize(s.MaxBodySize), server.WithReports(s.Store)}
+	if s.Dashboard {
+		opts = append(opts, server.WithDashboard())
+	}
+	if s.RateLimit > 0 {
+		opts = append(opts, server.WithRateLimit(s.RateLimit, s.RateBurst))
+	}
+	if s.AuthConfig == "" {
+		return opts, nil
+	}
+	auth, err := server.LoadAuthConfig(s.AuthConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var authenticators []server.Authenticator
+	if len(auth.APIKeys) > 0 {
+		resolver := newSecretResolver("")
+		for i, key := range auth.APIKeys {
+			if auth.APIKeys[i].Key, err = resolver.Resolve(s.ctx, key.Key); err != nil {
+				return nil, fmt.Errorf("api key %s: %w", key.Name, err)
+			}
+		}
+		authenticators = append(authenticators, server.NewAPIKeyAuthenticator(auth.APIKeys))
+	}
+	if auth.OIDC != nil {
+		authenticators = append(authenticators, server.NewOIDCAuthenticator(*auth.OIDC, nil))
+	}
+	return append(opts, server.WithAuthenticators(authenticators...)), nil
+}
+
+// isLoopback reports whether addr only accepts connections from the local host.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// newScanner returns the scanner of the targets of plan, which scans them as the
+// orchestrate command does, and the redactor of their reports.
+func (s *serveCmd) newScanner() (*orchestrateCmd, *redact.Redactor, error) {
+	scanner := &orchestrateCmd{
+		NewProvider:     s.NewProvider,
+		NewStateManager: s.NewStateManager,
+		StateHeaders:    s.StateHeaders,
+		StateRetries:    s.StateRetries,
+		ctx:             s.ctx,
+	}
+	if err := scanner.setDefaults(); err != nil {
+		return nil, nil, err
+	}
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	if err != nil {
+		return nil, nil, err
+	}
+	return scanner, redactor, nil
+}
+
+// runFunc returns the function running a schedule of plan: its targets are scanned
+// as by the orchestrate command and their reports saved in the store.
+func (s *serveCmd) runFunc(plan *orchestrate.Plan) (schedule.RunFunc, error) {
+	scanner, redactor, err := s.newScanner()
+	if err != nil {
+		return nil, err
+	}
+	concurrency := plan.Concurrency
+	if s.Concurrency > 0 {
+		concurrency = s.Concurrency
+	}
+
+	return func(ctx context.Context, name string, runId string) (int, error) {
+		index := slices.IndexFunc(plan.Schedules, func(s orchestrate.Schedule) bool { return s.Name == name })
+		targets := plan.ScheduleTargets(plan.Schedules[index])
+
+		run := &driftchecker.RunMetadata{RunId: runId, StartedAt: time.Now()}
+		ctx = driftchecker.NewRunContext(ctx, run)
+		if err := s.Store.StartRun(ctx, store.RunMetadata{RunId: runId, StartedAt: run.StartedAt, StatePath: s.PlanPath}); err != nil {
+			return 0, err
+		}
+		result := scanner.scanTargets(ctx, targets, concurrency, redactor)
+
+		drifted := 0
+		for _, target := range targets {
+			targetResult := result.Targets[target.Name]
+			drifted += targetResult.Drifted
+			for _, report := range targetResult.Reports {
+				if err := s.Store.SaveReport(context.WithoutCancel(ctx), runId, report); err != nil {
+					return drifted, err
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return drifted, fmt.Errorf("drift detection interrupted: %w", err)
+		}
+		if failed := result.Failed(); len(failed) > 0 {
+			return drifted, fmt.Errorf("drift detection failed for %d of %d targets: %s", len(failed), len(targets), strings.Join(failed, ", "))
+		}
+		return drifted, nil
+	}, nil
+}
+
+// scanFunc returns the function scanning an uploaded state file as a target of plan,
+// whose reports are saved in the store under the id of the scan.
+func (s *serveCmd) scanFunc(plan *orchestrate.Plan) (scanqueue.RunFunc, error) {
+	scanner, redactor, err := s.newScanner()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, job scanqueue.Job, report func(*driftchecker.DriftReport)) error {
+		index := slices.IndexFunc(plan.Targets, func(t orchestrate.Target) bool { return t.Name == job.Target })
+		target := plan.Targets[index]
+		target.State = job.StatePath
+
+		run := &driftchecker.RunMetadata{RunId: job.Id, StartedAt: time.Now()}
+		ctx = driftchecker.NewRunContext(ctx, run)
+		if err := s.Store.StartRun(ctx, store.RunMetadata{RunId: job.Id, StartedAt: run.StartedAt, StatePath: job.Target}); err != nil {
+			return err
+		}
+		writer := reporter.NewStoreReporter(s.Store, job.Id, jobReporter(report))
+		return scanner.scanTarget(ctx, target, redactor, writer)
+	}, nil
+}
+
+// jobReporter hands the reports of an uploaded state scan to its job, for the clients
+// following it.
+type jobReporter func(*driftchecker.DriftReport)
+
+func (r jobReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	r(report)
+	return nil
+}
+
+// scanTargetNames returns the names of the targets of plan.
+func scanTargetNames(plan *orchestrate.Plan) []string {
+	names := make([]string, 0, len(plan.Targets))
+	for _, target := range plan.Targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// scheduleEntries returns the schedules of plan, whose cron expressions were
+// validated when the plan was loaded.
+func scheduleEntries(plan *orchestrate.Plan) []schedule.Entry {
+	entries := make([]schedule.Entry, 0, len(plan.Schedules))
+	for _, s := range plan.Schedules {
+		cron, _ := schedule.ParseCron(s.Cron)
+		entries = append(entries, schedule.Entry{Name: s.Name, Cron: cron})
+	}
+	return entries
+}
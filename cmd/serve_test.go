@@ -0,0 +1,264 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/orchestrate"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schedulePlan = `
+targets:
+  - name: prod
+    state: prod.tfstate
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+  - name: staging
+    state: staging.tfstate
+    resources:
+      - type: aws_instance
+        attributes: [instance_type]
+schedules:
+  - name: hourly
+    cron: "@hourly"
+    targets: [prod]
+  - name: nightly
+    cron: "0 2 * * *"
+`
+
+func writePlan(t *testing.T, plan string) string {
+	t.Helper()
+	planPath := filepath.Join(t.TempDir(), "plan.yaml")
+	require.NoError(t, os.WriteFile(planPath, []byte(plan), 0644))
+	return planPath
+}
+
+// freeAddress returns a local address nothing listens on.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServeCmd_Run_TriggerRecordsRun(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	address := freeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc := cmd.NewServeCmd(ctx, &config.Config{})
+	sc.NewStateManager = func() (statemanager.StateManagerI, error) {
+		mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+		mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+			{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1", "instance_type": "t2.micro"}}}},
+		}, nil)
+		return mockStateManager, nil
+	}
+	sc.NewProvider = func(orchestrate.Target) (provider.ProviderI, error) {
+		mockResource := &providerfakes.FakeInfrastructureResourceI{}
+		mockResource.ResourceTypeReturns("aws_instance")
+		mockResource.AttributeValueReturns("t3.large", nil)
+		mockProvider := &providerfakes.FakeProviderI{}
+		mockProvider.InfrastructreMetadataReturns(mockResource, nil)
+		return mockProvider, nil
+	}
+	sc.Cmd.SetArgs([]string{"--plan", writePlan(t, schedulePlan), "--listen", address, "--store-dsn", dsn, "--lock", "file://" + t.TempDir()})
+	done := make(chan error, 1)
+	go func() { done <- sc.Cmd.ExecuteContext(ctx) }()
+
+	base := "http://" + address + "/api/v1/schedules"
+	require.Eventually(t, func() bool {
+		resp, err := http.Post(base+"/hourly/run", "application/json", nil)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusAccepted
+	}, 5*time.Second, 20*time.Millisecond)
+
+	var runs []store.ScheduledRun
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(base + "/hourly/runs")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return json.NewDecoder(resp.Body).Decode(&runs) == nil && len(runs) == 1 && runs[0].Status != store.RunRunning
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, store.RunOK, runs[0].Status)
+	assert.Equal(t, 1, runs[0].Drifted)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not stop")
+	}
+
+	// the reports of the run are recorded under its run id
+	reportStore, err := store.NewSQLStore(context.Background(), store.SQLiteDriver, dsn)
+	require.NoError(t, err)
+	defer reportStore.Close()
+	reports, err := reportStore.History(context.Background(), store.HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, runs[0].RunId, reports[0].RunId)
+}
+
+func TestServeCmd_Run_UploadedScan(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	address := freeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var parsed []string
+	sc := cmd.NewServeCmd(ctx, &config.Config{})
+	sc.NewStateManager = func() (statemanager.StateManagerI, error) {
+		mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+		mockStateManager.ParseStateFileStub = func(ctx context.Context, statePath string) (statemanager.StateContent, error) {
+			content, err := os.ReadFile(statePath)
+			mu.Lock()
+			defer mu.Unlock()
+			parsed = append(parsed, string(content))
+			return statemanager.StateContent{}, err
+		}
+		mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+			{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1", "instance_type": "t2.micro"}}}},
+		}, nil)
+		return mockStateManager, nil
+	}
+	sc.NewProvider = func(target orchestrate.Target) (provider.ProviderI, error) {
+		assert.Equal(t, "staging", target.Name)
+		mockResource := &providerfakes.FakeInfrastructureResourceI{}
+		mockResource.ResourceTypeReturns("aws_instance")
+		mockResource.AttributeValueReturns("t3.large", nil)
+		mockProvider := &providerfakes.FakeProviderI{}
+		mockProvider.InfrastructreMetadataReturns(mockResource, nil)
+		return mockProvider, nil
+	}
+	sc.Cmd.SetArgs([]string{"--plan", writePlan(t, schedulePlan), "--listen", address, "--store-dsn", dsn})
+	done := make(chan error, 1)
+	go func() { done <- sc.Cmd.ExecuteContext(ctx) }()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("target", "staging"))
+	part, err := form.CreateFormFile("state", "terraform.tfstate")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(`{"version": 4, "serial": 12}`))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	var job struct {
+		Id      string `json:"id"`
+		Status  string `json:"status"`
+		Drifted int    `json:"drifted"`
+	}
+	require.Eventually(t, func() bool {
+		resp, err := http.Post("http://"+address+"/api/v1/scans", form.FormDataContentType(), bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusAccepted && json.NewDecoder(resp.Body).Decode(&job) == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + address + "/api/v1/scans/" + job.Id)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return json.NewDecoder(resp.Body).Decode(&job) == nil && job.Status == "ok"
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 1, job.Drifted)
+	mu.Lock()
+	assert.Equal(t, []string{`{"version": 4, "serial": 12}`}, parsed, "the uploaded state file is scanned")
+	mu.Unlock()
+
+	// the dashboard shows the scan as the latest run of its target
+	resp, err := http.Get("http://" + address + "/api/v1/dashboard")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var dashboard struct {
+		Targets []struct {
+			RunId   string `json:"run_id"`
+			Target  string `json:"target"`
+			Drifted int    `json:"drifted"`
+		} `json:"targets"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dashboard))
+	require.Len(t, dashboard.Targets, 1)
+	assert.Equal(t, job.Id, dashboard.Targets[0].RunId)
+	assert.Equal(t, "staging", dashboard.Targets[0].Target)
+	assert.Equal(t, 1, dashboard.Targets[0].Drifted)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not stop")
+	}
+
+	// the reports of the scan are recorded under its id
+	reportStore, err := store.NewSQLStore(context.Background(), store.SQLiteDriver, dsn)
+	require.NoError(t, err)
+	defer reportStore.Close()
+	reports, err := reportStore.History(context.Background(), store.HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, job.Id, reports[0].RunId)
+}
+
+func TestServeCmd_Run_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"no plan", nil, "an orchestration file is required"},
+		{"no schedules", []string{"--plan", writePlan(t, "targets:\n  - name: a\n    state: a.tfstate\n    resources:\n      - type: aws_instance\n        attributes: [ami]\n")}, "no schedules declared"},
+		{"negative concurrency", []string{"--plan", writePlan(t, schedulePlan), "--concurrency", "-1"}, "--concurrency must not be negative"},
+		{"zero lock ttl", []string{"--plan", writePlan(t, schedulePlan), "--lock-ttl", "0s"}, "--lock-ttl must be positive"},
+		{"unsupported lock", []string{"--plan", writePlan(t, schedulePlan), "--lock", "redis://localhost"}, "unsupported lock URI redis://localhost"},
+		{"negative rate limit", []string{"--plan", writePlan(t, schedulePlan), "--rate-limit", "-1"}, "--rate-limit must not be negative"},
+		{"zero body size", []string{"--plan", writePlan(t, schedulePlan), "--max-body-size", "0"}, "--max-body-size must be positive"},
+		{"negative max runs", []string{"--plan", writePlan(t, schedulePlan), "--max-runs", "-1"}, "--max-runs must not be negative"},
+		{"zero scan workers", []string{"--plan", writePlan(t, schedulePlan), "--scan-workers", "0"}, "--scan-workers and --scan-queue must be positive"},
+		{"empty auth config", []string{"--plan", writePlan(t, schedulePlan), "--auth-config", writePlan(t, "api_keys: []\n")}, "no api_keys or oidc declared"},
+		{"unresolved api key", []string{"--plan", writePlan(t, schedulePlan), "--auth-config", writePlan(t, "api_keys:\n  - name: ci\n    key: env://DRIFT_TEST_UNSET_API_KEY\n    role: operator\n")}, "api key ci: failed to resolve secret env://DRIFT_TEST_UNSET_API_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := cmd.NewServeCmd(context.Background(), &config.Config{})
+			sc.Cmd.SilenceUsage = true
+			sc.Cmd.SetArgs(tt.args)
+			err := sc.Cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"drift-watcher/config"
+	"drift-watcher/pkg/logging"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to a flag's name to form the environment variable that can
+// set it, e.g. --output-file can be set with DRIFT_OUTPUT_FILE.
+const envPrefix = "DRIFT_"
+
+// EnvVarName returns the environment variable that overrides the flag with the given name.
+func EnvVarName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// resolveSettings fills every flag the user did not set explicitly, first from its
+// DRIFT_* environment variable, then from the project file and then from the selected
+// config profile, giving the precedence flag > environment > project file > config
+// file > default. Secret references in the credential settings are resolved last,
+// wherever they were set.
+func (d *detectCmd) resolveSettings(cmd *cobra.Command) error {
+	explicit := map[string]bool{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var envErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if explicit[f.Name] || envErr != nil {
+			return
+		}
+		value, ok := os.LookupEnv(EnvVarName(f.Name))
+		if !ok {
+			return
+		}
+		if err := setFlagValue(f, value); err != nil {
+			envErr = fmt.Errorf("invalid value %q for %s: %w", value, EnvVarName(f.Name), err)
+			return
+		}
+		explicit[f.Name] = true
+	})
+	if envErr != nil {
+		return envErr
+	}
+
+	if err := d.applyProject(cmd, explicit); err != nil {
+		return err
+	}
+	if err := d.applyProfile(cmd, explicit); err != nil {
+		return err
+	}
+	if err := d.expandPaths(); err != nil {
+		return err
+	}
+	return d.resolveSecrets()
+}
+
+// resolveSecrets replaces the secret references, such as env://SLACK_WEBHOOK_URL or
+// aws-sm://prod/driftwatcher#pagerduty_key, in the settings holding credentials with
+// the secrets they point to.
+func (d *detectCmd) resolveSecrets() error {
+	resolver := d.Secrets
+	if resolver == nil {
+		resolver = newSecretResolver(d.Profile, d.awsOptions()...)
+	}
+	settings := []struct {
+		flag  string
+		value *string
+	}{
+		{"notify-url", &d.NotifyURL},
+		{"pr-token", &d.PRToken},
+		{"alert-key", &d.AlertKey},
+	}
+	for _, setting := range settings {
+		secret, err := resolver.Resolve(d.ctx, *setting.value)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", setting.flag, err)
+		}
+		*setting.value = secret
+	}
+	for i, header := range d.StateHeaders {
+		// only the value of a header can be a reference, e.g. 'Authorization: env://TOKEN'
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || !resolver.IsReference(strings.TrimSpace(value)) {
+			continue
+		}
+		secret, err := resolver.Resolve(d.ctx, strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("--state-header %s: %w", name, err)
+		}
+		d.StateHeaders[i] = name + ": " + secret
+	}
+	return nil
+}
+
+// expandPaths resolves ~ and %USERPROFILE% in the path settings, wherever they were
+// set, as neither is expanded by the Windows command prompt or in files and DRIFT_*
+// environment variables.
+func (d *detectCmd) expandPaths() error {
+	paths := []*string{&d.TfConfigPath, &d.OutputPath, &d.StateCommits, &d.OutputTemplate, &d.EquivalenceFile, &d.IgnoreFile, &d.CacheDir, &d.ChangedOnlyCache, &d.AnsibleFactsDir, &d.AnsibleInventory}
+	for i := range d.Policies {
+		paths = append(paths, &d.Policies[i])
+	}
+	for _, path := range paths {
+		expanded, err := config.ExpandPath(*path)
+		if err != nil {
+			return err
+		}
+		*path = expanded
+	}
+	return nil
+}
+
+// applyProject fills every flag that is not in explicit from the project file set
+// with --project-file, or else the driftwatcher.yaml found in the working directory
+// or one of its parents. The flags it sets are added to explicit.
+func (d *detectCmd) applyProject(cmd *cobra.Command, explicit map[string]bool) error {
+	path, err := config.ExpandPath(d.ProjectFile)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if path, err = config.FindProjectFile(wd); err != nil || path == "" {
+			return err
+		}
+	}
+
+	project, err := config.LoadProject(path)
+	if err != nil {
+		return err
+	}
+	for name, value := range project.Flags() {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || value == "" || explicit[name] {
+			continue
+		}
+		if err := setFlagValue(f, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s in project file %s: %w", value, name, path, err)
+		}
+		explicit[name] = true
+	}
+
+	logging.FromContext(d.ctx).Debug("Applied project file", "path", path)
+	return nil
+}
+
+// applyProfile fills every flag that is not in explicit from the selected config
+// profile, so a named scan target can be run without repeating its flags.
+// A missing profile is only an error when it was requested explicitly.
+func (d *detectCmd) applyProfile(cmd *cobra.Command, explicit map[string]bool) error {
+	if d.cfg == nil {
+		return nil
+	}
+
+	found, err := d.cfg.Profile.Load()
+	if err != nil {
+		return err
+	}
+	if !found {
+		if explicit["profile"] {
+			return fmt.Errorf("profile %s not found in config file", d.cfg.Profile.ProfileName)
+		}
+		return nil
+	}
+
+	settings := d.cfg.Profile.Settings
+	values := map[string]string{
+		"configfile":    settings.StatePath,
+		"state-manager": settings.StateManager,
+		"provider":      settings.Provider,
+		"resource":      settings.Resource,
+		"attributes":    strings.Join(settings.Attributes, ","),
+		"output-file":   settings.OutputFile,
+		"format":        settings.Format,
+		"awsprofile":    settings.AWSProfile,
+		"notify-url":    settings.NotifyURL,
+		"alert-key":     settings.AlertKey,
+		"pr-token":      settings.PRToken,
+	}
+	for name, value := range values {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || value == "" || explicit[name] {
+			continue
+		}
+		if err := setFlagValue(f, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s in profile %s: %w", value, name, d.cfg.Profile.ProfileName, err)
+		}
+	}
+
+	logging.FromContext(d.ctx).Debug("Applied config profile", "profile", d.cfg.Profile.ProfileName)
+	return nil
+}
+
+// flagAliases maps alternative flag names to the flag they set, e.g. --output-format
+// to --format.
+func flagAliases(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "output-format":
+		name = "format"
+	case "localstack-url":
+		name = "endpoint-url"
+	case "localstackregion":
+		name = "aws-region"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// setFlagValue sets f without marking it as changed on the command line. Slice flags
+// are replaced rather than appended to.
+func setFlagValue(f *pflag.Flag, value string) error {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return slice.Replace(items)
+	}
+	return f.Value.Set(value)
+}
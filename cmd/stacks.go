@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager/discover"
+	"drift-watcher/pkg/services/statemanager/terragrunt"
+	"fmt"
+	"strings"
+)
+
+// stackWriter labels every report with the stack it belongs to before
+// passing it on. It implements neither reporter.Beginner nor reporter.Flusher, so the
+// reports of all stacks make up a single run, begun before the first stack is checked
+// and flushed once every stack has been.
+type stackWriter struct {
+	stack string
+	out   reporter.OutputWriter
+}
+
+func (s *stackWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	report.Stack = s.stack
+	return s.out.WriteReport(ctx, report)
+}
+
+// stack is a state checked as part of a multi-state scan and the path its reports are
+// labelled with.
+type stack struct {
+	path      string
+	statePath string
+}
+
+// discoverStacks returns the states under the configured path: the stacks of a
+// Terragrunt project, or the Terraform roots found by --state-manager discover. Roots
+// whose state could not be located are returned in failed.
+func (d *detectCmd) discoverStacks(ctx context.Context) (stacks []stack, failed []string, err error) {
+	if d.StateManagerType == "discover" {
+		roots, err := discover.Discover(ctx, d.TfConfigPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to discover terraform roots: %w", err)
+		}
+		if len(roots) == 0 {
+			return nil, nil, fmt.Errorf("failed to discover terraform roots: none found under %s", d.TfConfigPath)
+		}
+		for _, root := range roots {
+			if root.Error != "" {
+				logging.FromContext(ctx).Error("Skipping terraform root whose state could not be located", "root", root.Path, "error", root.Error)
+				failed = append(failed, root.Path)
+				continue
+			}
+			stacks = append(stacks, stack{path: root.Path, statePath: root.StatePath})
+		}
+		logging.FromContext(ctx).Info("Discovered terraform roots", "root", d.TfConfigPath, "count", len(roots))
+		return stacks, failed, nil
+	}
+
+	found, err := terragrunt.Discover(ctx, d.TfConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover terragrunt stacks: %w", err)
+	}
+	for _, s := range found {
+		stacks = append(stacks, stack{path: s.Path, statePath: s.StatePath})
+	}
+	logging.FromContext(ctx).Info("Discovered terragrunt stacks", "root", d.TfConfigPath, "count", len(stacks))
+	return stacks, nil, nil
+}
+
+// detectStacks runs drift detection for every stack of the Terragrunt project, or every
+// Terraform root, under the configured path. A stack that fails is logged and the
+// remaining stacks are still checked; the error returned lists the stacks that failed.
+func (d *detectCmd) detectStacks(ctx context.Context, outputWriter reporter.OutputWriter, opts []driftwatcher.DetectionOption) error {
+	stacks, failed, err := d.discoverStacks(ctx)
+	if err != nil {
+		return err
+	}
+	total := len(stacks) + len(failed)
+
+	if err := reporter.BeginWriter(ctx, outputWriter, driftchecker.RunFromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to begin reports: %w", err)
+	}
+
+	for _, stack := range stacks {
+		if ctx.Err() != nil {
+			break
+		}
+		logging.FromContext(ctx).Info("Checking stack", "stack", stack.path, "state_path", stack.statePath)
+		writer := &stackWriter{stack: stack.path, out: outputWriter}
+		err := driftwatcher.RunDriftDetection(ctx, stack.statePath, d.Resource, d.AttributesToTrack, d.StateManager, d.PlatformProvider, d.DriftChecker, writer, opts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				// the partial summary has been written, flush it with the other stacks
+				if flushErr := reporter.FlushWriter(context.WithoutCancel(ctx), outputWriter); flushErr != nil {
+					logging.FromContext(ctx).Error("Failed to flush reporter", "error", flushErr)
+				}
+				return err
+			}
+			logging.FromContext(ctx).Error("Drift detection failed for stack", "stack", stack.path, "error", err)
+			failed = append(failed, stack.path)
+		}
+	}
+
+	if err := reporter.FlushWriter(context.WithoutCancel(ctx), outputWriter); err != nil {
+		return fmt.Errorf("failed to flush reports: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("drift detection interrupted: %w", err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("drift detection failed for %d of %d stacks: %s", len(failed), total, strings.Join(failed, ", "))
+	}
+	return nil
+}
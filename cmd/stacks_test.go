@@ -0,0 +1,120 @@
+package cmd_test
+
+import (
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// terragruntProject creates a project with a prod/vpc and a prod/web stack, each with a
+// local backend.
+func terragruntProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, stack := range []string{"prod/vpc", "prod/web"} {
+		dir := filepath.Join(root, stack)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte(`
+remote_state {
+  backend = "local"
+  config = {
+    path = "terraform.tfstate"
+  }
+}
+`), 0644))
+	}
+	return root
+}
+
+func newStackDetectCmd(t *testing.T, root string) (*statemanagerfakes.FakeStateManagerI, *flushingWriter, func() error) {
+	t.Helper()
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockProvider := &providerfakes.FakeProviderI{}
+	writer := &flushingWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockProvider
+	dc.DriftChecker = &fixedChecker{}
+	dc.Reporter = writer
+	dc.Cmd.SetArgs([]string{"--configfile", root, "--state-manager", "terragrunt"})
+	return mockStateManager, writer, dc.Cmd.Execute
+}
+
+// fixedChecker returns a fresh drifted report for every resource.
+type fixedChecker struct{}
+
+func (fixedChecker) CompareStates(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []string) (*driftchecker.DriftReport, error) {
+	return reporter.CreateDummyDriftReport(true), nil
+}
+
+// flushingWriter records reports and how often it was flushed.
+type flushingWriter struct {
+	reports []*driftchecker.DriftReport
+	flushes int
+}
+
+func (f *flushingWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	f.reports = append(f.reports, report)
+	return nil
+}
+
+func (f *flushingWriter) Flush(ctx context.Context) error {
+	f.flushes++
+	return nil
+}
+
+func TestDetectCmd_Run_TerragruntStacks(t *testing.T) {
+	root := terragruntProject(t)
+	mockStateManager, writer, run := newStackDetectCmd(t, root)
+
+	require.NoError(t, run())
+
+	require.Equal(t, 2, mockStateManager.ParseStateFileCallCount())
+	_, first := mockStateManager.ParseStateFileArgsForCall(0)
+	_, second := mockStateManager.ParseStateFileArgsForCall(1)
+	assert.Equal(t, filepath.Join(root, "prod", "vpc", "terraform.tfstate"), first)
+	assert.Equal(t, filepath.Join(root, "prod", "web", "terraform.tfstate"), second)
+
+	require.Len(t, writer.reports, 2)
+	assert.Equal(t, "prod/vpc", writer.reports[0].Stack)
+	assert.Equal(t, "prod/web", writer.reports[1].Stack)
+	assert.Equal(t, 1, writer.flushes, "reports of every stack are flushed together")
+}
+
+func TestDetectCmd_Run_TerragruntStackFails(t *testing.T) {
+	root := terragruntProject(t)
+	mockStateManager, writer, run := newStackDetectCmd(t, root)
+	mockStateManager.ParseStateFileReturnsOnCall(0, statemanager.StateContent{}, errors.New("access denied"))
+
+	err := run()
+	assert.EqualError(t, err, "drift detection failed for 1 of 2 stacks: prod/vpc")
+
+	require.Len(t, writer.reports, 1, "the remaining stacks are still checked")
+	assert.Equal(t, "prod/web", writer.reports[0].Stack)
+}
+
+func TestDetectCmd_Run_TerragruntNoStacks(t *testing.T) {
+	_, _, run := newStackDetectCmd(t, t.TempDir())
+
+	err := run()
+	assert.ErrorContains(t, err, "failed to discover terragrunt stacks")
+}
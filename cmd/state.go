@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// stateCmd groups the subcommands that inspect a state file without contacting a
+// provider.
+type stateCmd struct {
+	Cmd *cobra.Command
+}
+
+// NewStateCmd creates the 'state' Cobra command and its subcommands.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to a stateCmd struct, which encapsulates the Cobra command.
+func NewStateCmd(ctx context.Context, cfg *config.Config) *stateCmd {
+	sc := &stateCmd{}
+	sc.Cmd = &cobra.Command{
+		Use:   "state",
+		Short: "Inspect the resources in a state file",
+	}
+	sc.Cmd.AddCommand(newStateLsCmd(ctx).Cmd)
+	sc.Cmd.AddCommand(newStateStatsCmd(ctx).Cmd)
+	return sc
+}
+
+// stateSource holds the flags that locate the state for the state subcommands.
+type stateSource struct {
+	StatePath    string
+	StateHeaders []string
+}
+
+func (s *stateSource) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.StatePath, "configfile", "", "Path to the terraform state file, a remote state URI (https, s3, gs), or - to read the state from stdin")
+	cmd.Flags().StringArrayVar(&s.StateHeaders, "state-header", nil, "Header sent when fetching a remote state URI, as 'Name: value' (repeatable)")
+}
+
+// load parses the state into a terraform.StateParser.
+func (s *stateSource) load(ctx context.Context, stdin io.Reader) (*terraform.StateParser, error) {
+	if s.StatePath == "" {
+		return nil, fmt.Errorf("A state file is required")
+	}
+	headers, err := parseHeaders(s.StateHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return terraform.LoadStateParser(ctx, s.StatePath, stdin, remote.NewFetcher(remote.Options{Headers: headers}))
+}
+
+// stateResource is a resource instance as listed by 'state ls'.
+type stateResource struct {
+	Address  string `json:"address"`
+	Mode     string `json:"mode"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Module   string `json:"module,omitempty"`
+	Id       string `json:"id,omitempty"`
+	Provider string `json:"provider"`
+}
+
+type stateLsCmd struct {
+	stateSource
+	Types  []string
+	Format string
+	ctx    context.Context
+	Cmd    *cobra.Command
+}
+
+func newStateLsCmd(ctx context.Context) *stateLsCmd {
+	lc := &stateLsCmd{ctx: ctx}
+	lc.Cmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the resources in a state file",
+		Long: `List every resource instance in a state file with its type, name, module, id and
+provider, to see what can be scanned for drift.
+
+For example:
+  driftwatcher state ls --configfile terraform.tfstate
+  driftwatcher state ls --configfile terraform.tfstate --type aws_instance --format json
+  terraform state pull | driftwatcher state ls --configfile -
+`,
+		RunE: lc.Run,
+	}
+	lc.addFlags(lc.Cmd)
+	lc.Cmd.Flags().StringSliceVar(&lc.Types, "type", nil, "Only list resources of these types")
+	lc.Cmd.Flags().StringVar(&lc.Format, "format", "table", "Output format (table, json)")
+	return lc
+}
+
+func (l *stateLsCmd) Run(cmd *cobra.Command, args []string) error {
+	if l.Format != "table" && l.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", l.Format)
+	}
+	ctx := l.ctx
+	if cmd.Context() != nil {
+		ctx = cmd.Context()
+	}
+
+	parser, err := l.load(ctx, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	listed := []stateResource{}
+	for _, resource := range parser.GetResources() {
+		if len(l.Types) > 0 && !slices.Contains(l.Types, resource.Type) {
+			continue
+		}
+		for _, instance := range resource.Instances {
+			id, _ := instance.Attributes["id"].(string)
+			listed = append(listed, stateResource{
+				Address:  terraform.InstanceAddress(resource, instance),
+				Mode:     resource.Mode,
+				Type:     resource.Type,
+				Name:     resource.Name,
+				Module:   resource.Module,
+				Id:       id,
+				Provider: providerSource(string(resource.Provider)),
+			})
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if l.Format == "json" {
+		encoded, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal resources: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tTYPE\tNAME\tMODULE\tID\tPROVIDER")
+	for _, r := range listed {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Address, r.Type, r.Name, dash(r.Module), dash(r.Id), r.Provider)
+	}
+	return tw.Flush()
+}
+
+// providerSource strips the provider["..."] wrapper Terraform stores provider
+// addresses in, e.g. provider["registry.terraform.io/hashicorp/aws"].
+func providerSource(provider string) string {
+	if source, ok := strings.CutPrefix(provider, `provider["`); ok {
+		if source, ok := strings.CutSuffix(source, `"]`); ok {
+			return source
+		}
+	}
+	return provider
+}
+
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// stateTypeStats counts the resources and instances of one resource type.
+type stateTypeStats struct {
+	Type      string `json:"type"`
+	Resources int    `json:"resources"`
+	Instances int    `json:"instances"`
+}
+
+// stateOutput describes a root module output without its value, which may be
+// sensitive.
+type stateOutput struct {
+	Name      string `json:"name"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// stateStats summarises a state file as printed by 'state stats'.
+type stateStats struct {
+	TerraformVersion string           `json:"terraform_version"`
+	StateVersion     int              `json:"state_version"`
+	Serial           int              `json:"serial"`
+	Lineage          string           `json:"lineage"`
+	Resources        int              `json:"resources"`
+	Instances        int              `json:"instances"`
+	Types            []stateTypeStats `json:"types"`
+	Outputs          []stateOutput    `json:"outputs"`
+}
+
+type stateStatsCmd struct {
+	stateSource
+	Format string
+	ctx    context.Context
+	Cmd    *cobra.Command
+}
+
+func newStateStatsCmd(ctx context.Context) *stateStatsCmd {
+	sc := &stateStatsCmd{ctx: ctx}
+	sc.Cmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Summarise a state file",
+		Long: `Print the Terraform version, state version, serial and lineage of a state file
+together with its resource and instance counts per type and its outputs. Output
+values are never printed.
+
+For example:
+  driftwatcher state stats --configfile terraform.tfstate
+  driftwatcher state stats --configfile s3://my-bucket/prod/terraform.tfstate --format json
+`,
+		RunE: sc.Run,
+	}
+	sc.addFlags(sc.Cmd)
+	sc.Cmd.Flags().StringVar(&sc.Format, "format", "table", "Output format (table, json)")
+	return sc
+}
+
+func (s *stateStatsCmd) Run(cmd *cobra.Command, args []string) error {
+	if s.Format != "table" && s.Format != "json" {
+		return fmt.Errorf("%s output format not currently supported", s.Format)
+	}
+	ctx := s.ctx
+	if cmd.Context() != nil {
+		ctx = cmd.Context()
+	}
+
+	parser, err := s.load(ctx, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	stats := stateStats{
+		TerraformVersion: parser.GetVersion(),
+		StateVersion:     parser.GetStateVersion(),
+		Serial:           parser.GetSerial(),
+		Lineage:          parser.GetLineage(),
+		Resources:        parser.GetResourceCount(),
+		Instances:        parser.GetResourceInstanceCount(),
+		Types:            []stateTypeStats{},
+		Outputs:          []stateOutput{},
+	}
+
+	byType := map[string]*stateTypeStats{}
+	for _, resourceType := range parser.ListResourceTypes() {
+		byType[resourceType] = &stateTypeStats{Type: resourceType}
+	}
+	for _, resource := range parser.GetResources() {
+		byType[resource.Type].Resources++
+		byType[resource.Type].Instances += len(resource.Instances)
+	}
+	for _, typeStats := range byType {
+		stats.Types = append(stats.Types, *typeStats)
+	}
+	sort.Slice(stats.Types, func(i, j int) bool { return stats.Types[i].Type < stats.Types[j].Type })
+
+	for name, output := range parser.GetOutputs() {
+		stats.Outputs = append(stats.Outputs, stateOutput{Name: name, Sensitive: output.Sensitive})
+	}
+	sort.Slice(stats.Outputs, func(i, j int) bool { return stats.Outputs[i].Name < stats.Outputs[j].Name })
+
+	out := cmd.OutOrStdout()
+	if s.Format == "json" {
+		encoded, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal state stats: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Terraform version:\t%s\n", dash(stats.TerraformVersion))
+	fmt.Fprintf(tw, "State version:\t%d\n", stats.StateVersion)
+	fmt.Fprintf(tw, "Serial:\t%d\n", stats.Serial)
+	fmt.Fprintf(tw, "Lineage:\t%s\n", dash(stats.Lineage))
+	fmt.Fprintf(tw, "Resources:\t%d (%d instances)\n", stats.Resources, stats.Instances)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(stats.Types) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(tw, "TYPE\tRESOURCES\tINSTANCES")
+		for _, t := range stats.Types {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", t.Type, t.Resources, t.Instances)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(stats.Outputs) > 0 {
+		fmt.Fprintf(out, "\nOutputs (%d):\n", len(stats.Outputs))
+		for _, o := range stats.Outputs {
+			if o.Sensitive {
+				fmt.Fprintf(out, "  %s (sensitive)\n", o.Name)
+				continue
+			}
+			fmt.Fprintf(out, "  %s\n", o.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runStateCmd(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	sc := cmd.NewStateCmd(context.Background(), &config.Config{})
+	sc.Cmd.SetOut(&out)
+	sc.Cmd.SetIn(strings.NewReader(stdin))
+	sc.Cmd.SetArgs(args)
+	err := sc.Cmd.Execute()
+	return out.String(), err
+}
+
+const moduleState = `{
+  "version": 4,
+  "terraform_version": "1.5.0",
+  "serial": 3,
+  "lineage": "3f1c1f6a-0000-4000-8000-000000000000",
+  "resources": [
+    {
+      "module": "module.network",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "i-0"}},
+        {"index_key": 1, "attributes": {"id": "i-1"}}
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"attributes": {"id": "ami-1"}}]
+    }
+  ]
+}`
+
+func TestStateCmd_Ls_Table(t *testing.T) {
+	out, err := runStateCmd(t, "", "ls", "--configfile", "../assets/terraform_ec2_state.tfstate")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ADDRESS", "TYPE", "NAME", "MODULE", "ID", "PROVIDER"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"aws_instance.web_server", "aws_instance", "web_server", "-", "i-0723dd4b084b79ce6", "registry.terraform.io/hashicorp/aws"}, strings.Fields(lines[1]))
+	assert.Equal(t, "aws_security_group.web_sg", strings.Fields(lines[2])[0])
+}
+
+func TestStateCmd_Ls_TypeFilterJSON(t *testing.T) {
+	out, err := runStateCmd(t, moduleState, "ls", "--configfile", "-", "--type", "aws_instance", "--format", "json")
+	require.NoError(t, err)
+
+	var listed []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	require.Len(t, listed, 2)
+	assert.Equal(t, map[string]any{
+		"address":  "module.network.aws_instance.web[0]",
+		"mode":     "managed",
+		"type":     "aws_instance",
+		"name":     "web",
+		"module":   "module.network",
+		"id":       "i-0",
+		"provider": "registry.terraform.io/hashicorp/aws",
+	}, listed[0])
+	assert.Equal(t, "module.network.aws_instance.web[1]", listed[1]["address"])
+}
+
+func TestStateCmd_Ls_DataSource(t *testing.T) {
+	out, err := runStateCmd(t, moduleState, "ls", "--configfile", "-", "--type", "aws_ami")
+	require.NoError(t, err)
+	assert.Contains(t, out, "data.aws_ami.ubuntu")
+}
+
+func TestStateCmd_Ls_Errors(t *testing.T) {
+	_, err := runStateCmd(t, "", "ls")
+	assert.EqualError(t, err, "A state file is required")
+
+	_, err = runStateCmd(t, "", "ls", "--configfile", "../assets/terraform_ec2_state.tfstate", "--format", "csv")
+	assert.EqualError(t, err, "csv output format not currently supported")
+
+	_, err = runStateCmd(t, "", "ls", "--configfile", "missing.tfstate")
+	assert.Error(t, err)
+}
+
+func TestStateCmd_Stats_Table(t *testing.T) {
+	out, err := runStateCmd(t, "", "stats", "--configfile", "../assets/terraform_ec2_state.tfstate")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "Terraform version:  1.5.0\n")
+	assert.Contains(t, out, "Lineage:            a1b2c3d4-e5f6-7890-abcd-ef1234567890\n")
+	assert.Contains(t, out, "Resources:          2 (2 instances)\n")
+	assert.Contains(t, out, "aws_instance        1          1\n")
+	assert.Contains(t, out, "Outputs (3):\n  instance_id\n")
+}
+
+func TestStateCmd_Stats_JSON(t *testing.T) {
+	state := strings.Replace(moduleState, `"resources": [`, `"outputs": {"password": {"value": "hunter2", "type": "string", "sensitive": true}},
+  "resources": [`, 1)
+	out, err := runStateCmd(t, state, "stats", "--configfile", "-", "--format", "json")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "hunter2")
+
+	var stats struct {
+		TerraformVersion string `json:"terraform_version"`
+		Serial           int    `json:"serial"`
+		Resources        int    `json:"resources"`
+		Instances        int    `json:"instances"`
+		Types            []struct {
+			Type      string `json:"type"`
+			Resources int    `json:"resources"`
+			Instances int    `json:"instances"`
+		} `json:"types"`
+		Outputs []struct {
+			Name      string `json:"name"`
+			Sensitive bool   `json:"sensitive"`
+		} `json:"outputs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	assert.Equal(t, "1.5.0", stats.TerraformVersion)
+	assert.Equal(t, 3, stats.Serial)
+	assert.Equal(t, 2, stats.Resources)
+	assert.Equal(t, 3, stats.Instances)
+	require.Len(t, stats.Types, 2)
+	assert.Equal(t, "aws_ami", stats.Types[0].Type)
+	assert.Equal(t, "aws_instance", stats.Types[1].Type)
+	assert.Equal(t, 2, stats.Types[1].Instances)
+	require.Len(t, stats.Outputs, 1)
+	assert.Equal(t, "password", stats.Outputs[0].Name)
+	assert.True(t, stats.Outputs[0].Sensitive)
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/filter"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// validateFlags are the detect flags that affect what a scan would check. They are
+// shared with the validate command so both resolve settings the same way.
+var validateFlags = []string{
+	"configfile",
+	"attributes",
+	"awsprofile",
+	"aws-region",
+	"endpoint-url",
+	"dev",
+	"kubeconfig",
+	"kube-context",
+	"ansible-facts-dir",
+	"ansible-inventory",
+	"ansible-host-attribute",
+	"ansible-fact",
+	"provider",
+	"resource",
+	"state-manager",
+	"state-header",
+	"state-retries",
+	"comparison",
+	"compare-attribute",
+	"filter",
+	"exclude",
+	"ignore-file",
+	"project-file",
+	"policy",
+	"provider-schema",
+}
+
+// validateCmd embeds a detectCmd so that its dependencies, such as StateManager and
+// PlatformProvider, can be injected the same way.
+type validateCmd struct {
+	*detectCmd
+	Cmd *cobra.Command
+}
+
+// NewValidateCmd creates and configures the 'validate' Cobra command.
+// This command checks a detect configuration without querying live resources: it
+// parses the state, checks the provider credentials, verifies that the resource type
+// and attributes are supported and prints what a detect run would scan.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration, containing settings like AWS profile.
+//
+// Returns:
+//
+//	A pointer to a validateCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewValidateCmd(ctx context.Context, cfg *config.Config) *validateCmd {
+	vc := &validateCmd{
+		detectCmd: NewDetectCmd(ctx, cfg),
+	}
+	vc.Cmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check a detect configuration and show what would be scanned, without querying live resources",
+		Long: `Validate the configuration of a detect run before starting it. The state file is
+parsed, the provider credentials are resolved, the resource type and attributes are
+checked against what the provider supports and against the Terraform provider schema
+of the resource type, as are the attributes the --policy files refer to, and the
+resources that would be scanned are listed. No resource is described, so
+misconfiguration surfaces before a long run.
+
+The provider schema is the output of 'terraform providers schema -json' given with
+--provider-schema, or fetched with it in the Terraform directory it names; the
+schemas of the AWS resources driftwatcher reads are bundled.
+
+It accepts the same state, provider and scoping flags as detect, and reads the same
+DRIFT_* environment variables, project file and config profile.
+
+For example:
+  driftwatcher validate --configfile terraform.tfstate --attributes instance_type,tags.Name
+  driftwatcher validate --profile prod-us-east
+`,
+		RunE: vc.Run,
+	}
+
+	for _, name := range validateFlags {
+		vc.Cmd.Flags().AddFlag(vc.detectCmd.Cmd.Flags().Lookup(name))
+	}
+	vc.Cmd.Flags().SetNormalizeFunc(flagAliases)
+
+	return vc
+}
+
+func (v *validateCmd) Run(cmd *cobra.Command, args []string) error {
+	d := v.detectCmd
+	if ctx := cmd.Context(); ctx != nil {
+		d.ctx = ctx
+	}
+	if err := d.resolveSettings(cmd); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var problems []string
+	check := func(label string, err error) bool {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+			fmt.Fprintf(out, "✗ %s: %v\n", label, err)
+			return false
+		}
+		return true
+	}
+
+	if d.TfConfigPath == "" {
+		return fmt.Errorf("A state file is required")
+	}
+
+	_, err := d.checkerOptions()
+	check("comparison", err)
+
+	filters, exclusions, err := d.scope()
+	scoped := check("resource scope", err)
+
+	var resources []statemanager.StateResource
+	if check("state manager", d.setupStateManager()) {
+		resources, err = v.readState(cmd.InOrStdin())
+		if check("state", err) {
+			fmt.Fprintf(out, "✓ state %s: %d %s resource(s)\n", d.TfConfigPath, len(resources), d.Resource)
+		}
+	}
+
+	if check("provider", d.setupProvider()) {
+		v.checkProvider(out, check)
+	}
+
+	v.checkSchema(out, check)
+
+	if scoped && resources != nil {
+		printScanPlan(out, d.Resource, d.AttributesToTrack, filter.Apply(resources, filters), exclusions)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("configuration is invalid: %s", strings.Join(problems, "; "))
+	}
+	fmt.Fprintln(out, "Configuration is valid.")
+	return nil
+}
+
+// readState parses the state and returns the resources of the configured type.
+func (v *validateCmd) readState(stdin io.Reader) ([]statemanager.StateResource, error) {
+	d := v.detectCmd
+	var stateContent statemanager.StateContent
+	var err error
+	if d.TfConfigPath == statemanager.StdinStatePath {
+		stateContent, err = d.StateManager.ParseState(d.ctx, stdin)
+	} else {
+		stateContent, err = d.StateManager.ParseStateFile(d.ctx, d.TfConfigPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resources, err := d.StateManager.RetrieveResources(d.ctx, stateContent, d.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if resources == nil {
+		resources = []statemanager.StateResource{}
+	}
+	return resources, nil
+}
+
+// checkProvider verifies the provider credentials and that the resource type and
+// every tracked attribute are supported. Providers that cannot validate a
+// configuration are skipped with a note.
+func (v *validateCmd) checkProvider(out io.Writer, check func(string, error) bool) {
+	d := v.detectCmd
+	validator, ok := d.PlatformProvider.(provider.ValidatorI)
+	if !ok {
+		fmt.Fprintf(out, "- provider %s cannot validate credentials or attributes, skipped\n", d.Provider)
+		return
+	}
+
+	if check("credentials", validator.CheckCredentials(d.ctx)) {
+		fmt.Fprintf(out, "✓ %s credentials\n", d.Provider)
+	}
+
+	supported, err := validator.SupportedAttributes(d.Resource)
+	if !check("resource type", err) {
+		return
+	}
+	fmt.Fprintf(out, "✓ resource type %s\n", d.Resource)
+
+	var unsupported []string
+	for _, attribute := range d.AttributesToTrack {
+		if !attributeSupported(attribute, supported) {
+			unsupported = append(unsupported, attribute)
+		}
+	}
+	if len(unsupported) > 0 {
+		check("attributes", errors.New("not supported for "+d.Resource+": "+strings.Join(unsupported, ", ")))
+		return
+	}
+	fmt.Fprintf(out, "✓ attributes %s\n", strings.Join(d.AttributesToTrack, ", "))
+}
+
+// checkSchema checks the tracked attributes, and those the policies refer to, against
+// the provider schema of the resource type.
+func (v *validateCmd) checkSchema(out io.Writer, check func(string, error) bool) {
+	d := v.detectCmd
+	schemas, err := d.providerSchemas()
+	if !check("provider schema", err) || schemas == nil {
+		return
+	}
+	policies, err := d.loadPolicies()
+	if !check("policies", err) {
+		return
+	}
+	problems, warnings, checked := d.schemaProblems(schemas, policies)
+	if !checked {
+		fmt.Fprintf(out, "- no provider schema of %s, attributes not checked against it\n", d.Resource)
+		return
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(out, "- policy attribute not in the provider schema of %s: %s\n", d.Resource, warning)
+	}
+	if len(problems) > 0 {
+		check("provider schema", errors.New(strings.Join(problems, "; ")))
+		return
+	}
+	fmt.Fprintf(out, "✓ attributes in the provider schema of %s\n", d.Resource)
+}
+
+func attributeSupported(attribute string, supported []string) bool {
+	for _, pattern := range supported {
+		if ok, _ := path.Match(pattern, attribute); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// printScanPlan lists the resources a detect run would check and those it would skip.
+func printScanPlan(out io.Writer, resourceType string, attributes []string, resources []statemanager.StateResource, exclusions *ignore.Matcher) {
+	included, excluded := exclusions.Split(resources)
+
+	fmt.Fprintf(out, "\nWould check %d %s resource(s) for %s:\n", len(included), resourceType, strings.Join(attributes, ", "))
+	for _, resource := range included {
+		fmt.Fprintf(out, "  %s\n", resourceLine(resource))
+	}
+	if len(excluded) > 0 {
+		fmt.Fprintf(out, "Would skip %d resource(s):\n", len(excluded))
+		for _, resource := range excluded {
+			fmt.Fprintf(out, "  %s\n", resourceLine(resource))
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+func resourceLine(resource statemanager.StateResource) string {
+	line := ignore.Address(resource)
+	if id, err := resource.AttributeValue("id"); err == nil && id != "" {
+		line += " (" + id + ")"
+	}
+	return line
+}
@@ -0,0 +1,127 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validatingProvider is a provider that can also validate a configuration.
+type validatingProvider struct {
+	*providerfakes.FakeProviderI
+	*providerfakes.FakeValidatorI
+}
+
+func newValidateCmd(t *testing.T) (*bytes.Buffer, *statemanagerfakes.FakeStateManagerI, *providerfakes.FakeProviderI, *providerfakes.FakeValidatorI, func(...string) error) {
+	t.Helper()
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockProvider := &providerfakes.FakeProviderI{}
+	mockValidator := &providerfakes.FakeValidatorI{}
+	mockValidator.SupportedAttributesReturns([]string{"instance_type", "tags.*"}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+		{Type: "aws_instance", Name: "scratch", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-2"}}}},
+	}, nil)
+
+	var out bytes.Buffer
+	run := func(args ...string) error {
+		vc := cmd.NewValidateCmd(context.Background(), &config.Config{})
+		vc.StateManager = mockStateManager
+		vc.PlatformProvider = validatingProvider{mockProvider, mockValidator}
+		vc.Cmd.SetOut(&out)
+		vc.Cmd.SetArgs(args)
+		return vc.Cmd.Execute()
+	}
+	return &out, mockStateManager, mockProvider, mockValidator, run
+}
+
+func TestValidateCmd_Valid(t *testing.T) {
+	out, _, mockProvider, mockValidator, run := newValidateCmd(t)
+
+	err := run("--configfile", "state.tfstate", "--attributes", "instance_type,tags.Name", "--exclude", "aws_instance.scratch")
+	require.NoError(t, err)
+
+	got := out.String()
+	assert.Contains(t, got, "✓ state state.tfstate: 2 aws_instance resource(s)")
+	assert.Contains(t, got, "✓ aws credentials")
+	assert.Contains(t, got, "✓ attributes instance_type, tags.Name")
+	assert.Contains(t, got, "Would check 1 aws_instance resource(s) for instance_type, tags.Name:\n  aws_instance.web (i-1)\n")
+	assert.Contains(t, got, "Would skip 1 resource(s):\n  aws_instance.scratch (i-2)\n")
+	assert.Contains(t, got, "Configuration is valid.")
+	assert.Equal(t, 1, mockValidator.CheckCredentialsCallCount())
+	assert.Equal(t, 0, mockProvider.InfrastructreMetadataCallCount(), "no live resource is queried")
+}
+
+func TestValidateCmd_Problems(t *testing.T) {
+	out, _, _, mockValidator, run := newValidateCmd(t)
+	mockValidator.CheckCredentialsReturns(errors.New("no credentials found"))
+
+	err := run("--configfile", "state.tfstate", "--attributes", "instance_type,ami_id")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "credentials: no credentials found")
+	assert.Contains(t, err.Error(), "attributes: not supported for aws_instance: ami_id")
+	assert.Contains(t, out.String(), "Would check 2 aws_instance resource(s)", "the scan plan is printed even when a check fails")
+}
+
+func TestValidateCmd_ProviderSchema(t *testing.T) {
+	out, _, _, mockValidator, run := newValidateCmd(t)
+	mockValidator.SupportedAttributesReturns([]string{"instance_type", "metadata_options", "tags.*"}, nil)
+
+	require.NoError(t, run("--configfile", "state.tfstate", "--attributes", "instance_type,metadata_options"))
+	assert.Contains(t, out.String(), "✓ attributes in the provider schema of aws_instance")
+
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/aws": {
+      "resource_schemas": {
+        "aws_instance": {"version": 1, "block": {"attributes": {"instance_type": {"type": "string"}}}}
+      }
+    }
+  }
+}`), 0644))
+	err := run("--configfile", "state.tfstate", "--attributes", "instance_type,metadata_options.http_tokenz,instance_typ", "--provider-schema", schemaPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "provider schema: metadata_options.http_tokenz: metadata_options is not an attribute of aws_instance; instance_typ is not an attribute of aws_instance, did you mean instance_type?")
+
+	out.Reset()
+	require.NoError(t, run("--configfile", "state.tfstate", "--resource", "aws_custom", "--provider-schema", schemaPath))
+	assert.Contains(t, out.String(), "- no provider schema of aws_custom, attributes not checked against it")
+}
+
+func TestValidateCmd_UnsupportedResourceType(t *testing.T) {
+	_, _, _, mockValidator, run := newValidateCmd(t)
+	mockValidator.SupportedAttributesReturns(nil, errors.New("aws_s3_bucket resource not yet supported for AWS provider"))
+
+	err := run("--configfile", "state.tfstate", "--resource", "aws_s3_bucket")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resource type: aws_s3_bucket resource not yet supported")
+	assert.Equal(t, "aws_s3_bucket", mockValidator.SupportedAttributesArgsForCall(0))
+}
+
+func TestValidateCmd_StateError(t *testing.T) {
+	out, mockStateManager, _, _, run := newValidateCmd(t)
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, errors.New("line 3: version is required"))
+
+	err := run("--configfile", "state.tfstate")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "state: line 3: version is required")
+	assert.NotContains(t, out.String(), "Would check")
+}
+
+func TestValidateCmd_MissingStateFile(t *testing.T) {
+	_, _, _, _, run := newValidateCmd(t)
+	assert.ErrorContains(t, run(), "A state file is required")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/signing"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type verifyCmd struct {
+	Verifier      signing.Verifier
+	SignaturePath string
+	Key           string
+	Profile       string
+	ctx           context.Context
+	Cmd           *cobra.Command
+	cfg           *config.Config
+}
+
+// NewVerifyCmd creates and configures the 'verify' Cobra command.
+// This command checks a report file written by 'detect --sign-key' or
+// 'detect --sign-kms-key' against its signature, proving it was not modified.
+//
+// Parameters:
+//
+//	ctx: The context for the command's execution, allowing for cancellation or timeouts.
+//	cfg: The application's global configuration.
+//
+// Returns:
+//
+//	A pointer to a verifyCmd struct, which encapsulates the Cobra command and its dependencies.
+func NewVerifyCmd(ctx context.Context, cfg *config.Config) *verifyCmd {
+	vc := &verifyCmd{
+		cfg: cfg,
+		ctx: ctx,
+	}
+	vc.Cmd = &cobra.Command{
+		Use:   "verify <report-file>",
+		Short: "Verify the signature of a signed report file",
+		Long: `Verify that a report file written with 'detect --sign-key' or 'detect --sign-kms-key'
+is unmodified. The signature is read from <report-file>.sig unless --signature is given.
+
+Reports signed with a local key are verified with its public key (or the private key
+itself); reports signed with AWS KMS are verified by KMS, using the key recorded in the
+signature.
+
+For example:
+  driftwatcher verify drift.json --key report-signing.pub.pem
+  driftwatcher verify drift.csv --awsprofile audit
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: vc.Run,
+	}
+
+	vc.Cmd.Flags().StringVar(&vc.SignaturePath, "signature", "", "Signature file of the report (default: <report-file>.sig)")
+	vc.Cmd.Flags().StringVar(&vc.Key, "key", "", "PEM public key of a report signed with --sign-key")
+	vc.Cmd.Flags().StringVar(&vc.Profile, "awsprofile", "", "AWS profile used to verify a report signed with --sign-kms-key, AWS_PROFILE or else the default profile when empty")
+
+	return vc
+}
+
+func (v *verifyCmd) Run(cmd *cobra.Command, args []string) error {
+	if ctx := cmd.Context(); ctx != nil {
+		v.ctx = ctx
+	}
+	reportPath := args[0]
+	signaturePath := v.SignaturePath
+	if signaturePath == "" {
+		signaturePath = signing.SignaturePath(reportPath)
+	}
+
+	if v.Verifier == nil {
+		if v.Key != "" {
+			verifier, err := signing.NewLocalVerifier(v.Key)
+			if err != nil {
+				return err
+			}
+			v.Verifier = verifier
+		} else {
+			client, err := newKMSClient(v.ctx, v.Profile)
+			if err != nil {
+				return fmt.Errorf("failed to create KMS client, pass --key for reports signed with a local key: %w", err)
+			}
+			v.Verifier = signing.NewKMSVerifier(client)
+		}
+	}
+
+	envelope, err := signing.VerifyFile(v.ctx, v.Verifier, reportPath, signaturePath)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Verified %s\n", reportPath)
+	fmt.Fprintf(out, "  digest:    %s\n", envelope.Digest)
+	fmt.Fprintf(out, "  algorithm: %s\n", envelope.Algorithm)
+	fmt.Fprintf(out, "  key:       %s\n", envelope.KeyID)
+	fmt.Fprintf(out, "  signed at: %s\n", envelope.SignedAt.Format("2006-01-02 15:04:05 MST"))
+	return nil
+}
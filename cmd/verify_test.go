@@ -0,0 +1,103 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"drift-watcher/cmd"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/signing"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedReport runs detect with --sign-key and returns the report path and the
+// public key that verifies it.
+func signedReport(t *testing.T, outputFile string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "signing.pem")
+	publicPath := filepath.Join(dir, "signing.pub.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockProvider := &providerfakes.FakeProviderI{}
+	mockProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+
+	reportPath := filepath.Join(dir, outputFile)
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.StateManager = mockStateManager
+	dc.PlatformProvider = mockProvider
+	dc.DriftChecker = &fixedChecker{}
+	dc.Cmd.SetArgs([]string{"--configfile", "terraform.tfstate", "--output-file", reportPath, "--sign-key", keyPath})
+	require.NoError(t, dc.Cmd.Execute())
+	require.FileExists(t, signing.SignaturePath(reportPath))
+
+	return reportPath, publicPath
+}
+
+func runVerifyCmd(args ...string) (string, error) {
+	vc := cmd.NewVerifyCmd(context.Background(), &config.Config{})
+	out := &bytes.Buffer{}
+	vc.Cmd.SilenceUsage = true
+	vc.Cmd.SetOut(out)
+	vc.Cmd.SetErr(out)
+	vc.Cmd.SetArgs(args)
+	err := vc.Cmd.Execute()
+	return out.String(), err
+}
+
+func TestVerifyCmd_SignedReports(t *testing.T) {
+	for _, outputFile := range []string{"drift.json", "drift.csv"} {
+		t.Run(outputFile, func(t *testing.T) {
+			reportPath, publicPath := signedReport(t, outputFile)
+
+			out, err := runVerifyCmd(reportPath, "--key", publicPath)
+			require.NoError(t, err)
+			assert.Contains(t, out, "Verified "+reportPath)
+			assert.Contains(t, out, "algorithm: ed25519")
+		})
+	}
+}
+
+func TestVerifyCmd_TamperedReport(t *testing.T) {
+	reportPath, publicPath := signedReport(t, "drift.json")
+	content, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reportPath, bytes.Replace(content, []byte(`"has_drift": true`), []byte(`"has_drift": false`), 1), 0644))
+
+	_, err = runVerifyCmd(reportPath, "--key", publicPath)
+	require.ErrorIs(t, err, signing.ErrInvalidSignature)
+}
+
+func TestDetectCmd_Run_SignRequiresOutputFile(t *testing.T) {
+	dc := cmd.NewDetectCmd(context.Background(), &config.Config{})
+	dc.StateManager = &statemanagerfakes.FakeStateManagerI{}
+	dc.PlatformProvider = &providerfakes.FakeProviderI{}
+	dc.Cmd.SilenceUsage = true
+	dc.Cmd.SetArgs([]string{"--configfile", "terraform.tfstate", "--sign-key", "signing.pem"})
+
+	err := dc.Cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signing reports requires --output-file")
+}
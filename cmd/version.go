@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"drift-watcher/pkg/buildinfo"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// newVersionCmd creates the 'version' command, which prints the build information of
+// the binary.
+func newVersionCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit and build date of driftwatcher",
+		Long: `Print the version of driftwatcher with the commit it was built from and when.
+Reports record the same version in their run metadata, so stored reports identify the
+release that produced them.`,
+		Example: `driftwatcher version
+  driftwatcher version --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := buildinfo.Get()
+			if !asJSON {
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), "driftwatcher "+info.String())
+				return err
+			}
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(info)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the build information as JSON")
+	return cmd
+}
@@ -0,0 +1,32 @@
+package cmd_test
+
+import (
+	"bytes"
+	"drift-watcher/cmd"
+	"drift-watcher/pkg/buildinfo"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCmd(t *testing.T) {
+	t.Cleanup(func() {
+		cmd.RootCmd.SetOut(nil)
+		cmd.RootCmd.SetArgs(nil)
+	})
+	var out bytes.Buffer
+	cmd.RootCmd.SetOut(&out)
+
+	cmd.RootCmd.SetArgs([]string{"version"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	assert.Equal(t, "driftwatcher "+buildinfo.Get().String()+"\n", out.String())
+
+	out.Reset()
+	cmd.RootCmd.SetArgs([]string{"version", "--json"})
+	require.NoError(t, cmd.RootCmd.Execute())
+	var info buildinfo.Info
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, buildinfo.Get(), info)
+}
@@ -6,8 +6,9 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"strings"
+	"sort"
 
 	"github.com/spf13/viper"
 )
@@ -18,7 +19,14 @@ type IConfig any
 // It encapsulates various parameters that control the tool's behavior,
 // such as logging verbosity and AWS profile settings.
 type Config struct {
-	LogLevel    string
+	LogLevel        string
+	LogFormat       string
+	LogFile         string
+	LogModuleLevels []string
+	// CPUProfile and MemProfile are the files the pprof CPU and memory profiles of
+	// the command are written to, if any.
+	CPUProfile  string
+	MemProfile  string
 	ProfileFile string
 	Profile     Profile
 }
@@ -44,35 +52,9 @@ func (c *Config) GetConfigFolder(xdgPath string) (string, error) {
 	return driftWatcherConfigPath, nil
 }
 
+// Init points viper at the profiles file. The logger is built from the log settings
+// by the root command and carried in the command context.
 func (c *Config) Init() {
-	var level slog.Level
-	var output io.Writer = os.Stderr
-
-	switch strings.ToLower(c.LogLevel) {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	case "trace": // TODO: implement tracing with open telementry
-		level = slog.LevelDebug
-	default:
-		slog.Error("Unrecognized log level value. Defaulting to 'info'.", "provided_level", c.LogLevel)
-		level = slog.LevelInfo
-	}
-
-	handler := slog.NewTextHandler(output, &slog.HandlerOptions{
-		Level: level,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			return a
-		},
-	})
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
-
 	if c.ProfileFile != "" {
 		viper.SetConfigFile(c.ProfileFile)
 	} else {
@@ -95,10 +77,39 @@ func (c *Config) Init() {
 	}
 }
 
-func (c *Config) PrintConfig() error {
+// PrintConfig writes the settings of the selected profile to out as key = value lines.
+func (c *Config) PrintConfig(out io.Writer) error {
+	if err := c.Profile.readConfig(); err != nil {
+		return err
+	}
+
+	section := viper.GetStringMap(c.Profile.name())
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(out, "[%s]\n", c.Profile.name())
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s = %v\n", key, section[key])
+	}
 	return nil
 }
 
+// EditConfig opens the config file in the editor named by $EDITOR, falling back to vi.
 func (c *Config) EditConfig() error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command(editor, c.ProfileFile)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to open config file in %s: %w", editor, err)
+	}
 	return nil
 }
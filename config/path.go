@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// homePrefixes are the prefixes of a path that stand for the home directory of the
+// user: ~ as in a Unix shell, and %USERPROFILE% as in the Windows command prompt,
+// which does not expand ~.
+var homePrefixes = []string{"~", "%USERPROFILE%"}
+
+// ExpandPath resolves a leading ~ or %USERPROFILE% of path to the home directory of the
+// user, and converts slashes to the separator of the platform, so paths can be
+// written the same way on Linux, macOS and Windows. Other paths, standard input (-)
+// and remote URIs such as s3://bucket/key are returned as they are.
+func ExpandPath(path string) (string, error) {
+	if path == "" || path == "-" || strings.Contains(path, "://") {
+		return path, nil
+	}
+
+	for _, prefix := range homePrefixes {
+		rest, ok := cutPrefixFold(path, prefix)
+		if !ok || (rest != "" && rest[0] != '/' && rest[0] != '\\') {
+			continue
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+		return filepath.Join(home, filepath.FromSlash(strings.TrimLeft(rest, `/\`))), nil
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring case, as Windows environment variable
+// names are case-insensitive.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	"drift-watcher/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "~", want: home},
+		{path: "~/.aws/credentials", want: filepath.Join(home, ".aws", "credentials")},
+		{path: `%USERPROFILE%\terraform.tfstate`, want: filepath.Join(home, "terraform.tfstate")},
+		{path: "%USERPROFILE%/infra/terraform.tfstate", want: filepath.Join(home, "infra", "terraform.tfstate")},
+		{path: "%userprofile%/infra", want: filepath.Join(home, "infra")},
+		{path: "states/prod.tfstate", want: filepath.Join("states", "prod.tfstate")},
+		{path: "~other/file", want: filepath.FromSlash("~other/file")},
+		{path: "-", want: "-"},
+		{path: "s3://bucket/prod/terraform.tfstate", want: "s3://bucket/prod/terraform.tfstate"},
+		{path: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := config.ExpandPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadProject_HomePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	path := filepath.Join(t.TempDir(), "driftwatcher.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("state: ~/states/prod.tfstate\n"), 0o644))
+
+	project, err := config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "states", "prod.tfstate"), project.State)
+}
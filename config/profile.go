@@ -1,31 +1,269 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultProfileName is the profile used when none is selected.
+const DefaultProfileName = "default"
+
+// Profile configuration keys. Each key is stored under the profile's table in the
+// config file, e.g. [prod-us-east] state_path = "...".
+const (
+	StatePathField    = "state_path"
+	StateManagerField = "state_manager"
+	ProviderField     = "provider"
+	ResourceField     = "resource"
+	AttributesField   = "attributes"
+	OutputFileField   = "output_file"
+	FormatField       = "format"
+	AWSProfileField   = "aws_profile"
+	NotifyURLField    = "notify_url"
+	AlertKeyField     = "alert_key"
+	PRTokenField      = "pr_token"
+)
 
 type AWSConfig struct {
 	CredentialPath  []string
 	ConfigPath      []string
 	DefaultLocation bool
 	ProfileName     string
+	// ExplicitProfile is whether ProfileName was selected, with a flag or AWS_PROFILE,
+	// rather than defaulted. Only a selected profile is required to exist, as the
+	// default profile may be missing when credentials come from the environment or an
+	// instance role.
+	ExplicitProfile bool
+
+	// RetryMode selects the SDK retry strategy, "standard" or "adaptive". Adaptive
+	// mode also rate limits the client once throttling errors are seen.
+	RetryMode string
+	// MaxAttempts is the maximum number of attempts per API call, including the first.
+	MaxAttempts int
+	// MaxBackoff caps the delay between retry attempts.
+	MaxBackoff time.Duration
+	// BreakerThreshold is the number of consecutive connection failures after which
+	// calls to the region fail fast. Zero uses the provider default and a negative
+	// value disables the circuit breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long calls fail fast once the breaker has opened.
+	BreakerCooldown time.Duration
+	// RequestRate is the maximum number of list and describe calls per second, shared
+	// by every resource of a scan. Zero uses the provider default and a negative value
+	// disables rate limiting.
+	RequestRate float64
+	// PageSize is the number of results requested per page by list and describe
+	// calls. Zero uses the provider default.
+	PageSize int32
+
+	// Partition is the AWS partition of the account: aws, aws-us-gov or aws-cn. The
+	// region must belong to it. It is derived from the region when empty.
+	Partition string
+	// UseFIPS sends API calls to the FIPS 140 endpoints of the services, which are
+	// available in the aws and aws-us-gov partitions.
+	UseFIPS bool
+
+	// CacheTTL is how long live resource metadata is reused before AWS is queried
+	// again. Zero disables the cache.
+	CacheTTL time.Duration
+	// CacheDir persists cached metadata across runs. Metadata is only cached in
+	// memory when it is empty.
+	CacheDir string
+}
+
+// KubernetesConfig holds the settings of the kubernetes provider.
+type KubernetesConfig struct {
+	// Kubeconfig is the path to the kubeconfig file. The default loading rules, the
+	// KUBECONFIG environment variable and then ~/.kube/config, apply when it is empty.
+	Kubeconfig string
+	// Context selects a kubeconfig context other than the current one.
+	Context string
+}
+
+// AnsibleConfig holds the settings of the ansible provider.
+type AnsibleConfig struct {
+	// FactsDir is a directory of gathered facts with one JSON file per host, as
+	// written by the jsonfile fact cache or by `ansible -m setup --tree`.
+	FactsDir string
+	// Inventory is an optional INI or YAML inventory used to match hosts that are
+	// addressed by ansible_host rather than by inventory name.
+	Inventory string
+	// HostAttribute is the state attribute holding the host name or address of a
+	// resource. It defaults to "name".
+	HostAttribute string
+	// FactMappings maps state attributes to the facts they are compared with, in
+	// addition to the provider defaults.
+	FactMappings map[string]string
+}
+
+// HookConfig registers an executable that is run when an attribute drifts.
+type HookConfig struct {
+	// Attribute is a glob pattern (path.Match syntax) of the attribute paths the hook
+	// runs for, e.g. instance_type or tags.*.
+	Attribute string `mapstructure:"attribute"`
+	// Command is the executable to run. It receives the drift item as JSON on stdin.
+	Command string `mapstructure:"command"`
+	// Args are passed to Command.
+	Args []string `mapstructure:"args"`
+	// Timeout bounds a single run of the hook. Zero uses the default hook timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// MaintenanceConfig declares a maintenance window, during which drift on the resources
+// it covers is expected.
+type MaintenanceConfig struct {
+	// Resource is a glob pattern (path.Match syntax) of the addresses of the resources
+	// the window covers, e.g. aws_instance.web or module.network.*.
+	Resource string `mapstructure:"resource"`
+	// Start and End bound the window. They are datetimes of the config file, e.g.
+	// start = 2026-10-16T22:00:00Z in TOML.
+	Start time.Time `mapstructure:"start"`
+	End   time.Time `mapstructure:"end"`
+	// Duration ends the window relative to Start when End is not set.
+	Duration time.Duration `mapstructure:"duration"`
+	// Reason is recorded on the reports of the drift found during the window, e.g. a
+	// change ticket.
+	Reason string `mapstructure:"reason"`
+}
+
+// ProfileSettings bundles the settings of a named scan target so that a detect run
+// can be selected by name instead of repeating flags.
+type ProfileSettings struct {
+	StatePath    string   `mapstructure:"state_path"`
+	StateManager string   `mapstructure:"state_manager"`
+	Provider     string   `mapstructure:"provider"`
+	Resource     string   `mapstructure:"resource"`
+	Attributes   []string `mapstructure:"attributes"`
+	OutputFile   string   `mapstructure:"output_file"`
+	Format       string   `mapstructure:"format"`
+	AWSProfile   string   `mapstructure:"aws_profile"`
+	// NotifyURL, AlertKey and PRToken are credentials. They are best set to a secret
+	// reference such as env://SLACK_WEBHOOK_URL or aws-sm://driftwatcher#alert_key
+	// rather than to the credential itself.
+	NotifyURL string `mapstructure:"notify_url"`
+	AlertKey  string `mapstructure:"alert_key"`
+	PRToken   string `mapstructure:"pr_token"`
+	// Hooks are run when the attributes they name drift. They can only be set in the
+	// config file.
+	Hooks []HookConfig `mapstructure:"hooks"`
+	// Maintenance declares maintenance windows in addition to those given with
+	// --maintenance.
+	Maintenance []MaintenanceConfig `mapstructure:"maintenance"`
 }
 
 type Profile struct {
-	AWSConfig *AWSConfig
+	ProfileName string
+	AWSConfig   *AWSConfig
+	Settings    ProfileSettings
 }
 
+// WriteConfigField sets a configuration field for the specific profile.
 func (p *Profile) WriteConfigField(field, value string) error {
-	viper.ReadInConfig()
-	viper.Set(p.GetConfigField(field), value)
-	return viper.WriteConfig()
+	return p.updateConfig(func(section map[string]any) {
+		section[field] = value
+	})
 }
 
 // GetConfigField returns the configuration field for the specific profile
 func (p *Profile) GetConfigField(field string) string {
-	// return p.ProfileName + "." + field
-	return ""
+	return p.name() + "." + field
 }
 
 // DeleteConfigField deletes a configuration field.
 func (p *Profile) DeleteConfigField(field string) error {
-	return nil
+	return p.updateConfig(func(section map[string]any) {
+		delete(section, field)
+	})
+}
+
+// Load reads the profile's settings from the config file into Settings.
+// It reports whether a section for the profile exists.
+func (p *Profile) Load() (bool, error) {
+	if err := p.readConfig(); err != nil {
+		return false, err
+	}
+
+	section := viper.Sub(p.name())
+	if section == nil {
+		return false, nil
+	}
+
+	var settings ProfileSettings
+	if err := section.Unmarshal(&settings); err != nil {
+		return false, fmt.Errorf("failed to parse profile %s: %w", p.name(), err)
+	}
+	p.Settings = settings
+	return true, nil
+}
+
+// updateConfig applies update to the profile's section of the config file and writes
+// it back. A separate viper instance is used because viper has no way to unset a key
+// once it has been set on the global instance.
+func (p *Profile) updateConfig(update func(section map[string]any)) error {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return errors.New("no config file configured")
+	}
+
+	current := newFileViper(file)
+	if err := current.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := current.AllSettings()
+	section, ok := settings[p.name()].(map[string]any)
+	if !ok {
+		section = map[string]any{}
+		settings[p.name()] = section
+	}
+	update(section)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("failed to create config folder: %w", err)
+	}
+	updated := newFileViper(file)
+	for key, value := range settings {
+		updated.Set(key, value)
+	}
+	if err := updated.WriteConfig(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return p.readConfig()
+}
+
+func newFileViper(file string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if filepath.Ext(file) == "" {
+		v.SetConfigType("toml")
+	}
+	return v
+}
+
+// readConfig loads the config file, treating a missing file as an empty config.
+func (p *Profile) readConfig() error {
+	if viper.ConfigFileUsed() == "" {
+		return nil
+	}
+
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	if err == nil || errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return fmt.Errorf("failed to read config file: %w", err)
+}
+
+func (p *Profile) name() string {
+	if p.ProfileName == "" {
+		return DefaultProfileName
+	}
+	return p.ProfileName
 }
@@ -0,0 +1,146 @@
+package config_test
+
+import (
+	"bytes"
+	"drift-watcher/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useConfigFile(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "driftwatcher", "config.toml")
+	viper.Reset()
+	viper.SetConfigFile(file)
+	t.Cleanup(viper.Reset)
+	return file
+}
+
+func TestProfile_WriteAndLoad(t *testing.T) {
+	file := useConfigFile(t)
+	profile := &config.Profile{ProfileName: "prod-us-east"}
+
+	require.NoError(t, profile.WriteConfigField(config.StatePathField, "./prod/terraform.tfstate"))
+	require.NoError(t, profile.WriteConfigField(config.AttributesField, "instance_type,tags"))
+	require.NoError(t, profile.WriteConfigField(config.AWSProfileField, "prod"))
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[prod-us-east]")
+
+	found, err := profile.Load()
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "./prod/terraform.tfstate", profile.Settings.StatePath)
+	assert.Equal(t, []string{"instance_type", "tags"}, profile.Settings.Attributes)
+	assert.Equal(t, "prod", profile.Settings.AWSProfile)
+}
+
+func TestProfile_DeleteConfigField(t *testing.T) {
+	useConfigFile(t)
+	profile := &config.Profile{ProfileName: "staging"}
+
+	require.NoError(t, profile.WriteConfigField(config.StatePathField, "staging.tfstate"))
+	require.NoError(t, profile.WriteConfigField(config.FormatField, "diff"))
+	require.NoError(t, profile.DeleteConfigField(config.FormatField))
+
+	found, err := profile.Load()
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "staging.tfstate", profile.Settings.StatePath)
+	assert.Empty(t, profile.Settings.Format)
+}
+
+func TestConfig_PrintConfig(t *testing.T) {
+	useConfigFile(t)
+	cfg := &config.Config{Profile: config.Profile{ProfileName: "prod-us-east"}}
+	require.NoError(t, cfg.Profile.WriteConfigField(config.StatePathField, "prod.tfstate"))
+	require.NoError(t, cfg.Profile.WriteConfigField(config.FormatField, "diff"))
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.PrintConfig(&out))
+	assert.Equal(t, "[prod-us-east]\nformat = diff\nstate_path = prod.tfstate\n", out.String())
+}
+
+func TestProfile_Load_MissingProfile(t *testing.T) {
+	useConfigFile(t)
+	require.NoError(t, (&config.Profile{ProfileName: "staging"}).WriteConfigField(config.StatePathField, "staging.tfstate"))
+
+	profile := &config.Profile{ProfileName: "prod"}
+	found, err := profile.Load()
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestProfile_GetConfigField_DefaultProfile(t *testing.T) {
+	profile := &config.Profile{}
+	assert.Equal(t, "default.state_path", profile.GetConfigField(config.StatePathField))
+}
+
+func TestProfile_Load_Hooks(t *testing.T) {
+	file := useConfigFile(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+	require.NoError(t, os.WriteFile(file, []byte(`
+[prod]
+state_path = "prod.tfstate"
+
+[[prod.hooks]]
+attribute = "instance_type"
+command = "/usr/local/bin/resize-runbook"
+args = ["--notify", "ops"]
+timeout = "1m"
+
+[[prod.hooks]]
+attribute = "tags.*"
+command = "tag-audit"
+`), 0600))
+
+	profile := &config.Profile{ProfileName: "prod"}
+	found, err := profile.Load()
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []config.HookConfig{
+		{Attribute: "instance_type", Command: "/usr/local/bin/resize-runbook", Args: []string{"--notify", "ops"}, Timeout: time.Minute},
+		{Attribute: "tags.*", Command: "tag-audit"},
+	}, profile.Settings.Hooks)
+}
+
+func TestProfile_Load_Maintenance(t *testing.T) {
+	file := useConfigFile(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+	require.NoError(t, os.WriteFile(file, []byte(`
+[prod]
+state_path = "prod.tfstate"
+
+[[prod.maintenance]]
+resource = "aws_instance.db-*"
+start = 2026-10-16T22:00:00Z
+end = 2026-10-17T02:00:00Z
+reason = "CHG-1042 database failover test"
+
+[[prod.maintenance]]
+resource = "module.network.*"
+start = 2026-10-20T06:00:00Z
+duration = "90m"
+`), 0600))
+
+	profile := &config.Profile{ProfileName: "prod"}
+	found, err := profile.Load()
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []config.MaintenanceConfig{
+		{
+			Resource: "aws_instance.db-*",
+			Start:    time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC),
+			End:      time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC),
+			Reason:   "CHG-1042 database failover test",
+		},
+		{Resource: "module.network.*", Start: time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC), Duration: 90 * time.Minute},
+	}, profile.Settings.Maintenance)
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileNames are the names of the project file, looked up in the working
+// directory and its parents.
+var ProjectFileNames = []string{"driftwatcher.yaml", "driftwatcher.yml"}
+
+// ProjectOutput selects the reporter of a project.
+type ProjectOutput struct {
+	// Format is the output format, e.g. json, csv or diff.
+	Format string `yaml:"format"`
+	// File is the file reports are written to.
+	File string `yaml:"file"`
+}
+
+// ProjectConfig is a project file, a driftwatcher.yaml kept next to the Terraform
+// code, declaring how drift is detected for the project. Paths are relative to the
+// directory of the file.
+type ProjectConfig struct {
+	// Path is the file the project was loaded from.
+	Path string `yaml:"-"`
+
+	State        string        `yaml:"state"`
+	StateManager string        `yaml:"state_manager"`
+	Provider     string        `yaml:"provider"`
+	AWSProfile   string        `yaml:"aws_profile"`
+	Resource     string        `yaml:"resource"`
+	Attributes   []string      `yaml:"attributes"`
+	Output       ProjectOutput `yaml:"output"`
+	// Exclude holds glob patterns of resource addresses that are not checked, and
+	// IgnoreFile a file of such patterns.
+	Exclude    []string `yaml:"exclude"`
+	IgnoreFile string   `yaml:"ignore_file"`
+	// Policies are Rego policy files or directories evaluated over every report.
+	Policies []string `yaml:"policies"`
+}
+
+// FindProjectFile returns the project file in dir or the closest of its parents, or
+// an empty path when there is none.
+func FindProjectFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	for {
+		for _, name := range ProjectFileNames {
+			candidate := filepath.Join(dir, name)
+			info, err := os.Stat(candidate)
+			if err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("failed to check project file: %w", err)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads the project file at filePath. Unknown keys are rejected so that a
+// misspelled setting is not silently ignored, and relative paths are resolved
+// against the directory of the file.
+func LoadProject(filePath string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	project := &ProjectConfig{}
+	if err := decoder.Decode(project); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: failed to parse project file: %w", filePath, err)
+	}
+	project.Path = filePath
+
+	dir := filepath.Dir(filePath)
+	paths := []*string{&project.State, &project.Output.File, &project.IgnoreFile}
+	for i := range project.Policies {
+		paths = append(paths, &project.Policies[i])
+	}
+	for _, path := range paths {
+		if *path, err = projectPath(dir, *path); err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+	return project, nil
+}
+
+// Flags returns the detect flags set by the project, keyed by flag name. List values
+// are comma-separated.
+func (p *ProjectConfig) Flags() map[string]string {
+	return map[string]string{
+		"configfile":    p.State,
+		"state-manager": p.StateManager,
+		"provider":      p.Provider,
+		"awsprofile":    p.AWSProfile,
+		"resource":      p.Resource,
+		"attributes":    strings.Join(p.Attributes, ","),
+		"format":        p.Output.Format,
+		"output-file":   p.Output.File,
+		"exclude":       strings.Join(p.Exclude, ","),
+		"ignore-file":   p.IgnoreFile,
+		"policy":        strings.Join(p.Policies, ","),
+	}
+}
+
+// projectPath resolves a path of the project file against its directory, after
+// expanding it with ExpandPath. Standard input and remote URIs such as s3://bucket/key
+// are kept as they are.
+func projectPath(dir, path string) (string, error) {
+	if path == "" || path == "-" || strings.Contains(path, "://") {
+		return path, nil
+	}
+	path, err := ExpandPath(path)
+	if err != nil || filepath.IsAbs(path) {
+		return path, err
+	}
+	return filepath.Join(dir, path), nil
+}
@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"drift-watcher/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "envs", "prod")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	path, err := config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "driftwatcher.yaml"), []byte("provider: aws\n"), 0o644))
+	path, err = config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "driftwatcher.yaml"), path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "driftwatcher.yml"), []byte("provider: aws\n"), 0o644))
+	path, err = config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(nested, "driftwatcher.yml"), path, "the closest project file wins")
+}
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "driftwatcher.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+state: states/prod.tfstate
+provider: aws
+resource: aws_instance
+attributes: [instance_type, tags.Env]
+output:
+  format: json
+  file: reports/drift.json
+exclude: ["aws_instance.scratch*"]
+policies: [policies]
+`), 0o644))
+
+	project, err := config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "states", "prod.tfstate"), project.State)
+	assert.Equal(t, filepath.Join(dir, "reports", "drift.json"), project.Output.File)
+	assert.Equal(t, []string{filepath.Join(dir, "policies")}, project.Policies)
+
+	flags := project.Flags()
+	assert.Equal(t, "instance_type,tags.Env", flags["attributes"])
+	assert.Equal(t, "json", flags["format"])
+	assert.Equal(t, "aws_instance.scratch*", flags["exclude"])
+	assert.Empty(t, flags["ignore-file"])
+}
+
+func TestLoadProject_RemoteState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "driftwatcher.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("state: s3://states/prod.tfstate\n"), 0o644))
+
+	project, err := config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://states/prod.tfstate", project.State)
+}
+
+func TestLoadProject_UnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "driftwatcher.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("atributes: [instance_type]\n"), 0o644))
+
+	_, err := config.LoadProject(path)
+	assert.ErrorContains(t, err, "field atributes not found")
+}
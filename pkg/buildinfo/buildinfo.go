@@ -0,0 +1,117 @@
+// Package buildinfo describes the driftwatcher binary: its version, the commit it was
+// built from and when, so that reports and traces identify the release that produced
+// them. Release builds inject the values with -ldflags:
+//
+//	go build -ldflags "-X drift-watcher/pkg/buildinfo.version=v1.4.0 \
+//		-X drift-watcher/pkg/buildinfo.commit=$(git rev-parse HEAD) \
+//		-X drift-watcher/pkg/buildinfo.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them fall back to the VCS information Go records in the binary.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// DevVersion is the version of builds that were not given one.
+const DevVersion = "dev"
+
+// modulePath is the path of the driftwatcher module.
+const modulePath = "drift-watcher"
+
+// Set at build time with -ldflags -X.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info is the build information of the binary.
+type Info struct {
+	Version string `json:"version"`
+	// Commit is the VCS revision the binary was built from and Date when, in RFC 3339
+	// format. Either is empty when unknown.
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Modified is set when the binary was built from a working tree with uncommitted
+	// changes.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Get returns the build information of the binary: the values injected at build
+// time, completed with the module version and VCS settings Go records when the
+// binary is built from the driftwatcher module.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		complete(&info, build)
+	}
+	if info.Version == "" {
+		info.Version = DevVersion
+	}
+	return info
+}
+
+// complete fills in the fields of info that were not injected from build.
+func complete(info *Info, build *debug.BuildInfo) {
+	module := &build.Main
+	if module.Path != modulePath {
+		// driftwatcher is a dependency of the binary, whose VCS settings are not ours
+		module = nil
+		for _, dep := range build.Deps {
+			if dep.Path == modulePath {
+				module = dep
+			}
+		}
+		if module != nil && info.Version == "" {
+			info.Version = module.Version
+		}
+		return
+	}
+	if info.Version == "" && module.Version != "" && module.Version != "(devel)" {
+		info.Version = module.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+}
+
+// String describes the build in a single line, such as
+// "v1.4.0 (commit 1a2b3c4, built 2025-06-01T10:00:00Z, go1.24.2 linux/amd64)".
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		short := i.Commit[:min(len(i.Commit), 7)]
+		if i.Modified {
+			short += "-dirty"
+		}
+		details = append(details, "commit "+short)
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	details = append(details, i.GoVersion+" "+i.Platform)
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
@@ -0,0 +1,53 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet_Defaults(t *testing.T) {
+	info := Get()
+	assert.NotEmpty(t, info.Version)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.NotEmpty(t, info.Platform)
+}
+
+func TestComplete_MainModule(t *testing.T) {
+	info := Info{}
+	complete(&info, &debug.BuildInfo{
+		Main: debug.Module{Path: modulePath, Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1a2b3c4d5e6f"},
+			{Key: "vcs.time", Value: "2025-06-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+	assert.Equal(t, Info{Commit: "1a2b3c4d5e6f", Date: "2025-06-01T10:00:00Z", Modified: true}, info, "a devel build has no version")
+
+	injected := Info{Version: "v1.4.0", Commit: "ffffff0"}
+	complete(&injected, &debug.BuildInfo{
+		Main:     debug.Module{Path: modulePath, Version: "v1.3.0"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "1a2b3c4d5e6f"}},
+	})
+	assert.Equal(t, "v1.4.0", injected.Version, "injected values win")
+	assert.Equal(t, "ffffff0", injected.Commit)
+}
+
+func TestComplete_Dependency(t *testing.T) {
+	info := Info{}
+	complete(&info, &debug.BuildInfo{
+		Main:     debug.Module{Path: "example.com/tool"},
+		Deps:     []*debug.Module{{Path: modulePath, Version: "v1.2.0"}},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "1a2b3c4d5e6f"}},
+	})
+	assert.Equal(t, Info{Version: "v1.2.0"}, info, "the VCS settings of the embedding program are not ours")
+}
+
+func TestInfo_String(t *testing.T) {
+	info := Info{Version: "v1.4.0", Commit: "1a2b3c4d5e6f", Date: "2025-06-01T10:00:00Z", GoVersion: "go1.24.2", Platform: "linux/amd64"}
+	assert.Equal(t, "v1.4.0 (commit 1a2b3c4, built 2025-06-01T10:00:00Z, go1.24.2 linux/amd64)", info.String())
+
+	assert.Equal(t, "dev (go1.24.2 linux/amd64)", Info{Version: "dev", GoVersion: "go1.24.2", Platform: "linux/amd64"}.String())
+}
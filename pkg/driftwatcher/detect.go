@@ -0,0 +1,787 @@
+package driftwatcher
+
+import (
+	"context"
+	"drift-watcher/pkg/buildinfo"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/cost"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/filter"
+	"drift-watcher/pkg/services/gitcontext"
+	"drift-watcher/pkg/services/hooks"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/incremental"
+	"drift-watcher/pkg/services/maintenance"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/progress"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/remediation"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/sample"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"drift-watcher/pkg/telemetry"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultConcurrency is the number of resources checked in parallel by default.
+const DefaultConcurrency = 5
+
+// DefaultShutdownTimeout is how long checks in progress may finish after the scan
+// is interrupted.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// detectionOptions holds the optional behaviour of RunDriftDetection.
+type detectionOptions struct {
+	concurrency int
+	stdin       io.Reader
+	redactor    *redact.Redactor
+	remediation *remediation.Engine
+	resolver    provider.ReferenceResolverI
+	advisor     provider.AdvisorI
+	// dataSources holds the data sources of the scanned state, which the advisor
+	// reads the arguments of.
+	dataSources []statemanager.StateResource
+	policies    *policy.Engine
+	hooks       *hooks.Runner
+	maintenance *maintenance.Schedule
+	costs       *cost.Estimator
+	lister      provider.ResourceListerI
+	filters     []filter.Filter
+	exclusions  *ignore.Matcher
+	progress    *progress.Tracker
+	shutdown    time.Duration
+	perResource time.Duration
+	outputs     bool
+	sampling    sample.Sampling
+	incremental *incremental.Cache
+	commits     *gitcontext.CommitMap
+}
+
+// DetectionOption configures optional behaviour of RunDriftDetection.
+type DetectionOption func(*detectionOptions)
+
+// WithStdin sets the reader the state is read from when the state path is
+// statemanager.StdinStatePath. It defaults to os.Stdin.
+func WithStdin(r io.Reader) DetectionOption {
+	return func(o *detectionOptions) {
+		o.stdin = r
+	}
+}
+
+// WithRedaction redacts the values of sensitive attributes with redactor before each
+// report is written.
+func WithRedaction(redactor *redact.Redactor) DetectionOption {
+	return func(o *detectionOptions) {
+		o.redactor = redactor
+	}
+}
+
+// WithFilters limits the check to the resources of the state that match filters.
+func WithFilters(filters ...filter.Filter) DetectionOption {
+	return func(o *detectionOptions) {
+		o.filters = filters
+	}
+}
+
+// WithExclusions skips the resources excluded by matcher. Each skipped resource is
+// reported with the status driftchecker.Skipped.
+func WithExclusions(matcher *ignore.Matcher) DetectionOption {
+	return func(o *detectionOptions) {
+		o.exclusions = matcher
+	}
+}
+
+// WithProgress reports the progress of the scan to tracker.
+func WithProgress(tracker *progress.Tracker) DetectionOption {
+	return func(o *detectionOptions) {
+		o.progress = tracker
+	}
+}
+
+// WithShutdownTimeout sets how long checks already in progress may finish once ctx
+// is cancelled, before they are cancelled too and the partial results are flushed.
+func WithShutdownTimeout(timeout time.Duration) DetectionOption {
+	return func(o *detectionOptions) {
+		o.shutdown = timeout
+	}
+}
+
+// WithResourceTimeout bounds the checks of every resource, so that a single hung
+// provider call cannot stall the scan. A resource whose check times out is logged and
+// the scan moves on. Zero, the default, sets no limit.
+func WithResourceTimeout(timeout time.Duration) DetectionOption {
+	return func(o *detectionOptions) {
+		o.perResource = timeout
+	}
+}
+
+// WithConcurrency sets the number of resources checked in parallel. Values below one
+// fall back to the default.
+func WithConcurrency(n int) DetectionOption {
+	return func(o *detectionOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithSampling checks only the sample of the selected resources described by
+// sampling, for a quick smoke check of a very large state. The sample is recorded in
+// the run metadata of every report, so the reports are not mistaken for a complete
+// scan.
+func WithSampling(sampling sample.Sampling) DetectionOption {
+	return func(o *detectionOptions) {
+		o.sampling = sampling
+	}
+}
+
+// WithChangedOnly skips the resources that have not changed in state since cache
+// recorded their last check, unless that check is older than the TTL of the cache.
+// Every resource checked is recorded in the cache, which is saved once the scan ends.
+func WithChangedOnly(cache *incremental.Cache) DetectionOption {
+	return func(o *detectionOptions) {
+		o.incremental = cache
+	}
+}
+
+// WithStateCommits looks up the commit the checked state serial was applied from in
+// commits, and warns when it is not part of the branch checked out for the run.
+func WithStateCommits(commits *gitcontext.CommitMap) DetectionOption {
+	return func(o *detectionOptions) {
+		o.commits = commits
+	}
+}
+
+// WithUnmanagedScan reports live resources listed by lister that are missing from
+// the state, each with a suggested import block.
+func WithUnmanagedScan(lister provider.ResourceListerI) DetectionOption {
+	return func(o *detectionOptions) {
+		o.lister = lister
+	}
+}
+
+// WithRemediation reverts remediable drift with engine before each report is written.
+func WithRemediation(engine *remediation.Engine) DetectionOption {
+	return func(o *detectionOptions) {
+		o.remediation = engine
+	}
+}
+
+// WithPolicies evaluates the compliance policies of engine over every report and
+// attaches the violations to it.
+func WithPolicies(engine *policy.Engine) DetectionOption {
+	return func(o *detectionOptions) {
+		o.policies = engine
+	}
+}
+
+// WithHooks runs the hooks registered for the drifted attributes of every report.
+// RunDriftDetection returns once the hooks it started have finished.
+func WithHooks(runner *hooks.Runner) DetectionOption {
+	return func(o *detectionOptions) {
+		o.hooks = runner
+	}
+}
+
+// WithMaintenance flags the drift found during the maintenance windows of schedule as
+// expected. Expected drift is reported, but runs no hook.
+func WithMaintenance(schedule *maintenance.Schedule) DetectionOption {
+	return func(o *detectionOptions) {
+		o.maintenance = schedule
+	}
+}
+
+// WithCostEstimates annotates the drifted attributes of every report with the change
+// of the monthly cost of the resource they cause, estimated by estimator.
+func WithCostEstimates(estimator *cost.Estimator) DetectionOption {
+	return func(o *detectionOptions) {
+		o.costs = estimator
+	}
+}
+
+// WithReferenceResolution names the resources referenced by drifted attributes, such
+// as the subnet or security groups of an instance, with resolver.
+func WithReferenceResolution(resolver provider.ReferenceResolverI) DetectionOption {
+	return func(o *detectionOptions) {
+		o.resolver = resolver
+	}
+}
+
+// WithAdvisories adds the advisories of advisor to every report, such as a newer AMI
+// matching the filters of the data source the AMI of an instance was selected with.
+func WithAdvisories(advisor provider.AdvisorI) DetectionOption {
+	return func(o *detectionOptions) {
+		o.advisor = advisor
+	}
+}
+
+// RunDriftDetection orchestrates the complete drift detection workflow for infrastructure resources.
+// This function coordinates multiple components to parse IaC state, retrieve live infrastructure
+// data, compare states, and generate drift reports. It processes resources concurrently using a
+// worker pool pattern to improve performance when checking multiple resources.
+//
+// The workflow consists of the following steps:
+//  1. Parse the IaC state file to extract resource definitions
+//  2. Retrieve resources of the specified type from the parsed state and apply any filters
+//  3. For each resource, concurrently:
+//     a. Fetch live infrastructure metadata from the cloud provider
+//     b. Compare the desired state with actual infrastructure state
+//     c. Generate and write drift reports for any detected differences
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control across all operations
+//   - tfConfigPath: File system path to the Terraform state file (.tfstate), or "-" to read it from stdin
+//   - resourceType: Type of resources to check for drift (e.g., "aws_instance" )
+//   - attributesToTrack: List of specific resource attributes to monitor for drift
+//   - stateManager: Interface for parsing and retrieving data from Terraform state files
+//   - platformProvider: Interface for retrieving live infrastructure data from cloud providers
+//   - driftChecker: Interface for comparing desired state with actual infrastructure state
+//   - outputWriter: Interface for writing drift reports to various output destinations
+//   - opts: Optional behaviour such as remediation of detected drift
+//
+// Returns:
+//   - error: Any critical error that prevents the drift detection process from completing
+func RunDriftDetection(
+	ctx context.Context,
+	tfConfigPath string,
+	resourceType string,
+	attributesToTrack []string,
+	stateManager statemanager.StateManagerI,
+	platformProvider provider.ProviderI,
+	driftChecker driftchecker.DriftChecker,
+	outputWriter reporter.OutputWriter,
+	opts ...DetectionOption,
+) (err error) {
+	options := &detectionOptions{concurrency: DefaultConcurrency, stdin: os.Stdin, shutdown: DefaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.concurrency < 1 {
+		options.concurrency = DefaultConcurrency
+	}
+
+	// attributes are reported under their canonical path, so tags["Name"] and tags.Name
+	// are the same attribute for ignore rules, comparisons and remediation, and aliases
+	// under the Terraform name the state holds them under
+	aliases, _ := platformProvider.(provider.AttributeAliasesI)
+	canonical := make([]string, 0, len(attributesToTrack))
+	for _, attribute := range attributesToTrack {
+		c, err := attrpath.Canonical(attribute)
+		if err != nil {
+			return err
+		}
+		if aliases != nil {
+			c = aliases.CanonicalAttribute(resourceType, c)
+		}
+		canonical = append(canonical, c)
+	}
+	attributesToTrack = canonical
+
+	// every invocation reports under the run of its context, or a run of its own
+	run := &driftchecker.RunMetadata{RunId: uuid.NewString(), StartedAt: time.Now()}
+	if parent := driftchecker.RunFromContext(ctx); parent != nil {
+		*run = *parent
+	}
+	if run.DriftwatcherVersion == "" {
+		run.DriftwatcherVersion = buildinfo.Get().Version
+	}
+	run.StatePath = tfConfigPath
+	run.ResourceType = resourceType
+	run.Attributes = attributesToTrack
+	ctx = driftchecker.NewRunContext(ctx, run)
+	outputWriter = &runWriter{next: outputWriter, run: run}
+
+	ctx, span := telemetry.StartSpan(ctx, "RunDriftDetection",
+		attribute.String("drift.run_id", run.RunId),
+		attribute.String("drift.state_path", tfConfigPath),
+		attribute.String("drift.resource_type", resourceType),
+		attribute.StringSlice("drift.attributes", attributesToTrack),
+	)
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	var stateContent statemanager.StateContent
+	if tfConfigPath == statemanager.StdinStatePath {
+		stateContent, err = stateManager.ParseState(ctx, options.stdin)
+	} else {
+		stateContent, err = stateManager.ParseStateFile(ctx, tfConfigPath)
+	}
+	if err != nil {
+		logger(ctx).Error("Failed to parse desired state information from the state file", "error", err)
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+	run.Lineage = stateContent.StateId
+	run.Serial, _ = stateContent.ToolMetadata["serial"].(int)
+	run.TerraformVersion = stateContent.ToolVersion
+	options.commits.Check(ctx, run)
+	outputWriter = &dependencyWriter{next: outputWriter, graph: statemanager.NewDependencyGraph(stateContent.Resource)}
+	if options.advisor != nil {
+		for _, resource := range stateContent.Resource {
+			if resource.Mode == "data" {
+				options.dataSources = append(options.dataSources, resource)
+			}
+		}
+	}
+
+	resources, err := stateManager.RetrieveResources(ctx, stateContent, resourceType)
+	if err != nil {
+		logger(ctx).Error("Failed to retrieve resources from state", "error", err)
+		return fmt.Errorf("failed to retrieve resources: %w", err)
+	}
+	// every instance of a count or for_each resource is checked on its own
+	resources = statemanager.ExpandInstances(resources)
+	selected := filter.Apply(resources, options.filters)
+	if len(selected) != len(resources) {
+		logger(ctx).Info("Filtered resources", "selected", len(selected), "total", len(resources))
+	}
+	selected, skipped := options.exclusions.Split(selected)
+	if options.sampling.Enabled() {
+		sampled := options.sampling.Select(selected)
+		run.Sample = &driftchecker.SampleSummary{
+			Percent: options.sampling.Percent,
+			Limit:   options.sampling.Limit,
+			Seed:    options.sampling.Seed,
+			Sampled: len(sampled),
+			Total:   len(selected),
+		}
+		logger(ctx).Warn("Checking a sample of the resources, the reports do not cover the whole state", "sample", run.Sample.String())
+		selected = sampled
+	}
+	var scan *incremental.Scan
+	if options.incremental != nil {
+		scan = options.incremental.Scan(tfConfigPath, run.Lineage, run.Serial, attributesToTrack)
+		defer func() {
+			if saveErr := options.incremental.Save(); saveErr != nil {
+				logger(ctx).Warn("Failed to save the incremental scan cache", "error", saveErr)
+			}
+		}()
+		changed, unchanged := scan.Split(selected)
+		run.Incremental = &driftchecker.IncrementalSummary{
+			PreviousSerial: scan.PreviousSerial,
+			Changed:        len(changed),
+			Unchanged:      len(unchanged),
+		}
+		logger(ctx).Info("Skipping resources unchanged in state since their last check", "changed_only", run.Incremental.String())
+		selected = changed
+	}
+	span.SetAttributes(
+		attribute.Int("drift.resource_count", len(selected)),
+		attribute.Int("drift.skipped_count", len(skipped)),
+	)
+
+	if len(selected) == 0 && len(skipped) == 0 && options.lister == nil {
+		if run.Incremental != nil && run.Incremental.Unchanged > 0 {
+			logger(ctx).Info("No resources changed in state since their last check.")
+			return nil
+		}
+		logger(ctx).Error("No resources found to check for drift.")
+		return nil
+	}
+
+	if err := reporter.BeginWriter(ctx, outputWriter, run); err != nil {
+		logger(ctx).Error("Failed to begin reporter", "error", err)
+		return fmt.Errorf("failed to begin reports: %w", err)
+	}
+	options.progress.Start(len(selected))
+
+	// Checks run on a context that outlives an interrupt by the shutdown timeout, so
+	// checks in progress can finish and their reports are not cut off mid-write.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-workCtx.Done():
+			return
+		}
+		timer := time.NewTimer(options.shutdown)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancelWork()
+		case <-workCtx.Done():
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+	maxWorker := options.concurrency
+	channel := make(chan statemanager.StateResource, maxWorker)
+	var checked atomic.Int64
+
+	for range maxWorker {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for resource := range channel {
+				if ctx.Err() != nil {
+					// interrupted: drop queued resources, only checks in progress finish
+					continue
+				}
+				options.progress.Begin(ignore.Address(resource))
+				if report := checkResource(workCtx, resourceType, resource, attributesToTrack, platformProvider, driftChecker, outputWriter, options); report != nil {
+					scan.Checked(resource, report.Status == driftchecker.Match)
+				}
+				options.progress.Complete()
+				checked.Add(1)
+			}
+		}()
+	}
+
+dispatch:
+	for _, resource := range selected {
+		select {
+		case channel <- resource:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(channel)
+
+	wg.Wait()
+	options.hooks.Wait()
+	options.progress.Finish()
+
+	if ctx.Err() != nil {
+		return flushPartial(ctx, outputWriter, int(checked.Load()), len(selected))
+	}
+
+	reportSkipped(ctx, resourceType, skipped, outputWriter)
+
+	if options.outputs {
+		reportOutputs(ctx, resourceType, stateContent.Outputs, selected, platformProvider, outputWriter)
+	}
+
+	if options.lister != nil {
+		reportUnmanaged(ctx, resourceType, resources, options.lister, outputWriter)
+	}
+
+	if err := reporter.FlushWriter(ctx, outputWriter); err != nil {
+		logger(ctx).Error("Failed to flush reporter", "error", err)
+		return fmt.Errorf("failed to flush reports: %w", err)
+	}
+
+	logger(ctx).Info("Drift detection completed.")
+	return nil
+}
+
+// runWriter stamps the run metadata on every report before passing it on, so that
+// reports can be correlated whichever reporter or store they end up in.
+type runWriter struct {
+	next reporter.OutputWriter
+	run  *driftchecker.RunMetadata
+}
+
+func (w *runWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	report.Run = w.run
+	return w.next.WriteReport(ctx, report)
+}
+
+func (w *runWriter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	return reporter.BeginWriter(ctx, w.next, run)
+}
+
+func (w *runWriter) Flush(ctx context.Context) error {
+	return reporter.FlushWriter(ctx, w.next)
+}
+
+// dependencyWriter sets the dependents of the drifted resource on every report with
+// drift, so reporters can show the blast radius of the drift.
+type dependencyWriter struct {
+	next  reporter.OutputWriter
+	graph *statemanager.DependencyGraph
+}
+
+func (w *dependencyWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	if report.HasDrift && report.ResourceAddress != "" {
+		report.Dependents = w.graph.Dependents(report.ResourceAddress)
+	}
+	return w.next.WriteReport(ctx, report)
+}
+
+func (w *dependencyWriter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	return reporter.BeginWriter(ctx, w.next, run)
+}
+
+func (w *dependencyWriter) Flush(ctx context.Context) error {
+	return reporter.FlushWriter(ctx, w.next)
+}
+
+// flushPartial writes a report marking the scan as partial and flushes the reporter,
+// so that an interrupted scan still leaves complete output behind. The reporter is
+// flushed on a context that is no longer cancelled.
+func flushPartial(ctx context.Context, outputWriter reporter.OutputWriter, checked int, total int) error {
+	logger(ctx).Warn("Drift detection interrupted, flushing partial results", "checked", checked, "total", total)
+	flushCtx := context.WithoutCancel(ctx)
+
+	report := &driftchecker.DriftReport{
+		GeneratedAt: time.Now(),
+		Status:      driftchecker.Partial,
+		Summary: &driftchecker.ScanSummary{
+			Checked: checked,
+			Total:   total,
+			Reason:  context.Cause(ctx).Error(),
+		},
+	}
+	if err := outputWriter.WriteReport(flushCtx, report); err != nil {
+		logger(ctx).Error("Failed to write partial scan report", "error", err)
+	}
+	if err := reporter.FlushWriter(flushCtx, outputWriter); err != nil {
+		logger(ctx).Error("Failed to flush reporter", "error", err)
+		return fmt.Errorf("failed to flush reports: %w", err)
+	}
+	return fmt.Errorf("drift detection interrupted after %d of %d resources: %w", checked, total, ctx.Err())
+}
+
+// reportSkipped writes a report for every resource excluded by an ignore pattern, so
+// the output lists what was not checked.
+func reportSkipped(ctx context.Context, resourceType string, skipped []statemanager.StateResource, outputWriter reporter.OutputWriter) {
+	for _, resource := range skipped {
+		report := &driftchecker.DriftReport{
+			ResourceType:    resourceType,
+			ResourceName:    resource.Name,
+			ResourceAddress: resource.Address(),
+			IndexKey:        resource.IndexKey(),
+			GeneratedAt:     time.Now(),
+			Status:          driftchecker.Skipped,
+		}
+		if id, err := resource.AttributeValue("id"); err == nil {
+			report.ResourceId = id
+		}
+		if err := outputWriter.WriteReport(ctx, report); err != nil {
+			logger(ctx).Error("Failed to write report for skipped resource", "resource", ignore.Address(resource), "error", err)
+		}
+	}
+}
+
+// reportUnmanaged writes a report for every live resource that has no counterpart in
+// the state, suggesting how to import it.
+func reportUnmanaged(
+	ctx context.Context,
+	resourceType string,
+	resources []statemanager.StateResource,
+	lister provider.ResourceListerI,
+	outputWriter reporter.OutputWriter,
+) {
+	ctx, span := telemetry.StartSpan(ctx, "ReportUnmanaged", attribute.String("drift.resource_type", resourceType))
+	defer span.End()
+
+	liveIds, err := lister.ListResourceIds(ctx, resourceType)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		logger(ctx).Error("Failed to list live resources", "resource_type", resourceType, "error", err)
+		return
+	}
+
+	managed := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		for _, instance := range resource.Instances {
+			if id, ok := instance.Attributes["id"].(string); ok {
+				managed[id] = true
+			}
+		}
+	}
+
+	unmanaged := 0
+	for _, id := range liveIds {
+		if managed[id] {
+			continue
+		}
+		unmanaged++
+		report := &driftchecker.DriftReport{
+			ResourceId:       id,
+			ResourceType:     resourceType,
+			HasDrift:         true,
+			GeneratedAt:      time.Now(),
+			Status:           driftchecker.ResourceMissingInTerraform,
+			ImportSuggestion: terraform.SuggestImport(resourceType, id),
+		}
+		if err := outputWriter.WriteReport(ctx, report); err != nil {
+			logger(ctx).Error("Failed to write report for unmanaged resource", "resource_id", id, "error", err)
+		}
+	}
+	span.SetAttributes(attribute.Int("drift.unmanaged_count", unmanaged))
+}
+
+// checkResource runs the fetch, compare and report steps for a single resource
+// inside its own span. Failures are logged and recorded on the span rather than
+// returned, so that one bad resource does not stop the rest of the scan. It returns
+// the report of the resource once written, nil if the check failed or the report
+// could not be written.
+func checkResource(
+	ctx context.Context,
+	resourceType string,
+	resource statemanager.StateResource,
+	attributesToTrack []string,
+	platformProvider provider.ProviderI,
+	driftChecker driftchecker.DriftChecker,
+	outputWriter reporter.OutputWriter,
+	options *detectionOptions,
+) *driftchecker.DriftReport {
+	ctx, span := telemetry.StartSpan(ctx, "CheckResource",
+		attribute.String("drift.resource_type", resourceType),
+		attribute.String("drift.resource_name", resource.Name),
+	)
+	defer span.End()
+
+	// The resource timeout bounds the calls made for the resource. Hooks outlive the
+	// check and writing the report is not a provider call, so both use ctx.
+	checkCtx := ctx
+	if options.perResource > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeoutCause(ctx, options.perResource,
+			fmt.Errorf("resource check timed out after %s", options.perResource))
+		defer cancel()
+	}
+
+	infrastructureResource, err := platformProvider.InfrastructreMetadata(checkCtx, resourceType, resource)
+	if err != nil {
+		err = timeoutCause(checkCtx, err)
+		telemetry.RecordError(span, err)
+		logger(ctx).Error("Failed to retrieve infrastructure metadata", "resource_id", resource.Name, "error", err)
+		reportFailure(ctx, resourceType, resource, err, outputWriter)
+		return nil
+	}
+
+	// Compare the desired state (from state file) with the actual infrastructure state.
+	report, err := driftChecker.CompareStates(checkCtx, infrastructureResource, resource, attributesToTrack)
+	if err != nil {
+		err = timeoutCause(checkCtx, err)
+		telemetry.RecordError(span, err)
+		logger(ctx).Error("Failed to compare states for resource", "resource_id", resource.Name, "error", err)
+		reportFailure(ctx, resourceType, resource, err, outputWriter)
+		return nil
+	}
+	span.SetAttributes(attribute.Bool("drift.has_drift", report.HasDrift))
+
+	if options.remediation != nil {
+		if _, err := options.remediation.Remediate(checkCtx, resource, report); err != nil {
+			err = timeoutCause(checkCtx, err)
+			telemetry.RecordError(span, err)
+			logger(ctx).Error("Failed to remediate resource", "resource_id", resource.Name, "error", err)
+		}
+	}
+
+	if options.resolver != nil {
+		resolveReferences(checkCtx, options.resolver, resourceType, report)
+	}
+
+	// advisories are best effort, like reference names
+	if options.advisor != nil {
+		advisories, err := options.advisor.Advise(checkCtx, infrastructureResource, resource, options.dataSources)
+		if err != nil {
+			logger(ctx).Warn("Failed to check for advisories", "resource_id", resource.Name, "error", timeoutCause(checkCtx, err))
+		}
+		report.Advisories = advisories
+	}
+
+	options.costs.Annotate(resource, report)
+
+	// Redaction happens after remediation and cost estimates, which need the real
+	// values.
+	options.redactor.RedactReport(report, resource.SensitiveAttributes())
+
+	// Policies see redacted values so that violation messages cannot leak secrets.
+	if options.policies != nil {
+		input := policy.NewInput(resource, report)
+		input.Resource.Attributes = options.redactor.RedactAttributes(input.Resource.Attributes, resource.SensitiveAttributes())
+		violations, err := options.policies.Evaluate(checkCtx, input)
+		if err != nil {
+			err = timeoutCause(checkCtx, err)
+			telemetry.RecordError(span, err)
+			logger(ctx).Error("Failed to evaluate policies for resource", "resource_id", resource.Name, "error", err)
+		}
+		report.Violations = violations
+	}
+
+	options.maintenance.Annotate(resource, report, time.Now())
+	options.hooks.Dispatch(ctx, report)
+
+	// Write the drift report.
+	if err := outputWriter.WriteReport(ctx, report); err != nil {
+		telemetry.RecordError(span, err)
+		logger(ctx).Error("Failed to write report for resource", "resource_id", resource.Name, "error", err)
+		return nil
+	}
+	return report
+}
+
+// timeoutCause names the resource timeout as the cause of err when the check ran out
+// of time, rather than a bare context deadline error.
+func timeoutCause(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", context.Cause(ctx), err)
+	}
+	return err
+}
+
+// resolveReferences sets the names of the resources referenced by each drifted
+// attribute of report. Resolution is best effort: a failure is logged and the report
+// is written without names.
+func resolveReferences(ctx context.Context, resolver provider.ReferenceResolverI, resourceType string, report *driftchecker.DriftReport) {
+	for i := range report.DriftDetails {
+		item := &report.DriftDetails[i]
+		ids := referenceIds(item.TerraformValue, item.ActualValue)
+		if len(ids) == 0 {
+			continue
+		}
+		names, err := resolver.ResolveReferences(ctx, resourceType, item.Field, ids)
+		if err != nil {
+			logger(ctx).Warn("Failed to resolve references", "resource_id", report.ResourceId, "attribute", item.Field, "error", err)
+			continue
+		}
+		if len(names) > 0 {
+			item.References = names
+		}
+	}
+}
+
+// referenceIds returns the distinct identifiers held by attribute values, which are
+// either lists or comma-separated strings.
+func referenceIds(values ...any) []string {
+	var ids []string
+	add := func(id string) {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			for _, id := range strings.Split(v, ",") {
+				add(id)
+			}
+		case []any:
+			for _, id := range v {
+				if id, ok := id.(string); ok {
+					add(id)
+				}
+			}
+		case []string:
+			for _, id := range v {
+				add(id)
+			}
+		}
+	}
+	return ids
+}
+
+// logger returns the logger carried by ctx for the driftwatcher module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "driftwatcher")
+}
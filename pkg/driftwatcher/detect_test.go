@@ -0,0 +1,1139 @@
+package driftwatcher_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/buildinfo"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/logging/loggingtest"
+	"drift-watcher/pkg/services/cost"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/driftchecker/driftcheckerfakes"
+	"drift-watcher/pkg/services/filter"
+	"drift-watcher/pkg/services/hooks"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/incremental"
+	"drift-watcher/pkg/services/maintenance"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/progress"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/chaos"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/remediation"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"drift-watcher/pkg/services/sample"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDriftDetection_ParseStateFileError(t *testing.T) {
+	// Setup mocks
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
+	_ = mockInfraResource
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/nonexistent.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	assert.NoError(t, err)
+	assert.Equal(t, mockPlatformProvider.InfrastructreMetadataCallCount(), 0)
+	assert.Equal(t, mockDriftChecker.CompareStatesCallCount(), 0)
+	assert.Equal(t, mockReporter.WriteReportCallCount(), 0)
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "No resources found to check for drift")
+}
+
+func TestRunDriftDetection_RetrieveResourcesError(t *testing.T) {
+	// Setup mocks
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
+	_ = mockInfraResource
+
+	mockStateManager.RetrieveResourcesReturnsOnCall(0, []statemanager.StateResource{}, errors.New("retrieve error"))
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to retrieve resources: retrieve error")
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "Failed to retrieve resources from state")
+}
+
+func TestRunDriftDetection_NoResourcesFound(t *testing.T) {
+	// Setup mocks
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
+	_ = mockInfraResource
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "No resources found to check for drift.")
+}
+
+func TestRunDriftDetection_SuccessWithDrift(t *testing.T) {
+	// Setup mocks
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockInfraResource1 := &providerfakes.FakeInfrastructureResourceI{}
+	mockInfraResource2 := &providerfakes.FakeInfrastructureResourceI{}
+
+	// Prepare dummy resources
+	resource1 := statemanager.StateResource{
+		Name: "res1",
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{
+			{
+				Attributes: map[string]any{
+					"instance_type": "t2.micro",
+				},
+			},
+		},
+	}
+	resource2 := statemanager.StateResource{
+		Name: "res2",
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{
+			{
+				Attributes: map[string]any{
+					"instance_type": "t2.micro",
+				},
+			},
+		},
+	}
+	resources := []statemanager.StateResource{resource1, resource2}
+	_ = resources
+
+	// Mock behaviors
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
+	mockStateManager.RetrieveResourcesReturns(resources, nil)
+
+	mockInfraResource1.ResourceTypeReturnsOnCall(0, "aws_instance")
+	mockInfraResource1.AttributeValueReturnsOnCall(0, "t2.medium", nil)
+
+	mockInfraResource2.ResourceTypeReturnsOnCall(0, "aws_instance")
+	mockInfraResource2.AttributeValueReturnsOnCall(0, "t2.micro", nil)
+
+	mockPlatformProvider.InfrastructreMetadataReturnsOnCall(0, mockInfraResource1, nil)
+	mockPlatformProvider.InfrastructreMetadataReturnsOnCall(1, mockInfraResource2, nil)
+
+	driftReport1 := &driftchecker.DriftReport{
+		HasDrift: true,
+		Status:   driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.medium", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}
+	driftReport2 := &driftchecker.DriftReport{
+		HasDrift: false,
+		Status:   driftchecker.Match,
+	}
+	_, _ = driftReport1, driftReport2
+
+	mockDriftChecker.CompareStatesReturnsOnCall(0, driftReport1, nil)
+	mockDriftChecker.CompareStatesReturnsOnCall(1, driftReport2, nil)
+
+	mockReporter.WriteReportReturnsOnCall(0, nil)
+	mockReporter.WriteReportReturnsOnCall(1, nil)
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "level=INFO")
+	assert.Contains(t, buf.String(), "Drift detection completed.")
+}
+
+func TestRunDriftDetection_InfrastructureMetadataError(t *testing.T) {
+	// Setup mocks
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	mockInfraResource := &providerfakes.FakeInfrastructureResourceI{}
+	_ = mockInfraResource
+
+	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
+	resources := []statemanager.StateResource{resource1}
+
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
+	mockStateManager.RetrieveResourcesReturns(resources, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(nil, fmt.Errorf("infra metadata error"))
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	require.NoError(t, err) // Function should continue despite worker error
+
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "Failed to retrieve infrastructure metadata")
+	assert.Contains(t, buf.String(), "resource_id=res1")
+}
+
+func TestRunDriftDetection_CompareStatesError(t *testing.T) {
+	mockStateManager := statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := providerfakes.FakeProviderI{}
+	mockDriftChecker := driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := reporterfakes.FakeOutputWriter{}
+	mockInfraResource := providerfakes.FakeInfrastructureResourceI{}
+
+	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
+	resources := []statemanager.StateResource{resource1}
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
+	mockStateManager.RetrieveResourcesReturns(resources, nil)
+
+	mockInfraResource.ResourceTypeReturns("aws_instance")
+	mockPlatformProvider.InfrastructreMetadataReturns(&mockInfraResource, nil)
+
+	mockDriftChecker.CompareStatesReturns(nil, fmt.Errorf("compare states error"))
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, &mockStateManager, &mockPlatformProvider, &mockDriftChecker, &mockReporter)
+	require.NoError(t, err) // Function should continue despite worker error
+
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "Failed to compare states for resource")
+	assert.Contains(t, buf.String(), "resource_id=res1")
+}
+
+func TestRunDriftDetection_WriteReportError(t *testing.T) {
+	mockStateManager := statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := providerfakes.FakeProviderI{}
+	mockDriftChecker := driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := reporterfakes.FakeOutputWriter{}
+	mockInfraResource := providerfakes.FakeInfrastructureResourceI{}
+
+	resource1 := statemanager.StateResource{Name: "res1", Type: "aws_instance"}
+	resources := []statemanager.StateResource{resource1}
+
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{}, nil)
+	mockStateManager.RetrieveResourcesReturns(resources, nil)
+
+	mockInfraResource.ResourceTypeReturns("aws")
+	mockPlatformProvider.InfrastructreMetadataReturns(&mockInfraResource, nil)
+
+	driftReport1 := &driftchecker.DriftReport{HasDrift: true}
+	mockDriftChecker.CompareStatesReturns(driftReport1, nil)
+	mockReporter.WriteReportReturns(fmt.Errorf("write report error"))
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "/tmp/test.tfstate", "aws_instance", []string{"instance_type"}, &mockStateManager, &mockPlatformProvider, &mockDriftChecker, &mockReporter)
+	require.NoError(t, err) // Function should continue despite worker error
+
+	assert.Contains(t, buf.String(), "level=ERROR")
+	assert.Contains(t, buf.String(), "Failed to write report for resource")
+	assert.Contains(t, buf.String(), "resource_id=res1")
+}
+
+func TestRunDriftDetection_WithRedaction(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "db", Instances: []statemanager.ResourceInstance{{SensitiveAttributes: []string{"connection_string"}}}},
+	}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{
+		HasDrift: true,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "connection_string", TerraformValue: "postgres://a", ActualValue: "postgres://b", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "user_data", TerraformValue: "echo a", ActualValue: "echo b", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}, nil)
+
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_db_instance", []string{"connection_string"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithRedaction(redactor))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, redact.Masked, report.DriftDetails[0].ActualValue, "attributes marked sensitive in state are redacted")
+	assert.Equal(t, redact.Masked, report.DriftDetails[1].ActualValue, "attributes matching a pattern are redacted")
+	assert.Equal(t, "t2.large", report.DriftDetails[2].ActualValue)
+}
+
+func TestRunDriftDetection_WithRemediation(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	fakeRemediator := &providerfakes.FakeRemediatorI{}
+	fakeRemediator.CanRemediateReturns(true)
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(true), nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithRemediation(remediation.NewEngine(fakeRemediator, nil)))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, fakeRemediator.RemediateCallCount())
+	_, written := mockReporter.WriteReportArgsForCall(0)
+	assert.True(t, written.DriftDetails[0].Remediated, "reports are written after remediation")
+}
+
+func TestRunDriftDetection_WithUnmanagedScan(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	fakeLister := &providerfakes.FakeResourceListerI{}
+
+	managed := statemanager.StateResource{
+		Name:      "web",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-managed"}}},
+	}
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{managed}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+	fakeLister.ListResourceIdsReturns([]string{"i-managed", "i-stray"}, nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithUnmanagedScan(fakeLister))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, mockReporter.WriteReportCallCount())
+	_, unmanaged := mockReporter.WriteReportArgsForCall(1)
+	assert.Equal(t, "i-stray", unmanaged.ResourceId)
+	assert.Equal(t, driftchecker.ResourceMissingInTerraform, unmanaged.Status)
+	require.NotNil(t, unmanaged.ImportSuggestion)
+	assert.Contains(t, unmanaged.ImportSuggestion.Block, `id = "i-stray"`)
+}
+
+func TestRunDriftDetection_WithUnmanagedScan_EmptyState(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	fakeLister := &providerfakes.FakeResourceListerI{}
+	fakeLister.ListResourceIdsReturns([]string{"i-stray"}, nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "/tmp/test.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, &providerfakes.FakeProviderI{}, &driftcheckerfakes.FakeDriftChecker{}, mockReporter,
+		driftwatcher.WithUnmanagedScan(fakeLister))
+	require.NoError(t, err)
+	assert.Equal(t, 1, mockReporter.WriteReportCallCount(), "live resources are reported even when the state has none")
+}
+
+func TestRunDriftDetection_WithFilters(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	fakeLister := &providerfakes.FakeResourceListerI{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web-1", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+		{Name: "api", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-2"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+	fakeLister.ListResourceIdsReturns([]string{"i-1", "i-2"}, nil)
+
+	filters, err := filter.ParseAll([]string{"name=web-*"})
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithFilters(filters...), driftwatcher.WithUnmanagedScan(fakeLister))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockPlatformProvider.InfrastructreMetadataCallCount())
+	_, _, checked := mockPlatformProvider.InfrastructreMetadataArgsForCall(0)
+	assert.Equal(t, "web-1", checked.Name)
+	assert.Equal(t, 1, mockReporter.WriteReportCallCount(), "resources filtered out are not reported as unmanaged")
+}
+
+func TestRunDriftDetection_WithExclusions(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+		{Type: "aws_instance", Name: "scratch", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-2"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+
+	exclusions, err := ignore.New([]string{"aws_instance.scratch"})
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithExclusions(exclusions))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockPlatformProvider.InfrastructreMetadataCallCount())
+	require.Equal(t, 2, mockReporter.WriteReportCallCount())
+	_, skipped := mockReporter.WriteReportArgsForCall(1)
+	assert.Equal(t, driftchecker.Skipped, skipped.Status)
+	assert.Equal(t, "scratch", skipped.ResourceName)
+	assert.Equal(t, "i-2", skipped.ResourceId)
+}
+
+func TestRunDriftDetection_WithSampling(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	var resources []statemanager.StateResource
+	for i := range 20 {
+		resources = append(resources, statemanager.StateResource{Type: "aws_instance", Name: fmt.Sprintf("web-%d", i)})
+	}
+	mockStateManager.RetrieveResourcesReturns(resources, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesStub = func(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []string) (*driftchecker.DriftReport, error) {
+		return reporter.CreateDummyDriftReport(false), nil
+	}
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithSampling(sample.Sampling{Percent: 25, Limit: 3, Seed: 42}))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, mockPlatformProvider.InfrastructreMetadataCallCount(), "the 25% sample of 5 resources is cut to the limit")
+	require.Equal(t, 3, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.NotNil(t, report.Run)
+	assert.Equal(t, &driftchecker.SampleSummary{Percent: 25, Limit: 3, Seed: 42, Sampled: 3, Total: 20}, report.Run.Sample)
+}
+
+func TestRunDriftDetection_WithChangedOnly(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+
+	state := func(webType string) []statemanager.StateResource {
+		return []statemanager.StateResource{
+			{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"instance_type": webType}}}},
+			{Type: "aws_instance", Name: "api", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"instance_type": "t2.micro"}}}},
+		}
+	}
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{StateId: "lineage-1", ToolMetadata: map[string]any{"serial": 4}}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesStub = func(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []string) (*driftchecker.DriftReport, error) {
+		return reporter.CreateDummyDriftReport(false), nil
+	}
+
+	cache, err := incremental.Open(filepath.Join(t.TempDir(), "incremental.json"), time.Hour)
+	require.NoError(t, err)
+
+	mockStateManager.RetrieveResourcesReturns(state("t2.micro"), nil)
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, &reporterfakes.FakeOutputWriter{},
+		driftwatcher.WithChangedOnly(cache))
+	require.NoError(t, err)
+	assert.Equal(t, 2, mockPlatformProvider.InfrastructreMetadataCallCount())
+
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{StateId: "lineage-1", ToolMetadata: map[string]any{"serial": 5}}, nil)
+	mockStateManager.RetrieveResourcesReturns(state("t2.large"), nil)
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithChangedOnly(cache))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, mockPlatformProvider.InfrastructreMetadataCallCount(), "only the changed resource is checked again")
+	_, _, resource := mockPlatformProvider.InfrastructreMetadataArgsForCall(2)
+	assert.Equal(t, "web", resource.Name)
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.NotNil(t, report.Run)
+	assert.Equal(t, &driftchecker.IncrementalSummary{PreviousSerial: 4, Changed: 1, Unchanged: 1}, report.Run.Incremental)
+}
+
+func TestRunDriftDetection_WithChangedOnly_PersistentDrift(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{StateId: "lineage-1", ToolMetadata: map[string]any{"serial": 4}}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"instance_type": "t2.micro"}}}},
+		{Type: "aws_instance", Name: "api", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"instance_type": "t2.micro"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	// web was resized by hand and stays drifted, its state does not change
+	mockDriftChecker.CompareStatesStub = func(_ context.Context, _ provider.InfrastructureResourceI, resource statemanager.StateResource, _ []string) (*driftchecker.DriftReport, error) {
+		report := reporter.CreateDummyDriftReport(resource.Name == "web")
+		report.ResourceName = resource.Name
+		return report, nil
+	}
+
+	cache, err := incremental.Open(filepath.Join(t.TempDir(), "incremental.json"), time.Hour)
+	require.NoError(t, err)
+	run := func() []*driftchecker.DriftReport {
+		mockReporter := &reporterfakes.FakeOutputWriter{}
+		err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+			mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+			driftwatcher.WithChangedOnly(cache))
+		require.NoError(t, err)
+		var reports []*driftchecker.DriftReport
+		for i := range mockReporter.WriteReportCallCount() {
+			_, report := mockReporter.WriteReportArgsForCall(i)
+			reports = append(reports, report)
+		}
+		return reports
+	}
+
+	assert.Len(t, run(), 2)
+	reports := run()
+	require.Len(t, reports, 1, "the drifted resource is checked again, the one in sync is not")
+	assert.Equal(t, "web", reports[0].ResourceName)
+	assert.True(t, reports[0].HasDrift)
+	assert.Equal(t, 3, mockPlatformProvider.InfrastructreMetadataCallCount())
+}
+
+func TestRunDriftDetection_WithProgress(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web"},
+		{Type: "aws_instance", Name: "api"},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+
+	var stderr bytes.Buffer
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithProgress(progress.New(&stderr, true)))
+	require.NoError(t, err)
+
+	assert.Contains(t, stderr.String(), "[2/2] 100%")
+	assert.Contains(t, stderr.String(), "2/2 resources checked")
+}
+
+func TestRunDriftDetection_Interrupted(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockPlatformProvider.InfrastructreMetadataStub = func(checkCtx context.Context, _ string, _ statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		// the interrupt arrives while the first resource is being checked
+		cancel()
+		assert.NoError(t, checkCtx.Err(), "checks in progress are not cancelled right away")
+		return &providerfakes.FakeInfrastructureResourceI{}, nil
+	}
+
+	err := driftwatcher.RunDriftDetection(ctx, "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithConcurrency(1))
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, 1, mockPlatformProvider.InfrastructreMetadataCallCount(), "no new resources are dispatched")
+	require.Equal(t, 2, mockReporter.WriteReportCallCount(), "the finished check is reported before the partial summary")
+	writeCtx, partial := mockReporter.WriteReportArgsForCall(1)
+	assert.Equal(t, driftchecker.Partial, partial.Status)
+	assert.Equal(t, &driftchecker.ScanSummary{Checked: 1, Total: 3, Reason: context.Canceled.Error()}, partial.Summary)
+	assert.NoError(t, writeCtx.Err())
+}
+
+func TestRunDriftDetection_InterruptedShutdownTimeout(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "a"}}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockPlatformProvider.InfrastructreMetadataStub = func(checkCtx context.Context, _ string, _ statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		cancel()
+		// a hung API call only returns once the shutdown timeout cancels it
+		<-checkCtx.Done()
+		return nil, checkCtx.Err()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- driftwatcher.RunDriftDetection(ctx, "state.tfstate", "aws_instance", []string{"instance_type"},
+			mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+			driftwatcher.WithShutdownTimeout(10*time.Millisecond))
+	}()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("drift detection did not stop after the shutdown timeout")
+	}
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, partial := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, driftchecker.Partial, partial.Status)
+}
+
+func TestRunDriftDetection_WithResourceTimeout(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "hung"}, {Name: "web"}}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+	mockPlatformProvider.InfrastructreMetadataStub = func(checkCtx context.Context, _ string, resource statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		if resource.Name == "hung" {
+			// a hung API call only returns once the resource timeout cancels it
+			<-checkCtx.Done()
+			return nil, checkCtx.Err()
+		}
+		return &providerfakes.FakeInfrastructureResourceI{}, nil
+	}
+
+	ctx, buf := loggingtest.Capture()
+	err := driftwatcher.RunDriftDetection(ctx, "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter,
+		driftwatcher.WithConcurrency(1), driftwatcher.WithResourceTimeout(10*time.Millisecond))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, mockPlatformProvider.InfrastructreMetadataCallCount())
+	require.Equal(t, 2, mockReporter.WriteReportCallCount(), "the scan moves on to the next resource")
+	writeCtx, failed := mockReporter.WriteReportArgsForCall(0)
+	_, hasDeadline := writeCtx.Deadline()
+	assert.False(t, hasDeadline, "reports are written outside the resource timeout")
+	assert.Contains(t, buf.String(), "resource check timed out after 10ms: context deadline exceeded")
+
+	// the resource that timed out is reported as not verified
+	assert.Equal(t, "hung", failed.ResourceName)
+	assert.Equal(t, driftchecker.CheckFailed, failed.Status)
+	require.NotNil(t, failed.Failure)
+	assert.Equal(t, driftchecker.FailureTimeout, failed.Failure.Class)
+	assert.Equal(t, "resource check timed out after 10ms: context deadline exceeded", failed.Failure.Error)
+	_, checked := mockReporter.WriteReportArgsForCall(1)
+	assert.Equal(t, "MATCH", checked.Status)
+}
+
+func TestRunDriftDetection_ThrottledCheck(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	throttled := chaos.Wrap(&providerfakes.FakeProviderI{}).Inject(chaos.Always(), chaos.Throttle())
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, throttled, &driftcheckerfakes.FakeDriftChecker{}, mockReporter)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, driftchecker.CheckFailed, report.Status)
+	assert.Equal(t, "i-1", report.ResourceId)
+	assert.False(t, report.HasDrift)
+	require.NotNil(t, report.Failure)
+	assert.Equal(t, driftchecker.FailureThrottled, report.Failure.Class)
+	assert.Contains(t, report.Failure.Error, "ThrottlingException")
+}
+
+func TestRunDriftDetection_StampsRunMetadata(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{
+		StateId:      "lineage-1",
+		ToolVersion:  "1.8.5",
+		ToolMetadata: map[string]any{"serial": 7},
+	}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}, {Name: "db"}}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturnsOnCall(0, reporter.CreateDummyDriftReport(false), nil)
+	mockDriftChecker.CompareStatesReturnsOnCall(1, reporter.CreateDummyDriftReport(true), nil)
+
+	ctx, buf := loggingtest.Capture()
+	parent := &driftchecker.RunMetadata{RunId: "run-1", Provider: "aws", Comparison: "auto"}
+	ctx = driftchecker.NewRunContext(ctx, parent)
+	err := driftwatcher.RunDriftDetection(ctx, "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, mockReporter.WriteReportCallCount())
+	for i := range 2 {
+		_, report := mockReporter.WriteReportArgsForCall(i)
+		require.NotNil(t, report.Run)
+		assert.Equal(t, "run-1", report.Run.RunId)
+		assert.Equal(t, "aws", report.Run.Provider)
+		assert.Equal(t, "state.tfstate", report.Run.StatePath)
+		assert.Equal(t, "lineage-1", report.Run.Lineage)
+		assert.Equal(t, 7, report.Run.Serial)
+		assert.Equal(t, "1.8.5", report.Run.TerraformVersion)
+		assert.Equal(t, []string{"instance_type"}, report.Run.Attributes)
+		assert.Equal(t, buildinfo.Get().Version, report.Run.DriftwatcherVersion)
+	}
+	assert.Empty(t, parent.StatePath, "the run of the context is not modified")
+	assert.Contains(t, buf.String(), "run_id=run-1")
+}
+
+func TestRunDriftDetection_GeneratesRunId(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+
+	var runIds []string
+	for range 2 {
+		err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", nil,
+			mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter)
+		require.NoError(t, err)
+		_, report := mockReporter.WriteReportArgsForCall(mockReporter.WriteReportCallCount() - 1)
+		require.NotNil(t, report.Run)
+		runIds = append(runIds, report.Run.RunId)
+	}
+	assert.NotEmpty(t, runIds[0])
+	assert.NotEqual(t, runIds[0], runIds[1], "every invocation gets a run of its own")
+}
+
+func TestRunDriftDetection_WithReferenceResolution(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	fakeResolver := &providerfakes.FakeReferenceResolverI{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{
+		ResourceId: "i-1",
+		HasDrift:   true,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "subnet_id", TerraformValue: "subnet-1", ActualValue: "subnet-2", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "vpc_security_group_ids", TerraformValue: []any{"sg-1", "sg-2"}, ActualValue: "sg-2,sg-1,sg-3", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "tags.Env", TerraformValue: "prod", ActualValue: "dev", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}, nil)
+	fakeResolver.ResolveReferencesCalls(func(ctx context.Context, resourceType string, attribute string, ids []string) (map[string]string, error) {
+		switch attribute {
+		case "subnet_id":
+			return map[string]string{"subnet-1": "public-a", "subnet-2": "default subnet in us-east-1a"}, nil
+		case "vpc_security_group_ids":
+			return nil, errors.New("throttled")
+		}
+		return nil, nil
+	})
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"subnet_id"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithReferenceResolution(fakeResolver))
+	require.NoError(t, err)
+
+	require.Equal(t, 3, fakeResolver.ResolveReferencesCallCount())
+	_, resourceType, attribute, ids := fakeResolver.ResolveReferencesArgsForCall(1)
+	assert.Equal(t, "aws_instance", resourceType)
+	assert.Equal(t, "vpc_security_group_ids", attribute)
+	assert.Equal(t, []string{"sg-1", "sg-2", "sg-3"}, ids)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, map[string]string{"subnet-1": "public-a", "subnet-2": "default subnet in us-east-1a"}, report.DriftDetails[0].References)
+	assert.Nil(t, report.DriftDetails[1].References, "a failed lookup leaves the report without names")
+	assert.Nil(t, report.DriftDetails[2].References)
+}
+
+// aliasingProvider is a provider accepting attributes under aliases.
+type aliasingProvider struct {
+	*providerfakes.FakeProviderI
+	*providerfakes.FakeAttributeAliasesI
+}
+
+func TestRunDriftDetection_CanonicalAttributeAliases(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	platformProvider := aliasingProvider{&providerfakes.FakeProviderI{}, &providerfakes.FakeAttributeAliasesI{}}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Name: "web"}}, nil)
+	platformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	platformProvider.CanonicalAttributeCalls(func(resourceType string, attribute string) string {
+		if attribute == "security_group_ids" {
+			return "vpc_security_group_ids"
+		}
+		return attribute
+	})
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type", "security_group_ids"},
+		mockStateManager, platformProvider, mockDriftChecker, mockReporter)
+	require.NoError(t, err)
+
+	_, _, _, attributes := mockDriftChecker.CompareStatesArgsForCall(0)
+	assert.Equal(t, []string{"instance_type", "vpc_security_group_ids"}, attributes)
+	resourceType, _ := platformProvider.CanonicalAttributeArgsForCall(0)
+	assert.Equal(t, "aws_instance", resourceType)
+}
+
+func TestRunDriftDetection_WithAdvisories(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+	advisor := &providerfakes.FakeAdvisorI{}
+
+	dataSource := statemanager.StateResource{Mode: "data", Type: "aws_ami", Name: "al2023"}
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{Resource: []statemanager.StateResource{
+		{Mode: "managed", Type: "aws_instance", Name: "web"},
+		dataSource,
+	}}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{{Type: "aws_instance", Name: "web"}}, nil)
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	mockPlatformProvider.InfrastructreMetadataReturns(live, nil)
+	mockDriftChecker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+	advisories := []provider.Advisory{{Attribute: "ami", Message: "newer AMI ami-new matches data.aws_ami.al2023", Suggested: "ami-new"}}
+	advisor.AdviseReturns(advisories, nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"ami"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithAdvisories(advisor))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, advisor.AdviseCallCount())
+	_, adviseLive, resource, data := advisor.AdviseArgsForCall(0)
+	assert.Equal(t, live, adviseLive)
+	assert.Equal(t, "web", resource.Name)
+	assert.Equal(t, []statemanager.StateResource{dataSource}, data, "only data sources are passed")
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, advisories, report.Advisories)
+}
+
+func TestRunDriftDetection_WithPolicies(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"id":        "i-1",
+			"user_data": "echo secret",
+		}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{
+		ResourceId: "i-1",
+		HasDrift:   true,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "user_data", TerraformValue: "echo secret", ActualValue: "echo other", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}, nil)
+
+	engine, err := policy.New(context.Background(), map[string]string{"user_data.rego": `
+package driftwatcher
+
+violations contains {"severity": "critical", "message": sprintf("%s: user data changed from %v", [input.resource.address, input.resource.attributes.user_data])} if {
+	some item in input.report.drift_details
+	item.field == "user_data"
+}
+`})
+	require.NoError(t, err)
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"user_data"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithRedaction(redactor), driftwatcher.WithPolicies(engine))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.Equal(t, []driftchecker.PolicyViolation{
+		{Severity: "critical", Message: "aws_instance.web: user data changed from " + redact.Masked},
+	}, report.Violations, "policies only see redacted values")
+}
+
+func TestRunDriftDetection_WithHooks(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{
+		ResourceId: "i-1",
+		HasDrift:   true,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}, nil)
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runbook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nsleep 0.1\ncat > \"$1\"\n"), 0755))
+	payload := filepath.Join(dir, "payload.json")
+	runner, err := hooks.NewRunner([]config.HookConfig{
+		{Attribute: "instance_type", Command: script, Args: []string{payload}},
+	}, 1, time.Minute)
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithHooks(runner))
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(payload)
+	require.NoError(t, err, "hooks have finished when drift detection returns")
+	assert.Contains(t, string(content), `"field":"instance_type"`)
+}
+
+func TestRunDriftDetection_WithMaintenance(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "db", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true, Status: driftchecker.Drift}, nil)
+
+	start := time.Now().Add(-time.Hour)
+	schedule, err := maintenance.New([]maintenance.Window{{Resource: "aws_instance.db", Start: start, End: start.Add(2 * time.Hour), Reason: "failover test"}})
+	require.NoError(t, err)
+
+	err = driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithMaintenance(schedule))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	assert.True(t, report.HasDrift, "expected drift is still recorded")
+	require.NotNil(t, report.Maintenance)
+	assert.Equal(t, "failover test", report.Maintenance.Reason)
+}
+
+func TestRunDriftDetection_WithCostEstimates(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true, Status: driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{{Field: "instance_type", TerraformValue: "m5.large", ActualValue: "m5.xlarge", DriftType: driftchecker.AttributeValueChanged}},
+	}, nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, mockReporter, driftwatcher.WithCostEstimates(cost.NewEstimator(cost.DefaultPrices())))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.NotNil(t, report.DriftDetails[0].Cost)
+	assert.Equal(t, 70.08, report.DriftDetails[0].Cost.Monthly)
+}
+
+// lifecycleWriter records the lifecycle phases of a run it takes part in.
+type lifecycleWriter struct {
+	mu     sync.Mutex
+	phases []string
+	run    *driftchecker.RunMetadata
+}
+
+func (w *lifecycleWriter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.phases = append(w.phases, "begin")
+	w.run = run
+	return nil
+}
+
+func (w *lifecycleWriter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.phases = append(w.phases, "write")
+	return nil
+}
+
+func (w *lifecycleWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.phases = append(w.phases, "flush")
+	return nil
+}
+
+func TestRunDriftDetection_ReporterLifecycle(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockDriftChecker := &driftcheckerfakes.FakeDriftChecker{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "a", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-1"}}}},
+		{Type: "aws_instance", Name: "b", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-2"}}}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(&providerfakes.FakeInfrastructureResourceI{}, nil)
+	mockDriftChecker.CompareStatesReturns(&driftchecker.DriftReport{Status: driftchecker.Match}, nil)
+
+	w := &lifecycleWriter{}
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, mockDriftChecker, w)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin", "write", "write", "flush"}, w.phases)
+	require.NotNil(t, w.run)
+	assert.Equal(t, "state.tfstate", w.run.StatePath)
+}
+
+func TestRunDriftDetection_ChecksEveryInstance(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{
+			{IndexKey: "blue", Attributes: map[string]any{"id": "i-1", "instance_type": "t2.micro"}},
+			{IndexKey: "green", Attributes: map[string]any{"id": "i-2", "instance_type": "t2.micro"}},
+		}},
+	}, nil)
+	mockPlatformProvider.InfrastructreMetadataCalls(func(ctx context.Context, resourceType string, resource statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		id, _ := resource.AttributeValue("id")
+		live := &providerfakes.FakeInfrastructureResourceI{}
+		live.ResourceTypeReturns("aws_instance")
+		live.AttributeValueReturns(map[string]string{"i-1": "t2.micro", "i-2": "t3.large"}[id], nil)
+		return live, nil
+	})
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, driftchecker.NewDefaultDriftChecker(), mockReporter, driftwatcher.WithConcurrency(1))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, mockReporter.WriteReportCallCount())
+	reports := map[string]*driftchecker.DriftReport{}
+	for i := range 2 {
+		_, report := mockReporter.WriteReportArgsForCall(i)
+		reports[report.ResourceId] = report
+	}
+	assert.Equal(t, "blue", reports["i-1"].IndexKey)
+	assert.Equal(t, `aws_instance.web["blue"]`, reports["i-1"].ResourceAddress)
+	assert.False(t, reports["i-1"].HasDrift)
+	assert.Equal(t, "green", reports["i-2"].IndexKey)
+	assert.True(t, reports["i-2"].HasDrift, "instances after the first are checked too")
+}
+
+func TestRunDriftDetection_SetsDependents(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	group := statemanager.StateResource{Mode: "managed", Type: "aws_security_group", Name: "web", Instances: []statemanager.ResourceInstance{
+		{Attributes: map[string]any{"id": "sg-1", "description": "web"}},
+	}}
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{Resource: []statemanager.StateResource{
+		group,
+		{Mode: "managed", Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{
+			{Dependencies: []string{"aws_security_group.web"}},
+		}},
+	}}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{group}, nil)
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_security_group")
+	live.AttributeValueReturns("changed by hand", nil)
+	mockPlatformProvider.InfrastructreMetadataReturns(live, nil)
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_security_group", []string{"description"},
+		mockStateManager, mockPlatformProvider, driftchecker.NewDefaultDriftChecker(), mockReporter)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, mockReporter.WriteReportCallCount())
+	_, report := mockReporter.WriteReportArgsForCall(0)
+	require.True(t, report.HasDrift)
+	assert.Equal(t, []string{"aws_instance.web"}, report.Dependents)
+}
+
+func TestRunDriftDetection_WithOutputs(t *testing.T) {
+	mockStateManager := &statemanagerfakes.FakeStateManagerI{}
+	mockPlatformProvider := &providerfakes.FakeProviderI{}
+	mockReporter := &reporterfakes.FakeOutputWriter{}
+
+	web := statemanager.StateResource{Mode: "managed", Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{
+		{Attributes: map[string]any{"id": "i-1", "public_ip": "203.0.113.10", "instance_type": "t2.micro"}},
+	}}
+	api := statemanager.StateResource{Mode: "managed", Type: "aws_instance", Name: "api", Instances: []statemanager.ResourceInstance{
+		{Attributes: map[string]any{"id": "i-2", "public_ip": "203.0.113.20", "instance_type": "t2.micro", "primary_network_interface_id": "eni-2", "network_interface_id": "eni-2"}},
+	}}
+	mockStateManager.ParseStateFileReturns(statemanager.StateContent{Outputs: []statemanager.StateOutput{
+		{Name: "api_eni", Value: "eni-2"},
+		{Name: "api_ip", Value: "203.0.113.20"},
+		{Name: "instance_type", Value: "t2.micro"},
+		{Name: "secret_ip", Value: "203.0.113.10", Sensitive: true},
+		{Name: "web_ip", Value: "203.0.113.10"},
+	}}, nil)
+	mockStateManager.RetrieveResourcesReturns([]statemanager.StateResource{web, api}, nil)
+	mockPlatformProvider.InfrastructreMetadataCalls(func(ctx context.Context, resourceType string, resource statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+		live := &providerfakes.FakeInfrastructureResourceI{}
+		live.AttributeValueCalls(func(attribute string) (string, error) {
+			id, _ := resource.AttributeValue("id")
+			if attribute == "public_ip" && id == "i-1" {
+				return "198.51.100.7", nil
+			}
+			return resource.AttributeValue(attribute)
+		})
+		return live, nil
+	})
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		mockStateManager, mockPlatformProvider, driftchecker.NewDefaultDriftChecker(), mockReporter, driftwatcher.WithOutputs())
+	require.NoError(t, err)
+
+	outputs := map[string]*driftchecker.DriftReport{}
+	for i := range mockReporter.WriteReportCallCount() {
+		_, report := mockReporter.WriteReportArgsForCall(i)
+		if report.ResourceType == "output" {
+			outputs[report.ResourceName] = report
+		}
+	}
+	require.Len(t, outputs, 2, "outputs of unknown source and sensitive outputs are not checked")
+	assert.False(t, outputs["api_ip"].HasDrift)
+	assert.Equal(t, "aws_instance.api.public_ip", outputs["api_ip"].OutputSource)
+	require.True(t, outputs["web_ip"].HasDrift)
+	assert.Equal(t, "output.web_ip", outputs["web_ip"].ResourceAddress)
+	assert.Equal(t, []driftchecker.DriftItem{{Field: "value", TerraformValue: "203.0.113.10", ActualValue: "198.51.100.7", DriftType: driftchecker.AttributeValueChanged}}, outputs["web_ip"].DriftDetails)
+}
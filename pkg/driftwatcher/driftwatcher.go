@@ -0,0 +1,210 @@
+// Package driftwatcher is the Go API of drift-watcher. It lets other programs embed
+// drift detection without going through the command line.
+//
+// A Detector compares the resources of an IaC state with the live infrastructure and
+// returns a report per resource:
+//
+//	detector, err := driftwatcher.New(
+//		driftwatcher.WithStatePath("terraform.tfstate"),
+//		driftwatcher.WithResourceType("aws_instance"),
+//		driftwatcher.WithAttributes("instance_type", "tags"),
+//		driftwatcher.WithAWSProfile("prod"),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	reports, err := detector.Run(ctx)
+//
+// Every component of the scan can be replaced: the state manager, the platform
+// provider, the drift checker and the reporter reports are also written to. The
+// behaviour of the scan itself, such as its concurrency or the redaction of sensitive
+// values, is set with the DetectionOptions of RunDriftDetection.
+package driftwatcher
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"errors"
+	"sync"
+)
+
+// Defaults of a Detector, matching those of the detect command.
+const (
+	DefaultResourceType = "aws_instance"
+	DefaultAWSProfile   = "default"
+)
+
+// DefaultAttributes are the attributes checked when none are set.
+var DefaultAttributes = []string{"instance_type"}
+
+// Detector runs drift detection for the resources of a single type in a state.
+// A Detector can be run any number of times, but not concurrently.
+type Detector struct {
+	statePath    string
+	resourceType string
+	attributes   []string
+	awsProfile   string
+
+	stateManager statemanager.StateManagerI
+	provider     provider.ProviderI
+	checker      driftchecker.DriftChecker
+	reporter     reporter.OutputWriter
+	detection    []DetectionOption
+}
+
+// Option configures a Detector.
+type Option func(*Detector)
+
+// WithStatePath sets the path or URL of the state to check, or "-" to read it from
+// the reader set with WithStdin.
+func WithStatePath(path string) Option {
+	return func(d *Detector) {
+		d.statePath = path
+	}
+}
+
+// WithResourceType sets the type of the resources to check. It defaults to
+// aws_instance.
+func WithResourceType(resourceType string) Option {
+	return func(d *Detector) {
+		d.resourceType = resourceType
+	}
+}
+
+// WithAttributes sets the attributes compared for every resource. They default to
+// DefaultAttributes.
+func WithAttributes(attributes ...string) Option {
+	return func(d *Detector) {
+		d.attributes = attributes
+	}
+}
+
+// WithAWSProfile sets the AWS profile the default AWS provider reads its credentials
+// from. It is ignored when a provider is set with WithProvider.
+func WithAWSProfile(profile string) Option {
+	return func(d *Detector) {
+		d.awsProfile = profile
+	}
+}
+
+// WithStateManager replaces the Terraform state manager used by default.
+func WithStateManager(stateManager statemanager.StateManagerI) Option {
+	return func(d *Detector) {
+		d.stateManager = stateManager
+	}
+}
+
+// WithProvider replaces the AWS provider used by default, e.g. with the Kubernetes
+// provider.
+func WithProvider(platformProvider provider.ProviderI) Option {
+	return func(d *Detector) {
+		d.provider = platformProvider
+	}
+}
+
+// WithDriftChecker replaces the default drift checker.
+func WithDriftChecker(checker driftchecker.DriftChecker) Option {
+	return func(d *Detector) {
+		d.checker = checker
+	}
+}
+
+// WithReporter writes every report to w as well, e.g. a reporter.FileReporter. w is
+// flushed at the end of every run.
+func WithReporter(w reporter.OutputWriter) Option {
+	return func(d *Detector) {
+		d.reporter = w
+	}
+}
+
+// WithDetectionOptions sets the options every run is started with, such as
+// WithConcurrency or WithRedaction.
+func WithDetectionOptions(opts ...DetectionOption) Option {
+	return func(d *Detector) {
+		d.detection = append(d.detection, opts...)
+	}
+}
+
+// New creates a Detector. Components that are not set are created with their
+// defaults: a Terraform state manager, an AWS provider using the profile set with
+// WithAWSProfile, and the default drift checker.
+func New(opts ...Option) (*Detector, error) {
+	d := &Detector{
+		resourceType: DefaultResourceType,
+		attributes:   DefaultAttributes,
+		awsProfile:   DefaultAWSProfile,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.statePath == "" {
+		return nil, errors.New("a state path is required")
+	}
+	if d.stateManager == nil {
+		d.stateManager = terraform.NewTerraformManager()
+	}
+	if d.checker == nil {
+		d.checker = driftchecker.NewDefaultDriftChecker()
+	}
+	if d.provider == nil {
+		awsConfig, err := aws.CheckAWSConfig(context.Background(), "", d.awsProfile)
+		if err != nil {
+			return nil, err
+		}
+		platformProvider, err := aws.NewAWSProvider(&awsConfig)
+		if err != nil {
+			return nil, err
+		}
+		d.provider = platformProvider
+	}
+	return d, nil
+}
+
+// Run checks every resource of the configured type for drift and returns their
+// reports, in the order they were produced. Reports are also written to the reporter
+// set with WithReporter. opts are applied after those set with WithDetectionOptions.
+func (d *Detector) Run(ctx context.Context, opts ...DetectionOption) ([]*driftchecker.DriftReport, error) {
+	collector := &collector{next: d.reporter}
+	err := RunDriftDetection(ctx, d.statePath, d.resourceType, d.attributes, d.stateManager, d.provider, d.checker, collector,
+		append(append([]DetectionOption{}, d.detection...), opts...)...)
+	return collector.reports, err
+}
+
+// collector keeps the reports of a run and passes them on to next.
+type collector struct {
+	next reporter.OutputWriter
+
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+}
+
+func (c *collector) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	c.mu.Lock()
+	c.reports = append(c.reports, report)
+	c.mu.Unlock()
+
+	if c.next == nil {
+		return nil
+	}
+	return c.next.WriteReport(ctx, report)
+}
+
+func (c *collector) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	if c.next == nil {
+		return nil
+	}
+	return reporter.BeginWriter(ctx, c.next, run)
+}
+
+func (c *collector) Flush(ctx context.Context) error {
+	if c.next == nil {
+		return nil
+	}
+	return reporter.FlushWriter(ctx, c.next)
+}
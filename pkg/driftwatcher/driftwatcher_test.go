@@ -0,0 +1,53 @@
+package driftwatcher_test
+
+import (
+	"context"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector_Run(t *testing.T) {
+	liveResource := &providerfakes.FakeInfrastructureResourceI{}
+	liveResource.ResourceTypeReturns("aws_instance")
+	liveResource.AttributeValueReturns("t2.large", nil)
+	platformProvider := &providerfakes.FakeProviderI{}
+	platformProvider.InfrastructreMetadataReturns(liveResource, nil)
+	next := &reporterfakes.FakeOutputWriter{}
+
+	detector, err := driftwatcher.New(
+		driftwatcher.WithStatePath("../../assets/terraform_ec2_state.tfstate"),
+		driftwatcher.WithProvider(platformProvider),
+		driftwatcher.WithReporter(next),
+		driftwatcher.WithDetectionOptions(driftwatcher.WithConcurrency(1)),
+	)
+	require.NoError(t, err)
+
+	reports, err := detector.Run(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, reports)
+	assert.Equal(t, len(reports), next.WriteReportCallCount(), "every report is written to the reporter too")
+
+	report := reports[0]
+	assert.True(t, report.HasDrift)
+	assert.Equal(t, "aws_instance", report.ResourceType)
+	require.Len(t, report.DriftDetails, 1)
+	assert.Equal(t, driftchecker.DriftItem{
+		Field:          "instance_type",
+		TerraformValue: "t2.micro",
+		ActualValue:    "t2.large",
+		DriftType:      driftchecker.AttributeValueChanged,
+	}, report.DriftDetails[0])
+	_, _, resource := platformProvider.InfrastructreMetadataArgsForCall(0)
+	assert.Equal(t, "aws_instance", resource.Type)
+}
+
+func TestNew_StatePathRequired(t *testing.T) {
+	_, err := driftwatcher.New(driftwatcher.WithProvider(&providerfakes.FakeProviderI{}))
+	assert.ErrorContains(t, err, "a state path is required")
+}
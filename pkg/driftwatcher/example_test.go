@@ -0,0 +1,76 @@
+package driftwatcher_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/services/provider/kubernetes"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/reporter"
+	"fmt"
+	"log"
+)
+
+func ExampleNew() {
+	detector, err := driftwatcher.New(
+		driftwatcher.WithStatePath("terraform.tfstate"),
+		driftwatcher.WithResourceType("aws_instance"),
+		driftwatcher.WithAttributes("instance_type", "tags"),
+		driftwatcher.WithAWSProfile("prod"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reports, err := detector.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, report := range reports {
+		if report.HasDrift {
+			fmt.Printf("%s.%s drifted\n", report.ResourceType, report.ResourceName)
+		}
+	}
+}
+
+func ExampleWithProvider() {
+	platformProvider, err := kubernetes.NewKubernetesProvider(&config.KubernetesConfig{Context: "prod"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	detector, err := driftwatcher.New(
+		driftwatcher.WithStatePath("k8s.tfstate"),
+		driftwatcher.WithResourceType("kubernetes_deployment"),
+		driftwatcher.WithAttributes("spec.replicas"),
+		driftwatcher.WithProvider(platformProvider),
+		// reports are also written to a file, as with detect --output-file
+		driftwatcher.WithReporter(reporter.NewFileReporter("drift.json")),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := detector.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleDetector_Run() {
+	detector, err := driftwatcher.New(driftwatcher.WithStatePath("terraform.tfstate"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redactor, err := redact.NewRedactor(nil, redact.ModeMask)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reports, err := detector.Run(context.Background(),
+		driftwatcher.WithConcurrency(10),
+		driftwatcher.WithRedaction(redactor),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(reports), "resources checked")
+}
@@ -0,0 +1,68 @@
+package driftwatcher
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/provider/breaker"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// failureClass returns the class of the error a resource check failed with, one of
+// the driftchecker Failure classes.
+func failureClass(err error) string {
+	var apiErr smithy.APIError
+	var statusErr interface{ HTTPStatusCode() int }
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return driftchecker.FailureTimeout
+	case errors.Is(err, breaker.ErrOpen):
+		return driftchecker.FailureUnavailable
+	case errors.As(err, &apiErr) && isThrottleCode(apiErr.ErrorCode()),
+		errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusTooManyRequests,
+		apierrors.IsTooManyRequests(err):
+		return driftchecker.FailureThrottled
+	default:
+		return driftchecker.FailureError
+	}
+}
+
+// isThrottleCode reports whether code is an AWS API error code of a throttled call.
+func isThrottleCode(code string) bool {
+	_, ok := retry.DefaultThrottleErrorCodes[code]
+	return ok
+}
+
+// reportFailure writes a report marking resource as not verified because its check
+// failed with err, so the output does not pass an unchecked resource off as checked.
+// Checks cut short by an interrupt are left to the partial summary instead.
+func reportFailure(ctx context.Context, resourceType string, resource statemanager.StateResource, err error, outputWriter reporter.OutputWriter) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	report := &driftchecker.DriftReport{
+		ResourceType:    resourceType,
+		ResourceName:    resource.Name,
+		ResourceAddress: resource.Address(),
+		IndexKey:        resource.IndexKey(),
+		GeneratedAt:     time.Now(),
+		Status:          driftchecker.CheckFailed,
+		Failure:         &driftchecker.CheckFailure{Class: failureClass(err), Error: err.Error()},
+	}
+	if id, err := resource.AttributeValue("id"); err == nil {
+		report.ResourceId = id
+	}
+	if err := outputWriter.WriteReport(ctx, report); err != nil {
+		logger(ctx).Error("Failed to write report for failed check", "resource", ignore.Address(resource), "error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package driftwatcher
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WithOutputs checks the outputs of the state against live infrastructure. An output
+// is recomputed from the live resource attribute it was taken from, e.g. the
+// public_ip of an instance, and reported as drifted when it no longer reflects it.
+func WithOutputs() DetectionOption {
+	return func(o *detectionOptions) {
+		o.outputs = true
+	}
+}
+
+// outputSource is the attribute of a checked resource an output was taken from.
+type outputSource struct {
+	resource  statemanager.StateResource
+	attribute string
+}
+
+// findOutputSource returns the attribute of resources whose value in the state is the
+// value of output, and the number of attributes holding the value. The state does not
+// record the expression of an output, so the source is only known when the value is a
+// scalar held by exactly one attribute of one resource; with any other number of
+// candidates the source is unknown and the returned source is empty.
+func findOutputSource(output statemanager.StateOutput, resources []statemanager.StateResource) (source outputSource, candidates int) {
+	value, isScalar := scalarString(output.Value)
+	if !isScalar || value == "" {
+		return outputSource{}, 0
+	}
+
+	for _, resource := range resources {
+		if len(resource.Instances) == 0 {
+			continue
+		}
+		attributes := resource.Instances[0].Attributes
+		for _, name := range slices.Sorted(maps.Keys(attributes)) {
+			if v, isScalar := scalarString(attributes[name]); isScalar && v == value {
+				source = outputSource{resource: resource, attribute: name}
+				candidates++
+			}
+		}
+	}
+	if candidates != 1 {
+		return outputSource{}, candidates
+	}
+	return source, candidates
+}
+
+// scalarString renders a string, number or boolean value of the state as a string.
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64, bool, int:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// reportOutputs writes a report for every output of the state that could be traced to
+// an attribute of one of the checked resources, comparing it with the live value of
+// the attribute. Sensitive outputs are not checked, so their values are never
+// reported.
+func reportOutputs(
+	ctx context.Context,
+	resourceType string,
+	outputs []statemanager.StateOutput,
+	resources []statemanager.StateResource,
+	platformProvider provider.ProviderI,
+	outputWriter reporter.OutputWriter,
+) {
+	ctx, span := telemetry.StartSpan(ctx, "ReportOutputs", attribute.String("drift.resource_type", resourceType))
+	defer span.End()
+
+	checked := 0
+	for _, output := range outputs {
+		if output.Sensitive {
+			continue
+		}
+		source, candidates := findOutputSource(output, resources)
+		if candidates != 1 {
+			// several attributes holding the value may be a coincidence, such as two
+			// instances of the same type, so the output is not attributed to any
+			logger(ctx).Debug("Output source unknown, output not checked", "output", output.Name, "candidates", candidates)
+			continue
+		}
+		sourceAddress := source.resource.Address() + "." + source.attribute
+
+		live, err := platformProvider.InfrastructreMetadata(ctx, resourceType, source.resource)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			logger(ctx).Error("Failed to retrieve infrastructure metadata for output", "output", output.Name, "source", sourceAddress, "error", err)
+			continue
+		}
+		liveValue, err := provider.LiveAttributeValue(ctx, live, source.attribute)
+		if err != nil {
+			logger(ctx).Debug("Output source attribute not read from live resources", "output", output.Name, "source", sourceAddress, "error", err)
+			continue
+		}
+		desired, _ := scalarString(output.Value)
+
+		report := &driftchecker.DriftReport{
+			ResourceType:    "output",
+			ResourceName:    output.Name,
+			ResourceAddress: "output." + output.Name,
+			OutputSource:    sourceAddress,
+			GeneratedAt:     time.Now(),
+			Status:          driftchecker.Match,
+		}
+		if liveValue != desired {
+			report.HasDrift = true
+			report.Status = driftchecker.Drift
+			report.DriftDetails = []driftchecker.DriftItem{{
+				Field:          "value",
+				TerraformValue: desired,
+				ActualValue:    liveValue,
+				DriftType:      driftchecker.AttributeValueChanged,
+			}}
+		}
+		checked++
+		if err := outputWriter.WriteReport(ctx, report); err != nil {
+			logger(ctx).Error("Failed to write report for output", "output", output.Name, "error", err)
+		}
+	}
+	span.SetAttributes(attribute.Int("drift.output_count", checked))
+}
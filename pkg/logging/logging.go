@@ -0,0 +1,194 @@
+// Package logging builds the structured logger of driftwatcher and carries it in a
+// context.Context, so that commands, the serve and watch loops and library users can
+// each log to their own sink without replacing the process-wide slog default.
+//
+// Code that logs takes the logger of its context, tagged with the module it belongs
+// to so that modules can be given their own log level:
+//
+//	logging.Module(ctx, "aws").Debug("Describing instance", "instance_id", id)
+//
+// A context without a logger logs to slog.Default().
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleKey is the attribute naming the module a record was logged by.
+const ModuleKey = "module"
+
+// Supported log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options describes a logger.
+type Options struct {
+	// Level is the lowest level logged: debug, info, warn or error. trace is accepted
+	// as an alias of debug. It defaults to info.
+	Level string
+	// Format is text or json. It defaults to text.
+	Format string
+	// File is the file records are appended to. Records go to Out when empty.
+	File string
+	// Out is the writer records go to when no file is set. It defaults to os.Stderr.
+	Out io.Writer
+	// ModuleLevels overrides Level for single modules, as module=level pairs such as
+	// aws=debug.
+	ModuleLevels []string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default() when it carries
+// none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// Module returns the logger carried by ctx, tagged with the module it logs for.
+func Module(ctx context.Context, module string) *slog.Logger {
+	return FromContext(ctx).With(ModuleKey, module)
+}
+
+// New creates the logger described by opts. The returned closer closes the log file
+// and must be called once the logger is no longer used.
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	moduleLevels, err := ParseModuleLevels(opts.ModuleLevels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out io.Writer = os.Stderr
+	if opts.Out != nil {
+		out = opts.Out
+	}
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0o755); err != nil {
+			return nil, nil, fmt.Errorf("failed to create log file directory: %w", err)
+		}
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out, closer = file, file
+	}
+
+	// the inner handler lets every record through that some module may log, the
+	// module handler applies the level of the module
+	lowest := level
+	for _, moduleLevel := range moduleLevels {
+		lowest = min(lowest, moduleLevel)
+	}
+	handlerOptions := &slog.HandlerOptions{Level: lowest}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(out, handlerOptions)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, handlerOptions)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("unknown log format %q, expected text or json", opts.Format)
+	}
+
+	return slog.New(&moduleHandler{next: handler, level: level, modules: moduleLevels}), closer, nil
+}
+
+// ParseLevel parses a log level name. An empty name is info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug", "trace":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q, expected one of: debug, info, warn, error", name)
+	}
+}
+
+// ParseModuleLevels parses module=level pairs into the level of each module.
+func ParseModuleLevels(pairs []string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	for _, pair := range pairs {
+		module, name, ok := strings.Cut(pair, "=")
+		if !ok || module == "" {
+			return nil, fmt.Errorf("invalid module log level %q, expected module=level", pair)
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid module log level %q: %w", pair, err)
+		}
+		levels[module] = level
+	}
+	return levels, nil
+}
+
+// moduleHandler filters records by the level of the module that logged them, taken
+// from the ModuleKey attribute added with Module.
+type moduleHandler struct {
+	next    slog.Handler
+	level   slog.Level
+	modules map[string]slog.Level
+	module  string
+}
+
+func (h *moduleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	threshold := h.level
+	if moduleLevel, ok := h.modules[h.module]; ok {
+		threshold = moduleLevel
+	}
+	return level >= threshold && h.next.Enabled(ctx, level)
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	for _, attr := range attrs {
+		if attr.Key == ModuleKey {
+			clone.module = attr.Value.String()
+		}
+	}
+	clone.next = h.next.WithAttrs(attrs)
+	return &clone
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
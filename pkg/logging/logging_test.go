@@ -0,0 +1,96 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/logging"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_JSON(t *testing.T) {
+	var out bytes.Buffer
+	logger, closer, err := logging.New(logging.Options{Level: "info", Format: "json", Out: &out})
+	require.NoError(t, err)
+	defer closer.Close()
+
+	ctx := logging.NewContext(context.Background(), logger)
+	logging.Module(ctx, "aws").Info("Described instance", "instance_id", "i-1")
+	logging.Module(ctx, "aws").Debug("not logged")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "Described instance", record["msg"])
+	assert.Equal(t, "aws", record["module"])
+	assert.Equal(t, "i-1", record["instance_id"])
+}
+
+func TestNew_ModuleLevels(t *testing.T) {
+	var out bytes.Buffer
+	logger, _, err := logging.New(logging.Options{
+		Level:        "warn",
+		Out:          &out,
+		ModuleLevels: []string{"aws=debug", "hooks=error"},
+	})
+	require.NoError(t, err)
+	ctx := logging.NewContext(context.Background(), logger)
+
+	logging.Module(ctx, "aws").Debug("aws debug")
+	logging.Module(ctx, "hooks").Warn("hooks warn")
+	logging.Module(ctx, "remote").Info("remote info")
+	logging.Module(ctx, "remote").Warn("remote warn")
+	logging.FromContext(ctx).Debug("unscoped debug")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `msg="aws debug" module=aws`)
+	assert.Contains(t, lines[1], `msg="remote warn" module=remote`)
+}
+
+func TestNew_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "driftwatcher.log")
+	logger, closer, err := logging.New(logging.Options{File: path})
+	require.NoError(t, err)
+	logger.Info("first")
+	require.NoError(t, closer.Close())
+
+	// the file is appended to
+	logger, closer, err = logging.New(logging.Options{File: path})
+	require.NoError(t, err)
+	logger.Info("second")
+	require.NoError(t, closer.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "msg=first")
+	assert.Contains(t, string(content), "msg=second")
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, _, err := logging.New(logging.Options{Level: "verbose"})
+	assert.ErrorContains(t, err, `unknown log level "verbose"`)
+
+	_, _, err = logging.New(logging.Options{Format: "xml"})
+	assert.ErrorContains(t, err, `unknown log format "xml"`)
+
+	_, _, err = logging.New(logging.Options{ModuleLevels: []string{"aws"}})
+	assert.ErrorContains(t, err, "expected module=level")
+
+	_, _, err = logging.New(logging.Options{ModuleLevels: []string{"aws=loud"}})
+	assert.ErrorContains(t, err, `unknown log level "loud"`)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), logging.FromContext(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, logger, logging.FromContext(logging.NewContext(context.Background(), logger)))
+}
@@ -0,0 +1,17 @@
+// Package loggingtest provides helpers for tests that assert on the logs written
+// through the logger carried by a context.
+package loggingtest
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/logging"
+	"log/slog"
+)
+
+// Capture returns a context carrying a logger that writes to the returned buffer.
+func Capture() (context.Context, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	return logging.NewContext(context.Background(), logger), &buf
+}
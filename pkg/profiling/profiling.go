@@ -0,0 +1,66 @@
+// Package profiling writes CPU and memory profiles of a command in the pprof format,
+// so that a slow scan can be inspected with `go tool pprof` without rebuilding the
+// CLI.
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// StopFunc stops profiling and writes the profiles that are only known at the end
+// of the command.
+type StopFunc func() error
+
+// Start starts profiling the CPU to cpuPath, unless it is empty, and returns the
+// function that stops it. The function also writes the heap profile to memPath,
+// unless it is empty, after a garbage collection so that it reflects the memory
+// still in use; the profile holds the allocations made since the start of the
+// program as well.
+func Start(cpuPath, memPath string) (StopFunc, error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	return func() error {
+		var errs []error
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to write CPU profile: %w", err))
+			}
+		}
+		if memPath != "" {
+			errs = append(errs, writeHeapProfile(memPath))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %w", err)
+	}
+	runtime.GC()
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	return nil
+}
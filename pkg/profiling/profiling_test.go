@@ -0,0 +1,59 @@
+package profiling_test
+
+import (
+	"drift-watcher/pkg/profiling"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertProfile checks that path holds a profile, which pprof writes gzipped.
+func assertProfile(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Greater(t, len(data), 2)
+	assert.Equal(t, []byte{0x1f, 0x8b}, data[:2])
+}
+
+func TestStart(t *testing.T) {
+	dir := t.TempDir()
+	cpuPath, memPath := filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof")
+
+	stop, err := profiling.Start(cpuPath, memPath)
+	require.NoError(t, err)
+	require.NoError(t, stop())
+
+	assertProfile(t, cpuPath)
+	assertProfile(t, memPath)
+}
+
+func TestStart_MemoryOnly(t *testing.T) {
+	memPath := filepath.Join(t.TempDir(), "mem.pprof")
+
+	stop, err := profiling.Start("", memPath)
+	require.NoError(t, err)
+	require.NoError(t, stop())
+
+	assertProfile(t, memPath)
+}
+
+func TestStart_Disabled(t *testing.T) {
+	stop, err := profiling.Start("", "")
+	require.NoError(t, err)
+	assert.NoError(t, stop())
+}
+
+func TestStart_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "profile.pprof")
+
+	_, err := profiling.Start(missing, "")
+	assert.ErrorContains(t, err, "failed to create CPU profile")
+
+	stop, err := profiling.Start("", missing)
+	require.NoError(t, err)
+	assert.ErrorContains(t, stop(), "failed to create memory profile")
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role grants access to the endpoints of the API. Each role is granted everything the
+// roles below it are.
+type Role string
+
+const (
+	// RoleViewer reads schedules, runs and reports.
+	RoleViewer Role = "viewer"
+	// RoleOperator also triggers scans.
+	RoleOperator Role = "operator"
+	// RoleAdmin also pauses and resumes schedules.
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// Allows reports whether r is granted the access of required.
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// ParseRole parses the name of a role.
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q, expected viewer, operator or admin", name)
+	}
+	return role, nil
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// Method is the way the caller authenticated, api-key or oidc.
+	Method string `json:"method"`
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller of the request ctx belongs to, or nil when
+// the server does not authenticate requests.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Authenticator authenticates the caller of a request.
+type Authenticator interface {
+	// Authenticate returns the caller of r. It returns nil without an error when r
+	// carries no credentials of the kind the authenticator checks, so that the next
+	// authenticator can check them, and an error for credentials it rejects.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Errors of requests no authenticator accepted.
+var (
+	errUnauthenticated    = errors.New("authentication required")
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// AuthConfig declares who may call the API of the serve command, e.g.
+//
+//	api_keys:
+//	  - name: ci
+//	    key: env://DRIFT_CI_API_KEY
+//	    role: operator
+//	oidc:
+//	  issuer: https://login.example.com
+//	  audience: driftwatcher
+//	  role_claim: groups
+//	  roles:
+//	    platform-admins: admin
+//	  default_role: viewer
+type AuthConfig struct {
+	APIKeys []APIKey    `yaml:"api_keys"`
+	OIDC    *OIDCConfig `yaml:"oidc"`
+}
+
+// APIKey grants Role to the callers presenting Key.
+type APIKey struct {
+	Name string `yaml:"name"`
+	// Key is the key itself, or a secret reference such as env://NAME resolved by the
+	// serve command.
+	Key  string `yaml:"key"`
+	Role Role   `yaml:"role"`
+}
+
+// LoadAuthConfig reads and validates an AuthConfig from a YAML file.
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+	cfg := &AuthConfig{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config %s: %w", path, err)
+	}
+	if len(cfg.APIKeys) == 0 && cfg.OIDC == nil {
+		return nil, fmt.Errorf("auth config %s: no api_keys or oidc declared", path)
+	}
+	for i, key := range cfg.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			return nil, fmt.Errorf("auth config %s: api key %d requires a name and a key", path, i+1)
+		}
+		if cfg.APIKeys[i].Role, err = ParseRole(string(key.Role)); err != nil {
+			return nil, fmt.Errorf("auth config %s: api key %s: %w", path, key.Name, err)
+		}
+	}
+	if cfg.OIDC != nil {
+		if err := cfg.OIDC.validate(); err != nil {
+			return nil, fmt.Errorf("auth config %s: oidc: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// APIKeyAuthenticator authenticates the callers presenting one of a set of API keys,
+// either as a bearer token or in the X-API-Key header.
+type APIKeyAuthenticator struct {
+	keys []apiKeyHash
+}
+
+type apiKeyHash struct {
+	name string
+	role Role
+	hash [sha256.Size]byte
+}
+
+// NewAPIKeyAuthenticator creates an authenticator of keys, whose secret references
+// must have been resolved.
+func NewAPIKeyAuthenticator(keys []APIKey) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{}
+	for _, key := range keys {
+		a.keys = append(a.keys, apiKeyHash{name: key.Name, role: key.Role, hash: sha256.Sum256([]byte(key.Key))})
+	}
+	return a
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	presented := r.Header.Get("X-API-Key")
+	if presented == "" {
+		presented = bearerToken(r)
+	}
+	if presented == "" {
+		return nil, nil
+	}
+	// compare hashes of equal length in constant time, so the time taken tells
+	// nothing about the keys
+	hash := sha256.Sum256([]byte(presented))
+	var found *apiKeyHash
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], a.keys[i].hash[:]) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found == nil {
+		if r.Header.Get("X-API-Key") != "" {
+			return nil, errors.New("invalid API key")
+		}
+		return nil, nil
+	}
+	return &Principal{Name: found.name, Role: found.role, Method: "api-key"}, nil
+}
+
+// bearerToken returns the token of the Authorization header of r, if any.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authorize returns handler guarded by the authenticators of s: the caller must be
+// granted role. Without authenticators every request is let through.
+func (s *Server) authorize(role Role, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.authenticators) == 0 {
+			handler(w, r)
+			return
+		}
+
+		principal, err := s.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="driftwatcher"`)
+			writeJSON(r.Context(), w, http.StatusUnauthorized, errorBody{Error: err.Error()})
+			return
+		}
+		if !principal.Role.Allows(role) {
+			logger(r.Context()).Warn("Request denied", "principal", principal.Name, "role", principal.Role, "method", r.Method, "path", r.URL.Path)
+			writeJSON(r.Context(), w, http.StatusForbidden, errorBody{Error: fmt.Sprintf("the %s role is required", role)})
+			return
+		}
+		if r.Method != http.MethodGet {
+			logger(r.Context()).Info("Request authorized", "principal", principal.Name, "role", principal.Role, "method", r.Method, "path", r.URL.Path)
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	}
+}
+
+// authenticate returns the caller of r as identified by the first authenticator that
+// recognizes its credentials.
+func (s *Server) authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range s.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			return principal, nil
+		}
+	}
+	if r.Header.Get("Authorization") != "" {
+		return nil, errInvalidCredentials
+	}
+	return nil, errUnauthenticated
+}
@@ -0,0 +1,261 @@
+package server_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store/storefakes"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthServer(t *testing.T, authenticators ...server.Authenticator) *httptest.Server {
+	t.Helper()
+	hourly, err := schedule.ParseCron("@hourly")
+	require.NoError(t, err)
+	run := func(ctx context.Context, name string, runId string) (int, error) { return 0, nil }
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: hourly}}, &storefakes.FakeScheduleStore{}, run)
+	t.Cleanup(scheduler.Wait)
+	srv := httptest.NewServer(server.New(scheduler, server.WithAuthenticators(authenticators...)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// call sends a request with the given Authorization header and returns its status and
+// body, decoded when it is a JSON object.
+func call(t *testing.T, method, url, authorization string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var raw json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&raw))
+	var body map[string]any
+	_ = json.Unmarshal(raw, &body)
+	return resp.StatusCode, body
+}
+
+func TestServer_APIKeyRoles(t *testing.T) {
+	srv := newAuthServer(t, server.NewAPIKeyAuthenticator([]server.APIKey{
+		{Name: "dashboard", Key: "viewer-key", Role: server.RoleViewer},
+		{Name: "ci", Key: "operator-key", Role: server.RoleOperator},
+		{Name: "platform", Key: "admin-key", Role: server.RoleAdmin},
+	}))
+	base := srv.URL + "/api/v1/schedules"
+
+	status, _ := call(t, http.MethodGet, srv.URL+"/healthz", "")
+	assert.Equal(t, http.StatusOK, status, "health checks need no credentials")
+
+	resp, err := http.Get(base)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer realm="driftwatcher"`, resp.Header.Get("WWW-Authenticate"))
+	status, body := call(t, http.MethodGet, base, "Bearer wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid credentials", body["error"])
+
+	for _, tc := range []struct {
+		key                 string
+		read, run, schedule int
+	}{
+		{"viewer-key", http.StatusOK, http.StatusForbidden, http.StatusForbidden},
+		{"operator-key", http.StatusOK, http.StatusAccepted, http.StatusForbidden},
+		{"admin-key", http.StatusOK, http.StatusAccepted, http.StatusOK},
+	} {
+		status, _ := call(t, http.MethodGet, base+"/hourly/runs", "Bearer "+tc.key)
+		assert.Equal(t, tc.read, status, tc.key)
+		status, body := call(t, http.MethodPost, base+"/hourly/pause", "Bearer "+tc.key)
+		assert.Equal(t, tc.schedule, status, tc.key)
+		if status == http.StatusForbidden {
+			assert.Equal(t, "the admin role is required", body["error"])
+		}
+		status, _ = call(t, http.MethodPost, base+"/hourly/resume", "Bearer "+tc.key)
+		assert.Equal(t, tc.schedule, status, tc.key)
+
+		var runStatus int
+		require.Eventually(t, func() bool {
+			// the run of the previous key may still be in progress
+			runStatus, _ = call(t, http.MethodPost, base+"/hourly/run", "Bearer "+tc.key)
+			return runStatus != http.StatusConflict
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, tc.run, runStatus, tc.key)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/whoami", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", "operator-key")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var principal server.Principal
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&principal))
+	assert.Equal(t, server.Principal{Name: "ci", Role: server.RoleOperator, Method: "api-key"}, principal)
+}
+
+// issuer is an OIDC identity provider signing tokens with an RSA key.
+type issuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	iss := &issuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": iss.URL, "jwks_uri": iss.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+// token returns an RS256 token signed with key id kid and holding claims, valid for an
+// hour unless claims set exp.
+func (i *issuer) token(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+	full := map[string]any{"iss": i.URL, "aud": "driftwatcher", "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range claims {
+		full[name] = value
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(full)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestServer_OIDC(t *testing.T) {
+	iss := newIssuer(t)
+	srv := newAuthServer(t,
+		server.NewAPIKeyAuthenticator([]server.APIKey{{Name: "ci", Key: "operator-key", Role: server.RoleOperator}}),
+		server.NewOIDCAuthenticator(server.OIDCConfig{
+			Issuer:    iss.URL,
+			Audience:  "driftwatcher",
+			NameClaim: "email",
+			RoleClaim: "groups",
+			Roles:     map[string]server.Role{"sre": server.RoleOperator, "platform-admins": server.RoleAdmin},
+		}, nil),
+	)
+	whoami := srv.URL + "/api/v1/whoami"
+
+	status, body := call(t, http.MethodGet, whoami, "Bearer "+iss.token(t, "key-1", map[string]any{
+		"email": "jane@example.com", "groups": []string{"developers", "platform-admins", "sre"},
+	}))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"name": "jane@example.com", "role": "admin", "method": "oidc"}, body)
+
+	status, _ = call(t, http.MethodGet, whoami, "Bearer operator-key")
+	assert.Equal(t, http.StatusOK, status, "API keys are still accepted")
+
+	status, body = call(t, http.MethodGet, whoami, "Bearer "+iss.token(t, "key-1", map[string]any{"groups": []string{"developers"}}))
+	assert.Equal(t, http.StatusForbidden, status, "callers without a mapped role and no default role are denied")
+	assert.Equal(t, "the viewer role is required", body["error"])
+
+	for claims, expected := range map[string]map[string]any{
+		"invalid token: oidc: token is expired":                        {"exp": time.Now().Add(-time.Hour).Unix()},
+		`invalid token: oidc: expected audience "driftwatcher"`:        {"aud": []string{"other"}},
+		`invalid token: oidc: id token issued by a different provider`: {"iss": "https://evil.example.com"},
+	} {
+		status, body := call(t, http.MethodGet, whoami, "Bearer "+iss.token(t, "key-1", expected))
+		assert.Equal(t, http.StatusUnauthorized, status, claims)
+		assert.Contains(t, body["error"], claims)
+	}
+
+	status, body = call(t, http.MethodGet, whoami, "Bearer "+iss.token(t, "key-2", nil))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid token: failed to verify signature: failed to verify id token signature", body["error"])
+
+	// a token of the right issuer and key id, signed with another key
+	forged := newIssuer(t).token(t, "key-1", map[string]any{"iss": iss.URL, "groups": "platform-admins"})
+	status, body = call(t, http.MethodGet, whoami, "Bearer "+forged)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid token: failed to verify signature: failed to verify id token signature", body["error"])
+}
+
+func TestServer_OIDCJWKSURL(t *testing.T) {
+	iss := newIssuer(t)
+	srv := newAuthServer(t, server.NewOIDCAuthenticator(server.OIDCConfig{
+		// the issuer is not discovered when the keys are declared
+		Issuer:      "https://login.example.com",
+		Audience:    "driftwatcher",
+		JWKSURL:     iss.URL + "/keys",
+		DefaultRole: server.RoleViewer,
+	}, nil))
+
+	status, body := call(t, http.MethodGet, srv.URL+"/api/v1/whoami", "Bearer "+iss.token(t, "key-1", map[string]any{
+		"iss": "https://login.example.com", "sub": "jane",
+	}))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"name": "jane", "role": "viewer", "method": "oidc"}, body)
+}
+
+func TestLoadAuthConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+api_keys:
+  - name: ci
+    key: env://DRIFT_CI_KEY
+    role: Operator
+oidc:
+  issuer: https://login.example.com
+  audience: driftwatcher
+  role_claim: groups
+  roles:
+    platform-admins: admin
+  default_role: viewer
+`), 0600))
+
+	cfg, err := server.LoadAuthConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []server.APIKey{{Name: "ci", Key: "env://DRIFT_CI_KEY", Role: server.RoleOperator}}, cfg.APIKeys)
+	require.NotNil(t, cfg.OIDC)
+	assert.Equal(t, server.RoleViewer, cfg.OIDC.DefaultRole)
+	assert.Equal(t, map[string]server.Role{"platform-admins": server.RoleAdmin}, cfg.OIDC.Roles)
+
+	for content, expected := range map[string]string{
+		"api_keys: []\n": "no api_keys or oidc declared",
+		"api_keys:\n  - name: ci\n    key: k\n    role: root\n":       `api key ci: unknown role "root"`,
+		"oidc:\n  issuer: https://login.example.com\n":                "oidc: an issuer and an audience are required",
+		"oidc:\n  issuer: i\n  audience: a\n  roles:\n    x: admin\n": "oidc: roles require a role_claim",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		_, err := server.LoadAuthConfig(path)
+		assert.ErrorContains(t, err, expected)
+	}
+}
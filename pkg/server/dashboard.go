@@ -0,0 +1,172 @@
+package server
+
+import (
+	"cmp"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"embed"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// dashboardAssets is the web dashboard, a single page reading the API.
+//
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// defaultTrendDays and maxTrendDays bound how far back the dashboard reads reports.
+const (
+	defaultTrendDays = 14
+	maxTrendDays     = 90
+)
+
+// WithReports serves the reports of reportStore: the reports of a run, and the
+// drift summaries the dashboard shows.
+func WithReports(reportStore store.ReportStore) Option {
+	return func(s *Server) {
+		s.reports = reportStore
+	}
+}
+
+// WithDashboard serves the web dashboard under /dashboard/. It needs WithReports.
+// The assets of the dashboard are public; the data it shows is read from the API
+// with the credentials the user enters.
+func WithDashboard() Option {
+	return func(s *Server) {
+		s.dashboard = true
+	}
+}
+
+// handleReports registers the endpoints of the reports and the dashboard.
+func (s *Server) handleReports() {
+	if s.reports == nil {
+		return
+	}
+	s.mux.HandleFunc("GET /api/v1/runs/{id}/reports", s.authorize(RoleViewer, s.runReports))
+	s.mux.HandleFunc("GET /api/v1/dashboard", s.authorize(RoleViewer, s.dashboardSummary))
+	if s.dashboard {
+		assets, _ := fs.Sub(dashboardAssets, "dashboard")
+		s.mux.Handle("GET /dashboard/", http.StripPrefix("/dashboard/", http.FileServerFS(assets)))
+		s.mux.Handle("GET /{$}", http.RedirectHandler("/dashboard/", http.StatusFound))
+	}
+}
+
+// runReports returns the reports of a run, optionally of a single target.
+func (s *Server) runReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.reports.History(r.Context(), store.HistoryQuery{RunId: r.PathValue("id")})
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	target := r.URL.Query().Get("target")
+	out := []driftchecker.DriftReport{}
+	for _, stored := range reports {
+		if target == "" || reportTarget(&stored.Report) == target {
+			out = append(out, stored.Report)
+		}
+	}
+	writeJSON(r.Context(), w, http.StatusOK, out)
+}
+
+// RunSummary counts the resources of a target checked in a run. Drift that was
+// resolved or is expected during a maintenance window is not counted as drifted.
+type RunSummary struct {
+	RunId     string    `json:"run_id"`
+	Target    string    `json:"target"`
+	ScannedAt time.Time `json:"scanned_at"`
+	Resources int       `json:"resources"`
+	Drifted   int       `json:"drifted"`
+	Failed    int       `json:"failed"`
+}
+
+// Dashboard is what the dashboard shows: the latest run of every target, and the
+// runs of the trend period, oldest first.
+type Dashboard struct {
+	Since   time.Time    `json:"since"`
+	Targets []RunSummary `json:"targets"`
+	Runs    []RunSummary `json:"runs"`
+}
+
+// dashboardSummary summarizes the reports of the last ?days= days, 14 by default.
+func (s *Server) dashboardSummary(w http.ResponseWriter, r *http.Request) {
+	days := defaultTrendDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTrendDays {
+			writeJSON(r.Context(), w, http.StatusBadRequest, errorBody{Error: "days must be an integer from 1 to " + strconv.Itoa(maxTrendDays)})
+			return
+		}
+		days = n
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	reports, err := s.reports.History(r.Context(), store.HistoryQuery{Since: since})
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	dashboard := summarize(reports)
+	dashboard.Since = since
+	writeJSON(r.Context(), w, http.StatusOK, dashboard)
+}
+
+// summarize counts reports by run and target.
+func summarize(reports []store.StoredReport) Dashboard {
+	type key struct{ runId, target string }
+	runs := map[key]*RunSummary{}
+	for _, stored := range reports {
+		report := stored.Report
+		k := key{stored.RunId, reportTarget(&report)}
+		run, ok := runs[k]
+		if !ok {
+			run = &RunSummary{RunId: k.runId, Target: k.target}
+			runs[k] = run
+		}
+		if report.GeneratedAt.After(run.ScannedAt) {
+			run.ScannedAt = report.GeneratedAt
+		}
+		if report.Summary != nil || report.Status == string(driftchecker.Skipped) {
+			// neither the marker of an interrupted scan nor a skipped resource was checked
+			continue
+		}
+		run.Resources++
+		switch {
+		case report.Status == string(driftchecker.CheckFailed):
+			run.Failed++
+		case report.HasDrift && report.Status != string(driftchecker.DriftResolved) && !report.Expected():
+			run.Drifted++
+		}
+	}
+
+	dashboard := Dashboard{Targets: []RunSummary{}, Runs: []RunSummary{}}
+	latest := map[string]RunSummary{}
+	for _, run := range runs {
+		dashboard.Runs = append(dashboard.Runs, *run)
+		if run.ScannedAt.After(latest[run.Target].ScannedAt) {
+			latest[run.Target] = *run
+		}
+	}
+	for _, run := range latest {
+		dashboard.Targets = append(dashboard.Targets, run)
+	}
+	slices.SortFunc(dashboard.Runs, func(a, b RunSummary) int {
+		return cmp.Or(a.ScannedAt.Compare(b.ScannedAt), cmp.Compare(a.Target, b.Target))
+	})
+	slices.SortFunc(dashboard.Targets, func(a, b RunSummary) int { return cmp.Compare(a.Target, b.Target) })
+	return dashboard
+}
+
+// reportTarget returns the target of report: its orchestration target, or else the
+// state file it was read from, so that the reports of plain detect runs are shown too.
+func reportTarget(report *driftchecker.DriftReport) string {
+	switch {
+	case report.Run == nil:
+		return ""
+	case report.Run.Target != "":
+		return report.Run.Target
+	default:
+		return report.Run.StatePath
+	}
+}
@@ -0,0 +1,249 @@
+// The driftwatcher dashboard reads everything it shows from the serve API.
+"use strict";
+
+const tokenKey = "driftwatcher.token";
+const palette = ["#0969da", "#cf222e", "#1a7f37", "#9a6700", "#8250df", "#bc4c00", "#1b7c83"];
+
+const $ = (id) => document.getElementById(id);
+
+// ApiError is a failed API response.
+class ApiError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+// api gets path, sending the credentials the user entered, if any.
+async function api(path) {
+  const headers = {};
+  const token = sessionStorage.getItem(tokenKey);
+  if (token) {
+    headers.Authorization = "Bearer " + token;
+  }
+  const resp = await fetch(path, { headers });
+  if (!resp.ok) {
+    let message = resp.statusText;
+    try {
+      message = (await resp.json()).error || message;
+    } catch (e) {
+      // not a JSON error body
+    }
+    throw new ApiError(resp.status, message);
+  }
+  return resp.json();
+}
+
+// el creates an element with the given attributes and children.
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs)) {
+    if (name === "class") {
+      node.className = value;
+    } else if (name.startsWith("on")) {
+      node.addEventListener(name.slice(2), value);
+    } else {
+      node.setAttribute(name, value);
+    }
+  }
+  node.append(...children.filter((child) => child !== null && child !== undefined));
+  return node;
+}
+
+function svg(tag, attrs = {}) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [name, value] of Object.entries(attrs)) {
+    node.setAttribute(name, value);
+  }
+  return node;
+}
+
+function formatTime(value) {
+  return new Date(value).toLocaleString();
+}
+
+function showError(err) {
+  if (err instanceof ApiError && (err.status === 401 || err.status === 403)) {
+    $("login").hidden = false;
+    $("error").hidden = err.status === 401;
+  } else {
+    $("error").hidden = false;
+  }
+  $("error").textContent = err.message;
+}
+
+async function load() {
+  $("error").hidden = true;
+  let dashboard;
+  try {
+    dashboard = await api("/api/v1/dashboard?days=" + $("days").value);
+  } catch (err) {
+    showError(err);
+    return;
+  }
+  $("login").hidden = true;
+  $("signout").hidden = !sessionStorage.getItem(tokenKey);
+  renderTargets(dashboard.targets);
+  renderTrend(dashboard.runs);
+}
+
+function renderTargets(targets) {
+  $("empty").hidden = targets.length > 0;
+  $("targets").replaceChildren(
+    ...targets.map((run) =>
+      el(
+        "tr",
+        {},
+        el("td", {}, run.target || "(unknown)"),
+        el("td", {}, formatTime(run.scanned_at)),
+        el("td", { class: "number" }, String(run.resources)),
+        el("td", { class: "number" + (run.drifted ? " drift" : " ok") }, String(run.drifted)),
+        el("td", { class: "number" + (run.failed ? " failed" : "") }, String(run.failed)),
+        el("td", {}, el("button", { type: "button", onclick: () => showRun(run) }, "Details")),
+      ),
+    ),
+  );
+}
+
+// renderTrend draws the drifted resources of every run, one line per target.
+function renderTrend(runs) {
+  const chart = $("chart");
+  const width = chart.clientWidth || 800;
+  const height = chart.clientHeight || 220;
+  const pad = 32;
+  chart.setAttribute("viewBox", `0 0 ${width} ${height}`);
+  chart.replaceChildren();
+  $("legend").replaceChildren();
+  if (runs.length === 0) {
+    return;
+  }
+
+  const times = runs.map((run) => new Date(run.scanned_at).getTime());
+  const first = Math.min(...times);
+  const span = Math.max(...times) - first || 1;
+  const top = Math.max(1, ...runs.map((run) => run.drifted));
+  const x = (run) => pad + ((new Date(run.scanned_at).getTime() - first) / span) * (width - 2 * pad);
+  const y = (run) => height - pad - (run.drifted / top) * (height - 2 * pad);
+
+  chart.append(
+    svg("line", { x1: pad, y1: height - pad, x2: width - pad, y2: height - pad, stroke: "#d0d7de" }),
+    Object.assign(svg("text", { x: 4, y: pad, "font-size": 11, fill: "#656d76" }), { textContent: String(top) }),
+    Object.assign(svg("text", { x: 4, y: height - pad, "font-size": 11, fill: "#656d76" }), { textContent: "0" }),
+  );
+
+  const targets = [...new Set(runs.map((run) => run.target))].sort();
+  targets.forEach((target, i) => {
+    const color = palette[i % palette.length];
+    const points = runs.filter((run) => run.target === target);
+    chart.append(
+      svg("polyline", {
+        points: points.map((run) => `${x(run)},${y(run)}`).join(" "),
+        fill: "none",
+        stroke: color,
+        "stroke-width": 2,
+      }),
+    );
+    for (const run of points) {
+      const dot = svg("circle", { cx: x(run), cy: y(run), r: 4, fill: color, cursor: "pointer" });
+      const title = svg("title");
+      title.textContent = `${target || "(unknown)"}: ${run.drifted} drifted at ${formatTime(run.scanned_at)}`;
+      dot.append(title);
+      dot.addEventListener("click", () => showRun(run));
+      chart.append(dot);
+    }
+    $("legend").append(el("li", {}, el("span", { style: `background: ${color}` }), target || "(unknown)"));
+  });
+}
+
+let shown = [];
+
+async function showRun(run) {
+  $("details").hidden = false;
+  $("details-title").textContent = `${run.target || "(unknown)"} – run ${run.run_id} at ${formatTime(run.scanned_at)}`;
+  $("reports").replaceChildren("Loading…");
+  try {
+    shown = await api(
+      "/api/v1/runs/" + encodeURIComponent(run.run_id) + "/reports?target=" + encodeURIComponent(run.target),
+    );
+  } catch (err) {
+    showError(err);
+    $("reports").replaceChildren();
+    return;
+  }
+  renderReports();
+  $("details").scrollIntoView({ behavior: "smooth" });
+}
+
+function value(v) {
+  if (v === null || v === undefined) {
+    return el("em", {}, "(none)");
+  }
+  return el("pre", {}, typeof v === "string" ? v : JSON.stringify(v, null, 2));
+}
+
+function renderReports() {
+  const driftOnly = $("drift-only").checked;
+  const reports = shown.filter(
+    (report) => !report.summary && (!driftOnly || report.has_drift || report.status === "CHECK_FAILED"),
+  );
+  if (reports.length === 0) {
+    $("reports").replaceChildren(driftOnly ? "No resource drifted in this run." : "This run has no reports.");
+    return;
+  }
+  $("reports").replaceChildren(
+    ...reports.map((report) => {
+      const name = report.resource_address || `${report.resource_type}.${report.resource_name}`;
+      let status = el("span", { class: "ok" }, "in sync");
+      if (report.status === "CHECK_FAILED") {
+        status = el("span", { class: "failed" }, "check failed");
+      } else if (report.has_drift) {
+        status = el("span", { class: "drift" }, "drifted");
+      }
+      const details = report.drift_details || [];
+      return el(
+        "div",
+        { class: "report" },
+        el("h3", {}, el("code", {}, name), " ", status),
+        report.resource_id ? el("div", {}, el("code", {}, report.resource_id)) : null,
+        report.failure ? el("p", { class: "failed" }, report.failure.error) : null,
+        details.length === 0
+          ? null
+          : el(
+              "table",
+              {},
+              el("thead", {}, el("tr", {}, el("th", {}, "Attribute"), el("th", {}, "Terraform"), el("th", {}, "Actual"), el("th", {}, "Drift"))),
+              el(
+                "tbody",
+                {},
+                ...details.map((item) =>
+                  el(
+                    "tr",
+                    {},
+                    el("td", {}, el("code", {}, item.field)),
+                    el("td", {}, value(item.terraform_value)),
+                    el("td", {}, value(item.actual_value)),
+                    el("td", {}, item.drift_type),
+                  ),
+                ),
+              ),
+            ),
+      );
+    }),
+  );
+}
+
+$("login").addEventListener("submit", (event) => {
+  event.preventDefault();
+  sessionStorage.setItem(tokenKey, $("token").value.trim());
+  $("token").value = "";
+  load();
+});
+$("signout").addEventListener("click", () => {
+  sessionStorage.removeItem(tokenKey);
+  location.reload();
+});
+$("days").addEventListener("change", load);
+$("refresh").addEventListener("click", load);
+$("drift-only").addEventListener("change", renderReports);
+
+load();
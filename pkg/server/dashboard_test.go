@@ -0,0 +1,127 @@
+package server_test
+
+import (
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/services/store/storefakes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReportServer(t *testing.T, fakeReports *storefakes.FakeReportStore, opts ...server.Option) *httptest.Server {
+	t.Helper()
+	scheduler := schedule.New(nil, &storefakes.FakeScheduleStore{}, nil)
+	srv := httptest.NewServer(server.New(scheduler, append(opts, server.WithReports(fakeReports))...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func storedReport(runId, target string, at time.Time, report driftchecker.DriftReport) store.StoredReport {
+	report.GeneratedAt = at
+	report.Run = &driftchecker.RunMetadata{RunId: runId, Target: target}
+	return store.StoredReport{RunId: runId, Report: report}
+}
+
+func TestServer_Dashboard(t *testing.T) {
+	earlier := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+	later := earlier.Add(24 * time.Hour)
+	fakeReports := &storefakes.FakeReportStore{}
+	fakeReports.HistoryReturns([]store.StoredReport{
+		storedReport("run-2", "prod", later, driftchecker.DriftReport{ResourceId: "i-1"}),
+		storedReport("run-2", "prod", later, driftchecker.DriftReport{ResourceId: "i-2", HasDrift: true}),
+		storedReport("run-2", "prod", later, driftchecker.DriftReport{ResourceId: "i-4", HasDrift: true, Status: string(driftchecker.DriftResolved)}),
+		storedReport("run-2", "prod", later, driftchecker.DriftReport{ResourceId: "i-5", HasDrift: true, Maintenance: &driftchecker.MaintenanceWindow{Resource: "i-5"}}),
+		storedReport("run-2", "prod", later, driftchecker.DriftReport{ResourceId: "i-6", Status: string(driftchecker.Skipped)}),
+		storedReport("run-1", "prod", earlier, driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true}),
+		storedReport("run-1", "prod", earlier, driftchecker.DriftReport{ResourceId: "i-2", HasDrift: true}),
+		storedReport("run-1", "staging", earlier, driftchecker.DriftReport{ResourceId: "i-3", Status: string(driftchecker.CheckFailed)}),
+		storedReport("run-1", "staging", earlier, driftchecker.DriftReport{Summary: &driftchecker.ScanSummary{Checked: 1, Total: 2}}),
+	}, nil)
+	srv := newReportServer(t, fakeReports)
+
+	var dashboard server.Dashboard
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/dashboard?days=30", &dashboard))
+	_, query := fakeReports.HistoryArgsForCall(0)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), query.Since, time.Minute)
+
+	// the latest run of every target, and every run oldest first; resolved and expected
+	// drift is not counted as drifted, skipped resources are not counted at all
+	assert.Equal(t, []server.RunSummary{
+		{RunId: "run-2", Target: "prod", ScannedAt: later, Resources: 4, Drifted: 1},
+		{RunId: "run-1", Target: "staging", ScannedAt: earlier, Resources: 1, Failed: 1},
+	}, dashboard.Targets)
+	assert.Equal(t, []server.RunSummary{
+		{RunId: "run-1", Target: "prod", ScannedAt: earlier, Resources: 2, Drifted: 2},
+		{RunId: "run-1", Target: "staging", ScannedAt: earlier, Resources: 1, Failed: 1},
+		{RunId: "run-2", Target: "prod", ScannedAt: later, Resources: 4, Drifted: 1},
+	}, dashboard.Runs)
+
+	for _, days := range []string{"0", "91", "week"} {
+		assert.Equal(t, http.StatusBadRequest, do(t, http.MethodGet, srv.URL+"/api/v1/dashboard?days="+days, nil), days)
+	}
+}
+
+func TestServer_RunReports(t *testing.T) {
+	at := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+	fakeReports := &storefakes.FakeReportStore{}
+	fakeReports.HistoryReturns([]store.StoredReport{
+		storedReport("run-1", "prod", at, driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true, DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t3.large", DriftType: driftchecker.AttributeValueChanged},
+		}}),
+		storedReport("run-1", "staging", at, driftchecker.DriftReport{ResourceId: "i-2"}),
+	}, nil)
+	srv := newReportServer(t, fakeReports)
+
+	var reports []driftchecker.DriftReport
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/runs/run-1/reports", &reports))
+	assert.Len(t, reports, 2)
+	_, query := fakeReports.HistoryArgsForCall(0)
+	assert.Equal(t, "run-1", query.RunId)
+
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/runs/run-1/reports?target=prod", &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "i-1", reports[0].ResourceId)
+	require.Len(t, reports[0].DriftDetails, 1)
+	assert.Equal(t, "t3.large", reports[0].DriftDetails[0].ActualValue)
+}
+
+func TestServer_DashboardAssets(t *testing.T) {
+	srv := newReportServer(t, &storefakes.FakeReportStore{}, server.WithDashboard())
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	resp, err := client.Get(srv.URL + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/dashboard/", resp.Header.Get("Location"))
+
+	for path, contentType := range map[string]string{
+		"/dashboard/":          "text/html; charset=utf-8",
+		"/dashboard/app.js":    "text/javascript; charset=utf-8",
+		"/dashboard/style.css": "text/css; charset=utf-8",
+	} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, contentType, resp.Header.Get("Content-Type"), path)
+		assert.NotEmpty(t, body, path)
+	}
+
+	// without WithDashboard only the API is served
+	srv = newReportServer(t, &storefakes.FakeReportStore{})
+	resp, err = client.Get(srv.URL + "/dashboard/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
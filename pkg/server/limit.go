@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// busyRetryAfter is how long clients are told to wait before triggering a run again
+// when the scheduler runs as many as it may.
+const busyRetryAfter = 30 * time.Second
+
+// clientIdleTimeout is how long the rate limiter of a client is kept after its last
+// request.
+const clientIdleTimeout = 10 * time.Minute
+
+// WithRateLimit limits the requests of each client, identified by its IP address, to
+// perSecond on average with bursts of burst requests. Requests over the limit are
+// answered with 429 Too Many Requests and a Retry-After header. Health checks are not
+// limited.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(s *Server) {
+		s.limiter = &clientLimiter{
+			limit:   rate.Limit(perSecond),
+			burst:   max(burst, 1),
+			clients: map[string]*clientRate{},
+		}
+	}
+}
+
+// WithMaxBodySize answers the requests whose body is larger than size bytes with 413
+// Request Entity Too Large.
+func WithMaxBodySize(size int64) Option {
+	return func(s *Server) {
+		s.maxBodySize = size
+	}
+}
+
+// limit guards handler with the rate limit and the body size limit of s.
+func (s *Server) limit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter != nil && r.URL.Path != "/healthz" {
+			client := clientAddress(r)
+			if wait := s.limiter.reserve(client, time.Now()); wait > 0 {
+				logger(r.Context()).Warn("Request rate limited", "client", client, "method", r.Method, "path", r.URL.Path)
+				writeRetryAfter(r, w, http.StatusTooManyRequests, wait, "rate limit exceeded")
+				return
+			}
+		}
+		if s.maxBodySize > 0 {
+			if r.ContentLength > s.maxBodySize {
+				writeJSON(r.Context(), w, http.StatusRequestEntityTooLarge, errorBody{Error: fmt.Sprintf("request body larger than %d bytes", s.maxBodySize)})
+				return
+			}
+			// bodies of unknown length are cut off when read past the limit
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// writeRetryAfter writes an error response telling the client to retry after wait.
+func writeRetryAfter(r *http.Request, w http.ResponseWriter, status int, wait time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSON(r.Context(), w, status, errorBody{Error: message})
+}
+
+// clientAddress returns the IP address of the client of r. Forwarding headers are not
+// trusted, as any client can set them.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientLimiter keeps a token bucket per client. Buckets of clients idle for longer
+// than clientIdleTimeout are dropped, so that the clients seen over the lifetime of
+// the server do not accumulate.
+type clientLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	clients   map[string]*clientRate
+	lastSweep time.Time
+}
+
+type clientRate struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// reserve takes a request of client at now from its bucket, and returns how long the
+// client has to wait when the bucket is empty.
+func (l *clientLimiter) reserve(client string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > clientIdleTimeout {
+		for address, c := range l.clients {
+			if now.Sub(c.seen) > clientIdleTimeout {
+				delete(l.clients, address)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientRate{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = c
+	}
+	c.seen = now
+	reservation := c.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Second
+	}
+	if wait := reservation.DelayFrom(now); wait > 0 {
+		// the request is refused, so it does not take the token
+		reservation.CancelAt(now)
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package server_test
+
+import (
+	"context"
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store/storefakes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	hourly, err := schedule.ParseCron("@hourly")
+	require.NoError(t, err)
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: hourly}}, &storefakes.FakeScheduleStore{}, nil)
+	srv := httptest.NewServer(server.New(scheduler, server.WithRateLimit(0.5, 2)))
+	t.Cleanup(srv.Close)
+
+	assert.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/schedules", nil))
+	assert.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/schedules", nil))
+
+	resp, err := http.Get(srv.URL + "/api/v1/schedules")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.True(t, retryAfter >= 1 && retryAfter <= 2, "Retry-After %d", retryAfter)
+
+	// health checks are not limited
+	assert.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/healthz", nil))
+}
+
+func TestServer_MaxBodySize(t *testing.T) {
+	hourly, err := schedule.ParseCron("@hourly")
+	require.NoError(t, err)
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: hourly}}, &storefakes.FakeScheduleStore{}, nil)
+	srv := httptest.NewServer(server.New(scheduler, server.WithMaxBodySize(16)))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Post(srv.URL+"/api/v1/schedules/hourly/pause", "application/json", strings.NewReader(`{"reason": "maintenance window"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/api/v1/schedules/hourly/pause", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_TriggerTooManyRuns(t *testing.T) {
+	release := make(chan struct{})
+	run := func(ctx context.Context, name string, runId string) (int, error) {
+		<-release
+		return 0, nil
+	}
+	scheduler := schedule.New([]schedule.Entry{
+		{Name: "hourly", Cron: mustParseCron(t, "@hourly")},
+		{Name: "nightly", Cron: mustParseCron(t, "0 2 * * *")},
+	}, &storefakes.FakeScheduleStore{}, run, schedule.WithMaxRuns(1))
+	srv := httptest.NewServer(server.New(scheduler))
+	t.Cleanup(srv.Close)
+	t.Cleanup(scheduler.Wait)
+	defer close(release)
+
+	assert.Equal(t, http.StatusAccepted, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/run", nil))
+	resp, err := http.Post(srv.URL+"/api/v1/schedules/nightly/run", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+}
+
+func mustParseCron(t *testing.T, expr string) *schedule.Cron {
+	t.Helper()
+	cron, err := schedule.ParseCron(expr)
+	require.NoError(t, err)
+	return cron
+}
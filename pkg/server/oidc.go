@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// OIDCConfig declares the identity provider whose ID or access tokens are accepted as
+// bearer tokens, and how the claims of a token map to a role.
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// JWKSURL is the URL of the keys of the issuer, discovered from its
+	// /.well-known/openid-configuration when empty.
+	JWKSURL string `yaml:"jwks_url"`
+	// NameClaim names the caller in logs, sub when empty.
+	NameClaim string `yaml:"name_claim"`
+	// RoleClaim is the claim, a string or a list of strings, whose values are mapped
+	// to a role through Roles. The highest role mapped is granted.
+	RoleClaim string          `yaml:"role_claim"`
+	Roles     map[string]Role `yaml:"roles"`
+	// DefaultRole is granted to the callers none of whose claim values are mapped.
+	// Such callers are denied every request when it is empty.
+	DefaultRole Role `yaml:"default_role"`
+}
+
+func (c *OIDCConfig) validate() error {
+	if c.Issuer == "" || c.Audience == "" {
+		return errors.New("an issuer and an audience are required")
+	}
+	if len(c.Roles) > 0 && c.RoleClaim == "" {
+		return errors.New("roles require a role_claim")
+	}
+	for value, role := range c.Roles {
+		parsed, err := ParseRole(string(role))
+		if err != nil {
+			return fmt.Errorf("role of %s: %w", value, err)
+		}
+		c.Roles[value] = parsed
+	}
+	if c.DefaultRole != "" {
+		parsed, err := ParseRole(string(c.DefaultRole))
+		if err != nil {
+			return fmt.Errorf("default_role: %w", err)
+		}
+		c.DefaultRole = parsed
+	}
+	return nil
+}
+
+// oidcSigningAlgs are the algorithms accepted for the signature of a token.
+var oidcSigningAlgs = []string{oidc.RS256, oidc.RS384, oidc.RS512, oidc.ES256, oidc.ES384}
+
+// OIDCAuthenticator authenticates the callers presenting a JSON Web Token signed by the
+// configured issuer, with RS256, RS384, RS512, ES256 or ES384.
+type OIDCAuthenticator struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCAuthenticator creates an authenticator of the tokens of the issuer of config.
+// The discovery document and the keys of the issuer are fetched with client on the
+// first request.
+func NewOIDCAuthenticator(config OIDCConfig, client *http.Client) *OIDCAuthenticator {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCAuthenticator{config: config, client: client, now: time.Now}
+}
+
+func (a *OIDCAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerToken(r)
+	if strings.Count(token, ".") != 2 {
+		// not a JWT, such as an API key
+		return nil, nil
+	}
+	verifier, err := a.tokenVerifier(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := verifier.Verify(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	nameClaim := a.config.NameClaim
+	if nameClaim == "" {
+		nameClaim = "sub"
+	}
+	name, _ := claims[nameClaim].(string)
+	return &Principal{Name: name, Role: a.role(claims), Method: "oidc"}, nil
+}
+
+// tokenVerifier returns the verifier of the tokens of the issuer, discovering the
+// issuer on the first call. A failed discovery is retried on the next call.
+func (a *OIDCAuthenticator) tokenVerifier(ctx context.Context) (*oidc.IDTokenVerifier, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.verifier != nil {
+		return a.verifier, nil
+	}
+
+	config := &oidc.Config{ClientID: a.config.Audience, SupportedSigningAlgs: oidcSigningAlgs, Now: a.now}
+	if a.config.JWKSURL != "" {
+		// the key set outlives the request creating it
+		keys := oidc.NewRemoteKeySet(oidc.ClientContext(context.Background(), a.client), a.config.JWKSURL)
+		a.verifier = oidc.NewVerifier(a.config.Issuer, keys, config)
+		return a.verifier, nil
+	}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, a.client), a.config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovering issuer %s: %w", a.config.Issuer, err)
+	}
+	a.verifier = provider.Verifier(config)
+	return a.verifier, nil
+}
+
+// role returns the highest role the values of the role claim map to.
+func (a *OIDCAuthenticator) role(claims map[string]any) Role {
+	var values []string
+	switch claim := claims[a.config.RoleClaim].(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []any:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	role := a.config.DefaultRole
+	for _, value := range values {
+		if mapped, ok := a.config.Roles[value]; ok && !role.Allows(mapped) {
+			role = mapped
+		}
+	}
+	return role
+}
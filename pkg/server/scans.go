@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// maxFormMemory is how much of an upload is kept in memory, the rest is spooled to a
+// temporary file.
+const maxFormMemory = 1 << 20
+
+// submitScan queues the scan of the state file uploaded as the "state" part of a
+// multipart form, as the plan target named by its "target" field. It returns the
+// queued job at once; the client polls GET /api/v1/scans/{id} for its outcome.
+func (s *Server) submitScan(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxFormMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(r.Context(), w, err)
+			return
+		}
+		writeJSON(r.Context(), w, http.StatusBadRequest, errorBody{Error: "expected a multipart form with a state file"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	upload, _, err := r.FormFile("state")
+	if err != nil {
+		writeJSON(r.Context(), w, http.StatusBadRequest, errorBody{Error: "the form has no state file"})
+		return
+	}
+	defer upload.Close()
+
+	statePath, err := saveUpload(upload)
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	job, err := s.scans.Submit(r.Context(), r.FormValue("target"), statePath)
+	if err != nil {
+		os.Remove(statePath)
+		writeError(r.Context(), w, err)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/scans/"+job.Id)
+	writeJSON(r.Context(), w, http.StatusAccepted, job)
+}
+
+func (s *Server) getScan(w http.ResponseWriter, r *http.Request) {
+	job, err := s.scans.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	writeJSON(r.Context(), w, http.StatusOK, job)
+}
+
+// saveUpload copies an uploaded state file to a temporary file the scan reads, as the
+// upload itself is removed once the request returns.
+func saveUpload(upload io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "driftwatcher-scan-*.tfstate")
+	if err != nil {
+		return "", fmt.Errorf("failed to save uploaded state file: %w", err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, upload); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to save uploaded state file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// sseKeepAlive is how often a comment is sent on an idle event stream, so that
+// proxies do not close it.
+const sseKeepAlive = 15 * time.Second
+
+// streamScan follows a scan as server-sent events: a "status" event with the job,
+// without its reports, whenever its status changes, and a "report" event for every
+// report as soon as it is produced, whose event id is its index. The stream ends after
+// the status event of the finished scan. A client reconnecting with the
+// Last-Event-ID header is only sent the reports after that id.
+func (s *Server) streamScan(w http.ResponseWriter, r *http.Request) {
+	job, changed, err := s.scans.Watch(r.PathValue("id"))
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(r.Context(), w, errors.New("streaming is not supported"))
+		return
+	}
+	sent := 0
+	if lastId, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && lastId >= 0 {
+		sent = lastId + 1
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	status := ""
+	for {
+		for ; sent < len(job.Reports); sent++ {
+			if err := writeEvent(w, "report", strconv.Itoa(sent), job.Reports[sent]); err != nil {
+				return
+			}
+		}
+		if job.Status != status {
+			status = job.Status
+			summary := job
+			summary.Reports = nil
+			if err := writeEvent(w, "status", "", summary); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		if job.Finished() {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-changed:
+			if job, changed, err = s.scans.Watch(job.Id); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes a server-sent event of type event holding data as JSON.
+func writeEvent(w io.Writer, event string, id string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
@@ -0,0 +1,219 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/scanqueue"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store/storefakes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScanServer(t *testing.T, run scanqueue.RunFunc, opts ...server.Option) *httptest.Server {
+	t.Helper()
+	queue := scanqueue.New([]string{"prod", "staging"}, run)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		queue.Start(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+
+	scheduler := schedule.New(nil, &storefakes.FakeScheduleStore{}, nil)
+	srv := httptest.NewServer(server.New(scheduler, append(opts, server.WithScans(queue))...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// upload posts state as the state file of a multipart form with the given target.
+func upload(t *testing.T, url string, target string, state string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if target != "" {
+		require.NoError(t, form.WriteField("target", target))
+	}
+	if state != "" {
+		part, err := form.CreateFormFile("state", "terraform.tfstate")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(state))
+		require.NoError(t, err)
+	}
+	require.NoError(t, form.Close())
+	resp, err := http.Post(url+"/api/v1/scans", form.FormDataContentType(), &body)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServer_Scans(t *testing.T) {
+	var scanned []byte
+	srv := newScanServer(t, func(ctx context.Context, job scanqueue.Job, report func(*driftchecker.DriftReport)) error {
+		var err error
+		scanned, err = os.ReadFile(job.StatePath)
+		report(&driftchecker.DriftReport{ResourceId: "i-0abc", HasDrift: true})
+		return err
+	})
+
+	resp := upload(t, srv.URL, "staging", `{"version": 4, "resources": []}`)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var job scanqueue.Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, "staging", job.Target)
+	assert.Equal(t, "/api/v1/scans/"+job.Id, resp.Header.Get("Location"))
+
+	require.Eventually(t, func() bool {
+		require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/scans/"+job.Id, &job))
+		return job.Status == scanqueue.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, job.Drifted)
+	require.Len(t, job.Reports, 1)
+	assert.Equal(t, "i-0abc", job.Reports[0].ResourceId)
+	assert.JSONEq(t, `{"version": 4, "resources": []}`, string(scanned))
+
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, srv.URL+"/api/v1/scans/unknown", nil))
+}
+
+func TestServer_Scans_Invalid(t *testing.T) {
+	srv := newScanServer(t, nil, server.WithMaxBodySize(1024))
+
+	for name, tc := range map[string]struct {
+		target, state, error string
+		status               int
+	}{
+		"no state file":  {target: "prod", status: http.StatusBadRequest, error: "the form has no state file"},
+		"no target":      {state: "{}", status: http.StatusBadRequest, error: "unknown target: a target is required, one of prod, staging"},
+		"unknown target": {target: "dev", state: "{}", status: http.StatusBadRequest, error: "unknown target: dev"},
+		"too large":      {target: "prod", state: string(bytes.Repeat([]byte("x"), 2048)), status: http.StatusRequestEntityTooLarge},
+	} {
+		resp := upload(t, srv.URL, tc.target, tc.state)
+		assert.Equal(t, tc.status, resp.StatusCode, name)
+		if tc.error != "" {
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tc.error, body["error"], name)
+		}
+	}
+
+	resp, err := http.Post(srv.URL+"/api/v1/scans", "application/json", bytes.NewReader([]byte("{}")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// event is a server-sent event.
+type event struct {
+	id, name, data string
+}
+
+// readEvents reads the events of a stream until it ends.
+func readEvents(t *testing.T, body io.Reader, events chan<- event) {
+	t.Helper()
+	defer close(events)
+	scanner := bufio.NewScanner(body)
+	var current event
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name != "" {
+				events <- current
+			}
+			current = event{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestServer_StreamScan(t *testing.T) {
+	next := make(chan struct{})
+	srv := newScanServer(t, func(ctx context.Context, job scanqueue.Job, report func(*driftchecker.DriftReport)) error {
+		for _, id := range []string{"i-1", "i-2"} {
+			<-next
+			report(&driftchecker.DriftReport{ResourceId: id, HasDrift: id == "i-2"})
+		}
+		<-next
+		return nil
+	})
+	resp := upload(t, srv.URL, "prod", "{}")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var job scanqueue.Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+
+	stream, err := http.Get(srv.URL + "/api/v1/scans/" + job.Id + "/events")
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+	events := make(chan event)
+	go readEvents(t, stream.Body, events)
+
+	status := func(e event) string {
+		var job scanqueue.Job
+		require.NoError(t, json.Unmarshal([]byte(e.data), &job))
+		assert.Empty(t, job.Reports, "status events leave out the reports")
+		return job.Status
+	}
+	e := <-events
+	require.Equal(t, "status", e.name)
+	if status(e) == scanqueue.StatusQueued {
+		e = <-events
+		require.Equal(t, "status", e.name)
+	}
+	assert.Equal(t, scanqueue.StatusRunning, status(e))
+
+	// every report is streamed as soon as it is produced
+	for i, id := range []string{"i-1", "i-2"} {
+		next <- struct{}{}
+		e := <-events
+		assert.Equal(t, "report", e.name)
+		assert.Equal(t, strconv.Itoa(i), e.id)
+		assert.Contains(t, e.data, `"resource_id":"`+id+`"`)
+	}
+	next <- struct{}{}
+	e = <-events
+	assert.Equal(t, "status", e.name)
+	assert.Equal(t, scanqueue.StatusOK, status(e))
+	_, open := <-events
+	assert.False(t, open, "the stream ends with the scan")
+
+	// a client reconnecting after the first report is sent the rest
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/scans/"+job.Id+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "0")
+	resumed, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resumed.Body.Close()
+	events = make(chan event)
+	go readEvents(t, resumed.Body, events)
+	var names []string
+	for e := range events {
+		names = append(names, e.name+" "+e.id)
+	}
+	assert.Equal(t, []string{"report 1", "status "}, names)
+
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, srv.URL+"/api/v1/scans/unknown/events", nil))
+}
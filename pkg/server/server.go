@@ -0,0 +1,194 @@
+// Package server implements the HTTP API of the serve command, through which the
+// schedules of the server are listed, paused, resumed and triggered, their runs
+// inspected, uploaded state files scanned, and the stored reports browsed, also from
+// a web dashboard.
+package server
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/scanqueue"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Server serves the API of a scheduler.
+type Server struct {
+	scheduler      *schedule.Scheduler
+	scans          *scanqueue.Queue
+	reports        store.ReportStore
+	dashboard      bool
+	mux            *http.ServeMux
+	handler        http.Handler
+	authenticators []Authenticator
+	limiter        *clientLimiter
+	maxBodySize    int64
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAuthenticators requires every request but health checks to be made by a caller
+// one of authenticators accepts, and granted the role of the endpoint: viewer to read,
+// operator to trigger runs and admin to pause and resume schedules. Authenticators are
+// tried in order.
+func WithAuthenticators(authenticators ...Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithScans serves the scans of uploaded state files, run by queue.
+func WithScans(queue *scanqueue.Queue) Option {
+	return func(s *Server) {
+		s.scans = queue
+	}
+}
+
+// New creates the API of scheduler.
+func New(scheduler *schedule.Scheduler, opts ...Option) *Server {
+	s := &Server{scheduler: scheduler, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("GET /healthz", s.health)
+	s.mux.HandleFunc("GET /api/v1/whoami", s.authorize(RoleViewer, s.whoami))
+	s.mux.HandleFunc("GET /api/v1/schedules", s.authorize(RoleViewer, s.listSchedules))
+	s.mux.HandleFunc("GET /api/v1/schedules/{name}/runs", s.authorize(RoleViewer, s.listRuns))
+	s.mux.HandleFunc("POST /api/v1/schedules/{name}/pause", s.authorize(RoleAdmin, s.pause))
+	s.mux.HandleFunc("POST /api/v1/schedules/{name}/resume", s.authorize(RoleAdmin, s.resume))
+	s.mux.HandleFunc("POST /api/v1/schedules/{name}/run", s.authorize(RoleOperator, s.trigger))
+	if s.scans != nil {
+		s.mux.HandleFunc("POST /api/v1/scans", s.authorize(RoleOperator, s.submitScan))
+		s.mux.HandleFunc("GET /api/v1/scans/{id}", s.authorize(RoleViewer, s.getScan))
+		s.mux.HandleFunc("GET /api/v1/scans/{id}/events", s.authorize(RoleViewer, s.streamScan))
+	}
+	s.handleReports()
+	s.handler = s.limit(s.mux)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(r.Context(), w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// whoami returns the caller of the request, so that clients can check their
+// credentials and role.
+func (s *Server) whoami(w http.ResponseWriter, r *http.Request) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		principal = &Principal{Name: "anonymous", Role: RoleAdmin}
+	}
+	writeJSON(r.Context(), w, http.StatusOK, principal)
+}
+
+func (s *Server) listSchedules(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.scheduler.Status(r.Context(), time.Now())
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	writeJSON(r.Context(), w, http.StatusOK, statuses)
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeJSON(r.Context(), w, http.StatusBadRequest, errorBody{Error: "limit must be a non-negative integer"})
+			return
+		}
+		limit = n
+	}
+	runs, err := s.scheduler.Runs(r.Context(), r.PathValue("name"), limit)
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	if runs == nil {
+		runs = []store.ScheduledRun{}
+	}
+	writeJSON(r.Context(), w, http.StatusOK, runs)
+}
+
+func (s *Server) pause(w http.ResponseWriter, r *http.Request) {
+	if err := s.scheduler.Pause(r.Context(), r.PathValue("name")); err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	writeJSON(r.Context(), w, http.StatusOK, map[string]any{"name": r.PathValue("name"), "paused": true})
+}
+
+func (s *Server) resume(w http.ResponseWriter, r *http.Request) {
+	if err := s.scheduler.Resume(r.Context(), r.PathValue("name")); err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	writeJSON(r.Context(), w, http.StatusOK, map[string]any{"name": r.PathValue("name"), "paused": false})
+}
+
+func (s *Server) trigger(w http.ResponseWriter, r *http.Request) {
+	runId, err := s.scheduler.Trigger(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	writeJSON(r.Context(), w, http.StatusAccepted, map[string]string{"name": r.PathValue("name"), "run_id": runId})
+}
+
+// errorBody is the body of an error response.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// writeError writes err with the status it maps to: 400 for an unknown target, 404
+// for an unknown schedule or scan, 409 for a schedule whose previous run has not
+// finished or that runs on another server, 413 for a request body over the size
+// limit, 503 when too many runs are in progress or the scan queue is full, and 500
+// otherwise.
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	var status int
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, scanqueue.ErrUnknownTarget):
+		status = http.StatusBadRequest
+	case errors.Is(err, schedule.ErrUnknownSchedule), errors.Is(err, scanqueue.ErrUnknownJob):
+		status = http.StatusNotFound
+	case errors.Is(err, schedule.ErrRunInProgress), errors.Is(err, schedule.ErrLockHeld):
+		status = http.StatusConflict
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, schedule.ErrTooManyRuns), errors.Is(err, scanqueue.ErrQueueFull):
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", strconv.Itoa(int(busyRetryAfter.Seconds())))
+	default:
+		status = http.StatusInternalServerError
+		logger(ctx).Error("Request failed", "error", err)
+	}
+	writeJSON(ctx, w, status, errorBody{Error: err.Error()})
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger(ctx).Warn("Failed to write response", "error", err)
+	}
+}
+
+// logger returns the logger carried by ctx for the server module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "server")
+}
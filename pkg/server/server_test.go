@@ -0,0 +1,129 @@
+package server_test
+
+import (
+	"context"
+	"drift-watcher/pkg/server"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/services/store/storefakes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, fakeStore *storefakes.FakeScheduleStore, run schedule.RunFunc) (*httptest.Server, *schedule.Scheduler) {
+	t.Helper()
+	hourly, err := schedule.ParseCron("@hourly")
+	require.NoError(t, err)
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: hourly}}, fakeStore, run)
+	srv := httptest.NewServer(server.New(scheduler))
+	t.Cleanup(srv.Close)
+	return srv, scheduler
+}
+
+func do(t *testing.T, method, url string, body any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	if body != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(body))
+	}
+	return resp.StatusCode
+}
+
+func TestServer_Schedules(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	fakeStore.PausedSchedulesReturns(map[string]bool{"hourly": true}, nil)
+	fakeStore.ScheduledRunsReturns([]store.ScheduledRun{{RunId: "run-1", Schedule: "hourly", Status: store.RunOK, Drifted: 2}}, nil)
+	srv, _ := newServer(t, fakeStore, nil)
+
+	var health map[string]string
+	assert.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/healthz", &health))
+	assert.Equal(t, "ok", health["status"])
+
+	var statuses []schedule.Status
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/schedules", &statuses))
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "hourly", statuses[0].Name)
+	assert.Equal(t, "@hourly", statuses[0].Cron)
+	assert.True(t, statuses[0].Paused)
+	require.NotNil(t, statuses[0].LastRun)
+	assert.Equal(t, "run-1", statuses[0].LastRun.RunId)
+
+	var runs []store.ScheduledRun
+	require.Equal(t, http.StatusOK, do(t, http.MethodGet, srv.URL+"/api/v1/schedules/hourly/runs?limit=5", &runs))
+	assert.Len(t, runs, 1)
+	_, name, limit := fakeStore.ScheduledRunsArgsForCall(fakeStore.ScheduledRunsCallCount() - 1)
+	assert.Equal(t, "hourly", name)
+	assert.Equal(t, 5, limit)
+
+	assert.Equal(t, http.StatusBadRequest, do(t, http.MethodGet, srv.URL+"/api/v1/schedules/hourly/runs?limit=-1", nil))
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, srv.URL+"/api/v1/schedules/weekly/runs", nil))
+}
+
+func TestServer_PauseResume(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	srv, _ := newServer(t, fakeStore, nil)
+
+	var body map[string]any
+	require.Equal(t, http.StatusOK, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/pause", &body))
+	assert.Equal(t, true, body["paused"])
+	require.Equal(t, http.StatusOK, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/resume", &body))
+	assert.Equal(t, false, body["paused"])
+
+	require.Equal(t, 2, fakeStore.SetSchedulePausedCallCount())
+	_, name, paused := fakeStore.SetSchedulePausedArgsForCall(0)
+	assert.Equal(t, "hourly", name)
+	assert.True(t, paused)
+	_, _, paused = fakeStore.SetSchedulePausedArgsForCall(1)
+	assert.False(t, paused)
+
+	var errBody map[string]string
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/weekly/pause", &errBody))
+	assert.Equal(t, "unknown schedule: weekly", errBody["error"])
+
+	fakeStore.SetSchedulePausedReturns(errors.New("database is locked"))
+	assert.Equal(t, http.StatusInternalServerError, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/pause", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, statusOf(t, http.MethodGet, srv.URL+"/api/v1/schedules/hourly/pause"))
+}
+
+func TestServer_Trigger(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	release := make(chan struct{})
+	srv, scheduler := newServer(t, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		<-release
+		return 0, nil
+	})
+
+	var body map[string]string
+	require.Equal(t, http.StatusAccepted, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/run", &body))
+	assert.Equal(t, "hourly", body["name"])
+	assert.NotEmpty(t, body["run_id"])
+
+	assert.Equal(t, http.StatusConflict, do(t, http.MethodPost, srv.URL+"/api/v1/schedules/hourly/run", nil))
+	close(release)
+	scheduler.Wait()
+
+	_, finished := fakeStore.RecordScheduledRunArgsForCall(1)
+	assert.Equal(t, body["run_id"], finished.RunId)
+	assert.Equal(t, store.RunOK, finished.Status)
+}
+
+func statusOf(t *testing.T, method, url string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
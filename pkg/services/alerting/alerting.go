@@ -0,0 +1,82 @@
+// Package alerting opens incidents in an on-call service, such as PagerDuty or
+// Opsgenie, when severe drift is detected and resolves them once the drift is gone.
+//
+// Every drifted attribute is alerted on separately, deduplicated by the resource
+// address and attribute, so that repeated scans update a single incident instead of
+// opening new ones.
+package alerting
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Action is what an event does to the incident of its dedup key.
+type Action string
+
+const (
+	Trigger Action = "trigger"
+	Resolve Action = "resolve"
+)
+
+// Event opens or resolves the incident of a drifted attribute.
+type Event struct {
+	Action Action
+	// DedupKey identifies the incident, derived from the resource address and attribute.
+	DedupKey string
+	Summary  string
+	// Severity is the policy severity of the drift, one of low, medium, high or critical.
+	Severity string
+	Details  map[string]any
+}
+
+// Notifier delivers events to an on-call service.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Supported services.
+const (
+	PagerDuty = "pagerduty"
+	Opsgenie  = "opsgenie"
+)
+
+// source identifies driftwatcher as the origin of events.
+const source = "driftwatcher"
+
+// requestTimeout bounds a single call to an on-call service.
+const requestTimeout = 10 * time.Second
+
+// NewNotifier creates the notifier of the named service.
+// service: pagerduty or opsgenie.
+// key: The PagerDuty integration routing key or the Opsgenie API key.
+// endpoint: The API endpoint, or empty for the service's public endpoint.
+func NewNotifier(service string, key string, endpoint string) (Notifier, error) {
+	if key == "" {
+		return nil, fmt.Errorf("an integration key is required to send %s alerts", service)
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	switch service {
+	case PagerDuty:
+		if endpoint == "" {
+			endpoint = PagerDutyEndpoint
+		}
+		return &PagerDutyNotifier{RoutingKey: key, Endpoint: endpoint, Client: client}, nil
+	case Opsgenie:
+		if endpoint == "" {
+			endpoint = OpsgenieEndpoint
+		}
+		return &OpsgenieNotifier{APIKey: key, Endpoint: endpoint, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("%s alerting not currently supported", service)
+	}
+}
+
+// logger returns the logger carried by ctx for the alerting module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "alerting")
+}
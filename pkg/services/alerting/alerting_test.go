@@ -0,0 +1,287 @@
+package alerting_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/alerting"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// request is a request received by the test server.
+type request struct {
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]any
+}
+
+func newServer(t *testing.T, status int) (*httptest.Server, *[]request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var decoded map[string]any
+		_ = json.Unmarshal(body, &decoded)
+		mu.Lock()
+		requests = append(requests, request{Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header, Body: decoded})
+		mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPagerDutyNotifier_Notify(t *testing.T) {
+	server, requests := newServer(t, http.StatusAccepted)
+	notifier, err := alerting.NewNotifier(alerting.PagerDuty, "routing-key", server.URL)
+	require.NoError(t, err)
+
+	err = notifier.Notify(context.Background(), alerting.Event{
+		Action:   alerting.Trigger,
+		DedupKey: "aws_instance.web:instance_type",
+		Summary:  "critical drift on aws_instance.web: instance_type",
+		Severity: "high",
+		Details:  map[string]any{"attribute": "instance_type"},
+	})
+	require.NoError(t, err)
+	err = notifier.Notify(context.Background(), alerting.Event{Action: alerting.Resolve, DedupKey: "aws_instance.web:instance_type"})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	trigger := (*requests)[0].Body
+	assert.Equal(t, "routing-key", trigger["routing_key"])
+	assert.Equal(t, "trigger", trigger["event_action"])
+	assert.Equal(t, "aws_instance.web:instance_type", trigger["dedup_key"])
+	payload := trigger["payload"].(map[string]any)
+	assert.Equal(t, "error", payload["severity"])
+	assert.Equal(t, "driftwatcher", payload["source"])
+	assert.Equal(t, map[string]any{"attribute": "instance_type"}, payload["custom_details"])
+
+	resolve := (*requests)[1].Body
+	assert.Equal(t, "resolve", resolve["event_action"])
+	assert.NotContains(t, resolve, "payload")
+}
+
+func TestOpsgenieNotifier_Notify(t *testing.T) {
+	server, requests := newServer(t, http.StatusAccepted)
+	notifier, err := alerting.NewNotifier(alerting.Opsgenie, "api-key", server.URL+"/v2/alerts")
+	require.NoError(t, err)
+
+	err = notifier.Notify(context.Background(), alerting.Event{
+		Action:   alerting.Trigger,
+		DedupKey: "prod/vpc/aws_instance.web:instance_type",
+		Summary:  "critical drift on prod/vpc/aws_instance.web: instance_type",
+		Severity: "critical",
+		Details:  map[string]any{"actual_value": 3},
+	})
+	require.NoError(t, err)
+	err = notifier.Notify(context.Background(), alerting.Event{Action: alerting.Resolve, DedupKey: "prod/vpc/aws_instance.web:instance_type"})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	create := (*requests)[0]
+	assert.Equal(t, "/v2/alerts", create.Path)
+	assert.Equal(t, "GenieKey api-key", create.Header.Get("Authorization"))
+	assert.Equal(t, "prod/vpc/aws_instance.web:instance_type", create.Body["alias"])
+	assert.Equal(t, "P1", create.Body["priority"])
+	assert.Equal(t, map[string]any{"actual_value": "3"}, create.Body["details"])
+
+	closeRequest := (*requests)[1]
+	assert.Equal(t, "/v2/alerts/prod/vpc/aws_instance.web:instance_type/close", closeRequest.Path)
+	assert.Equal(t, "identifierType=alias", closeRequest.Query)
+}
+
+func TestNotifier_Errors(t *testing.T) {
+	server, _ := newServer(t, http.StatusBadRequest)
+	notifier, err := alerting.NewNotifier(alerting.PagerDuty, "routing-key", server.URL)
+	require.NoError(t, err)
+	err = notifier.Notify(context.Background(), alerting.Event{Action: alerting.Trigger, DedupKey: "key"})
+	assert.ErrorContains(t, err, "400 Bad Request")
+
+	_, err = alerting.NewNotifier(alerting.PagerDuty, "", "")
+	assert.ErrorContains(t, err, "integration key is required")
+	_, err = alerting.NewNotifier("slack", "key", "")
+	assert.ErrorContains(t, err, "slack alerting not currently supported")
+}
+
+// fakeNotifier records the events it is sent.
+type fakeNotifier struct {
+	mu     sync.Mutex
+	events []alerting.Event
+	err    error
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, event alerting.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeNotifier) take() []alerting.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	events := f.events
+	f.events = nil
+	return events
+}
+
+func driftReport(violations ...driftchecker.PolicyViolation) *driftchecker.DriftReport {
+	return &driftchecker.DriftReport{
+		ResourceId:   "i-1",
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		HasDrift:     true,
+		Status:       driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "ami", TerraformValue: "ami-1", ActualValue: "ami-1", DriftType: driftchecker.Match},
+			{Field: "tags.Env", TerraformValue: "prod", ActualValue: "dev", DriftType: driftchecker.AttributeValueChanged},
+		},
+		Violations: violations,
+	}
+}
+
+func TestReporter_TriggersAndResolves(t *testing.T) {
+	notifier := &fakeNotifier{}
+	stateFile := filepath.Join(t.TempDir(), "alerts.json")
+	r, err := alerting.NewReporter(nil, notifier, "critical", stateFile)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	report := driftReport(
+		driftchecker.PolicyViolation{Severity: "critical", Message: "instance type changed", Attribute: "instance_type"},
+		driftchecker.PolicyViolation{Severity: "low", Message: "resource drifted"},
+	)
+	require.NoError(t, r.WriteReport(ctx, report))
+	events := notifier.take()
+	require.Len(t, events, 1)
+	assert.Equal(t, alerting.Trigger, events[0].Action)
+	assert.Equal(t, "aws_instance.web:instance_type", events[0].DedupKey)
+	assert.Equal(t, "critical", events[0].Severity)
+	assert.Equal(t, []string{"instance type changed", "resource drifted"}, events[0].Details["violations"])
+
+	// the same drift does not trigger the open alert again
+	require.NoError(t, r.WriteReport(ctx, report))
+	assert.Empty(t, notifier.take())
+	require.NoError(t, r.Flush(ctx))
+
+	// a new run resolves the alert opened by the previous one
+	r, err = alerting.NewReporter(nil, notifier, "critical", stateFile)
+	require.NoError(t, err)
+	resolved := driftReport()
+	resolved.HasDrift = false
+	resolved.Status = driftchecker.Match
+	resolved.DriftDetails = nil
+	require.NoError(t, r.WriteReport(ctx, resolved))
+	events = notifier.take()
+	require.Len(t, events, 1)
+	assert.Equal(t, alerting.Resolve, events[0].Action)
+	assert.Equal(t, "aws_instance.web:instance_type", events[0].DedupKey)
+}
+
+func TestReporter_MinSeverity(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r, err := alerting.NewReporter(nil, notifier, "medium", "")
+	require.NoError(t, err)
+
+	// a violation without an attribute applies to every drifted attribute
+	require.NoError(t, r.WriteReport(context.Background(), driftReport(
+		driftchecker.PolicyViolation{Severity: "high", Message: "production resource drifted"},
+	)))
+	events := notifier.take()
+	require.Len(t, events, 2)
+	assert.Equal(t, "aws_instance.web:instance_type", events[0].DedupKey)
+	assert.Equal(t, "aws_instance.web:tags.Env", events[1].DedupKey)
+
+	// drift below the minimum severity resolves the open alerts
+	require.NoError(t, r.WriteReport(context.Background(), driftReport(
+		driftchecker.PolicyViolation{Severity: "low", Message: "resource drifted"},
+	)))
+	events = notifier.take()
+	require.Len(t, events, 2)
+	assert.Equal(t, alerting.Resolve, events[0].Action)
+	assert.Equal(t, alerting.Resolve, events[1].Action)
+
+	_, err = alerting.NewReporter(nil, notifier, "urgent", "")
+	assert.ErrorContains(t, err, `unknown alert severity "urgent"`)
+}
+
+func TestReporter_Maintenance(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r, err := alerting.NewReporter(nil, notifier, "critical", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+	violation := driftchecker.PolicyViolation{Severity: "critical", Message: "changed", Attribute: "instance_type"}
+
+	expected := driftReport(violation)
+	expected.Maintenance = &driftchecker.MaintenanceWindow{Resource: "aws_instance.web", Reason: "resize"}
+	require.NoError(t, r.WriteReport(ctx, expected))
+	assert.Empty(t, notifier.take(), "drift expected during maintenance opens no alert")
+
+	require.NoError(t, r.WriteReport(ctx, driftReport(violation)))
+	require.Len(t, notifier.take(), 1)
+
+	// the alert opened outside the window stays open while the drift is expected
+	require.NoError(t, r.WriteReport(ctx, expected))
+	assert.Empty(t, notifier.take())
+}
+
+func TestReporter_CheckFailedKeepsAlertsOpen(t *testing.T) {
+	notifier := &fakeNotifier{}
+	r, err := alerting.NewReporter(nil, notifier, "critical", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, driftReport(
+		driftchecker.PolicyViolation{Severity: "critical", Message: "changed", Attribute: "instance_type"},
+	)))
+	require.Len(t, notifier.take(), 1)
+
+	// a failed check says nothing about the drift, the alert stays open
+	failed := driftReport()
+	failed.HasDrift = false
+	failed.Status = driftchecker.CheckFailed
+	failed.DriftDetails = nil
+	require.NoError(t, r.WriteReport(ctx, failed))
+	assert.Empty(t, notifier.take())
+
+	failed.Status = driftchecker.Skipped
+	require.NoError(t, r.WriteReport(ctx, failed))
+	assert.Empty(t, notifier.take())
+}
+
+func TestReporter_NotifyFailure(t *testing.T) {
+	notifier := &fakeNotifier{err: errors.New("service unavailable")}
+	r, err := alerting.NewReporter(nil, notifier, "critical", "")
+	require.NoError(t, err)
+
+	report := driftReport(driftchecker.PolicyViolation{Severity: "critical", Message: "changed", Attribute: "instance_type"})
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	// the alert is not recorded as open, so it is sent again once the service recovers
+	notifier.err = nil
+	require.NoError(t, r.WriteReport(context.Background(), report))
+	assert.Len(t, notifier.take(), 1)
+}
+
+func TestAddress(t *testing.T) {
+	report := &driftchecker.DriftReport{ResourceType: "aws_instance", ResourceId: "i-1", Stack: "prod/vpc"}
+	assert.Equal(t, "prod/vpc/aws_instance.i-1", alerting.Address(report))
+	assert.Equal(t, "prod/vpc/aws_instance.i-1:ami", alerting.DedupKey(alerting.Address(report), "ami"))
+}
@@ -0,0 +1,93 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OpsgenieEndpoint is the Opsgenie alert API of the US region. Accounts in the EU
+// region use https://api.eu.opsgenie.com/v2/alerts.
+const OpsgenieEndpoint = "https://api.opsgenie.com/v2/alerts"
+
+// opsgeniePriorities maps policy severities to Opsgenie alert priorities.
+var opsgeniePriorities = map[string]string{
+	"critical": "P1",
+	"high":     "P2",
+	"medium":   "P3",
+	"low":      "P4",
+}
+
+// OpsgenieNotifier creates and closes Opsgenie alerts. The dedup key is used as the
+// alert alias, so Opsgenie deduplicates open alerts of the same attribute.
+type OpsgenieNotifier struct {
+	APIKey   string
+	Endpoint string
+	Client   *http.Client
+}
+
+type opsgenieAlert struct {
+	Message     string         `json:"message"`
+	Alias       string         `json:"alias"`
+	Description string         `json:"description,omitempty"`
+	Priority    string         `json:"priority"`
+	Source      string         `json:"source"`
+	Details     map[string]any `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+func (o *OpsgenieNotifier) Notify(ctx context.Context, event Event) error {
+	headers := map[string]string{"Authorization": "GenieKey " + o.APIKey}
+	endpoint := strings.TrimSuffix(o.Endpoint, "/")
+
+	if event.Action == Resolve {
+		closeURL := endpoint + "/" + url.PathEscape(event.DedupKey) + "/close?identifierType=alias"
+		return postJSON(ctx, o.Client, closeURL, headers, opsgenieClose{Source: source, Note: "drift resolved"})
+	}
+
+	priority, ok := opsgeniePriorities[event.Severity]
+	if !ok {
+		priority = "P1"
+	}
+	details := map[string]any{}
+	for key, value := range event.Details {
+		// Opsgenie only accepts string details
+		details[key] = stringify(value)
+	}
+	return postJSON(ctx, o.Client, endpoint, headers, opsgenieAlert{
+		Message:     truncate(event.Summary, 130),
+		Alias:       event.DedupKey,
+		Description: event.Summary,
+		Priority:    priority,
+		Source:      source,
+		Details:     details,
+	})
+}
+
+// stringify formats a detail value as Opsgenie expects, encoding anything but a
+// string as JSON.
+func stringify(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// truncate shortens text to at most limit bytes, as Opsgenie rejects longer messages.
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit-3] + "..."
+}
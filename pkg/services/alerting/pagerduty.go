@@ -0,0 +1,94 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PagerDutyEndpoint is the PagerDuty Events API v2 endpoint.
+const PagerDutyEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutySeverities maps policy severities to PagerDuty event severities.
+var pagerDutySeverities = map[string]string{
+	"critical": "critical",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "info",
+}
+
+// PagerDutyNotifier sends events to a PagerDuty service through the Events API v2.
+type PagerDutyNotifier struct {
+	RoutingKey string
+	Endpoint   string
+	Client     *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction Action            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+func (p *PagerDutyNotifier) Notify(ctx context.Context, event Event) error {
+	body := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: event.Action,
+		DedupKey:    event.DedupKey,
+	}
+	if event.Action == Trigger {
+		severity, ok := pagerDutySeverities[event.Severity]
+		if !ok {
+			severity = "critical"
+		}
+		body.Payload = &pagerDutyPayload{
+			Summary:       event.Summary,
+			Source:        source,
+			Severity:      severity,
+			CustomDetails: event.Details,
+		}
+	}
+	return postJSON(ctx, p.Client, p.Endpoint, nil, body)
+}
+
+// postJSON posts body as JSON and fails on any non-2xx response.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to send alert: %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
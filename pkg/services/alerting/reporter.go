@@ -0,0 +1,284 @@
+package alerting
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/reporter"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reporter triggers an alert for every drifted attribute whose policy severity is
+// at least MinSeverity, and resolves the alert once the attribute no longer drifts
+// at that severity. The severity of an attribute is the highest severity of the
+// policy violations naming it; a violation that names no attribute applies to every
+// drifted attribute of the resource. Drift found during a maintenance window is
+// expected and triggers no alert. Reports are passed on to Next unchanged.
+//
+// Open alerts are kept in StateFile between runs, so that drift resolved by a later
+// scan resolves the alert opened by an earlier one.
+type Reporter struct {
+	Next        reporter.OutputWriter
+	Notifier    Notifier
+	MinSeverity string
+	StateFile   string
+
+	mu   sync.Mutex
+	open map[string]openAlert
+}
+
+// openAlert is an alert triggered and not yet resolved.
+type openAlert struct {
+	// Resource is the address of the resource the alert belongs to.
+	Resource    string    `json:"resource"`
+	Severity    string    `json:"severity"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// NewReporter creates a new Reporter instance.
+// next: The OutputWriter that receives every report.
+// notifier: The service alerts are sent to.
+// minSeverity: The lowest policy severity that triggers an alert.
+// stateFile: The file open alerts are kept in, or empty to keep them in memory only.
+func NewReporter(next reporter.OutputWriter, notifier Notifier, minSeverity string, stateFile string) (*Reporter, error) {
+	if policy.Rank(minSeverity) == 0 {
+		return nil, fmt.Errorf("unknown alert severity %q, expected one of: low, medium, high, critical", minSeverity)
+	}
+	r := &Reporter{
+		Next:        next,
+		Notifier:    notifier,
+		MinSeverity: minSeverity,
+		StateFile:   stateFile,
+		open:        map[string]openAlert{},
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DefaultStateFile returns the file open alerts are kept in by default, or an empty
+// string when no user cache directory is available.
+func DefaultStateFile() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "driftwatcher", "alerts.json")
+}
+
+// Begin begins the run of the next writer.
+func (r *Reporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	if r.Next == nil {
+		return nil
+	}
+	return reporter.BeginWriter(ctx, r.Next, run)
+}
+
+// WriteReport sends the alerts the report triggers or resolves and forwards it to the
+// next writer. Alerts that cannot be sent are logged and retried on the next scan.
+func (r *Reporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	resource := Address(report)
+	events := r.events(resource, report)
+	for _, event := range events {
+		if err := r.Notifier.Notify(ctx, event); err != nil {
+			logger(ctx).Warn("Failed to send drift alert", "action", event.Action, "dedup_key", event.DedupKey, "error", err)
+			continue
+		}
+		logger(ctx).Info("Sent drift alert", "action", event.Action, "dedup_key", event.DedupKey, "severity", event.Severity)
+
+		r.mu.Lock()
+		if event.Action == Trigger {
+			r.open[event.DedupKey] = openAlert{Resource: resource, Severity: event.Severity, TriggeredAt: time.Now().UTC()}
+		} else {
+			delete(r.open, event.DedupKey)
+		}
+		r.mu.Unlock()
+	}
+
+	if r.Next == nil {
+		return nil
+	}
+	return r.Next.WriteReport(ctx, report)
+}
+
+// Flush saves the open alerts and flushes the next writer.
+func (r *Reporter) Flush(ctx context.Context) error {
+	if err := r.save(); err != nil {
+		return err
+	}
+	if r.Next == nil {
+		return nil
+	}
+	return reporter.FlushWriter(ctx, r.Next)
+}
+
+// Close closes the next writer.
+func (r *Reporter) Close() error {
+	if r.Next == nil {
+		return nil
+	}
+	return reporter.CloseWriter(r.Next)
+}
+
+// events returns the alerts to trigger for the drifted attributes of report and the
+// open alerts of its resource to resolve. Only a report that compared the resource,
+// MATCH, DRIFT or DRIFT_RESOLVED, tells whether its drift is gone: a resource whose
+// check failed or was skipped keeps its open alerts.
+func (r *Reporter) events(resource string, report *driftchecker.DriftReport) []Event {
+	switch report.Status {
+	case driftchecker.Match, driftchecker.Drift, driftchecker.DriftResolved:
+	default:
+		return nil
+	}
+	alerting := map[string]bool{}
+	var events []Event
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if report.HasDrift && report.Status != driftchecker.DriftResolved {
+		for _, item := range report.DriftDetails {
+			if item.DriftType == driftchecker.Match {
+				continue
+			}
+			severity := itemSeverity(report.Violations, item.Field)
+			if policy.Rank(severity) < policy.Rank(r.MinSeverity) {
+				continue
+			}
+			key := DedupKey(resource, item.Field)
+			alerting[key] = true
+			// drift expected during maintenance neither opens an alert nor resolves
+			// the alert opened before the window
+			if _, open := r.open[key]; open || report.Expected() {
+				continue
+			}
+			events = append(events, Event{
+				Action:   Trigger,
+				DedupKey: key,
+				Summary:  fmt.Sprintf("%s drift on %s: %s", severity, resource, item.Field),
+				Severity: severity,
+				Details: map[string]any{
+					"resource_id":     report.ResourceId,
+					"attribute":       item.Field,
+					"drift_type":      item.DriftType,
+					"terraform_value": item.TerraformValue,
+					"actual_value":    item.ActualValue,
+					"violations":      violationMessages(report.Violations, item.Field),
+				},
+			})
+		}
+	}
+
+	var resolved []string
+	for key, alert := range r.open {
+		if alert.Resource == resource && !alerting[key] {
+			resolved = append(resolved, key)
+		}
+	}
+	sort.Strings(resolved)
+	for _, key := range resolved {
+		events = append(events, Event{
+			Action:   Resolve,
+			DedupKey: key,
+			Summary:  fmt.Sprintf("drift resolved on %s", resource),
+			Severity: r.open[key].Severity,
+		})
+	}
+	return events
+}
+
+// itemSeverity returns the highest severity of the violations that apply to the
+// attribute, or an empty string when none does.
+func itemSeverity(violations []driftchecker.PolicyViolation, attribute string) string {
+	severity := ""
+	for _, violation := range violations {
+		if violation.Attribute != "" && violation.Attribute != attribute {
+			continue
+		}
+		if policy.Rank(violation.Severity) > policy.Rank(severity) {
+			severity = violation.Severity
+		}
+	}
+	return severity
+}
+
+func violationMessages(violations []driftchecker.PolicyViolation, attribute string) []string {
+	var messages []string
+	for _, violation := range violations {
+		if violation.Attribute == "" || violation.Attribute == attribute {
+			messages = append(messages, violation.Message)
+		}
+	}
+	return messages
+}
+
+// Address returns the address of the resource of a report, its full state address or
+// type.name, prefixed with the stack of Terragrunt resources. The resource id is used
+// for resources without a name.
+func Address(report *driftchecker.DriftReport) string {
+	address := report.ResourceType
+	switch {
+	case report.ResourceAddress != "":
+		address = report.ResourceAddress
+	case report.ResourceName != "":
+		address += "." + report.ResourceName
+	case report.ResourceId != "":
+		address += "." + report.ResourceId
+	}
+	if report.Stack != "" {
+		address = report.Stack + "/" + address
+	}
+	return address
+}
+
+// DedupKey returns the key deduplicating the alerts of an attribute of a resource.
+func DedupKey(address string, attribute string) string {
+	return address + ":" + attribute
+}
+
+// load reads the open alerts of previous runs.
+func (r *Reporter) load() error {
+	if r.StateFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(r.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read alert state: %w", err)
+	}
+	if err := json.Unmarshal(content, &r.open); err != nil {
+		return fmt.Errorf("failed to read alert state %s: %w", r.StateFile, err)
+	}
+	return nil
+}
+
+// save writes the open alerts for the next run.
+func (r *Reporter) save() error {
+	if r.StateFile == "" {
+		return nil
+	}
+	r.mu.Lock()
+	content, err := json.MarshalIndent(r.open, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.StateFile), 0o755); err != nil {
+		return fmt.Errorf("failed to write alert state: %w", err)
+	}
+	if err := os.WriteFile(r.StateFile, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write alert state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+// Package attrpath parses the attribute path expressions checked for drift, such as
+// root_block_device[0].volume_size, metadata_options.http_tokens or
+// tags["kubernetes.io/role"], and resolves them against the nested attributes of the
+// state and the attributes read from live resources.
+package attrpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Segment is a step of a Path: a key into an object, or an index into a list when
+// IsIndex is set.
+type Segment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// Path is a parsed attribute path expression.
+type Path []Segment
+
+// Parse parses an attribute path expression. Keys are separated by dots; a key
+// holding dots or brackets is written as a quoted string in brackets, with double or
+// single quotes, and a list element as its index in brackets:
+//
+//	tags.Name
+//	root_block_device[0].volume_size
+//	tags["kubernetes.io/cluster/prod"]
+//
+// A numeric key such as the 0 of root_block_device.0.volume_size indexes into a list
+// as well, as in flat Terraform state attributes.
+func Parse(expr string) (Path, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("empty attribute path")
+	}
+	var p Path
+	for i := 0; i < len(expr); {
+		switch {
+		case expr[i] == '[':
+			segment, next, err := parseBracket(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			if len(p) == 0 && segment.IsIndex {
+				return nil, fmt.Errorf("invalid attribute path %q: it must start with an attribute name", expr)
+			}
+			p, i = append(p, segment), next
+		case i > 0 && expr[i] == '.' || i == 0:
+			if i > 0 {
+				i++
+			}
+			end := i
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' && expr[end] != ']' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("invalid attribute path %q: empty key at offset %d", expr, i)
+			}
+			p, i = append(p, Segment{Key: expr[i:end]}), end
+		default:
+			return nil, fmt.Errorf("invalid attribute path %q: unexpected %q at offset %d", expr, expr[i], i)
+		}
+		if i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+			return nil, fmt.Errorf("invalid attribute path %q: unexpected %q at offset %d", expr, expr[i], i)
+		}
+		if i == len(expr)-1 && expr[i] == '.' {
+			return nil, fmt.Errorf("invalid attribute path %q: empty key at offset %d", expr, len(expr))
+		}
+	}
+	return p, nil
+}
+
+// parseBracket parses the bracketed segment starting at expr[start] and returns it
+// with the offset following the closing bracket.
+func parseBracket(expr string, start int) (Segment, int, error) {
+	i := start + 1
+	if i < len(expr) && (expr[i] == '"' || expr[i] == '\'') {
+		quote := expr[i]
+		var key strings.Builder
+		for i++; i < len(expr) && expr[i] != quote; i++ {
+			if expr[i] == '\\' && i+1 < len(expr) {
+				i++
+			}
+			key.WriteByte(expr[i])
+		}
+		if i+1 >= len(expr) || expr[i+1] != ']' {
+			return Segment{}, 0, fmt.Errorf("invalid attribute path %q: unterminated key at offset %d", expr, start)
+		}
+		return Segment{Key: key.String()}, i + 2, nil
+	}
+
+	end := strings.IndexByte(expr[i:], ']')
+	if end < 0 {
+		return Segment{}, 0, fmt.Errorf("invalid attribute path %q: unterminated index at offset %d", expr, start)
+	}
+	index, err := strconv.Atoi(expr[i : i+end])
+	if err != nil || index < 0 || strings.HasPrefix(expr[i:i+end], "+") {
+		return Segment{}, 0, fmt.Errorf("invalid attribute path %q: invalid index %q", expr, expr[i:i+end])
+	}
+	return Segment{Index: index, IsIndex: true}, i + end + 1, nil
+}
+
+// Canonical returns the canonical form of an attribute path expression, so that
+// tags["Name"] and tags.Name are reported, ignored and remediated as the same
+// attribute.
+func Canonical(expr string) (string, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+// String returns the canonical expression of p: keys are joined with dots unless
+// they hold dots, brackets or quotes, in which case they are quoted in brackets, and
+// indexes are written in brackets.
+func (p Path) String() string {
+	var b strings.Builder
+	for i, segment := range p {
+		switch {
+		case segment.IsIndex:
+			fmt.Fprintf(&b, "[%d]", segment.Index)
+		case strings.ContainsAny(segment.Key, `.[]"'\`):
+			b.WriteString("[" + strconv.Quote(segment.Key) + "]")
+		default:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment.Key)
+		}
+	}
+	return b.String()
+}
+
+// Dotted returns the keys of p joined with dots, the flat attribute name providers
+// read, e.g. tags.kubernetes.io/role for tags["kubernetes.io/role"]. ok is false
+// when p holds an index.
+func (p Path) Dotted() (name string, ok bool) {
+	keys := make([]string, 0, len(p))
+	for _, segment := range p {
+		if segment.IsIndex {
+			return "", false
+		}
+		keys = append(keys, segment.Key)
+	}
+	return strings.Join(keys, "."), true
+}
+
+// Lookup follows p through nested maps and lists. An index, or a numeric key,
+// selects an element of a list, and index 0 selects an object itself, as a nested
+// block is stored as a single-element list by Terraform but as an object by most
+// APIs. Any other key applied to a list is applied to each of its elements, so a
+// nested block is stepped into transparently and a repeated block yields one value
+// per element. A key missing from an object matches the key of the object whose
+// snake_case form it is, e.g. http_tokens matches HttpTokens.
+func Lookup(value any, p Path) (any, bool) {
+	found, err := lookup(value, p)
+	return found, err == nil
+}
+
+// errMissing is returned by lookup for a path leading nowhere.
+type errMissing struct {
+	path Path
+	// unknownKey is set when the path names a key an object does not have, rather
+	// than a list element out of range or a value that is not an object or list.
+	unknownKey bool
+}
+
+func (e *errMissing) Error() string {
+	return fmt.Sprintf("%s not found", e.path)
+}
+
+// lookup implements Lookup, reporting a missing value as an *errMissing.
+func lookup(value any, p Path) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	segment, rest := p[0], p[1:]
+	missing := &errMissing{path: p}
+
+	if segment.IsIndex {
+		switch v := value.(type) {
+		case []any:
+			if segment.Index >= len(v) {
+				return nil, missing
+			}
+			return lookup(v[segment.Index], rest)
+		case map[string]any:
+			if segment.Index != 0 {
+				return nil, missing
+			}
+			return lookup(v, rest)
+		}
+		return nil, missing
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		child, ok := v[segment.Key]
+		if !ok {
+			for key, c := range v {
+				if snakeCase(key) == segment.Key {
+					child, ok = c, true
+					break
+				}
+			}
+		}
+		if !ok {
+			missing.unknownKey = true
+			return nil, missing
+		}
+		return lookup(child, rest)
+	case []any:
+		if index, err := strconv.Atoi(segment.Key); err == nil && index >= 0 {
+			return lookup(value, append(Path{{Index: index, IsIndex: true}}, rest...))
+		}
+		values := make([]any, 0, len(v))
+		var err error
+		for _, item := range v {
+			found, itemErr := lookup(item, p)
+			if itemErr != nil {
+				err = itemErr
+				continue
+			}
+			values = append(values, found)
+		}
+		switch len(values) {
+		case 0:
+			if err == nil {
+				err = missing
+			}
+			return nil, err
+		case 1:
+			return values[0], nil
+		default:
+			return values, nil
+		}
+	}
+	return nil, missing
+}
+
+// snakeCase returns the snake_case form of a CamelCase key, e.g. http_put_response_hop_limit
+// for HttpPutResponseHopLimit.
+func snakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if i > 0 {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || unicode.IsUpper(previous) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Format renders a value found with Lookup as a string, the way attributes are
+// compared: numbers and booleans in their shortest form, so that 2 and 2.0 render
+// the same, lists of scalars as a comma-separated string, and lists of objects and
+// objects as JSON. A null value renders as an empty string.
+func Format(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case string, float64, int, bool:
+				rendered, _ := Format(item)
+				items = append(items, rendered)
+				continue
+			}
+			return marshal(v)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return marshal(v)
+	}
+}
+
+func marshal(value any) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("attribute value cannot be parsed to string: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// Resolve returns the value of the attribute path expr of a live resource whose
+// attributes are read by flat name with read. The longest leading keys of expr that
+// read succeeds for name the attribute holding the value, e.g. metadata_options for
+// metadata_options.http_tokens; the rest of the path is looked up in its value,
+// decoded as JSON or, failing that, as a comma-separated list. An attribute that is
+// not set, or a list element out of range, resolves to an empty string. A key the
+// value does not hold is reported as an error, as it means the provider does not read
+// it, rather than as a missing value, which would be reported as drift.
+func Resolve(expr string, read func(attribute string) (string, error)) (string, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return "", err
+	}
+	var readErr error
+	for n := len(p); n > 0; n-- {
+		name, ok := p[:n].Dotted()
+		if !ok {
+			continue
+		}
+		value, err := read(name)
+		if err != nil {
+			if readErr == nil {
+				readErr = err
+			}
+			continue
+		}
+		if n == len(p) || value == "" {
+			return value, nil
+		}
+
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			var items []any
+			for _, item := range strings.Split(value, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+			decoded = items
+		}
+		found, err := lookup(decoded, p[n:])
+		if missing, ok := err.(*errMissing); ok {
+			if missing.unknownKey {
+				return "", fmt.Errorf("'%s' is not read from the live %s attribute", p[n:], name)
+			}
+			return "", nil
+		}
+		return Format(found)
+	}
+	return "", readErr
+}
@@ -0,0 +1,147 @@
+package attrpath_test
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	p, err := attrpath.Parse(`root_block_device[0].volume_size`)
+	require.NoError(t, err)
+	assert.Equal(t, attrpath.Path{{Key: "root_block_device"}, {Index: 0, IsIndex: true}, {Key: "volume_size"}}, p)
+
+	p, err = attrpath.Parse(`tags["kubernetes.io/role"]`)
+	require.NoError(t, err)
+	assert.Equal(t, attrpath.Path{{Key: "tags"}, {Key: "kubernetes.io/role"}}, p)
+
+	p, err = attrpath.Parse(`tags['a"b']`)
+	require.NoError(t, err)
+	assert.Equal(t, attrpath.Path{{Key: "tags"}, {Key: `a"b`}}, p)
+
+	for _, expr := range []string{"", "[0]", "tags.", "a..b", "a[", "a[x]", "a[-1]", `a["b`, "a]b", "a[0]b"} {
+		_, err := attrpath.Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		`tags["Name"]`:                   "tags.Name",
+		`tags['kubernetes.io/role']`:     `tags["kubernetes.io/role"]`,
+		"root_block_device[0].iops":      "root_block_device[0].iops",
+		"metadata_options.http_tokens":   "metadata_options.http_tokens",
+		`metadata["labels"]["app"]`:      "metadata.labels.app",
+		"spec.template.spec.container.0": "spec.template.spec.container.0",
+	}
+	for expr, want := range tests {
+		got, err := attrpath.Canonical(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, got, expr)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	state := map[string]any{
+		"root_block_device": []any{map[string]any{"volume_size": float64(8)}},
+		"ebs_block_device": []any{
+			map[string]any{"device_name": "/dev/sdb"},
+			map[string]any{"device_name": "/dev/sdc"},
+		},
+		"metadata_options": map[string]any{"HttpTokens": "required"},
+		"tags":             map[string]any{"kubernetes.io/role": "node"},
+	}
+
+	tests := []struct {
+		expr  string
+		want  any
+		found bool
+	}{
+		{"root_block_device[0].volume_size", float64(8), true},
+		{"root_block_device.volume_size", float64(8), true},
+		{"root_block_device.0.volume_size", float64(8), true},
+		{"ebs_block_device[1].device_name", "/dev/sdc", true},
+		{"ebs_block_device.device_name", []any{"/dev/sdb", "/dev/sdc"}, true},
+		{"ebs_block_device[2].device_name", nil, false},
+		{"metadata_options.http_tokens", "required", true},
+		{"metadata_options[0].http_tokens", "required", true},
+		{`tags["kubernetes.io/role"]`, "node", true},
+		{"tags.missing", nil, false},
+	}
+	for _, tt := range tests {
+		p, err := attrpath.Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		got, found := attrpath.Lookup(state, p)
+		assert.Equal(t, tt.found, found, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, ""},
+		{"t3.micro", "t3.micro"},
+		{float64(2), "2"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{[]any{"sg-1", "sg-2"}, "sg-1,sg-2"},
+		{[]any{map[string]any{"port": float64(22)}}, `[{"port":22}]`},
+		{map[string]any{"a": "b"}, `{"a":"b"}`},
+	}
+	for _, tt := range tests {
+		got, err := attrpath.Format(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	live := map[string]string{
+		"instance_type":           "t3.micro",
+		"metadata_options":        `{"http_tokens":"required","http_put_response_hop_limit":1}`,
+		"root_block_device":       `[{"volume_size":8}]`,
+		"security_group_ids":      "sg-1, sg-2",
+		"tags.kubernetes.io/role": "node",
+		"placement_group":         "",
+	}
+	read := func(attribute string) (string, error) {
+		if value, ok := live[attribute]; ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("unsupported attribute %s", attribute)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"instance_type", "t3.micro"},
+		{"metadata_options.http_tokens", "required"},
+		{"metadata_options.http_put_response_hop_limit", "1"},
+		{"root_block_device[0].volume_size", "8"},
+		{"root_block_device[1].volume_size", ""},
+		{"security_group_ids[1]", "sg-2"},
+		{`tags["kubernetes.io/role"]`, "node"},
+		{"placement_group.name", ""},
+	}
+	for _, tt := range tests {
+		got, err := attrpath.Resolve(tt.expr, read)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+
+	_, err := attrpath.Resolve("metadata_options.instance_metadata_tags", read)
+	assert.ErrorContains(t, err, "is not read from the live metadata_options attribute")
+
+	_, err = attrpath.Resolve("ami", read)
+	assert.ErrorContains(t, err, "unsupported attribute ami")
+
+	_, err = attrpath.Resolve("tags[", read)
+	assert.Error(t, err)
+}
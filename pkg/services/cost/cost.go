@@ -0,0 +1,170 @@
+// Package cost estimates the change of the monthly cost of a resource caused by the
+// drift of attributes with cost implications, such as an instance resized by hand or
+// a volume grown outside of Terraform, so that remediation can be prioritized by what
+// the drift costs.
+//
+// Estimates are computed from a bundled table of on-demand prices. They ignore
+// reservations, savings plans and operating system licensing, and are only meant to
+// rank drift, not to forecast a bill.
+package cost
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HoursPerMonth is the number of hours an hourly price is multiplied by for a month.
+const HoursPerMonth = 730
+
+// Currency is the currency of the prices and of the estimates.
+const Currency = "USD"
+
+// storage pairs the size and type attributes of a storage resource or block.
+type storage struct {
+	size string
+	kind string
+	// db selects the RDS storage prices rather than the EBS ones.
+	db bool
+	// resourceType limits the pair to a resource type, for attribute names as generic
+	// as size.
+	resourceType string
+}
+
+// storageAttributes are the storage attributes estimates are computed for. The size
+// attributes are in GB.
+var storageAttributes = []storage{
+	{size: "volume_size", kind: "volume_type"},
+	{size: "allocated_storage", kind: "storage_type", db: true},
+	{size: "size", kind: "type", resourceType: "aws_ebs_volume"},
+}
+
+// Estimator annotates drift items with the change of the monthly cost they cause.
+// A nil Estimator annotates nothing.
+type Estimator struct {
+	prices PriceTable
+}
+
+// NewEstimator creates a new Estimator instance.
+// prices: The prices estimates are computed from, such as DefaultPrices().
+func NewEstimator(prices PriceTable) *Estimator {
+	return &Estimator{prices: prices}
+}
+
+// Annotate sets the cost of the drifted attributes of report with cost implications:
+// instance_type and instance_class, and the size and type of volumes and database
+// storage. The size of storage whose type drifted, and the type of storage whose size
+// drifted, are read from resource. Attributes whose values are not in the price table
+// are left without an estimate.
+func (e *Estimator) Annotate(resource statemanager.StateResource, report *driftchecker.DriftReport) {
+	if e == nil || report == nil {
+		return
+	}
+	for i := range report.DriftDetails {
+		item := &report.DriftDetails[i]
+		if item.DriftType != driftchecker.AttributeValueChanged {
+			continue
+		}
+		if delta := e.estimate(resource, item); delta != nil {
+			item.Cost = delta
+		}
+	}
+}
+
+// estimate returns the cost delta of the drift of item, or nil when it has no cost
+// implication or cannot be priced.
+func (e *Estimator) estimate(resource statemanager.StateResource, item *driftchecker.DriftItem) *driftchecker.CostDelta {
+	p, err := attrpath.Parse(item.Field)
+	if err != nil {
+		return nil
+	}
+	for len(p) > 0 && p[len(p)-1].IsIndex {
+		p = p[:len(p)-1]
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	key, parent := p[len(p)-1].Key, p[:len(p)-1]
+	desired, actual := value(item.TerraformValue), value(item.ActualValue)
+
+	switch key {
+	case "instance_type", "instance_class":
+		before, ok := e.prices.Instances[strings.ToLower(desired)]
+		if !ok {
+			return nil
+		}
+		after, ok := e.prices.Instances[strings.ToLower(actual)]
+		if !ok {
+			return nil
+		}
+		return e.delta((after-before)*HoursPerMonth,
+			fmt.Sprintf("%s $%.4g/h -> %s $%.4g/h", desired, before, actual, after))
+	}
+
+	for _, s := range storageAttributes {
+		if s.resourceType != "" && s.resourceType != resource.Type {
+			continue
+		}
+		prices := e.prices.Volumes
+		if s.db {
+			prices = e.prices.DBStorage
+		}
+		switch key {
+		case s.size:
+			kind := sibling(resource, parent, s.kind)
+			price, ok := prices[strings.ToLower(kind)]
+			before, err1 := strconv.ParseFloat(desired, 64)
+			after, err2 := strconv.ParseFloat(actual, 64)
+			if !ok || err1 != nil || err2 != nil {
+				return nil
+			}
+			return e.delta((after-before)*price,
+				fmt.Sprintf("%s GB -> %s GB of %s at $%.4g/GB-month", desired, actual, kind, price))
+		case s.kind:
+			size, err := strconv.ParseFloat(sibling(resource, parent, s.size), 64)
+			before, ok1 := prices[strings.ToLower(desired)]
+			after, ok2 := prices[strings.ToLower(actual)]
+			if err != nil || !ok1 || !ok2 {
+				return nil
+			}
+			return e.delta(size*(after-before),
+				fmt.Sprintf("%g GB of %s $%.4g/GB-month -> %s $%.4g/GB-month", size, desired, before, actual, after))
+		}
+	}
+	return nil
+}
+
+// delta returns the cost delta of monthly, rounded to cents.
+func (e *Estimator) delta(monthly float64, basis string) *driftchecker.CostDelta {
+	return &driftchecker.CostDelta{
+		Monthly:  math.Round(monthly*100) / 100,
+		Currency: Currency,
+		Basis:    basis + ", " + e.prices.Region + " on-demand",
+	}
+}
+
+// sibling returns the state value of the attribute name next to the drifted one, in
+// the same nested block.
+func sibling(resource statemanager.StateResource, parent attrpath.Path, name string) string {
+	p := append(attrpath.Path{}, parent...)
+	p = append(p, attrpath.Segment{Key: name})
+	v, err := resource.AttributeValue(p.String())
+	if err != nil {
+		return ""
+	}
+	return v
+}
+
+// value renders a drifted value as a string. A list of one element, as read from a
+// nested block, renders as the element.
+func value(v any) string {
+	formatted, err := attrpath.Format(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(formatted)
+}
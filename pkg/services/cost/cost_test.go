@@ -0,0 +1,82 @@
+package cost_test
+
+import (
+	"drift-watcher/pkg/services/cost"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changed(field string, desired, actual any) driftchecker.DriftItem {
+	return driftchecker.DriftItem{Field: field, TerraformValue: desired, ActualValue: actual, DriftType: driftchecker.AttributeValueChanged}
+}
+
+func resource(resourceType string, attributes map[string]any) statemanager.StateResource {
+	return statemanager.StateResource{Type: resourceType, Name: "main", Instances: []statemanager.ResourceInstance{{Attributes: attributes}}}
+}
+
+func TestEstimator_Annotate_Instance(t *testing.T) {
+	report := &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{
+		changed("instance_type", "m5.large", "m5.2xlarge"),
+		changed("instance_type", "t3.large", "x9.huge"),
+		changed("ami", "ami-1", "ami-2"),
+		{Field: "instance_type", TerraformValue: "t3.micro", DriftType: driftchecker.AttributeMissingInInfrastructure},
+	}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(resource("aws_instance", nil), report)
+
+	assert.Equal(t, &driftchecker.CostDelta{
+		Monthly:  210.24,
+		Currency: "USD",
+		Basis:    "m5.large $0.096/h -> m5.2xlarge $0.384/h, us-east-1 on-demand",
+	}, report.DriftDetails[0].Cost)
+	assert.Nil(t, report.DriftDetails[1].Cost, "unknown instance types are not priced")
+	assert.Nil(t, report.DriftDetails[2].Cost, "the ami has no cost implication")
+	assert.Nil(t, report.DriftDetails[3].Cost, "only changed values are priced")
+
+	db := &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{changed("instance_class", "db.r6g.xlarge", "db.r6g.large")}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(resource("aws_db_instance", nil), db)
+	require.NotNil(t, db.DriftDetails[0].Cost)
+	assert.Equal(t, -156.95, db.DriftDetails[0].Cost.Monthly, "a smaller class saves money")
+}
+
+func TestEstimator_Annotate_Storage(t *testing.T) {
+	instance := resource("aws_instance", map[string]any{
+		"root_block_device": []any{map[string]any{"volume_size": float64(50), "volume_type": "gp3"}},
+	})
+	report := &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{
+		changed("root_block_device[0].volume_size", float64(50), float64(200)),
+		changed("root_block_device.volume_type", "gp3", "io1"),
+	}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(instance, report)
+	require.NotNil(t, report.DriftDetails[0].Cost)
+	assert.Equal(t, 12.0, report.DriftDetails[0].Cost.Monthly)
+	assert.Equal(t, "50 GB -> 200 GB of gp3 at $0.08/GB-month, us-east-1 on-demand", report.DriftDetails[0].Cost.Basis)
+	require.NotNil(t, report.DriftDetails[1].Cost)
+	assert.Equal(t, 2.25, report.DriftDetails[1].Cost.Monthly)
+
+	database := resource("aws_db_instance", map[string]any{"allocated_storage": float64(100), "storage_type": "gp2"})
+	report = &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{changed("allocated_storage", "100", "500")}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(database, report)
+	require.NotNil(t, report.DriftDetails[0].Cost)
+	assert.Equal(t, 46.0, report.DriftDetails[0].Cost.Monthly)
+
+	// size is only a storage attribute of volumes
+	report = &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{changed("size", "1", "2")}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(resource("aws_autoscaling_group", map[string]any{"type": "gp2"}), report)
+	assert.Nil(t, report.DriftDetails[0].Cost)
+
+	// without the type of the volume in the state, the size is not priced
+	report = &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{changed("size", "1", "2")}}
+	cost.NewEstimator(cost.DefaultPrices()).Annotate(resource("aws_ebs_volume", map[string]any{}), report)
+	assert.Nil(t, report.DriftDetails[0].Cost)
+}
+
+func TestEstimator_Nil(t *testing.T) {
+	report := &driftchecker.DriftReport{DriftDetails: []driftchecker.DriftItem{changed("instance_type", "t3.micro", "t3.large")}}
+	var estimator *cost.Estimator
+	estimator.Annotate(resource("aws_instance", nil), report)
+	assert.Nil(t, report.DriftDetails[0].Cost)
+}
@@ -0,0 +1,109 @@
+package cost
+
+// PriceTable holds the on-demand prices estimates are computed from, in USD.
+type PriceTable struct {
+	// Region is the region the prices are those of.
+	Region string
+	// Instances are hourly prices of EC2 instance types and RDS instance classes.
+	Instances map[string]float64
+	// Volumes are GB-month prices of EBS volume types.
+	Volumes map[string]float64
+	// DBStorage are GB-month prices of RDS storage types.
+	DBStorage map[string]float64
+}
+
+// sizes are the sizes of an instance family relative to its large size. AWS prices
+// the sizes of a family linearly.
+var sizes = map[string]float64{
+	"large":    1,
+	"xlarge":   2,
+	"2xlarge":  4,
+	"4xlarge":  8,
+	"8xlarge":  16,
+	"12xlarge": 24,
+	"16xlarge": 32,
+	"24xlarge": 48,
+}
+
+// burstableSizes are the sizes of a burstable family relative to its micro size.
+var burstableSizes = map[string]float64{
+	"nano":    0.5,
+	"micro":   1,
+	"small":   2,
+	"medium":  4,
+	"large":   8,
+	"xlarge":  16,
+	"2xlarge": 32,
+}
+
+// DefaultPrices returns the bundled us-east-1 on-demand prices of Linux instances,
+// Single-AZ database instances and storage. They are approximate and only meant to
+// rank drift by its cost.
+func DefaultPrices() PriceTable {
+	instances := map[string]float64{}
+	family := func(name string, large float64, sizes map[string]float64) {
+		for size, factor := range sizes {
+			instances[name+"."+size] = large * factor
+		}
+	}
+
+	for name, micro := range map[string]float64{
+		"t2":     0.0116,
+		"t3":     0.0104,
+		"t3a":    0.0094,
+		"t4g":    0.0084,
+		"db.t3":  0.017,
+		"db.t4g": 0.016,
+	} {
+		family(name, micro, burstableSizes)
+	}
+	for name, large := range map[string]float64{
+		"m5":     0.096,
+		"m5a":    0.086,
+		"m6i":    0.096,
+		"m6a":    0.0864,
+		"m6g":    0.077,
+		"m7i":    0.1008,
+		"m7g":    0.0816,
+		"c5":     0.085,
+		"c5a":    0.077,
+		"c6i":    0.085,
+		"c6g":    0.068,
+		"c7i":    0.08925,
+		"c7g":    0.0725,
+		"r5":     0.126,
+		"r5a":    0.113,
+		"r6i":    0.126,
+		"r6g":    0.1008,
+		"r7g":    0.1071,
+		"db.m5":  0.171,
+		"db.m6i": 0.171,
+		"db.m6g": 0.152,
+		"db.r5":  0.24,
+		"db.r6i": 0.24,
+		"db.r6g": 0.215,
+	} {
+		family(name, large, sizes)
+	}
+
+	return PriceTable{
+		Region:    "us-east-1",
+		Instances: instances,
+		Volumes: map[string]float64{
+			"standard": 0.05,
+			"gp2":      0.10,
+			"gp3":      0.08,
+			"io1":      0.125,
+			"io2":      0.125,
+			"st1":      0.045,
+			"sc1":      0.015,
+		},
+		DBStorage: map[string]float64{
+			"standard": 0.10,
+			"gp2":      0.115,
+			"gp3":      0.115,
+			"io1":      0.125,
+			"io2":      0.125,
+		},
+	}
+}
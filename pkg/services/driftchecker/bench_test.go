@@ -0,0 +1,116 @@
+package driftchecker_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"testing"
+)
+
+// benchmarkResources is the number of resources compared per benchmark iteration,
+// the size of a large production workspace.
+const benchmarkResources = 5000
+
+// benchmarkAttributes are the attributes tracked by the benchmarks, covering scalar,
+// numeric, list, nested block and tag comparisons.
+var benchmarkAttributes = []string{
+	"instance_type",
+	"ami",
+	"subnet_id",
+	"vpc_security_group_ids",
+	"root_block_device.volume_size",
+	"root_block_device.volume_type",
+	"tags.Name",
+	"tags.Environment",
+}
+
+// liveResource is a live resource backed by a map. The counterfeiter fakes record
+// every call, which would dominate the measurements.
+type liveResource map[string]string
+
+func (l liveResource) ResourceType() string { return "aws_instance" }
+
+func (l liveResource) AttributeValue(attribute string) (string, error) {
+	return l[attribute], nil
+}
+
+func (l liveResource) Attributes() (map[string]string, error) {
+	return l, nil
+}
+
+// benchmarkPairs returns n desired resources with their live counterparts. One
+// resource in ten has drifted, in its instance type, tags or security groups.
+func benchmarkPairs(n int) ([]statemanager.StateResource, []liveResource) {
+	desired := make([]statemanager.StateResource, n)
+	live := make([]liveResource, n)
+	for i := range n {
+		securityGroups := []any{fmt.Sprintf("sg-%08x", i%32), "sg-0000000a"}
+		desired[i] = statemanager.StateResource{
+			Mode: "managed",
+			Type: "aws_instance",
+			Name: fmt.Sprintf("web_%d", i),
+			Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+				"id":                     fmt.Sprintf("i-%017x", i),
+				"ami":                    "ami-0c55b159cbfafe1f0",
+				"instance_type":          "t3.micro",
+				"subnet_id":              fmt.Sprintf("subnet-%08x", i%64),
+				"vpc_security_group_ids": securityGroups,
+				"monitoring":             false,
+				"root_block_device":      []any{map[string]any{"volume_size": float64(20), "volume_type": "gp3"}},
+				"tags":                   map[string]any{"Name": fmt.Sprintf("web-%d", i), "Environment": "production"},
+			}}},
+		}
+		live[i] = liveResource{
+			"id":                            fmt.Sprintf("i-%017x", i),
+			"ami":                           "ami-0c55b159cbfafe1f0",
+			"instance_type":                 "t3.micro",
+			"subnet_id":                     fmt.Sprintf("subnet-%08x", i%64),
+			"vpc_security_group_ids":        fmt.Sprintf("sg-0000000a,sg-%08x", i%32),
+			"monitoring":                    "false",
+			"root_block_device.volume_size": "20",
+			"root_block_device.volume_type": "gp3",
+			"tags.Name":                     fmt.Sprintf("web-%d", i),
+			"tags.Environment":              "production",
+		}
+		switch i % 30 {
+		case 0:
+			live[i]["instance_type"] = "t3.large"
+		case 10:
+			live[i]["tags.Environment"] = "PRODUCTION"
+		case 20:
+			live[i]["vpc_security_group_ids"] = "sg-0000000a"
+		}
+	}
+	return desired, live
+}
+
+func BenchmarkCompareStates(b *testing.B) {
+	ctx := context.Background()
+	checker := driftchecker.NewDefaultDriftChecker()
+	desired, live := benchmarkPairs(benchmarkResources)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range desired {
+			if _, err := checker.CompareStates(ctx, live[i], desired[i], benchmarkAttributes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCompareStates_AllAttributes(b *testing.B) {
+	ctx := context.Background()
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithAllAttributes())
+	desired, live := benchmarkPairs(benchmarkResources)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range desired {
+			if _, err := checker.CompareStates(ctx, live[i], desired[i], benchmarkAttributes[:1]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package driftchecker
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// DefaultUnorderedAttributes lists the set-typed attributes whose element order carries
+// no meaning. The state and the live APIs return their elements in different orders,
+// so they are compared as sets rather than as strings.
+var DefaultUnorderedAttributes = []string{
+	"security_group_ids",
+	"vpc_security_group_ids",
+	"security_groups",
+	"ebs_block_device",
+	"secondary_private_ips",
+	"network_interface",
+	"ipv6_addresses",
+	"ingress",
+	"egress",
+	"subnet_ids",
+	"enabled_cluster_log_types",
+}
+
+// canonicalSet returns an order independent form of a set-typed value. JSON arrays,
+// such as security group rules, have every nested array sorted; any other value is
+// treated as a comma-separated list.
+func canonicalSet(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") {
+		var decoded any
+		if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+			encoded, err := json.Marshal(sortArrays(decoded))
+			if err == nil {
+				return string(encoded)
+			}
+		}
+	}
+
+	var items []string
+	for _, item := range strings.Split(trimmed, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// sortArrays sorts every array within v by the JSON encoding of its elements. Object
+// keys need no handling as encoding/json writes them in sorted order.
+func sortArrays(v any) any {
+	switch value := v.(type) {
+	case []any:
+		type keyed struct {
+			key  string
+			item any
+		}
+		items := make([]keyed, 0, len(value))
+		for _, item := range value {
+			item = sortArrays(item)
+			encoded, _ := json.Marshal(item)
+			items = append(items, keyed{key: string(encoded), item: item})
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].key < items[j].key })
+		sorted := make([]any, 0, len(items))
+		for _, item := range items {
+			sorted = append(sorted, item.item)
+		}
+		return sorted
+	case map[string]any:
+		for key, item := range value {
+			value[key] = sortArrays(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
@@ -0,0 +1,165 @@
+package driftchecker
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Comparison selects how a desired value is compared with a live value.
+type Comparison string
+
+const (
+	// CompareAuto treats values that both parse as numbers or both as booleans as
+	// typed values, so "1", "1.0" and 1 are equal, and compares anything else exactly.
+	// Numbers are compared without loss of precision, and values with leading zeros
+	// such as "007" are not numbers.
+	CompareAuto Comparison = "auto"
+	// CompareExact compares the values as plain strings.
+	CompareExact Comparison = "exact"
+	// CompareNumeric compares the values as numbers, falling back to exact comparison
+	// when either value is not a number.
+	CompareNumeric Comparison = "numeric"
+	// CompareBoolean compares the values as booleans, falling back to exact comparison
+	// when either value is not a boolean.
+	CompareBoolean Comparison = "boolean"
+	// CompareCaseInsensitive compares the values as strings ignoring case.
+	CompareCaseInsensitive Comparison = "case-insensitive"
+)
+
+// ParseComparison returns the Comparison named by name.
+func ParseComparison(name string) (Comparison, error) {
+	switch c := Comparison(strings.ToLower(strings.TrimSpace(name))); c {
+	case CompareAuto, CompareExact, CompareNumeric, CompareBoolean, CompareCaseInsensitive:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown comparison %q, expected one of auto, exact, numeric, boolean, case-insensitive", name)
+	}
+}
+
+// WithDefaultComparison sets the comparison used for attributes without an override.
+func WithDefaultComparison(c Comparison) CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		d.DefaultComparison = c
+	}
+}
+
+// WithAttributeComparison overrides the comparison used for a single attribute. The
+// attribute path expression is canonicalized, so tags["Env"] overrides tags.Env.
+func WithAttributeComparison(attribute string, c Comparison) CheckerOption {
+	if canonical, err := attrpath.Canonical(attribute); err == nil {
+		attribute = canonical
+	}
+	return func(d *DefaultDriftChecker) {
+		d.Comparisons[attribute] = c
+	}
+}
+
+// equal reports whether the desired and live values of attribute match. A CheckerFunc
+// registered for resourceType that handles the attribute decides alone, and user data
+// is compared by content. Otherwise set-typed attributes are canonicalized first so
+// element order is ignored, and values declared equivalent by an equivalence rule of
+// the attribute match too.
+func (d *DefaultDriftChecker) equal(resourceType, attribute, desired, live string) bool {
+	if equal, ok := registeredEqual(resourceType, attribute, desired, live); ok {
+		return equal
+	}
+	if isUserData(attribute) {
+		return userDataEqual(desired, live)
+	}
+	if d.Unordered[attribute] {
+		desired, live = canonicalSet(desired), canonicalSet(live)
+	}
+
+	rules := d.matchingRules(attribute)
+	comparison, ok := d.Comparisons[attribute]
+	if !ok {
+		comparison = d.DefaultComparison
+		for _, rule := range rules {
+			if rule.Comparison != "" {
+				comparison = rule.Comparison
+				break
+			}
+		}
+	}
+	return compareValues(comparison, desired, live) || equivalent(rules, comparison, desired, live)
+}
+
+func compareValues(comparison Comparison, desired, live string) bool {
+	if desired == live {
+		return true
+	}
+	switch comparison {
+	case CompareExact:
+		return desired == live
+	case CompareCaseInsensitive:
+		return strings.EqualFold(desired, live)
+	case CompareNumeric:
+		if equal, ok := numericEqual(desired, live); ok {
+			return equal
+		}
+		return desired == live
+	case CompareBoolean:
+		if equal, ok := booleanEqual(desired, live); ok {
+			return equal
+		}
+		return desired == live
+	default:
+		if equal, ok := numericEqual(desired, live); ok {
+			return equal
+		}
+		if equal, ok := booleanEqual(desired, live); ok {
+			return equal
+		}
+		return desired == live
+	}
+}
+
+// decimalNumber matches numbers written in canonical decimal form, with an optional
+// fraction and an exponent of up to three digits, which keeps exact comparison cheap.
+// Leading zeros are not accepted, so that identifiers such as "007" keep their string
+// meaning, nor are NaN and infinities.
+var decimalNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]{1,3})?$`)
+
+// numericEqual compares a and b as numbers. ok is false unless both are numbers. The
+// values are compared exactly, so that long numeric identifiers beyond the precision
+// of a float64 are told apart.
+func numericEqual(a, b string) (equal bool, ok bool) {
+	x, okA := parseNumber(a)
+	y, okB := parseNumber(b)
+	if !okA || !okB {
+		return false, false
+	}
+	return x.Cmp(y) == 0, true
+}
+
+func parseNumber(value string) (*big.Rat, bool) {
+	value = strings.TrimSpace(value)
+	if !decimalNumber.MatchString(value) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(value)
+}
+
+// booleanEqual compares a and b as booleans. Only true and false, in any case, are
+// accepted so that values such as "1" or "t" keep their string meaning.
+func booleanEqual(a, b string) (equal bool, ok bool) {
+	x, okA := parseBool(a)
+	y, okB := parseBool(b)
+	if !okA || !okB {
+		return false, false
+	}
+	return x == y, true
+}
+
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
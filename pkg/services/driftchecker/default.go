@@ -2,18 +2,74 @@ package driftchecker
 
 import (
 	"context"
+	"drift-watcher/pkg/logging"
 	"drift-watcher/pkg/services/provider"
 	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"time"
 )
 
-type DefaultDriftChecker struct{}
+type DefaultDriftChecker struct {
+	// Unordered holds the attributes compared as sets, ignoring element order.
+	Unordered map[string]bool
+	// DefaultComparison is used for attributes without an entry in Comparisons.
+	DefaultComparison Comparison
+	// Comparisons holds per-attribute comparison overrides.
+	Comparisons map[string]Comparison
+	// AllAttributes compares every attribute the live resource knows besides the
+	// tracked ones, and reports those that differ.
+	AllAttributes bool
+	// DefaultTags selects how tags inherited from the default_tags of the provider
+	// are compared.
+	DefaultTags DefaultTagsMode
+	// Equivalences holds the equivalence rules declaring differing values equal.
+	Equivalences []EquivalenceRule
+	// Resolver resolves the values of the state referring to SSM parameters and
+	// secrets. Values are compared as they are when it is nil.
+	Resolver provider.ValueResolverI
+}
+
+// CheckerOption configures a DefaultDriftChecker.
+type CheckerOption func(*DefaultDriftChecker)
+
+// WithUnorderedAttributes marks additional attributes as sets whose element order is
+// ignored during comparison.
+func WithUnorderedAttributes(attributes ...string) CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		for _, attribute := range attributes {
+			d.Unordered[attribute] = true
+		}
+	}
+}
+
+// WithAllAttributes diffs the full attribute set of live resources instead of only the
+// tracked attributes. Tracked attributes are always reported; the other attributes
+// only when they drifted.
+func WithAllAttributes() CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		d.AllAttributes = true
+	}
+}
 
 // NewDefaultDriftChecker creates a new instance of AWSDriftChecker.
-func NewDefaultDriftChecker() *DefaultDriftChecker {
-	return &DefaultDriftChecker{}
+func NewDefaultDriftChecker(opts ...CheckerOption) *DefaultDriftChecker {
+	d := &DefaultDriftChecker{
+		Unordered:         map[string]bool{},
+		DefaultComparison: CompareAuto,
+		Comparisons:       map[string]Comparison{},
+		DefaultTags:       DefaultTagsCompare,
+	}
+	for _, attribute := range DefaultUnorderedAttributes {
+		d.Unordered[attribute] = true
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 // CompareStates compares the attributes of a live AWS resource with its desired state.
@@ -28,7 +84,8 @@ func NewDefaultDriftChecker() *DefaultDriftChecker {
 //	              the configuration (e.g., Terraform state). It must also implement
 //	              methods to get resource type and attribute values.
 //	attributesToTrack: A slice of attribute keys (e.g., "instance_type", "tags.Name")
-//	                   to compare.
+//	                   to compare. With AllAttributes set, every attribute of the
+//	                   live resource is compared too.
 //
 // Returns:
 //
@@ -36,8 +93,20 @@ func NewDefaultDriftChecker() *DefaultDriftChecker {
 //	the initial setup of the report fails.
 //	An error if the resource types do not match or other critical issues occur.
 func (d *DefaultDriftChecker) CompareStates(ctx context.Context, liveState provider.InfrastructureResourceI, desiredState statemanager.StateResource, attributesToTrack []string) (*DriftReport, error) {
+	_, span := telemetry.StartSpan(ctx, "DefaultDriftChecker.CompareStates")
+	defer span.End()
+
 	out := &DriftReport{
-		GeneratedAt: time.Now(),
+		GeneratedAt:  time.Now(),
+		ResourceName: desiredState.Name,
+		IndexKey:     desiredState.IndexKey(),
+	}
+	if desiredState.Type != "" && desiredState.Name != "" {
+		out.ResourceAddress = desiredState.Address()
+	}
+	// the id attribute is best effort, a missing id should not prevent the comparison
+	if resourceId, err := desiredState.AttributeValue("id"); err == nil {
+		out.ResourceId = resourceId
 	}
 	if liveState == nil {
 		out.Status = ResourceMissingInInfrastructure
@@ -51,23 +120,43 @@ func (d *DefaultDriftChecker) CompareStates(ctx context.Context, liveState provi
 
 	out.ResourceType = liveState.ResourceType()
 
+	attributes, liveValues := attributesToTrack, map[string]string(nil)
+	if d.AllAttributes {
+		attributes, liveValues = d.allAttributes(ctx, liveState, attributesToTrack)
+	}
+
 	overallDrift := Match
-	for _, attribute := range attributesToTrack {
+	for _, attribute := range attributes {
 		driftItem := DriftItem{
 			Field: attribute,
 		}
+		inherited, isDefaultTag := defaultTag(desiredState, attribute)
+		if isDefaultTag && d.DefaultTags == DefaultTagsIgnore {
+			continue
+		}
 
 		// TODO: add drift Item to show that drift check for this attribute failed
-		liveVal, err := liveState.AttributeValue(attribute)
+		liveVal, ok := liveValues[attribute]
+		var err error
+		if !ok {
+			liveVal, err = provider.LiveAttributeValue(ctx, liveState, attribute)
+		}
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Failed to retrieve value of %s attribute for live state", attribute))
+			logger(ctx).Warn(fmt.Sprintf("Failed to retrieve value of %s attribute for live state", attribute))
 			continue
 		}
 		desiredVal, err := desiredState.AttributeValue(attribute)
+		if isUserData(attribute) {
+			desiredVal, err = userDataState(desiredState, attribute)
+		}
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Failed to retrieve value of %s attribute for desired state", attribute))
+			logger(ctx).Warn(fmt.Sprintf("Failed to retrieve value of %s attribute for desired state", attribute))
 			continue
 		}
+		if isDefaultTag {
+			desiredVal = inherited
+		}
+		desiredVal = d.resolveValue(ctx, &driftItem, desiredVal, liveVal)
 
 		driftItem.TerraformValue = desiredVal
 		driftItem.ActualValue = liveVal
@@ -81,16 +170,27 @@ func (d *DefaultDriftChecker) CompareStates(ctx context.Context, liveState provi
 			}
 		case driftItem.ActualValue == "" && driftItem.TerraformValue != "":
 			driftItem.DriftType = AttributeMissingInInfrastructure
+			if isDefaultTag {
+				driftItem.DriftType = DefaultTagMissingInInfrastructure
+			}
 			if overallDrift == Match {
 				overallDrift = Drift
 			}
-		case driftItem.TerraformValue != driftItem.ActualValue:
+		case !d.equal(out.ResourceType, attribute, desiredVal, liveVal):
 			driftItem.DriftType = AttributeValueChanged
 			if overallDrift == Match {
 				overallDrift = Drift
 			}
 		}
 
+		if isUserData(attribute) {
+			hashUserData(&driftItem, desiredVal, liveVal)
+		}
+
+		if driftItem.DriftType == Match && liveValues != nil && !slices.Contains(attributesToTrack, attribute) {
+			// untracked attributes are only reported when they drifted
+			continue
+		}
 		out.DriftDetails = append(out.DriftDetails, driftItem)
 
 	}
@@ -100,3 +200,29 @@ func (d *DefaultDriftChecker) CompareStates(ctx context.Context, liveState provi
 
 	return out, nil
 }
+
+// allAttributes returns the tracked attributes followed by the other attributes of
+// the live resource in sorted order, and the values read for the live resource.
+// Attributes that could not be read are logged and left out.
+func (d *DefaultDriftChecker) allAttributes(ctx context.Context, liveState provider.InfrastructureResourceI, attributesToTrack []string) ([]string, map[string]string) {
+	liveValues, err := provider.LiveAttributes(ctx, liveState)
+	if err != nil {
+		logger(ctx).Warn("Failed to read some attributes of the live state", "error", err)
+	}
+	if liveValues == nil {
+		liveValues = map[string]string{}
+	}
+
+	attributes := slices.Clone(attributesToTrack)
+	for _, attribute := range slices.Sorted(maps.Keys(liveValues)) {
+		if !slices.Contains(attributesToTrack, attribute) {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes, liveValues
+}
+
+// logger returns the logger carried by ctx for the driftchecker module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "driftchecker")
+}
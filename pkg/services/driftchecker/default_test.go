@@ -2,9 +2,15 @@ package driftchecker_test
 
 import (
 	"context"
+	"crypto/sha1"
+	"drift-watcher/pkg/logging"
 	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
 	"drift-watcher/pkg/services/provider/providerfakes"
 	"drift-watcher/pkg/services/statemanager"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 	"testing"
@@ -194,8 +200,7 @@ func TestCompareStates_AttributeMissingInInfrastructure(t *testing.T) {
 
 	// Capture slog output to ensure warning is logged
 	var buf strings.Builder
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
+	ctx = logging.NewContext(ctx, slog.New(slog.NewTextHandler(&buf, nil)))
 
 	report, err := checker.CompareStates(ctx, mockLiveState, desiredState, attributesToTrack)
 	require.NoError(t, err)
@@ -224,7 +229,7 @@ func TestCompareStates_DesiredStateAttributeError(t *testing.T) {
 		Instances: []statemanager.ResourceInstance{
 			{
 				Attributes: map[string]any{
-					"bucket_name": 123, // Not a string, will cause error in AttributeValue
+					"bucket_name": map[string]any{"name": 123}, // Not a scalar, will cause error in AttributeValue
 				},
 			},
 		},
@@ -233,8 +238,7 @@ func TestCompareStates_DesiredStateAttributeError(t *testing.T) {
 
 	// Capture slog output to ensure warning is logged
 	var buf strings.Builder
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
+	ctx = logging.NewContext(ctx, slog.New(slog.NewTextHandler(&buf, nil)))
 
 	report, err := checker.CompareStates(ctx, mockLiveState, desiredState, attributesToTrack)
 	require.NoError(t, err) // The function continues, logging a warning
@@ -273,8 +277,7 @@ func TestCompareStates_MixedDriftTypes(t *testing.T) {
 
 	// Capture slog output
 	var buf strings.Builder
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
+	ctx = logging.NewContext(ctx, slog.New(slog.NewTextHandler(&buf, nil)))
 
 	report, err := checker.CompareStates(ctx, mockLiveState, desiredState, attributesToTrack)
 	require.NoError(t, err)
@@ -326,3 +329,512 @@ func TestCompareStates_EmptyAttributesToTrack(t *testing.T) {
 	assert.Equal(t, driftchecker.Match, report.Status)
 	assert.Empty(t, report.DriftDetails)
 }
+
+func TestCompareStates_UnorderedSetAttributes(t *testing.T) {
+	checker := driftchecker.NewDefaultDriftChecker()
+
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_security_group")
+	live.AttributeValueStub = func(attribute string) (string, error) {
+		switch attribute {
+		case "security_group_ids":
+			return "sg-2, sg-1", nil
+		case "ingress":
+			return `[{"from_port":443,"cidr_blocks":["10.0.0.0/8","0.0.0.0/0"]},{"from_port":22,"cidr_blocks":["10.0.0.0/8"]}]`, nil
+		}
+		return "", nil
+	}
+	desired := statemanager.StateResource{
+		Type: "aws_security_group",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"security_group_ids": []any{"sg-1", "sg-2"},
+			"ingress": []any{
+				map[string]any{"from_port": 22, "cidr_blocks": []any{"10.0.0.0/8"}},
+				map[string]any{"cidr_blocks": []any{"0.0.0.0/0", "10.0.0.0/8"}, "from_port": 443},
+			},
+		}}},
+	}
+
+	report, err := checker.CompareStates(context.Background(), live, desired, []string{"security_group_ids", "ingress"})
+	require.NoError(t, err)
+	assert.False(t, report.HasDrift)
+	assert.Equal(t, driftchecker.Match, report.Status)
+}
+
+func TestCompareStates_UnorderedSetMembershipChange(t *testing.T) {
+	checker := driftchecker.NewDefaultDriftChecker()
+
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributeValueReturns("sg-3,sg-1", nil)
+	desired := statemanager.StateResource{
+		Type:      "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"security_group_ids": []any{"sg-1", "sg-2"}}}},
+	}
+
+	report, err := checker.CompareStates(context.Background(), live, desired, []string{"security_group_ids"})
+	require.NoError(t, err)
+	assert.True(t, report.HasDrift)
+	require.Len(t, report.DriftDetails, 1)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[0].DriftType)
+}
+
+func TestCompareStates_WithUnorderedAttributes(t *testing.T) {
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributeValueReturns("b,a", nil)
+	desired := statemanager.StateResource{
+		Type:      "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"aliases": "a,b"}}},
+	}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, []string{"aliases"})
+	require.NoError(t, err)
+	assert.True(t, report.HasDrift, "attributes are ordered unless tagged otherwise")
+
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithUnorderedAttributes("aliases"))
+	report, err = checker.CompareStates(context.Background(), live, desired, []string{"aliases"})
+	require.NoError(t, err)
+	assert.False(t, report.HasDrift)
+}
+
+func TestCompareStates_TypedComparison(t *testing.T) {
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributeValueStub = func(attribute string) (string, error) {
+		switch attribute {
+		case "cpu_core_count":
+			return "2", nil
+		case "ebs_optimized":
+			return "TRUE", nil
+		case "volume_size":
+			return "8.0", nil
+		case "tags.Env":
+			return "Prod", nil
+		}
+		return "", nil
+	}
+	desired := statemanager.StateResource{
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"cpu_core_count": float64(2),
+			"ebs_optimized":  true,
+			"volume_size":    "8",
+			"tags.Env":       "prod",
+		}}},
+	}
+	attributes := []string{"cpu_core_count", "ebs_optimized", "volume_size", "tags.Env"}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, attributes)
+	require.NoError(t, err)
+	require.Len(t, report.DriftDetails, 4)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[0].DriftType)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[1].DriftType)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[2].DriftType)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[3].DriftType, "strings are case sensitive by default")
+
+	checker := driftchecker.NewDefaultDriftChecker(
+		driftchecker.WithAttributeComparison("tags.Env", driftchecker.CompareCaseInsensitive),
+		driftchecker.WithAttributeComparison("volume_size", driftchecker.CompareExact),
+	)
+	report, err = checker.CompareStates(context.Background(), live, desired, attributes)
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[2].DriftType, "exact comparison disables numeric coercion")
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[3].DriftType)
+}
+
+func TestCompareStates_BooleanComparisonKeepsNumericStrings(t *testing.T) {
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributeValueReturns("1", nil)
+	desired := statemanager.StateResource{
+		Type:      "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"source_dest_check": true}}},
+	}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, []string{"source_dest_check"})
+	require.NoError(t, err)
+	assert.True(t, report.HasDrift)
+}
+
+func TestCompareStates_NumericComparisonIsExact(t *testing.T) {
+	tests := []struct {
+		desired, live string
+		drift         bool
+	}{
+		{"12345678901234567890", "12345678901234567891", true},
+		{"12345678901234567890", "12345678901234567890", false},
+		{"NaN", "NaN", false},
+		{"Inf", "+Inf", true},
+		{"007", "7", true},
+		{"1000000", "1e+06", false},
+		{"0.1", "0.10", false},
+	}
+	for _, tt := range tests {
+		live := &providerfakes.FakeInfrastructureResourceI{}
+		live.ResourceTypeReturns("aws_instance")
+		live.AttributeValueReturns(tt.live, nil)
+		desired := statemanager.StateResource{
+			Type:      "aws_instance",
+			Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"tags.Id": tt.desired}}},
+		}
+
+		for _, comparison := range []driftchecker.Comparison{driftchecker.CompareAuto, driftchecker.CompareNumeric} {
+			checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithAttributeComparison("tags.Id", comparison))
+			report, err := checker.CompareStates(context.Background(), live, desired, []string{"tags.Id"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.drift, report.HasDrift, "%s %q and %q", comparison, tt.desired, tt.live)
+		}
+	}
+}
+
+func TestCompareStates_AllAttributes(t *testing.T) {
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributesReturns(map[string]string{
+		"instance_type":  "t2.micro",
+		"monitoring":     "true",
+		"key_name":       "deploy",
+		"tags.Name":      "web",
+		"tags.ManagedBy": "console",
+	}, errors.New("user_data: access denied"))
+	desired := statemanager.StateResource{
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"instance_type": "t2.micro",
+			"monitoring":    false,
+			"key_name":      "deploy",
+			"tags":          map[string]any{"Name": "web"},
+		}}},
+	}
+
+	var buf strings.Builder
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithAllAttributes())
+	report, err := checker.CompareStates(ctx, live, desired, []string{"instance_type"})
+	require.NoError(t, err)
+
+	assert.True(t, report.HasDrift)
+	require.Len(t, report.DriftDetails, 3)
+	assert.Equal(t, "instance_type", report.DriftDetails[0].Field, "tracked attributes are reported even when they match")
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[0].DriftType)
+	assert.Equal(t, "monitoring", report.DriftDetails[1].Field)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[1].DriftType)
+	assert.Equal(t, "tags.ManagedBy", report.DriftDetails[2].Field)
+	assert.Equal(t, driftchecker.AttributeMissingInTerraform, report.DriftDetails[2].DriftType)
+	assert.Zero(t, live.AttributeValueCallCount(), "values are read once, with Attributes")
+	assert.Contains(t, buf.String(), "user_data: access denied")
+}
+
+func TestParseComparison(t *testing.T) {
+	c, err := driftchecker.ParseComparison("Case-Insensitive")
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.CompareCaseInsensitive, c)
+
+	_, err = driftchecker.ParseComparison("fuzzy")
+	assert.Error(t, err)
+}
+
+func TestCompareStates_DefaultTags(t *testing.T) {
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	liveTags := map[string]string{"tags.Name": "web", "tags.Environment": "prod"}
+	live.AttributeValueCalls(func(attribute string) (string, error) {
+		return liveTags[attribute], nil
+	})
+	desired := statemanager.StateResource{
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"tags":     map[string]any{"Name": "web", "Team": "platform"},
+			"tags_all": map[string]any{"Name": "web", "Team": "platform", "Environment": "prod", "CostCenter": "42"},
+		}}},
+	}
+	attributes := []string{"tags.Name", "tags.Team", "tags.Environment", "tags.CostCenter"}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, attributes)
+	require.NoError(t, err)
+	require.Len(t, report.DriftDetails, 4)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[0].DriftType)
+	assert.Equal(t, driftchecker.AttributeMissingInInfrastructure, report.DriftDetails[1].DriftType, "tags of the resource are missing resource tags")
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[2].DriftType, "default tags on the live resource match tags_all")
+	assert.Equal(t, driftchecker.DefaultTagMissingInInfrastructure, report.DriftDetails[3].DriftType)
+	assert.Equal(t, "42", report.DriftDetails[3].TerraformValue)
+
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithDefaultTags(driftchecker.DefaultTagsIgnore))
+	report, err = checker.CompareStates(context.Background(), live, desired, attributes)
+	require.NoError(t, err)
+	require.Len(t, report.DriftDetails, 2)
+	assert.Equal(t, "tags.Name", report.DriftDetails[0].Field)
+	assert.Equal(t, "tags.Team", report.DriftDetails[1].Field)
+}
+
+func TestCompareStates_UserData(t *testing.T) {
+	script := "#!/bin/bash\nyum install -y nginx\nsystemctl start nginx\n"
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(script)))
+	encoded := base64.StdEncoding.EncodeToString([]byte(script))
+
+	compare := func(state map[string]any, liveScript string) driftchecker.DriftItem {
+		t.Helper()
+		live := &providerfakes.FakeInfrastructureResourceI{}
+		live.ResourceTypeReturns("aws_instance")
+		live.AttributeValueCalls(func(attribute string) (string, error) {
+			if attribute == "user_data_base64" {
+				return base64.StdEncoding.EncodeToString([]byte(liveScript)), nil
+			}
+			return liveScript, nil
+		})
+		desired := statemanager.StateResource{
+			Type:      "aws_instance",
+			Instances: []statemanager.ResourceInstance{{Attributes: state}},
+		}
+		report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, []string{"user_data"})
+		require.NoError(t, err)
+		require.Len(t, report.DriftDetails, 1)
+		return report.DriftDetails[0]
+	}
+
+	item := compare(map[string]any{"user_data": hash}, script)
+	assert.Equal(t, driftchecker.Match, item.DriftType, "the state hash matches the live script")
+	assert.Equal(t, "sha1:"+hash, item.TerraformValue)
+	assert.Equal(t, "sha1:"+hash, item.ActualValue)
+
+	item = compare(map[string]any{"user_data": hash}, strings.ReplaceAll(script, "\n", "\r\n")+"  \n")
+	assert.Equal(t, driftchecker.Match, item.DriftType, "line endings and surrounding whitespace are ignored")
+
+	item = compare(map[string]any{"user_data": nil, "user_data_base64": encoded}, script)
+	assert.Equal(t, driftchecker.Match, item.DriftType, "user_data falls back to user_data_base64")
+
+	item = compare(map[string]any{"user_data": hash}, "#!/bin/bash\nyum install -y httpd\n")
+	assert.Equal(t, driftchecker.AttributeValueChanged, item.DriftType)
+	assert.Empty(t, item.Excerpt, "the state only holds the hash of the script")
+
+	item = compare(map[string]any{"user_data_base64": encoded}, "#!/bin/bash\nyum install -y httpd\nsystemctl start nginx\n")
+	assert.Equal(t, driftchecker.AttributeValueChanged, item.DriftType)
+	assert.Equal(t, "@@ line 2 @@\n- yum install -y nginx\n+ yum install -y httpd", item.Excerpt)
+}
+
+func TestCompareStates_ValueResolver(t *testing.T) {
+	const (
+		parameter = "resolve:ssm:/ami/al2023"
+		secret    = "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"
+	)
+	resolver := &providerfakes.FakeValueResolverI{}
+	resolver.ResolveValueCalls(func(_ context.Context, value string) (*provider.ResolvedValue, error) {
+		switch value {
+		case parameter:
+			return &provider.ResolvedValue{Value: "ami-0abc"}, nil
+		case secret:
+			return &provider.ResolvedValue{Value: "hunter2", Sensitive: true}, nil
+		case "resolve:ssm:/missing":
+			return nil, errors.New("ParameterNotFound")
+		}
+		return nil, nil
+	})
+
+	compare := func(state map[string]any, liveValues map[string]string) []driftchecker.DriftItem {
+		t.Helper()
+		live := &providerfakes.FakeInfrastructureResourceI{}
+		live.ResourceTypeReturns("aws_instance")
+		live.AttributeValueCalls(func(attribute string) (string, error) {
+			return liveValues[attribute], nil
+		})
+		desired := statemanager.StateResource{
+			Type:      "aws_instance",
+			Instances: []statemanager.ResourceInstance{{Attributes: state}},
+		}
+		report, err := driftchecker.NewDefaultDriftChecker(driftchecker.WithValueResolver(resolver)).CompareStates(context.Background(), live, desired, []string{"ami", "password"})
+		require.NoError(t, err)
+		require.Len(t, report.DriftDetails, 2)
+		return report.DriftDetails
+	}
+
+	items := compare(map[string]any{"ami": parameter, "password": secret}, map[string]string{"ami": "ami-0abc", "password": "hunter3"})
+	assert.Equal(t, driftchecker.Match, items[0].DriftType, "the parameter holds the live AMI")
+	assert.Equal(t, "ami-0abc", items[0].TerraformValue)
+	assert.Equal(t, parameter, items[0].Reference)
+	assert.False(t, items[0].Sensitive)
+	assert.Equal(t, driftchecker.AttributeValueChanged, items[1].DriftType)
+	assert.Equal(t, secret, items[1].Reference)
+	assert.True(t, items[1].Sensitive)
+
+	calls := resolver.ResolveValueCallCount()
+	items = compare(map[string]any{"ami": "ami-0abc", "password": secret}, map[string]string{"ami": "ami-0abc", "password": secret})
+	assert.Equal(t, calls, resolver.ResolveValueCallCount(), "values equal to the live value are not resolved")
+	assert.Equal(t, driftchecker.Match, items[1].DriftType, "the live API may return the reference itself")
+	assert.Empty(t, items[1].Reference)
+
+	items = compare(map[string]any{"ami": "resolve:ssm:/missing", "password": ""}, map[string]string{"ami": "ami-0abc"})
+	assert.Equal(t, driftchecker.AttributeValueChanged, items[0].DriftType, "unresolved references are compared as they are")
+	assert.Equal(t, "resolve:ssm:/missing", items[0].TerraformValue)
+	assert.Empty(t, items[0].Reference)
+}
+
+func TestParseDefaultTagsMode(t *testing.T) {
+	mode, err := driftchecker.ParseDefaultTagsMode("")
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.DefaultTagsCompare, mode)
+
+	mode, err = driftchecker.ParseDefaultTagsMode("Ignore")
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.DefaultTagsIgnore, mode)
+
+	_, err = driftchecker.ParseDefaultTagsMode("merge")
+	assert.ErrorContains(t, err, `unknown default tags mode "merge"`)
+}
+
+func TestCompareStates_EquivalenceRules(t *testing.T) {
+	rules, err := driftchecker.ParseEquivalenceRules([]byte(`
+rules:
+  - attribute: ebs_block_device.volume_type
+    equivalent: [standard, magnetic]
+  - attribute: availability_zone
+    comparison: case-insensitive
+  - attribute: ami
+    equivalent: [ami-0abc, ami-0def]
+`))
+	require.NoError(t, err)
+
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	liveValues := map[string]string{
+		"ebs_block_device.volume_type":  "magnetic",
+		"availability_zone":             "US-EAST-1A",
+		"ami":                           "ami-0def",
+		"root_block_device.volume_type": "gp3",
+	}
+	live.AttributeValueCalls(func(attribute string) (string, error) {
+		return liveValues[attribute], nil
+	})
+	desired := statemanager.StateResource{
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"ebs_block_device":  []any{map[string]any{"volume_type": "standard"}},
+			"availability_zone": "us-east-1a",
+			"ami":               "ami-0abc",
+			"root_block_device": []any{map[string]any{"volume_type": "gp2"}},
+		}}},
+	}
+
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithEquivalenceRules(rules...))
+	report, err := checker.CompareStates(context.Background(), live, desired, []string{"ebs_block_device.volume_type", "availability_zone", "ami", "root_block_device.volume_type"})
+	require.NoError(t, err)
+	require.Len(t, report.DriftDetails, 4)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[0].DriftType)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[1].DriftType)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[2].DriftType)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[3].DriftType, "gp2 and gp3 are not declared equivalent")
+}
+
+func TestParseEquivalenceRules_Errors(t *testing.T) {
+	for input, message := range map[string]string{
+		"rules:\n  - equivalent: [a, b]":                      "rule 1: attribute is required",
+		"rules:\n  - attribute: ami":                          "rule 1: equivalent or comparison is required",
+		"rules:\n  - attribute: ami\n    equivalent: [a]":     "rule 1: equivalent needs at least two values",
+		"rules:\n  - attribute: ami\n    comparison: fuzzy":   `unknown comparison "fuzzy"`,
+		"rules:\n  - attribute: '['\n    equivalent: [a, b]":  "invalid attribute pattern",
+		"rules:\n  - attribute: ami\n    equivalents: [a, b]": "field equivalents not found",
+	} {
+		_, err := driftchecker.ParseEquivalenceRules([]byte(input))
+		assert.ErrorContains(t, err, message, input)
+	}
+}
+
+func TestGroupByDependency(t *testing.T) {
+	group := &driftchecker.DriftReport{ResourceAddress: "aws_security_group.web", HasDrift: true, Dependents: []string{"aws_eip.web", "aws_instance.web"}}
+	drifted := &driftchecker.DriftReport{ResourceAddress: "aws_instance.web[0]", HasDrift: true}
+	clean := &driftchecker.DriftReport{ResourceAddress: "aws_instance.web[1]"}
+	otherStack := &driftchecker.DriftReport{ResourceAddress: "aws_instance.web[2]", HasDrift: true, Stack: "staging"}
+	resolved := &driftchecker.DriftReport{ResourceAddress: "aws_security_group.db", Dependents: []string{"aws_instance.db"}}
+
+	groups := driftchecker.GroupByDependency([]*driftchecker.DriftReport{group, drifted, clean, otherStack, resolved})
+	require.Len(t, groups, 1, "only drifted resources with dependents are grouped")
+	assert.Same(t, group, groups[0].Report)
+	assert.Equal(t, []*driftchecker.DriftReport{drifted}, groups[0].Drifted)
+	assert.Equal(t, group.Dependents, groups[0].Dependents)
+}
+
+func TestRegister(t *testing.T) {
+	// the registry is global, so the test registers a type of its own
+	const resourceType = "test_register_policy"
+	driftchecker.Register(resourceType, driftchecker.ForAttributes(driftchecker.JSONEqual, "policy"))
+	driftchecker.Register(resourceType, func(attribute, desired, live string) (bool, bool) {
+		if attribute != "name" {
+			return false, false
+		}
+		return strings.TrimSuffix(desired, "-v1") == strings.TrimSuffix(live, "-v1"), true
+	})
+
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns(resourceType)
+	liveValues := map[string]string{
+		"policy":      `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject"}]}`,
+		"name":        "reader-v1",
+		"description": "Reader",
+		"path":        "[1, 2]",
+	}
+	live.AttributeValueCalls(func(attribute string) (string, error) {
+		return liveValues[attribute], nil
+	})
+	desired := statemanager.StateResource{
+		Type: resourceType,
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"policy":      `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow"}],"Version":"2012-10-17"}`,
+			"name":        "reader",
+			"description": "reader",
+			"path":        "[1,2]",
+		}}},
+	}
+
+	checker := driftchecker.NewDefaultDriftChecker(driftchecker.WithAttributeComparison("policy", driftchecker.CompareExact))
+	report, err := checker.CompareStates(context.Background(), live, desired, []string{"policy", "name", "description", "path"})
+	require.NoError(t, err)
+	require.Len(t, report.DriftDetails, 4)
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[0].DriftType, "the registered checker overrides the exact comparison")
+	assert.Equal(t, driftchecker.Match, report.DriftDetails[1].DriftType)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[2].DriftType, "attributes without a checker use the default comparison")
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[3].DriftType, "checkers only apply to their attributes")
+
+	other := &providerfakes.FakeInfrastructureResourceI{}
+	other.ResourceTypeReturns("aws_iam_policy")
+	other.AttributeValueReturns(liveValues["policy"], nil)
+	desired.Type = "aws_iam_policy"
+	report, err = checker.CompareStates(context.Background(), other, desired, []string{"policy"})
+	require.NoError(t, err)
+	assert.Equal(t, driftchecker.AttributeValueChanged, report.DriftDetails[0].DriftType, "checkers only apply to their resource type")
+
+	assert.Panics(t, func() { driftchecker.Register(resourceType, nil) })
+}
+
+func TestJSONEqual(t *testing.T) {
+	for _, tt := range []struct {
+		desired, live string
+		equal, ok     bool
+	}{
+		{`{"a": 1, "b": [true, null]}`, `{"b":[true,null],"a":1.0}`, true, true},
+		{`{"a": [1, 2]}`, `{"a": [2, 1]}`, false, true},
+		{`{"a": 1}`, `not json`, false, false},
+		{`t3.micro`, `t3.micro`, false, false},
+	} {
+		equal, ok := driftchecker.JSONEqual("policy", tt.desired, tt.live)
+		assert.Equal(t, tt.equal, equal, tt.desired)
+		assert.Equal(t, tt.ok, ok, tt.desired)
+	}
+}
+
+func TestJSONSubsetEqual(t *testing.T) {
+	for _, tt := range []struct {
+		desired, live string
+		equal, ok     bool
+	}{
+		{`[{"origin_id": "a", "origin_path": "", "connection_attempts": 3}]`, `[{"origin_id":"a","connection_attempts":3.0}]`, true, true},
+		{`[{"origin_id": "a"}, {"origin_id": "b"}]`, `[{"origin_id":"b"},{"origin_id":"a"}]`, true, true},
+		{`[{"origin_id": "a", "custom_origin_config": [{"http_port": 80, "origin_read_timeout": 30}]}]`, `[{"origin_id":"a","custom_origin_config":[{"http_port":8080}]}]`, false, true},
+		{`[{"origin_id": "a"}]`, `[{"origin_id":"a","origin_path":"/v2"}]`, false, true},
+		{`[{"methods": ["GET", "HEAD"]}]`, `[{"methods":["HEAD","GET"]}]`, true, true},
+		{`[{"origin_id": "a"}]`, `a`, false, false},
+	} {
+		equal, ok := driftchecker.JSONSubsetEqual("origin", tt.desired, tt.live)
+		assert.Equal(t, tt.equal, equal, tt.desired)
+		assert.Equal(t, tt.ok, ok, tt.desired)
+	}
+}
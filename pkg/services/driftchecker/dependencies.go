@@ -0,0 +1,42 @@
+package driftchecker
+
+import (
+	"drift-watcher/pkg/services/statemanager"
+	"slices"
+)
+
+// DependencyGroup is a drifted resource together with the resources depending on it,
+// the blast radius of its drift.
+type DependencyGroup struct {
+	// Report is the report of the drifted resource.
+	Report *DriftReport
+	// Drifted are the reports of dependents that drifted too, in the order they were
+	// reported.
+	Drifted []*DriftReport
+	// Dependents are the addresses of every dependent, drifted or not.
+	Dependents []string
+}
+
+// GroupByDependency groups reports by the dependencies recorded in their Dependents,
+// returning one group per drifted report with dependents, in the order of reports.
+// A drifted dependent is part of the group of every resource it depends on, so drift
+// of a security group is listed together with drift of the instances using it.
+func GroupByDependency(reports []*DriftReport) []DependencyGroup {
+	var groups []DependencyGroup
+	for _, report := range reports {
+		if !report.HasDrift || len(report.Dependents) == 0 {
+			continue
+		}
+		group := DependencyGroup{Report: report, Dependents: report.Dependents}
+		for _, other := range reports {
+			if other == report || !other.HasDrift || other.Stack != report.Stack || other.ResourceAddress == "" {
+				continue
+			}
+			if slices.Contains(report.Dependents, statemanager.ConfigAddress(other.ResourceAddress)) {
+				group.Drifted = append(group.Drifted, other)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -16,6 +16,10 @@ const (
 	AttributeValueChanged            DrfitItemValue = "VALUE_CHANGED"
 	AttributeMissingInTerraform      DrfitItemValue = "MISSING_IN_TERRAFORM"
 	AttributeMissingInInfrastructure DrfitItemValue = "MISSING_IN_INFRASTRUCTURE"
+	// DefaultTagMissingInInfrastructure marks a tag inherited from the default_tags of
+	// the provider that is missing on the live resource. A missing tag set on the
+	// resource itself is AttributeMissingInInfrastructure.
+	DefaultTagMissingInInfrastructure DrfitItemValue = "DEFAULT_TAG_MISSING_IN_INFRASTRUCTURE"
 )
 
 // DriftItem represents a specific drift between expected and actual values
@@ -23,7 +27,35 @@ type DriftItem struct {
 	Field          string         `json:"field"`
 	TerraformValue any            `json:"terraform_value"`
 	ActualValue    any            `json:"actual_value"`
-	DriftType      DrfitItemValue `json:"drift_type"` // "VALUE_CHANGED", "MISSING_IN_TERRAFORM", "MISSING_IN_INFRASTRUCTURE"
+	DriftType      DrfitItemValue `json:"drift_type"` // "VALUE_CHANGED", "MISSING_IN_TERRAFORM", "MISSING_IN_INFRASTRUCTURE", "DEFAULT_TAG_MISSING_IN_INFRASTRUCTURE"
+	Remediated     bool           `json:"remediated,omitempty"`
+	// References names the resources whose identifiers appear in the values, keyed by
+	// identifier. It is only set when reference resolution is enabled.
+	References map[string]string `json:"references,omitempty"`
+	// Cost is the approximate change of the monthly cost of the resource caused by the
+	// drift. It is only set when cost estimates are enabled, for attributes with cost
+	// implications such as instance_type.
+	Cost *CostDelta `json:"cost,omitempty"`
+	// Excerpt shows the lines that differ between the values in the style of a unified
+	// diff, for values too long to compare by eye such as user_data.
+	Excerpt string `json:"excerpt,omitempty"`
+	// Reference is the SSM parameter or secret reference the state holds for the
+	// attribute, when the value in the state was resolved from it before comparing.
+	Reference string `json:"reference,omitempty"`
+	// Sensitive marks values resolved from a secret or a SecureString parameter. They
+	// are redacted from reports unless redaction is disabled.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// CostDelta is the approximate change of the monthly cost of a resource caused by the
+// drift of an attribute.
+type CostDelta struct {
+	// Monthly is the monthly cost of the live value less that of the value in the
+	// state. It is positive when the drift makes the resource more expensive.
+	Monthly  float64 `json:"monthly"`
+	Currency string  `json:"currency"`
+	// Basis describes the prices the estimate was computed from.
+	Basis string `json:"basis"`
 }
 
 type DriftReportStatus = string
@@ -33,17 +65,126 @@ const (
 	Drift                           DriftReportStatus = "DRIFT"
 	ResourceMissingInTerraform      DriftReportStatus = "MISSING_IN_TERRAFORM"
 	ResourceMissingInInfrastructure DriftReportStatus = "MISSING_IN_INFRASTRUCTURE"
+	// DriftResolved marks a resource that had drifted on a previous check and now matches.
+	DriftResolved DriftReportStatus = "DRIFT_RESOLVED"
+	// Skipped marks a resource that was excluded from the check by an ignore pattern.
+	Skipped DriftReportStatus = "SKIPPED"
+	// Partial marks the summary written when a scan is interrupted before every
+	// resource was checked.
+	Partial DriftReportStatus = "PARTIAL"
+	// CheckFailed marks a resource whose live state could not be read or compared,
+	// e.g. because the provider call timed out or was throttled. Whether the resource
+	// drifted is not known.
+	CheckFailed DriftReportStatus = "CHECK_FAILED"
+)
+
+// Classes of the failure of a resource check.
+const (
+	// FailureTimeout is a check that ran out of time, such as --per-resource-timeout.
+	FailureTimeout = "timeout"
+	// FailureThrottled is a check whose provider calls were throttled, even after
+	// retries.
+	FailureThrottled = "throttled"
+	// FailureUnavailable is a check skipped while the circuit breaker of the provider
+	// API was open.
+	FailureUnavailable = "unavailable"
+	// FailureError is any other failed check, such as a resource that no longer exists.
+	FailureError = "error"
 )
 
+// ImportSuggestion holds ready-to-paste instructions for bringing an unmanaged
+// resource under management of the IaC tool.
+type ImportSuggestion struct {
+	Address string `json:"address"`
+	Block   string `json:"block"`
+	Command string `json:"command"`
+}
+
+// PolicyViolation is a violation of a compliance policy evaluated over a report.
+type PolicyViolation struct {
+	// Severity is one of low, medium, high or critical.
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Attribute is the drifted attribute the violation is about, when the policy names one.
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// ScanSummary describes how much of a scan completed. It is only attached to the
+// report written when a scan is interrupted.
+type ScanSummary struct {
+	Checked int    `json:"checked"`
+	Total   int    `json:"total"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// MaintenanceWindow describes the maintenance window drift was found in.
+type MaintenanceWindow struct {
+	// Resource is the pattern of the resource addresses the window covers.
+	Resource string    `json:"resource"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// CheckFailure describes why the check of a resource failed.
+type CheckFailure struct {
+	// Class is one of timeout, throttled, unavailable or error.
+	Class string `json:"class"`
+	Error string `json:"error"`
+}
+
 // DriftReport represents the comparison result
 type DriftReport struct {
-	ResourceId   string      `json:"resource_id,omitempty"`
-	ResourceType string      `json:"resource_type,omitempty"`
-	ResourceName string      `json:"resource_nae,omitempty"`
+	// SchemaVersion is the version of the JSON encoding of the report. Reports are
+	// always encoded in the current SchemaVersion.
+	SchemaVersion int    `json:"schema_version"`
+	ResourceId    string `json:"resource_id,omitempty"`
+	ResourceType  string `json:"resource_type,omitempty"`
+	ResourceName  string `json:"resource_name,omitempty"`
+	// ResourceAddress is the full address of the checked instance in the state, e.g.
+	// module.network.aws_instance.web[0].
+	ResourceAddress string `json:"resource_address,omitempty"`
+	// IndexKey is the count index or for_each key of the checked instance of a
+	// resource created with count or for_each.
+	IndexKey     any         `json:"index_key,omitempty"`
 	HasDrift     bool        `json:"has_drift,omitempty"`
 	DriftDetails []DriftItem `json:"drift_details,omitempty"`
 	GeneratedAt  time.Time   `json:"generated_at"`
 	Status       string      `json:"status,omitempty"`
+	// ImportSuggestion is only set for resources found live but missing from the state.
+	ImportSuggestion *ImportSuggestion `json:"import_suggestion,omitempty"`
+	// Summary is only set on the report marking a partial scan.
+	Summary *ScanSummary `json:"summary,omitempty"`
+	// Stack is the path of the Terragrunt stack the resource belongs to, relative to
+	// the project root. It is only set when a Terragrunt project is scanned.
+	Stack string `json:"stack,omitempty"`
+	// Violations lists the compliance policies the report violates. It is only set
+	// when policies are evaluated.
+	Violations []PolicyViolation `json:"violations,omitempty"`
+	// Advisories point out updates available for the resource beyond its drift, such
+	// as a newer AMI. They are only set when advisories are enabled.
+	Advisories []provider.Advisory `json:"advisories,omitempty"`
+	// Dependents are the addresses of the resources of the state that depend on the
+	// drifted resource, directly or through other resources, and may be affected by its
+	// drift. It is only set for reports with drift.
+	Dependents []string `json:"dependents,omitempty"`
+	// OutputSource is the resource attribute a state output was recomputed from, e.g.
+	// aws_instance.web.public_ip. It is only set on reports of outputs.
+	OutputSource string `json:"output_source,omitempty"`
+	// Run describes the run and state snapshot the report was produced by.
+	Run *RunMetadata `json:"run,omitempty"`
+	// Maintenance is set on reports of drift found during a maintenance window
+	// covering the resource. The drift is expected: it is recorded like any other, but
+	// raises no alert and runs no hook.
+	Maintenance *MaintenanceWindow `json:"maintenance,omitempty"`
+	// Failure is only set on reports with the CheckFailed status.
+	Failure *CheckFailure `json:"failure,omitempty"`
+}
+
+// Expected reports whether the drift of the report is expected, because it was found
+// during a maintenance window.
+func (r *DriftReport) Expected() bool {
+	return r.Maintenance != nil
 }
 
 // DriftChecker defines the interface for comparing infrastructure states and detecting drift.
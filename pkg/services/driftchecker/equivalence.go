@@ -0,0 +1,115 @@
+package driftchecker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EquivalenceRule declares values of an attribute that are not drift although they
+// differ, such as the standard and magnetic names of the same EBS volume type or
+// AMI ids of the same image copied between accounts.
+type EquivalenceRule struct {
+	// Attribute is a glob pattern (path.Match syntax) of the attributes the rule
+	// applies to, e.g. availability_zone or tags.*.
+	Attribute string `yaml:"attribute"`
+	// Equivalent lists values that are equal to each other. Values not listed are
+	// compared as usual, so gp2 and gp3 stay drift when only standard and magnetic
+	// are declared equivalent.
+	Equivalent []string `yaml:"equivalent"`
+	// Comparison overrides the comparison of the attributes, e.g. case-insensitive for
+	// availability_zone. Members of Equivalent are matched with it too.
+	Comparison Comparison `yaml:"comparison"`
+}
+
+// EquivalenceRules is a rules file of equivalence rules.
+type EquivalenceRules struct {
+	Rules []EquivalenceRule `yaml:"rules"`
+}
+
+// LoadEquivalenceRules reads and validates the equivalence rules file at filePath.
+func LoadEquivalenceRules(filePath string) ([]EquivalenceRule, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read equivalence rules file: %w", err)
+	}
+	rules, err := ParseEquivalenceRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return rules, nil
+}
+
+// ParseEquivalenceRules decodes and validates a YAML equivalence rules file. Unknown
+// keys are rejected so that a misspelled setting is not silently ignored.
+func ParseEquivalenceRules(data []byte) ([]EquivalenceRule, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	file := &EquivalenceRules{}
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse equivalence rules file: %w", err)
+	}
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Attribute == "" {
+			return nil, fmt.Errorf("rule %d: attribute is required", i+1)
+		}
+		if _, err := path.Match(rule.Attribute, ""); err != nil {
+			return nil, fmt.Errorf("rule %d: invalid attribute pattern %q: %w", i+1, rule.Attribute, err)
+		}
+		if rule.Comparison != "" {
+			comparison, err := ParseComparison(string(rule.Comparison))
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i+1, err)
+			}
+			rule.Comparison = comparison
+		}
+		if len(rule.Equivalent) == 1 {
+			return nil, fmt.Errorf("rule %d: equivalent needs at least two values", i+1)
+		}
+		if len(rule.Equivalent) == 0 && rule.Comparison == "" {
+			return nil, fmt.Errorf("rule %d: equivalent or comparison is required", i+1)
+		}
+	}
+	return file.Rules, nil
+}
+
+// WithEquivalenceRules adds equivalence rules to the checker. Comparisons set with
+// WithAttributeComparison take precedence over the comparison of a rule.
+func WithEquivalenceRules(rules ...EquivalenceRule) CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		d.Equivalences = append(d.Equivalences, rules...)
+	}
+}
+
+// matchingRules returns the equivalence rules applying to attribute.
+func (d *DefaultDriftChecker) matchingRules(attribute string) []EquivalenceRule {
+	var rules []EquivalenceRule
+	for _, rule := range d.Equivalences {
+		if ok, _ := path.Match(rule.Attribute, attribute); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// equivalent reports whether desired and live are both members of the values of one
+// of rules.
+func equivalent(rules []EquivalenceRule, comparison Comparison, desired, live string) bool {
+	member := func(values []string, value string) bool {
+		return slices.ContainsFunc(values, func(v string) bool { return compareValues(comparison, v, value) })
+	}
+	for _, rule := range rules {
+		if member(rule.Equivalent, desired) && member(rule.Equivalent, live) {
+			return true
+		}
+	}
+	return false
+}
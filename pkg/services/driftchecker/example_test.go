@@ -0,0 +1,19 @@
+package driftchecker_test
+
+import (
+	"drift-watcher/pkg/services/driftchecker"
+	"strings"
+)
+
+func ExampleRegister() {
+	// policies are compared as JSON documents, as the AWS API reorders their keys
+	driftchecker.Register("aws_iam_policy", driftchecker.ForAttributes(driftchecker.JSONEqual, "policy"))
+
+	// names are compared without the environment suffix added by the deployment
+	driftchecker.Register("aws_lambda_function", func(attribute, desired, live string) (bool, bool) {
+		if attribute != "function_name" {
+			return false, false
+		}
+		return strings.TrimSuffix(live, "-prod") == desired, true
+	})
+}
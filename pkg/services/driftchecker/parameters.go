@@ -0,0 +1,38 @@
+package driftchecker
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"fmt"
+)
+
+// WithValueResolver resolves the values of the state that refer to an SSM parameter
+// or a secret before they are compared, so that a reference whose target holds the
+// live value is not reported as drift. Drift items of resolved values record the
+// reference, and are marked sensitive when the target is a secret.
+func WithValueResolver(resolver provider.ValueResolverI) CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		d.Resolver = resolver
+	}
+}
+
+// resolveValue returns the value a desired value refers to, and records the reference
+// on item. Values that equal the live value are not resolved, as the live API may
+// return the reference itself, and a reference that cannot be resolved is compared as
+// it is.
+func (d *DefaultDriftChecker) resolveValue(ctx context.Context, item *DriftItem, desired, live string) string {
+	if d.Resolver == nil || desired == "" || desired == live {
+		return desired
+	}
+	resolved, err := d.Resolver.ResolveValue(ctx, desired)
+	if err != nil {
+		logger(ctx).Warn(fmt.Sprintf("Failed to resolve the value of %s attribute, comparing the reference", item.Field), "error", err)
+		return desired
+	}
+	if resolved == nil {
+		return desired
+	}
+	item.Reference = desired
+	item.Sensitive = resolved.Sensitive
+	return resolved.Value
+}
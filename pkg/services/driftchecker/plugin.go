@@ -0,0 +1,124 @@
+package driftchecker
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// CheckerFunc compares the desired and live values of an attribute of a resource in
+// place of the default comparison. ok is false for attributes the function does not
+// handle, or values it cannot interpret, which are then compared as usual.
+type CheckerFunc func(attribute, desired, live string) (equal bool, ok bool)
+
+// checkers holds the CheckerFuncs registered per resource type, in registration order.
+var checkers = struct {
+	mu     sync.RWMutex
+	byType map[string][]CheckerFunc
+}{byType: map[string][]CheckerFunc{}}
+
+// Register adds a CheckerFunc for the attributes of resources of resourceType, e.g.
+// to compare IAM policy documents semantically:
+//
+//	driftchecker.Register("aws_iam_policy", driftchecker.ForAttributes(driftchecker.JSONEqual, "policy"))
+//
+// It is meant to be called from the init function of a package embedding the drift
+// checker. The functions registered for a type are tried in registration order and
+// the first that handles an attribute decides whether it drifted, overriding the
+// comparison options, unordered attributes and equivalence rules of the checker. They
+// are only called when both values are set; a value missing on either side is
+// reported as missing. Register panics if checker is nil.
+func Register(resourceType string, checker CheckerFunc) {
+	if checker == nil {
+		panic("driftchecker: Register checker is nil for " + resourceType)
+	}
+	checkers.mu.Lock()
+	defer checkers.mu.Unlock()
+	checkers.byType[resourceType] = append(checkers.byType[resourceType], checker)
+}
+
+// registeredEqual compares the values with the CheckerFuncs registered for
+// resourceType. ok is false when none of them handles the attribute.
+func registeredEqual(resourceType, attribute, desired, live string) (equal bool, ok bool) {
+	checkers.mu.RLock()
+	registered := checkers.byType[resourceType]
+	checkers.mu.RUnlock()
+	for _, checker := range registered {
+		if equal, ok := checker(attribute, desired, live); ok {
+			return equal, true
+		}
+	}
+	return false, false
+}
+
+// ForAttributes restricts checker to the attributes matching one of patterns, in
+// path.Match syntax, e.g. "policy" or "tags.*".
+func ForAttributes(checker CheckerFunc, patterns ...string) CheckerFunc {
+	return func(attribute, desired, live string) (bool, bool) {
+		matches := slices.ContainsFunc(patterns, func(pattern string) bool {
+			ok, _ := path.Match(pattern, attribute)
+			return ok
+		})
+		if !matches {
+			return false, false
+		}
+		return checker(attribute, desired, live)
+	}
+}
+
+// JSONEqual is a CheckerFunc comparing values as JSON documents, so that documents
+// differing only in key order, whitespace or number formatting, such as a policy
+// normalized by the AWS API, are equal. Values that are not both JSON are left to the
+// default comparison.
+func JSONEqual(attribute, desired, live string) (equal bool, ok bool) {
+	var x, y any
+	if json.Unmarshal([]byte(desired), &x) != nil || json.Unmarshal([]byte(live), &y) != nil {
+		return false, false
+	}
+	return reflect.DeepEqual(x, y), true
+}
+
+// JSONSubsetEqual is a CheckerFunc comparing JSON blocks on the keys of the live
+// value only, for providers that read a subset of the keys of a block the state holds
+// in full, such as the origins of a CloudFront distribution. The elements of lists
+// are compared as sets, as nested blocks are sets in most schemas. Values that are
+// not both JSON are left to the default comparison.
+func JSONSubsetEqual(attribute, desired, live string) (equal bool, ok bool) {
+	var x, y any
+	if json.Unmarshal([]byte(desired), &x) != nil || json.Unmarshal([]byte(live), &y) != nil {
+		return false, false
+	}
+	return reflect.DeepEqual(sortArrays(project(x, y)), sortArrays(y)), true
+}
+
+// project returns the part of desired holding the keys of live, recursively. The
+// elements of a list are projected on the keys of the first live element, as the
+// elements read for a block all have the same keys.
+func project(desired, live any) any {
+	switch l := live.(type) {
+	case map[string]any:
+		d, ok := desired.(map[string]any)
+		if !ok {
+			return desired
+		}
+		projected := make(map[string]any, len(l))
+		for key, value := range l {
+			projected[key] = project(d[key], value)
+		}
+		return projected
+	case []any:
+		d, ok := desired.([]any)
+		if !ok || len(l) == 0 {
+			return desired
+		}
+		projected := make([]any, 0, len(d))
+		for _, item := range d {
+			projected = append(projected, project(item, l[0]))
+		}
+		return projected
+	default:
+		return desired
+	}
+}
@@ -0,0 +1,158 @@
+package driftchecker
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunMetadata describes the run a report was produced by, so that reports written
+// to different reporters and stores can be correlated. Every report of a run carries
+// the same RunId; the state fields describe the state file the report was read from.
+type RunMetadata struct {
+	RunId     string    `json:"run_id"`
+	StartedAt time.Time `json:"started_at"`
+	Provider  string    `json:"provider,omitempty"`
+	// Target is the name of the orchestration target the report belongs to, when the
+	// run scanned the targets of a plan.
+	Target string `json:"target,omitempty"`
+	// DriftwatcherVersion is the version of the driftwatcher binary that produced the
+	// report.
+	DriftwatcherVersion string `json:"driftwatcher_version,omitempty"`
+	// StatePath, Lineage and Serial identify the state snapshot that was checked, and
+	// TerraformVersion the version of Terraform that wrote it.
+	StatePath        string   `json:"state_path,omitempty"`
+	Lineage          string   `json:"lineage,omitempty"`
+	Serial           int      `json:"serial,omitempty"`
+	TerraformVersion string   `json:"terraform_version,omitempty"`
+	ResourceType     string   `json:"resource_type,omitempty"`
+	Attributes       []string `json:"attributes,omitempty"`
+	// Comparison is the default comparison of attributes and AttributeComparisons
+	// the comparisons overriding it for single attributes.
+	Comparison           string            `json:"comparison,omitempty"`
+	AttributeComparisons map[string]string `json:"attribute_comparisons,omitempty"`
+	// Sample is only set when a subset of the resources was checked, so the reports
+	// are not mistaken for a complete scan.
+	Sample *SampleSummary `json:"sample,omitempty"`
+	// Incremental is only set when resources unchanged in state since their last
+	// check were skipped.
+	Incremental *IncrementalSummary `json:"incremental,omitempty"`
+	// Git is only set when driftwatcher ran inside a git checkout.
+	Git *GitContext `json:"git,omitempty"`
+}
+
+// GitContext describes the checkout a run was started in, so that drift findings can
+// be related to the code that was checked out at the time.
+type GitContext struct {
+	Commit string `json:"commit"`
+	// Branch is empty when HEAD is detached.
+	Branch string `json:"branch,omitempty"`
+	// Dirty is set when tracked .tf files have uncommitted changes.
+	Dirty bool `json:"dirty,omitempty"`
+	// StateCommit is the commit the checked state serial was applied from, looked up
+	// in the state commit mapping, and StateCommitMissing is set when that commit is
+	// not part of the checked out branch.
+	StateCommit        string `json:"state_commit,omitempty"`
+	StateCommitMissing bool   `json:"state_commit_missing,omitempty"`
+}
+
+// String describes the checkout in a single line, such as "main@3f2c1a9 (uncommitted
+// .tf changes)".
+func (g *GitContext) String() string {
+	commit := g.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	description := commit
+	if g.Branch != "" {
+		description = g.Branch + "@" + commit
+	}
+	if g.Dirty {
+		description += " (uncommitted .tf changes)"
+	}
+	return description
+}
+
+// IncrementalSummary describes a scan of the resources that changed in state since
+// the previous scan, read at PreviousSerial, or whose last check expired.
+type IncrementalSummary struct {
+	PreviousSerial int `json:"previous_serial,omitempty"`
+	// Changed is the number of resources checked, Unchanged those skipped.
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// String describes the scan in a single line, such as "3 of 40 resources changed
+// since serial 41".
+func (s *IncrementalSummary) String() string {
+	since := "the last scan"
+	if s.PreviousSerial != 0 {
+		since = "serial " + strconv.Itoa(s.PreviousSerial)
+	}
+	return fmt.Sprintf("%d of %d resources changed since %s", s.Changed, s.Changed+s.Unchanged, since)
+}
+
+// SampleSummary describes the subset of the resources of a state a quick scan
+// checked: a random Percent of them, picked with Seed, cut to Limit resources.
+type SampleSummary struct {
+	Percent float64 `json:"percent,omitempty"`
+	Limit   int     `json:"limit,omitempty"`
+	Seed    uint64  `json:"seed,omitempty"`
+	// Sampled is the number of resources checked out of the Total selected by the
+	// filters and exclusions of the scan.
+	Sampled int `json:"sampled"`
+	Total   int `json:"total"`
+}
+
+// String describes the sample in a single line, such as "4 of 40 resources, 10%
+// sample with seed 7".
+func (s *SampleSummary) String() string {
+	description := fmt.Sprintf("%d of %d resources", s.Sampled, s.Total)
+	if s.Percent > 0 {
+		description += fmt.Sprintf(", %s%% sample with seed %d", strconv.FormatFloat(s.Percent, 'f', -1, 64), s.Seed)
+	}
+	if s.Limit > 0 {
+		description += fmt.Sprintf(", limit %d", s.Limit)
+	}
+	return description
+}
+
+// StateSnapshot describes the state snapshot of the run in a single line, such as
+// "prod.tfstate (lineage 8a4e..., serial 42, terraform 1.8.5)".
+func (r *RunMetadata) StateSnapshot() string {
+	var details []string
+	if r.Lineage != "" {
+		details = append(details, "lineage "+r.Lineage)
+	}
+	if r.Serial != 0 {
+		details = append(details, "serial "+strconv.Itoa(r.Serial))
+	}
+	if r.TerraformVersion != "" {
+		details = append(details, "terraform "+r.TerraformVersion)
+	}
+	if len(details) == 0 {
+		return r.StatePath
+	}
+	return strings.TrimSpace(r.StatePath + " (" + strings.Join(details, ", ") + ")")
+}
+
+type runKey struct{}
+
+// NewRunContext returns a copy of ctx carrying run. The logger of ctx is tagged with
+// the run id, so that every line logged during the run can be matched with its
+// reports. A logger already tagged with the same run id is kept as it is.
+func NewRunContext(ctx context.Context, run *RunMetadata) context.Context {
+	if parent := RunFromContext(ctx); parent == nil || parent.RunId != run.RunId {
+		ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("run_id", run.RunId))
+	}
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+// RunFromContext returns the run carried by ctx, or nil when it carries none.
+func RunFromContext(ctx context.Context) *RunMetadata {
+	run, _ := ctx.Value(runKey{}).(*RunMetadata)
+	return run
+}
@@ -0,0 +1,80 @@
+package driftchecker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the JSON encoding of DriftReport written by this
+// release. Fields may be added to the encoding without changing the version, so
+// consumers must ignore fields they do not know. The version is only raised when a
+// field is renamed or removed or its meaning changes, and upgrades registers the
+// conversion of a report of the previous version.
+//
+// Version 1 is the encoding of reports written before versioning, which carry no
+// schema_version and hold the resource name in resource_nae. Version 2 holds it in
+// resource_name.
+const SchemaVersion = 2
+
+// upgrades converts the decoded JSON of a report of version v, at index v-1, to
+// version v+1.
+var upgrades = []func(report map[string]any){
+	// 1 -> 2: resource_nae is renamed resource_name
+	func(report map[string]any) {
+		if name, ok := report["resource_nae"]; ok {
+			report["resource_name"] = name
+			delete(report, "resource_nae")
+		}
+	},
+}
+
+// reportJSON is DriftReport without its JSON methods.
+type reportJSON DriftReport
+
+// MarshalJSON encodes the report in the current schema version, whatever version it
+// was decoded from.
+func (r DriftReport) MarshalJSON() ([]byte, error) {
+	r.SchemaVersion = SchemaVersion
+	return json.Marshal(reportJSON(r))
+}
+
+// UnmarshalJSON decodes a report of any schema version up to SchemaVersion,
+// upgrading reports of older versions as described by UpgradeReport.
+func (r *DriftReport) UnmarshalJSON(data []byte) error {
+	upgraded, err := UpgradeReport(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, (*reportJSON)(r))
+}
+
+// UpgradeReport converts the JSON encoding of a report of an older schema version,
+// such as one kept in a report store or a report file, to the current version. A
+// report without schema_version is of version 1. Reports of the current version are
+// returned unchanged, and reports of a newer version than this release supports are
+// rejected.
+func UpgradeReport(data []byte) ([]byte, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	version := max(header.SchemaVersion, 1)
+	switch {
+	case version == SchemaVersion:
+		return data, nil
+	case version > SchemaVersion:
+		return nil, fmt.Errorf("report schema version %d is newer than the supported version %d", version, SchemaVersion)
+	}
+
+	var report map[string]any
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	for ; version < SchemaVersion; version++ {
+		upgrades[version-1](report)
+	}
+	report["schema_version"] = SchemaVersion
+	return json.Marshal(report)
+}
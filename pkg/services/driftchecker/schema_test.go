@@ -0,0 +1,56 @@
+package driftchecker_test
+
+import (
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftReport_MarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(driftchecker.DriftReport{ResourceId: "i-1", ResourceName: "web"})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(driftchecker.SchemaVersion), fields["schema_version"])
+	assert.Equal(t, "web", fields["resource_name"])
+	assert.NotContains(t, fields, "resource_nae")
+
+	// pointers to reports are encoded the same way
+	encodedPointer, err := json.Marshal(&driftchecker.DriftReport{ResourceId: "i-1", ResourceName: "web"})
+	require.NoError(t, err)
+	assert.JSONEq(t, string(encoded), string(encodedPointer))
+}
+
+func TestDriftReport_UnmarshalJSON(t *testing.T) {
+	// a report written before schema versioning
+	var report driftchecker.DriftReport
+	require.NoError(t, json.Unmarshal([]byte(`{"resource_id": "i-1", "resource_nae": "web", "has_drift": true}`), &report))
+	assert.Equal(t, driftchecker.SchemaVersion, report.SchemaVersion)
+	assert.Equal(t, "web", report.ResourceName)
+	assert.True(t, report.HasDrift)
+
+	report = driftchecker.DriftReport{}
+	require.NoError(t, json.Unmarshal([]byte(`{"schema_version": 2, "resource_name": "db", "new_field": 1}`), &report))
+	assert.Equal(t, "db", report.ResourceName)
+
+	err := json.Unmarshal([]byte(`{"schema_version": 99, "resource_name": "db"}`), &report)
+	assert.ErrorContains(t, err, "report schema version 99 is newer than the supported version")
+}
+
+func TestUpgradeReport(t *testing.T) {
+	upgraded, err := driftchecker.UpgradeReport([]byte(`{"resource_nae": "web", "status": "DRIFT"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version": 2, "resource_name": "web", "status": "DRIFT"}`, string(upgraded))
+
+	current := []byte(`{"schema_version": 2, "resource_name": "web"}`)
+	upgraded, err = driftchecker.UpgradeReport(current)
+	require.NoError(t, err)
+	assert.Equal(t, current, upgraded)
+
+	_, err = driftchecker.UpgradeReport([]byte(`not json`))
+	assert.Error(t, err)
+}
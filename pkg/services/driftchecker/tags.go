@@ -0,0 +1,62 @@
+package driftchecker
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"strings"
+)
+
+// DefaultTagsMode selects how tags inherited from the default_tags of the provider
+// are compared. Terraform records them in the tags_all attribute of a resource, but
+// not in its tags.
+type DefaultTagsMode string
+
+const (
+	// DefaultTagsCompare expects default tags on the live resource like tags of the
+	// resource, and reports a missing one as DefaultTagMissingInInfrastructure.
+	DefaultTagsCompare DefaultTagsMode = "compare"
+	// DefaultTagsIgnore leaves default tags out of the comparison, so only the tags
+	// of the resource itself are checked.
+	DefaultTagsIgnore DefaultTagsMode = "ignore"
+)
+
+// ParseDefaultTagsMode returns the DefaultTagsMode named by name. An empty name is
+// DefaultTagsCompare.
+func ParseDefaultTagsMode(name string) (DefaultTagsMode, error) {
+	switch mode := DefaultTagsMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "", DefaultTagsCompare:
+		return DefaultTagsCompare, nil
+	case DefaultTagsIgnore:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown default tags mode %q, expected compare or ignore", name)
+	}
+}
+
+// WithDefaultTags sets how tags inherited from the default_tags of the provider are
+// compared.
+func WithDefaultTags(mode DefaultTagsMode) CheckerOption {
+	return func(d *DefaultDriftChecker) {
+		d.DefaultTags = mode
+	}
+}
+
+// defaultTag returns the value of a tags.KEY attribute that the state only holds in
+// tags_all, because the tag is inherited from the default_tags of the provider. ok is
+// false for other attributes and for tags set on the resource itself.
+func defaultTag(desiredState statemanager.StateResource, attribute string) (value string, ok bool) {
+	p, err := attrpath.Parse(attribute)
+	if err != nil || len(p) < 2 || p[0].IsIndex || p[0].Key != "tags" {
+		return "", false
+	}
+	if value, err := desiredState.AttributeValue(attribute); err == nil && value != "" {
+		return "", false
+	}
+	inherited := append(attrpath.Path{{Key: "tags_all"}}, p[1:]...)
+	value, err = desiredState.AttributeValue(inherited.String())
+	if err != nil || value == "" {
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,159 @@
+package driftchecker
+
+import (
+	"crypto/sha1"
+	"drift-watcher/pkg/services/statemanager"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// UserDataAttributes lists the attributes holding the user data of an instance. The
+// state keeps user_data as the SHA1 hash of the script and user_data_base64 as its
+// base64 encoding, while the live value is the script itself, so they are compared by
+// content rather than as strings: base64 is decoded, surrounding whitespace and line
+// endings are ignored, and a hash matches the script it was computed from.
+var UserDataAttributes = []string{"user_data", "user_data_base64"}
+
+// maxExcerptLines caps the lines of each side shown in the excerpt of a user data
+// mismatch.
+const maxExcerptLines = 10
+
+var sha1Hex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func isUserData(attribute string) bool {
+	return slices.Contains(UserDataAttributes, attribute)
+}
+
+// userData is one side of a user data comparison.
+type userData struct {
+	// content is the script, or empty when only its hash is known.
+	content string
+	// hash is the SHA1 hex of the script as Terraform computes it.
+	hash string
+}
+
+// parseUserData reads a user_data or user_data_base64 value: the hash Terraform keeps
+// in the state, a base64 encoded script or a plain script.
+func parseUserData(value string) userData {
+	trimmed := strings.TrimSpace(value)
+	if sha1Hex.MatchString(trimmed) {
+		return userData{hash: trimmed}
+	}
+	content := value
+	if decoded, err := base64.StdEncoding.DecodeString(trimmed); err == nil && trimmed != "" {
+		content = string(decoded)
+	}
+	return userData{content: content, hash: sha1Sum(content)}
+}
+
+// text returns the script without surrounding whitespace and with LF line endings.
+func (u userData) text() string {
+	return strings.TrimSpace(strings.ReplaceAll(u.content, "\r\n", "\n"))
+}
+
+// matches reports whether u and other hold the same script. Scripts are compared as
+// text when both are known, and by hash when only the hash of either is.
+func (u userData) matches(other userData) bool {
+	switch {
+	case u.content != "" && other.content != "":
+		return u.text() == other.text()
+	case u.content != "":
+		return u.hashes(other.hash)
+	case other.content != "":
+		return other.hashes(u.hash)
+	default:
+		return u.hash == other.hash
+	}
+}
+
+// hashes reports whether hash is the hash of the script of u, as it is or with its
+// whitespace and line endings normalized, with or without a final newline.
+func (u userData) hashes(hash string) bool {
+	text := u.text()
+	for _, candidate := range []string{u.content, strings.ReplaceAll(u.content, "\r\n", "\n"), text, text + "\n"} {
+		if sha1Sum(candidate) == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func sha1Sum(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// userDataEqual reports whether the desired and live user data hold the same script.
+func userDataEqual(desired, live string) bool {
+	return parseUserData(desired).matches(parseUserData(live))
+}
+
+// userDataState returns the value of a user data attribute in the state, falling back
+// to the other user data attribute, as Terraform only sets one of them and leaves the
+// other null.
+func userDataState(desiredState statemanager.StateResource, attribute string) (string, error) {
+	value, err := desiredState.AttributeValue(attribute)
+	if value != "" {
+		return value, err
+	}
+	for _, other := range UserDataAttributes {
+		if other == attribute {
+			continue
+		}
+		if fallback, fallbackErr := desiredState.AttributeValue(other); fallbackErr == nil && fallback != "" {
+			return fallback, nil
+		}
+	}
+	return value, err
+}
+
+// hashUserData replaces the user data values of item with the sha1: hash of their
+// script, so that reports neither carry whole scripts nor compare a hash with a
+// script, and sets the excerpt of the lines that differ when both scripts are known.
+func hashUserData(item *DriftItem, desired, live string) {
+	desiredData, liveData := parseUserData(desired), parseUserData(live)
+	if desired != "" {
+		item.TerraformValue = "sha1:" + desiredData.hash
+	}
+	if live != "" {
+		item.ActualValue = "sha1:" + liveData.hash
+	}
+	if item.DriftType == AttributeValueChanged && desiredData.content != "" && liveData.content != "" {
+		item.Excerpt = excerpt(desiredData.text(), liveData.text())
+	}
+}
+
+// excerpt renders the lines that differ between desired and live in the style of a
+// unified diff: the lines both share at the start and end are left out, the rest of
+// desired is prefixed with - and the rest of live with +.
+func excerpt(desired, live string) string {
+	a, b := strings.Split(desired, "\n"), strings.Split(live, "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ line %d @@\n", prefix+1)
+	writeLines(&out, "- ", a[prefix:len(a)-suffix])
+	writeLines(&out, "+ ", b[prefix:len(b)-suffix])
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func writeLines(out *strings.Builder, marker string, lines []string) {
+	for i, line := range lines {
+		if i == maxExcerptLines {
+			fmt.Fprintf(out, "%s... %d more line(s)\n", marker, len(lines)-i)
+			return
+		}
+		out.WriteString(marker + line + "\n")
+	}
+}
@@ -0,0 +1,169 @@
+// Package filter scopes drift detection to a subset of the resources in a state, by
+// resource name, module or tag, so a large state can be checked piece by piece
+// without editing it.
+package filter
+
+import (
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Field is the resource property a filter matches on.
+type Field string
+
+const (
+	// FieldName matches the resource name, e.g. web in aws_instance.web.
+	FieldName Field = "name"
+	// FieldModule matches the module the resource belongs to. Both the full module
+	// address (module.network.module.subnets) and the short form without the
+	// module. prefixes (network.subnets) are matched.
+	FieldModule Field = "module"
+	// FieldTag matches the value of a tag; the tag key follows the field, as in
+	// tag:Environment=prod.
+	FieldTag Field = "tag"
+)
+
+// Filter selects resources whose Field matches Pattern. Patterns use path.Match
+// glob syntax, e.g. web-*.
+type Filter struct {
+	Field Field
+	// Key is the tag key for FieldTag filters.
+	Key     string
+	Pattern string
+}
+
+// Parse parses a filter expression of the form name=<pattern>, module=<pattern> or
+// tag:<key>=<pattern>.
+func Parse(expr string) (Filter, error) {
+	field, pattern, ok := strings.Cut(expr, "=")
+	field = strings.TrimSpace(field)
+	if !ok || field == "" {
+		return Filter{}, fmt.Errorf("invalid filter %q, expected name=<pattern>, module=<pattern> or tag:<key>=<pattern>", expr)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return Filter{}, fmt.Errorf("invalid pattern in filter %q: %w", expr, err)
+	}
+
+	f := Filter{Pattern: pattern}
+	switch {
+	case field == string(FieldName):
+		f.Field = FieldName
+	case field == string(FieldModule):
+		f.Field = FieldModule
+	case strings.HasPrefix(field, string(FieldTag)+":"):
+		f.Field = FieldTag
+		f.Key = strings.TrimPrefix(field, string(FieldTag)+":")
+		if f.Key == "" {
+			return Filter{}, fmt.Errorf("invalid filter %q, the tag key is missing", expr)
+		}
+	default:
+		return Filter{}, fmt.Errorf("unknown filter field %q in %q, expected name, module or tag:<key>", field, expr)
+	}
+	return f, nil
+}
+
+// ParseAll parses every filter expression in exprs.
+func ParseAll(exprs []string) ([]Filter, error) {
+	filters := make([]Filter, 0, len(exprs))
+	for _, expr := range exprs {
+		f, err := Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// Match reports whether resource matches the filter.
+func (f Filter) Match(resource statemanager.StateResource) bool {
+	switch f.Field {
+	case FieldName:
+		return match(f.Pattern, resource.Name)
+	case FieldModule:
+		return match(f.Pattern, resource.Module) || match(f.Pattern, shortModule(resource.Module))
+	case FieldTag:
+		value, ok := tagValue(resource, f.Key)
+		return ok && match(f.Pattern, value)
+	default:
+		return false
+	}
+}
+
+// Apply returns the resources that match filters. Filters on the same field (and
+// tag key) are alternatives, so a resource needs to match only one of them, while
+// filters on different fields must all match. Without filters every resource is
+// returned.
+func Apply(resources []statemanager.StateResource, filters []Filter) []statemanager.StateResource {
+	if len(filters) == 0 {
+		return resources
+	}
+
+	groups := map[string][]Filter{}
+	for _, f := range filters {
+		key := string(f.Field) + ":" + f.Key
+		groups[key] = append(groups[key], f)
+	}
+
+	var selected []statemanager.StateResource
+	for _, resource := range resources {
+		if matchesAll(resource, groups) {
+			selected = append(selected, resource)
+		}
+	}
+	return selected
+}
+
+func matchesAll(resource statemanager.StateResource, groups map[string][]Filter) bool {
+	for _, group := range groups {
+		matched := false
+		for _, f := range group {
+			if f.Match(resource) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func match(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// shortModule strips the module. prefixes from a module address, turning
+// module.network.module.subnets into network.subnets.
+func shortModule(module string) string {
+	parts := strings.Split(module, ".")
+	short := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "module" {
+			short = append(short, part)
+		}
+	}
+	return strings.Join(short, ".")
+}
+
+// tagValue returns the value of the tag key of the resource's first instance. Tags
+// inherited from provider default_tags are only found in tags_all.
+func tagValue(resource statemanager.StateResource, key string) (string, bool) {
+	if len(resource.Instances) == 0 {
+		return "", false
+	}
+	for _, attribute := range []string{"tags", "tags_all"} {
+		tags, ok := resource.Instances[0].Attributes[attribute].(map[string]any)
+		if !ok {
+			continue
+		}
+		if value, ok := tags[key].(string); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package filter_test
+
+import (
+	"drift-watcher/pkg/services/filter"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resource(name, module string, tags map[string]any) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type:   "aws_instance",
+		Name:   name,
+		Module: module,
+		Instances: []statemanager.ResourceInstance{
+			{Attributes: map[string]any{"id": "i-" + name, "tags": tags}},
+		},
+	}
+}
+
+func names(resources []statemanager.StateResource) []string {
+	var out []string
+	for _, r := range resources {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	f, err := filter.Parse("tag:Environment=prod")
+	require.NoError(t, err)
+	assert.Equal(t, filter.Filter{Field: filter.FieldTag, Key: "Environment", Pattern: "prod"}, f)
+
+	f, err = filter.Parse("name=web-*")
+	require.NoError(t, err)
+	assert.Equal(t, filter.Filter{Field: filter.FieldName, Pattern: "web-*"}, f)
+
+	for _, expr := range []string{"web-*", "type=aws_instance", "tag:=prod", "name=[", "=x"} {
+		_, err := filter.Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestApply(t *testing.T) {
+	resources := []statemanager.StateResource{
+		resource("web-1", "", map[string]any{"Environment": "prod"}),
+		resource("web-2", "module.network", map[string]any{"Environment": "dev"}),
+		resource("api", "module.network.module.subnets", map[string]any{"Environment": "prod"}),
+		resource("db", "", nil),
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{"no filters", nil, []string{"web-1", "web-2", "api", "db"}},
+		{"name glob", []string{"name=web-*"}, []string{"web-1", "web-2"}},
+		{"short module", []string{"module=network"}, []string{"web-2"}},
+		{"nested module", []string{"module=network.*"}, []string{"api"}},
+		{"full module address", []string{"module=module.network"}, []string{"web-2"}},
+		{"tag", []string{"tag:Environment=prod"}, []string{"web-1", "api"}},
+		{"different fields must all match", []string{"name=web-*", "tag:Environment=prod"}, []string{"web-1"}},
+		{"same field matches any", []string{"name=web-1", "name=db"}, []string{"web-1", "db"}},
+		{"no match", []string{"tag:Team=core"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := filter.ParseAll(tt.filters)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names(filter.Apply(resources, filters)))
+		})
+	}
+}
+
+func TestApply_TagsAll(t *testing.T) {
+	r := resource("web", "", nil)
+	r.Instances[0].Attributes["tags_all"] = map[string]any{"Environment": "prod"}
+
+	filters, err := filter.ParseAll([]string{"tag:Environment=prod"})
+	require.NoError(t, err)
+	assert.Len(t, filter.Apply([]statemanager.StateResource{r}, filters), 1)
+}
@@ -0,0 +1,167 @@
+// Package gitcontext records the git checkout a drift check runs in, and relates the
+// serial of the checked state to the commit it was applied from, so that drift can be
+// told apart from code that was merged but never applied, or applied from a branch
+// that was never merged.
+package gitcontext
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inspect returns the commit and branch checked out in dir, the working directory when
+// empty, and whether tracked .tf files have uncommitted changes. It returns nil when dir
+// is not inside a git checkout or git is not installed.
+func Inspect(ctx context.Context, dir string) (*driftchecker.GitContext, error) {
+	if _, err := git(ctx, dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		var exitErr *exec.ExitError
+		if errors.Is(err, exec.ErrNotFound) || errors.As(err, &exitErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	commit, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the checked out commit: %w", err)
+	}
+	info := &driftchecker.GitContext{Commit: commit}
+	if branch, err := git(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+	changes, err := git(ctx, dir, "status", "--porcelain", "--untracked-files=no", "--", "*.tf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the status of the checkout: %w", err)
+	}
+	info.Dirty = changes != ""
+	return info, nil
+}
+
+// StateCommit maps a state serial to the commit it was applied from. An empty Lineage
+// matches the serial of any state.
+type StateCommit struct {
+	Lineage string `yaml:"lineage"`
+	Serial  int    `yaml:"serial"`
+	Commit  string `yaml:"commit"`
+}
+
+// CommitMap relates state serials to the commits they were applied from, as recorded
+// by the pipeline that applies the configuration, and warns when the commit of a
+// checked state is not part of the checked out branch.
+type CommitMap struct {
+	// Dir is the checkout commits are looked up in, the working directory when empty.
+	Dir     string
+	Entries []StateCommit
+}
+
+// Load reads a CommitMap from a YAML file holding a list of state commits, e.g.
+//
+//	# state-commits.yaml
+//	- lineage: 8a4e3b1c-...
+//	  serial: 42
+//	  commit: 3f2c1a9
+func Load(path string) (*CommitMap, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state commit mapping: %w", err)
+	}
+	var entries []StateCommit
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse state commit mapping %s: %w", path, err)
+	}
+	for i, entry := range entries {
+		if entry.Serial <= 0 || entry.Commit == "" {
+			return nil, fmt.Errorf("state commit mapping %s: entry %d requires a serial and a commit", path, i+1)
+		}
+	}
+	return &CommitMap{Entries: entries}, nil
+}
+
+// Commit returns the commit the state of lineage was applied from at serial. Serials
+// written without an apply of their own, such as by a refresh, belong to the closest
+// lower serial that was recorded.
+func (m *CommitMap) Commit(lineage string, serial int) (string, bool) {
+	var found *StateCommit
+	for i, entry := range m.Entries {
+		if entry.Lineage != "" && entry.Lineage != lineage || entry.Serial > serial {
+			continue
+		}
+		if found == nil || entry.Serial > found.Serial {
+			found = &m.Entries[i]
+		}
+	}
+	if found == nil {
+		return "", false
+	}
+	return found.Commit, true
+}
+
+// Check looks up the commit the state of run was applied from and records it in the
+// git context of run, warning when the commit is not part of the checked out branch.
+// Nothing is checked outside of a git checkout, or by a nil CommitMap.
+func (m *CommitMap) Check(ctx context.Context, run *driftchecker.RunMetadata) {
+	if m == nil || run.Git == nil {
+		return
+	}
+	commit, ok := m.Commit(run.Lineage, run.Serial)
+	if !ok {
+		logger(ctx).Info("No commit recorded for the state serial", "lineage", run.Lineage, "serial", run.Serial)
+		return
+	}
+	// the git context is shared with the parent run, which may check other states
+	checkout := *run.Git
+	checkout.StateCommit = commit
+	run.Git = &checkout
+
+	// --is-ancestor exits with 1 for a commit that is not an ancestor, and fails
+	// otherwise for a commit that is not in the repository at all
+	_, err := git(ctx, m.Dir, "merge-base", "--is-ancestor", commit, "HEAD")
+	if err == nil {
+		return
+	}
+	reason := "not in the current branch"
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		reason = "unknown to the repository"
+	}
+	run.Git.StateCommitMissing = true
+	branch := run.Git.Branch
+	if branch == "" {
+		branch = "HEAD"
+	}
+	logger(ctx).Warn("The state was applied from a commit "+reason+", drift may come from code that is not checked out",
+		"serial", run.Serial, "state_commit", commit, "branch", branch)
+}
+
+// git runs git with args in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	command := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// logger returns the logger carried by ctx for the git module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "git")
+}
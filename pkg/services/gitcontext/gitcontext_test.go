@@ -0,0 +1,119 @@
+package gitcontext_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/gitcontext"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run runs git in dir and returns its trimmed output.
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// newRepo creates a repository on branch main with a committed main.tf.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run(t, dir, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_instance" "web" {}`), 0644))
+	run(t, dir, "add", "main.tf")
+	run(t, dir, "commit", "-q", "-m", "web")
+	return dir
+}
+
+func TestInspect(t *testing.T) {
+	dir := newRepo(t)
+	head := run(t, dir, "rev-parse", "HEAD")
+
+	git, err := gitcontext.Inspect(context.Background(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, &driftchecker.GitContext{Commit: head, Branch: "main"}, git)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("untracked"), 0644))
+	git, err = gitcontext.Inspect(context.Background(), dir)
+	require.NoError(t, err)
+	assert.False(t, git.Dirty, "only tracked .tf files make the checkout dirty")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "aws_instance" "api" {}`), 0644))
+	git, err = gitcontext.Inspect(context.Background(), dir)
+	require.NoError(t, err)
+	assert.True(t, git.Dirty)
+	assert.Equal(t, "main@"+head[:7]+" (uncommitted .tf changes)", git.String())
+}
+
+func TestInspect_NotARepository(t *testing.T) {
+	git, err := gitcontext.Inspect(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, git)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commits.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- serial: 40
+  commit: aaaaaaa
+- lineage: prod
+  serial: 42
+  commit: bbbbbbb
+- lineage: staging
+  serial: 43
+  commit: ccccccc
+`), 0644))
+
+	commits, err := gitcontext.Load(path)
+	require.NoError(t, err)
+
+	commit, ok := commits.Commit("prod", 42)
+	assert.True(t, ok)
+	assert.Equal(t, "bbbbbbb", commit)
+	commit, _ = commits.Commit("prod", 45)
+	assert.Equal(t, "bbbbbbb", commit, "later serials belong to the last recorded apply")
+	commit, _ = commits.Commit("staging", 42)
+	assert.Equal(t, "aaaaaaa", commit, "entries without a lineage match every state")
+	_, ok = commits.Commit("prod", 39)
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte("- serial: 41\n"), 0644))
+	_, err = gitcontext.Load(path)
+	assert.ErrorContains(t, err, "entry 1 requires a serial and a commit")
+}
+
+func TestCommitMap_Check(t *testing.T) {
+	dir := newRepo(t)
+	merged := run(t, dir, "rev-parse", "HEAD")
+	run(t, dir, "checkout", "-q", "-b", "hotfix")
+	run(t, dir, "commit", "-q", "--allow-empty", "-m", "hotfix")
+	unmerged := run(t, dir, "rev-parse", "HEAD")
+	run(t, dir, "checkout", "-q", "main")
+
+	commits := &gitcontext.CommitMap{Dir: dir, Entries: []gitcontext.StateCommit{
+		{Serial: 1, Commit: merged},
+		{Serial: 2, Commit: unmerged},
+		{Serial: 3, Commit: "0123456789abcdef0123456789abcdef01234567"},
+	}}
+	checkout := &driftchecker.GitContext{Commit: merged, Branch: "main"}
+
+	for serial, missing := range map[int]bool{1: false, 2: true, 3: true} {
+		run := &driftchecker.RunMetadata{Serial: serial, Git: checkout}
+		commits.Check(context.Background(), run)
+		assert.Equal(t, missing, run.Git.StateCommitMissing, "serial %d", serial)
+		assert.NotEmpty(t, run.Git.StateCommit)
+	}
+	assert.Empty(t, checkout.StateCommit, "the git context of the parent run is left alone")
+}
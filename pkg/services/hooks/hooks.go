@@ -0,0 +1,185 @@
+// Package hooks runs user supplied executables when specific attributes drift, such
+// as a runbook when the instance type of a resource changes.
+//
+// A hook receives the drift item as JSON on stdin. The resource it belongs to is
+// described by the DRIFT_RESOURCE_ID, DRIFT_RESOURCE_TYPE, DRIFT_RESOURCE_NAME,
+// DRIFT_RESOURCE_ADDRESS and DRIFT_ATTRIBUTE environment variables.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the number of hooks run at the same time by default.
+const DefaultConcurrency = 4
+
+// DefaultTimeout bounds a hook run when neither the hook nor the runner set a timeout.
+const DefaultTimeout = 30 * time.Second
+
+// waitDelay is how long the output of a hook is still read after it was killed.
+const waitDelay = time.Second
+
+// maxOutput is how much of a failed hook's output is logged.
+const maxOutput = 512
+
+// Runner runs the hooks matching the drifted attributes of reports. Hooks run in the
+// background with bounded concurrency; Wait blocks until every hook has finished.
+// A nil Runner runs nothing.
+type Runner struct {
+	hooks   []config.HookConfig
+	timeout time.Duration
+	sem     chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a new Runner instance.
+// hooks: The registered hooks.
+// concurrency: The maximum number of hooks running at the same time.
+// timeout: The timeout of hooks that do not set their own.
+func NewRunner(hooks []config.HookConfig, concurrency int, timeout time.Duration) (*Runner, error) {
+	for i, hook := range hooks {
+		if hook.Command == "" {
+			return nil, fmt.Errorf("hook %d: command is required", i+1)
+		}
+		if hook.Attribute == "" {
+			return nil, fmt.Errorf("hook %s: attribute is required", hook.Command)
+		}
+		if _, err := path.Match(hook.Attribute, ""); err != nil {
+			return nil, fmt.Errorf("hook %s: invalid attribute pattern %q: %w", hook.Command, hook.Attribute, err)
+		}
+		if hook.Timeout < 0 {
+			return nil, fmt.Errorf("hook %s: timeout must not be negative", hook.Command)
+		}
+	}
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Runner{
+		hooks:   hooks,
+		timeout: timeout,
+		sem:     make(chan struct{}, concurrency),
+	}, nil
+}
+
+// Dispatch starts every hook registered for an attribute that drifted in report.
+// It does not wait for the hooks to finish. Drift expected during maintenance runs
+// no hook.
+func (r *Runner) Dispatch(ctx context.Context, report *driftchecker.DriftReport) {
+	if r == nil || report == nil || report.Expected() {
+		return
+	}
+	// the report may be changed by writers while hooks run, so they get a copy of
+	// what they need
+	resource := resource{id: report.ResourceId, resourceType: report.ResourceType, name: report.ResourceName, address: report.ResourceAddress}
+	for _, item := range report.DriftDetails {
+		if item.DriftType == driftchecker.Match {
+			continue
+		}
+		for _, hook := range r.hooks {
+			if ok, _ := path.Match(hook.Attribute, item.Field); !ok {
+				continue
+			}
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				select {
+				case r.sem <- struct{}{}:
+				case <-ctx.Done():
+					logger(ctx).Warn("Drift hook not run", "command", hook.Command, "attribute", item.Field, "error", ctx.Err())
+					return
+				}
+				defer func() { <-r.sem }()
+
+				if err := r.run(ctx, hook, resource, item); err != nil {
+					logger(ctx).Warn("Drift hook failed", "command", hook.Command, "attribute", item.Field, "resource_id", resource.id, "error", err)
+				}
+			}()
+		}
+	}
+}
+
+// Wait blocks until every dispatched hook has finished.
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+// resource identifies the resource a hook runs for.
+type resource struct {
+	id           string
+	resourceType string
+	name         string
+	address      string
+}
+
+// run runs a single hook for a drifted item.
+func (r *Runner) run(ctx context.Context, hook config.HookConfig, resource resource, item driftchecker.DriftItem) error {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode drift item: %w", err)
+	}
+
+	timeout := hook.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	command := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	command.Stdin = bytes.NewReader(payload)
+	command.Stdout = &output
+	command.Stderr = &output
+	// stop waiting for output held open by children of a killed hook
+	command.WaitDelay = waitDelay
+	command.Env = append(os.Environ(),
+		"DRIFT_RESOURCE_ID="+resource.id,
+		"DRIFT_RESOURCE_TYPE="+resource.resourceType,
+		"DRIFT_RESOURCE_NAME="+resource.name,
+		"DRIFT_RESOURCE_ADDRESS="+resource.address,
+		"DRIFT_ATTRIBUTE="+item.Field,
+	)
+
+	logger(ctx).Debug("Running drift hook", "command", hook.Command, "attribute", item.Field, "resource_id", resource.id)
+	err = command.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, truncate(strings.TrimSpace(output.String())))
+	}
+	logger(ctx).Info("Drift hook completed", "command", hook.Command, "attribute", item.Field, "resource_id", resource.id)
+	return nil
+}
+
+func truncate(text string) string {
+	if len(text) <= maxOutput {
+		return text
+	}
+	return text[:maxOutput] + "..."
+}
+
+// logger returns the logger carried by ctx for the hooks module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "hooks")
+}
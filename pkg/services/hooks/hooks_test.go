@@ -0,0 +1,156 @@
+package hooks_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/hooks"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes an executable shell script to a temporary directory.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"+body), 0755))
+	return script
+}
+
+func driftReport() *driftchecker.DriftReport {
+	return &driftchecker.DriftReport{
+		ResourceId:   "i-1",
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		HasDrift:     true,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "ami", TerraformValue: "ami-1", ActualValue: "ami-1", DriftType: driftchecker.Match},
+			{Field: "tags.Env", TerraformValue: "prod", ActualValue: "dev", DriftType: driftchecker.AttributeValueChanged},
+		},
+	}
+}
+
+func TestRunner_Dispatch(t *testing.T) {
+	out := t.TempDir()
+	script := writeScript(t, `cat > "$1/$DRIFT_ATTRIBUTE.json"
+echo "$DRIFT_RESOURCE_ID $DRIFT_RESOURCE_TYPE $DRIFT_RESOURCE_NAME" > "$1/$DRIFT_ATTRIBUTE.env"
+`)
+
+	runner, err := hooks.NewRunner([]config.HookConfig{
+		{Attribute: "instance_type", Command: script, Args: []string{out}},
+		{Attribute: "ami", Command: script, Args: []string{out}},
+		{Attribute: "tags.*", Command: script, Args: []string{out}},
+	}, 2, time.Minute)
+	require.NoError(t, err)
+
+	runner.Dispatch(context.Background(), driftReport())
+	runner.Wait()
+
+	payload, err := os.ReadFile(filepath.Join(out, "instance_type.json"))
+	require.NoError(t, err)
+	var item driftchecker.DriftItem
+	require.NoError(t, json.Unmarshal(payload, &item))
+	assert.Equal(t, "instance_type", item.Field)
+	assert.Equal(t, "t2.large", item.ActualValue)
+
+	env, err := os.ReadFile(filepath.Join(out, "instance_type.env"))
+	require.NoError(t, err)
+	assert.Equal(t, "i-1 aws_instance web\n", string(env))
+
+	assert.FileExists(t, filepath.Join(out, "tags.Env.json"))
+	assert.NoFileExists(t, filepath.Join(out, "ami.json"), "hooks do not run for matching attributes")
+}
+
+func TestRunner_Dispatch_Maintenance(t *testing.T) {
+	out := t.TempDir()
+	script := writeScript(t, `touch "$1/$DRIFT_ATTRIBUTE"`)
+	runner, err := hooks.NewRunner([]config.HookConfig{{Attribute: "*", Command: script, Args: []string{out}}}, 1, time.Minute)
+	require.NoError(t, err)
+
+	report := driftReport()
+	report.Maintenance = &driftchecker.MaintenanceWindow{Resource: "aws_instance.web"}
+	runner.Dispatch(context.Background(), report)
+	runner.Wait()
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "hooks do not run for drift expected during maintenance")
+}
+
+func TestRunner_Timeout(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "finished")
+	script := writeScript(t, "sleep 5\ntouch "+marker+"\n")
+
+	runner, err := hooks.NewRunner([]config.HookConfig{
+		{Attribute: "instance_type", Command: script, Timeout: 100 * time.Millisecond},
+	}, 1, time.Minute)
+	require.NoError(t, err)
+
+	start := time.Now()
+	runner.Dispatch(context.Background(), driftReport())
+	runner.Wait()
+	assert.Less(t, time.Since(start), 4*time.Second, "the hook is killed after its timeout")
+	assert.NoFileExists(t, marker)
+}
+
+func TestRunner_Concurrency(t *testing.T) {
+	dir := t.TempDir()
+	// every run records the number of hooks running alongside it
+	script := writeScript(t, `touch "$1/running.$$"
+ls "$1" | grep -c running > "$1/seen.$$"
+sleep 0.2
+rm "$1/running.$$"
+`)
+
+	runner, err := hooks.NewRunner([]config.HookConfig{
+		{Attribute: "*", Command: script, Args: []string{dir}},
+	}, 1, time.Minute)
+	require.NoError(t, err)
+
+	for range 3 {
+		runner.Dispatch(context.Background(), driftReport())
+	}
+	runner.Wait()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	seen := 0
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "seen.") {
+			continue
+		}
+		seen++
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		assert.Equal(t, "1\n", string(content), "only one hook runs at a time")
+	}
+	assert.Equal(t, 6, seen)
+}
+
+func TestNewRunner_Invalid(t *testing.T) {
+	_, err := hooks.NewRunner([]config.HookConfig{{Attribute: "instance_type"}}, 1, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command is required")
+
+	_, err = hooks.NewRunner([]config.HookConfig{{Command: "runbook"}}, 1, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "attribute is required")
+
+	_, err = hooks.NewRunner([]config.HookConfig{{Attribute: "[tags", Command: "runbook"}}, 1, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid attribute pattern")
+}
+
+func TestRunner_Nil(t *testing.T) {
+	var runner *hooks.Runner
+	runner.Dispatch(context.Background(), driftReport())
+	runner.Wait()
+}
@@ -0,0 +1,149 @@
+// Package ignore excludes resources from drift detection using gitignore-style
+// patterns matched against resource addresses, read from a .driftignore file or
+// given with --exclude.
+package ignore
+
+import (
+	"bufio"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultFile is the ignore file read from the working directory when no other
+// file is configured.
+const DefaultFile = ".driftignore"
+
+// rule is a single parsed ignore pattern.
+type rule struct {
+	pattern string
+	negate  bool
+}
+
+// Matcher decides which resources are excluded. A nil Matcher excludes nothing.
+type Matcher struct {
+	rules []rule
+}
+
+// New creates a new Matcher instance.
+// patterns: Glob patterns (path.Match syntax) matched against resource addresses
+// such as aws_instance.web or module.network.aws_instance.web. A pattern prefixed
+// with ! re-includes resources excluded by an earlier pattern; the last matching
+// pattern wins, as in .gitignore.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if err := m.add(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Parse reads patterns from r, one per line. Blank lines and lines starting with #
+// are skipped; a leading \# or \! escapes the character.
+func Parse(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// Load reads the patterns of the ignore file at filePath. A missing file yields no
+// patterns.
+func Load(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer f.Close()
+
+	patterns, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return patterns, nil
+}
+
+func (m *Matcher) add(pattern string) error {
+	r := rule{pattern: strings.TrimSpace(pattern)}
+	if strings.HasPrefix(r.pattern, "!") {
+		r.negate = true
+		r.pattern = r.pattern[1:]
+	} else if strings.HasPrefix(r.pattern, `\!`) || strings.HasPrefix(r.pattern, `\#`) {
+		r.pattern = r.pattern[1:]
+	}
+	if r.pattern == "" {
+		return nil
+	}
+	if _, err := path.Match(r.pattern, ""); err != nil {
+		return fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+	}
+	m.rules = append(m.rules, r)
+	return nil
+}
+
+// Excluded reports whether resource is excluded. Patterns are matched against the
+// full address and against the address without its module path, so aws_instance.web
+// also matches the resource inside a module.
+func (m *Matcher) Excluded(resource statemanager.StateResource) bool {
+	if m == nil {
+		return false
+	}
+	full := Address(resource)
+	short := resource.Type + "." + resource.Name
+
+	excluded := false
+	for _, r := range m.rules {
+		if match(r.pattern, full) || match(r.pattern, short) {
+			excluded = !r.negate
+		}
+	}
+	return excluded
+}
+
+// Split separates resources into those to check and those excluded.
+func (m *Matcher) Split(resources []statemanager.StateResource) (included []statemanager.StateResource, excluded []statemanager.StateResource) {
+	if m == nil || len(m.rules) == 0 {
+		return resources, nil
+	}
+	for _, resource := range resources {
+		if m.Excluded(resource) {
+			excluded = append(excluded, resource)
+		} else {
+			included = append(included, resource)
+		}
+	}
+	return included, excluded
+}
+
+// Address returns the resource's address, e.g. module.network.aws_instance.web.
+func Address(resource statemanager.StateResource) string {
+	address := resource.Type + "." + resource.Name
+	if resource.Module != "" {
+		address = resource.Module + "." + address
+	}
+	return address
+}
+
+func match(pattern, address string) bool {
+	ok, _ := path.Match(pattern, address)
+	return ok
+}
@@ -0,0 +1,78 @@
+package ignore_test
+
+import (
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/statemanager"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	patterns, err := ignore.Parse(strings.NewReader(`
+# scratch instances are managed by hand
+aws_instance.scratch-*
+
+!aws_instance.scratch-keep
+\#literal
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_instance.scratch-*", "!aws_instance.scratch-keep", `\#literal`}, patterns)
+}
+
+func TestMatcher_Excluded(t *testing.T) {
+	m, err := ignore.New([]string{"aws_instance.scratch-*", "!aws_instance.scratch-keep", "module.legacy.*"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		resource statemanager.StateResource
+		excluded bool
+	}{
+		{statemanager.StateResource{Type: "aws_instance", Name: "scratch-1"}, true},
+		{statemanager.StateResource{Type: "aws_instance", Name: "scratch-keep"}, false},
+		{statemanager.StateResource{Type: "aws_instance", Name: "web"}, false},
+		{statemanager.StateResource{Type: "aws_instance", Name: "scratch-2", Module: "module.network"}, true},
+		{statemanager.StateResource{Type: "aws_instance", Name: "web", Module: "module.legacy"}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.excluded, m.Excluded(tt.resource), ignore.Address(tt.resource))
+	}
+}
+
+func TestMatcher_Split(t *testing.T) {
+	m, err := ignore.New([]string{"aws_instance.db"})
+	require.NoError(t, err)
+
+	included, excluded := m.Split([]statemanager.StateResource{
+		{Type: "aws_instance", Name: "web"},
+		{Type: "aws_instance", Name: "db"},
+	})
+	assert.Equal(t, []statemanager.StateResource{{Type: "aws_instance", Name: "web"}}, included)
+	assert.Equal(t, []statemanager.StateResource{{Type: "aws_instance", Name: "db"}}, excluded)
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := ignore.New([]string{"aws_instance.["})
+	assert.ErrorContains(t, err, "invalid ignore pattern")
+}
+
+func TestLoad(t *testing.T) {
+	patterns, err := ignore.Load(filepath.Join(t.TempDir(), ".driftignore"))
+	require.NoError(t, err)
+	assert.Empty(t, patterns, "a missing ignore file excludes nothing")
+
+	file := filepath.Join(t.TempDir(), ".driftignore")
+	require.NoError(t, os.WriteFile(file, []byte("aws_instance.web\n"), 0600))
+	patterns, err = ignore.Load(file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_instance.web"}, patterns)
+}
+
+func TestMatcher_Nil(t *testing.T) {
+	var m *ignore.Matcher
+	assert.False(t, m.Excluded(statemanager.StateResource{Type: "aws_instance", Name: "web"}))
+}
@@ -0,0 +1,199 @@
+// Package incremental remembers the serial of every state file scanned and the
+// attributes every resource had in state when it was last checked, so a repeat scan
+// can skip the resources that have not changed in state since.
+package incremental
+
+import (
+	"crypto/sha256"
+	"drift-watcher/pkg/services/statemanager"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long the last live check of an unchanged resource is trusted by
+// default.
+const DefaultTTL = 24 * time.Hour
+
+// Cache holds the last scan of every state file. It is safe for concurrent use, so
+// the scans of several resource types of a state can share it.
+type Cache struct {
+	// File is the JSON file the cache is loaded from and saved to.
+	File string
+	// TTL is how long the last live check of a resource is trusted. A resource whose
+	// last check is older is checked again even if it has not changed in state. Zero
+	// trusts it forever.
+	TTL time.Duration
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*stateRecord
+}
+
+type stateRecord struct {
+	Lineage   string                    `json:"lineage,omitempty"`
+	Serial    int                       `json:"serial"`
+	Resources map[string]resourceRecord `json:"resources"`
+}
+
+type resourceRecord struct {
+	Digest    string    `json:"digest"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DefaultFile returns the file the cache is kept in by default, or an empty string
+// when no user cache directory is available.
+func DefaultFile() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "driftwatcher", "incremental.json")
+}
+
+// Open loads the cache kept in file. A missing file is an empty cache.
+func Open(file string, ttl time.Duration) (*Cache, error) {
+	c := &Cache{File: file, TTL: ttl, Now: time.Now, states: map[string]*stateRecord{}}
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incremental scan cache: %w", err)
+	}
+	if err := json.Unmarshal(content, &c.states); err != nil {
+		return nil, fmt.Errorf("failed to parse incremental scan cache %s: %w", file, err)
+	}
+	return c, nil
+}
+
+// Save writes the cache to its file.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	content, err := json.MarshalIndent(c.states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal incremental scan cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.File), 0755); err != nil {
+		return fmt.Errorf("failed to create incremental scan cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.File, content, 0644); err != nil {
+		return fmt.Errorf("failed to write incremental scan cache: %w", err)
+	}
+	return nil
+}
+
+// Scan is a scan of a state snapshot against the cache.
+type Scan struct {
+	// PreviousSerial is the serial of the state when it was last scanned, zero when
+	// it was never scanned or its lineage changed since.
+	PreviousSerial int
+
+	cache      *Cache
+	key        string
+	attributes []string
+}
+
+// Scan starts a scan of the state read from statePath, with the given lineage and
+// serial, checking attributes. The serial is recorded as the last one scanned. A
+// state whose lineage changed is a new state, and its previous records are dropped.
+func (c *Cache) Scan(statePath string, lineage string, serial int, attributes []string) *Scan {
+	key := statePath
+	if statePath == statemanager.StdinStatePath {
+		// states read from stdin can only be told apart by their lineage
+		key = "stdin:" + lineage
+	} else if !strings.Contains(statePath, "://") {
+		if abs, err := filepath.Abs(statePath); err == nil {
+			key = abs
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scan := &Scan{cache: c, key: key, attributes: attributes}
+	record, ok := c.states[key]
+	if ok && record.Lineage == lineage {
+		scan.PreviousSerial = record.Serial
+	} else {
+		record = &stateRecord{Lineage: lineage, Resources: map[string]resourceRecord{}}
+		c.states[key] = record
+	}
+	if record.Resources == nil {
+		record.Resources = map[string]resourceRecord{}
+	}
+	record.Serial = serial
+	return scan
+}
+
+// Split splits resources into those that changed in state since they were last
+// checked, or whose last check is older than the TTL, and those that did not. A
+// resource also counts as changed when the scan checks other attributes than its
+// last check did.
+func (s *Scan) Split(resources []statemanager.StateResource) (changed, unchanged []statemanager.StateResource) {
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	record := s.cache.states[s.key]
+	now := s.cache.now()
+	for _, resource := range resources {
+		last, ok := record.Resources[resource.Address()]
+		switch {
+		case !ok, last.Digest == "", last.Digest != s.digest(resource):
+			changed = append(changed, resource)
+		case s.cache.TTL > 0 && now.Sub(last.CheckedAt) >= s.cache.TTL:
+			changed = append(changed, resource)
+		default:
+			unchanged = append(unchanged, resource)
+		}
+	}
+	return changed, unchanged
+}
+
+// Checked records that resource was checked now and whether it was found in sync with
+// its state. Only resources in sync are recorded: the record of a drifted resource is
+// dropped, so that the next scan checks it again and reports its drift even if its
+// state did not change. A nil Scan records nothing.
+func (s *Scan) Checked(resource statemanager.StateResource, inSync bool) {
+	if s == nil {
+		return
+	}
+	digest := s.digest(resource)
+
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+	if !inSync {
+		delete(s.cache.states[s.key].Resources, resource.Address())
+		return
+	}
+	s.cache.states[s.key].Resources[resource.Address()] = resourceRecord{Digest: digest, CheckedAt: s.cache.now()}
+}
+
+// digest identifies the state attributes of resource and the attributes checked.
+func (s *Scan) digest(resource statemanager.StateResource) string {
+	encoded, err := json.Marshal(struct {
+		Attributes []string                        `json:"attributes"`
+		Instances  []statemanager.ResourceInstance `json:"instances"`
+	}{s.attributes, resource.Instances})
+	if err != nil {
+		// a resource that cannot be encoded is never considered unchanged
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
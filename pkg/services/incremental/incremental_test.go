@@ -0,0 +1,113 @@
+package incremental_test
+
+import (
+	"drift-watcher/pkg/services/incremental"
+	"drift-watcher/pkg/services/statemanager"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func instance(name string, instanceType string) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type: "aws_instance",
+		Name: name,
+		Instances: []statemanager.ResourceInstance{
+			{Attributes: map[string]any{"instance_type": instanceType}},
+		},
+	}
+}
+
+func names(resources []statemanager.StateResource) []string {
+	var out []string
+	for _, r := range resources {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestScan_SkipsUnchangedResources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "incremental.json")
+	cache, err := incremental.Open(file, time.Hour)
+	require.NoError(t, err)
+
+	attributes := []string{"instance_type"}
+	scan := cache.Scan("prod.tfstate", "lineage-1", 1, attributes)
+	assert.Equal(t, 0, scan.PreviousSerial)
+	changed, unchanged := scan.Split([]statemanager.StateResource{instance("web", "t2.micro"), instance("api", "t2.micro")})
+	assert.Equal(t, []string{"web", "api"}, names(changed), "nothing was checked yet")
+	assert.Empty(t, unchanged)
+	scan.Checked(instance("web", "t2.micro"), true)
+	scan.Checked(instance("api", "t2.micro"), true)
+	require.NoError(t, cache.Save())
+
+	reopened, err := incremental.Open(file, time.Hour)
+	require.NoError(t, err)
+	scan = reopened.Scan("prod.tfstate", "lineage-1", 2, attributes)
+	assert.Equal(t, 1, scan.PreviousSerial)
+	changed, unchanged = scan.Split([]statemanager.StateResource{instance("web", "t2.large"), instance("api", "t2.micro")})
+	assert.Equal(t, []string{"web"}, names(changed))
+	assert.Equal(t, []string{"api"}, names(unchanged))
+
+	scan = reopened.Scan("prod.tfstate", "lineage-1", 2, []string{"instance_type", "ami"})
+	changed, _ = scan.Split([]statemanager.StateResource{instance("api", "t2.micro")})
+	assert.Equal(t, []string{"api"}, names(changed), "other attributes are checked")
+}
+
+func TestScan_DriftedResourcesAreCheckedAgain(t *testing.T) {
+	cache, err := incremental.Open(filepath.Join(t.TempDir(), "incremental.json"), 0)
+	require.NoError(t, err)
+
+	scan := cache.Scan("prod.tfstate", "lineage-1", 1, nil)
+	scan.Checked(instance("web", "t2.micro"), false)
+	changed, _ := scan.Split([]statemanager.StateResource{instance("web", "t2.micro")})
+	assert.Len(t, changed, 1, "a drifted resource is not recorded as unchanged")
+
+	scan.Checked(instance("web", "t2.micro"), true)
+	scan.Checked(instance("web", "t2.micro"), false)
+	changed, _ = scan.Split([]statemanager.StateResource{instance("web", "t2.micro")})
+	assert.Len(t, changed, 1, "the record of a resource that drifted since is dropped")
+}
+
+func TestScan_ExpiredCheck(t *testing.T) {
+	cache, err := incremental.Open(filepath.Join(t.TempDir(), "incremental.json"), time.Hour)
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.Now = func() time.Time { return now }
+
+	scan := cache.Scan("prod.tfstate", "lineage-1", 1, nil)
+	scan.Checked(instance("web", "t2.micro"), true)
+
+	now = now.Add(59 * time.Minute)
+	_, unchanged := scan.Split([]statemanager.StateResource{instance("web", "t2.micro")})
+	assert.Len(t, unchanged, 1)
+
+	now = now.Add(time.Minute)
+	changed, _ := scan.Split([]statemanager.StateResource{instance("web", "t2.micro")})
+	assert.Len(t, changed, 1, "the last check is older than the TTL")
+}
+
+func TestScan_NewLineage(t *testing.T) {
+	cache, err := incremental.Open(filepath.Join(t.TempDir(), "incremental.json"), 0)
+	require.NoError(t, err)
+
+	scan := cache.Scan("prod.tfstate", "lineage-1", 7, nil)
+	scan.Checked(instance("web", "t2.micro"), true)
+
+	scan = cache.Scan("prod.tfstate", "lineage-2", 1, nil)
+	assert.Equal(t, 0, scan.PreviousSerial)
+	changed, _ := scan.Split([]statemanager.StateResource{instance("web", "t2.micro")})
+	assert.Len(t, changed, 1, "a state with a new lineage is a new state")
+}
+
+func TestOpen_InvalidFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "incremental.json")
+	require.NoError(t, os.WriteFile(file, []byte("not json"), 0644))
+
+	_, err := incremental.Open(file, time.Hour)
+	assert.ErrorContains(t, err, "failed to parse incremental scan cache")
+}
@@ -0,0 +1,117 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used to hold locks.
+//
+//counterfeiter:generate . DynamoDBAPI
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBLocker implements Locker with an item per lock in a DynamoDB table whose
+// partition key is the string attribute lock_key. Locks are taken with conditional
+// writes, so only one owner holds a lock at a time. The expiry is stored in the
+// number attribute expires_at, in Unix seconds, which can be enabled as the table's
+// time to live attribute to clean up the locks of schedules no longer served.
+type DynamoDBLocker struct {
+	Client DynamoDBAPI
+	Table  string
+	Owner  string
+	// Region is the region of the table, the region of the default AWS configuration
+	// when empty.
+	Region string
+
+	mu sync.Mutex
+}
+
+// Acquire implements Locker.
+func (d *DynamoDBLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	client, err := d.client(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(math.Ceil(ttl.Seconds())) * time.Second)
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item: map[string]types.AttributeValue{
+			"lock_key":   &types.AttributeValueMemberS{Value: key},
+			"owner":      &types.AttributeValueMemberS{Value: d.Owner},
+			"expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #owner = :owner OR #expires_at < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":        "lock_key",
+			"#owner":      "owner",
+			"#expires_at": "expires_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: d.Owner},
+			":now":   &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s in %s: %w", key, d.Table, err)
+	}
+	return true, nil
+}
+
+// Release implements Locker.
+func (d *DynamoDBLocker) Release(ctx context.Context, key string) error {
+	client, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(d.Table),
+		Key:                       map[string]types.AttributeValue{"lock_key": &types.AttributeValueMemberS{Value: key}},
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  map[string]string{"#owner": "owner"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":owner": &types.AttributeValueMemberS{Value: d.Owner}},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &conditionFailed) {
+		return fmt.Errorf("failed to release lock %s in %s: %w", key, d.Table, err)
+	}
+	return nil
+}
+
+// client returns the configured client, creating one from the default AWS
+// configuration when none is set.
+func (d *DynamoDBLocker) client(ctx context.Context) (DynamoDBAPI, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Client != nil {
+		return d.Client, nil
+	}
+
+	var loadOptions []func(*aConfig.LoadOptions) error
+	if d.Region != "" {
+		loadOptions = append(loadOptions, aConfig.WithRegion(d.Region))
+	}
+	awsConfig, err := aConfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	d.Client = dynamodb.NewFromConfig(awsConfig)
+	return d.Client, nil
+}
@@ -0,0 +1,63 @@
+package lock_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/lock"
+	"drift-watcher/pkg/services/lock/lockfakes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDBLocker_Acquire(t *testing.T) {
+	client := &lockfakes.FakeDynamoDBAPI{}
+	locker := &lock.DynamoDBLocker{Client: client, Table: "locks", Owner: "server-1"}
+
+	acquired, err := locker.Acquire(context.Background(), "schedule/nightly", 90*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	require.Equal(t, 1, client.PutItemCallCount())
+	_, input, _ := client.PutItemArgsForCall(0)
+	assert.Equal(t, "locks", aws.ToString(input.TableName))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "schedule/nightly"}, input.Item["lock_key"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "server-1"}, input.Item["owner"])
+	expiresAt, err := strconv.ParseInt(input.Item["expires_at"].(*types.AttributeValueMemberN).Value, 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(90*time.Second).Unix(), expiresAt, 2)
+	assert.Equal(t, "attribute_not_exists(#key) OR #owner = :owner OR #expires_at < :now", aws.ToString(input.ConditionExpression))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "server-1"}, input.ExpressionAttributeValues[":owner"])
+
+	client.PutItemReturns(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")})
+	acquired, err = locker.Acquire(context.Background(), "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	client.PutItemReturns(nil, errors.New("ResourceNotFoundException: table not found"))
+	_, err = locker.Acquire(context.Background(), "schedule/nightly", time.Minute)
+	assert.ErrorContains(t, err, "failed to acquire lock schedule/nightly in locks")
+}
+
+func TestDynamoDBLocker_Release(t *testing.T) {
+	client := &lockfakes.FakeDynamoDBAPI{}
+	locker := &lock.DynamoDBLocker{Client: client, Table: "locks", Owner: "server-1"}
+
+	require.NoError(t, locker.Release(context.Background(), "schedule/nightly"))
+	require.Equal(t, 1, client.DeleteItemCallCount())
+	_, input, _ := client.DeleteItemArgsForCall(0)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "schedule/nightly"}, input.Key["lock_key"])
+	assert.Equal(t, "#owner = :owner", aws.ToString(input.ConditionExpression))
+
+	// a lock held by another owner is left alone
+	client.DeleteItemReturns(nil, &types.ConditionalCheckFailedException{})
+	require.NoError(t, locker.Release(context.Background(), "schedule/nightly"))
+
+	client.DeleteItemReturns(nil, errors.New("throttled"))
+	assert.ErrorContains(t, locker.Release(context.Background(), "schedule/nightly"), "failed to release lock")
+}
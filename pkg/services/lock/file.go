@@ -0,0 +1,134 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileLocker implements Locker with a lock file per key in a directory, such as a
+// network file system mounted by every server. A lock is acquired by linking a file
+// holding its owner and expiry into place, which fails when the lock file exists.
+//
+// An expired lock file is replaced rather than linked, so two servers finding the same
+// expired lock at the same moment may both take it over. Use a DynamoDBLocker when
+// that matters.
+type FileLocker struct {
+	dir   string
+	owner string
+}
+
+// fileLock is the content of a lock file.
+type fileLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewFileLocker creates a locker for owner keeping its lock files in dir, which is
+// created if it does not exist.
+func NewFileLocker(dir string, owner string) (*FileLocker, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("a lock directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory %s: %w", dir, err)
+	}
+	return &FileLocker{dir: dir, owner: owner}, nil
+}
+
+// Acquire implements Locker.
+func (f *FileLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	path := f.path(key)
+	temp, err := f.writeTemp(fileLock{Owner: f.owner, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(temp)
+
+	err = os.Link(temp, path)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, fs.ErrExist) {
+		return false, fmt.Errorf("failed to create lock file %s: %w", path, err)
+	}
+
+	current, err := readFileLock(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// released since the link failed
+		return f.Acquire(ctx, key, ttl)
+	}
+	if err != nil {
+		return false, err
+	}
+	if current.Owner != f.owner && time.Now().Before(current.ExpiresAt) {
+		return false, nil
+	}
+	// renew our own lock or take over an expired one
+	if err := os.Rename(temp, path); err != nil {
+		return false, fmt.Errorf("failed to write lock file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Release implements Locker.
+func (f *FileLocker) Release(ctx context.Context, key string) error {
+	path := f.path(key)
+	current, err := readFileLock(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Owner != f.owner {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file %s: %w", path, err)
+	}
+	return nil
+}
+
+// path returns the lock file of key, escaped so any key is a valid file name.
+func (f *FileLocker) path(key string) string {
+	return filepath.Join(f.dir, url.QueryEscape(key)+".lock")
+}
+
+func (f *FileLocker) writeTemp(lock fileLock) (string, error) {
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(f.dir, ".lock-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create lock file: %w", err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+func readFileLock(path string) (fileLock, error) {
+	var lock fileLock
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("invalid lock file %s: %w", path, err)
+	}
+	return lock, nil
+}
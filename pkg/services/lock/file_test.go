@@ -0,0 +1,92 @@
+package lock_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/lock"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLocker(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, err := lock.NewFileLocker(dir, "server-1")
+	require.NoError(t, err)
+	second, err := lock.NewFileLocker(dir, "server-2")
+	require.NoError(t, err)
+
+	acquired, err := first.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = second.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "held by the first owner")
+
+	acquired, err = first.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "renewed by its owner")
+
+	acquired, err = second.Acquire(ctx, "schedule/hourly", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "locks are independent")
+
+	// releasing a lock held by another owner does nothing
+	require.NoError(t, second.Release(ctx, "schedule/nightly"))
+	acquired, err = second.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, first.Release(ctx, "schedule/nightly"))
+	require.NoError(t, first.Release(ctx, "schedule/nightly"))
+	acquired, err = second.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// only lock files are left in the directory
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"schedule%2Fhourly.lock", "schedule%2Fnightly.lock"}, names)
+}
+
+func TestFileLocker_TakesOverExpiredLock(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, err := lock.NewFileLocker(dir, "server-1")
+	require.NoError(t, err)
+	second, err := lock.NewFileLocker(dir, "server-2")
+	require.NoError(t, err)
+
+	acquired, err := first.Acquire(ctx, "schedule/nightly", -time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = second.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = first.Acquire(ctx, "schedule/nightly", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+}
+
+func TestFileLocker_InvalidLockFile(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := lock.NewFileLocker(dir, "server-1")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nightly.lock"), []byte("{"), 0644))
+
+	_, err = locker.Acquire(context.Background(), "nightly", time.Minute)
+	assert.ErrorContains(t, err, "invalid lock file")
+
+	_, err = lock.NewFileLocker("", "server-1")
+	assert.ErrorContains(t, err, "a lock directory is required")
+}
@@ -0,0 +1,62 @@
+// Package lock provides the locks that let several driftwatcher servers run the same
+// schedules for high availability while only one of them runs each scheduled scan.
+// Locks expire after a time to live, so a lock held by a server that died is taken
+// over once it expires, and are renewed by their owner while it needs them.
+package lock
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Locker acquires named locks on behalf of a single owner.
+//
+//counterfeiter:generate . Locker
+type Locker interface {
+	// Acquire takes the lock key for ttl, or extends it when the owner already holds
+	// it. It returns false when the lock is held by another owner and has not expired.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release gives up the lock key if the owner holds it.
+	Release(ctx context.Context, key string) error
+}
+
+// DefaultOwner identifies this process as the owner of its locks: the host name and
+// process id, to tell in a lock who holds it, and a random suffix.
+func DefaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
+
+// New returns the locker selected by uri for owner: dynamodb://<table> for a DynamoDB
+// table, optionally with ?region=<region>, or file://<dir> or a plain directory path
+// for lock files in a directory shared by the servers.
+func New(uri string, owner string) (Locker, error) {
+	if !strings.Contains(uri, "://") {
+		return NewFileLocker(uri, owner)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock URI %s: %w", uri, err)
+	}
+	switch u.Scheme {
+	case "file":
+		return NewFileLocker(u.Host+u.Path, owner)
+	case "dynamodb":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid lock URI %s: a table name is required", uri)
+		}
+		return &DynamoDBLocker{Table: u.Host, Owner: owner, Region: u.Query().Get("region")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported lock URI %s, expected dynamodb://<table> or file://<dir>", uri)
+	}
+}
@@ -0,0 +1,36 @@
+package lock_test
+
+import (
+	"drift-watcher/pkg/services/lock"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	locker, err := lock.New("dynamodb://locks?region=eu-west-1", "server-1")
+	require.NoError(t, err)
+	assert.Equal(t, &lock.DynamoDBLocker{Table: "locks", Owner: "server-1", Region: "eu-west-1"}, locker)
+
+	locker, err = lock.New("file://"+filepath.Join(dir, "a"), "server-1")
+	require.NoError(t, err)
+	assert.IsType(t, &lock.FileLocker{}, locker)
+	assert.DirExists(t, filepath.Join(dir, "a"))
+
+	locker, err = lock.New(filepath.Join(dir, "b"), "server-1")
+	require.NoError(t, err)
+	assert.IsType(t, &lock.FileLocker{}, locker)
+
+	_, err = lock.New("dynamodb://", "server-1")
+	assert.ErrorContains(t, err, "a table name is required")
+	_, err = lock.New("redis://localhost", "server-1")
+	assert.ErrorContains(t, err, "unsupported lock URI redis://localhost")
+}
+
+func TestDefaultOwner(t *testing.T) {
+	assert.NotEqual(t, lock.DefaultOwner(), lock.DefaultOwner())
+}
@@ -0,0 +1,205 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package lockfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/lock"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type FakeDynamoDBAPI struct {
+	DeleteItemStub        func(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	deleteItemMutex       sync.RWMutex
+	deleteItemArgsForCall []struct {
+		arg1 context.Context
+		arg2 *dynamodb.DeleteItemInput
+		arg3 []func(*dynamodb.Options)
+	}
+	deleteItemReturns struct {
+		result1 *dynamodb.DeleteItemOutput
+		result2 error
+	}
+	deleteItemReturnsOnCall map[int]struct {
+		result1 *dynamodb.DeleteItemOutput
+		result2 error
+	}
+	PutItemStub        func(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	putItemMutex       sync.RWMutex
+	putItemArgsForCall []struct {
+		arg1 context.Context
+		arg2 *dynamodb.PutItemInput
+		arg3 []func(*dynamodb.Options)
+	}
+	putItemReturns struct {
+		result1 *dynamodb.PutItemOutput
+		result2 error
+	}
+	putItemReturnsOnCall map[int]struct {
+		result1 *dynamodb.PutItemOutput
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItem(arg1 context.Context, arg2 *dynamodb.DeleteItemInput, arg3 ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	fake.deleteItemMutex.Lock()
+	ret, specificReturn := fake.deleteItemReturnsOnCall[len(fake.deleteItemArgsForCall)]
+	fake.deleteItemArgsForCall = append(fake.deleteItemArgsForCall, struct {
+		arg1 context.Context
+		arg2 *dynamodb.DeleteItemInput
+		arg3 []func(*dynamodb.Options)
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteItemStub
+	fakeReturns := fake.deleteItemReturns
+	fake.recordInvocation("DeleteItem", []interface{}{arg1, arg2, arg3})
+	fake.deleteItemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItemCallCount() int {
+	fake.deleteItemMutex.RLock()
+	defer fake.deleteItemMutex.RUnlock()
+	return len(fake.deleteItemArgsForCall)
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItemCalls(stub func(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)) {
+	fake.deleteItemMutex.Lock()
+	defer fake.deleteItemMutex.Unlock()
+	fake.DeleteItemStub = stub
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItemArgsForCall(i int) (context.Context, *dynamodb.DeleteItemInput, []func(*dynamodb.Options)) {
+	fake.deleteItemMutex.RLock()
+	defer fake.deleteItemMutex.RUnlock()
+	argsForCall := fake.deleteItemArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItemReturns(result1 *dynamodb.DeleteItemOutput, result2 error) {
+	fake.deleteItemMutex.Lock()
+	defer fake.deleteItemMutex.Unlock()
+	fake.DeleteItemStub = nil
+	fake.deleteItemReturns = struct {
+		result1 *dynamodb.DeleteItemOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDynamoDBAPI) DeleteItemReturnsOnCall(i int, result1 *dynamodb.DeleteItemOutput, result2 error) {
+	fake.deleteItemMutex.Lock()
+	defer fake.deleteItemMutex.Unlock()
+	fake.DeleteItemStub = nil
+	if fake.deleteItemReturnsOnCall == nil {
+		fake.deleteItemReturnsOnCall = make(map[int]struct {
+			result1 *dynamodb.DeleteItemOutput
+			result2 error
+		})
+	}
+	fake.deleteItemReturnsOnCall[i] = struct {
+		result1 *dynamodb.DeleteItemOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDynamoDBAPI) PutItem(arg1 context.Context, arg2 *dynamodb.PutItemInput, arg3 ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	fake.putItemMutex.Lock()
+	ret, specificReturn := fake.putItemReturnsOnCall[len(fake.putItemArgsForCall)]
+	fake.putItemArgsForCall = append(fake.putItemArgsForCall, struct {
+		arg1 context.Context
+		arg2 *dynamodb.PutItemInput
+		arg3 []func(*dynamodb.Options)
+	}{arg1, arg2, arg3})
+	stub := fake.PutItemStub
+	fakeReturns := fake.putItemReturns
+	fake.recordInvocation("PutItem", []interface{}{arg1, arg2, arg3})
+	fake.putItemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDynamoDBAPI) PutItemCallCount() int {
+	fake.putItemMutex.RLock()
+	defer fake.putItemMutex.RUnlock()
+	return len(fake.putItemArgsForCall)
+}
+
+func (fake *FakeDynamoDBAPI) PutItemCalls(stub func(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)) {
+	fake.putItemMutex.Lock()
+	defer fake.putItemMutex.Unlock()
+	fake.PutItemStub = stub
+}
+
+func (fake *FakeDynamoDBAPI) PutItemArgsForCall(i int) (context.Context, *dynamodb.PutItemInput, []func(*dynamodb.Options)) {
+	fake.putItemMutex.RLock()
+	defer fake.putItemMutex.RUnlock()
+	argsForCall := fake.putItemArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDynamoDBAPI) PutItemReturns(result1 *dynamodb.PutItemOutput, result2 error) {
+	fake.putItemMutex.Lock()
+	defer fake.putItemMutex.Unlock()
+	fake.PutItemStub = nil
+	fake.putItemReturns = struct {
+		result1 *dynamodb.PutItemOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDynamoDBAPI) PutItemReturnsOnCall(i int, result1 *dynamodb.PutItemOutput, result2 error) {
+	fake.putItemMutex.Lock()
+	defer fake.putItemMutex.Unlock()
+	fake.PutItemStub = nil
+	if fake.putItemReturnsOnCall == nil {
+		fake.putItemReturnsOnCall = make(map[int]struct {
+			result1 *dynamodb.PutItemOutput
+			result2 error
+		})
+	}
+	fake.putItemReturnsOnCall[i] = struct {
+		result1 *dynamodb.PutItemOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDynamoDBAPI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteItemMutex.RLock()
+	defer fake.deleteItemMutex.RUnlock()
+	fake.putItemMutex.RLock()
+	defer fake.putItemMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDynamoDBAPI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ lock.DynamoDBAPI = new(FakeDynamoDBAPI)
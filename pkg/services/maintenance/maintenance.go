@@ -0,0 +1,144 @@
+// Package maintenance flags the drift found during declared maintenance windows as
+// expected, so that planned manual changes are still recorded but page no one.
+//
+// A window covers the resources whose addresses match a glob pattern between a start
+// and an end time. Windows are declared in the config profile or given with
+// --maintenance as PATTERN@START/END[=REASON], where END is a time or a duration from
+// START:
+//
+//	aws_instance.db-*@2026-10-16T22:00:00Z/2026-10-17T02:00:00Z=CHG-1042
+//	module.network.*@2026-10-20T06:00:00Z/90m
+package maintenance
+
+import (
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/ignore"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Window is a maintenance window.
+type Window struct {
+	// Resource is a glob pattern (path.Match syntax) matched against the full address
+	// of resources and against their address without the module path.
+	Resource string
+	Start    time.Time
+	End      time.Time
+	Reason   string
+}
+
+// Parse parses a window given as PATTERN@START/END[=REASON]. START and END are
+// RFC 3339 times; END may also be a duration from START, such as 2h.
+func Parse(s string) (Window, error) {
+	spec, reason, _ := strings.Cut(s, "=")
+	i := strings.LastIndex(spec, "@")
+	if i < 0 {
+		return Window{}, fmt.Errorf("invalid maintenance window %q, expected PATTERN@START/END[=REASON]", s)
+	}
+	start, end, ok := strings.Cut(spec[i+1:], "/")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid maintenance window %q, expected PATTERN@START/END[=REASON]", s)
+	}
+	w := Window{Resource: strings.TrimSpace(spec[:i]), Reason: strings.TrimSpace(reason)}
+	var err error
+	if w.Start, err = time.Parse(time.RFC3339, strings.TrimSpace(start)); err != nil {
+		return Window{}, fmt.Errorf("invalid start of maintenance window %q: %w", s, err)
+	}
+	end = strings.TrimSpace(end)
+	if d, err := time.ParseDuration(end); err == nil {
+		w.End = w.Start.Add(d)
+	} else if w.End, err = time.Parse(time.RFC3339, end); err != nil {
+		return Window{}, fmt.Errorf("invalid end of maintenance window %q, expected a time or a duration: %w", s, err)
+	}
+	return w, nil
+}
+
+// FromConfig returns the window declared in the config profile. The window ends
+// Duration after its start when no end is set.
+func FromConfig(c config.MaintenanceConfig) Window {
+	w := Window{Resource: c.Resource, Start: c.Start, End: c.End, Reason: c.Reason}
+	if w.End.IsZero() {
+		w.End = w.Start.Add(c.Duration)
+	}
+	return w
+}
+
+func (w Window) validate() error {
+	if w.Resource == "" {
+		return errors.New("maintenance window: resource is required")
+	}
+	if _, err := path.Match(w.Resource, ""); err != nil {
+		return fmt.Errorf("maintenance window: invalid resource pattern %q: %w", w.Resource, err)
+	}
+	if w.Start.IsZero() {
+		return fmt.Errorf("maintenance window %s: start is required", w.Resource)
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("maintenance window %s: end must be after start", w.Resource)
+	}
+	return nil
+}
+
+// covers reports whether the window covers the resource with the given addresses at t.
+// The window includes its start and excludes its end.
+func (w Window) covers(full, short string, t time.Time) bool {
+	if t.Before(w.Start) || !t.Before(w.End) {
+		return false
+	}
+	for _, address := range []string{full, short} {
+		if ok, _ := path.Match(w.Resource, address); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule is a set of maintenance windows. A nil Schedule has no windows.
+type Schedule struct {
+	windows []Window
+}
+
+// New creates a new Schedule instance.
+// windows: The maintenance windows. Each needs a resource pattern and an end after
+// its start.
+func New(windows []Window) (*Schedule, error) {
+	for _, w := range windows {
+		if err := w.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Schedule{windows: windows}, nil
+}
+
+// Active returns the first window covering resource at t, or nil when none does.
+func (s *Schedule) Active(resource statemanager.StateResource, t time.Time) *Window {
+	if s == nil {
+		return nil
+	}
+	full := ignore.Address(resource)
+	short := resource.Type + "." + resource.Name
+	for i := range s.windows {
+		if s.windows[i].covers(full, short, t) {
+			return &s.windows[i]
+		}
+	}
+	return nil
+}
+
+// Annotate flags the drift of the report of resource as expected when a window covers
+// the resource at t. Reports without drift are left unchanged.
+func (s *Schedule) Annotate(resource statemanager.StateResource, report *driftchecker.DriftReport, t time.Time) {
+	if report == nil || !report.HasDrift || report.Status == driftchecker.DriftResolved {
+		return
+	}
+	w := s.Active(resource, t)
+	if w == nil {
+		return
+	}
+	report.Maintenance = &driftchecker.MaintenanceWindow{Resource: w.Resource, Start: w.Start, End: w.End, Reason: w.Reason}
+}
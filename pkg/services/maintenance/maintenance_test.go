@@ -0,0 +1,101 @@
+package maintenance_test
+
+import (
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/maintenance"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	w, err := maintenance.Parse("aws_instance.db-*@2026-10-16T22:00:00Z/2026-10-17T02:00:00Z=CHG-1042 failover test")
+	require.NoError(t, err)
+	assert.Equal(t, maintenance.Window{
+		Resource: "aws_instance.db-*",
+		Start:    start,
+		End:      start.Add(4 * time.Hour),
+		Reason:   "CHG-1042 failover test",
+	}, w)
+
+	w, err = maintenance.Parse("module.network.*@2026-10-16T22:00:00Z/90m")
+	require.NoError(t, err)
+	assert.Equal(t, maintenance.Window{Resource: "module.network.*", Start: start, End: start.Add(90 * time.Minute)}, w)
+
+	for value, message := range map[string]string{
+		"aws_instance.web":                         "expected PATTERN@START/END[=REASON]",
+		"aws_instance.web@2026-10-16T22:00:00Z":    "expected PATTERN@START/END[=REASON]",
+		"aws_instance.web@tonight/2h":              "invalid start of maintenance window",
+		"aws_instance.web@2026-10-16T22:00:00Z/ab": "expected a time or a duration",
+	} {
+		_, err := maintenance.Parse(value)
+		assert.ErrorContains(t, err, message, value)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	w := maintenance.FromConfig(config.MaintenanceConfig{Resource: "aws_instance.web", Start: start, Duration: time.Hour, Reason: "resize"})
+	assert.Equal(t, maintenance.Window{Resource: "aws_instance.web", Start: start, End: start.Add(time.Hour), Reason: "resize"}, w)
+}
+
+func TestSchedule_Active(t *testing.T) {
+	s, err := maintenance.New([]maintenance.Window{
+		{Resource: "aws_instance.db-*", Start: start, End: start.Add(time.Hour), Reason: "failover"},
+		{Resource: "module.network.*", Start: start, End: start.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	db := statemanager.StateResource{Type: "aws_instance", Name: "db-1"}
+	assert.Equal(t, "failover", s.Active(db, start).Reason)
+	assert.NotNil(t, s.Active(db, start.Add(59*time.Minute)))
+	assert.Nil(t, s.Active(db, start.Add(-time.Second)), "before the window")
+	assert.Nil(t, s.Active(db, start.Add(time.Hour)), "the end is not part of the window")
+
+	// resources inside modules match by full and by short address
+	assert.NotNil(t, s.Active(statemanager.StateResource{Type: "aws_subnet", Name: "a", Module: "module.network"}, start))
+	assert.NotNil(t, s.Active(statemanager.StateResource{Type: "aws_instance", Name: "db-2", Module: "module.data"}, start))
+	assert.Nil(t, s.Active(statemanager.StateResource{Type: "aws_instance", Name: "web"}, start))
+
+	var none *maintenance.Schedule
+	assert.Nil(t, none.Active(db, start))
+}
+
+func TestSchedule_Annotate(t *testing.T) {
+	s, err := maintenance.New([]maintenance.Window{{Resource: "aws_instance.web", Start: start, End: start.Add(time.Hour), Reason: "resize"}})
+	require.NoError(t, err)
+	resource := statemanager.StateResource{Type: "aws_instance", Name: "web"}
+
+	drifted := &driftchecker.DriftReport{HasDrift: true, Status: driftchecker.Drift}
+	s.Annotate(resource, drifted, start.Add(time.Minute))
+	assert.Equal(t, &driftchecker.MaintenanceWindow{Resource: "aws_instance.web", Start: start, End: start.Add(time.Hour), Reason: "resize"}, drifted.Maintenance)
+	assert.True(t, drifted.Expected())
+
+	matching := &driftchecker.DriftReport{Status: driftchecker.Match}
+	s.Annotate(resource, matching, start.Add(time.Minute))
+	assert.False(t, matching.Expected(), "reports without drift are not annotated")
+
+	late := &driftchecker.DriftReport{HasDrift: true, Status: driftchecker.Drift}
+	s.Annotate(resource, late, start.Add(2*time.Hour))
+	assert.False(t, late.Expected())
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		window  maintenance.Window
+		message string
+	}{
+		{maintenance.Window{Start: start, End: start.Add(time.Hour)}, "resource is required"},
+		{maintenance.Window{Resource: "aws_instance.[", Start: start, End: start.Add(time.Hour)}, "invalid resource pattern"},
+		{maintenance.Window{Resource: "aws_instance.web", End: start}, "start is required"},
+		{maintenance.Window{Resource: "aws_instance.web", Start: start, End: start}, "end must be after start"},
+	} {
+		_, err := maintenance.New([]maintenance.Window{tt.window})
+		assert.ErrorContains(t, err, tt.message)
+	}
+}
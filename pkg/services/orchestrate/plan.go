@@ -0,0 +1,230 @@
+// Package orchestrate describes drift scans that span many targets, each an account
+// or cluster with its own credentials, region and state, and merges their results
+// into a single report keyed by target.
+package orchestrate
+
+import (
+	"bytes"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/schedule"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConcurrency is the number of targets scanned at the same time when the
+// plan does not set one.
+const DefaultConcurrency = 4
+
+// DefaultProvider is the provider of a target that does not name one.
+const DefaultProvider = "aws"
+
+// Resource selects a resource type of a target and the attributes checked for it.
+type Resource struct {
+	Type       string   `yaml:"type"`
+	Attributes []string `yaml:"attributes"`
+}
+
+// Target is a single scan of an orchestration plan.
+type Target struct {
+	// Name identifies the target in the merged report. It must be unique.
+	Name string `yaml:"name"`
+	// Provider is the platform provider of the target, aws unless set.
+	Provider string `yaml:"provider"`
+	// AWSProfile is the shared config profile the AWS credentials are read from.
+	AWSProfile string `yaml:"aws_profile"`
+	// RoleARN is an IAM role assumed with the profile's credentials, typically a
+	// read-only role in the target account.
+	RoleARN string `yaml:"role_arn"`
+	// Region overrides the region of the profile.
+	Region string `yaml:"region"`
+	// Partition is the AWS partition of the account, e.g. aws-us-gov, and FIPS sends
+	// calls to the FIPS 140 endpoints of AWS services.
+	Partition string `yaml:"partition"`
+	FIPS      bool   `yaml:"fips"`
+	// Kubeconfig and KubeContext select the cluster of a kubernetes target.
+	Kubeconfig  string `yaml:"kubeconfig"`
+	KubeContext string `yaml:"kube_context"`
+	// State is the path or remote URI of the target's state file.
+	State string `yaml:"state"`
+	// Concurrency is the number of resources of the target checked in parallel.
+	// Zero uses the detect default.
+	Concurrency int `yaml:"concurrency"`
+	// Resources lists the resource types checked for the target.
+	Resources []Resource `yaml:"resources"`
+}
+
+// Schedule scans targets of the plan on a cron schedule when the plan is served with
+// the serve command.
+type Schedule struct {
+	// Name identifies the schedule when pausing it or listing its runs. It must be
+	// unique.
+	Name string `yaml:"name"`
+	// Cron is the cron expression of the schedule, e.g. "0 * * * *" for hourly.
+	Cron string `yaml:"cron"`
+	// Targets names the targets scanned by the schedule; every target when empty.
+	Targets []string `yaml:"targets"`
+}
+
+// Plan is an orchestration file declaring the targets of a scan.
+type Plan struct {
+	// Concurrency is the number of targets scanned at the same time.
+	Concurrency int `yaml:"concurrency"`
+	// Targets are the scans of the plan.
+	Targets []Target `yaml:"targets"`
+	// Schedules are the schedules the targets are scanned on in serve mode.
+	Schedules []Schedule `yaml:"schedules"`
+}
+
+// ScheduleTargets returns the targets scanned by the schedule s.
+func (p *Plan) ScheduleTargets(s Schedule) []Target {
+	if len(s.Targets) == 0 {
+		return p.Targets
+	}
+	var targets []Target
+	for _, target := range p.Targets {
+		if slices.Contains(s.Targets, target.Name) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// Load reads and validates the plan at filePath.
+func Load(filePath string) (*Plan, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orchestration file: %w", err)
+	}
+	plan, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return plan, nil
+}
+
+// Parse decodes and validates a YAML plan. Unknown keys are rejected so that a
+// misspelled setting is not silently ignored.
+func Parse(data []byte) (*Plan, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	plan := &Plan{}
+	if err := decoder.Decode(plan); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse orchestration file: %w", err)
+	}
+	if err := plan.validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// validate checks the plan and fills in defaults.
+func (p *Plan) validate() error {
+	if p.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+	if p.Concurrency == 0 {
+		p.Concurrency = DefaultConcurrency
+	}
+	if len(p.Targets) == 0 {
+		return fmt.Errorf("no targets declared")
+	}
+
+	seen := map[string]bool{}
+	for i := range p.Targets {
+		target := &p.Targets[i]
+		if target.Name == "" {
+			return fmt.Errorf("target %d: name is required", i+1)
+		}
+		if seen[target.Name] {
+			return fmt.Errorf("target %s: declared more than once", target.Name)
+		}
+		seen[target.Name] = true
+
+		if target.Provider == "" {
+			target.Provider = DefaultProvider
+		}
+		if target.State == "" {
+			return fmt.Errorf("target %s: state is required", target.Name)
+		}
+		if target.State == "-" {
+			return fmt.Errorf("target %s: reading the state from stdin is not supported", target.Name)
+		}
+		if target.Concurrency < 0 {
+			return fmt.Errorf("target %s: concurrency must not be negative", target.Name)
+		}
+		if len(target.Resources) == 0 {
+			return fmt.Errorf("target %s: no resources declared", target.Name)
+		}
+		for _, resource := range target.Resources {
+			if resource.Type == "" {
+				return fmt.Errorf("target %s: resource type is required", target.Name)
+			}
+			if len(resource.Attributes) == 0 {
+				return fmt.Errorf("target %s: no attributes declared for %s", target.Name, resource.Type)
+			}
+		}
+	}
+
+	schedules := map[string]bool{}
+	for i, s := range p.Schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedule %d: name is required", i+1)
+		}
+		if schedules[s.Name] {
+			return fmt.Errorf("schedule %s: declared more than once", s.Name)
+		}
+		schedules[s.Name] = true
+		if _, err := schedule.ParseCron(s.Cron); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		for _, name := range s.Targets {
+			if !seen[name] {
+				return fmt.Errorf("schedule %s: unknown target %s", s.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Target statuses of the merged report.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// TargetResult holds the reports of a single target.
+type TargetResult struct {
+	Status  string                      `json:"status"`
+	Error   string                      `json:"error,omitempty"`
+	Drifted int                         `json:"drifted"`
+	Reports []*driftchecker.DriftReport `json:"reports"`
+}
+
+// Result is the merged report of an orchestrated scan, keyed by target name.
+type Result struct {
+	// SchemaVersion is the driftchecker.SchemaVersion of the reports of every target.
+	SchemaVersion int       `json:"schema_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	// RunId is the run id stamped on the reports of every target.
+	RunId   string                   `json:"run_id,omitempty"`
+	Targets map[string]*TargetResult `json:"targets"`
+}
+
+// Failed returns the sorted names of the targets that could not be scanned.
+func (r *Result) Failed() []string {
+	var failed []string
+	for name, target := range r.Targets {
+		if target.Status == StatusError {
+			failed = append(failed, name)
+		}
+	}
+	slices.Sort(failed)
+	return failed
+}
@@ -0,0 +1,120 @@
+package orchestrate_test
+
+import (
+	"drift-watcher/pkg/services/orchestrate"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	plan, err := orchestrate.Parse([]byte(`
+targets:
+  - name: prod
+    aws_profile: platform
+    role_arn: arn:aws:iam::111111111111:role/drift-readonly
+    region: us-east-1
+    state: s3://states/prod/terraform.tfstate
+    concurrency: 10
+    resources:
+      - type: aws_instance
+        attributes: [instance_type, tags.Env]
+  - name: cluster
+    provider: kubernetes
+    kube_context: prod
+    state: cluster.tfstate
+    resources:
+      - type: kubernetes_deployment
+        attributes: [spec.replicas]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, orchestrate.DefaultConcurrency, plan.Concurrency)
+	require.Len(t, plan.Targets, 2)
+	assert.Equal(t, orchestrate.Target{
+		Name:        "prod",
+		Provider:    "aws",
+		AWSProfile:  "platform",
+		RoleARN:     "arn:aws:iam::111111111111:role/drift-readonly",
+		Region:      "us-east-1",
+		State:       "s3://states/prod/terraform.tfstate",
+		Concurrency: 10,
+		Resources: []orchestrate.Resource{
+			{Type: "aws_instance", Attributes: []string{"instance_type", "tags.Env"}},
+		},
+	}, plan.Targets[0])
+	assert.Equal(t, "kubernetes", plan.Targets[1].Provider)
+	assert.Equal(t, "prod", plan.Targets[1].KubeContext)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	resources := "\n    resources:\n      - type: aws_instance\n        attributes: [instance_type]"
+	tests := []struct {
+		name string
+		plan string
+		err  string
+	}{
+		{"no targets", "concurrency: 2", "no targets declared"},
+		{"negative concurrency", "concurrency: -1", "concurrency must not be negative"},
+		{"missing name", "targets:\n  - state: a.tfstate" + resources, "target 1: name is required"},
+		{"duplicate name", "targets:\n  - name: a\n    state: a.tfstate" + resources + "\n  - name: a\n    state: b.tfstate" + resources, "target a: declared more than once"},
+		{"missing state", "targets:\n  - name: a" + resources, "target a: state is required"},
+		{"stdin state", "targets:\n  - name: a\n    state: '-'" + resources, "reading the state from stdin is not supported"},
+		{"no resources", "targets:\n  - name: a\n    state: a.tfstate", "target a: no resources declared"},
+		{"no attributes", "targets:\n  - name: a\n    state: a.tfstate\n    resources:\n      - type: aws_instance", "no attributes declared for aws_instance"},
+		{"unknown key", "targets:\n  - name: a\n    regoin: us-east-1", "field regoin not found"},
+		{"missing schedule name", "targets:\n  - name: a\n    state: a.tfstate" + resources + "\nschedules:\n  - cron: '@hourly'", "schedule 1: name is required"},
+		{"duplicate schedule", "targets:\n  - name: a\n    state: a.tfstate" + resources + "\nschedules:\n  - name: s\n    cron: '@hourly'\n  - name: s\n    cron: '@daily'", "schedule s: declared more than once"},
+		{"invalid cron", "targets:\n  - name: a\n    state: a.tfstate" + resources + "\nschedules:\n  - name: s\n    cron: '* * *'", "schedule s: invalid cron expression"},
+		{"unknown schedule target", "targets:\n  - name: a\n    state: a.tfstate" + resources + "\nschedules:\n  - name: s\n    cron: '@hourly'\n    targets: [b]", "schedule s: unknown target b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orchestrate.Parse([]byte(tt.plan))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, err := orchestrate.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+
+	planPath := filepath.Join(t.TempDir(), "plan.yaml")
+	require.NoError(t, os.WriteFile(planPath, []byte("concurrency: 3\ntargets:\n  - name: a\n    state: a.tfstate\n    resources:\n      - type: aws_instance\n        attributes: [ami]\n"), 0644))
+	plan, err := orchestrate.Load(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, 3, plan.Concurrency)
+}
+
+func TestPlan_ScheduleTargets(t *testing.T) {
+	resources := "\n    resources:\n      - type: aws_instance\n        attributes: [instance_type]"
+	plan, err := orchestrate.Parse([]byte("targets:\n  - name: a\n    state: a.tfstate" + resources + "\n  - name: b\n    state: b.tfstate" + resources + `
+schedules:
+  - name: hourly
+    cron: "0 * * * *"
+    targets: [b]
+  - name: nightly
+    cron: "@daily"
+`))
+	require.NoError(t, err)
+	require.Len(t, plan.Schedules, 2)
+
+	hourly := plan.ScheduleTargets(plan.Schedules[0])
+	require.Len(t, hourly, 1)
+	assert.Equal(t, "b", hourly[0].Name)
+	assert.Len(t, plan.ScheduleTargets(plan.Schedules[1]), 2)
+}
+
+func TestResult_Failed(t *testing.T) {
+	result := orchestrate.Result{Targets: map[string]*orchestrate.TargetResult{
+		"c": {Status: orchestrate.StatusError},
+		"b": {Status: orchestrate.StatusOK},
+		"a": {Status: orchestrate.StatusError},
+	}}
+	assert.Equal(t, []string{"a", "c"}, result.Failed())
+}
@@ -0,0 +1,358 @@
+// Package policy evaluates compliance policies written in Rego over drift reports,
+// so that drift which breaks a rule, such as any change to the security groups of a
+// production resource, is reported as a violation with a severity.
+//
+// Policies are Rego modules in the driftwatcher package defining a violations set.
+// Each element is either a message or an object with a message, a severity and
+// optionally the attribute concerned:
+//
+//	package driftwatcher
+//
+//	violations contains {"severity": "high", "message": msg, "attribute": item.field} if {
+//		some item in input.report.drift_details
+//		item.field == "vpc_security_group_ids"
+//		input.resource.attributes.tags.Env == "prod"
+//		msg := sprintf("security groups of %s changed", [input.resource.address])
+//	}
+//
+// The input holds the report as it appears in JSON output and the resource as
+// recorded in the state.
+package policy
+
+import (
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// Query is the rule policies define violations in.
+const Query = "data.driftwatcher.violations"
+
+// Severities in increasing order.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// DefaultSeverity is the severity of a violation that does not set one.
+const DefaultSeverity = SeverityMedium
+
+var severities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// Engine evaluates a set of compiled policies.
+type Engine struct {
+	query      rego.PreparedEvalQuery
+	attributes []AttributeReference
+}
+
+// Load compiles the Rego policies at paths. A path is either a .rego file or a
+// directory searched recursively for .rego files.
+func Load(ctx context.Context, paths []string) (*Engine, error) {
+	modules := map[string]string{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (path != root && filepath.Ext(path) != ".rego") {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			modules[path] = string(content)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policies: %w", err)
+		}
+	}
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no .rego policies found in %s", strings.Join(paths, ", "))
+	}
+	return New(ctx, modules)
+}
+
+// New compiles the given Rego modules, keyed by file name.
+func New(ctx context.Context, modules map[string]string) (*Engine, error) {
+	opts := []func(*rego.Rego){rego.Query(Query)}
+	for name, module := range modules {
+		opts = append(opts, rego.Module(name, module))
+	}
+	query, err := rego.New(opts...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile policies: %w", err)
+	}
+	engine := &Engine{query: query}
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		module, err := ast.ParseModule(name, modules[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile policies: %w", err)
+		}
+		engine.attributes = append(engine.attributes, attributeReferences(module)...)
+	}
+	return engine, nil
+}
+
+// AttributeReference is an attribute of the scanned resources a policy refers to.
+type AttributeReference struct {
+	// Attribute is an attribute path expression, such as tags.Env.
+	Attribute string
+	// Location is the file and line of the reference.
+	Location string
+	// ResourceType is the resource type the rule holding the reference is restricted
+	// to by an input.resource.type == "TYPE" expression, empty when the rule applies
+	// to every type.
+	ResourceType string
+}
+
+// Attributes returns the attributes the policies refer to, so that they can be
+// checked against the schema of the scanned resource type: the paths under
+// input.resource.attributes, and the strings the field of a drift detail is compared
+// with, as in item.field == "vpc_security_group_ids".
+func (e *Engine) Attributes() []AttributeReference {
+	return e.attributes
+}
+
+// attributeReferences returns the attributes module refers to, in source order.
+func attributeReferences(module *ast.Module) []AttributeReference {
+	type found struct {
+		attribute    attrpath.Path
+		location     *ast.Location
+		resourceType string
+	}
+	var references []found
+
+	for _, rule := range module.Rules {
+		resourceType := ruleResourceType(rule)
+		ast.WalkRefs(rule, func(ref ast.Ref) bool {
+			if len(ref) < 4 || !ref[0].Equal(ast.InputRootDocument) ||
+				!ref[1].Equal(ast.StringTerm("resource")) || !ref[2].Equal(ast.StringTerm("attributes")) {
+				return false
+			}
+			var attribute attrpath.Path
+		path:
+			for _, term := range ref[3:] {
+				switch value := term.Value.(type) {
+				case ast.String:
+					attribute = append(attribute, attrpath.Segment{Key: string(value)})
+				case ast.Number:
+					index, ok := value.Int()
+					if !ok || index < 0 {
+						break path
+					}
+					attribute = append(attribute, attrpath.Segment{Index: index, IsIndex: true})
+				default:
+					// a variable key, such as the k of attributes.tags[k], ends the path
+					break path
+				}
+			}
+			if len(attribute) > 0 {
+				references = append(references, found{attribute, ref[0].Location, resourceType})
+			}
+			return false
+		})
+
+		ast.WalkExprs(rule, func(expr *ast.Expr) bool {
+			operator := expr.Operator()
+			if !operator.Equal(ast.Equal.Ref()) && !operator.Equal(ast.NotEqual.Ref()) && !operator.Equal(ast.Equality.Ref()) {
+				return false
+			}
+			operands := expr.Operands()
+			if len(operands) != 2 {
+				return false
+			}
+			for i, operand := range operands {
+				ref, isRef := operand.Value.(ast.Ref)
+				field, isString := operands[1-i].Value.(ast.String)
+				if !isRef || !isString || len(ref) < 2 || !ref[len(ref)-1].Equal(ast.StringTerm("field")) {
+					continue
+				}
+				if attribute, err := attrpath.Parse(string(field)); err == nil {
+					references = append(references, found{attribute, operands[1-i].Location, resourceType})
+				}
+			}
+			return false
+		})
+	}
+
+	slices.SortStableFunc(references, func(a, b found) int {
+		if a.location == nil || b.location == nil {
+			return 0
+		}
+		return a.location.Row - b.location.Row
+	})
+	attributes := make([]AttributeReference, 0, len(references))
+	for _, reference := range references {
+		attribute := AttributeReference{Attribute: reference.attribute.String(), ResourceType: reference.resourceType}
+		if reference.location != nil {
+			attribute.Location = fmt.Sprintf("%s:%d", reference.location.File, reference.location.Row)
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes
+}
+
+// ruleResourceType returns the resource type the body of rule is restricted to by an
+// input.resource.type == "TYPE" expression, or an empty string when there is none.
+func ruleResourceType(rule *ast.Rule) string {
+	resourceType := ast.Ref{ast.InputRootDocument, ast.StringTerm("resource"), ast.StringTerm("type")}
+	for _, expr := range rule.Body {
+		operator := expr.Operator()
+		if !operator.Equal(ast.Equal.Ref()) && !operator.Equal(ast.Equality.Ref()) {
+			continue
+		}
+		operands := expr.Operands()
+		if len(operands) != 2 {
+			continue
+		}
+		for i, operand := range operands {
+			ref, isRef := operand.Value.(ast.Ref)
+			value, isString := operands[1-i].Value.(ast.String)
+			if isRef && isString && ref.Equal(resourceType) {
+				return string(value)
+			}
+		}
+	}
+	return ""
+}
+
+// Input is the document policies are evaluated against.
+type Input struct {
+	Report   *driftchecker.DriftReport `json:"report"`
+	Resource Resource                  `json:"resource"`
+}
+
+// Resource describes the state resource a report belongs to.
+type Resource struct {
+	Address    string         `json:"address"`
+	Type       string         `json:"type"`
+	Name       string         `json:"name"`
+	Module     string         `json:"module,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// NewInput creates the policy input for a report of the given state resource.
+func NewInput(resource statemanager.StateResource, report *driftchecker.DriftReport) Input {
+	address := resource.Type + "." + resource.Name
+	if resource.Module != "" {
+		address = resource.Module + "." + address
+	}
+	attributes := map[string]any{}
+	if len(resource.Instances) > 0 && resource.Instances[0].Attributes != nil {
+		attributes = resource.Instances[0].Attributes
+	}
+	return Input{
+		Report: report,
+		Resource: Resource{
+			Address:    address,
+			Type:       resource.Type,
+			Name:       resource.Name,
+			Module:     resource.Module,
+			Attributes: attributes,
+		},
+	}
+}
+
+// Evaluate returns the violations of every policy for input, ordered by decreasing
+// severity.
+func (e *Engine) Evaluate(ctx context.Context, input Input) (_ []driftchecker.PolicyViolation, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "Policy.Evaluate")
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	// evaluate the JSON form of the input so policies see the field names of reports
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var document any
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+
+	results, err := e.query.Eval(ctx, rego.EvalInput(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	var violations []driftchecker.PolicyViolation
+	for _, result := range results {
+		for _, expression := range result.Expressions {
+			values, ok := expression.Value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a set or array, got %T", Query, expression.Value)
+			}
+			for _, value := range values {
+				violation, err := parseViolation(value)
+				if err != nil {
+					return nil, err
+				}
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	slices.SortStableFunc(violations, func(a, b driftchecker.PolicyViolation) int {
+		return Rank(b.Severity) - Rank(a.Severity)
+	})
+	return violations, nil
+}
+
+// parseViolation converts an element of the violations set.
+func parseViolation(value any) (driftchecker.PolicyViolation, error) {
+	violation := driftchecker.PolicyViolation{Severity: DefaultSeverity}
+	switch value := value.(type) {
+	case string:
+		violation.Message = value
+	case map[string]any:
+		for key, field := range value {
+			text, ok := field.(string)
+			if !ok {
+				return violation, fmt.Errorf("violation %s must be a string, got %T", key, field)
+			}
+			switch key {
+			case "message", "msg":
+				violation.Message = text
+			case "severity":
+				violation.Severity = strings.ToLower(text)
+			case "attribute":
+				violation.Attribute = text
+			}
+		}
+	default:
+		return violation, fmt.Errorf("violation must be a message or an object, got %T", value)
+	}
+
+	if violation.Message == "" {
+		return violation, fmt.Errorf("violation without a message")
+	}
+	if Rank(violation.Severity) == 0 {
+		return violation, fmt.Errorf("unknown violation severity %q, expected one of: %s", violation.Severity, strings.Join(severities, ", "))
+	}
+	return violation, nil
+}
+
+// Rank orders severities, from 1 for low to 4 for critical. It returns 0 for an
+// unknown severity.
+func Rank(severity string) int {
+	return slices.Index(severities, severity) + 1
+}
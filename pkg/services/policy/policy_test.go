@@ -0,0 +1,149 @@
+package policy_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/policy"
+	"drift-watcher/pkg/services/statemanager"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const securityGroupPolicy = `
+package driftwatcher
+
+violations contains {"severity": "high", "message": msg, "attribute": item.field} if {
+	some item in input.report.drift_details
+	item.field == "vpc_security_group_ids"
+	input.resource.attributes.tags.Env == "prod"
+	msg := sprintf("security groups of %s changed", [input.resource.address])
+}
+
+violations contains "instance type drifted" if {
+	some item in input.report.drift_details
+	item.field == "instance_type"
+}
+`
+
+func webResource(env string) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type:   "aws_instance",
+		Name:   "web",
+		Module: "module.app",
+		Instances: []statemanager.ResourceInstance{
+			{Attributes: map[string]any{"id": "i-1", "tags": map[string]any{"Env": env}}},
+		},
+	}
+}
+
+func driftedReport(fields ...string) *driftchecker.DriftReport {
+	report := &driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true}
+	for _, field := range fields {
+		report.DriftDetails = append(report.DriftDetails, driftchecker.DriftItem{Field: field, DriftType: driftchecker.AttributeValueChanged})
+	}
+	return report
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := policy.New(context.Background(), map[string]string{"security.rego": securityGroupPolicy})
+	require.NoError(t, err)
+
+	report := driftedReport("instance_type", "vpc_security_group_ids")
+	violations, err := engine.Evaluate(context.Background(), policy.NewInput(webResource("prod"), report))
+	require.NoError(t, err)
+	assert.Equal(t, []driftchecker.PolicyViolation{
+		{Severity: "high", Message: "security groups of module.app.aws_instance.web changed", Attribute: "vpc_security_group_ids"},
+		{Severity: policy.DefaultSeverity, Message: "instance type drifted"},
+	}, violations)
+
+	violations, err = engine.Evaluate(context.Background(), policy.NewInput(webResource("dev"), driftedReport("vpc_security_group_ids")))
+	require.NoError(t, err)
+	assert.Empty(t, violations, "security group drift is only a violation on prod resources")
+}
+
+func TestEngine_Evaluate_InvalidViolation(t *testing.T) {
+	tests := []struct {
+		name   string
+		module string
+		err    string
+	}{
+		{"unknown severity", `package driftwatcher
+violations contains {"severity": "urgent", "message": "x"} if { true }`, `unknown violation severity "urgent"`},
+		{"no message", `package driftwatcher
+violations contains {"severity": "low"} if { true }`, "violation without a message"},
+		{"not a set", `package driftwatcher
+violations := "x"`, "must be a set or array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := policy.New(context.Background(), map[string]string{"p.rego": tt.module})
+			require.NoError(t, err)
+			_, err = engine.Evaluate(context.Background(), policy.NewInput(webResource("prod"), driftedReport()))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "network"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "network", "security.rego"), []byte(securityGroupPolicy), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a policy"), 0644))
+
+	engine, err := policy.Load(context.Background(), []string{dir})
+	require.NoError(t, err)
+	violations, err := engine.Evaluate(context.Background(), policy.NewInput(webResource("prod"), driftedReport("instance_type")))
+	require.NoError(t, err)
+	assert.Len(t, violations, 1)
+
+	_, err = policy.Load(context.Background(), []string{t.TempDir()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no .rego policies found")
+
+	broken := filepath.Join(t.TempDir(), "broken.rego")
+	require.NoError(t, os.WriteFile(broken, []byte("package driftwatcher\nviolations contains"), 0644))
+	_, err = policy.Load(context.Background(), []string{broken})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile policies")
+}
+
+func TestRank(t *testing.T) {
+	assert.Less(t, policy.Rank(policy.SeverityLow), policy.Rank(policy.SeverityCritical))
+	assert.Equal(t, 0, policy.Rank("urgent"))
+}
+
+func TestEngine_Attributes(t *testing.T) {
+	engine, err := policy.New(context.Background(), map[string]string{
+		"security.rego": securityGroupPolicy,
+		"tags.rego": `package driftwatcher
+
+violations contains msg if {
+	some key, _ in input.resource.attributes.tags
+	input.resource.attributes.root_block_device[0].volume_size > 100
+	input.resource.attributes.tags[key] == ""
+	msg := "empty tag"
+}
+
+violations contains "public bucket" if {
+	input.resource.type == "aws_s3_bucket"
+	input.resource.attributes.acl == "public-read"
+}
+`,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []policy.AttributeReference{
+		{Attribute: "vpc_security_group_ids", Location: "security.rego:6"},
+		{Attribute: "tags.Env", Location: "security.rego:7"},
+		{Attribute: "instance_type", Location: "security.rego:13"},
+		{Attribute: "tags", Location: "tags.rego:4"},
+		{Attribute: "root_block_device[0].volume_size", Location: "tags.rego:5"},
+		{Attribute: "tags", Location: "tags.rego:6"},
+		{Attribute: "acl", Location: "tags.rego:12", ResourceType: "aws_s3_bucket"},
+	}, engine.Attributes())
+}
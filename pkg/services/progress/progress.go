@@ -0,0 +1,169 @@
+// Package progress reports how far a drift detection scan has got, so that scans of
+// thousands of resources don't appear frozen. On a terminal a single status line is
+// redrawn as resources complete; otherwise a log line is written periodically.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often a progress line is logged when the output is not a
+// terminal.
+const DefaultInterval = 10 * time.Second
+
+// Tracker counts processed resources and reports progress. A nil Tracker reports
+// nothing, so callers can use it unconditionally.
+type Tracker struct {
+	Out io.Writer
+	// Interactive redraws a status line on Out instead of logging periodically.
+	Interactive bool
+	// Interval is the minimum time between logged progress lines.
+	Interval time.Duration
+	// Logger is the logger progress lines are logged to when not interactive. The
+	// default logger is used when nil.
+	Logger *slog.Logger
+
+	mu        sync.Mutex
+	total     int
+	done      int
+	current   string
+	started   time.Time
+	lastShown time.Time
+	now       func() time.Time
+}
+
+// New creates a new Tracker instance.
+// out: The writer progress is written to, usually stderr.
+// interactive: Whether out is a terminal that a status line can be redrawn on.
+func New(out io.Writer, interactive bool) *Tracker {
+	return &Tracker{
+		Out:         out,
+		Interactive: interactive,
+		Interval:    DefaultInterval,
+		now:         time.Now,
+	}
+}
+
+// IsTerminal reports whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start begins tracking a scan of total resources.
+func (t *Tracker) Start(total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total = total
+	t.done = 0
+	t.current = ""
+	t.started = t.clock()
+	t.lastShown = t.started
+}
+
+// Begin records that the check of the named resource has started.
+func (t *Tracker) Begin(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.current = name
+	if t.Interactive {
+		t.show()
+	}
+}
+
+// Complete records that the check of a resource has finished.
+func (t *Tracker) Complete() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done++
+	if t.Interactive || t.clock().Sub(t.lastShown) >= t.Interval {
+		t.show()
+	}
+}
+
+// Finish reports the final count and ends the status line.
+func (t *Tracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed := t.clock().Sub(t.started).Round(time.Second)
+	if t.Interactive {
+		fmt.Fprintf(t.Out, "\r\033[K%d/%d resources checked in %s\n", t.done, t.total, elapsed)
+		return
+	}
+	t.logger().Info("Scan finished", "done", t.done, "total", t.total, "elapsed", elapsed.String())
+}
+
+// show writes the current progress. The caller must hold mu.
+func (t *Tracker) show() {
+	t.lastShown = t.clock()
+	percent := 0
+	if t.total > 0 {
+		percent = t.done * 100 / t.total
+	}
+	eta := t.eta()
+
+	if t.Interactive {
+		line := fmt.Sprintf("[%d/%d] %3d%%", t.done, t.total, percent)
+		if eta != "" {
+			line += " ETA " + eta
+		}
+		if t.current != "" {
+			line += "  " + t.current
+		}
+		fmt.Fprint(t.Out, "\r\033[K"+line)
+		return
+	}
+	t.logger().Info("Scan progress", "done", t.done, "total", t.total, "percent", percent, "eta", eta, "current", t.current)
+}
+
+// eta estimates the remaining time from the average time per completed resource.
+// The caller must hold mu.
+func (t *Tracker) eta() string {
+	if t.done == 0 || t.done >= t.total {
+		return ""
+	}
+	perResource := t.clock().Sub(t.started) / time.Duration(t.done)
+	return (perResource * time.Duration(t.total-t.done)).Round(time.Second).String()
+}
+
+func (t *Tracker) clock() time.Time {
+	if t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+func (t *Tracker) logger() *slog.Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	return slog.Default()
+}
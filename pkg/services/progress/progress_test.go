@@ -0,0 +1,54 @@
+package progress
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_Interactive(t *testing.T) {
+	now := time.Unix(0, 0)
+	var out bytes.Buffer
+	tracker := New(&out, true)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Start(4)
+	tracker.Begin("aws_instance.web")
+	now = now.Add(10 * time.Second)
+	tracker.Complete()
+	assert.Contains(t, out.String(), "[1/4]  25% ETA 30s  aws_instance.web")
+
+	tracker.Finish()
+	assert.Contains(t, out.String(), "1/4 resources checked in 10s\n")
+}
+
+func TestTracker_LogsPeriodically(t *testing.T) {
+	var logs bytes.Buffer
+	now := time.Unix(0, 0)
+	var out bytes.Buffer
+	tracker := New(&out, false)
+	tracker.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	tracker.now = func() time.Time { return now }
+
+	tracker.Start(3)
+	tracker.Begin("aws_instance.web")
+	tracker.Complete()
+	assert.Empty(t, logs.String(), "nothing is logged before the interval has passed")
+
+	now = now.Add(DefaultInterval)
+	tracker.Complete()
+	assert.Contains(t, logs.String(), "Scan progress")
+	assert.Contains(t, logs.String(), "done=2 total=3")
+	assert.Empty(t, out.String(), "no status line is drawn without a terminal")
+}
+
+func TestTracker_Nil(t *testing.T) {
+	var tracker *Tracker
+	tracker.Start(1)
+	tracker.Begin("x")
+	tracker.Complete()
+	tracker.Finish()
+}
@@ -0,0 +1,155 @@
+// Package ansible provides an infrastructure provider that uses facts gathered by
+// Ansible as the live state of on-premises hosts, so that attributes declared for
+// virtual machines in Terraform can be compared with what is running on the machines.
+package ansible
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultHostAttribute is the state attribute that names the host of a resource when
+// none is configured.
+const DefaultHostAttribute = "name"
+
+// DefaultFactMappings maps common virtual machine attributes of Terraform providers
+// to the facts they are compared with.
+var DefaultFactMappings = map[string]string{
+	"num_cpus":           "ansible_processor_vcpus",
+	"vcpu":               "ansible_processor_vcpus",
+	"hostname":           "ansible_hostname",
+	"default_ip_address": "ansible_default_ipv4.address",
+	"ipv4_address":       "ansible_default_ipv4.address",
+}
+
+// AnsibleProvider implements the ProviderI interface on top of a directory of
+// gathered facts.
+type AnsibleProvider struct {
+	FactsDir      string
+	Inventory     *Inventory
+	HostAttribute string
+	FactMappings  map[string]string
+}
+
+// NewAnsibleProvider creates a new AnsibleProvider reading facts from cfg.FactsDir.
+//
+// Parameters:
+//   - cfg: Ansible configuration containing the facts directory, inventory and mappings
+//
+// Returns:
+//   - provider.ProviderI: A configured Ansible provider instance
+//   - error: Any error encountered while checking the facts directory or reading the inventory
+func NewAnsibleProvider(cfg *config.AnsibleConfig) (provider.ProviderI, error) {
+	if cfg.FactsDir == "" {
+		return nil, fmt.Errorf("a facts directory is required for the ansible provider")
+	}
+	info, err := os.Stat(cfg.FactsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read facts directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("facts directory %s is not a directory", cfg.FactsDir)
+	}
+
+	p := &AnsibleProvider{
+		FactsDir:      cfg.FactsDir,
+		HostAttribute: cfg.HostAttribute,
+		FactMappings:  map[string]string{},
+	}
+	if p.HostAttribute == "" {
+		p.HostAttribute = DefaultHostAttribute
+	}
+	for attribute, fact := range DefaultFactMappings {
+		p.FactMappings[attribute] = fact
+	}
+	for attribute, fact := range cfg.FactMappings {
+		p.FactMappings[attribute] = fact
+	}
+	if cfg.Inventory != "" {
+		p.Inventory, err = LoadInventory(cfg.Inventory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// InfrastructreMetadata returns the gathered facts of the host a state resource
+// describes. The host is named by the resource's HostAttribute; when an inventory is
+// configured, a host addressed by its ansible_host is matched to its inventory name.
+// Any resource type is accepted, since only the host attribute and fact mappings
+// depend on it.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The type of the resource (e.g., "vsphere_virtual_machine")
+//   - resource: The Terraform state resource
+//
+// Returns:
+//   - provider.InfrastructureResourceI: The facts of the host
+//   - error: Any error encountered while reading the facts
+func (a *AnsibleProvider) InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (_ provider.InfrastructureResourceI, err error) {
+	_, span := telemetry.StartSpan(ctx, "AnsibleProvider.InfrastructreMetadata", attribute.String("drift.resource_type", resourceType))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	host, err := resource.AttributeValue(a.HostAttribute)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "Failed to parse host from parsed state object")
+	}
+	if host == "" {
+		return nil, fmt.Errorf("host not parsed from the %s attribute of the state file", a.HostAttribute)
+	}
+	if name, ok := a.Inventory.Resolve(host); ok {
+		host = name
+	}
+
+	facts, err := a.loadFacts(host)
+	if err != nil {
+		return nil, err
+	}
+	return &Host{Type: resourceType, Name: host, Facts: facts, FactMappings: a.FactMappings}, nil
+}
+
+// loadFacts reads the facts file of host, named after the host with an optional .json
+// extension. Output of `ansible -m setup --tree` wraps the facts in an ansible_facts
+// key, which is unwrapped.
+func (a *AnsibleProvider) loadFacts(host string) (map[string]any, error) {
+	var data []byte
+	var err error
+	for _, name := range []string{host, host + ".json"} {
+		data, err = os.ReadFile(filepath.Join(a.FactsDir, name))
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no facts gathered for host %s in %s", host, a.FactsDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read facts of host %s: %w", host, err)
+	}
+
+	var facts map[string]any
+	if err := json.Unmarshal(data, &facts); err != nil {
+		return nil, fmt.Errorf("failed to parse facts of host %s: %w", host, err)
+	}
+	if wrapped, ok := facts["ansible_facts"].(map[string]any); ok {
+		facts = wrapped
+	}
+	return facts, nil
+}
@@ -0,0 +1,172 @@
+package ansible_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider/ansible"
+	"drift-watcher/pkg/services/statemanager"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webFacts = `{
+  "ansible_hostname": "web01",
+  "ansible_processor_vcpus": 4,
+  "ansible_memtotal_mb": 7812,
+  "ansible_virtualization_role": "guest",
+  "ansible_default_ipv4": {"address": "10.0.0.11", "interface": "eth0"},
+  "ansible_dns": {"nameservers": ["10.0.0.2"]}
+}`
+
+// setupFacts writes a facts directory holding web01 in jsonfile cache format and db01
+// in 'ansible -m setup --tree' format.
+func setupFacts(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web01"), []byte(webFacts), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db01.json"), []byte(`{"ansible_facts": {"ansible_processor_vcpus": 8}, "changed": false}`), 0600))
+	return dir
+}
+
+func vm(attributes map[string]any) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type:      "vsphere_virtual_machine",
+		Name:      "web",
+		Instances: []statemanager.ResourceInstance{{Attributes: attributes}},
+	}
+}
+
+func TestInfrastructreMetadata_Facts(t *testing.T) {
+	p, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{
+		FactsDir:     setupFacts(t),
+		FactMappings: map[string]string{"memory": "ansible_memtotal_mb"},
+	})
+	require.NoError(t, err)
+
+	live, err := p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{"name": "web01"}))
+	require.NoError(t, err)
+	assert.Equal(t, "vsphere_virtual_machine", live.ResourceType())
+
+	tests := []struct {
+		attribute string
+		want      string
+	}{
+		{"num_cpus", "4"},
+		{"memory", "7812"},
+		{"default_ip_address", "10.0.0.11"},
+		{"virtualization_role", "guest"},
+		{"ansible_dns.nameservers", `["10.0.0.2"]`},
+		{"ansible_dns.nameservers.0", "10.0.0.2"},
+		{"guest_id", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			got, err := live.AttributeValue(tt.attribute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHost_Attributes(t *testing.T) {
+	p, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{
+		FactsDir:     setupFacts(t),
+		FactMappings: map[string]string{"memory": "ansible_memtotal_mb"},
+	})
+	require.NoError(t, err)
+	live, err := p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{"name": "web01"}))
+	require.NoError(t, err)
+
+	attributes, err := live.Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, "4", attributes["num_cpus"])
+	assert.Equal(t, "7812", attributes["memory"])
+	assert.NotContains(t, attributes, "ansible_dns", "unmapped facts are left out")
+}
+
+func TestInfrastructreMetadata_SetupTreeFormat(t *testing.T) {
+	p, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{FactsDir: setupFacts(t)})
+	require.NoError(t, err)
+
+	live, err := p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{"name": "db01"}))
+	require.NoError(t, err)
+	got, err := live.AttributeValue("num_cpus")
+	require.NoError(t, err)
+	assert.Equal(t, "8", got)
+}
+
+func TestInfrastructreMetadata_InventoryAddress(t *testing.T) {
+	dir := setupFacts(t)
+	inventory := filepath.Join(t.TempDir(), "hosts.ini")
+	require.NoError(t, os.WriteFile(inventory, []byte(`
+# web servers
+[web]
+web01 ansible_host=10.0.0.11 ansible_user=deploy
+
+[web:vars]
+http_port=80
+`), 0600))
+
+	p, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{
+		FactsDir:      dir,
+		Inventory:     inventory,
+		HostAttribute: "default_ip_address",
+	})
+	require.NoError(t, err)
+
+	live, err := p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{"default_ip_address": "10.0.0.11"}))
+	require.NoError(t, err)
+	got, err := live.AttributeValue("hostname")
+	require.NoError(t, err)
+	assert.Equal(t, "web01", got)
+}
+
+func TestInfrastructreMetadata_Errors(t *testing.T) {
+	p, err := ansible.NewAnsibleProvider(&config.AnsibleConfig{FactsDir: setupFacts(t)})
+	require.NoError(t, err)
+
+	_, err = p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{"name": "mail01"}))
+	assert.ErrorContains(t, err, "no facts gathered for host mail01")
+
+	_, err = p.InfrastructreMetadata(context.Background(), "vsphere_virtual_machine", vm(map[string]any{}))
+	assert.EqualError(t, err, "host not parsed from the name attribute of the state file")
+
+	_, err = ansible.NewAnsibleProvider(&config.AnsibleConfig{})
+	assert.EqualError(t, err, "a facts directory is required for the ansible provider")
+
+	_, err = ansible.NewAnsibleProvider(&config.AnsibleConfig{FactsDir: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
+
+func TestLoadInventory_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+all:
+  hosts:
+    bastion:
+  children:
+    web:
+      hosts:
+        web01:
+          ansible_host: 10.0.0.11
+        web02:
+          ansible_host: 10.0.0.12
+`), 0600))
+
+	inventory, err := ansible.LoadInventory(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"bastion": "", "web01": "10.0.0.11", "web02": "10.0.0.12"}, inventory.Hosts)
+
+	name, ok := inventory.Resolve("10.0.0.12")
+	assert.True(t, ok)
+	assert.Equal(t, "web02", name)
+	name, ok = inventory.Resolve("bastion")
+	assert.True(t, ok)
+	assert.Equal(t, "bastion", name)
+	_, ok = inventory.Resolve("10.0.0.99")
+	assert.False(t, ok)
+}
@@ -0,0 +1,79 @@
+package ansible
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Host is the live state of a host as described by its gathered facts.
+type Host struct {
+	// Type is the Terraform resource type the host was looked up for.
+	Type         string
+	Name         string
+	Facts        map[string]any
+	FactMappings map[string]string
+}
+
+func (h *Host) ResourceType() string {
+	return h.Type
+}
+
+// AttributeValue returns the fact an attribute is compared with. A mapped attribute
+// reads its mapped fact; any other attribute reads the fact of the same name, with or
+// without the ansible_ prefix. Facts are addressed with attribute path expressions
+// such as ansible_default_ipv4.address or ansible_mounts[0].size_total. A fact that
+// was not gathered is returned as an empty string, and lists and objects are rendered
+// as JSON.
+func (h *Host) AttributeValue(attribute string) (string, error) {
+	candidates := []string{attribute, "ansible_" + attribute}
+	if fact, ok := h.FactMappings[attribute]; ok {
+		candidates = []string{fact}
+	}
+
+	for _, fact := range candidates {
+		p, err := attrpath.Parse(fact)
+		if err != nil {
+			return "", err
+		}
+		value, ok := attrpath.Lookup(h.Facts, p)
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			return "", nil
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal fact %s: %w", fact, err)
+			}
+			return string(encoded), nil
+		}
+	}
+	return "", nil
+}
+
+// Attributes returns the value of every mapped attribute. Unmapped facts are left
+// out, as they have no counterpart in the state to be compared with.
+func (h *Host) Attributes() (map[string]string, error) {
+	attributes := make(map[string]string, len(h.FactMappings))
+	var errs []error
+	for attribute := range h.FactMappings {
+		value, err := h.AttributeValue(attribute)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		attributes[attribute] = value
+	}
+	return attributes, errors.Join(errs...)
+}
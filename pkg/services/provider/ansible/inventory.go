@@ -0,0 +1,113 @@
+package ansible
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inventory maps inventory host names to the address Ansible connects to.
+type Inventory struct {
+	// Hosts holds the ansible_host of every host, or an empty string when the host is
+	// reached by its inventory name.
+	Hosts map[string]string
+}
+
+// LoadInventory reads an INI or YAML inventory file. Files ending in .yml or .yaml are
+// parsed as YAML and any other file as INI. Only host names and their ansible_host
+// variable are read; group variables and dynamic inventories are not supported.
+func LoadInventory(path string) (*Inventory, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inventory: %w", err)
+	}
+	defer file.Close()
+
+	inventory := &Inventory{Hosts: map[string]string{}}
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		var groups map[string]yamlGroup
+		if err := yaml.NewDecoder(file).Decode(&groups); err != nil {
+			return nil, fmt.Errorf("failed to parse inventory %s: %w", path, err)
+		}
+		for _, group := range groups {
+			group.collect(inventory.Hosts)
+		}
+	default:
+		if err := parseINI(bufio.NewScanner(file), inventory.Hosts); err != nil {
+			return nil, fmt.Errorf("failed to parse inventory %s: %w", path, err)
+		}
+	}
+	return inventory, nil
+}
+
+// Resolve returns the inventory name of the host that is named or addressed by host.
+func (i *Inventory) Resolve(host string) (string, bool) {
+	if i == nil {
+		return "", false
+	}
+	if _, ok := i.Hosts[host]; ok {
+		return host, true
+	}
+	for name, address := range i.Hosts {
+		if address == host {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// yamlGroup is a group of a YAML inventory.
+type yamlGroup struct {
+	Hosts    map[string]map[string]any `yaml:"hosts"`
+	Children map[string]yamlGroup      `yaml:"children"`
+}
+
+func (g yamlGroup) collect(hosts map[string]string) {
+	for name, vars := range g.Hosts {
+		address, _ := vars["ansible_host"].(string)
+		if address != "" || hosts[name] == "" {
+			hosts[name] = address
+		}
+	}
+	for _, child := range g.Children {
+		child.collect(hosts)
+	}
+}
+
+// parseINI reads the host lines of an INI inventory. Lines in [group:vars] and
+// [group:children] sections do not name hosts and are skipped.
+func parseINI(scanner *bufio.Scanner, hosts map[string]string) error {
+	inHosts := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section := strings.Trim(line, "[]")
+			inHosts = !strings.Contains(section, ":")
+			continue
+		}
+		if !inHosts {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		name := fields[0]
+		address := ""
+		for _, field := range fields[1:] {
+			if value, ok := strings.CutPrefix(field, "ansible_host="); ok {
+				address = strings.Trim(value, `"'`)
+			}
+		}
+		if address != "" || hosts[name] == "" {
+			hosts[name] = address
+		}
+	}
+	return scanner.Err()
+}
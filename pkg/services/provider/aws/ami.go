@@ -0,0 +1,214 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/services/statemanager"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+)
+
+// Advise points out a newer AMI for an instance that runs the AMI recorded in its
+// state, when that AMI was selected with an aws_ami data source of the same state. The
+// images matching the owners, executable_users, filter and name_regex of the data
+// source are described, and the newest available one is suggested when the instance
+// does not run it. An instance whose AMI drifted gets no advisory, as the drift is
+// reported already, and other resource types get none either.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - live: The live instance
+//   - resource: The instance in the state
+//   - data: The data sources of the state
+//
+// Returns:
+//   - []provider.Advisory: The advisories of the instance, if any
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) Advise(ctx context.Context, live provider.InfrastructureResourceI, resource statemanager.StateResource, data []statemanager.StateResource) ([]provider.Advisory, error) {
+	if resource.ResourceType() != "aws_instance" || live == nil {
+		return nil, nil
+	}
+	stateAMI, err := resource.AttributeValue(string(EC2AMIID))
+	if err != nil || stateAMI == "" {
+		return nil, err
+	}
+	liveAMI, err := provider.LiveAttributeValue(ctx, live, string(EC2AMIID))
+	if err != nil || liveAMI != stateAMI {
+		return nil, err
+	}
+	source, ok := amiSource(data, stateAMI)
+	if !ok {
+		return nil, nil
+	}
+
+	newest, err := a.newestImage(ctx, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to describe the images matching %s", source.Address())
+	}
+	if newest == nil || aws.ToString(newest.ImageId) == stateAMI {
+		return nil, nil
+	}
+	id := aws.ToString(newest.ImageId)
+	return []provider.Advisory{{
+		Attribute: string(EC2AMIID),
+		Message:   fmt.Sprintf("newer AMI %s (%s) matches %s", id, imageName(*newest), source.Address()),
+		Suggested: id,
+	}}, nil
+}
+
+// amiSource returns the instance of an aws_ami data source that selected the AMI id.
+func amiSource(data []statemanager.StateResource, id string) (statemanager.StateResource, bool) {
+	for _, source := range statemanager.ExpandInstances(data) {
+		if source.Mode != "data" || source.Type != "aws_ami" {
+			continue
+		}
+		if sourceId, err := source.AttributeValue("id"); err == nil && sourceId == id {
+			return source, true
+		}
+	}
+	return statemanager.StateResource{}, false
+}
+
+// imageCache holds the newest image of aws_ami data sources, keyed by the address and
+// the arguments of the source.
+type imageCache struct {
+	mu     sync.Mutex
+	newest map[string]*types.Image
+}
+
+func (c *imageCache) get(key string) (*types.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	image, ok := c.newest[key]
+	return image, ok
+}
+
+func (c *imageCache) set(key string, image *types.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.newest == nil {
+		c.newest = map[string]*types.Image{}
+	}
+	c.newest[key] = image
+}
+
+// newestImage returns the most recently created available image matching the
+// arguments of an aws_ami data source, or nil if no image matches. The result is kept
+// for the run, so the instances selected by the same source describe the images once.
+func (a *AWSProvider) newestImage(ctx context.Context, source statemanager.StateResource) (*types.Image, error) {
+	input, nameRegex, err := imageQuery(source)
+	if err != nil {
+		return nil, err
+	}
+	query, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	key := source.Address() + " " + string(query)
+	if nameRegex != nil {
+		key += " " + nameRegex.String()
+	}
+	if newest, ok := a.images.get(key); ok {
+		return newest, nil
+	}
+
+	var newest *types.Image
+	paginator := ec2.NewDescribeImagesPaginator(a.ec2(), input, func(o *ec2.DescribeImagesPaginatorOptions) {
+		o.Limit = a.PageSize
+	})
+	err = paginate.Pages(ctx, paginator, a.calls(), func(page *ec2.DescribeImagesOutput) bool {
+		for _, image := range page.Images {
+			if image.State != types.ImageStateAvailable {
+				continue
+			}
+			if nameRegex != nil && !nameRegex.MatchString(aws.ToString(image.Name)) {
+				continue
+			}
+			// creation dates are ISO 8601 timestamps in UTC, which sort as strings
+			if newest == nil || aws.ToString(image.CreationDate) > aws.ToString(newest.CreationDate) {
+				newest = &image
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.images.set(key, newest)
+	return newest, nil
+}
+
+// imageQuery returns the DescribeImages input and the compiled name_regex of an aws_ami
+// data source, from the arguments the state records for it.
+func imageQuery(source statemanager.StateResource) (*ec2.DescribeImagesInput, *regexp.Regexp, error) {
+	if len(source.Instances) == 0 {
+		return nil, nil, fmt.Errorf("no instance for %s", source.Address())
+	}
+	attributes := source.Instances[0].Attributes
+
+	input := &ec2.DescribeImagesInput{
+		Owners:          stringList(attributes["owners"]),
+		ExecutableUsers: stringList(attributes["executable_users"]),
+	}
+	if includeDeprecated, ok := attributes["include_deprecated"].(bool); ok {
+		input.IncludeDeprecated = aws.Bool(includeDeprecated)
+	}
+	filters, _ := attributes["filter"].([]any)
+	for _, filter := range filters {
+		filter, ok := filter.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := filter["name"].(string)
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String(name), Values: stringList(filter["values"])})
+	}
+
+	var nameRegex *regexp.Regexp
+	if pattern, _ := attributes["name_regex"].(string); pattern != "" {
+		var err error
+		if nameRegex, err = regexp.Compile(pattern); err != nil {
+			return nil, nil, fmt.Errorf("invalid name_regex of %s: %w", source.Address(), err)
+		}
+	}
+	return input, nameRegex, nil
+}
+
+// stringList returns the strings of a list attribute of the state.
+func stringList(value any) []string {
+	values, _ := value.([]any)
+	var out []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// imageName describes an image by its name and creation date, e.g.
+// al2023-ami-2023.4.20240401.0-kernel-6.1-x86_64, created 2024-04-01.
+func imageName(image types.Image) string {
+	name := aws.ToString(image.Name)
+	if name == "" {
+		name = aws.ToString(image.Description)
+	}
+	created := aws.ToString(image.CreationDate)
+	if len(created) >= len("2006-01-02") {
+		created = created[:len("2006-01-02")]
+	}
+	switch {
+	case created == "":
+		return name
+	case name == "":
+		return "created " + created
+	}
+	return name + ", created " + created
+}
@@ -1,32 +1,50 @@
 package aws
 
 // EC2Attributes defines string constants for various EC2 instance attributes
-// that can be tracked for drift detection.
+// that can be tracked for drift detection, named after the Terraform schema. The
+// names earlier releases used, such as security_group_ids, are aliases in fields.
 type EC2Attributes string
 
 const (
 	// Core Instance Configuration
 	EC2AMIID            EC2Attributes = "ami"
 	EC2INSTANCETYPE     EC2Attributes = "instance_type"
-	EC2INSTANCEID       EC2Attributes = "instance_id"
+	EC2INSTANCEID       EC2Attributes = "id"
 	EC2KEYNAME          EC2Attributes = "key_name"
 	EC2AvailabilityZone EC2Attributes = "availability_zone"
 	EC2TENANCY          EC2Attributes = "tenancy"
 	EC2CPUCORECOUNT     EC2Attributes = "cpu_core_count"
-	EC2CPUTHREADPERCORE EC2Attributes = "cpu_thread_per_core"
+	EC2CPUTHREADPERCORE EC2Attributes = "cpu_threads_per_core"
 	EC2EbsOptimzied     EC2Attributes = "ebs_optimized"
+	EC2PlacementGroup   EC2Attributes = "placement_group"
+	EC2Monitoring       EC2Attributes = "monitoring"
+	EC2Hibernation      EC2Attributes = "hibernation"
+	// EC2CreditSpecification is the credit_specification block of burstable
+	// instances, EC2CPUCredits its single cpu_credits attribute.
+	EC2CreditSpecification EC2Attributes = "credit_specification"
+	EC2CPUCredits          EC2Attributes = "credit_specification.cpu_credits"
 
 	// Networking & Security
-	EC2SecurityGroupIDs         EC2Attributes = "security_group_ids"
-	EC2SUBNETID                 EC2Attributes = "subnet_id"
-	EC2AssociatePublicIPAddress EC2Attributes = "associate_public_ip_address"
-	EC2PrivateIP                EC2Attributes = "private_ip"
-	EC2PrivateDnsName           EC2Attributes = "private_dns_name"
-	EC2PublicIP                 EC2Attributes = "public_ip"
-	EC2PublicDnsName            EC2Attributes = "public_dns_name"
-	EC2SourceDestCheck          EC2Attributes = "source_dest_check"
-	EC2IAMInstanceID            EC2Attributes = "iam_instance_id"
-	EC2IAMInstanceARN           EC2Attributes = "iam_instance_arn"
+	EC2SecurityGroupIDs          EC2Attributes = "vpc_security_group_ids"
+	EC2SUBNETID                  EC2Attributes = "subnet_id"
+	EC2AssociatePublicIPAddress  EC2Attributes = "associate_public_ip_address"
+	EC2PrivateIP                 EC2Attributes = "private_ip"
+	EC2PrivateDnsName            EC2Attributes = "private_dns"
+	EC2PublicIP                  EC2Attributes = "public_ip"
+	EC2PublicDnsName             EC2Attributes = "public_dns"
+	EC2SourceDestCheck           EC2Attributes = "source_dest_check"
+	EC2IAMInstanceID             EC2Attributes = "iam_instance_id"
+	EC2IAMInstanceARN            EC2Attributes = "iam_instance_arn"
+	EC2IAMInstanceProfile        EC2Attributes = "iam_instance_profile"
+	EC2PrimaryNetworkInterfaceID EC2Attributes = "primary_network_interface_id"
+	EC2SecondaryPrivateIPs       EC2Attributes = "secondary_private_ips"
+	// EC2NetworkInterface is the network_interface blocks of the attached interfaces,
+	// the attributes below read one value of each interface in device index order.
+	EC2NetworkInterface                EC2Attributes = "network_interface"
+	EC2NetworkInterfaceID              EC2Attributes = "network_interface.network_interface_id"
+	EC2NetworkInterfaceDeviceIndex     EC2Attributes = "network_interface.device_index"
+	EC2NetworkInterfaceSourceDestCheck EC2Attributes = "network_interface.source_dest_check"
+	EC2NetworkInterfacePrivateIPs      EC2Attributes = "network_interface.private_ips"
 
 	// Storage (EBS Volumes)
 	// For these, we typically look at sub-attributes within "root_block_device"
@@ -34,6 +52,10 @@ const (
 	// attribute for the block device configurations.
 	EC2RootBlockDevice EC2Attributes = "root_block_device"
 	EC2EBSBlockDevice  EC2Attributes = "ebs_block_device"
+	// Attributes of the ebs_block_device blocks, one value per attached volume.
+	EC2EBSBlockDeviceName     EC2Attributes = "ebs_block_device.device_name"
+	EC2EBSVolumeID            EC2Attributes = "ebs_block_device.volume_id"
+	EC2EBSDeleteOnTermination EC2Attributes = "ebs_block_device.delete_on_termination"
 	// Specific sub-attributes for block devices (could be used as keys in a nested map if needed)
 	EC2BlockDeviceName     EC2Attributes = "block_device_name"
 	EC2VolumeID            EC2Attributes = "volume_id"
@@ -42,9 +64,18 @@ const (
 	EC2VolumeEncrypted     EC2Attributes = "encrypted"
 	EC2DeleteOnTermination EC2Attributes = "delete_on_termination"
 
+	// Launch Template
+	// The id and version of the template an instance was launched from are read from
+	// the aws:ec2launchtemplate:* tags EC2 adds to the instance at launch.
+	EC2LaunchTemplate        EC2Attributes = "launch_template"
+	EC2LaunchTemplateID      EC2Attributes = "launch_template.id"
+	EC2LaunchTemplateName    EC2Attributes = "launch_template.name"
+	EC2LaunchTemplateVersion EC2Attributes = "launch_template.version"
+
 	// Metadata & User Data
 	EC2MetadataOptions EC2Attributes = "metadata_options"
 	EC2UserData        EC2Attributes = "user_data"
+	EC2UserDataBase64  EC2Attributes = "user_data_base64"
 
 	// State
 	EC2InstanceState EC2Attributes = "instance_state"
@@ -5,16 +5,36 @@ package aws
 import (
 	"context"
 	"drift-watcher/config"
+	"drift-watcher/pkg/logging"
 	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/breaker"
+	"drift-watcher/pkg/services/provider/cache"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/services/secrets"
 	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
 	"fmt"
-	"os"
+	"log/slog"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	aConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
 )
 
 // AWSProvider implements the ProviderI interface for AWS infrastructure.
@@ -22,38 +42,404 @@ import (
 // live infrastructure data from AWS services.
 type AWSProvider struct {
 	Config aws.Config
+	// EC2 is the client EC2 resources are read and remediated with. A client is
+	// created from Config when nil.
+	EC2 EC2API
+	// ELBv2 is the client application and network load balancers, their listeners
+	// and target groups are read with. A client is created from Config when nil.
+	ELBv2 ELBv2API
+	// ELB is the client classic load balancers are read with. A client is created
+	// from Config when nil.
+	ELB ELBAPI
+	// CloudFront is the client CloudFront distributions are read with. A client is
+	// created from Config when nil.
+	CloudFront CloudFrontAPI
+	// EKS is the client EKS clusters and their managed node groups are read with. A
+	// client is created from Config when nil.
+	EKS EKSAPI
+	// SQS is the client SQS queues are read with. A client is created from Config
+	// when nil.
+	SQS SQSAPI
+	// SNS is the client SNS topics and subscriptions are read with. A client is
+	// created from Config when nil.
+	SNS SNSAPI
+	// SSM is the client the SSM parameters referenced by the state are resolved with.
+	// A client is created from Config when nil.
+	SSM SSMAPI
+	// SecretsManager is the client the secrets referenced by the state are resolved
+	// with. A client is created from Config when nil.
+	SecretsManager secrets.SecretsManagerAPI
+	// PageSize is the number of results requested per page by list and describe
+	// calls, the API default when zero.
+	PageSize int32
+
+	// breaker fails calls fast once the region has been unreachable for a number
+	// of consecutive calls. A nil breaker lets every call through.
+	breaker *breaker.Breaker
+	// limiter spaces out list and describe calls to stay below the API rate limits.
+	// A nil limiter lets every call through.
+	limiter *rate.Limiter
+	// cache holds live resource metadata keyed by region and resource id. A nil
+	// cache disables caching.
+	cache *cache.Cache
+	// values holds the values resolved for references of the state, in memory only so
+	// that secrets are never written to disk. A nil cache disables caching.
+	values *cache.Cache
+	// scope identifies the credentials the provider reads with: the profile, endpoint
+	// and assumed role. It is part of the cache keys, so that accounts sharing a
+	// region and a cache directory never read each other's metadata.
+	scope string
+	// images holds the newest image matching each aws_ami data source, so that the
+	// images are described once per run however many instances the source selected.
+	images imageCache
+}
+
+// EC2API is the part of the EC2 API used by the provider. It is implemented by
+// *ec2.Client, and by awstest.FakeEC2 for tests that do not reach AWS.
+type EC2API interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error)
+	DescribeLaunchTemplates(ctx context.Context, params *ec2.DescribeLaunchTemplatesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplatesOutput, error)
+	DescribeInstanceCreditSpecifications(ctx context.Context, params *ec2.DescribeInstanceCreditSpecificationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceCreditSpecificationsOutput, error)
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeSecurityGroupRules(ctx context.Context, params *ec2.DescribeSecurityGroupRulesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error)
+	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+	ModifyInstanceAttribute(ctx context.Context, params *ec2.ModifyInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyInstanceAttributeOutput, error)
+	CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error)
+	DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error)
+	StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error)
+	StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error)
+}
+
+// Option configures how an AWS provider, or another AWS client created with
+// LoadConfig, reaches AWS.
+type Option func(*options)
+
+type options struct {
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	roleARN     string
+	httpClient  aws.HTTPClient
+	ec2         EC2API
+	elbv2       ELBv2API
+	elb         ELBAPI
+	cloudFront  CloudFrontAPI
+	eks         EKSAPI
+	sqs         SQSAPI
+	sns         SNSAPI
+	ssm         SSMAPI
+	secrets     secrets.SecretsManagerAPI
+}
+
+// WithRegion overrides the region of the profile.
+func WithRegion(region string) Option {
+	return func(o *options) {
+		o.region = region
+	}
+}
+
+// WithEndpoint sends every AWS API call to endpoint instead of the AWS endpoint of
+// the service, e.g. a LocalStack instance such as http://localhost:4566.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// Defaults of a LocalStack container started with its standard settings: the edge
+// port every service is served on, the default region and the test access key it
+// accepts.
+const (
+	LocalStackEndpoint  = "http://localhost:4566"
+	LocalStackRegion    = "us-east-1"
+	LocalStackAccessKey = "test"
+)
+
+// WithCredentials uses the static access key accessKeyID and secretAccessKey instead
+// of the credentials of the profile, e.g. the test credentials accepted by LocalStack.
+func WithCredentials(accessKeyID, secretAccessKey string) Option {
+	return func(o *options) {
+		o.credentials = credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")
+	}
+}
+
+// WithAssumeRole assumes the IAM role roleARN with the profile's credentials, e.g. a
+// read-only role in another account. The temporary credentials are refreshed before
+// they expire.
+func WithAssumeRole(roleARN string) Option {
+	return func(o *options) {
+		o.roleARN = roleARN
+	}
+}
+
+// WithHTTPClient sets the HTTP client AWS API calls are made with.
+func WithHTTPClient(client aws.HTTPClient) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithEC2Client sets the client EC2 resources are read and remediated with, e.g. an
+// awstest.FakeEC2 in tests.
+func WithEC2Client(client EC2API) Option {
+	return func(o *options) {
+		o.ec2 = client
+	}
+}
+
+// WithELBv2Client sets the client application and network load balancers are read
+// with, e.g. an awstest.FakeELBv2 in tests.
+func WithELBv2Client(client ELBv2API) Option {
+	return func(o *options) {
+		o.elbv2 = client
+	}
+}
+
+// WithELBClient sets the client classic load balancers are read with.
+func WithELBClient(client ELBAPI) Option {
+	return func(o *options) {
+		o.elb = client
+	}
+}
+
+// WithCloudFrontClient sets the client CloudFront distributions are read with.
+func WithCloudFrontClient(client CloudFrontAPI) Option {
+	return func(o *options) {
+		o.cloudFront = client
+	}
+}
+
+// WithEKSClient sets the client EKS clusters and node groups are read with.
+func WithEKSClient(client EKSAPI) Option {
+	return func(o *options) {
+		o.eks = client
+	}
+}
+
+// WithSQSClient sets the client SQS queues are read with.
+func WithSQSClient(client SQSAPI) Option {
+	return func(o *options) {
+		o.sqs = client
+	}
+}
+
+// WithSNSClient sets the client SNS topics and subscriptions are read with.
+func WithSNSClient(client SNSAPI) Option {
+	return func(o *options) {
+		o.sns = client
+	}
+}
+
+// WithSSMClient sets the client SSM parameters referenced by the state are resolved
+// with.
+func WithSSMClient(client SSMAPI) Option {
+	return func(o *options) {
+		o.ssm = client
+	}
+}
+
+// WithSecretsManagerClient sets the client secrets referenced by the state are
+// resolved with.
+func WithSecretsManagerClient(client secrets.SecretsManagerAPI) Option {
+	return func(o *options) {
+		o.secrets = client
+	}
 }
 
 // NewAWSProvider creates a new AWSProvider instance with the given configuration.
-// It initializes the AWS SDK config with credentials, region, and optional LocalStack settings
-// for local development and testing.
+// It initializes the AWS SDK config with credentials and region, adjusted by opts.
+// API calls are retried with backoff according to the retry settings in cfg and
+// guarded by a circuit breaker for unreachable regions.
 //
 // Parameters:
 //   - cfg: AWS configuration containing credential paths, config paths, and profile information
+//   - opts: Optional region, endpoint, role and HTTP client overrides
 //
 // Returns:
 //   - provider.ProviderI: A configured AWS provider instance
 //   - error: Any error encountered during AWS SDK configuration
-func NewAWSProvider(cfg *config.AWSConfig) (provider.ProviderI, error) {
+func NewAWSProvider(cfg *config.AWSConfig, opts ...Option) (provider.ProviderI, error) {
 	provider := AWSProvider{}
 
-	localStack := os.Getenv("DRIFT_LOCALSTACK_URL")
-	localStackRegion := os.Getenv("DRIFT_LOCALSTACK_REGION")
-
-	awsConfig, err := aConfig.LoadDefaultConfig(context.Background(),
-		aConfig.WithSharedCredentialsFiles(cfg.CredentialPath),
-		aConfig.WithSharedConfigFiles(cfg.ConfigPath),
-		aConfig.WithSharedConfigProfile(cfg.ProfileName),
-		aConfig.WithBaseEndpoint(localStack),
-		aConfig.WithRegion(localStackRegion))
+	awsConfig, err := LoadConfig(cfg, opts...)
 	if err != nil {
 		return nil, err
 	}
 	provider.Config = awsConfig
+	provider.EC2 = ec2Client(awsConfig, opts)
+	provider.ELBv2, provider.ELB = elbClients(awsConfig, opts)
+	provider.CloudFront = cloudFrontClient(awsConfig, opts)
+	provider.EKS = eksClient(awsConfig, opts)
+	provider.SQS, provider.SNS = messagingClients(awsConfig, opts)
+	provider.SSM, provider.SecretsManager = secretStoreClients(awsConfig, opts)
+	provider.breaker = newBreaker(cfg)
+	provider.limiter = newLimiter(cfg)
+	provider.PageSize = pageSize(cfg)
+	provider.scope = cacheScope(cfg, opts)
+	provider.cache = cache.New(cfg.CacheTTL, cfg.CacheDir)
+	provider.values = cache.New(valueCacheTTL(cfg.CacheTTL), "")
 
 	return &provider, nil
 }
 
+// ec2Client returns the EC2 client set with WithEC2Client, or a client for awsConfig.
+func ec2Client(awsConfig aws.Config, opts []Option) EC2API {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.ec2 != nil {
+		return options.ec2
+	}
+	return ec2.NewFromConfig(awsConfig)
+}
+
+// elbClients returns the Elastic Load Balancing clients set with WithELBv2Client and
+// WithELBClient, or clients for awsConfig.
+func elbClients(awsConfig aws.Config, opts []Option) (ELBv2API, ELBAPI) {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	var v2 ELBv2API = elasticloadbalancingv2.NewFromConfig(awsConfig)
+	if options.elbv2 != nil {
+		v2 = options.elbv2
+	}
+	var classic ELBAPI = elasticloadbalancing.NewFromConfig(awsConfig)
+	if options.elb != nil {
+		classic = options.elb
+	}
+	return v2, classic
+}
+
+// cloudFrontClient returns the CloudFront client set with WithCloudFrontClient, or a
+// client for awsConfig.
+func cloudFrontClient(awsConfig aws.Config, opts []Option) CloudFrontAPI {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.cloudFront != nil {
+		return options.cloudFront
+	}
+	return cloudfront.NewFromConfig(awsConfig)
+}
+
+// eksClient returns the EKS client set with WithEKSClient, or a client for awsConfig.
+func eksClient(awsConfig aws.Config, opts []Option) EKSAPI {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.eks != nil {
+		return options.eks
+	}
+	return eks.NewFromConfig(awsConfig)
+}
+
+// messagingClients returns the SQS and SNS clients set with WithSQSClient and
+// WithSNSClient, or clients for awsConfig.
+func messagingClients(awsConfig aws.Config, opts []Option) (SQSAPI, SNSAPI) {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	var queues SQSAPI = sqs.NewFromConfig(awsConfig)
+	if options.sqs != nil {
+		queues = options.sqs
+	}
+	var topics SNSAPI = sns.NewFromConfig(awsConfig)
+	if options.sns != nil {
+		topics = options.sns
+	}
+	return queues, topics
+}
+
+// secretStoreClients returns the SSM and Secrets Manager clients set with
+// WithSSMClient and WithSecretsManagerClient, or clients for awsConfig.
+func secretStoreClients(awsConfig aws.Config, opts []Option) (SSMAPI, secrets.SecretsManagerAPI) {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	var parameters SSMAPI = ssm.NewFromConfig(awsConfig)
+	if options.ssm != nil {
+		parameters = options.ssm
+	}
+	var secretStore secrets.SecretsManagerAPI = secretsmanager.NewFromConfig(awsConfig)
+	if options.secrets != nil {
+		secretStore = options.secrets
+	}
+	return parameters, secretStore
+}
+
+// LoadConfig loads the AWS SDK configuration described by cfg and opts, for use by
+// the provider and by other AWS clients such as KMS.
+//
+// Parameters:
+//   - cfg: AWS configuration containing credential paths, config paths, and profile information
+//   - opts: Optional region, endpoint, credentials, role and HTTP client overrides
+//
+// Returns:
+//   - aws.Config: The loaded AWS SDK configuration
+//   - error: Any error encountered during AWS SDK configuration
+func LoadConfig(cfg *config.AWSConfig, opts ...Option) (aws.Config, error) {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	retryer, err := newRetryer(cfg)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	loadOptions := []func(*aConfig.LoadOptions) error{
+		aConfig.WithBaseEndpoint(options.endpoint),
+		aConfig.WithRegion(options.region),
+		aConfig.WithRetryer(retryer),
+	}
+	// without files the SDK looks up its default files, and falls back to the
+	// credentials of the environment, SSO and the ECS and EC2 roles
+	if cfg.ExplicitProfile {
+		loadOptions = append(loadOptions, aConfig.WithSharedConfigProfile(cfg.ProfileName))
+	}
+	if len(cfg.CredentialPath) > 0 {
+		loadOptions = append(loadOptions, aConfig.WithSharedCredentialsFiles(cfg.CredentialPath))
+	}
+	if len(cfg.ConfigPath) > 0 {
+		loadOptions = append(loadOptions, aConfig.WithSharedConfigFiles(cfg.ConfigPath))
+	}
+	if options.httpClient != nil {
+		loadOptions = append(loadOptions, aConfig.WithHTTPClient(options.httpClient))
+	}
+	if options.credentials != nil {
+		loadOptions = append(loadOptions, aConfig.WithCredentialsProvider(options.credentials))
+	}
+	if cfg.UseFIPS {
+		loadOptions = append(loadOptions, aConfig.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	awsConfig, err := aConfig.LoadDefaultConfig(context.Background(), loadOptions...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	// the SDK resolves the endpoints of the partition of the region, a region of
+	// another partition than the account's would only fail on the first call
+	if err := checkPartition(cfg, awsConfig.Region); err != nil {
+		return aws.Config{}, err
+	}
+	if options.roleARN != "" {
+		assumeRole := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsConfig), options.roleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "driftwatcher"
+		})
+		awsConfig.Credentials = aws.NewCredentialsCache(assumeRole)
+	}
+	return awsConfig, nil
+}
+
 // InfrastructreMetadata retrieves live infrastructure metadata for a given resource
 // from AWS services. It acts as a dispatcher, routing requests to appropriate
 // service-specific handlers based on the resource type.
@@ -66,7 +452,13 @@ func NewAWSProvider(cfg *config.AWSConfig) (provider.ProviderI, error) {
 // Returns:
 //   - provider.InfrastructureResourceI: Live infrastructure data for the resource
 //   - error: Any error encountered during metadata retrieval
-func (a *AWSProvider) InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+func (a *AWSProvider) InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (_ provider.InfrastructureResourceI, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AWSProvider.InfrastructreMetadata", attribute.String("drift.resource_type", resourceType))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
 	switch resourceType {
 	case "aws_instance":
 		resourceId, err := resource.AttributeValue("id")
@@ -81,14 +473,108 @@ func (a *AWSProvider) InfrastructreMetadata(ctx context.Context, resourceType st
 		if err != nil {
 			return instance, err
 		}
+		// $Latest and $Default in the state are resolved against the launch template
+		instance.stateTemplateVersion, _ = resource.AttributeValue(string(EC2LaunchTemplateVersion))
 
 		return instance, nil
 
+	case "aws_lb", "aws_alb":
+		arn, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleLoadBalancerMetadata(ctx, resourceType, arn)
+
+	case "aws_lb_listener", "aws_alb_listener":
+		arn, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleListenerMetadata(ctx, resourceType, arn)
+
+	case "aws_lb_target_group", "aws_alb_target_group":
+		arn, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleTargetGroupMetadata(ctx, resourceType, arn)
+
+	case "aws_elb":
+		name, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleClassicLoadBalancerMetadata(ctx, name)
+
+	case "aws_cloudfront_distribution":
+		id, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleCloudFrontMetadata(ctx, id)
+
+	case "aws_eks_cluster":
+		name, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleEKSClusterMetadata(ctx, name)
+
+	case "aws_eks_node_group":
+		id, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleEKSNodeGroupMetadata(ctx, id)
+
+	case "aws_sqs_queue":
+		url, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleSQSQueueMetadata(ctx, url)
+
+	case "aws_sns_topic":
+		arn, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleSNSTopicMetadata(ctx, arn)
+
+	case "aws_sns_topic_subscription":
+		arn, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleSNSSubscriptionMetadata(ctx, arn)
+
+	case "aws_security_group":
+		id, err := stateId(resource)
+		if err != nil {
+			return nil, err
+		}
+		return a.HandleSecurityGroupMetadata(ctx, id)
+
 	default:
 		return nil, fmt.Errorf("%s resource not yet supported for AWS provider", resourceType)
 	}
 }
 
+// stateId returns the id of resource in the state: the ARN of a load balancer,
+// listener or target group, the name of a classic load balancer or EKS cluster and
+// CLUSTER:NODE_GROUP for an EKS node group, the URL of an SQS queue, the ARN of an
+// SNS topic or subscription and the id of a security group.
+func stateId(resource statemanager.StateResource) (string, error) {
+	id, err := resource.AttributeValue("id")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse resource identifier from parsed state object")
+	}
+	if id == "" {
+		return "", fmt.Errorf("resource Id not parsed from state file")
+	}
+	return id, nil
+}
+
 // HandleEC2Metadata retrieves metadata for a specific EC2 instance from AWS.
 // It uses the AWS EC2 API to describe the instance and returns the live infrastructure data.
 //
@@ -100,6 +586,16 @@ func (a *AWSProvider) InfrastructreMetadata(ctx context.Context, resourceType st
 //   - *EC2InfraInstance: The live EC2 instance data wrapped in our internal structure
 //   - error: Any error encountered during the AWS API call or data processing
 func (a *AWSProvider) HandleEC2Metadata(ctx context.Context, resourceId string) (*EC2InfraInstance, error) {
+	ctx, span := telemetry.StartSpan(ctx, "EC2.DescribeInstances", attribute.String("aws.ec2.instance_id", resourceId))
+	defer span.End()
+
+	cacheKey := a.cacheKey("aws_instance", resourceId)
+	cached := &EC2InfraInstance{details: a.instanceDetails(resourceId)}
+	if a.cache.Get(ctx, cacheKey, &cached.Instance) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return cached, nil
+	}
+
 	ec2Filters := []types.Filter{
 		{
 			Name:   aws.String("instance-id"),
@@ -107,12 +603,14 @@ func (a *AWSProvider) HandleEC2Metadata(ctx context.Context, resourceId string)
 		},
 	}
 
-	ec2Client := ec2.NewFromConfig(a.Config)
 	input := ec2.DescribeInstancesInput{
 		Filters: ec2Filters,
 	}
-	output, err := ec2Client.DescribeInstances(ctx, &input)
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*ec2.DescribeInstancesOutput, error) {
+		return a.ec2().DescribeInstances(ctx, &input)
+	})
 	if err != nil {
+		telemetry.RecordError(span, err)
 		return nil, errors.Wrap(err, "Failed to describe ec2 instance")
 	}
 	if len(output.Reservations) == 0 {
@@ -124,7 +622,188 @@ func (a *AWSProvider) HandleEC2Metadata(ctx context.Context, resourceId string)
 	}
 	out := &EC2InfraInstance{
 		Instance: output.Reservations[0].Instances[0],
+		details:  a.instanceDetails(resourceId),
+	}
+	if err := a.cache.Set(ctx, cacheKey, out.Instance); err != nil {
+		logger(ctx).Debug("failed to cache ec2 instance metadata", "instance_id", resourceId, "error", err)
 	}
 
 	return out, nil
 }
+
+// ec2 returns the EC2 client of the provider.
+func (a *AWSProvider) ec2() EC2API {
+	if a.EC2 != nil {
+		return a.EC2
+	}
+	return ec2.NewFromConfig(a.Config)
+}
+
+// calls returns how the list and describe calls of the provider are made: rate limited
+// and guarded by the circuit breaker.
+func (a *AWSProvider) calls() paginate.Options {
+	return paginate.Options{Limiter: a.limiter, Breaker: a.breaker}
+}
+
+// cacheKey returns the key live metadata of a resource is cached under. The scope
+// and the region are part of the key because resource ids, and the names EKS
+// clusters and classic load balancers are identified by, are only unique within an
+// account and a region.
+func (a *AWSProvider) cacheKey(resourceType string, resourceId string) string {
+	return a.scope + "/" + a.Config.Region + "/" + resourceType + "/" + resourceId
+}
+
+// cacheScope returns the scope of the cache keys of a provider configured with cfg
+// and opts: the selected profile, the static access key, the endpoint and the
+// assumed role.
+func cacheScope(cfg *config.AWSConfig, opts []Option) string {
+	options := &options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	profile, accessKey := "", ""
+	if cfg.ExplicitProfile {
+		profile = cfg.ProfileName
+	}
+	if static, ok := options.credentials.(credentials.StaticCredentialsProvider); ok {
+		accessKey = static.Value.AccessKeyID
+	}
+	return strings.Join([]string{profile, accessKey, options.endpoint, options.roleARN}, "|")
+}
+
+// ListResourceIds returns the identifiers of every live resource of the given type
+// in the configured account and region. Terminated instances are excluded. Load
+// balancers and target groups are identified by ARN, classic load balancers by name.
+// Listeners are not listed, as they can only be listed per load balancer. CloudFront
+// distributions are global and listed whatever the region. EKS clusters are identified
+// by name and node groups as CLUSTER:NODE_GROUP, like in the state. SQS queues are
+// identified by URL, SNS topics and subscriptions by ARN and security groups by id.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The type of AWS resource to list (e.g., "aws_instance")
+//
+// Returns:
+//   - []string: The identifiers of the live resources
+//   - error: Any error encountered while listing resources
+func (a *AWSProvider) ListResourceIds(ctx context.Context, resourceType string) ([]string, error) {
+	ctx, span := telemetry.StartSpan(ctx, "AWSProvider.ListResourceIds", attribute.String("drift.resource_type", resourceType))
+	defer span.End()
+
+	switch resourceType {
+	case "aws_instance":
+		paginator := ec2.NewDescribeInstancesPaginator(a.ec2(), &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("instance-state-name"),
+					Values: []string{"pending", "running", "stopping", "stopped"},
+				},
+			},
+		}, func(o *ec2.DescribeInstancesPaginatorOptions) {
+			o.Limit = a.PageSize
+		})
+
+		ids, err := paginate.Collect(ctx, paginator, a.calls(), func(page *ec2.DescribeInstancesOutput) []string {
+			var ids []string
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					ids = append(ids, aws.ToString(instance.InstanceId))
+				}
+			}
+			return ids
+		})
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list ec2 instances")
+		}
+		return ids, nil
+
+	case "aws_lb", "aws_alb":
+		arns, err := a.listLoadBalancers(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list load balancers")
+		}
+		return arns, nil
+
+	case "aws_lb_target_group", "aws_alb_target_group":
+		arns, err := a.listTargetGroups(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list target groups")
+		}
+		return arns, nil
+
+	case "aws_elb":
+		names, err := a.listClassicLoadBalancers(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list classic load balancers")
+		}
+		return names, nil
+
+	case "aws_cloudfront_distribution":
+		ids, err := a.listDistributions(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list CloudFront distributions")
+		}
+		return ids, nil
+
+	case "aws_eks_cluster":
+		names, err := a.listEKSClusters(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list EKS clusters")
+		}
+		return names, nil
+
+	case "aws_eks_node_group":
+		ids, err := a.listEKSNodeGroups(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list EKS node groups")
+		}
+		return ids, nil
+
+	case "aws_sqs_queue":
+		urls, err := a.listQueues(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list SQS queues")
+		}
+		return urls, nil
+
+	case "aws_sns_topic":
+		arns, err := a.listTopics(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list SNS topics")
+		}
+		return arns, nil
+
+	case "aws_sns_topic_subscription":
+		arns, err := a.listSubscriptions(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list SNS subscriptions")
+		}
+		return arns, nil
+
+	case "aws_security_group":
+		ids, err := a.listSecurityGroups(ctx)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			return nil, errors.Wrap(err, "Failed to list security groups")
+		}
+		return ids, nil
+
+	default:
+		return nil, fmt.Errorf("%s resource not yet supported for AWS provider", resourceType)
+	}
+}
+
+// logger returns the logger carried by ctx for the aws module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "aws")
+}
@@ -371,3 +371,96 @@ func TestEC2InfraInstance_AttributeValue_DirectAttributes(t *testing.T) {
 	assert.Contains(t, err.Error(), "'non_existent_field' attribute is not supported for EC2 instances or is an invalid attribute name")
 	assert.Empty(t, val)
 }
+
+func TestHandleEC2Metadata_Cache(t *testing.T) {
+	ctx := context.Background()
+	ec2Client := ec2.NewFromConfig(awsConfig)
+
+	runInstancesOutput, err := ec2Client.RunInstances(ctx, &ec2.RunInstancesInput{
+		ImageId:      aws.String("ami-0abcdef1234567890"),
+		InstanceType: types.InstanceTypeT2Micro,
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+	})
+	require.NoError(t, err)
+	instanceID := aws.ToString(runInstancesOutput.Instances[0].InstanceId)
+	defer func() {
+		_, _ = ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+	}()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	p, err := awsProvider.NewAWSProvider(&config.AWSConfig{CacheTTL: time.Minute, CacheDir: t.TempDir()},
+		awsProvider.WithEndpoint(localstackEndpoint), awsProvider.WithRegion("us-east-1"))
+	require.NoError(t, err)
+	provider := p.(*awsProvider.AWSProvider)
+
+	first, err := provider.HandleEC2Metadata(ctx, instanceID)
+	require.NoError(t, err)
+
+	// point the provider at an unreachable endpoint; the second lookup must be
+	// served from the cache
+	provider.Config.BaseEndpoint = aws.String("http://127.0.0.1:1")
+	second, err := provider.HandleEC2Metadata(ctx, instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, aws.ToString(first.Instance.InstanceId), aws.ToString(second.Instance.InstanceId))
+	assert.Equal(t, first.Instance.InstanceType, second.Instance.InstanceType)
+}
+
+func TestResolveReferences(t *testing.T) {
+	ctx := context.Background()
+	ec2Client := ec2.NewFromConfig(awsConfig)
+
+	vpc, err := ec2Client.CreateVpc(ctx, &ec2.CreateVpcInput{CidrBlock: aws.String("10.42.0.0/16")})
+	require.NoError(t, err)
+	vpcID := aws.ToString(vpc.Vpc.VpcId)
+	defer func() {
+		_, _ = ec2Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcID)})
+	}()
+
+	subnet, err := ec2Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		VpcId:     aws.String(vpcID),
+		CidrBlock: aws.String("10.42.1.0/24"),
+		TagSpecifications: []types.TagSpecification{{
+			ResourceType: types.ResourceTypeSubnet,
+			Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("public-a")}},
+		}},
+	})
+	require.NoError(t, err)
+	subnetID := aws.ToString(subnet.Subnet.SubnetId)
+	defer func() {
+		_, _ = ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: aws.String(subnetID)})
+	}()
+
+	group, err := ec2Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String("web-sg"),
+		Description: aws.String("web"),
+		VpcId:       aws.String(vpcID),
+	})
+	require.NoError(t, err)
+	groupID := aws.ToString(group.GroupId)
+	defer func() {
+		_, _ = ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(groupID)})
+	}()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	p, err := awsProvider.NewAWSProvider(&config.AWSConfig{},
+		awsProvider.WithEndpoint(localstackEndpoint), awsProvider.WithRegion("us-east-1"))
+	require.NoError(t, err)
+	provider := p.(*awsProvider.AWSProvider)
+
+	names, err := provider.ResolveReferences(ctx, "aws_instance", "subnet_id", []string{subnetID, "subnet-0000000000deleted"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{subnetID: "public-a"}, names)
+
+	names, err = provider.ResolveReferences(ctx, "aws_instance", "vpc_security_group_ids", []string{groupID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{groupID: "web-sg"}, names, "security groups without a Name tag use the group name")
+
+	names, err = provider.ResolveReferences(ctx, "aws_instance", "instance_type", []string{"t2.micro"})
+	require.NoError(t, err)
+	assert.Nil(t, names)
+}
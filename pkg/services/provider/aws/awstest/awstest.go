@@ -0,0 +1,722 @@
+// Package awstest provides in-memory implementations of the EC2, Elastic Load
+// Balancing, CloudFront, EKS, SQS and SNS APIs used by the AWS provider, so drift
+// flows can be unit tested without AWS or a LocalStack container:
+//
+//	fake := awstest.NewFakeEC2()
+//	fake.AddInstance(types.Instance{
+//		InstanceId:   aws.String("i-123"),
+//		InstanceType: types.InstanceTypeT2Micro,
+//	})
+//	provider := awstest.NewProvider(fake)
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+var _ awsProvider.EC2API = (*FakeEC2)(nil)
+
+// FakeEC2 implements awsProvider.EC2API over in-memory instances, subnets, security
+// groups and their rules, VPCs and images. Describe calls support the filters used by the provider and
+// mutating calls change the stored resources, so a remediation is visible to the next
+// describe call. Describe calls are paginated with MaxResults and NextToken like
+// EC2's. It is safe for concurrent use.
+type FakeEC2 struct {
+	mu             sync.Mutex
+	instances      map[string]types.Instance
+	userData       map[string]string
+	cpuCredits     map[string]string
+	subnets        map[string]types.Subnet
+	securityGroups map[string]types.SecurityGroup
+	rules          map[string]types.SecurityGroupRule
+	vpcs           map[string]types.Vpc
+	templates      map[string]types.LaunchTemplate
+	images         map[string]types.Image
+	errs           map[string]error
+	calls          []string
+}
+
+// NewFakeEC2 creates an empty FakeEC2.
+func NewFakeEC2() *FakeEC2 {
+	return &FakeEC2{
+		instances:      map[string]types.Instance{},
+		userData:       map[string]string{},
+		cpuCredits:     map[string]string{},
+		subnets:        map[string]types.Subnet{},
+		securityGroups: map[string]types.SecurityGroup{},
+		rules:          map[string]types.SecurityGroupRule{},
+		vpcs:           map[string]types.Vpc{},
+		templates:      map[string]types.LaunchTemplate{},
+		images:         map[string]types.Image{},
+		errs:           map[string]error{},
+	}
+}
+
+// NewProvider returns an AWS provider that reads and remediates EC2 resources through
+// client, with caching and the circuit breaker disabled.
+func NewProvider(client awsProvider.EC2API) *awsProvider.AWSProvider {
+	return &awsProvider.AWSProvider{EC2: client}
+}
+
+// AddInstance stores an instance, replacing any instance with the same id. Instances
+// without a state are running.
+func (f *FakeEC2) AddInstance(instance types.Instance) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if instance.State == nil {
+		instance.State = &types.InstanceState{Name: types.InstanceStateNameRunning}
+	}
+	f.instances[aws.ToString(instance.InstanceId)] = instance
+}
+
+// Instance returns the stored instance with the given id.
+func (f *FakeEC2) Instance(id string) (types.Instance, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	instance, ok := f.instances[id]
+	return instance, ok
+}
+
+// SetUserData sets the user data of an instance, given decoded.
+func (f *FakeEC2) SetUserData(id string, userData string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.userData[id] = userData
+}
+
+// SetCPUCredits sets the CPU credit option of a burstable instance, standard or
+// unlimited. Without one, t2 instances are standard and later T families unlimited,
+// as in EC2.
+func (f *FakeEC2) SetCPUCredits(id string, cpuCredits string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cpuCredits[id] = cpuCredits
+}
+
+// AddSubnet stores a subnet, replacing any subnet with the same id.
+func (f *FakeEC2) AddSubnet(subnet types.Subnet) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subnets[aws.ToString(subnet.SubnetId)] = subnet
+}
+
+// AddSecurityGroup stores a security group, replacing any group with the same id.
+func (f *FakeEC2) AddSecurityGroup(group types.SecurityGroup) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.securityGroups[aws.ToString(group.GroupId)] = group
+}
+
+// AddSecurityGroupRule stores a rule of a security group, replacing any rule with the
+// same id. Rules without an id are given one.
+func (f *FakeEC2) AddSecurityGroupRule(rule types.SecurityGroupRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rule.SecurityGroupRuleId == nil {
+		rule.SecurityGroupRuleId = aws.String(fmt.Sprintf("sgr-%d", len(f.rules)+1))
+	}
+	f.rules[aws.ToString(rule.SecurityGroupRuleId)] = rule
+}
+
+// AddVpc stores a VPC, replacing any VPC with the same id.
+func (f *FakeEC2) AddVpc(vpc types.Vpc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vpcs[aws.ToString(vpc.VpcId)] = vpc
+}
+
+// AddLaunchTemplate stores a launch template, replacing any template with the same
+// id. Instances launched from it carry the aws:ec2launchtemplate:id and
+// aws:ec2launchtemplate:version tags.
+func (f *FakeEC2) AddLaunchTemplate(template types.LaunchTemplate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.templates[aws.ToString(template.LaunchTemplateId)] = template
+}
+
+// AddImage stores an AMI, replacing any image with the same id. Images without a
+// state are available.
+func (f *FakeEC2) AddImage(image types.Image) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if image.State == "" {
+		image.State = types.ImageStateAvailable
+	}
+	f.images[aws.ToString(image.ImageId)] = image
+}
+
+// SetError makes every call of the named operation, e.g. "DescribeInstances", fail
+// with err until it is reset with a nil error.
+func (f *FakeEC2) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeEC2) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// page returns the page of items starting at the offset nextToken, of at most
+// maxResults items when set, and the token of the next page, as EC2 paginates its
+// describe calls.
+func page[T any](items []T, maxResults *int32, nextToken *string) ([]T, *string, error) {
+	start := 0
+	if nextToken != nil {
+		n, err := strconv.Atoi(*nextToken)
+		if err != nil || n < 0 || n > len(items) {
+			return nil, nil, fmt.Errorf("InvalidParameterValue: invalid NextToken %q", *nextToken)
+		}
+		start = n
+	}
+	if maxResults == nil {
+		return items[start:], nil, nil
+	}
+	if *maxResults < 5 || *maxResults > 1000 {
+		return nil, nil, fmt.Errorf("InvalidParameterValue: MaxResults must be between 5 and 1000, got %d", *maxResults)
+	}
+	end := min(start+int(*maxResults), len(items))
+	if end == len(items) {
+		return items[start:end], nil, nil
+	}
+	return items[start:end], aws.String(strconv.Itoa(end)), nil
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeEC2) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeInstances"); err != nil {
+		return nil, err
+	}
+
+	for _, id := range params.InstanceIds {
+		if _, ok := f.instances[id]; !ok {
+			return nil, fmt.Errorf("InvalidInstanceID.NotFound: the instance ID '%s' does not exist", id)
+		}
+	}
+
+	output := &ec2.DescribeInstancesOutput{}
+	for _, id := range sortedKeys(f.instances) {
+		instance := f.instances[id]
+		if len(params.InstanceIds) > 0 && !slices.Contains(params.InstanceIds, id) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string { return instanceValues(instance, name) }) {
+			continue
+		}
+		// every instance is returned in a reservation of its own, as when launched one by one
+		output.Reservations = append(output.Reservations, types.Reservation{Instances: []types.Instance{instance}})
+	}
+	var err error
+	output.Reservations, output.NextToken, err = page(output.Reservations, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+// DescribeInstanceAttribute returns the user data of an instance, base64 encoded as
+// by EC2. Other attributes are not supported.
+func (f *FakeEC2) DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeInstanceAttribute"); err != nil {
+		return nil, err
+	}
+	// like the SDK clients, fail calls made with a context that is done
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.InstanceId)
+	if _, ok := f.instances[id]; !ok {
+		return nil, fmt.Errorf("InvalidInstanceID.NotFound: the instance ID '%s' does not exist", id)
+	}
+	if params.Attribute != types.InstanceAttributeNameUserData {
+		return nil, fmt.Errorf("awstest: attribute %q is not supported", params.Attribute)
+	}
+	output := &ec2.DescribeInstanceAttributeOutput{InstanceId: aws.String(id), UserData: &types.AttributeValue{}}
+	if userData, ok := f.userData[id]; ok {
+		output.UserData.Value = aws.String(base64.StdEncoding.EncodeToString([]byte(userData)))
+	}
+	return output, nil
+}
+
+// DescribeInstanceCreditSpecifications returns the CPU credit option of the given
+// burstable instances.
+func (f *FakeEC2) DescribeInstanceCreditSpecifications(ctx context.Context, params *ec2.DescribeInstanceCreditSpecificationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceCreditSpecificationsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeInstanceCreditSpecifications"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeInstanceCreditSpecificationsOutput{}
+	for _, id := range params.InstanceIds {
+		instance, ok := f.instances[id]
+		if !ok {
+			return nil, fmt.Errorf("InvalidInstanceID.NotFound: the instance ID '%s' does not exist", id)
+		}
+		family, _, _ := strings.Cut(string(instance.InstanceType), ".")
+		if !strings.HasPrefix(family, "t") {
+			return nil, fmt.Errorf("UnsupportedOperation: the instance '%s' is not a burstable instance", id)
+		}
+		credits, ok := f.cpuCredits[id]
+		if !ok {
+			credits = "unlimited"
+			if family == "t2" {
+				credits = "standard"
+			}
+		}
+		output.InstanceCreditSpecifications = append(output.InstanceCreditSpecifications, types.InstanceCreditSpecification{
+			InstanceId: aws.String(id),
+			CpuCredits: aws.String(credits),
+		})
+	}
+	return output, nil
+}
+
+func (f *FakeEC2) DescribeLaunchTemplates(ctx context.Context, params *ec2.DescribeLaunchTemplatesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeLaunchTemplates"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeLaunchTemplatesOutput{}
+	for _, id := range sortedKeys(f.templates) {
+		template := f.templates[id]
+		if len(params.LaunchTemplateIds) > 0 && !slices.Contains(params.LaunchTemplateIds, id) {
+			continue
+		}
+		if len(params.LaunchTemplateNames) > 0 && !slices.Contains(params.LaunchTemplateNames, aws.ToString(template.LaunchTemplateName)) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string {
+			switch name {
+			case "launch-template-id":
+				return []string{id}
+			case "launch-template-name":
+				return []string{aws.ToString(template.LaunchTemplateName)}
+			}
+			return tagValues(template.Tags, name)
+		}) {
+			continue
+		}
+		output.LaunchTemplates = append(output.LaunchTemplates, template)
+	}
+	return output, nil
+}
+
+func (f *FakeEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeSubnets"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeSubnetsOutput{}
+	for _, id := range sortedKeys(f.subnets) {
+		subnet := f.subnets[id]
+		if len(params.SubnetIds) > 0 && !slices.Contains(params.SubnetIds, id) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string {
+			switch name {
+			case "subnet-id":
+				return []string{id}
+			case "vpc-id":
+				return []string{aws.ToString(subnet.VpcId)}
+			}
+			return tagValues(subnet.Tags, name)
+		}) {
+			continue
+		}
+		output.Subnets = append(output.Subnets, subnet)
+	}
+	var err error
+	output.Subnets, output.NextToken, err = page(output.Subnets, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+func (f *FakeEC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeSecurityGroups"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeSecurityGroupsOutput{}
+	for _, id := range sortedKeys(f.securityGroups) {
+		group := f.securityGroups[id]
+		if len(params.GroupIds) > 0 && !slices.Contains(params.GroupIds, id) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string {
+			switch name {
+			case "group-id":
+				return []string{id}
+			case "group-name":
+				return []string{aws.ToString(group.GroupName)}
+			case "vpc-id":
+				return []string{aws.ToString(group.VpcId)}
+			}
+			return tagValues(group.Tags, name)
+		}) {
+			continue
+		}
+		output.SecurityGroups = append(output.SecurityGroups, group)
+	}
+	var err error
+	output.SecurityGroups, output.NextToken, err = page(output.SecurityGroups, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+func (f *FakeEC2) DescribeSecurityGroupRules(ctx context.Context, params *ec2.DescribeSecurityGroupRulesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeSecurityGroupRules"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeSecurityGroupRulesOutput{}
+	for _, id := range sortedKeys(f.rules) {
+		rule := f.rules[id]
+		if len(params.SecurityGroupRuleIds) > 0 && !slices.Contains(params.SecurityGroupRuleIds, id) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string {
+			switch name {
+			case "group-id":
+				return []string{aws.ToString(rule.GroupId)}
+			case "security-group-rule-id":
+				return []string{id}
+			}
+			return tagValues(rule.Tags, name)
+		}) {
+			continue
+		}
+		output.SecurityGroupRules = append(output.SecurityGroupRules, rule)
+	}
+	var err error
+	output.SecurityGroupRules, output.NextToken, err = page(output.SecurityGroupRules, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+func (f *FakeEC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeVpcs"); err != nil {
+		return nil, err
+	}
+
+	output := &ec2.DescribeVpcsOutput{}
+	for _, id := range sortedKeys(f.vpcs) {
+		vpc := f.vpcs[id]
+		if len(params.VpcIds) > 0 && !slices.Contains(params.VpcIds, id) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string {
+			if name == "vpc-id" {
+				return []string{id}
+			}
+			return tagValues(vpc.Tags, name)
+		}) {
+			continue
+		}
+		output.Vpcs = append(output.Vpcs, vpc)
+	}
+	var err error
+	output.Vpcs, output.NextToken, err = page(output.Vpcs, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+// DescribeImages returns the stored images matching the ids, owners and filters of
+// params. Owners match the owner id or alias of an image. As in EC2, deprecated images
+// are left out unless IncludeDeprecated is set or they are requested by id, and
+// disabled images unless IncludeDisabled is set.
+func (f *FakeEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeImages"); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	output := &ec2.DescribeImagesOutput{}
+	for _, id := range sortedKeys(f.images) {
+		image := f.images[id]
+		if len(params.ImageIds) > 0 && !slices.Contains(params.ImageIds, id) {
+			continue
+		}
+		if len(params.Owners) > 0 && !slices.Contains(params.Owners, aws.ToString(image.OwnerId)) && !slices.Contains(params.Owners, aws.ToString(image.ImageOwnerAlias)) {
+			continue
+		}
+		deprecated := image.DeprecationTime != nil && aws.ToString(image.DeprecationTime) <= now
+		if deprecated && !aws.ToBool(params.IncludeDeprecated) && len(params.ImageIds) == 0 {
+			continue
+		}
+		if image.State == types.ImageStateDisabled && !aws.ToBool(params.IncludeDisabled) {
+			continue
+		}
+		if !matchesFilters(params.Filters, func(name string) []string { return imageValues(image, name) }) {
+			continue
+		}
+		output.Images = append(output.Images, image)
+	}
+	var err error
+	output.Images, output.NextToken, err = page(output.Images, params.MaxResults, params.NextToken)
+	return output, err
+}
+
+// ModifyInstanceAttribute changes the instance type or the security groups of an
+// instance. As in EC2, the instance type of a running instance cannot be changed.
+func (f *FakeEC2) ModifyInstanceAttribute(ctx context.Context, params *ec2.ModifyInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyInstanceAttributeOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ModifyInstanceAttribute"); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.InstanceId)
+	instance, ok := f.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("InvalidInstanceID.NotFound: the instance ID '%s' does not exist", id)
+	}
+	if params.InstanceType != nil {
+		if instance.State.Name != types.InstanceStateNameStopped {
+			return nil, fmt.Errorf("IncorrectInstanceState: the instance '%s' is not in the 'stopped' state", id)
+		}
+		instance.InstanceType = types.InstanceType(aws.ToString(params.InstanceType.Value))
+	}
+	if len(params.Groups) > 0 {
+		instance.SecurityGroups = nil
+		for _, groupId := range params.Groups {
+			group := types.GroupIdentifier{GroupId: aws.String(groupId)}
+			if stored, ok := f.securityGroups[groupId]; ok {
+				group.GroupName = stored.GroupName
+			}
+			instance.SecurityGroups = append(instance.SecurityGroups, group)
+		}
+	}
+	f.instances[id] = instance
+	return &ec2.ModifyInstanceAttributeOutput{}, nil
+}
+
+// CreateTags adds or overwrites the tags of the given instances.
+func (f *FakeEC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CreateTags"); err != nil {
+		return nil, err
+	}
+
+	for _, id := range params.Resources {
+		instance, ok := f.instances[id]
+		if !ok {
+			return nil, fmt.Errorf("InvalidID: the ID '%s' is not valid", id)
+		}
+		for _, tag := range params.Tags {
+			instance.Tags = slices.DeleteFunc(slices.Clone(instance.Tags), func(existing types.Tag) bool {
+				return aws.ToString(existing.Key) == aws.ToString(tag.Key)
+			})
+			instance.Tags = append(instance.Tags, tag)
+		}
+		f.instances[id] = instance
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// DeleteTags removes tags from the given instances. A tag with a value is only
+// removed when its value matches.
+func (f *FakeEC2) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteTags"); err != nil {
+		return nil, err
+	}
+
+	for _, id := range params.Resources {
+		instance, ok := f.instances[id]
+		if !ok {
+			return nil, fmt.Errorf("InvalidID: the ID '%s' is not valid", id)
+		}
+		instance.Tags = slices.DeleteFunc(slices.Clone(instance.Tags), func(existing types.Tag) bool {
+			for _, tag := range params.Tags {
+				if aws.ToString(existing.Key) == aws.ToString(tag.Key) &&
+					(tag.Value == nil || aws.ToString(existing.Value) == aws.ToString(tag.Value)) {
+					return true
+				}
+			}
+			return false
+		})
+		f.instances[id] = instance
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+// StopInstances stops the given instances immediately.
+func (f *FakeEC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("StopInstances"); err != nil {
+		return nil, err
+	}
+	changes, err := f.setState(params.InstanceIds, types.InstanceStateNameStopped)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.StopInstancesOutput{StoppingInstances: changes}, nil
+}
+
+// StartInstances starts the given instances immediately.
+func (f *FakeEC2) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("StartInstances"); err != nil {
+		return nil, err
+	}
+	changes, err := f.setState(params.InstanceIds, types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.StartInstancesOutput{StartingInstances: changes}, nil
+}
+
+// setState moves instances to the given state. The caller must hold f.mu.
+func (f *FakeEC2) setState(ids []string, state types.InstanceStateName) ([]types.InstanceStateChange, error) {
+	var changes []types.InstanceStateChange
+	for _, id := range ids {
+		instance, ok := f.instances[id]
+		if !ok {
+			return nil, fmt.Errorf("InvalidInstanceID.NotFound: the instance ID '%s' does not exist", id)
+		}
+		changes = append(changes, types.InstanceStateChange{
+			InstanceId:    aws.String(id),
+			PreviousState: instance.State,
+			CurrentState:  &types.InstanceState{Name: state},
+		})
+		instance.State = &types.InstanceState{Name: state}
+		f.instances[id] = instance
+	}
+	return changes, nil
+}
+
+// instanceValues returns the values of an instance for a describe filter.
+func instanceValues(instance types.Instance, name string) []string {
+	switch name {
+	case "instance-id":
+		return []string{aws.ToString(instance.InstanceId)}
+	case "instance-state-name":
+		return []string{string(instance.State.Name)}
+	case "instance-type":
+		return []string{string(instance.InstanceType)}
+	case "subnet-id":
+		return []string{aws.ToString(instance.SubnetId)}
+	case "vpc-id":
+		return []string{aws.ToString(instance.VpcId)}
+	}
+	return tagValues(instance.Tags, name)
+}
+
+// imageValues returns the values of an image for a describe filter.
+func imageValues(image types.Image, name string) []string {
+	switch name {
+	case "image-id":
+		return []string{aws.ToString(image.ImageId)}
+	case "name":
+		return []string{aws.ToString(image.Name)}
+	case "architecture":
+		return []string{string(image.Architecture)}
+	case "owner-id":
+		return []string{aws.ToString(image.OwnerId)}
+	case "owner-alias":
+		return []string{aws.ToString(image.ImageOwnerAlias)}
+	case "state":
+		return []string{string(image.State)}
+	case "virtualization-type":
+		return []string{string(image.VirtualizationType)}
+	case "root-device-type":
+		return []string{string(image.RootDeviceType)}
+	}
+	return tagValues(image.Tags, name)
+}
+
+// tagValues returns the values of a tag:<key> filter, or nil for any other filter.
+func tagValues(tags []types.Tag, name string) []string {
+	key, ok := strings.CutPrefix(name, "tag:")
+	if !ok {
+		return nil
+	}
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return []string{aws.ToString(tag.Value)}
+		}
+	}
+	return nil
+}
+
+// matchesFilters reports whether a resource matches every filter, a filter matching
+// when one of the resource's values for it matches one of the filter values. Filter
+// values may hold the * and ? wildcards, as in EC2. Filters the fake does not know
+// match nothing.
+func matchesFilters(filters []types.Filter, values func(name string) []string) bool {
+	for _, filter := range filters {
+		matched := false
+		for _, value := range values(aws.ToString(filter.Name)) {
+			if slices.ContainsFunc(filter.Values, func(pattern string) bool { return wildcardMatch(pattern, value) }) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcardMatch reports whether value matches pattern, in which * matches any run of
+// characters and ? any single character.
+func wildcardMatch(pattern string, value string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == value
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+	return regexp.MustCompile("^" + expr + "$").MatchString(value)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
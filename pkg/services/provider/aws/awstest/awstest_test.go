@@ -0,0 +1,450 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func instanceResource(id string) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type: "aws_instance",
+		Instances: []statemanager.ResourceInstance{
+			{Attributes: map[string]any{"id": id, "instance_type": "t2.micro"}},
+		},
+	}
+}
+
+func TestProvider_InfrastructureMetadata(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{
+		InstanceId:   aws.String("i-123"),
+		InstanceType: types.InstanceTypeT2Large,
+		Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+	})
+	p := awstest.NewProvider(fake)
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	instanceType, err := resource.AttributeValue("instance_type")
+	require.NoError(t, err)
+	assert.Equal(t, "t2.large", instanceType)
+	name, err := resource.AttributeValue("tags.Name")
+	require.NoError(t, err)
+	assert.Equal(t, "web", name)
+
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-missing"))
+	assert.ErrorContains(t, err, "not running")
+}
+
+func TestProvider_InfrastructureMetadata_InstanceDetails(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{
+		InstanceId:         aws.String("i-123"),
+		InstanceType:       types.InstanceTypeT3Micro,
+		RootDeviceName:     aws.String("/dev/xvda"),
+		Placement:          &types.Placement{GroupName: aws.String("cluster-a")},
+		Monitoring:         &types.Monitoring{State: types.MonitoringStateEnabled},
+		HibernationOptions: &types.HibernationOptions{Configured: aws.Bool(true)},
+		IamInstanceProfile: &types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/app/web")},
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-root")}},
+			{DeviceName: aws.String("/dev/sdf"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-data"), DeleteOnTermination: aws.Bool(true)}},
+		},
+	})
+	fake.SetUserData("i-123", "#!/bin/bash\necho hello\n")
+	p := awstest.NewProvider(fake)
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DescribeInstances"}, fake.Calls(), "details are only read once asked for")
+
+	for attribute, expected := range map[string]string{
+		"placement_group":                  "cluster-a",
+		"monitoring":                       "true",
+		"hibernation":                      "true",
+		"iam_instance_profile":             "web",
+		"ebs_block_device":                 `[{"delete_on_termination":true,"device_name":"/dev/sdf","volume_id":"vol-data"}]`,
+		"ebs_block_device.volume_id":       "vol-data",
+		"user_data":                        "#!/bin/bash\necho hello\n",
+		"credit_specification":             `[{"cpu_credits":"unlimited"}]`,
+		"credit_specification.cpu_credits": "unlimited",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+	assert.ElementsMatch(t, []string{"DescribeInstances", "DescribeInstanceAttribute", "DescribeInstanceCreditSpecifications"}, fake.Calls(),
+		"each detail is read once")
+
+	// instances of other families have no credit specification
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-456"), InstanceType: types.InstanceTypeM5Large})
+	resource, err = p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-456"))
+	require.NoError(t, err)
+	credits, err := resource.AttributeValue("credit_specification")
+	require.NoError(t, err)
+	assert.Empty(t, credits)
+	userData, err := resource.AttributeValue("user_data")
+	require.NoError(t, err)
+	assert.Empty(t, userData)
+
+	fake.SetError("DescribeInstanceAttribute", errors.New("UnauthorizedOperation"))
+	resource, err = p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-456"))
+	require.NoError(t, err)
+	_, err = resource.AttributeValue("user_data")
+	assert.ErrorContains(t, err, "UnauthorizedOperation")
+}
+
+func TestProvider_InstanceDetails_CheckContext(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-123")})
+	fake.SetUserData("i-123", "echo hello")
+
+	// the lookup context ends before the attributes are read
+	lookup, cancel := context.WithCancel(context.Background())
+	resource, err := awstest.NewProvider(fake).InfrastructreMetadata(lookup, "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	cancel()
+
+	value, err := provider.LiveAttributeValue(context.Background(), resource, "user_data")
+	require.NoError(t, err, "details are read with the context of the check")
+	assert.Equal(t, "echo hello", value)
+
+	check, cancel := context.WithCancel(context.Background())
+	cancel()
+	resource, err = awstest.NewProvider(fake).InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	_, err = provider.LiveAttributeValue(check, resource, "user_data")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestEC2InfraInstance_Attributes(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{
+		InstanceId:   aws.String("i-123"),
+		InstanceType: types.InstanceTypeM5Large,
+		KeyName:      aws.String("deploy"),
+		Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+	})
+	fake.SetError("DescribeInstanceAttribute", errors.New("UnauthorizedOperation"))
+	resource, err := awstest.NewProvider(fake).InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+
+	attributes, err := resource.Attributes()
+	assert.ErrorContains(t, err, "user_data", "attributes that cannot be read are reported")
+	assert.Equal(t, "m5.large", attributes["instance_type"])
+	assert.Equal(t, "deploy", attributes["key_name"])
+	assert.Equal(t, "web", attributes["tags.Name"])
+	assert.Equal(t, "running", attributes["instance_state"])
+	assert.Equal(t, "i-123", attributes["id"], "attributes are named after the Terraform schema")
+	assert.NotContains(t, attributes, "instance_id")
+	assert.Contains(t, attributes, "vpc_security_group_ids")
+	assert.Contains(t, attributes, "cpu_threads_per_core")
+	assert.NotContains(t, attributes, "user_data")
+	assert.NotContains(t, attributes, "ebs_block_device.volume_id", "block attributes are only returned as part of their block")
+}
+
+func TestProvider_InfrastructureMetadata_LaunchTemplate(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddLaunchTemplate(types.LaunchTemplate{
+		LaunchTemplateId:     aws.String("lt-1"),
+		LaunchTemplateName:   aws.String("web"),
+		DefaultVersionNumber: aws.Int64(3),
+		LatestVersionNumber:  aws.Int64(5),
+	})
+	fake.AddInstance(types.Instance{
+		InstanceId: aws.String("i-123"),
+		Tags: []types.Tag{
+			{Key: aws.String("aws:ec2launchtemplate:id"), Value: aws.String("lt-1")},
+			{Key: aws.String("aws:ec2launchtemplate:version"), Value: aws.String("3")},
+		},
+	})
+	p := awstest.NewProvider(fake)
+
+	tests := []struct {
+		stateVersion string
+		want         string
+	}{
+		{"$Default", "$Default"},
+		{"$Latest", "3"},
+		{"3", "3"},
+		{"4", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stateVersion, func(t *testing.T) {
+			resource := instanceResource("i-123")
+			resource.Instances[0].Attributes["launch_template"] = []any{map[string]any{"id": "lt-1", "name": "web", "version": tt.stateVersion}}
+			live, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource)
+			require.NoError(t, err)
+
+			version, err := live.AttributeValue("launch_template.version")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+
+	live, err := p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	block, err := live.AttributeValue("launch_template")
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":"lt-1","name":"web","version":"3"}]`, block)
+	attributes, err := live.Attributes()
+	require.NoError(t, err)
+	assert.NotContains(t, attributes, "tags.aws:ec2launchtemplate:id", "tags added by AWS are not compared")
+
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-456")})
+	live, err = p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-456"))
+	require.NoError(t, err)
+	block, err = live.AttributeValue("launch_template")
+	require.NoError(t, err)
+	assert.Empty(t, block, "instances launched without a template have none")
+}
+
+func TestProvider_InfrastructureMetadata_NetworkInterfaces(t *testing.T) {
+	eni := func(id string, deviceIndex int32, sourceDestCheck bool, ips ...string) types.InstanceNetworkInterface {
+		networkInterface := types.InstanceNetworkInterface{
+			NetworkInterfaceId: aws.String(id),
+			SourceDestCheck:    aws.Bool(sourceDestCheck),
+			Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(deviceIndex), NetworkCardIndex: aws.Int32(0)},
+		}
+		for i, ip := range ips {
+			networkInterface.PrivateIpAddresses = append(networkInterface.PrivateIpAddresses, types.InstancePrivateIpAddress{
+				PrivateIpAddress: aws.String(ip),
+				Primary:          aws.Bool(i == 0),
+			})
+		}
+		return networkInterface
+	}
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{
+		InstanceId: aws.String("i-123"),
+		// the primary interface is not necessarily listed first
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			eni("eni-nat", 1, false, "10.0.1.5"),
+			eni("eni-primary", 0, true, "10.0.0.5", "10.0.0.6", "10.0.0.7"),
+		},
+	})
+
+	live, err := awstest.NewProvider(fake).InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"source_dest_check":                      "true",
+		"primary_network_interface_id":           "eni-primary",
+		"secondary_private_ips":                  "10.0.0.6,10.0.0.7",
+		"network_interface.network_interface_id": "eni-primary,eni-nat",
+		"network_interface.device_index":         "0,1",
+		"network_interface.source_dest_check":    "true,false",
+		"network_interface.private_ips":          "10.0.0.5 10.0.0.6 10.0.0.7,10.0.1.5",
+		"network_interface": `[{"delete_on_termination":false,"device_index":0,"network_card_index":0,"network_interface_id":"eni-primary"},` +
+			`{"delete_on_termination":false,"device_index":1,"network_card_index":0,"network_interface_id":"eni-nat"}]`,
+	} {
+		value, err := live.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+}
+
+func TestProvider_ListResourceIds(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-2")})
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-1"), State: &types.InstanceState{Name: types.InstanceStateNameStopped}})
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-3"), State: &types.InstanceState{Name: types.InstanceStateNameTerminated}})
+
+	ids, err := awstest.NewProvider(fake).ListResourceIds(context.Background(), "aws_instance")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i-1", "i-2"}, ids)
+}
+
+func TestProvider_ListResourceIds_Pages(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	var want []string
+	for i := range 12 {
+		id := fmt.Sprintf("i-%02d", i)
+		fake.AddInstance(types.Instance{InstanceId: aws.String(id)})
+		want = append(want, id)
+	}
+	p := awstest.NewProvider(fake)
+	p.PageSize = 5
+
+	ids, err := p.ListResourceIds(context.Background(), "aws_instance")
+	require.NoError(t, err)
+	assert.Equal(t, want, ids)
+	assert.Equal(t, []string{"DescribeInstances", "DescribeInstances", "DescribeInstances"}, fake.Calls())
+}
+
+func TestProvider_ResolveReferences_Batches(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	var ids []string
+	for i := range 450 {
+		id := fmt.Sprintf("subnet-%03d", i)
+		fake.AddSubnet(types.Subnet{SubnetId: aws.String(id), Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("private-" + id)}}})
+		ids = append(ids, id)
+	}
+	p := awstest.NewProvider(fake)
+	p.PageSize = 150
+
+	names, err := p.ResolveReferences(context.Background(), "aws_instance", "subnet_id", ids)
+	require.NoError(t, err)
+	assert.Len(t, names, 450)
+	assert.Equal(t, "private-subnet-449", names["subnet-449"])
+	// batches of 200, 200 and 50 ids, the first two over two pages
+	assert.Len(t, fake.Calls(), 5)
+}
+
+func TestProvider_ResolveReferences(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddSubnet(types.Subnet{SubnetId: aws.String("subnet-1"), Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("private-a")}}})
+	fake.AddSecurityGroup(types.SecurityGroup{GroupId: aws.String("sg-1"), GroupName: aws.String("web")})
+	fake.AddVpc(types.Vpc{VpcId: aws.String("vpc-1"), IsDefault: aws.Bool(true)})
+	p := awstest.NewProvider(fake)
+
+	names, err := p.ResolveReferences(context.Background(), "aws_instance", "subnet_id", []string{"subnet-1", "subnet-deleted"})
+	require.NoError(t, err)
+	assert.Equal(t, "private-a", names["subnet-1"])
+	assert.NotContains(t, names, "subnet-deleted")
+
+	names, err = p.ResolveReferences(context.Background(), "aws_instance", "vpc_security_group_ids", []string{"sg-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "web", names["sg-1"])
+}
+
+func TestProvider_ResolveReferences_AMI(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddImage(types.Image{ImageId: aws.String("ami-1"), Name: aws.String("al2023-ami-2023.4.20240401.0-x86_64"), CreationDate: aws.String("2024-04-01T10:00:00.000Z")})
+	fake.AddImage(types.Image{ImageId: aws.String("ami-2"), Name: aws.String("al2023-ami-2023.1.20230301.0-x86_64"), CreationDate: aws.String("2023-03-01T10:00:00.000Z"), DeprecationTime: aws.String("2024-01-01T00:00:00.000Z")})
+	p := awstest.NewProvider(fake)
+
+	names, err := p.ResolveReferences(context.Background(), "aws_instance", "ami", []string{"ami-1", "ami-2", "ami-deleted"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ami-1": "al2023-ami-2023.4.20240401.0-x86_64, created 2024-04-01",
+		"ami-2": "al2023-ami-2023.1.20230301.0-x86_64, created 2023-03-01",
+	}, names, "deprecated images are named too")
+}
+
+func TestProvider_Advise(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-123"), ImageId: aws.String("ami-old")})
+	image := func(id, name, created string) types.Image {
+		return types.Image{ImageId: aws.String(id), Name: aws.String(name), CreationDate: aws.String(created), ImageOwnerAlias: aws.String("amazon")}
+	}
+	fake.AddImage(image("ami-old", "al2023-ami-2023.3.20240201.0-x86_64", "2024-02-01T10:00:00.000Z"))
+	fake.AddImage(image("ami-new", "al2023-ami-2023.4.20240401.0-x86_64", "2024-04-01T10:00:00.000Z"))
+	fake.AddImage(image("ami-arm", "al2023-ami-2023.5.20240501.0-arm64", "2024-05-01T10:00:00.000Z"))
+	fake.AddImage(image("ami-minimal", "al2023-ami-minimal-2023.5.20240501.0-x86_64", "2024-05-01T10:00:00.000Z"))
+	p := awstest.NewProvider(fake)
+
+	resource := statemanager.StateResource{
+		Type: "aws_instance",
+		Name: "web",
+		Instances: []statemanager.ResourceInstance{
+			{Attributes: map[string]any{"id": "i-123", "ami": "ami-old"}},
+		},
+	}
+	data := []statemanager.StateResource{{
+		Mode: "data",
+		Type: "aws_ami",
+		Name: "al2023",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"id":          "ami-old",
+			"most_recent": true,
+			"owners":      []any{"amazon"},
+			"name_regex":  "^al2023-ami-2023",
+			"filter":      []any{map[string]any{"name": "name", "values": []any{"al2023-ami-*-x86_64"}}},
+		}}},
+	}}
+	live, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource)
+	require.NoError(t, err)
+
+	advisories, err := p.Advise(context.Background(), live, resource, data)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Advisory{{
+		Attribute: "ami",
+		Message:   "newer AMI ami-new (al2023-ami-2023.4.20240401.0-x86_64, created 2024-04-01) matches data.aws_ami.al2023",
+		Suggested: "ami-new",
+	}}, advisories)
+
+	// the images matching the data source are described once per run
+	again, err := p.Advise(context.Background(), live, resource, data)
+	require.NoError(t, err)
+	assert.Equal(t, advisories, again)
+	describes := 0
+	for _, call := range fake.Calls() {
+		if call == "DescribeImages" {
+			describes++
+		}
+	}
+	assert.Equal(t, 1, describes)
+
+	advisories, err = p.Advise(context.Background(), live, resource, nil)
+	require.NoError(t, err)
+	assert.Empty(t, advisories, "AMIs not selected by a data source get no advisory")
+
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-123"), ImageId: aws.String("ami-new")})
+	live, err = p.InfrastructreMetadata(context.Background(), "aws_instance", resource)
+	require.NoError(t, err)
+	advisories, err = p.Advise(context.Background(), live, resource, data)
+	require.NoError(t, err)
+	assert.Empty(t, advisories, "drift of the AMI is reported as drift")
+}
+
+func TestProvider_Remediate(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{
+		InstanceId:   aws.String("i-123"),
+		InstanceType: types.InstanceTypeT2Large,
+		Tags:         []types.Tag{{Key: aws.String("Env"), Value: aws.String("dev")}},
+	})
+	p := awstest.NewProvider(fake)
+	ctx := context.Background()
+
+	// a running instance is stopped, resized and started again
+	require.NoError(t, p.Remediate(ctx, instanceResource("i-123"), provider.Change{Attribute: "instance_type", DesiredValue: "t2.micro"}))
+	instance, _ := fake.Instance("i-123")
+	assert.Equal(t, types.InstanceTypeT2Micro, instance.InstanceType)
+	assert.Equal(t, types.InstanceStateNameRunning, instance.State.Name)
+	assert.Equal(t, []string{"DescribeInstances", "StopInstances", "DescribeInstances", "ModifyInstanceAttribute", "StartInstances", "DescribeInstances"}, fake.Calls())
+
+	require.NoError(t, p.Remediate(ctx, instanceResource("i-123"), provider.Change{Attribute: "tags.Env", DesiredValue: "prod"}))
+	require.NoError(t, p.Remediate(ctx, instanceResource("i-123"), provider.Change{Attribute: "tags.Owner", DesiredValue: "platform"}))
+	require.NoError(t, p.Remediate(ctx, instanceResource("i-123"), provider.Change{Attribute: "tags.Env", DesiredValue: ""}))
+	instance, _ = fake.Instance("i-123")
+	assert.Equal(t, []types.Tag{{Key: aws.String("Owner"), Value: aws.String("platform")}}, instance.Tags)
+}
+
+func TestProvider_Remediate_FailedInstanceTypeRestartsInstance(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-123"), InstanceType: types.InstanceTypeT2Large})
+	fake.SetError("ModifyInstanceAttribute", errors.New("InsufficientInstanceCapacity"))
+	p := awstest.NewProvider(fake)
+
+	err := p.Remediate(context.Background(), instanceResource("i-123"), provider.Change{Attribute: "instance_type", DesiredValue: "t2.micro"})
+	assert.ErrorContains(t, err, "InsufficientInstanceCapacity")
+	assert.Equal(t, []string{"DescribeInstances", "StopInstances", "DescribeInstances", "ModifyInstanceAttribute", "StartInstances", "DescribeInstances"}, fake.Calls())
+	instance, _ := fake.Instance("i-123")
+	assert.Equal(t, types.InstanceStateNameRunning, instance.State.Name, "the instance is not left stopped")
+	assert.Equal(t, types.InstanceTypeT2Large, instance.InstanceType)
+}
+
+func TestFakeEC2_SetError(t *testing.T) {
+	fake := awstest.NewFakeEC2()
+	fake.AddInstance(types.Instance{InstanceId: aws.String("i-123")})
+	p := awstest.NewProvider(fake)
+
+	fake.SetError("DescribeInstances", errors.New("RequestLimitExceeded"))
+	_, err := p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	assert.ErrorContains(t, err, "RequestLimitExceeded")
+
+	fake.SetError("DescribeInstances", nil)
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_instance", instanceResource("i-123"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,131 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+var _ awsProvider.CloudFrontAPI = (*FakeCloudFront)(nil)
+
+// FakeCloudFront implements awsProvider.CloudFrontAPI over in-memory distributions.
+// ListDistributions is paginated with MaxItems and Marker like CloudFront's. It is
+// safe for concurrent use.
+type FakeCloudFront struct {
+	mu            sync.Mutex
+	distributions map[string]cftypes.Distribution
+	tags          map[string]map[string]string
+	errs          map[string]error
+	calls         []string
+}
+
+// NewFakeCloudFront creates an empty FakeCloudFront.
+func NewFakeCloudFront() *FakeCloudFront {
+	return &FakeCloudFront{
+		distributions: map[string]cftypes.Distribution{},
+		tags:          map[string]map[string]string{},
+		errs:          map[string]error{},
+	}
+}
+
+// AddDistribution stores a distribution with its tags, replacing any distribution
+// with the same id. Distributions without an ARN are given one from their id.
+func (f *FakeCloudFront) AddDistribution(distribution cftypes.Distribution, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := aws.ToString(distribution.Id)
+	if distribution.ARN == nil {
+		distribution.ARN = aws.String("arn:aws:cloudfront::123456789012:distribution/" + id)
+	}
+	f.distributions[id] = distribution
+	f.tags[aws.ToString(distribution.ARN)] = tags
+}
+
+// SetError makes every call of the named operation, e.g. "GetDistribution", fail
+// with err until it is reset with a nil error.
+func (f *FakeCloudFront) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeCloudFront) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeCloudFront) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeCloudFront) GetDistribution(ctx context.Context, params *cloudfront.GetDistributionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.GetDistributionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetDistribution"); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.Id)
+	distribution, ok := f.distributions[id]
+	if !ok {
+		return nil, fmt.Errorf("NoSuchDistribution: the specified distribution '%s' does not exist", id)
+	}
+	return &cloudfront.GetDistributionOutput{Distribution: &distribution}, nil
+}
+
+func (f *FakeCloudFront) ListDistributions(ctx context.Context, params *cloudfront.ListDistributionsInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListDistributionsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListDistributions"); err != nil {
+		return nil, err
+	}
+
+	var summaries []cftypes.DistributionSummary
+	for _, id := range sortedKeys(f.distributions) {
+		distribution := f.distributions[id]
+		summaries = append(summaries, cftypes.DistributionSummary{Id: distribution.Id, ARN: distribution.ARN, DomainName: distribution.DomainName})
+	}
+	summaries, next, err := markerPage(summaries, params.MaxItems, params.Marker, 1000)
+	if err != nil {
+		return nil, err
+	}
+	list := &cftypes.DistributionList{
+		Items:       summaries,
+		Quantity:    aws.Int32(int32(len(summaries))),
+		IsTruncated: aws.Bool(next != nil),
+		NextMarker:  next,
+		Marker:      params.Marker,
+		MaxItems:    params.MaxItems,
+	}
+	return &cloudfront.ListDistributionsOutput{DistributionList: list}, nil
+}
+
+func (f *FakeCloudFront) ListTagsForResource(ctx context.Context, params *cloudfront.ListTagsForResourceInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListTagsForResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListTagsForResource"); err != nil {
+		return nil, err
+	}
+
+	output := &cloudfront.ListTagsForResourceOutput{Tags: &cftypes.Tags{}}
+	tags := f.tags[aws.ToString(params.Resource)]
+	for _, key := range sortedKeys(tags) {
+		output.Tags.Items = append(output.Tags.Items, cftypes.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return output, nil
+}
@@ -0,0 +1,136 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func distribution(id string) cftypes.Distribution {
+	return cftypes.Distribution{
+		Id:         aws.String(id),
+		DomainName: aws.String("d111111abcdef8.cloudfront.net"),
+		Status:     aws.String("Deployed"),
+		DistributionConfig: &cftypes.DistributionConfig{
+			Enabled: aws.Bool(true),
+			Aliases: &cftypes.Aliases{Items: []string{"www.example.com", "example.com"}},
+			Origins: &cftypes.Origins{Items: []cftypes.Origin{
+				{
+					Id:                 aws.String("api"),
+					DomainName:         aws.String("api.example.com"),
+					ConnectionAttempts: aws.Int32(3),
+					CustomOriginConfig: &cftypes.CustomOriginConfig{
+						HTTPPort:             aws.Int32(80),
+						HTTPSPort:            aws.Int32(443),
+						OriginProtocolPolicy: cftypes.OriginProtocolPolicyHttpsOnly,
+						OriginSslProtocols:   &cftypes.OriginSslProtocols{Items: []cftypes.SslProtocol{cftypes.SslProtocolTLSv12}},
+					},
+				},
+				{
+					Id:             aws.String("assets"),
+					DomainName:     aws.String("assets.s3.amazonaws.com"),
+					S3OriginConfig: &cftypes.S3OriginConfig{OriginAccessIdentity: aws.String("")},
+				},
+			}},
+			DefaultCacheBehavior: &cftypes.DefaultCacheBehavior{
+				TargetOriginId:       aws.String("api"),
+				ViewerProtocolPolicy: cftypes.ViewerProtocolPolicyRedirectToHttps,
+				Compress:             aws.Bool(true),
+				AllowedMethods: &cftypes.AllowedMethods{
+					Items:         []cftypes.Method{cftypes.MethodGet, cftypes.MethodHead},
+					CachedMethods: &cftypes.CachedMethods{Items: []cftypes.Method{cftypes.MethodGet, cftypes.MethodHead}},
+				},
+			},
+			ViewerCertificate: &cftypes.ViewerCertificate{
+				ACMCertificateArn:      aws.String("arn:aws:acm:us-east-1:123456789012:certificate/abc"),
+				MinimumProtocolVersion: cftypes.MinimumProtocolVersionTLSv122021,
+				SSLSupportMethod:       cftypes.SSLSupportMethodSniOnly,
+			},
+		},
+	}
+}
+
+func TestProvider_InfrastructureMetadata_CloudFront(t *testing.T) {
+	fake := awstest.NewFakeCloudFront()
+	fake.AddDistribution(distribution("E123"), map[string]string{"Team": "web"})
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.CloudFront = fake
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_cloudfront_distribution", resourceWithId("aws_cloudfront_distribution", "E123"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"enabled":                                "true",
+		"aliases":                                "example.com,www.example.com",
+		"hosted_zone_id":                         "Z2FDTNDATAQYW2",
+		"origin.origin_id":                       "api,assets",
+		"default_cache_behavior.compress":        "true",
+		"viewer_certificate.ssl_support_method":  "sni-only",
+		"viewer_certificate.acm_certificate_arn": "arn:aws:acm:us-east-1:123456789012:certificate/abc",
+		"tags.Team":                              "web",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_cloudfront_distribution", resourceWithId("aws_cloudfront_distribution", "EMISSING"))
+	assert.ErrorContains(t, err, "NoSuchDistribution")
+}
+
+func TestProvider_CloudFront_BlockDrift(t *testing.T) {
+	live := distribution("E123")
+	live.DistributionConfig.Origins.Items[0].CustomOriginConfig.HTTPSPort = aws.Int32(8443)
+	fake := awstest.NewFakeCloudFront()
+	fake.AddDistribution(live, nil)
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.CloudFront = fake
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_cloudfront_distribution", resourceWithId("aws_cloudfront_distribution", "E123"))
+	require.NoError(t, err)
+
+	// the state holds the origins in another order and keys that are not read
+	desired := statemanager.StateResource{
+		Type: "aws_cloudfront_distribution",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"id": "E123",
+			"origin": []any{
+				map[string]any{
+					"origin_id": "assets", "domain_name": "assets.s3.amazonaws.com", "origin_path": "",
+					"connection_attempts": 0, "connection_timeout": 0, "origin_access_control_id": "",
+					"custom_origin_config": []any{}, "origin_shield": []any{},
+					"s3_origin_config": []any{map[string]any{"origin_access_identity": ""}},
+				},
+				map[string]any{
+					"origin_id": "api", "domain_name": "api.example.com", "origin_path": "",
+					"connection_attempts": 3, "connection_timeout": 0, "origin_access_control_id": "",
+					"custom_origin_config": []any{map[string]any{
+						"http_port": 80, "https_port": 443, "origin_protocol_policy": "https-only",
+						"origin_ssl_protocols": []any{"TLSv1.2"}, "origin_keepalive_timeout": 0, "origin_read_timeout": 0,
+					}},
+					"s3_origin_config": []any{}, "origin_shield": []any{},
+				},
+			},
+			"default_cache_behavior": []any{map[string]any{
+				"target_origin_id": "api", "viewer_protocol_policy": "redirect-to-https", "compress": true,
+				"allowed_methods": []any{"HEAD", "GET"}, "cached_methods": []any{"GET", "HEAD"},
+				"cache_policy_id": "", "origin_request_policy_id": "", "response_headers_policy_id": "",
+				"default_ttl": 0, "min_ttl": 0, "max_ttl": 0, "smooth_streaming": false,
+			}},
+		}}},
+	}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), resource, desired, []string{"origin", "default_cache_behavior"})
+	require.NoError(t, err)
+	drifted := map[string]string{}
+	for _, item := range report.DriftDetails {
+		drifted[item.Field] = item.DriftType
+	}
+	assert.Equal(t, driftchecker.AttributeValueChanged, drifted["origin"])
+	assert.Equal(t, driftchecker.Match, drifted["default_cache_behavior"], "blocks are compared as sets on the keys read")
+}
@@ -0,0 +1,142 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+var _ awsProvider.EKSAPI = (*FakeEKS)(nil)
+
+// FakeEKS implements awsProvider.EKSAPI over in-memory clusters and managed node
+// groups. ListClusters and ListNodegroups are paginated with MaxResults and NextToken
+// like EKS's. It is safe for concurrent use.
+type FakeEKS struct {
+	mu         sync.Mutex
+	clusters   map[string]ekstypes.Cluster
+	nodeGroups map[string]map[string]ekstypes.Nodegroup
+	errs       map[string]error
+	calls      []string
+}
+
+// NewFakeEKS creates an empty FakeEKS.
+func NewFakeEKS() *FakeEKS {
+	return &FakeEKS{
+		clusters:   map[string]ekstypes.Cluster{},
+		nodeGroups: map[string]map[string]ekstypes.Nodegroup{},
+		errs:       map[string]error{},
+	}
+}
+
+// AddCluster stores a cluster, replacing any cluster with the same name.
+func (f *FakeEKS) AddCluster(cluster ekstypes.Cluster) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clusters[aws.ToString(cluster.Name)] = cluster
+}
+
+// AddNodeGroup stores a managed node group of its ClusterName, replacing any node
+// group with the same name in the cluster.
+func (f *FakeEKS) AddNodeGroup(nodeGroup ekstypes.Nodegroup) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cluster := aws.ToString(nodeGroup.ClusterName)
+	if f.nodeGroups[cluster] == nil {
+		f.nodeGroups[cluster] = map[string]ekstypes.Nodegroup{}
+	}
+	f.nodeGroups[cluster][aws.ToString(nodeGroup.NodegroupName)] = nodeGroup
+}
+
+// SetError makes every call of the named operation, e.g. "DescribeNodegroup", fail
+// with err until it is reset with a nil error.
+func (f *FakeEKS) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeEKS) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeEKS) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeCluster"); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.Name)
+	cluster, ok := f.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: No cluster found for name: %s", name)
+	}
+	return &eks.DescribeClusterOutput{Cluster: &cluster}, nil
+}
+
+func (f *FakeEKS) ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListClusters"); err != nil {
+		return nil, err
+	}
+
+	names, next, err := markerPage(sortedKeys(f.clusters), params.MaxResults, params.NextToken, 100)
+	if err != nil {
+		return nil, err
+	}
+	return &eks.ListClustersOutput{Clusters: names, NextToken: next}, nil
+}
+
+func (f *FakeEKS) DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeNodegroup"); err != nil {
+		return nil, err
+	}
+
+	cluster, name := aws.ToString(params.ClusterName), aws.ToString(params.NodegroupName)
+	nodeGroup, ok := f.nodeGroups[cluster][name]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: No node group found for name: %s in cluster %s", name, cluster)
+	}
+	return &eks.DescribeNodegroupOutput{Nodegroup: &nodeGroup}, nil
+}
+
+func (f *FakeEKS) ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListNodegroups"); err != nil {
+		return nil, err
+	}
+
+	cluster := aws.ToString(params.ClusterName)
+	if _, ok := f.clusters[cluster]; !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: No cluster found for name: %s", cluster)
+	}
+	names, next, err := markerPage(sortedKeys(f.nodeGroups[cluster]), params.MaxResults, params.NextToken, 100)
+	if err != nil {
+		return nil, err
+	}
+	return &eks.ListNodegroupsOutput{Nodegroups: names, NextToken: next}, nil
+}
@@ -0,0 +1,116 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeEKS() *awstest.FakeEKS {
+	fake := awstest.NewFakeEKS()
+	fake.AddCluster(ekstypes.Cluster{
+		Name:    aws.String("prod"),
+		Version: aws.String("1.30"),
+		Logging: &ekstypes.Logging{ClusterLogging: []ekstypes.LogSetup{
+			{Enabled: aws.Bool(true), Types: []ekstypes.LogType{ekstypes.LogTypeAudit, ekstypes.LogTypeApi}},
+			{Enabled: aws.Bool(false), Types: []ekstypes.LogType{ekstypes.LogTypeScheduler}},
+		}},
+		ResourcesVpcConfig: &ekstypes.VpcConfigResponse{
+			EndpointPrivateAccess: true,
+			EndpointPublicAccess:  false,
+			SubnetIds:             []string{"subnet-b", "subnet-a"},
+		},
+		Tags: map[string]string{"Team": "platform"},
+	})
+	fake.AddCluster(ekstypes.Cluster{Name: aws.String("staging")})
+	fake.AddNodeGroup(ekstypes.Nodegroup{
+		ClusterName:    aws.String("prod"),
+		NodegroupName:  aws.String("workers"),
+		ReleaseVersion: aws.String("1.30.0-20240625"),
+		InstanceTypes:  []string{"m5.large", "m5a.large"},
+		ScalingConfig:  &ekstypes.NodegroupScalingConfig{DesiredSize: aws.Int32(6), MaxSize: aws.Int32(10), MinSize: aws.Int32(2)},
+		Labels:         map[string]string{"role": "worker"},
+	})
+	fake.AddNodeGroup(ekstypes.Nodegroup{ClusterName: aws.String("staging"), NodegroupName: aws.String("workers")})
+	return fake
+}
+
+func TestProvider_InfrastructureMetadata_EKS(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.EKS = newFakeEKS()
+
+	cluster, err := p.InfrastructreMetadata(context.Background(), "aws_eks_cluster", resourceWithId("aws_eks_cluster", "prod"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"version":                           "1.30",
+		"enabled_cluster_log_types":         "api,audit",
+		"vpc_config.endpoint_public_access": "false",
+		"vpc_config.subnet_ids":             "subnet-a,subnet-b",
+		"tags.Team":                         "platform",
+	} {
+		value, err := cluster.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	nodeGroup, err := p.InfrastructreMetadata(context.Background(), "aws_eks_node_group", resourceWithId("aws_eks_node_group", "prod:workers"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"release_version":             "1.30.0-20240625",
+		"instance_types":              "m5.large,m5a.large",
+		"scaling_config.desired_size": "6",
+		"labels.role":                 "worker",
+	} {
+		value, err := nodeGroup.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_eks_node_group", resourceWithId("aws_eks_node_group", "workers"))
+	assert.ErrorContains(t, err, "CLUSTER:NODE_GROUP")
+}
+
+func TestProvider_ListResourceIds_EKSNodeGroups(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.EKS = newFakeEKS()
+	p.PageSize = 1
+
+	ids, err := p.ListResourceIds(context.Background(), "aws_eks_node_group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod:workers", "staging:workers"}, ids)
+}
+
+func TestProvider_EKS_ScalingDrift(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.EKS = newFakeEKS()
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_eks_node_group", resourceWithId("aws_eks_node_group", "prod:workers"))
+	require.NoError(t, err)
+
+	// an emergency scale up from 3 to 6 nodes outside of terraform
+	desired := statemanager.StateResource{
+		Type: "aws_eks_node_group",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"id":              "prod:workers",
+			"release_version": "1.30.0-20240625",
+			"instance_types":  []any{"m5.large", "m5a.large"},
+			"scaling_config":  []any{map[string]any{"desired_size": 3, "max_size": 10, "min_size": 2}},
+		}}},
+	}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), resource, desired, []string{"release_version", "instance_types", "scaling_config"})
+	require.NoError(t, err)
+	drifted := map[string]string{}
+	for _, item := range report.DriftDetails {
+		drifted[item.Field] = item.DriftType
+	}
+	assert.Equal(t, driftchecker.AttributeValueChanged, drifted["scaling_config"])
+	assert.Equal(t, driftchecker.Match, drifted["release_version"])
+	assert.Equal(t, driftchecker.Match, drifted["instance_types"])
+}
@@ -0,0 +1,388 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+)
+
+var (
+	_ awsProvider.ELBv2API = (*FakeELBv2)(nil)
+	_ awsProvider.ELBAPI   = (*FakeELB)(nil)
+)
+
+// FakeELBv2 implements awsProvider.ELBv2API over in-memory load balancers, listeners
+// and target groups. Describe calls of load balancers and target groups are paginated
+// with PageSize and Marker like Elastic Load Balancing's. It is safe for concurrent
+// use.
+type FakeELBv2 struct {
+	mu                     sync.Mutex
+	loadBalancers          map[string]elbv2types.LoadBalancer
+	loadBalancerAttributes map[string]map[string]string
+	listeners              map[string]elbv2types.Listener
+	targetGroups           map[string]elbv2types.TargetGroup
+	targetGroupAttributes  map[string]map[string]string
+	tags                   map[string]map[string]string
+	errs                   map[string]error
+	calls                  []string
+}
+
+// NewFakeELBv2 creates an empty FakeELBv2.
+func NewFakeELBv2() *FakeELBv2 {
+	return &FakeELBv2{
+		loadBalancers:          map[string]elbv2types.LoadBalancer{},
+		loadBalancerAttributes: map[string]map[string]string{},
+		listeners:              map[string]elbv2types.Listener{},
+		targetGroups:           map[string]elbv2types.TargetGroup{},
+		targetGroupAttributes:  map[string]map[string]string{},
+		tags:                   map[string]map[string]string{},
+		errs:                   map[string]error{},
+	}
+}
+
+// AddLoadBalancer stores a load balancer with its attributes by API key, replacing
+// any load balancer with the same ARN.
+func (f *FakeELBv2) AddLoadBalancer(lb elbv2types.LoadBalancer, attributes map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	arn := aws.ToString(lb.LoadBalancerArn)
+	f.loadBalancers[arn] = lb
+	f.loadBalancerAttributes[arn] = attributes
+}
+
+// AddListener stores a listener, replacing any listener with the same ARN.
+func (f *FakeELBv2) AddListener(listener elbv2types.Listener) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listeners[aws.ToString(listener.ListenerArn)] = listener
+}
+
+// AddTargetGroup stores a target group with its attributes by API key, replacing any
+// target group with the same ARN.
+func (f *FakeELBv2) AddTargetGroup(group elbv2types.TargetGroup, attributes map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	arn := aws.ToString(group.TargetGroupArn)
+	f.targetGroups[arn] = group
+	f.targetGroupAttributes[arn] = attributes
+}
+
+// SetTags sets the tags of the load balancer, listener or target group with the
+// given ARN.
+func (f *FakeELBv2) SetTags(arn string, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tags[arn] = tags
+}
+
+// SetError makes every call of the named operation, e.g. "DescribeListeners", fail
+// with err until it is reset with a nil error.
+func (f *FakeELBv2) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeELBv2) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeELBv2) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeELBv2) DescribeLoadBalancers(ctx context.Context, params *elasticloadbalancingv2.DescribeLoadBalancersInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeLoadBalancersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeLoadBalancers"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancingv2.DescribeLoadBalancersOutput{}
+	for _, arn := range params.LoadBalancerArns {
+		lb, ok := f.loadBalancers[arn]
+		if !ok {
+			return nil, fmt.Errorf("LoadBalancerNotFound: one or more load balancers not found")
+		}
+		output.LoadBalancers = append(output.LoadBalancers, lb)
+	}
+	if len(params.LoadBalancerArns) > 0 {
+		return output, nil
+	}
+	for _, arn := range sortedKeys(f.loadBalancers) {
+		output.LoadBalancers = append(output.LoadBalancers, f.loadBalancers[arn])
+	}
+	var err error
+	output.LoadBalancers, output.NextMarker, err = markerPage(output.LoadBalancers, params.PageSize, params.Marker, 400)
+	return output, err
+}
+
+func (f *FakeELBv2) DescribeLoadBalancerAttributes(ctx context.Context, params *elasticloadbalancingv2.DescribeLoadBalancerAttributesInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeLoadBalancerAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeLoadBalancerAttributes"); err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(params.LoadBalancerArn)
+	if _, ok := f.loadBalancers[arn]; !ok {
+		return nil, fmt.Errorf("LoadBalancerNotFound: load balancer '%s' not found", arn)
+	}
+	output := &elasticloadbalancingv2.DescribeLoadBalancerAttributesOutput{}
+	attributes := f.loadBalancerAttributes[arn]
+	for _, key := range sortedKeys(attributes) {
+		output.Attributes = append(output.Attributes, elbv2types.LoadBalancerAttribute{Key: aws.String(key), Value: aws.String(attributes[key])})
+	}
+	return output, nil
+}
+
+func (f *FakeELBv2) DescribeListeners(ctx context.Context, params *elasticloadbalancingv2.DescribeListenersInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeListenersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeListeners"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancingv2.DescribeListenersOutput{}
+	for _, arn := range sortedKeys(f.listeners) {
+		listener := f.listeners[arn]
+		if len(params.ListenerArns) > 0 && !slices.Contains(params.ListenerArns, arn) {
+			continue
+		}
+		if params.LoadBalancerArn != nil && aws.ToString(listener.LoadBalancerArn) != *params.LoadBalancerArn {
+			continue
+		}
+		output.Listeners = append(output.Listeners, listener)
+	}
+	if len(output.Listeners) < len(params.ListenerArns) {
+		return nil, fmt.Errorf("ListenerNotFound: one or more listeners not found")
+	}
+	return output, nil
+}
+
+func (f *FakeELBv2) DescribeTargetGroups(ctx context.Context, params *elasticloadbalancingv2.DescribeTargetGroupsInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeTargetGroups"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancingv2.DescribeTargetGroupsOutput{}
+	for _, arn := range params.TargetGroupArns {
+		group, ok := f.targetGroups[arn]
+		if !ok {
+			return nil, fmt.Errorf("TargetGroupNotFound: one or more target groups not found")
+		}
+		output.TargetGroups = append(output.TargetGroups, group)
+	}
+	if len(params.TargetGroupArns) > 0 {
+		return output, nil
+	}
+	for _, arn := range sortedKeys(f.targetGroups) {
+		output.TargetGroups = append(output.TargetGroups, f.targetGroups[arn])
+	}
+	var err error
+	output.TargetGroups, output.NextMarker, err = markerPage(output.TargetGroups, params.PageSize, params.Marker, 400)
+	return output, err
+}
+
+func (f *FakeELBv2) DescribeTargetGroupAttributes(ctx context.Context, params *elasticloadbalancingv2.DescribeTargetGroupAttributesInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTargetGroupAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeTargetGroupAttributes"); err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(params.TargetGroupArn)
+	if _, ok := f.targetGroups[arn]; !ok {
+		return nil, fmt.Errorf("TargetGroupNotFound: target group '%s' not found", arn)
+	}
+	output := &elasticloadbalancingv2.DescribeTargetGroupAttributesOutput{}
+	attributes := f.targetGroupAttributes[arn]
+	for _, key := range sortedKeys(attributes) {
+		output.Attributes = append(output.Attributes, elbv2types.TargetGroupAttribute{Key: aws.String(key), Value: aws.String(attributes[key])})
+	}
+	return output, nil
+}
+
+func (f *FakeELBv2) DescribeTags(ctx context.Context, params *elasticloadbalancingv2.DescribeTagsInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTagsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeTags"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancingv2.DescribeTagsOutput{}
+	for _, arn := range params.ResourceArns {
+		description := elbv2types.TagDescription{ResourceArn: aws.String(arn)}
+		tags := f.tags[arn]
+		for _, key := range sortedKeys(tags) {
+			description.Tags = append(description.Tags, elbv2types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+		}
+		output.TagDescriptions = append(output.TagDescriptions, description)
+	}
+	return output, nil
+}
+
+// FakeELB implements awsProvider.ELBAPI over in-memory classic load balancers.
+// DescribeLoadBalancers is paginated with PageSize and Marker. It is safe for
+// concurrent use.
+type FakeELB struct {
+	mu            sync.Mutex
+	loadBalancers map[string]elbtypes.LoadBalancerDescription
+	attributes    map[string]elbtypes.LoadBalancerAttributes
+	tags          map[string]map[string]string
+	errs          map[string]error
+	calls         []string
+}
+
+// NewFakeELB creates an empty FakeELB.
+func NewFakeELB() *FakeELB {
+	return &FakeELB{
+		loadBalancers: map[string]elbtypes.LoadBalancerDescription{},
+		attributes:    map[string]elbtypes.LoadBalancerAttributes{},
+		tags:          map[string]map[string]string{},
+		errs:          map[string]error{},
+	}
+}
+
+// AddLoadBalancer stores a classic load balancer with its attributes and tags,
+// replacing any load balancer with the same name.
+func (f *FakeELB) AddLoadBalancer(lb elbtypes.LoadBalancerDescription, attributes elbtypes.LoadBalancerAttributes, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := aws.ToString(lb.LoadBalancerName)
+	f.loadBalancers[name] = lb
+	f.attributes[name] = attributes
+	f.tags[name] = tags
+}
+
+// SetError makes every call of the named operation, e.g. "DescribeLoadBalancers",
+// fail with err until it is reset with a nil error.
+func (f *FakeELB) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeELB) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeELB) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeELB) DescribeLoadBalancers(ctx context.Context, params *elasticloadbalancing.DescribeLoadBalancersInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeLoadBalancersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeLoadBalancers"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancing.DescribeLoadBalancersOutput{}
+	for _, name := range params.LoadBalancerNames {
+		lb, ok := f.loadBalancers[name]
+		if !ok {
+			return nil, fmt.Errorf("LoadBalancerNotFound: there is no ACTIVE Load Balancer named '%s'", name)
+		}
+		output.LoadBalancerDescriptions = append(output.LoadBalancerDescriptions, lb)
+	}
+	if len(params.LoadBalancerNames) > 0 {
+		return output, nil
+	}
+	for _, name := range sortedKeys(f.loadBalancers) {
+		output.LoadBalancerDescriptions = append(output.LoadBalancerDescriptions, f.loadBalancers[name])
+	}
+	var err error
+	output.LoadBalancerDescriptions, output.NextMarker, err = markerPage(output.LoadBalancerDescriptions, params.PageSize, params.Marker, 400)
+	return output, err
+}
+
+func (f *FakeELB) DescribeLoadBalancerAttributes(ctx context.Context, params *elasticloadbalancing.DescribeLoadBalancerAttributesInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeLoadBalancerAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeLoadBalancerAttributes"); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.LoadBalancerName)
+	attributes, ok := f.attributes[name]
+	if !ok {
+		return nil, fmt.Errorf("LoadBalancerNotFound: there is no ACTIVE Load Balancer named '%s'", name)
+	}
+	return &elasticloadbalancing.DescribeLoadBalancerAttributesOutput{LoadBalancerAttributes: &attributes}, nil
+}
+
+func (f *FakeELB) DescribeTags(ctx context.Context, params *elasticloadbalancing.DescribeTagsInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeTagsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DescribeTags"); err != nil {
+		return nil, err
+	}
+
+	output := &elasticloadbalancing.DescribeTagsOutput{}
+	for _, name := range params.LoadBalancerNames {
+		description := elbtypes.TagDescription{LoadBalancerName: aws.String(name)}
+		tags := f.tags[name]
+		for _, key := range sortedKeys(tags) {
+			description.Tags = append(description.Tags, elbtypes.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+		}
+		output.TagDescriptions = append(output.TagDescriptions, description)
+	}
+	return output, nil
+}
+
+// markerPage returns the page of items starting at the offset marker, of at most
+// pageSize items when set, and the marker of the next page, as Elastic Load Balancing
+// and CloudFront paginate their describe and list calls. pageSize may not exceed max.
+func markerPage[T any](items []T, pageSize *int32, marker *string, max int32) ([]T, *string, error) {
+	start := 0
+	if marker != nil {
+		n, err := strconv.Atoi(*marker)
+		if err != nil || n < 0 || n > len(items) {
+			return nil, nil, fmt.Errorf("ValidationError: invalid Marker %q", *marker)
+		}
+		start = n
+	}
+	if pageSize == nil {
+		return items[start:], nil, nil
+	}
+	if *pageSize < 1 || *pageSize > max {
+		return nil, nil, fmt.Errorf("ValidationError: page size must be between 1 and %d, got %d", max, *pageSize)
+	}
+	end := min(start+int(*pageSize), len(items))
+	if end == len(items) {
+		return items[start:end], nil, nil
+	}
+	return items[start:end], aws.String(strconv.Itoa(end)), nil
+}
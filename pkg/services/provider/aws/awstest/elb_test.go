@@ -0,0 +1,200 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	lbArn       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c0c9188"
+	listenerArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/web/50dc6c495c0c9188/f2f7dc8efc522ab2"
+	tgArn       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a067"
+)
+
+func resourceWithId(resourceType string, id string) statemanager.StateResource {
+	return statemanager.StateResource{
+		Type:      resourceType,
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": id}}},
+	}
+}
+
+func newFakeELBv2() *awstest.FakeELBv2 {
+	fake := awstest.NewFakeELBv2()
+	fake.AddLoadBalancer(elbv2types.LoadBalancer{
+		LoadBalancerArn:  aws.String(lbArn),
+		LoadBalancerName: aws.String("web"),
+		Type:             elbv2types.LoadBalancerTypeEnumApplication,
+		Scheme:           elbv2types.LoadBalancerSchemeEnumInternetFacing,
+		SecurityGroups:   []string{"sg-2", "sg-1"},
+		AvailabilityZones: []elbv2types.AvailabilityZone{
+			{SubnetId: aws.String("subnet-b")},
+			{SubnetId: aws.String("subnet-a")},
+		},
+	}, map[string]string{
+		"idle_timeout.timeout_seconds": "120",
+		"deletion_protection.enabled":  "false",
+		"access_logs.s3.enabled":       "true",
+		"access_logs.s3.bucket":        "lb-logs",
+	})
+	fake.SetTags(lbArn, map[string]string{"Name": "web", "aws:cloudformation:stack-name": "web"})
+	fake.AddListener(elbv2types.Listener{
+		ListenerArn:     aws.String(listenerArn),
+		LoadBalancerArn: aws.String(lbArn),
+		Port:            aws.Int32(443),
+		Protocol:        elbv2types.ProtocolEnumHttps,
+		SslPolicy:       aws.String("ELBSecurityPolicy-TLS13-1-2-2021-06"),
+		Certificates:    []elbv2types.Certificate{{CertificateArn: aws.String("arn:aws:acm:us-east-1:123456789012:certificate/abc")}},
+		DefaultActions: []elbv2types.Action{
+			{Type: elbv2types.ActionTypeEnumForward, TargetGroupArn: aws.String(tgArn), Order: aws.Int32(2)},
+			{Type: elbv2types.ActionTypeEnumAuthenticateOidc, Order: aws.Int32(1)},
+		},
+	})
+	fake.AddTargetGroup(elbv2types.TargetGroup{
+		TargetGroupArn:     aws.String(tgArn),
+		TargetGroupName:    aws.String("web"),
+		Port:               aws.Int32(8080),
+		Protocol:           elbv2types.ProtocolEnumHttp,
+		HealthCheckPath:    aws.String("/healthz"),
+		HealthCheckEnabled: aws.Bool(true),
+		Matcher:            &elbv2types.Matcher{HttpCode: aws.String("200")},
+	}, map[string]string{
+		"deregistration_delay.timeout_seconds":  "30",
+		"stickiness.enabled":                    "true",
+		"stickiness.type":                       "lb_cookie",
+		"stickiness.lb_cookie.duration_seconds": "3600",
+	})
+	return fake
+}
+
+func TestProvider_InfrastructureMetadata_LoadBalancer(t *testing.T) {
+	fake := newFakeELBv2()
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.ELBv2 = fake
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_alb", resourceWithId("aws_alb", lbArn))
+	require.NoError(t, err)
+	assert.Equal(t, "aws_alb", resource.ResourceType())
+	for attribute, expected := range map[string]string{
+		"name":                       "web",
+		"internal":                   "false",
+		"load_balancer_type":         "application",
+		"security_groups":            "sg-1,sg-2",
+		"subnets":                    "subnet-a,subnet-b",
+		"idle_timeout":               "120",
+		"enable_deletion_protection": "false",
+		"access_logs.bucket":         "lb-logs",
+		"tags.Name":                  "web",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	attributes, err := resource.Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, "120", attributes["idle_timeout"])
+	assert.NotContains(t, attributes, "tags.aws:cloudformation:stack-name")
+}
+
+func TestProvider_InfrastructureMetadata_ListenerAndTargetGroup(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.ELBv2 = newFakeELBv2()
+
+	listener, err := p.InfrastructreMetadata(context.Background(), "aws_lb_listener", resourceWithId("aws_lb_listener", listenerArn))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"port":                            "443",
+		"protocol":                        "HTTPS",
+		"certificate_arn":                 "arn:aws:acm:us-east-1:123456789012:certificate/abc",
+		"default_action.type":             "authenticate-oidc,forward",
+		"default_action.target_group_arn": "," + tgArn,
+	} {
+		value, err := listener.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	group, err := p.InfrastructreMetadata(context.Background(), "aws_lb_target_group", resourceWithId("aws_lb_target_group", tgArn))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"port":                       "8080",
+		"deregistration_delay":       "30",
+		"health_check.path":          "/healthz",
+		"health_check.matcher":       "200",
+		"stickiness.cookie_duration": "3600",
+	} {
+		value, err := group.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_lb_target_group", resourceWithId("aws_lb_target_group", "arn:missing"))
+	assert.ErrorContains(t, err, "TargetGroupNotFound")
+}
+
+func TestProvider_InfrastructureMetadata_ClassicLoadBalancer(t *testing.T) {
+	fake := awstest.NewFakeELB()
+	fake.AddLoadBalancer(elbtypes.LoadBalancerDescription{
+		LoadBalancerName: aws.String("legacy"),
+		Scheme:           aws.String("internal"),
+		Instances:        []elbtypes.Instance{{InstanceId: aws.String("i-2")}, {InstanceId: aws.String("i-1")}},
+		ListenerDescriptions: []elbtypes.ListenerDescription{{Listener: &elbtypes.Listener{
+			InstancePort:     aws.Int32(8080),
+			InstanceProtocol: aws.String("HTTP"),
+			LoadBalancerPort: 80,
+			Protocol:         aws.String("HTTP"),
+		}}},
+	}, elbtypes.LoadBalancerAttributes{
+		ConnectionSettings:     &elbtypes.ConnectionSettings{IdleTimeout: aws.Int32(400)},
+		CrossZoneLoadBalancing: &elbtypes.CrossZoneLoadBalancing{Enabled: true},
+	}, map[string]string{"Team": "platform"})
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.ELB = fake
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_elb", resourceWithId("aws_elb", "legacy"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"internal":                  "true",
+		"instances":                 "i-1,i-2",
+		"idle_timeout":              "400",
+		"cross_zone_load_balancing": "true",
+		"listener.instance_port":    "8080",
+		"listener.lb_protocol":      "http",
+		"tags.Team":                 "platform",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+}
+
+func TestProvider_ListResourceIds_LoadBalancers(t *testing.T) {
+	fake := newFakeELBv2()
+	fake.AddLoadBalancer(elbv2types.LoadBalancer{LoadBalancerArn: aws.String(lbArn + "-2")}, nil)
+	classic := awstest.NewFakeELB()
+	classic.AddLoadBalancer(elbtypes.LoadBalancerDescription{LoadBalancerName: aws.String("legacy")}, elbtypes.LoadBalancerAttributes{}, nil)
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.ELBv2, p.ELB = fake, classic
+	p.PageSize = 1
+
+	arns, err := p.ListResourceIds(context.Background(), "aws_lb")
+	require.NoError(t, err)
+	assert.Equal(t, []string{lbArn, lbArn + "-2"}, arns)
+	assert.Equal(t, []string{"DescribeLoadBalancers", "DescribeLoadBalancers"}, fake.Calls())
+
+	arns, err = p.ListResourceIds(context.Background(), "aws_lb_target_group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{tgArn}, arns)
+
+	names, err := p.ListResourceIds(context.Background(), "aws_elb")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, names)
+}
@@ -0,0 +1,167 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/secrets"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+var (
+	_ awsProvider.SSMAPI        = (*FakeSSM)(nil)
+	_ secrets.SecretsManagerAPI = (*FakeSecretsManager)(nil)
+)
+
+// FakeSSM implements awsProvider.SSMAPI over in-memory parameters keyed by name.
+// Parameters are found by name or ARN, and a :version or :label selector is accepted
+// but ignored, as only the latest version is kept. It is safe for concurrent use.
+type FakeSSM struct {
+	mu         sync.Mutex
+	parameters map[string]ssmtypes.Parameter
+	errs       map[string]error
+	calls      []string
+}
+
+// NewFakeSSM creates an empty FakeSSM.
+func NewFakeSSM() *FakeSSM {
+	return &FakeSSM{
+		parameters: map[string]ssmtypes.Parameter{},
+		errs:       map[string]error{},
+	}
+}
+
+// AddParameter stores a parameter of the given type, replacing any parameter with the
+// same name.
+func (f *FakeSSM) AddParameter(name, value string, parameterType ssmtypes.ParameterType) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.parameters[name] = ssmtypes.Parameter{Name: aws.String(name), Value: aws.String(value), Type: parameterType}
+}
+
+// SetError makes every call of the named operation, e.g. "GetParameter", fail with err
+// until it is reset with a nil error.
+func (f *FakeSSM) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeSSM) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *FakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "GetParameter")
+	if err := f.errs["GetParameter"]; err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.Name)
+	if _, path, isARN := strings.Cut(name, ":parameter"); isARN && strings.HasPrefix(name, "arn:") {
+		name = path
+	}
+	// parameter names cannot hold a colon, what follows one selects a version or label
+	if i := strings.LastIndex(name, ":"); i > 0 {
+		name = name[:i]
+	}
+	parameter, ok := f.parameters[name]
+	if !ok {
+		parameter, ok = f.parameters[strings.TrimPrefix(name, "/")]
+	}
+	if !ok {
+		return nil, fmt.Errorf("ParameterNotFound: parameter %s not found", aws.ToString(params.Name))
+	}
+	if parameter.Type == ssmtypes.ParameterTypeSecureString && !aws.ToBool(params.WithDecryption) {
+		parameter.Value = aws.String("(encrypted)")
+	}
+	return &ssm.GetParameterOutput{Parameter: &parameter}, nil
+}
+
+// FakeSecretsManager implements secrets.SecretsManagerAPI over in-memory secret
+// strings keyed by secret id and version stage. A secret is found by the id it was
+// stored with only, so tests store it under the name or the ARN they look it up by.
+// It is safe for concurrent use.
+type FakeSecretsManager struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+// NewFakeSecretsManager creates an empty FakeSecretsManager.
+func NewFakeSecretsManager() *FakeSecretsManager {
+	return &FakeSecretsManager{
+		secrets: map[string]map[string]string{},
+		errs:    map[string]error{},
+	}
+}
+
+// SetSecret stores the AWSCURRENT value of a secret.
+func (f *FakeSecretsManager) SetSecret(id, value string) {
+	f.SetSecretStage(id, "AWSCURRENT", value)
+}
+
+// SetSecretStage stores the value of a secret for a version stage, e.g. AWSPREVIOUS.
+func (f *FakeSecretsManager) SetSecretStage(id, stage, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.secrets[id] == nil {
+		f.secrets[id] = map[string]string{}
+	}
+	f.secrets[id][stage] = value
+}
+
+// SetError makes every call of the named operation, e.g. "GetSecretValue", fail with
+// err until it is reset with a nil error.
+func (f *FakeSecretsManager) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeSecretsManager) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *FakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "GetSecretValue")
+	if err := f.errs["GetSecretValue"]; err != nil {
+		return nil, err
+	}
+
+	id, stage := aws.ToString(params.SecretId), aws.ToString(params.VersionStage)
+	if stage == "" {
+		stage = "AWSCURRENT"
+	}
+	value, ok := f.secrets[id][stage]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: secret %s has no version in stage %s", id, stage)
+	}
+	return &secretsmanager.GetSecretValueOutput{Name: aws.String(id), SecretString: aws.String(value), VersionStages: []string{stage}}, nil
+}
@@ -0,0 +1,101 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"errors"
+	"testing"
+
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf"
+
+func newFakeSecretStores() (*awstest.FakeSSM, *awstest.FakeSecretsManager) {
+	parameters := awstest.NewFakeSSM()
+	parameters.AddParameter("/ami/al2023", "ami-0abc", ssmtypes.ParameterTypeString)
+	parameters.AddParameter("/prod/db/password", "hunter2", ssmtypes.ParameterTypeSecureString)
+	store := awstest.NewFakeSecretsManager()
+	store.SetSecret(secretARN, `{"username":"app","password":"hunter2"}`)
+	store.SetSecretStage(secretARN, "AWSPREVIOUS", `{"username":"app","password":"hunter1"}`)
+	store.SetSecret("api-token", "abc123")
+	return parameters, store
+}
+
+func TestProvider_ResolveValue(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.SSM, p.SecretsManager = newFakeSecretStores()
+
+	for _, test := range []struct {
+		value     string
+		expected  string
+		sensitive bool
+	}{
+		{value: "resolve:ssm:/ami/al2023", expected: "ami-0abc"},
+		{value: "{{resolve:ssm:/ami/al2023:3}}", expected: "ami-0abc"},
+		{value: "arn:aws:ssm:us-east-1:123456789012:parameter/ami/al2023", expected: "ami-0abc"},
+		{value: "{{resolve:ssm-secure:/prod/db/password}}", expected: "hunter2", sensitive: true},
+		{value: "arn:aws:ssm:us-east-1:123456789012:parameter/prod/db/password", expected: "hunter2", sensitive: true},
+		{value: secretARN, expected: `{"username":"app","password":"hunter2"}`, sensitive: true},
+		{value: secretARN + ":password::", expected: "hunter2", sensitive: true},
+		{value: secretARN + ":password:AWSPREVIOUS:", expected: "hunter1", sensitive: true},
+		{value: "{{resolve:secretsmanager:api-token}}", expected: "abc123", sensitive: true},
+		{value: "{{resolve:secretsmanager:" + secretARN + ":SecretString:username}}", expected: "app", sensitive: true},
+	} {
+		resolved, err := p.ResolveValue(context.Background(), test.value)
+		require.NoError(t, err, test.value)
+		require.NotNil(t, resolved, test.value)
+		assert.Equal(t, test.expected, resolved.Value, test.value)
+		assert.Equal(t, test.sensitive, resolved.Sensitive, test.value)
+	}
+
+	for _, value := range []string{"ami-0abc", "t3.micro", "arn:aws:iam::123456789012:role/app", "{{resolve:unknown:x}}", ""} {
+		resolved, err := p.ResolveValue(context.Background(), value)
+		require.NoError(t, err, value)
+		assert.Nil(t, resolved, "%q is not a reference", value)
+	}
+}
+
+func TestProvider_ResolveValue_Errors(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.SSM, p.SecretsManager = newFakeSecretStores()
+
+	_, err := p.ResolveValue(context.Background(), "resolve:ssm:/missing")
+	assert.ErrorContains(t, err, "Failed to resolve resolve:ssm:/missing")
+
+	_, err = p.ResolveValue(context.Background(), "{{resolve:secretsmanager:api-token:SecretString:password}}")
+	assert.ErrorContains(t, err, "not a JSON object")
+	assert.NotContains(t, err.Error(), "abc123", "errors never quote the secret")
+
+	_, err = p.ResolveValue(context.Background(), "{{resolve:secretsmanager:api-token:SecretBinary}}")
+	assert.ErrorContains(t, err, "only SecretString is supported")
+}
+
+func TestProvider_ResolveValue_Cached(t *testing.T) {
+	parameters, store := newFakeSecretStores()
+	p, err := awsProvider.NewAWSProvider(&config.AWSConfig{},
+		awsProvider.WithRegion("us-east-1"),
+		awsProvider.WithSSMClient(parameters),
+		awsProvider.WithSecretsManagerClient(store),
+	)
+	require.NoError(t, err)
+	resolver := p.(*awsProvider.AWSProvider)
+
+	for range 3 {
+		resolved, err := resolver.ResolveValue(context.Background(), "resolve:ssm:/ami/al2023")
+		require.NoError(t, err)
+		assert.Equal(t, "ami-0abc", resolved.Value)
+		_, err = resolver.ResolveValue(context.Background(), secretARN+":password::")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"GetParameter"}, parameters.Calls(), "values are cached even without a cache TTL")
+	assert.Equal(t, []string{"GetSecretValue"}, store.Calls())
+
+	parameters.SetError("GetParameter", errors.New("AccessDeniedException"))
+	_, err = resolver.ResolveValue(context.Background(), "resolve:ssm:/other")
+	assert.ErrorContains(t, err, "AccessDeniedException")
+}
@@ -0,0 +1,133 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// securityGroupState holds the rules of sg-web partly inline and partly as rule
+// resources, the self rule referencing the group by id.
+const securityGroupState = `{
+	"version": 4,
+	"lineage": "secgroup",
+	"resources": [
+		{
+			"mode": "managed",
+			"type": "aws_security_group",
+			"name": "web",
+			"instances": [{"attributes": {
+				"id": "sg-web",
+				"name": "web",
+				"vpc_id": "vpc-1",
+				"ingress": [{"protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_blocks": ["10.0.0.0/8", "0.0.0.0/0"], "description": "", "self": false}],
+				"egress": [],
+				"tags": {"Team": "web"}
+			}}]
+		},
+		{
+			"mode": "managed",
+			"type": "aws_vpc_security_group_ingress_rule",
+			"name": "peers",
+			"instances": [{"attributes": {"security_group_id": "sg-web", "ip_protocol": "tcp", "from_port": 8080, "to_port": 8080, "referenced_security_group_id": "sg-web"}}]
+		},
+		{
+			"mode": "managed",
+			"type": "aws_vpc_security_group_egress_rule",
+			"name": "all",
+			"instances": [{"attributes": {"security_group_id": "sg-web", "ip_protocol": "-1", "from_port": null, "to_port": null, "cidr_ipv4": "0.0.0.0/0"}}]
+		}
+	]
+}`
+
+func newFakeSecurityGroup() *awstest.FakeEC2 {
+	fake := awstest.NewFakeEC2()
+	fake.AddSecurityGroup(types.SecurityGroup{
+		GroupId:   aws.String("sg-web"),
+		GroupName: aws.String("web"),
+		VpcId:     aws.String("vpc-1"),
+		Tags:      []types.Tag{{Key: aws.String("Team"), Value: aws.String("web")}},
+	})
+	for _, rule := range []types.SecurityGroupRule{
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), CidrIpv4: aws.String("0.0.0.0/0")},
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), CidrIpv4: aws.String("10.0.0.0/8")},
+		{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(8080), ToPort: aws.Int32(8080), ReferencedGroupInfo: &types.ReferencedSecurityGroup{GroupId: aws.String("sg-web")}},
+		{IpProtocol: aws.String("-1"), FromPort: aws.Int32(-1), ToPort: aws.Int32(-1), CidrIpv4: aws.String("0.0.0.0/0"), IsEgress: aws.Bool(true)},
+	} {
+		rule.GroupId = aws.String("sg-web")
+		fake.AddSecurityGroupRule(rule)
+	}
+	return fake
+}
+
+func securityGroupResource(t *testing.T) statemanager.StateResource {
+	manager := terraform.NewTerraformManager()
+	content, err := manager.ParseState(context.Background(), strings.NewReader(securityGroupState))
+	require.NoError(t, err)
+	groups, err := manager.RetrieveResources(context.Background(), content, "aws_security_group")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	return groups[0]
+}
+
+func TestProvider_InfrastructureMetadata_SecurityGroup(t *testing.T) {
+	fake := newFakeSecurityGroup()
+	p := awstest.NewProvider(fake)
+
+	desired := securityGroupResource(t)
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_security_group", desired)
+	require.NoError(t, err)
+	value, err := resource.AttributeValue("tags.Team")
+	require.NoError(t, err)
+	assert.Equal(t, "web", value)
+
+	attributes := []string{"name", "vpc_id", "ingress", "egress", "tags.Team"}
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), resource, desired, attributes)
+	require.NoError(t, err)
+	assert.False(t, report.HasDrift, "%+v", report.DriftDetails)
+
+	ids, err := p.ListResourceIds(context.Background(), "aws_security_group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sg-web"}, ids)
+}
+
+func TestProvider_SecurityGroup_RuleDrift(t *testing.T) {
+	fake := newFakeSecurityGroup()
+	// SSH was opened by hand
+	fake.AddSecurityGroupRule(types.SecurityGroupRule{
+		GroupId:    aws.String("sg-web"),
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int32(22),
+		ToPort:     aws.Int32(22),
+		CidrIpv4:   aws.String("0.0.0.0/0"),
+	})
+	p := awstest.NewProvider(fake)
+
+	desired := securityGroupResource(t)
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_security_group", desired)
+	require.NoError(t, err)
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), resource, desired, []string{"ingress", "egress"})
+	require.NoError(t, err)
+	drifted := map[string]string{}
+	for _, item := range report.DriftDetails {
+		drifted[item.Field] = item.DriftType
+	}
+	assert.Equal(t, driftchecker.AttributeValueChanged, drifted["ingress"])
+	assert.Equal(t, driftchecker.Match, drifted["egress"])
+}
+
+func TestProvider_SecurityGroup_NotFound(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	_, err := p.InfrastructreMetadata(context.Background(), "aws_security_group", resourceWithId("aws_security_group", "sg-missing"))
+	assert.EqualError(t, err, "security group sg-missing not found")
+}
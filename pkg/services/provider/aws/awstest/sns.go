@@ -0,0 +1,186 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+var _ awsProvider.SNSAPI = (*FakeSNS)(nil)
+
+// snsPageSize is the number of topics and subscriptions SNS returns per page.
+const snsPageSize = 100
+
+// FakeSNS implements awsProvider.SNSAPI over in-memory topics and subscriptions
+// keyed by ARN. ListTopics and ListSubscriptions return pages of 100 with a NextToken
+// like SNS's. It is safe for concurrent use.
+type FakeSNS struct {
+	mu            sync.Mutex
+	topics        map[string]map[string]string
+	tags          map[string]map[string]string
+	subscriptions map[string]map[string]string
+	errs          map[string]error
+	calls         []string
+}
+
+// NewFakeSNS creates an empty FakeSNS.
+func NewFakeSNS() *FakeSNS {
+	return &FakeSNS{
+		topics:        map[string]map[string]string{},
+		tags:          map[string]map[string]string{},
+		subscriptions: map[string]map[string]string{},
+		errs:          map[string]error{},
+	}
+}
+
+// AddTopic stores a topic with its GetTopicAttributes attributes and tags, replacing
+// any topic with the same ARN. The TopicArn attribute is set to arn.
+func (f *FakeSNS) AddTopic(arn string, attributes map[string]string, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attributes = maps.Clone(attributes)
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	attributes["TopicArn"] = arn
+	f.topics[arn] = attributes
+	f.tags[arn] = tags
+}
+
+// AddSubscription stores a subscription with its GetSubscriptionAttributes
+// attributes, replacing any subscription with the same ARN. The SubscriptionArn
+// attribute is set to arn.
+func (f *FakeSNS) AddSubscription(arn string, attributes map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attributes = maps.Clone(attributes)
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	attributes["SubscriptionArn"] = arn
+	f.subscriptions[arn] = attributes
+}
+
+// SetError makes every call of the named operation, e.g. "GetTopicAttributes", fail
+// with err until it is reset with a nil error.
+func (f *FakeSNS) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeSNS) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeSNS) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeSNS) GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetTopicAttributes"); err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(params.TopicArn)
+	attributes, ok := f.topics[arn]
+	if !ok {
+		return nil, fmt.Errorf("NotFound: topic %s does not exist", arn)
+	}
+	return &sns.GetTopicAttributesOutput{Attributes: maps.Clone(attributes)}, nil
+}
+
+func (f *FakeSNS) ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListTopics"); err != nil {
+		return nil, err
+	}
+
+	arns, next, err := markerPage(sortedKeys(f.topics), aws.Int32(snsPageSize), params.NextToken, snsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	output := &sns.ListTopicsOutput{NextToken: next}
+	for _, arn := range arns {
+		output.Topics = append(output.Topics, snstypes.Topic{TopicArn: aws.String(arn)})
+	}
+	return output, nil
+}
+
+func (f *FakeSNS) ListTagsForResource(ctx context.Context, params *sns.ListTagsForResourceInput, optFns ...func(*sns.Options)) (*sns.ListTagsForResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListTagsForResource"); err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(params.ResourceArn)
+	if _, ok := f.topics[arn]; !ok {
+		return nil, fmt.Errorf("ResourceNotFound: topic %s does not exist", arn)
+	}
+	output := &sns.ListTagsForResourceOutput{}
+	tags := f.tags[arn]
+	for _, key := range sortedKeys(tags) {
+		output.Tags = append(output.Tags, snstypes.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return output, nil
+}
+
+func (f *FakeSNS) GetSubscriptionAttributes(ctx context.Context, params *sns.GetSubscriptionAttributesInput, optFns ...func(*sns.Options)) (*sns.GetSubscriptionAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetSubscriptionAttributes"); err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(params.SubscriptionArn)
+	attributes, ok := f.subscriptions[arn]
+	if !ok {
+		return nil, fmt.Errorf("NotFound: subscription %s does not exist", arn)
+	}
+	return &sns.GetSubscriptionAttributesOutput{Attributes: maps.Clone(attributes)}, nil
+}
+
+func (f *FakeSNS) ListSubscriptions(ctx context.Context, params *sns.ListSubscriptionsInput, optFns ...func(*sns.Options)) (*sns.ListSubscriptionsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListSubscriptions"); err != nil {
+		return nil, err
+	}
+
+	arns, next, err := markerPage(sortedKeys(f.subscriptions), aws.Int32(snsPageSize), params.NextToken, snsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	output := &sns.ListSubscriptionsOutput{NextToken: next}
+	for _, arn := range arns {
+		attributes := f.subscriptions[arn]
+		output.Subscriptions = append(output.Subscriptions, snstypes.Subscription{
+			SubscriptionArn: aws.String(arn),
+			TopicArn:        aws.String(attributes["TopicArn"]),
+			Protocol:        aws.String(attributes["Protocol"]),
+			Endpoint:        aws.String(attributes["Endpoint"]),
+		})
+	}
+	return output, nil
+}
@@ -0,0 +1,56 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const topicArn = "arn:aws:sns:us-east-1:123456789012:alerts"
+
+func TestProvider_InfrastructureMetadata_SNS(t *testing.T) {
+	fake := awstest.NewFakeSNS()
+	fake.AddTopic(topicArn, map[string]string{
+		"DisplayName":    "Alerts",
+		"KmsMasterKeyId": "alias/aws/sns",
+	}, map[string]string{"Team": "sre", "aws:cloudformation:stack-name": "alerts"})
+	fake.AddSubscription(topicArn+":1b2c", map[string]string{
+		"TopicArn":           topicArn,
+		"Protocol":           "sqs",
+		"Endpoint":           "arn:aws:sqs:us-east-1:123456789012:alerts",
+		"RawMessageDelivery": "true",
+	})
+	fake.AddSubscription("PendingConfirmation", map[string]string{"TopicArn": topicArn, "Protocol": "email"})
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.SNS = fake
+
+	topic, err := p.InfrastructreMetadata(context.Background(), "aws_sns_topic", resourceWithId("aws_sns_topic", topicArn))
+	require.NoError(t, err)
+	attributes, err := topic.Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, "alerts", attributes["name"])
+	assert.Equal(t, "Alerts", attributes["display_name"])
+	assert.Equal(t, "false", attributes["fifo_topic"])
+	assert.Equal(t, "sre", attributes["tags.Team"])
+	assert.NotContains(t, attributes, "tags.aws:cloudformation:stack-name")
+
+	subscription, err := p.InfrastructreMetadata(context.Background(), "aws_sns_topic_subscription", resourceWithId("aws_sns_topic_subscription", topicArn+":1b2c"))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"topic_arn":            topicArn,
+		"protocol":             "sqs",
+		"raw_message_delivery": "true",
+		"filter_policy":        "",
+	} {
+		value, err := subscription.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	ids, err := p.ListResourceIds(context.Background(), "aws_sns_topic_subscription")
+	require.NoError(t, err)
+	assert.Equal(t, []string{topicArn + ":1b2c"}, ids, "subscriptions pending confirmation have no ARN")
+}
@@ -0,0 +1,113 @@
+package awstest
+
+import (
+	"context"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+var _ awsProvider.SQSAPI = (*FakeSQS)(nil)
+
+// FakeSQS implements awsProvider.SQSAPI over in-memory queues keyed by URL.
+// ListQueues is paginated with MaxResults and NextToken like SQS's. It is safe for
+// concurrent use.
+type FakeSQS struct {
+	mu         sync.Mutex
+	attributes map[string]map[string]string
+	tags       map[string]map[string]string
+	errs       map[string]error
+	calls      []string
+}
+
+// NewFakeSQS creates an empty FakeSQS.
+func NewFakeSQS() *FakeSQS {
+	return &FakeSQS{
+		attributes: map[string]map[string]string{},
+		tags:       map[string]map[string]string{},
+		errs:       map[string]error{},
+	}
+}
+
+// AddQueue stores a queue with its GetQueueAttributes attributes and tags, replacing
+// any queue with the same URL.
+func (f *FakeSQS) AddQueue(url string, attributes map[string]string, tags map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attributes[url] = attributes
+	f.tags[url] = tags
+}
+
+// SetError makes every call of the named operation, e.g. "GetQueueAttributes", fail
+// with err until it is reset with a nil error.
+func (f *FakeSQS) SetError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, operation)
+		return
+	}
+	f.errs[operation] = err
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *FakeSQS) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// call records a call of the operation and returns the error set for it. The caller
+// must hold f.mu.
+func (f *FakeSQS) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *FakeSQS) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetQueueAttributes"); err != nil {
+		return nil, err
+	}
+
+	url := aws.ToString(params.QueueUrl)
+	attributes, ok := f.attributes[url]
+	if !ok {
+		return nil, fmt.Errorf("QueueDoesNotExist: the specified queue %s does not exist", url)
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: maps.Clone(attributes)}, nil
+}
+
+func (f *FakeSQS) ListQueues(ctx context.Context, params *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListQueues"); err != nil {
+		return nil, err
+	}
+
+	urls, next, err := markerPage(sortedKeys(f.attributes), params.MaxResults, params.NextToken, 1000)
+	if err != nil {
+		return nil, err
+	}
+	return &sqs.ListQueuesOutput{QueueUrls: urls, NextToken: next}, nil
+}
+
+func (f *FakeSQS) ListQueueTags(ctx context.Context, params *sqs.ListQueueTagsInput, optFns ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListQueueTags"); err != nil {
+		return nil, err
+	}
+
+	url := aws.ToString(params.QueueUrl)
+	if _, ok := f.attributes[url]; !ok {
+		return nil, fmt.Errorf("QueueDoesNotExist: the specified queue %s does not exist", url)
+	}
+	return &sqs.ListQueueTagsOutput{Tags: maps.Clone(f.tags[url])}, nil
+}
@@ -0,0 +1,76 @@
+package awstest_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const queueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/orders"
+
+func newFakeSQS() *awstest.FakeSQS {
+	fake := awstest.NewFakeSQS()
+	fake.AddQueue(queueURL, map[string]string{
+		"QueueArn":          "arn:aws:sqs:us-east-1:123456789012:orders",
+		"VisibilityTimeout": "120",
+		"KmsMasterKeyId":    "alias/orders",
+		"RedrivePolicy":     `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":5}`,
+	}, map[string]string{"Team": "checkout"})
+	return fake
+}
+
+func TestProvider_InfrastructureMetadata_SQS(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.SQS = newFakeSQS()
+
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_sqs_queue", resourceWithId("aws_sqs_queue", queueURL))
+	require.NoError(t, err)
+	for attribute, expected := range map[string]string{
+		"name":                       "orders",
+		"arn":                        "arn:aws:sqs:us-east-1:123456789012:orders",
+		"visibility_timeout_seconds": "120",
+		"kms_master_key_id":          "alias/orders",
+		"fifo_queue":                 "false",
+		"tags.Team":                  "checkout",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	ids, err := p.ListResourceIds(context.Background(), "aws_sqs_queue")
+	require.NoError(t, err)
+	assert.Equal(t, []string{queueURL}, ids)
+}
+
+func TestProvider_SQS_RedrivePolicyDrift(t *testing.T) {
+	p := awstest.NewProvider(awstest.NewFakeEC2())
+	p.SQS = newFakeSQS()
+	resource, err := p.InfrastructreMetadata(context.Background(), "aws_sqs_queue", resourceWithId("aws_sqs_queue", queueURL))
+	require.NoError(t, err)
+
+	// the redrive policy is formatted differently by terraform, the visibility
+	// timeout was raised by hand
+	desired := statemanager.StateResource{
+		Type: "aws_sqs_queue",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{
+			"id":                         queueURL,
+			"visibility_timeout_seconds": 30,
+			"redrive_policy":             `{"maxReceiveCount": 5, "deadLetterTargetArn": "arn:aws:sqs:us-east-1:123456789012:orders-dlq"}`,
+		}}},
+	}
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), resource, desired, []string{"visibility_timeout_seconds", "redrive_policy"})
+	require.NoError(t, err)
+	drifted := map[string]string{}
+	for _, item := range report.DriftDetails {
+		drifted[item.Field] = item.DriftType
+	}
+	assert.Equal(t, driftchecker.AttributeValueChanged, drifted["visibility_timeout_seconds"])
+	assert.Equal(t, driftchecker.Match, drifted["redrive_policy"])
+}
@@ -0,0 +1,312 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CloudFrontAPI is the part of the CloudFront API used by the provider. It is
+// implemented by *cloudfront.Client, and by awstest.FakeCloudFront for tests that do
+// not reach AWS.
+type CloudFrontAPI interface {
+	GetDistribution(ctx context.Context, params *cloudfront.GetDistributionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.GetDistributionOutput, error)
+	ListDistributions(ctx context.Context, params *cloudfront.ListDistributionsInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListDistributionsOutput, error)
+	ListTagsForResource(ctx context.Context, params *cloudfront.ListTagsForResourceInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListTagsForResourceOutput, error)
+}
+
+// Attributes of aws_cloudfront_distribution distributions, named after the Terraform
+// schema.
+const (
+	CFArn               = "arn"
+	CFDomainName        = "domain_name"
+	CFHostedZoneID      = "hosted_zone_id"
+	CFStatus            = "status"
+	CFEnabled           = "enabled"
+	CFAliases           = "aliases"
+	CFComment           = "comment"
+	CFDefaultRootObject = "default_root_object"
+	CFHTTPVersion       = "http_version"
+	CFIPv6Enabled       = "is_ipv6_enabled"
+	CFPriceClass        = "price_class"
+	CFWebACLID          = "web_acl_id"
+	// CFOrigin is the origin blocks, holding domain_name, origin_id, origin_path,
+	// connection_attempts, connection_timeout, origin_access_control_id and the
+	// custom_origin_config and s3_origin_config blocks.
+	CFOrigin = "origin"
+	// CFDefaultCacheBehavior is the default_cache_behavior block, holding
+	// target_origin_id, viewer_protocol_policy, allowed_methods, cached_methods,
+	// compress, the cache, origin request and response headers policy ids and the
+	// TTLs.
+	CFDefaultCacheBehavior = "default_cache_behavior"
+	// CFViewerCertificate is the viewer_certificate block, holding
+	// acm_certificate_arn, cloudfront_default_certificate, iam_certificate_id,
+	// minimum_protocol_version and ssl_support_method.
+	CFViewerCertificate = "viewer_certificate"
+)
+
+// cloudFrontHostedZoneID is the Route 53 hosted zone of every CloudFront distribution.
+const cloudFrontHostedZoneID = "Z2FDTNDATAQYW2"
+
+func init() {
+	// the state holds every key of the blocks while only some are read, and origins
+	// are a set in any order
+	driftchecker.Register("aws_cloudfront_distribution", driftchecker.ForAttributes(driftchecker.JSONSubsetEqual,
+		CFOrigin, CFDefaultCacheBehavior, CFViewerCertificate))
+}
+
+// CloudFrontDistribution is the live state of an aws_cloudfront_distribution.
+type CloudFrontDistribution struct {
+	Distribution cftypes.Distribution
+	Tags         map[string]string
+}
+
+func (c *CloudFrontDistribution) ResourceType() string {
+	return "aws_cloudfront_distribution"
+}
+
+// AttributeValue retrieves the string value of a distribution attribute. Aliases are
+// sorted and joined with commas, the origin, default_cache_behavior and
+// viewer_certificate blocks are read as JSON, so default_cache_behavior.compress
+// reaches into them, and tags with tags.KEY.
+func (c *CloudFrontDistribution) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, c.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (c *CloudFrontDistribution) attributeValue(attribute string) (string, error) {
+	distribution := c.Distribution
+	config := distribution.DistributionConfig
+	if config == nil {
+		config = &cftypes.DistributionConfig{}
+	}
+	switch attribute {
+	case CFArn:
+		return aws.ToString(distribution.ARN), nil
+	case CFDomainName:
+		return aws.ToString(distribution.DomainName), nil
+	case CFHostedZoneID:
+		return cloudFrontHostedZoneID, nil
+	case CFStatus:
+		return aws.ToString(distribution.Status), nil
+	case CFEnabled:
+		return strconv.FormatBool(aws.ToBool(config.Enabled)), nil
+	case CFAliases:
+		if config.Aliases == nil {
+			return "", nil
+		}
+		return sortedJoin(config.Aliases.Items), nil
+	case CFComment:
+		return aws.ToString(config.Comment), nil
+	case CFDefaultRootObject:
+		return aws.ToString(config.DefaultRootObject), nil
+	case CFHTTPVersion:
+		return string(config.HttpVersion), nil
+	case CFIPv6Enabled:
+		return strconv.FormatBool(aws.ToBool(config.IsIPV6Enabled)), nil
+	case CFPriceClass:
+		return string(config.PriceClass), nil
+	case CFWebACLID:
+		return aws.ToString(config.WebACLId), nil
+	case CFOrigin:
+		if config.Origins == nil {
+			return "", nil
+		}
+		origins := make([]map[string]any, 0, len(config.Origins.Items))
+		for _, origin := range config.Origins.Items {
+			origins = append(origins, originBlock(origin))
+		}
+		return marshalBlock(CFOrigin, origins...)
+	case CFDefaultCacheBehavior:
+		behavior := config.DefaultCacheBehavior
+		if behavior == nil {
+			return "", nil
+		}
+		var allowed, cached []string
+		if behavior.AllowedMethods != nil {
+			allowed = methods(behavior.AllowedMethods.Items)
+			if behavior.AllowedMethods.CachedMethods != nil {
+				cached = methods(behavior.AllowedMethods.CachedMethods.Items)
+			}
+		}
+		return marshalBlock(CFDefaultCacheBehavior, map[string]any{
+			"target_origin_id":           aws.ToString(behavior.TargetOriginId),
+			"viewer_protocol_policy":     string(behavior.ViewerProtocolPolicy),
+			"allowed_methods":            allowed,
+			"cached_methods":             cached,
+			"compress":                   aws.ToBool(behavior.Compress),
+			"cache_policy_id":            aws.ToString(behavior.CachePolicyId),
+			"origin_request_policy_id":   aws.ToString(behavior.OriginRequestPolicyId),
+			"response_headers_policy_id": aws.ToString(behavior.ResponseHeadersPolicyId),
+			"default_ttl":                aws.ToInt64(behavior.DefaultTTL),
+			"min_ttl":                    aws.ToInt64(behavior.MinTTL),
+			"max_ttl":                    aws.ToInt64(behavior.MaxTTL),
+		})
+	case CFViewerCertificate:
+		certificate := config.ViewerCertificate
+		if certificate == nil {
+			return "", nil
+		}
+		return marshalBlock(CFViewerCertificate, map[string]any{
+			"acm_certificate_arn":            aws.ToString(certificate.ACMCertificateArn),
+			"cloudfront_default_certificate": aws.ToBool(certificate.CloudFrontDefaultCertificate),
+			"iam_certificate_id":             aws.ToString(certificate.IAMCertificateId),
+			"minimum_protocol_version":       string(certificate.MinimumProtocolVersion),
+			"ssl_support_method":             string(certificate.SSLSupportMethod),
+		})
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return c.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for CloudFront distributions or is an invalid attribute name", attribute)
+}
+
+// originBlock returns the origin block of origin as the state holds it. The
+// custom_origin_config and s3_origin_config blocks are empty lists for origins of the
+// other kind.
+func originBlock(origin cftypes.Origin) map[string]any {
+	custom := []map[string]any{}
+	if config := origin.CustomOriginConfig; config != nil {
+		var protocols []string
+		if config.OriginSslProtocols != nil {
+			for _, protocol := range config.OriginSslProtocols.Items {
+				protocols = append(protocols, string(protocol))
+			}
+		}
+		custom = append(custom, map[string]any{
+			"http_port":                aws.ToInt32(config.HTTPPort),
+			"https_port":               aws.ToInt32(config.HTTPSPort),
+			"origin_protocol_policy":   string(config.OriginProtocolPolicy),
+			"origin_ssl_protocols":     protocols,
+			"origin_keepalive_timeout": aws.ToInt32(config.OriginKeepaliveTimeout),
+			"origin_read_timeout":      aws.ToInt32(config.OriginReadTimeout),
+		})
+	}
+	s3 := []map[string]any{}
+	if config := origin.S3OriginConfig; config != nil {
+		s3 = append(s3, map[string]any{"origin_access_identity": aws.ToString(config.OriginAccessIdentity)})
+	}
+	return map[string]any{
+		"domain_name":              aws.ToString(origin.DomainName),
+		"origin_id":                aws.ToString(origin.Id),
+		"origin_path":              aws.ToString(origin.OriginPath),
+		"connection_attempts":      aws.ToInt32(origin.ConnectionAttempts),
+		"connection_timeout":       aws.ToInt32(origin.ConnectionTimeout),
+		"origin_access_control_id": aws.ToString(origin.OriginAccessControlId),
+		"custom_origin_config":     custom,
+		"s3_origin_config":         s3,
+	}
+}
+
+// methods returns the names of HTTP methods.
+func methods(items []cftypes.Method) []string {
+	names := make([]string, 0, len(items))
+	for _, method := range items {
+		names = append(names, string(method))
+	}
+	return names
+}
+
+// Attributes returns every supported attribute of the distribution and one tags.KEY
+// attribute per tag.
+func (c *CloudFrontDistribution) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields[c.ResourceType()]), c.AttributeValue, c.Tags)
+}
+
+// HandleCloudFrontMetadata retrieves the CloudFront distribution with the given id
+// and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - id: The id of the distribution, the id of the resource in the state
+//
+// Returns:
+//   - *CloudFrontDistribution: The live distribution data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleCloudFrontMetadata(ctx context.Context, id string) (*CloudFrontDistribution, error) {
+	ctx, span := telemetry.StartSpan(ctx, "CloudFront.GetDistribution", attribute.String("aws.cloudfront.distribution_id", id))
+	defer span.End()
+
+	out := &CloudFrontDistribution{}
+	cacheKey := a.cacheKey("aws_cloudfront_distribution", id)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*cloudfront.GetDistributionOutput, error) {
+		return a.cloudFront().GetDistribution(ctx, &cloudfront.GetDistributionInput{Id: aws.String(id)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to get CloudFront distribution")
+	}
+	if output.Distribution == nil {
+		return nil, fmt.Errorf("CloudFront distribution %s not found", id)
+	}
+	out.Distribution = *output.Distribution
+
+	tags, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*cloudfront.ListTagsForResourceOutput, error) {
+		return a.cloudFront().ListTagsForResource(ctx, &cloudfront.ListTagsForResourceInput{Resource: out.Distribution.ARN})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to list CloudFront distribution tags")
+	}
+	out.Tags = map[string]string{}
+	if tags.Tags != nil {
+		for _, tag := range tags.Tags.Items {
+			out.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache CloudFront distribution metadata", "distribution_id", id, "error", err)
+	}
+	return out, nil
+}
+
+// listDistributions returns the ids of every CloudFront distribution of the account.
+func (a *AWSProvider) listDistributions(ctx context.Context) ([]string, error) {
+	paginator := cloudfront.NewListDistributionsPaginator(a.cloudFront(), &cloudfront.ListDistributionsInput{
+		MaxItems: a.cloudFrontPageSize(),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *cloudfront.ListDistributionsOutput) []string {
+		var ids []string
+		if page.DistributionList == nil {
+			return ids
+		}
+		for _, distribution := range page.DistributionList.Items {
+			ids = append(ids, aws.ToString(distribution.Id))
+		}
+		return ids
+	})
+}
+
+// cloudFrontPageSize returns the page size of ListDistributions, which accepts at
+// most 1000 results per page, or nil for the API default when PageSize is not set.
+func (a *AWSProvider) cloudFrontPageSize() *int32 {
+	if a.PageSize <= 0 {
+		return nil
+	}
+	return aws.Int32(min(a.PageSize, 1000))
+}
+
+// cloudFront returns the CloudFront client of the provider.
+func (a *AWSProvider) cloudFront() CloudFrontAPI {
+	if a.CloudFront != nil {
+		return a.CloudFront
+	}
+	return cloudfront.NewFromConfig(a.Config)
+}
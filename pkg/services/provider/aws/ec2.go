@@ -1,19 +1,43 @@
 package aws
 
 import (
+	"cmp"
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/provider/paginate"
+	"encoding/base64"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
 )
 
 type EC2InfraInstance struct {
 	Instance types.Instance
+
+	// details loads the attributes DescribeInstances does not return. It is nil for
+	// instances that were not read through a provider, whose user_data and
+	// credit_specification are then empty.
+	details *instanceDetails
+	// stateTemplateVersion is the launch template version recorded in the state,
+	// which may be $Latest or $Default.
+	stateTemplateVersion string
 }
 
+// Tags EC2 adds to instances launched from a launch template.
+const (
+	launchTemplateIdTag      = "aws:ec2launchtemplate:id"
+	launchTemplateVersionTag = "aws:ec2launchtemplate:version"
+)
+
 func (ec2 EC2InfraInstance) ResourceType() string {
 	return "aws_instance"
 }
@@ -30,7 +54,28 @@ func (ec2 EC2InfraInstance) ResourceType() string {
 //
 // If an attribute is missing in the live data (e.g., a tag doesn't exist), it returns an empty string
 // and nil error, allowing the drift checker to correctly identify it as "missing in infrastructure".
+//
+// Attribute path expressions reach into blocks, e.g. metadata_options.http_tokens or
+// ebs_block_device[1].volume_id, and tags whose keys hold dots are read with
+// tags["kubernetes.io/role"], as described by attrpath.Resolve. Aliases such as
+// security_group_ids read the attribute they are an alias of.
+//
+// Attributes that need an API call of their own, such as user_data, are read with a
+// background context; checks read them with AttributeValueContext instead.
 func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
+	return e.AttributeValueContext(context.Background(), attribute)
+}
+
+// AttributeValueContext is AttributeValue with the API calls of attributes such as
+// user_data, credit_specification and launch_template made with ctx.
+func (e *EC2InfraInstance) AttributeValueContext(ctx context.Context, attribute string) (string, error) {
+	return attrpath.Resolve(canonicalAttribute(e.ResourceType(), attribute), func(attribute string) (string, error) {
+		return e.attributeValue(ctx, attribute)
+	})
+}
+
+// attributeValue reads an attribute by its flat name.
+func (e *EC2InfraInstance) attributeValue(ctx context.Context, attribute string) (string, error) {
 	switch EC2Attributes(attribute) { // Cast attribute string to EC2Attributes type
 	// Core Instance Configuration
 	case EC2AMIID:
@@ -64,6 +109,37 @@ func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
 	case EC2EbsOptimzied:
 		// Convert pointer to bool, then to string
 		return strconv.FormatBool(aws.ToBool(e.Instance.EbsOptimized)), nil
+	case EC2PlacementGroup:
+		if e.Instance.Placement != nil {
+			return aws.ToString(e.Instance.Placement.GroupName), nil
+		}
+		return "", nil
+	case EC2Monitoring:
+		// pending means detailed monitoring is being enabled
+		if e.Instance.Monitoring != nil {
+			state := e.Instance.Monitoring.State
+			return strconv.FormatBool(state == types.MonitoringStateEnabled || state == types.MonitoringStatePending), nil
+		}
+		return strconv.FormatBool(false), nil
+	case EC2Hibernation:
+		if e.Instance.HibernationOptions != nil {
+			return strconv.FormatBool(aws.ToBool(e.Instance.HibernationOptions.Configured)), nil
+		}
+		return strconv.FormatBool(false), nil
+	case EC2CreditSpecification, EC2CPUCredits:
+		credits, err := e.details.cpuCredits(ctx, e.Instance)
+		if err != nil || credits == "" {
+			return "", err
+		}
+		if attribute == string(EC2CPUCredits) {
+			return credits, nil
+		}
+		// the state holds the block as a list with a single element
+		bytes, err := json.Marshal([]map[string]string{{"cpu_credits": credits}})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal credit_specification: %w", err)
+		}
+		return string(bytes), nil
 
 	// Networking & Security
 	case EC2SecurityGroupIDs:
@@ -76,10 +152,9 @@ func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
 	case EC2SUBNETID:
 		return aws.ToString(e.Instance.SubnetId), nil
 	case EC2AssociatePublicIPAddress:
-		// This attribute is typically on the primary network interface.
-		// Assume the first network interface if available.
-		if len(e.Instance.NetworkInterfaces) > 0 && e.Instance.NetworkInterfaces[0].Association != nil {
-			return strconv.FormatBool(e.Instance.NetworkInterfaces[0].Association.PublicIp != nil), nil
+		// This attribute is on the primary network interface.
+		if primary := e.primaryNetworkInterface(); primary != nil && primary.Association != nil {
+			return strconv.FormatBool(primary.Association.PublicIp != nil), nil
 		}
 		return strconv.FormatBool(false), nil // Default to false if no association found
 	case EC2PrivateIP:
@@ -90,12 +165,74 @@ func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
 		return aws.ToString(e.Instance.PublicIpAddress), nil
 	case EC2PublicDnsName:
 		return aws.ToString(e.Instance.PublicDnsName), nil
+	case EC2IAMInstanceProfile:
+		// the state holds the profile name, the last segment of its ARN
+		if e.Instance.IamInstanceProfile != nil {
+			arn := aws.ToString(e.Instance.IamInstanceProfile.Arn)
+			return arn[strings.LastIndex(arn, "/")+1:], nil
+		}
+		return "", nil
 	case EC2SourceDestCheck:
 		// This attribute is on the primary network interface.
-		if len(e.Instance.NetworkInterfaces) > 0 && e.Instance.NetworkInterfaces[0].SourceDestCheck != nil {
-			return strconv.FormatBool(aws.ToBool(e.Instance.NetworkInterfaces[0].SourceDestCheck)), nil
+		if primary := e.primaryNetworkInterface(); primary != nil && primary.SourceDestCheck != nil {
+			return strconv.FormatBool(aws.ToBool(primary.SourceDestCheck)), nil
 		}
 		return strconv.FormatBool(true), nil // Default to true if not specified (AWS default)
+	case EC2PrimaryNetworkInterfaceID:
+		if primary := e.primaryNetworkInterface(); primary != nil {
+			return aws.ToString(primary.NetworkInterfaceId), nil
+		}
+		return "", nil
+	case EC2SecondaryPrivateIPs:
+		// the secondary addresses of the primary network interface
+		var ips []string
+		if primary := e.primaryNetworkInterface(); primary != nil {
+			for _, address := range primary.PrivateIpAddresses {
+				if !aws.ToBool(address.Primary) {
+					ips = append(ips, aws.ToString(address.PrivateIpAddress))
+				}
+			}
+		}
+		return strings.Join(ips, ","), nil
+	case EC2NetworkInterface:
+		var blocks []map[string]any
+		for _, eni := range e.declaredNetworkInterfaces() {
+			blocks = append(blocks, map[string]any{
+				"device_index":          aws.ToInt32(eni.Attachment.DeviceIndex),
+				"network_card_index":    aws.ToInt32(eni.Attachment.NetworkCardIndex),
+				"network_interface_id":  aws.ToString(eni.NetworkInterfaceId),
+				"delete_on_termination": aws.ToBool(eni.Attachment.DeleteOnTermination),
+			})
+		}
+		if len(blocks) == 0 {
+			return "", nil
+		}
+		bytes, err := json.Marshal(blocks)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal network_interface: %w", err)
+		}
+		return string(bytes), nil
+	case EC2NetworkInterfaceID, EC2NetworkInterfaceDeviceIndex, EC2NetworkInterfaceSourceDestCheck, EC2NetworkInterfacePrivateIPs:
+		// one value per network interface in device index order, as the state yields
+		// for a repeated block
+		var values []string
+		for _, eni := range e.declaredNetworkInterfaces() {
+			switch EC2Attributes(attribute) {
+			case EC2NetworkInterfaceID:
+				values = append(values, aws.ToString(eni.NetworkInterfaceId))
+			case EC2NetworkInterfaceDeviceIndex:
+				values = append(values, strconv.Itoa(int(aws.ToInt32(eni.Attachment.DeviceIndex))))
+			case EC2NetworkInterfaceSourceDestCheck:
+				values = append(values, strconv.FormatBool(aws.ToBool(eni.SourceDestCheck)))
+			case EC2NetworkInterfacePrivateIPs:
+				var ips []string
+				for _, address := range eni.PrivateIpAddresses {
+					ips = append(ips, aws.ToString(address.PrivateIpAddress))
+				}
+				values = append(values, strings.Join(ips, " "))
+			}
+		}
+		return strings.Join(values, ","), nil
 	// Storage (EBS Volumes) - These are complex, so we'll JSON marshal them
 	case EC2RootBlockDevice:
 		for _, bdm := range e.Instance.BlockDeviceMappings {
@@ -111,8 +248,65 @@ func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
 			}
 		}
 		return "", nil // No root block device found or EBS info missing
+	case EC2EBSBlockDevice:
+		devices := e.ebsBlockDevices()
+		if len(devices) == 0 {
+			return "", nil
+		}
+		bytes, err := json.Marshal(devices)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal ebs_block_device: %w", err)
+		}
+		return string(bytes), nil
+	case EC2EBSBlockDeviceName, EC2EBSVolumeID, EC2EBSDeleteOnTermination:
+		// one value per device, as the state yields for a repeated block
+		key := strings.TrimPrefix(attribute, string(EC2EBSBlockDevice)+".")
+		var values []string
+		for _, device := range e.ebsBlockDevices() {
+			values = append(values, fmt.Sprint(device[key]))
+		}
+		return strings.Join(values, ","), nil
+
+	// Launch Template
+	case EC2LaunchTemplateID:
+		return e.tag(launchTemplateIdTag), nil
+	case EC2LaunchTemplateName:
+		template, err := e.details.launchTemplate(ctx, e.tag(launchTemplateIdTag))
+		if err != nil || template == nil {
+			return "", err
+		}
+		return aws.ToString(template.LaunchTemplateName), nil
+	case EC2LaunchTemplateVersion:
+		return e.launchTemplateVersion(ctx)
+	case EC2LaunchTemplate:
+		id := e.tag(launchTemplateIdTag)
+		if id == "" {
+			return "", nil
+		}
+		name, err := e.attributeValue(ctx, string(EC2LaunchTemplateName))
+		if err != nil {
+			return "", err
+		}
+		version, err := e.launchTemplateVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		// the state holds the block as a list with a single element
+		bytes, err := json.Marshal([]map[string]string{{"id": id, "name": name, "version": version}})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal launch_template: %w", err)
+		}
+		return string(bytes), nil
 
 	// Metadata & User Data
+	case EC2UserData:
+		return e.details.userData(ctx)
+	case EC2UserDataBase64:
+		userData, err := e.details.userData(ctx)
+		if err != nil || userData == "" {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString([]byte(userData)), nil
 	case EC2MetadataOptions:
 		if e.Instance.MetadataOptions != nil {
 			bytes, err := json.Marshal(e.Instance.MetadataOptions)
@@ -133,17 +327,265 @@ func (e *EC2InfraInstance) AttributeValue(attribute string) (string, error) {
 	default:
 		// Handle tags in the format "tags.KEY"
 		if strings.HasPrefix(attribute, "tags.") {
-			tagName := strings.TrimPrefix(attribute, "tags.")
-			for _, tag := range e.Instance.Tags {
-				if aws.ToString(tag.Key) == tagName {
-					return aws.ToString(tag.Value), nil
-				}
-			}
 			// If a tag is requested but not present, return empty string and nil error.
 			// This indicates absence, allowing the drift checker to mark it as missing.
-			return "", nil
+			return e.tag(strings.TrimPrefix(attribute, "tags.")), nil
 		}
 
 		return "", fmt.Errorf("'%s' attribute is not supported for EC2 instances or is an invalid attribute name", attribute)
 	}
 }
+
+// Attributes returns every supported attribute of the instance, keyed by its name in
+// the Terraform schema, and one tags.KEY attribute per tag. The attributes of a block
+// that are also readable alone, such as ebs_block_device.volume_id, are only returned
+// as part of their block.
+func (e *EC2InfraInstance) Attributes() (map[string]string, error) {
+	return e.AttributesContext(context.Background())
+}
+
+// AttributesContext is Attributes with the API calls of attributes such as user_data
+// made with ctx.
+func (e *EC2InfraInstance) AttributesContext(ctx context.Context) (map[string]string, error) {
+	attributes := map[string]string{}
+	var errs []error
+	for _, attribute := range fieldAttributes(fields[e.ResourceType()]) {
+		if strings.ContainsAny(attribute, ".*") {
+			continue
+		}
+		value, err := e.AttributeValueContext(ctx, attribute)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", attribute, err))
+			continue
+		}
+		attributes[attribute] = value
+	}
+	for _, tag := range e.Instance.Tags {
+		// tags with the reserved aws: prefix are added by AWS and never in the state
+		if key := aws.ToString(tag.Key); !strings.HasPrefix(key, "aws:") {
+			attributes["tags."+key] = aws.ToString(tag.Value)
+		}
+	}
+	return attributes, stderrors.Join(errs...)
+}
+
+// networkInterfaces returns the network interfaces attached to the instance, in
+// device index order.
+func (e *EC2InfraInstance) networkInterfaces() []types.InstanceNetworkInterface {
+	var enis []types.InstanceNetworkInterface
+	for _, eni := range e.Instance.NetworkInterfaces {
+		if eni.Attachment != nil {
+			enis = append(enis, eni)
+		}
+	}
+	slices.SortStableFunc(enis, func(a, b types.InstanceNetworkInterface) int {
+		return cmp.Or(
+			cmp.Compare(aws.ToInt32(a.Attachment.NetworkCardIndex), aws.ToInt32(b.Attachment.NetworkCardIndex)),
+			cmp.Compare(aws.ToInt32(a.Attachment.DeviceIndex), aws.ToInt32(b.Attachment.DeviceIndex)),
+		)
+	})
+	return enis
+}
+
+// declaredNetworkInterfaces returns the network interfaces the network_interface
+// blocks of the state can hold. Terraform leaves the blocks empty when the primary
+// interface was created with the instance rather than declared, which is when EC2
+// deletes it on termination by default, so none are returned then.
+func (e *EC2InfraInstance) declaredNetworkInterfaces() []types.InstanceNetworkInterface {
+	primary := e.primaryNetworkInterface()
+	if primary == nil || primary.Attachment == nil || aws.ToBool(primary.Attachment.DeleteOnTermination) {
+		return nil
+	}
+	return e.networkInterfaces()
+}
+
+// primaryNetworkInterface returns the network interface at device index 0, which
+// DescribeInstances does not necessarily list first, or nil when the instance has no
+// network interface.
+func (e *EC2InfraInstance) primaryNetworkInterface() *types.InstanceNetworkInterface {
+	for i, eni := range e.Instance.NetworkInterfaces {
+		if eni.Attachment != nil && aws.ToInt32(eni.Attachment.DeviceIndex) == 0 && aws.ToInt32(eni.Attachment.NetworkCardIndex) == 0 {
+			return &e.Instance.NetworkInterfaces[i]
+		}
+	}
+	// interfaces without attachment details, as returned by some emulators
+	if len(e.Instance.NetworkInterfaces) > 0 {
+		return &e.Instance.NetworkInterfaces[0]
+	}
+	return nil
+}
+
+// tag returns the value of the instance tag with the given key, or an empty string
+// when the instance has no such tag.
+func (e *EC2InfraInstance) tag(key string) string {
+	for _, tag := range e.Instance.Tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// launchTemplateVersion returns the launch template version the instance was launched
+// from. When the state records $Latest or $Default and the alias still resolves to
+// that version, the alias is returned so that it matches the state; once the template
+// has moved on, the version number the instance actually runs is returned instead.
+func (e *EC2InfraInstance) launchTemplateVersion(ctx context.Context) (string, error) {
+	launched := e.tag(launchTemplateVersionTag)
+	if launched == "" {
+		return "", nil
+	}
+
+	var resolve func(*types.LaunchTemplate) *int64
+	switch e.stateTemplateVersion {
+	case "$Latest":
+		resolve = func(t *types.LaunchTemplate) *int64 { return t.LatestVersionNumber }
+	case "$Default":
+		resolve = func(t *types.LaunchTemplate) *int64 { return t.DefaultVersionNumber }
+	default:
+		return launched, nil
+	}
+
+	template, err := e.details.launchTemplate(ctx, e.tag(launchTemplateIdTag))
+	if err != nil || template == nil {
+		return launched, err
+	}
+	if version := resolve(template); version != nil && strconv.FormatInt(*version, 10) == launched {
+		return e.stateTemplateVersion, nil
+	}
+	return launched, nil
+}
+
+// ebsBlockDevices returns the EBS volumes attached to the instance besides its root
+// volume, in the shape of the ebs_block_device blocks of the state.
+func (e *EC2InfraInstance) ebsBlockDevices() []map[string]any {
+	var devices []map[string]any
+	for _, bdm := range e.Instance.BlockDeviceMappings {
+		if bdm.Ebs == nil || e.isRootDevice(aws.ToString(bdm.DeviceName)) {
+			continue
+		}
+		devices = append(devices, map[string]any{
+			"device_name":           aws.ToString(bdm.DeviceName),
+			"volume_id":             aws.ToString(bdm.Ebs.VolumeId),
+			"delete_on_termination": aws.ToBool(bdm.Ebs.DeleteOnTermination),
+		})
+	}
+	return devices
+}
+
+// isRootDevice reports whether deviceName is the root device of the instance. The
+// common root device names are assumed when the instance does not name its own.
+func (e *EC2InfraInstance) isRootDevice(deviceName string) bool {
+	if root := aws.ToString(e.Instance.RootDeviceName); root != "" {
+		return deviceName == root
+	}
+	return deviceName == "/dev/sda1" || deviceName == "/dev/xvda"
+}
+
+// instanceDetails loads the instance attributes that need an API call of their own,
+// on first use so that instances whose user data or CPU credits are not tracked cost
+// no extra calls. The calls are made with the context of the check reading them.
+type instanceDetails struct {
+	provider   *AWSProvider
+	instanceId string
+
+	userDataOnce sync.Once
+	userDataVal  string
+	userDataErr  error
+
+	creditsOnce sync.Once
+	creditsVal  string
+	creditsErr  error
+
+	templateOnce sync.Once
+	templateVal  *types.LaunchTemplate
+	templateErr  error
+}
+
+// instanceDetails returns the lazily loaded details of an instance.
+func (a *AWSProvider) instanceDetails(instanceId string) *instanceDetails {
+	return &instanceDetails{provider: a, instanceId: instanceId}
+}
+
+// userData returns the decoded user data of the instance, read with
+// DescribeInstanceAttribute.
+func (d *instanceDetails) userData(ctx context.Context) (string, error) {
+	if d == nil {
+		return "", nil
+	}
+	d.userDataOnce.Do(func() {
+		output, err := paginate.Call(ctx, d.provider.calls(), func(ctx context.Context) (*ec2.DescribeInstanceAttributeOutput, error) {
+			return d.provider.ec2().DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+				InstanceId: aws.String(d.instanceId),
+				Attribute:  types.InstanceAttributeNameUserData,
+			})
+		})
+		if err != nil {
+			d.userDataErr = errors.Wrap(err, "Failed to describe ec2 instance user data")
+			return
+		}
+		if output.UserData == nil || output.UserData.Value == nil {
+			return
+		}
+		decoded, err := base64.StdEncoding.DecodeString(aws.ToString(output.UserData.Value))
+		if err != nil {
+			d.userDataErr = fmt.Errorf("failed to decode user_data: %w", err)
+			return
+		}
+		d.userDataVal = string(decoded)
+	})
+	return d.userDataVal, d.userDataErr
+}
+
+// cpuCredits returns the CPU credit option of a burstable instance, standard or
+// unlimited. Other instances have none and cost no call.
+func (d *instanceDetails) cpuCredits(ctx context.Context, instance types.Instance) (string, error) {
+	if d == nil || !isBurstable(instance.InstanceType) {
+		return "", nil
+	}
+	d.creditsOnce.Do(func() {
+		output, err := paginate.Call(ctx, d.provider.calls(), func(ctx context.Context) (*ec2.DescribeInstanceCreditSpecificationsOutput, error) {
+			return d.provider.ec2().DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{
+				InstanceIds: []string{d.instanceId},
+			})
+		})
+		if err != nil {
+			d.creditsErr = errors.Wrap(err, "Failed to describe ec2 instance credit specification")
+			return
+		}
+		if len(output.InstanceCreditSpecifications) > 0 {
+			d.creditsVal = aws.ToString(output.InstanceCreditSpecifications[0].CpuCredits)
+		}
+	})
+	return d.creditsVal, d.creditsErr
+}
+
+// launchTemplate returns the launch template with the given id, read with
+// DescribeLaunchTemplates, or nil when id is empty or the template no longer exists.
+func (d *instanceDetails) launchTemplate(ctx context.Context, id string) (*types.LaunchTemplate, error) {
+	if d == nil || id == "" {
+		return nil, nil
+	}
+	d.templateOnce.Do(func() {
+		output, err := paginate.Call(ctx, d.provider.calls(), func(ctx context.Context) (*ec2.DescribeLaunchTemplatesOutput, error) {
+			return d.provider.ec2().DescribeLaunchTemplates(ctx, &ec2.DescribeLaunchTemplatesInput{
+				Filters: []types.Filter{{Name: aws.String("launch-template-id"), Values: []string{id}}},
+			})
+		})
+		if err != nil {
+			d.templateErr = errors.Wrap(err, "Failed to describe launch template")
+			return
+		}
+		if len(output.LaunchTemplates) > 0 {
+			d.templateVal = &output.LaunchTemplates[0]
+		}
+	})
+	return d.templateVal, d.templateErr
+}
+
+// isBurstable reports whether instances of the type earn CPU credits, as the T
+// family does.
+func isBurstable(instanceType types.InstanceType) bool {
+	family, _, _ := strings.Cut(string(instanceType), ".")
+	return strings.HasPrefix(family, "t")
+}
@@ -1,7 +1,10 @@
 package aws_test
 
 import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
 	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/statemanager/terraform"
 	"encoding/json"
 	"testing"
 
@@ -307,3 +310,46 @@ func TestEC2InfraInstance_AttributeValue_EmptyInstance(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, val)
 }
+
+func TestEC2InfraInstance_AttributeValue_ImplicitPrimaryNetworkInterface(t *testing.T) {
+	ctx := context.Background()
+	manager := terraform.NewTerraformManager()
+	content, err := manager.ParseStateFile(ctx, "../../../../assets/terraform_ec2_state.tfstate")
+	require.NoError(t, err)
+	resources, err := manager.RetrieveResources(ctx, content, "aws_instance")
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	// the primary interface was created with the instance, the state declares none
+	attached := func(id string, index int32, deleteOnTermination bool) types.InstanceNetworkInterface {
+		return types.InstanceNetworkInterface{
+			NetworkInterfaceId: aws.String(id),
+			Attachment: &types.InstanceNetworkInterfaceAttachment{
+				DeviceIndex:         aws.Int32(index),
+				NetworkCardIndex:    aws.Int32(0),
+				DeleteOnTermination: aws.Bool(deleteOnTermination),
+			},
+		}
+	}
+	e := &awsProvider.EC2InfraInstance{Instance: types.Instance{
+		InstanceId: aws.String("i-0723dd4b084b79ce6"),
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			attached("eni-0123456789abcdef0", 0, true),
+			attached("eni-attached-later", 1, false),
+		},
+	}}
+
+	val, err := e.AttributeValue("network_interface")
+	assert.NoError(t, err)
+	assert.Empty(t, val)
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(ctx, e, resources[0], []string{"network_interface", "primary_network_interface_id"})
+	require.NoError(t, err)
+	assert.False(t, report.HasDrift, "%+v", report.DriftDetails)
+
+	// an interface declared as the primary one is kept on termination
+	e.Instance.NetworkInterfaces[0] = attached("eni-0123456789abcdef0", 0, false)
+	val, err = e.AttributeValue("network_interface.network_interface_id")
+	assert.NoError(t, err)
+	assert.Equal(t, "eni-0123456789abcdef0,eni-attached-later", val)
+}
@@ -0,0 +1,414 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EKSAPI is the part of the EKS API used by the provider, for clusters and their
+// managed node groups. It is implemented by *eks.Client, and by awstest.FakeEKS for
+// tests that do not reach AWS.
+type EKSAPI interface {
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+	ListClusters(ctx context.Context, params *eks.ListClustersInput, optFns ...func(*eks.Options)) (*eks.ListClustersOutput, error)
+	DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error)
+	ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error)
+}
+
+// Attributes of aws_eks_cluster clusters, named after the Terraform schema.
+const (
+	EKSName                   = "name"
+	EKSArn                    = "arn"
+	EKSVersion                = "version"
+	EKSPlatformVersion        = "platform_version"
+	EKSRoleArn                = "role_arn"
+	EKSEndpoint               = "endpoint"
+	EKSStatus                 = "status"
+	EKSEnabledClusterLogTypes = "enabled_cluster_log_types"
+	// EKSVPCConfig is the vpc_config block, holding endpoint_private_access,
+	// endpoint_public_access, public_access_cidrs, security_group_ids, subnet_ids,
+	// vpc_id and cluster_security_group_id.
+	EKSVPCConfig = "vpc_config"
+	// EKSKubernetesNetworkConfig is the kubernetes_network_config block, holding
+	// service_ipv4_cidr, service_ipv6_cidr and ip_family.
+	EKSKubernetesNetworkConfig = "kubernetes_network_config"
+)
+
+// Attributes of aws_eks_node_group managed node groups, named after the Terraform
+// schema.
+const (
+	NodeGroupClusterName    = "cluster_name"
+	NodeGroupName           = "node_group_name"
+	NodeGroupArn            = "arn"
+	NodeGroupVersion        = "version"
+	NodeGroupReleaseVersion = "release_version"
+	NodeGroupAMIType        = "ami_type"
+	NodeGroupCapacityType   = "capacity_type"
+	NodeGroupDiskSize       = "disk_size"
+	NodeGroupInstanceTypes  = "instance_types"
+	NodeGroupNodeRoleArn    = "node_role_arn"
+	NodeGroupSubnetIDs      = "subnet_ids"
+	NodeGroupStatus         = "status"
+	// NodeGroupScalingConfig is the scaling_config block, holding desired_size,
+	// max_size and min_size.
+	NodeGroupScalingConfig = "scaling_config"
+	// NodeGroupUpdateConfig is the update_config block, holding max_unavailable and
+	// max_unavailable_percentage.
+	NodeGroupUpdateConfig = "update_config"
+	// NodeGroupLaunchTemplate is the launch_template block, holding id, name and
+	// version.
+	NodeGroupLaunchTemplate = "launch_template"
+	// NodeGroupTaint is the taint blocks, holding key, value and effect.
+	NodeGroupTaint = "taint"
+)
+
+func init() {
+	// the state holds every key of the blocks while only some are read
+	driftchecker.Register("aws_eks_cluster", driftchecker.ForAttributes(driftchecker.JSONSubsetEqual,
+		EKSVPCConfig, EKSKubernetesNetworkConfig))
+	driftchecker.Register("aws_eks_node_group", driftchecker.ForAttributes(driftchecker.JSONSubsetEqual,
+		NodeGroupScalingConfig, NodeGroupUpdateConfig, NodeGroupLaunchTemplate, NodeGroupTaint))
+}
+
+// EKSCluster is the live state of an aws_eks_cluster.
+type EKSCluster struct {
+	Cluster ekstypes.Cluster
+}
+
+func (c *EKSCluster) ResourceType() string {
+	return "aws_eks_cluster"
+}
+
+// AttributeValue retrieves the string value of a cluster attribute. The enabled log
+// types are sorted and joined with commas, the vpc_config and
+// kubernetes_network_config blocks are read as JSON, so
+// vpc_config.endpoint_public_access reaches into them, and tags with tags.KEY.
+func (c *EKSCluster) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, c.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (c *EKSCluster) attributeValue(attribute string) (string, error) {
+	cluster := c.Cluster
+	switch attribute {
+	case EKSName:
+		return aws.ToString(cluster.Name), nil
+	case EKSArn:
+		return aws.ToString(cluster.Arn), nil
+	case EKSVersion:
+		return aws.ToString(cluster.Version), nil
+	case EKSPlatformVersion:
+		return aws.ToString(cluster.PlatformVersion), nil
+	case EKSRoleArn:
+		return aws.ToString(cluster.RoleArn), nil
+	case EKSEndpoint:
+		return aws.ToString(cluster.Endpoint), nil
+	case EKSStatus:
+		return string(cluster.Status), nil
+	case EKSEnabledClusterLogTypes:
+		var types []string
+		if cluster.Logging != nil {
+			for _, setup := range cluster.Logging.ClusterLogging {
+				if !aws.ToBool(setup.Enabled) {
+					continue
+				}
+				for _, logType := range setup.Types {
+					types = append(types, string(logType))
+				}
+			}
+		}
+		return sortedJoin(types), nil
+	case EKSVPCConfig:
+		config := cluster.ResourcesVpcConfig
+		if config == nil {
+			return "", nil
+		}
+		return marshalBlock(EKSVPCConfig, map[string]any{
+			"endpoint_private_access":   config.EndpointPrivateAccess,
+			"endpoint_public_access":    config.EndpointPublicAccess,
+			"public_access_cidrs":       slices.Sorted(slices.Values(config.PublicAccessCidrs)),
+			"security_group_ids":        slices.Sorted(slices.Values(config.SecurityGroupIds)),
+			"subnet_ids":                slices.Sorted(slices.Values(config.SubnetIds)),
+			"vpc_id":                    aws.ToString(config.VpcId),
+			"cluster_security_group_id": aws.ToString(config.ClusterSecurityGroupId),
+		})
+	case EKSKubernetesNetworkConfig:
+		config := cluster.KubernetesNetworkConfig
+		if config == nil {
+			return "", nil
+		}
+		return marshalBlock(EKSKubernetesNetworkConfig, map[string]any{
+			"service_ipv4_cidr": aws.ToString(config.ServiceIpv4Cidr),
+			"service_ipv6_cidr": aws.ToString(config.ServiceIpv6Cidr),
+			"ip_family":         string(config.IpFamily),
+		})
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return cluster.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for EKS clusters or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the cluster and one tags.KEY
+// attribute per tag.
+func (c *EKSCluster) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields[c.ResourceType()]), c.AttributeValue, c.Cluster.Tags)
+}
+
+// EKSNodeGroup is the live state of an aws_eks_node_group managed node group.
+type EKSNodeGroup struct {
+	NodeGroup ekstypes.Nodegroup
+}
+
+func (n *EKSNodeGroup) ResourceType() string {
+	return "aws_eks_node_group"
+}
+
+// AttributeValue retrieves the string value of a node group attribute. Instance
+// types are joined with commas in order and subnets sorted, the scaling_config,
+// update_config, launch_template and taint blocks are read as JSON, so
+// scaling_config.desired_size reaches into them, and labels and tags with labels.KEY
+// and tags.KEY.
+func (n *EKSNodeGroup) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, n.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (n *EKSNodeGroup) attributeValue(attribute string) (string, error) {
+	group := n.NodeGroup
+	switch attribute {
+	case NodeGroupClusterName:
+		return aws.ToString(group.ClusterName), nil
+	case NodeGroupName:
+		return aws.ToString(group.NodegroupName), nil
+	case NodeGroupArn:
+		return aws.ToString(group.NodegroupArn), nil
+	case NodeGroupVersion:
+		return aws.ToString(group.Version), nil
+	case NodeGroupReleaseVersion:
+		return aws.ToString(group.ReleaseVersion), nil
+	case NodeGroupAMIType:
+		return string(group.AmiType), nil
+	case NodeGroupCapacityType:
+		return string(group.CapacityType), nil
+	case NodeGroupDiskSize:
+		if group.DiskSize == nil {
+			return "", nil
+		}
+		return strconv.Itoa(int(*group.DiskSize)), nil
+	case NodeGroupInstanceTypes:
+		return strings.Join(group.InstanceTypes, ","), nil
+	case NodeGroupNodeRoleArn:
+		return aws.ToString(group.NodeRole), nil
+	case NodeGroupSubnetIDs:
+		return sortedJoin(group.Subnets), nil
+	case NodeGroupStatus:
+		return string(group.Status), nil
+	case NodeGroupScalingConfig:
+		config := group.ScalingConfig
+		if config == nil {
+			return "", nil
+		}
+		return marshalBlock(NodeGroupScalingConfig, map[string]any{
+			"desired_size": aws.ToInt32(config.DesiredSize),
+			"max_size":     aws.ToInt32(config.MaxSize),
+			"min_size":     aws.ToInt32(config.MinSize),
+		})
+	case NodeGroupUpdateConfig:
+		config := group.UpdateConfig
+		if config == nil {
+			return "", nil
+		}
+		return marshalBlock(NodeGroupUpdateConfig, map[string]any{
+			"max_unavailable":            aws.ToInt32(config.MaxUnavailable),
+			"max_unavailable_percentage": aws.ToInt32(config.MaxUnavailablePercentage),
+		})
+	case NodeGroupLaunchTemplate:
+		template := group.LaunchTemplate
+		if template == nil {
+			return "", nil
+		}
+		return marshalBlock(NodeGroupLaunchTemplate, map[string]any{
+			"id":      aws.ToString(template.Id),
+			"name":    aws.ToString(template.Name),
+			"version": aws.ToString(template.Version),
+		})
+	case NodeGroupTaint:
+		taints := make([]map[string]any, 0, len(group.Taints))
+		for _, taint := range group.Taints {
+			taints = append(taints, map[string]any{
+				"key":    aws.ToString(taint.Key),
+				"value":  aws.ToString(taint.Value),
+				"effect": string(taint.Effect),
+			})
+		}
+		return marshalBlock(NodeGroupTaint, taints...)
+	}
+	if key, ok := strings.CutPrefix(attribute, "labels."); ok {
+		return group.Labels[key], nil
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return group.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for EKS node groups or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the node group, one labels.KEY
+// attribute per label and one tags.KEY attribute per tag.
+func (n *EKSNodeGroup) Attributes() (map[string]string, error) {
+	attributes, err := readAttributes(fieldAttributes(fields[n.ResourceType()]), n.AttributeValue, n.NodeGroup.Tags)
+	for key, value := range n.NodeGroup.Labels {
+		attributes["labels."+key] = value
+	}
+	return attributes, err
+}
+
+// HandleEKSClusterMetadata retrieves the EKS cluster with the given name.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - name: The name of the cluster, the id of the resource in the state
+//
+// Returns:
+//   - *EKSCluster: The live cluster data
+//   - error: Any error encountered during the AWS API call
+func (a *AWSProvider) HandleEKSClusterMetadata(ctx context.Context, name string) (*EKSCluster, error) {
+	ctx, span := telemetry.StartSpan(ctx, "EKS.DescribeCluster", attribute.String("aws.eks.cluster", name))
+	defer span.End()
+
+	out := &EKSCluster{}
+	cacheKey := a.cacheKey("aws_eks_cluster", name)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*eks.DescribeClusterOutput, error) {
+		return a.eks().DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(name)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe EKS cluster")
+	}
+	if output.Cluster == nil {
+		return nil, fmt.Errorf("EKS cluster %s not found", name)
+	}
+	out.Cluster = *output.Cluster
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache EKS cluster metadata", "cluster", name, "error", err)
+	}
+	return out, nil
+}
+
+// HandleEKSNodeGroupMetadata retrieves the managed node group with the given id.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - id: The id of the node group in the state, CLUSTER:NODE_GROUP
+//
+// Returns:
+//   - *EKSNodeGroup: The live node group data
+//   - error: Any error encountered during the AWS API call
+func (a *AWSProvider) HandleEKSNodeGroupMetadata(ctx context.Context, id string) (*EKSNodeGroup, error) {
+	ctx, span := telemetry.StartSpan(ctx, "EKS.DescribeNodegroup", attribute.String("aws.eks.node_group", id))
+	defer span.End()
+
+	clusterName, nodeGroupName, ok := strings.Cut(id, ":")
+	if !ok || clusterName == "" || nodeGroupName == "" {
+		return nil, fmt.Errorf("invalid EKS node group id %q, expected CLUSTER:NODE_GROUP", id)
+	}
+
+	out := &EKSNodeGroup{}
+	cacheKey := a.cacheKey("aws_eks_node_group", id)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*eks.DescribeNodegroupOutput, error) {
+		return a.eks().DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+			ClusterName:   aws.String(clusterName),
+			NodegroupName: aws.String(nodeGroupName),
+		})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe EKS node group")
+	}
+	if output.Nodegroup == nil {
+		return nil, fmt.Errorf("EKS node group %s not found", id)
+	}
+	out.NodeGroup = *output.Nodegroup
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache EKS node group metadata", "node_group", id, "error", err)
+	}
+	return out, nil
+}
+
+// listEKSClusters returns the names of every EKS cluster of the region.
+func (a *AWSProvider) listEKSClusters(ctx context.Context) ([]string, error) {
+	paginator := eks.NewListClustersPaginator(a.eks(), &eks.ListClustersInput{
+		MaxResults: aws.Int32(a.eksPageSize()),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *eks.ListClustersOutput) []string {
+		return page.Clusters
+	})
+}
+
+// listEKSNodeGroups returns the ids of every managed node group of every EKS cluster
+// of the region, as CLUSTER:NODE_GROUP.
+func (a *AWSProvider) listEKSNodeGroups(ctx context.Context) ([]string, error) {
+	clusters, err := a.listEKSClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, cluster := range clusters {
+		paginator := eks.NewListNodegroupsPaginator(a.eks(), &eks.ListNodegroupsInput{
+			ClusterName: aws.String(cluster),
+			MaxResults:  aws.Int32(a.eksPageSize()),
+		})
+		groups, err := paginate.Collect(ctx, paginator, a.calls(), func(page *eks.ListNodegroupsOutput) []string {
+			return page.Nodegroups
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to list node groups of EKS cluster %s", cluster)
+		}
+		for _, group := range groups {
+			ids = append(ids, cluster+":"+group)
+		}
+	}
+	return ids, nil
+}
+
+// eksPageSize returns the page size of EKS list calls, which accept at most 100
+// results per page.
+func (a *AWSProvider) eksPageSize() int32 {
+	if a.PageSize <= 0 {
+		return 100
+	}
+	return min(a.PageSize, 100)
+}
+
+// eks returns the EKS client of the provider.
+func (a *AWSProvider) eks() EKSAPI {
+	if a.EKS != nil {
+		return a.EKS
+	}
+	return eks.NewFromConfig(a.Config)
+}
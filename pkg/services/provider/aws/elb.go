@@ -0,0 +1,266 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ELBAPI is the part of the Elastic Load Balancing API used by the provider, for
+// classic load balancers. It is implemented by *elasticloadbalancing.Client, and by
+// awstest.FakeELB for tests that do not reach AWS.
+type ELBAPI interface {
+	DescribeLoadBalancers(ctx context.Context, params *elasticloadbalancing.DescribeLoadBalancersInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeLoadBalancersOutput, error)
+	DescribeLoadBalancerAttributes(ctx context.Context, params *elasticloadbalancing.DescribeLoadBalancerAttributesInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeLoadBalancerAttributesOutput, error)
+	DescribeTags(ctx context.Context, params *elasticloadbalancing.DescribeTagsInput, optFns ...func(*elasticloadbalancing.Options)) (*elasticloadbalancing.DescribeTagsOutput, error)
+}
+
+// Attributes of aws_elb classic load balancers, named after the Terraform schema.
+const (
+	ELBName                      = "name"
+	ELBDNSName                   = "dns_name"
+	ELBZoneID                    = "zone_id"
+	ELBInternal                  = "internal"
+	ELBSecurityGroups            = "security_groups"
+	ELBSubnets                   = "subnets"
+	ELBAvailabilityZones         = "availability_zones"
+	ELBInstances                 = "instances"
+	ELBIdleTimeout               = "idle_timeout"
+	ELBCrossZone                 = "cross_zone_load_balancing"
+	ELBConnectionDraining        = "connection_draining"
+	ELBConnectionDrainingTimeout = "connection_draining_timeout"
+	// ELBListener is the listener blocks, holding instance_port, instance_protocol,
+	// lb_port, lb_protocol and ssl_certificate_id.
+	ELBListener = "listener"
+	// ELBHealthCheck is the health_check block, holding target, interval, timeout,
+	// healthy_threshold and unhealthy_threshold.
+	ELBHealthCheck = "health_check"
+	// ELBAccessLogs is the access_logs block, holding bucket, bucket_prefix, interval
+	// and enabled.
+	ELBAccessLogs = "access_logs"
+)
+
+// ClassicLoadBalancer is the live state of an aws_elb classic load balancer.
+type ClassicLoadBalancer struct {
+	LoadBalancer elbtypes.LoadBalancerDescription
+	// Settings holds the load balancer attributes, such as the connection settings.
+	Settings elbtypes.LoadBalancerAttributes
+	Tags     map[string]string
+}
+
+func (c *ClassicLoadBalancer) ResourceType() string {
+	return "aws_elb"
+}
+
+// AttributeValue retrieves the string value of a classic load balancer attribute.
+// Lists such as security_groups and instances are sorted and joined with commas, the
+// listener, health_check and access_logs blocks are read as JSON and tags with
+// tags.KEY.
+func (c *ClassicLoadBalancer) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, c.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (c *ClassicLoadBalancer) attributeValue(attribute string) (string, error) {
+	lb := c.LoadBalancer
+	attributes := c.Settings
+	switch attribute {
+	case ELBName:
+		return aws.ToString(lb.LoadBalancerName), nil
+	case ELBDNSName:
+		return aws.ToString(lb.DNSName), nil
+	case ELBZoneID:
+		return aws.ToString(lb.CanonicalHostedZoneNameID), nil
+	case ELBInternal:
+		return strconv.FormatBool(aws.ToString(lb.Scheme) == "internal"), nil
+	case ELBSecurityGroups:
+		return sortedJoin(lb.SecurityGroups), nil
+	case ELBSubnets:
+		return sortedJoin(lb.Subnets), nil
+	case ELBAvailabilityZones:
+		return sortedJoin(lb.AvailabilityZones), nil
+	case ELBInstances:
+		var instances []string
+		for _, instance := range lb.Instances {
+			instances = append(instances, aws.ToString(instance.InstanceId))
+		}
+		return sortedJoin(instances), nil
+	case ELBIdleTimeout:
+		if attributes.ConnectionSettings == nil || attributes.ConnectionSettings.IdleTimeout == nil {
+			return "", nil
+		}
+		return strconv.Itoa(int(*attributes.ConnectionSettings.IdleTimeout)), nil
+	case ELBCrossZone:
+		if attributes.CrossZoneLoadBalancing == nil {
+			return "", nil
+		}
+		return strconv.FormatBool(attributes.CrossZoneLoadBalancing.Enabled), nil
+	case ELBConnectionDraining:
+		if attributes.ConnectionDraining == nil {
+			return "", nil
+		}
+		return strconv.FormatBool(attributes.ConnectionDraining.Enabled), nil
+	case ELBConnectionDrainingTimeout:
+		if attributes.ConnectionDraining == nil || attributes.ConnectionDraining.Timeout == nil {
+			return "", nil
+		}
+		return strconv.Itoa(int(*attributes.ConnectionDraining.Timeout)), nil
+	case ELBListener:
+		var listeners []map[string]any
+		for _, description := range lb.ListenerDescriptions {
+			listener := description.Listener
+			if listener == nil {
+				continue
+			}
+			// the state holds the protocols in lower case, the API in upper case
+			listeners = append(listeners, map[string]any{
+				"instance_port":      aws.ToInt32(listener.InstancePort),
+				"instance_protocol":  strings.ToLower(aws.ToString(listener.InstanceProtocol)),
+				"lb_port":            listener.LoadBalancerPort,
+				"lb_protocol":        strings.ToLower(aws.ToString(listener.Protocol)),
+				"ssl_certificate_id": aws.ToString(listener.SSLCertificateId),
+			})
+		}
+		slices.SortFunc(listeners, func(a, b map[string]any) int {
+			return int(a["lb_port"].(int32)) - int(b["lb_port"].(int32))
+		})
+		return marshalBlock(ELBListener, listeners...)
+	case ELBHealthCheck:
+		check := lb.HealthCheck
+		if check == nil {
+			return "", nil
+		}
+		return marshalBlock(ELBHealthCheck, map[string]any{
+			"target":              aws.ToString(check.Target),
+			"interval":            aws.ToInt32(check.Interval),
+			"timeout":             aws.ToInt32(check.Timeout),
+			"healthy_threshold":   aws.ToInt32(check.HealthyThreshold),
+			"unhealthy_threshold": aws.ToInt32(check.UnhealthyThreshold),
+		})
+	case ELBAccessLogs:
+		logs := attributes.AccessLog
+		if logs == nil {
+			return "", nil
+		}
+		return marshalBlock(ELBAccessLogs, map[string]any{
+			"bucket":        aws.ToString(logs.S3BucketName),
+			"bucket_prefix": aws.ToString(logs.S3BucketPrefix),
+			"interval":      aws.ToInt32(logs.EmitInterval),
+			"enabled":       logs.Enabled,
+		})
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return c.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for classic load balancers or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the classic load balancer and one
+// tags.KEY attribute per tag.
+func (c *ClassicLoadBalancer) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields["aws_elb"]), c.AttributeValue, c.Tags)
+}
+
+// HandleClassicLoadBalancerMetadata retrieves the classic load balancer with the
+// given name, its attributes and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - name: The name of the load balancer, the id of the resource in the state
+//
+// Returns:
+//   - *ClassicLoadBalancer: The live load balancer data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleClassicLoadBalancerMetadata(ctx context.Context, name string) (*ClassicLoadBalancer, error) {
+	ctx, span := telemetry.StartSpan(ctx, "ELB.DescribeLoadBalancers", attribute.String("aws.elb.name", name))
+	defer span.End()
+
+	out := &ClassicLoadBalancer{}
+	cacheKey := a.cacheKey("aws_elb", name)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancing.DescribeLoadBalancersOutput, error) {
+		return a.elb().DescribeLoadBalancers(ctx, &elasticloadbalancing.DescribeLoadBalancersInput{LoadBalancerNames: []string{name}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe classic load balancer")
+	}
+	if len(output.LoadBalancerDescriptions) == 0 {
+		return nil, fmt.Errorf("classic load balancer %s not found", name)
+	}
+	out.LoadBalancer = output.LoadBalancerDescriptions[0]
+
+	attributes, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancing.DescribeLoadBalancerAttributesOutput, error) {
+		return a.elb().DescribeLoadBalancerAttributes(ctx, &elasticloadbalancing.DescribeLoadBalancerAttributesInput{LoadBalancerName: aws.String(name)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe classic load balancer attributes")
+	}
+	if attributes.LoadBalancerAttributes != nil {
+		out.Settings = *attributes.LoadBalancerAttributes
+	}
+
+	tags, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancing.DescribeTagsOutput, error) {
+		return a.elb().DescribeTags(ctx, &elasticloadbalancing.DescribeTagsInput{LoadBalancerNames: []string{name}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe classic load balancer tags")
+	}
+	out.Tags = map[string]string{}
+	for _, description := range tags.TagDescriptions {
+		for _, tag := range description.Tags {
+			out.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache classic load balancer metadata", "name", name, "error", err)
+	}
+	return out, nil
+}
+
+// listClassicLoadBalancers returns the names of every classic load balancer of the
+// region.
+func (a *AWSProvider) listClassicLoadBalancers(ctx context.Context) ([]string, error) {
+	paginator := elasticloadbalancing.NewDescribeLoadBalancersPaginator(a.elb(), &elasticloadbalancing.DescribeLoadBalancersInput{
+		PageSize: aws.Int32(a.elbPageSize()),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *elasticloadbalancing.DescribeLoadBalancersOutput) []string {
+		var names []string
+		for _, lb := range page.LoadBalancerDescriptions {
+			names = append(names, aws.ToString(lb.LoadBalancerName))
+		}
+		return names
+	})
+}
+
+// elb returns the classic Elastic Load Balancing client of the provider.
+func (a *AWSProvider) elb() ELBAPI {
+	if a.ELB != nil {
+		return a.ELB
+	}
+	return elasticloadbalancing.NewFromConfig(a.Config)
+}
+
+// sortedJoin joins values with commas in sorted order, as the state holds sets such
+// as security groups sorted while the API returns them in any order.
+func sortedJoin(values []string) string {
+	return strings.Join(slices.Sorted(slices.Values(values)), ",")
+}
@@ -0,0 +1,296 @@
+package aws
+
+import (
+	"drift-watcher/pkg/services/provider"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// fields is the attribute table of the AWS provider: per resource type, the
+// attributes AttributeValue can read from live data, named after the Terraform schema,
+// the aliases they are also accepted under and the API field each is read from.
+// Attributes are added by adding a row here and reading it in the AttributeValue of
+// the resource type; the attributes validate and completion offer, and the table
+// printed by driftwatcher attributes, follow from it.
+var fields = map[string][]provider.Field{
+	"aws_instance": {
+		{Attribute: string(EC2AMIID), API: "DescribeInstances: ImageId"},
+		{Attribute: string(EC2INSTANCETYPE), API: "DescribeInstances: InstanceType"},
+		{Attribute: string(EC2INSTANCEID), Aliases: []string{"instance_id"}, API: "DescribeInstances: InstanceId"},
+		{Attribute: string(EC2KEYNAME), API: "DescribeInstances: KeyName"},
+		{Attribute: string(EC2AvailabilityZone), API: "DescribeInstances: Placement.AvailabilityZone"},
+		{Attribute: string(EC2TENANCY), API: "DescribeInstances: Placement.Tenancy"},
+		{Attribute: string(EC2CPUCORECOUNT), API: "DescribeInstances: CpuOptions.CoreCount"},
+		{Attribute: string(EC2CPUTHREADPERCORE), Aliases: []string{"cpu_thread_per_core"}, API: "DescribeInstances: CpuOptions.ThreadsPerCore"},
+		{Attribute: string(EC2EbsOptimzied), API: "DescribeInstances: EbsOptimized"},
+		{Attribute: string(EC2PlacementGroup), API: "DescribeInstances: Placement.GroupName"},
+		{Attribute: string(EC2Monitoring), API: "DescribeInstances: Monitoring.State"},
+		{Attribute: string(EC2Hibernation), API: "DescribeInstances: HibernationOptions.Configured"},
+		{Attribute: string(EC2CreditSpecification), API: "DescribeInstanceCreditSpecifications: InstanceCreditSpecifications[].CpuCredits"},
+		{Attribute: string(EC2CPUCredits), API: "DescribeInstanceCreditSpecifications: InstanceCreditSpecifications[].CpuCredits"},
+		{Attribute: string(EC2IAMInstanceProfile), API: "DescribeInstances: IamInstanceProfile.Arn"},
+		{Attribute: string(EC2SecurityGroupIDs), Aliases: []string{"security_group_ids"}, API: "DescribeInstances: SecurityGroups[].GroupId"},
+		{Attribute: string(EC2SUBNETID), API: "DescribeInstances: SubnetId"},
+		{Attribute: string(EC2AssociatePublicIPAddress), API: "DescribeInstances: NetworkInterfaces[].Association.PublicIp of the primary interface"},
+		{Attribute: string(EC2PrivateIP), API: "DescribeInstances: PrivateIpAddress"},
+		{Attribute: string(EC2PrivateDnsName), Aliases: []string{"private_dns_name"}, API: "DescribeInstances: PrivateDnsName"},
+		{Attribute: string(EC2PublicIP), API: "DescribeInstances: PublicIpAddress"},
+		{Attribute: string(EC2PublicDnsName), Aliases: []string{"public_dns_name"}, API: "DescribeInstances: PublicDnsName"},
+		{Attribute: string(EC2SourceDestCheck), API: "DescribeInstances: NetworkInterfaces[].SourceDestCheck of the primary interface"},
+		{Attribute: string(EC2PrimaryNetworkInterfaceID), API: "DescribeInstances: NetworkInterfaces[].NetworkInterfaceId of the primary interface"},
+		{Attribute: string(EC2SecondaryPrivateIPs), API: "DescribeInstances: NetworkInterfaces[].PrivateIpAddresses[].PrivateIpAddress of the primary interface"},
+		{Attribute: string(EC2NetworkInterface), API: "DescribeInstances: NetworkInterfaces[].Attachment"},
+		{Attribute: string(EC2NetworkInterfaceID), API: "DescribeInstances: NetworkInterfaces[].NetworkInterfaceId"},
+		{Attribute: string(EC2NetworkInterfaceDeviceIndex), API: "DescribeInstances: NetworkInterfaces[].Attachment.DeviceIndex"},
+		{Attribute: string(EC2NetworkInterfaceSourceDestCheck), API: "DescribeInstances: NetworkInterfaces[].SourceDestCheck"},
+		{Attribute: string(EC2NetworkInterfacePrivateIPs), API: "DescribeInstances: NetworkInterfaces[].PrivateIpAddresses[].PrivateIpAddress"},
+		{Attribute: string(EC2RootBlockDevice), API: "DescribeInstances: BlockDeviceMappings[].Ebs of the root device"},
+		{Attribute: string(EC2EBSBlockDevice), API: "DescribeInstances: BlockDeviceMappings[].Ebs"},
+		{Attribute: string(EC2EBSBlockDeviceName), API: "DescribeInstances: BlockDeviceMappings[].DeviceName"},
+		{Attribute: string(EC2EBSVolumeID), API: "DescribeInstances: BlockDeviceMappings[].Ebs.VolumeId"},
+		{Attribute: string(EC2EBSDeleteOnTermination), API: "DescribeInstances: BlockDeviceMappings[].Ebs.DeleteOnTermination"},
+		{Attribute: string(EC2LaunchTemplate), API: "DescribeInstances: Tags[aws:ec2launchtemplate:*], DescribeLaunchTemplates"},
+		{Attribute: string(EC2LaunchTemplateID), API: "DescribeInstances: Tags[aws:ec2launchtemplate:id]"},
+		{Attribute: string(EC2LaunchTemplateName), API: "DescribeLaunchTemplates: LaunchTemplates[].LaunchTemplateName"},
+		{Attribute: string(EC2LaunchTemplateVersion), API: "DescribeInstances: Tags[aws:ec2launchtemplate:version]"},
+		{Attribute: string(EC2MetadataOptions), API: "DescribeInstances: MetadataOptions"},
+		{Attribute: string(EC2UserData), API: "DescribeInstanceAttribute: UserData.Value"},
+		{Attribute: string(EC2UserDataBase64), API: "DescribeInstanceAttribute: UserData.Value"},
+		{Attribute: string(EC2InstanceState), API: "DescribeInstances: State.Name"},
+		{Attribute: "tags.*", API: "DescribeInstances: Tags"},
+	},
+	"aws_lb":               lbFields,
+	"aws_alb":              lbFields,
+	"aws_lb_listener":      listenerFields,
+	"aws_alb_listener":     listenerFields,
+	"aws_lb_target_group":  targetGroupFields,
+	"aws_alb_target_group": targetGroupFields,
+	"aws_elb": {
+		{Attribute: ELBName, API: "DescribeLoadBalancers: LoadBalancerName"},
+		{Attribute: ELBDNSName, API: "DescribeLoadBalancers: DNSName"},
+		{Attribute: ELBZoneID, API: "DescribeLoadBalancers: CanonicalHostedZoneNameID"},
+		{Attribute: ELBInternal, API: "DescribeLoadBalancers: Scheme"},
+		{Attribute: ELBSecurityGroups, API: "DescribeLoadBalancers: SecurityGroups"},
+		{Attribute: ELBSubnets, API: "DescribeLoadBalancers: Subnets"},
+		{Attribute: ELBAvailabilityZones, API: "DescribeLoadBalancers: AvailabilityZones"},
+		{Attribute: ELBInstances, API: "DescribeLoadBalancers: Instances[].InstanceId"},
+		{Attribute: ELBIdleTimeout, API: "DescribeLoadBalancerAttributes: ConnectionSettings.IdleTimeout"},
+		{Attribute: ELBCrossZone, API: "DescribeLoadBalancerAttributes: CrossZoneLoadBalancing.Enabled"},
+		{Attribute: ELBConnectionDraining, API: "DescribeLoadBalancerAttributes: ConnectionDraining.Enabled"},
+		{Attribute: ELBConnectionDrainingTimeout, API: "DescribeLoadBalancerAttributes: ConnectionDraining.Timeout"},
+		{Attribute: ELBListener, API: "DescribeLoadBalancers: ListenerDescriptions[].Listener"},
+		{Attribute: ELBListener + ".*", API: "DescribeLoadBalancers: ListenerDescriptions[].Listener"},
+		{Attribute: ELBHealthCheck, API: "DescribeLoadBalancers: HealthCheck"},
+		{Attribute: ELBHealthCheck + ".*", API: "DescribeLoadBalancers: HealthCheck"},
+		{Attribute: ELBAccessLogs, API: "DescribeLoadBalancerAttributes: AccessLog"},
+		{Attribute: ELBAccessLogs + ".*", API: "DescribeLoadBalancerAttributes: AccessLog"},
+		{Attribute: "tags.*", API: "DescribeTags: TagDescriptions[].Tags"},
+	},
+	"aws_cloudfront_distribution": {
+		{Attribute: CFArn, API: "GetDistribution: Distribution.ARN"},
+		{Attribute: CFDomainName, API: "GetDistribution: Distribution.DomainName"},
+		{Attribute: CFHostedZoneID, API: "none, the hosted zone " + cloudFrontHostedZoneID + " of every distribution"},
+		{Attribute: CFStatus, API: "GetDistribution: Distribution.Status"},
+		{Attribute: CFEnabled, API: "GetDistribution: DistributionConfig.Enabled"},
+		{Attribute: CFAliases, API: "GetDistribution: DistributionConfig.Aliases.Items"},
+		{Attribute: CFComment, API: "GetDistribution: DistributionConfig.Comment"},
+		{Attribute: CFDefaultRootObject, API: "GetDistribution: DistributionConfig.DefaultRootObject"},
+		{Attribute: CFHTTPVersion, API: "GetDistribution: DistributionConfig.HttpVersion"},
+		{Attribute: CFIPv6Enabled, API: "GetDistribution: DistributionConfig.IsIPV6Enabled"},
+		{Attribute: CFPriceClass, API: "GetDistribution: DistributionConfig.PriceClass"},
+		{Attribute: CFWebACLID, API: "GetDistribution: DistributionConfig.WebACLId"},
+		{Attribute: CFOrigin, API: "GetDistribution: DistributionConfig.Origins.Items"},
+		{Attribute: CFOrigin + ".*", API: "GetDistribution: DistributionConfig.Origins.Items"},
+		{Attribute: CFDefaultCacheBehavior, API: "GetDistribution: DistributionConfig.DefaultCacheBehavior"},
+		{Attribute: CFDefaultCacheBehavior + ".*", API: "GetDistribution: DistributionConfig.DefaultCacheBehavior"},
+		{Attribute: CFViewerCertificate, API: "GetDistribution: DistributionConfig.ViewerCertificate"},
+		{Attribute: CFViewerCertificate + ".*", API: "GetDistribution: DistributionConfig.ViewerCertificate"},
+		{Attribute: "tags.*", API: "ListTagsForResource: Tags.Items"},
+	},
+	"aws_eks_cluster": {
+		{Attribute: EKSName, API: "DescribeCluster: Name"},
+		{Attribute: EKSArn, API: "DescribeCluster: Arn"},
+		{Attribute: EKSVersion, API: "DescribeCluster: Version"},
+		{Attribute: EKSPlatformVersion, API: "DescribeCluster: PlatformVersion"},
+		{Attribute: EKSRoleArn, API: "DescribeCluster: RoleArn"},
+		{Attribute: EKSEndpoint, API: "DescribeCluster: Endpoint"},
+		{Attribute: EKSStatus, API: "DescribeCluster: Status"},
+		{Attribute: EKSEnabledClusterLogTypes, API: "DescribeCluster: Logging.ClusterLogging[].Types of the enabled setups"},
+		{Attribute: EKSVPCConfig, API: "DescribeCluster: ResourcesVpcConfig"},
+		{Attribute: EKSVPCConfig + ".*", API: "DescribeCluster: ResourcesVpcConfig"},
+		{Attribute: EKSKubernetesNetworkConfig, API: "DescribeCluster: KubernetesNetworkConfig"},
+		{Attribute: EKSKubernetesNetworkConfig + ".*", API: "DescribeCluster: KubernetesNetworkConfig"},
+		{Attribute: "tags.*", API: "DescribeCluster: Tags"},
+	},
+	"aws_eks_node_group": {
+		{Attribute: NodeGroupClusterName, API: "DescribeNodegroup: ClusterName"},
+		{Attribute: NodeGroupName, API: "DescribeNodegroup: NodegroupName"},
+		{Attribute: NodeGroupArn, API: "DescribeNodegroup: NodegroupArn"},
+		{Attribute: NodeGroupVersion, API: "DescribeNodegroup: Version"},
+		{Attribute: NodeGroupReleaseVersion, API: "DescribeNodegroup: ReleaseVersion"},
+		{Attribute: NodeGroupAMIType, API: "DescribeNodegroup: AmiType"},
+		{Attribute: NodeGroupCapacityType, API: "DescribeNodegroup: CapacityType"},
+		{Attribute: NodeGroupDiskSize, API: "DescribeNodegroup: DiskSize"},
+		{Attribute: NodeGroupInstanceTypes, API: "DescribeNodegroup: InstanceTypes"},
+		{Attribute: NodeGroupNodeRoleArn, API: "DescribeNodegroup: NodeRole"},
+		{Attribute: NodeGroupSubnetIDs, API: "DescribeNodegroup: Subnets"},
+		{Attribute: NodeGroupStatus, API: "DescribeNodegroup: Status"},
+		{Attribute: NodeGroupScalingConfig, API: "DescribeNodegroup: ScalingConfig"},
+		{Attribute: NodeGroupScalingConfig + ".*", API: "DescribeNodegroup: ScalingConfig"},
+		{Attribute: NodeGroupUpdateConfig, API: "DescribeNodegroup: UpdateConfig"},
+		{Attribute: NodeGroupUpdateConfig + ".*", API: "DescribeNodegroup: UpdateConfig"},
+		{Attribute: NodeGroupLaunchTemplate, API: "DescribeNodegroup: LaunchTemplate"},
+		{Attribute: NodeGroupLaunchTemplate + ".*", API: "DescribeNodegroup: LaunchTemplate"},
+		{Attribute: NodeGroupTaint, API: "DescribeNodegroup: Taints"},
+		{Attribute: NodeGroupTaint + ".*", API: "DescribeNodegroup: Taints"},
+		{Attribute: "labels.*", API: "DescribeNodegroup: Labels"},
+		{Attribute: "tags.*", API: "DescribeNodegroup: Tags"},
+	},
+	"aws_sqs_queue": slices.Concat(
+		[]provider.Field{
+			{Attribute: SQSName, API: "none, the last segment of the queue URL"},
+			{Attribute: SQSURL, API: "none, the queue URL the resource is looked up by"},
+		},
+		settingFields("GetQueueAttributes: Attributes", sqsQueueAttributes),
+		[]provider.Field{{Attribute: "tags.*", API: "ListQueueTags: Tags"}},
+	),
+	"aws_sns_topic": slices.Concat(
+		[]provider.Field{{Attribute: SNSName, API: "GetTopicAttributes: Attributes[TopicArn], its last segment"}},
+		settingFields("GetTopicAttributes: Attributes", snsTopicAttributes),
+		[]provider.Field{{Attribute: "tags.*", API: "ListTagsForResource: Tags"}},
+	),
+	"aws_sns_topic_subscription": settingFields("GetSubscriptionAttributes: Attributes", snsSubscriptionAttributes),
+	"aws_security_group": {
+		{Attribute: SecurityGroupName, API: "DescribeSecurityGroups: GroupName"},
+		{Attribute: SecurityGroupArn, API: "DescribeSecurityGroups: SecurityGroupArn"},
+		{Attribute: SecurityGroupDescription, API: "DescribeSecurityGroups: Description"},
+		{Attribute: SecurityGroupVPCID, API: "DescribeSecurityGroups: VpcId"},
+		{Attribute: SecurityGroupOwnerID, API: "DescribeSecurityGroups: OwnerId"},
+		{Attribute: SecurityGroupIngress, API: "DescribeSecurityGroupRules: SecurityGroupRules[] where not IsEgress"},
+		{Attribute: SecurityGroupIngress + ".*", API: "DescribeSecurityGroupRules: SecurityGroupRules[] where not IsEgress"},
+		{Attribute: SecurityGroupEgress, API: "DescribeSecurityGroupRules: SecurityGroupRules[] where IsEgress"},
+		{Attribute: SecurityGroupEgress + ".*", API: "DescribeSecurityGroupRules: SecurityGroupRules[] where IsEgress"},
+		{Attribute: "tags.*", API: "DescribeSecurityGroups: Tags"},
+	},
+}
+
+// lbFields are the attributes of aws_lb and its aws_alb alias.
+var lbFields = slices.Concat(
+	[]provider.Field{
+		{Attribute: LBName, API: "DescribeLoadBalancers: LoadBalancerName"},
+		{Attribute: LBArn, API: "DescribeLoadBalancers: LoadBalancerArn"},
+		{Attribute: LBDNSName, API: "DescribeLoadBalancers: DNSName"},
+		{Attribute: LBZoneID, API: "DescribeLoadBalancers: CanonicalHostedZoneId"},
+		{Attribute: LBInternal, API: "DescribeLoadBalancers: Scheme"},
+		{Attribute: LBType, API: "DescribeLoadBalancers: Type"},
+		{Attribute: LBIPAddressType, API: "DescribeLoadBalancers: IpAddressType"},
+		{Attribute: LBVPCID, API: "DescribeLoadBalancers: VpcId"},
+		{Attribute: LBSecurityGroups, API: "DescribeLoadBalancers: SecurityGroups"},
+		{Attribute: LBSubnets, API: "DescribeLoadBalancers: AvailabilityZones[].SubnetId"},
+	},
+	settingFields("DescribeLoadBalancerAttributes: Attributes", lbAttributeKeys),
+	[]provider.Field{
+		{Attribute: LBAccessLogs, API: "DescribeLoadBalancerAttributes: Attributes[access_logs.s3.*]"},
+		{Attribute: LBAccessLogs + ".*", API: "DescribeLoadBalancerAttributes: Attributes[access_logs.s3.*]"},
+		{Attribute: "tags.*", API: "DescribeTags: TagDescriptions[].Tags"},
+	},
+)
+
+// listenerFields are the attributes of aws_lb_listener and its aws_alb_listener alias.
+var listenerFields = []provider.Field{
+	{Attribute: ListenerArn, API: "DescribeListeners: ListenerArn"},
+	{Attribute: ListenerLoadBalancerArn, API: "DescribeListeners: LoadBalancerArn"},
+	{Attribute: ListenerPort, API: "DescribeListeners: Port"},
+	{Attribute: ListenerProtocol, API: "DescribeListeners: Protocol"},
+	{Attribute: ListenerSSLPolicy, API: "DescribeListeners: SslPolicy"},
+	{Attribute: ListenerCertificateArn, API: "DescribeListeners: Certificates[].CertificateArn of the default certificate"},
+	{Attribute: ListenerAlpnPolicy, API: "DescribeListeners: AlpnPolicy[0]"},
+	{Attribute: ListenerDefaultAction, API: "DescribeListeners: DefaultActions"},
+	{Attribute: ListenerDefaultAction + ".*", API: "DescribeListeners: DefaultActions"},
+	{Attribute: "tags.*", API: "DescribeTags: TagDescriptions[].Tags"},
+}
+
+// targetGroupFields are the attributes of aws_lb_target_group and its
+// aws_alb_target_group alias.
+var targetGroupFields = []provider.Field{
+	{Attribute: TGName, API: "DescribeTargetGroups: TargetGroupName"},
+	{Attribute: TGArn, API: "DescribeTargetGroups: TargetGroupArn"},
+	{Attribute: TGPort, API: "DescribeTargetGroups: Port"},
+	{Attribute: TGProtocol, API: "DescribeTargetGroups: Protocol"},
+	{Attribute: TGProtocolVersion, API: "DescribeTargetGroups: ProtocolVersion"},
+	{Attribute: TGTargetType, API: "DescribeTargetGroups: TargetType"},
+	{Attribute: TGVPCID, API: "DescribeTargetGroups: VpcId"},
+	{Attribute: TGIPAddressType, API: "DescribeTargetGroups: IpAddressType"},
+	{Attribute: TGDeregistrationDelay, API: "DescribeTargetGroupAttributes: Attributes[" + tgAttributeDeregister + "]"},
+	{Attribute: TGSlowStart, API: "DescribeTargetGroupAttributes: Attributes[" + tgAttributeSlowStart + "]"},
+	{Attribute: TGLoadBalancingAlgo, API: "DescribeTargetGroupAttributes: Attributes[" + tgAttributeAlgorithm + "]"},
+	{Attribute: TGHealthCheck, API: "DescribeTargetGroups: HealthCheck*"},
+	{Attribute: TGHealthCheck + ".*", API: "DescribeTargetGroups: HealthCheck*"},
+	{Attribute: TGStickiness, API: "DescribeTargetGroupAttributes: Attributes[stickiness.*]"},
+	{Attribute: TGStickiness + ".*", API: "DescribeTargetGroupAttributes: Attributes[stickiness.*]"},
+	{Attribute: "tags.*", API: "DescribeTags: TagDescriptions[].Tags"},
+}
+
+// settingFields returns the fields of the attributes read from the key-value
+// attributes of an API response, such as those of GetQueueAttributes, sorted by
+// attribute.
+func settingFields[K ~string](api string, keys map[string]K) []provider.Field {
+	var settings []provider.Field
+	for _, attribute := range slices.Sorted(maps.Keys(keys)) {
+		settings = append(settings, provider.Field{Attribute: attribute, API: api + "[" + string(keys[attribute]) + "]"})
+	}
+	return settings
+}
+
+// supportedAttributes lists, per resource type, the attributes AttributeValue can
+// read from live data and, after them, their aliases. Entries are glob patterns so
+// that tags.* covers every tag.
+var supportedAttributes = func() map[string][]string {
+	supported := map[string][]string{}
+	for resourceType, resourceFields := range fields {
+		attributes := fieldAttributes(resourceFields)
+		for _, field := range resourceFields {
+			attributes = append(attributes, field.Aliases...)
+		}
+		supported[resourceType] = attributes
+	}
+	return supported
+}()
+
+// fieldAttributes returns the attributes of resourceFields, without their aliases.
+func fieldAttributes(resourceFields []provider.Field) []string {
+	attributes := make([]string, 0, len(resourceFields))
+	for _, field := range resourceFields {
+		attributes = append(attributes, field.Attribute)
+	}
+	return attributes
+}
+
+// canonicalAttribute replaces the alias an attribute path expression starts with, if
+// any, with the attribute it is an alias of, e.g. security_group_ids[0] of an
+// aws_instance with vpc_security_group_ids[0].
+func canonicalAttribute(resourceType string, attribute string) string {
+	for _, field := range fields[resourceType] {
+		for _, alias := range field.Aliases {
+			rest, ok := strings.CutPrefix(attribute, alias)
+			if ok && (rest == "" || rest[0] == '.' || rest[0] == '[') {
+				return field.Attribute + rest
+			}
+		}
+	}
+	return attribute
+}
+
+// CanonicalAttribute returns attribute with the alias it starts with, if any,
+// replaced by the Terraform name of the attribute, which the state holds it under.
+func (a *AWSProvider) CanonicalAttribute(resourceType string, attribute string) string {
+	return canonicalAttribute(resourceType, attribute)
+}
+
+// Fields returns the attribute table of resourceType: the attributes that can be
+// checked, their aliases and the API fields they are read from, or nil if the type is
+// not supported. Like ResourceTypes, it needs no credentials.
+func Fields(resourceType string) []provider.Field {
+	return slices.Clone(fields[resourceType])
+}
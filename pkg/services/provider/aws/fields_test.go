@@ -0,0 +1,73 @@
+package aws_test
+
+import (
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields(t *testing.T) {
+	for _, resourceType := range awsProvider.ResourceTypes() {
+		fields := awsProvider.Fields(resourceType)
+		require.NotEmpty(t, fields, resourceType)
+		seen := map[string]bool{}
+		for _, field := range fields {
+			assert.NotEmpty(t, field.API, "%s %s", resourceType, field.Attribute)
+			for _, name := range append([]string{field.Attribute}, field.Aliases...) {
+				assert.False(t, seen[name], "%s %s listed twice", resourceType, name)
+				seen[name] = true
+			}
+		}
+	}
+	assert.Nil(t, awsProvider.Fields("aws_s3_bucket"))
+}
+
+func TestAWSProvider_CanonicalAttribute(t *testing.T) {
+	p := &awsProvider.AWSProvider{}
+
+	for attribute, canonical := range map[string]string{
+		"security_group_ids":       "vpc_security_group_ids",
+		"security_group_ids[0]":    "vpc_security_group_ids[0]",
+		"cpu_thread_per_core":      "cpu_threads_per_core",
+		"instance_id":              "id",
+		"vpc_security_group_ids":   "vpc_security_group_ids",
+		"security_group_ids_extra": "security_group_ids_extra",
+		"tags.instance_id":         "tags.instance_id",
+	} {
+		assert.Equal(t, canonical, p.CanonicalAttribute("aws_instance", attribute), attribute)
+	}
+	assert.Equal(t, "instance_id", p.CanonicalAttribute("aws_sqs_queue", "instance_id"))
+}
+
+func TestEC2InfraInstance_Aliases(t *testing.T) {
+	e := awsProvider.EC2InfraInstance{Instance: types.Instance{
+		InstanceId:     aws.String("i-1"),
+		PrivateDnsName: aws.String("ip-10-0-0-10.ec2.internal"),
+		PublicDnsName:  aws.String("ec2-54-1-2-3.compute-1.amazonaws.com"),
+		CpuOptions:     &types.CpuOptions{ThreadsPerCore: aws.Int32(2)},
+		SecurityGroups: []types.GroupIdentifier{{GroupId: aws.String("sg-1")}, {GroupId: aws.String("sg-2")}},
+	}}
+
+	for _, field := range awsProvider.Fields("aws_instance") {
+		for _, alias := range field.Aliases {
+			expected, err := e.AttributeValue(field.Attribute)
+			require.NoError(t, err, field.Attribute)
+			actual, err := e.AttributeValue(alias)
+			require.NoError(t, err, alias)
+			assert.Equal(t, expected, actual, alias)
+		}
+	}
+
+	value, err := e.AttributeValue("security_group_ids[1]")
+	require.NoError(t, err)
+	assert.Equal(t, "sg-2", value)
+
+	attributes, err := e.Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, "sg-1,sg-2", attributes["vpc_security_group_ids"])
+	assert.NotContains(t, attributes, "security_group_ids", "aliases are not listed")
+}
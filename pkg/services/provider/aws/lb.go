@@ -0,0 +1,619 @@
+package aws
+
+import (
+	"cmp"
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ELBv2API is the part of the Elastic Load Balancing v2 API used by the provider, for
+// application, network and gateway load balancers, their listeners and their target
+// groups. It is implemented by *elasticloadbalancingv2.Client, and by
+// awstest.FakeELBv2 for tests that do not reach AWS.
+type ELBv2API interface {
+	DescribeLoadBalancers(ctx context.Context, params *elasticloadbalancingv2.DescribeLoadBalancersInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeLoadBalancersOutput, error)
+	DescribeLoadBalancerAttributes(ctx context.Context, params *elasticloadbalancingv2.DescribeLoadBalancerAttributesInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeLoadBalancerAttributesOutput, error)
+	DescribeListeners(ctx context.Context, params *elasticloadbalancingv2.DescribeListenersInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeListenersOutput, error)
+	DescribeTargetGroups(ctx context.Context, params *elasticloadbalancingv2.DescribeTargetGroupsInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error)
+	DescribeTargetGroupAttributes(ctx context.Context, params *elasticloadbalancingv2.DescribeTargetGroupAttributesInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTargetGroupAttributesOutput, error)
+	DescribeTags(ctx context.Context, params *elasticloadbalancingv2.DescribeTagsInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTagsOutput, error)
+}
+
+// Attributes of aws_lb load balancers, named after the Terraform schema. aws_alb is
+// an alias of aws_lb and has the same attributes.
+const (
+	LBName                    = "name"
+	LBArn                     = "arn"
+	LBDNSName                 = "dns_name"
+	LBZoneID                  = "zone_id"
+	LBInternal                = "internal"
+	LBType                    = "load_balancer_type"
+	LBIPAddressType           = "ip_address_type"
+	LBVPCID                   = "vpc_id"
+	LBSecurityGroups          = "security_groups"
+	LBSubnets                 = "subnets"
+	LBIdleTimeout             = "idle_timeout"
+	LBDeletionProtection      = "enable_deletion_protection"
+	LBCrossZone               = "enable_cross_zone_load_balancing"
+	LBHTTP2                   = "enable_http2"
+	LBDropInvalidHeaderFields = "drop_invalid_header_fields"
+	// LBAccessLogs is the access_logs block, holding bucket, prefix and enabled.
+	LBAccessLogs = "access_logs"
+)
+
+// lbAttributeKeys maps the attributes of aws_lb read from the load balancer
+// attributes to their API key.
+var lbAttributeKeys = map[string]string{
+	LBIdleTimeout:             "idle_timeout.timeout_seconds",
+	LBDeletionProtection:      "deletion_protection.enabled",
+	LBCrossZone:               "load_balancing.cross_zone.enabled",
+	LBHTTP2:                   "routing.http2.enabled",
+	LBDropInvalidHeaderFields: "routing.http.drop_invalid_header_fields.enabled",
+}
+
+// Attributes of aws_lb_listener listeners, named after the Terraform schema.
+const (
+	ListenerArn             = "arn"
+	ListenerLoadBalancerArn = "load_balancer_arn"
+	ListenerPort            = "port"
+	ListenerProtocol        = "protocol"
+	ListenerSSLPolicy       = "ssl_policy"
+	ListenerCertificateArn  = "certificate_arn"
+	ListenerAlpnPolicy      = "alpn_policy"
+	// ListenerDefaultAction is the default_action blocks, holding type,
+	// target_group_arn and order. Compare default_action.type or
+	// default_action.target_group_arn, as the state holds more keys than are read.
+	ListenerDefaultAction = "default_action"
+)
+
+// Attributes of aws_lb_target_group target groups, named after the Terraform schema.
+const (
+	TGName                  = "name"
+	TGArn                   = "arn"
+	TGPort                  = "port"
+	TGProtocol              = "protocol"
+	TGProtocolVersion       = "protocol_version"
+	TGTargetType            = "target_type"
+	TGVPCID                 = "vpc_id"
+	TGIPAddressType         = "ip_address_type"
+	TGDeregistrationDelay   = "deregistration_delay"
+	TGSlowStart             = "slow_start"
+	TGLoadBalancingAlgo     = "load_balancing_algorithm_type"
+	TGHealthCheck           = "health_check"
+	TGStickiness            = "stickiness"
+	tgAttributeDeregister   = "deregistration_delay.timeout_seconds"
+	tgAttributeSlowStart    = "slow_start.duration_seconds"
+	tgAttributeAlgorithm    = "load_balancing.algorithm.type"
+	tgAttributeSticky       = "stickiness.enabled"
+	tgAttributeStickyType   = "stickiness.type"
+	tgAttributeStickyTTL    = "stickiness.lb_cookie.duration_seconds"
+	tgAttributeStickyAppTTL = "stickiness.app_cookie.duration_seconds"
+)
+
+// LoadBalancer is the live state of an aws_lb load balancer.
+type LoadBalancer struct {
+	// Type is the Terraform resource type the load balancer was looked up for,
+	// either aws_lb or aws_alb.
+	Type         string
+	LoadBalancer elbv2types.LoadBalancer
+	// Settings holds the load balancer attributes by API key, such as
+	// idle_timeout.timeout_seconds.
+	Settings map[string]string
+	Tags     map[string]string
+}
+
+func (l *LoadBalancer) ResourceType() string {
+	return l.Type
+}
+
+// AttributeValue retrieves the string value of a load balancer attribute. Security
+// groups and subnets are sorted and joined with commas, access_logs is read as a JSON
+// block and tags with tags.KEY. Attributes a load balancer type does not have, such
+// as the idle timeout of a network load balancer, are returned as an empty string.
+func (l *LoadBalancer) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, l.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (l *LoadBalancer) attributeValue(attribute string) (string, error) {
+	lb := l.LoadBalancer
+	if key, ok := lbAttributeKeys[attribute]; ok {
+		return l.Settings[key], nil
+	}
+	switch attribute {
+	case LBName:
+		return aws.ToString(lb.LoadBalancerName), nil
+	case LBArn:
+		return aws.ToString(lb.LoadBalancerArn), nil
+	case LBDNSName:
+		return aws.ToString(lb.DNSName), nil
+	case LBZoneID:
+		return aws.ToString(lb.CanonicalHostedZoneId), nil
+	case LBInternal:
+		return strconv.FormatBool(lb.Scheme == elbv2types.LoadBalancerSchemeEnumInternal), nil
+	case LBType:
+		return string(lb.Type), nil
+	case LBIPAddressType:
+		return string(lb.IpAddressType), nil
+	case LBVPCID:
+		return aws.ToString(lb.VpcId), nil
+	case LBSecurityGroups:
+		return sortedJoin(lb.SecurityGroups), nil
+	case LBSubnets:
+		var subnets []string
+		for _, zone := range lb.AvailabilityZones {
+			subnets = append(subnets, aws.ToString(zone.SubnetId))
+		}
+		return sortedJoin(subnets), nil
+	case LBAccessLogs:
+		// the state holds the block as a list with a single element
+		return marshalBlock(LBAccessLogs, map[string]any{
+			"bucket":  l.Settings["access_logs.s3.bucket"],
+			"prefix":  l.Settings["access_logs.s3.prefix"],
+			"enabled": l.Settings["access_logs.s3.enabled"] == "true",
+		})
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return l.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for load balancers or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the load balancer and one
+// tags.KEY attribute per tag.
+func (l *LoadBalancer) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields["aws_lb"]), l.AttributeValue, l.Tags)
+}
+
+// Listener is the live state of an aws_lb_listener listener.
+type Listener struct {
+	// Type is the Terraform resource type the listener was looked up for, either
+	// aws_lb_listener or aws_alb_listener.
+	Type     string
+	Listener elbv2types.Listener
+	Tags     map[string]string
+}
+
+func (l *Listener) ResourceType() string {
+	return l.Type
+}
+
+// AttributeValue retrieves the string value of a listener attribute. The
+// certificate_arn is the default certificate of the listener, and default_action
+// is read as a JSON list of blocks in action order, so default_action.type yields
+// the type of every action.
+func (l *Listener) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, l.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (l *Listener) attributeValue(attribute string) (string, error) {
+	listener := l.Listener
+	switch attribute {
+	case ListenerArn:
+		return aws.ToString(listener.ListenerArn), nil
+	case ListenerLoadBalancerArn:
+		return aws.ToString(listener.LoadBalancerArn), nil
+	case ListenerPort:
+		if listener.Port == nil {
+			return "", nil
+		}
+		return strconv.Itoa(int(*listener.Port)), nil
+	case ListenerProtocol:
+		return string(listener.Protocol), nil
+	case ListenerSSLPolicy:
+		return aws.ToString(listener.SslPolicy), nil
+	case ListenerCertificateArn:
+		for _, certificate := range listener.Certificates {
+			if certificate.IsDefault == nil || aws.ToBool(certificate.IsDefault) {
+				return aws.ToString(certificate.CertificateArn), nil
+			}
+		}
+		return "", nil
+	case ListenerAlpnPolicy:
+		if len(listener.AlpnPolicy) == 0 {
+			return "", nil
+		}
+		return listener.AlpnPolicy[0], nil
+	case ListenerDefaultAction:
+		actions := slices.Clone(listener.DefaultActions)
+		slices.SortStableFunc(actions, func(a, b elbv2types.Action) int {
+			return int(aws.ToInt32(a.Order)) - int(aws.ToInt32(b.Order))
+		})
+		blocks := make([]map[string]any, 0, len(actions))
+		for _, action := range actions {
+			blocks = append(blocks, map[string]any{
+				"type":             string(action.Type),
+				"target_group_arn": aws.ToString(action.TargetGroupArn),
+				"order":            aws.ToInt32(action.Order),
+			})
+		}
+		return marshalBlock(ListenerDefaultAction, blocks...)
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return l.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for load balancer listeners or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the listener and one tags.KEY
+// attribute per tag.
+func (l *Listener) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields["aws_lb_listener"]), l.AttributeValue, l.Tags)
+}
+
+// TargetGroup is the live state of an aws_lb_target_group target group.
+type TargetGroup struct {
+	// Type is the Terraform resource type the target group was looked up for,
+	// either aws_lb_target_group or aws_alb_target_group.
+	Type        string
+	TargetGroup elbv2types.TargetGroup
+	// Settings holds the target group attributes by API key, such as
+	// deregistration_delay.timeout_seconds.
+	Settings map[string]string
+	Tags     map[string]string
+}
+
+func (t *TargetGroup) ResourceType() string {
+	return t.Type
+}
+
+// AttributeValue retrieves the string value of a target group attribute. The
+// health_check and stickiness blocks are read as JSON, so health_check.path or
+// stickiness.enabled reach into them, and tags with tags.KEY.
+func (t *TargetGroup) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, t.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (t *TargetGroup) attributeValue(attribute string) (string, error) {
+	group := t.TargetGroup
+	switch attribute {
+	case TGName:
+		return aws.ToString(group.TargetGroupName), nil
+	case TGArn:
+		return aws.ToString(group.TargetGroupArn), nil
+	case TGPort:
+		if group.Port == nil {
+			return "", nil
+		}
+		return strconv.Itoa(int(*group.Port)), nil
+	case TGProtocol:
+		return string(group.Protocol), nil
+	case TGProtocolVersion:
+		return aws.ToString(group.ProtocolVersion), nil
+	case TGTargetType:
+		return string(group.TargetType), nil
+	case TGVPCID:
+		return aws.ToString(group.VpcId), nil
+	case TGIPAddressType:
+		return string(group.IpAddressType), nil
+	case TGDeregistrationDelay:
+		return t.Settings[tgAttributeDeregister], nil
+	case TGSlowStart:
+		return t.Settings[tgAttributeSlowStart], nil
+	case TGLoadBalancingAlgo:
+		return t.Settings[tgAttributeAlgorithm], nil
+	case TGHealthCheck:
+		matcher := ""
+		if group.Matcher != nil {
+			matcher = cmp.Or(aws.ToString(group.Matcher.HttpCode), aws.ToString(group.Matcher.GrpcCode))
+		}
+		return marshalBlock(TGHealthCheck, map[string]any{
+			"enabled":             aws.ToBool(group.HealthCheckEnabled),
+			"healthy_threshold":   aws.ToInt32(group.HealthyThresholdCount),
+			"unhealthy_threshold": aws.ToInt32(group.UnhealthyThresholdCount),
+			"interval":            aws.ToInt32(group.HealthCheckIntervalSeconds),
+			"timeout":             aws.ToInt32(group.HealthCheckTimeoutSeconds),
+			"path":                aws.ToString(group.HealthCheckPath),
+			"port":                aws.ToString(group.HealthCheckPort),
+			"protocol":            string(group.HealthCheckProtocol),
+			"matcher":             matcher,
+		})
+	case TGStickiness:
+		if _, ok := t.Settings[tgAttributeSticky]; !ok {
+			return "", nil
+		}
+		// the cookie duration is held by the attribute of the stickiness type
+		duration := t.Settings[tgAttributeStickyTTL]
+		if t.Settings[tgAttributeStickyType] == "app_cookie" {
+			duration = t.Settings[tgAttributeStickyAppTTL]
+		}
+		seconds, _ := strconv.Atoi(duration)
+		return marshalBlock(TGStickiness, map[string]any{
+			"enabled":         t.Settings[tgAttributeSticky] == "true",
+			"type":            t.Settings[tgAttributeStickyType],
+			"cookie_duration": seconds,
+		})
+	}
+	if key, ok := strings.CutPrefix(attribute, "tags."); ok {
+		return t.Tags[key], nil
+	}
+	return "", fmt.Errorf("'%s' attribute is not supported for target groups or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every supported attribute of the target group and one
+// tags.KEY attribute per tag.
+func (t *TargetGroup) Attributes() (map[string]string, error) {
+	return readAttributes(fieldAttributes(fields["aws_lb_target_group"]), t.AttributeValue, t.Tags)
+}
+
+// HandleLoadBalancerMetadata retrieves the load balancer with the given ARN, its
+// attributes and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The Terraform resource type, aws_lb or aws_alb
+//   - arn: The ARN of the load balancer, the id of the resource in the state
+//
+// Returns:
+//   - *LoadBalancer: The live load balancer data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleLoadBalancerMetadata(ctx context.Context, resourceType string, arn string) (*LoadBalancer, error) {
+	ctx, span := telemetry.StartSpan(ctx, "ELBv2.DescribeLoadBalancers", attribute.String("aws.elb.arn", arn))
+	defer span.End()
+
+	out := &LoadBalancer{}
+	cacheKey := a.cacheKey("aws_lb", arn)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		out.Type = resourceType
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeLoadBalancersOutput, error) {
+		return a.elbv2().DescribeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{LoadBalancerArns: []string{arn}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe load balancer")
+	}
+	if len(output.LoadBalancers) == 0 {
+		return nil, fmt.Errorf("load balancer %s not found", arn)
+	}
+	out.LoadBalancer = output.LoadBalancers[0]
+
+	attributes, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeLoadBalancerAttributesOutput, error) {
+		return a.elbv2().DescribeLoadBalancerAttributes(ctx, &elasticloadbalancingv2.DescribeLoadBalancerAttributesInput{LoadBalancerArn: aws.String(arn)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe load balancer attributes")
+	}
+	out.Settings = map[string]string{}
+	for _, attribute := range attributes.Attributes {
+		out.Settings[aws.ToString(attribute.Key)] = aws.ToString(attribute.Value)
+	}
+
+	if out.Tags, err = a.elbv2Tags(ctx, arn); err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache load balancer metadata", "arn", arn, "error", err)
+	}
+	out.Type = resourceType
+	return out, nil
+}
+
+// HandleListenerMetadata retrieves the listener with the given ARN and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The Terraform resource type, aws_lb_listener or aws_alb_listener
+//   - arn: The ARN of the listener, the id of the resource in the state
+//
+// Returns:
+//   - *Listener: The live listener data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleListenerMetadata(ctx context.Context, resourceType string, arn string) (*Listener, error) {
+	ctx, span := telemetry.StartSpan(ctx, "ELBv2.DescribeListeners", attribute.String("aws.elb.arn", arn))
+	defer span.End()
+
+	out := &Listener{}
+	cacheKey := a.cacheKey("aws_lb_listener", arn)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		out.Type = resourceType
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeListenersOutput, error) {
+		return a.elbv2().DescribeListeners(ctx, &elasticloadbalancingv2.DescribeListenersInput{ListenerArns: []string{arn}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe load balancer listener")
+	}
+	if len(output.Listeners) == 0 {
+		return nil, fmt.Errorf("load balancer listener %s not found", arn)
+	}
+	out.Listener = output.Listeners[0]
+
+	if out.Tags, err = a.elbv2Tags(ctx, arn); err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache load balancer listener metadata", "arn", arn, "error", err)
+	}
+	out.Type = resourceType
+	return out, nil
+}
+
+// HandleTargetGroupMetadata retrieves the target group with the given ARN, its
+// attributes and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The Terraform resource type, aws_lb_target_group or aws_alb_target_group
+//   - arn: The ARN of the target group, the id of the resource in the state
+//
+// Returns:
+//   - *TargetGroup: The live target group data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleTargetGroupMetadata(ctx context.Context, resourceType string, arn string) (*TargetGroup, error) {
+	ctx, span := telemetry.StartSpan(ctx, "ELBv2.DescribeTargetGroups", attribute.String("aws.elb.arn", arn))
+	defer span.End()
+
+	out := &TargetGroup{}
+	cacheKey := a.cacheKey("aws_lb_target_group", arn)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		out.Type = resourceType
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error) {
+		return a.elbv2().DescribeTargetGroups(ctx, &elasticloadbalancingv2.DescribeTargetGroupsInput{TargetGroupArns: []string{arn}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe target group")
+	}
+	if len(output.TargetGroups) == 0 {
+		return nil, fmt.Errorf("target group %s not found", arn)
+	}
+	out.TargetGroup = output.TargetGroups[0]
+
+	attributes, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeTargetGroupAttributesOutput, error) {
+		return a.elbv2().DescribeTargetGroupAttributes(ctx, &elasticloadbalancingv2.DescribeTargetGroupAttributesInput{TargetGroupArn: aws.String(arn)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe target group attributes")
+	}
+	out.Settings = map[string]string{}
+	for _, attribute := range attributes.Attributes {
+		out.Settings[aws.ToString(attribute.Key)] = aws.ToString(attribute.Value)
+	}
+
+	if out.Tags, err = a.elbv2Tags(ctx, arn); err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache target group metadata", "arn", arn, "error", err)
+	}
+	out.Type = resourceType
+	return out, nil
+}
+
+// elbv2Tags returns the tags of the load balancer, listener or target group with the
+// given ARN.
+func (a *AWSProvider) elbv2Tags(ctx context.Context, arn string) (map[string]string, error) {
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*elasticloadbalancingv2.DescribeTagsOutput, error) {
+		return a.elbv2().DescribeTags(ctx, &elasticloadbalancingv2.DescribeTagsInput{ResourceArns: []string{arn}})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to describe load balancer tags")
+	}
+	tags := map[string]string{}
+	for _, description := range output.TagDescriptions {
+		for _, tag := range description.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	return tags, nil
+}
+
+// listLoadBalancers returns the ARNs of every application, network and gateway load
+// balancer of the region.
+func (a *AWSProvider) listLoadBalancers(ctx context.Context) ([]string, error) {
+	paginator := elasticloadbalancingv2.NewDescribeLoadBalancersPaginator(a.elbv2(), &elasticloadbalancingv2.DescribeLoadBalancersInput{
+		PageSize: aws.Int32(a.elbPageSize()),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *elasticloadbalancingv2.DescribeLoadBalancersOutput) []string {
+		var arns []string
+		for _, lb := range page.LoadBalancers {
+			arns = append(arns, aws.ToString(lb.LoadBalancerArn))
+		}
+		return arns
+	})
+}
+
+// listTargetGroups returns the ARNs of every target group of the region.
+func (a *AWSProvider) listTargetGroups(ctx context.Context) ([]string, error) {
+	paginator := elasticloadbalancingv2.NewDescribeTargetGroupsPaginator(a.elbv2(), &elasticloadbalancingv2.DescribeTargetGroupsInput{
+		PageSize: aws.Int32(a.elbPageSize()),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *elasticloadbalancingv2.DescribeTargetGroupsOutput) []string {
+		var arns []string
+		for _, group := range page.TargetGroups {
+			arns = append(arns, aws.ToString(group.TargetGroupArn))
+		}
+		return arns
+	})
+}
+
+// elbPageSize returns the page size of Elastic Load Balancing describe calls, which
+// accept at most 400 results per page.
+func (a *AWSProvider) elbPageSize() int32 {
+	if a.PageSize <= 0 {
+		return 400
+	}
+	return min(a.PageSize, 400)
+}
+
+// elbv2 returns the Elastic Load Balancing v2 client of the provider.
+func (a *AWSProvider) elbv2() ELBv2API {
+	if a.ELBv2 != nil {
+		return a.ELBv2
+	}
+	return elasticloadbalancingv2.NewFromConfig(a.Config)
+}
+
+// marshalBlock encodes blocks as the JSON list the state holds a block in.
+func marshalBlock(name string, blocks ...map[string]any) (string, error) {
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	bytes, err := json.Marshal(blocks)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	return string(bytes), nil
+}
+
+// readAttributes reads every attribute of supported with read, leaving out the glob
+// patterns and the attributes of a block that are also readable alone, and adds one
+// tags.KEY attribute per tag. Tags with the reserved aws: prefix are added by AWS
+// and never in the state.
+func readAttributes(supported []string, read func(string) (string, error), tags map[string]string) (map[string]string, error) {
+	attributes := map[string]string{}
+	var errs []error
+	for _, attribute := range supported {
+		if strings.ContainsAny(attribute, ".*") {
+			continue
+		}
+		value, err := read(attribute)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", attribute, err))
+			continue
+		}
+		attributes[attribute] = value
+	}
+	for key, value := range tags {
+		if !strings.HasPrefix(key, "aws:") {
+			attributes["tags."+key] = value
+		}
+	}
+	return attributes, stderrors.Join(errs...)
+}
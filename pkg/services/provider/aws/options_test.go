@@ -0,0 +1,139 @@
+package aws_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/provider/aws/awstest"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAWSProvider_Options(t *testing.T) {
+	// a custom CA bundle makes the SDK wrap the client
+	t.Setenv("AWS_CA_BUNDLE", "")
+	client := &http.Client{}
+	p, err := awsProvider.NewAWSProvider(&config.AWSConfig{},
+		awsProvider.WithRegion("eu-west-1"),
+		awsProvider.WithEndpoint("http://localhost:4566"),
+		awsProvider.WithAssumeRole("arn:aws:iam::123456789012:role/drift-readonly"),
+		awsProvider.WithHTTPClient(client),
+	)
+	require.NoError(t, err)
+
+	cfg := p.(*awsProvider.AWSProvider).Config
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "http://localhost:4566", aws.ToString(cfg.BaseEndpoint))
+	assert.Same(t, client, cfg.HTTPClient)
+	assert.IsType(t, &aws.CredentialsCache{}, cfg.Credentials)
+}
+
+func TestNewAWSProvider_CacheIsScopedToTheAccount(t *testing.T) {
+	cfg := &config.AWSConfig{CacheTTL: time.Hour, CacheDir: t.TempDir()}
+	clusterVersion := func(accessKey, version string) string {
+		fake := awstest.NewFakeEKS()
+		fake.AddCluster(ekstypes.Cluster{Name: aws.String("main"), Version: aws.String(version)})
+		p, err := awsProvider.NewAWSProvider(cfg,
+			awsProvider.WithRegion(awsProvider.LocalStackRegion),
+			awsProvider.WithCredentials(accessKey, "secret"),
+			awsProvider.WithEKSClient(fake),
+		)
+		require.NoError(t, err)
+		cluster, err := p.(*awsProvider.AWSProvider).HandleEKSClusterMetadata(context.Background(), "main")
+		require.NoError(t, err)
+		value, err := cluster.AttributeValue(awsProvider.EKSVersion)
+		require.NoError(t, err)
+		return value
+	}
+
+	assert.Equal(t, "1.29", clusterVersion("AKIAPROD", "1.29"))
+	assert.Equal(t, "1.31", clusterVersion("AKIASTAGING", "1.31"), "a cluster of the same name in another account is not read from the cache")
+	assert.Equal(t, "1.29", clusterVersion("AKIAPROD", "1.30"), "the cluster is read from the cache of its account")
+}
+
+func TestNewAWSProvider_ClassicLoadBalancerCacheIsScopedToTheEndpoint(t *testing.T) {
+	cfg := &config.AWSConfig{CacheTTL: time.Hour, CacheDir: t.TempDir()}
+	dnsName := func(endpoint, dnsName string) string {
+		fake := awstest.NewFakeELB()
+		fake.AddLoadBalancer(elbtypes.LoadBalancerDescription{LoadBalancerName: aws.String("web"), DNSName: aws.String(dnsName)}, elbtypes.LoadBalancerAttributes{}, nil)
+		p, err := awsProvider.NewAWSProvider(cfg,
+			awsProvider.WithRegion(awsProvider.LocalStackRegion),
+			awsProvider.WithEndpoint(endpoint),
+			awsProvider.WithELBClient(fake),
+		)
+		require.NoError(t, err)
+		lb, err := p.(*awsProvider.AWSProvider).HandleClassicLoadBalancerMetadata(context.Background(), "web")
+		require.NoError(t, err)
+		value, err := lb.AttributeValue(awsProvider.ELBDNSName)
+		require.NoError(t, err)
+		return value
+	}
+
+	assert.Equal(t, "web-1.elb.amazonaws.com", dnsName("http://localhost:4566", "web-1.elb.amazonaws.com"))
+	assert.Equal(t, "web-2.elb.amazonaws.com", dnsName("http://localhost:4567", "web-2.elb.amazonaws.com"))
+	assert.Equal(t, "web-1.elb.amazonaws.com", dnsName("http://localhost:4566", "web-3.elb.amazonaws.com"))
+}
+
+func TestLoadConfig_Credentials(t *testing.T) {
+	cfg, err := awsProvider.LoadConfig(&config.AWSConfig{},
+		awsProvider.WithRegion(awsProvider.LocalStackRegion),
+		awsProvider.WithCredentials(awsProvider.LocalStackAccessKey, awsProvider.LocalStackAccessKey))
+	require.NoError(t, err)
+
+	credentials, err := cfg.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test", credentials.AccessKeyID)
+	assert.Equal(t, "test", credentials.SecretAccessKey)
+}
+
+func TestLoadConfig_NoOptions(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-west-2")
+	cfg, err := awsProvider.LoadConfig(&config.AWSConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", cfg.Region)
+	assert.Nil(t, cfg.BaseEndpoint)
+}
+
+func TestLoadConfig_Partitions(t *testing.T) {
+	for region, partition := range map[string]string{
+		"us-east-1":      awsProvider.PartitionStandard,
+		"us-gov-west-1":  awsProvider.PartitionGovCloud,
+		"cn-northwest-1": awsProvider.PartitionChina,
+	} {
+		got, err := awsProvider.PartitionOf(region)
+		require.NoError(t, err, region)
+		assert.Equal(t, partition, got, region)
+
+		_, err = awsProvider.LoadConfig(&config.AWSConfig{Partition: partition}, awsProvider.WithRegion(region))
+		assert.NoError(t, err, region)
+	}
+
+	_, err := awsProvider.LoadConfig(&config.AWSConfig{Partition: awsProvider.PartitionGovCloud}, awsProvider.WithRegion("us-east-1"))
+	assert.ErrorContains(t, err, "region us-east-1 belongs to the aws partition, not aws-us-gov")
+
+	_, err = awsProvider.LoadConfig(&config.AWSConfig{Partition: "aws-iso"}, awsProvider.WithRegion("us-east-1"))
+	assert.ErrorContains(t, err, `unsupported AWS partition "aws-iso"`)
+
+	_, err = awsProvider.LoadConfig(&config.AWSConfig{}, awsProvider.WithRegion("us-iso-east-1"))
+	assert.ErrorContains(t, err, "isolated AWS partition")
+
+	_, err = awsProvider.LoadConfig(&config.AWSConfig{}, awsProvider.WithRegion("useast1"))
+	assert.ErrorContains(t, err, `invalid AWS region "useast1"`)
+}
+
+func TestLoadConfig_FIPS(t *testing.T) {
+	cfg, err := awsProvider.LoadConfig(&config.AWSConfig{UseFIPS: true}, awsProvider.WithRegion("us-gov-west-1"))
+	require.NoError(t, err)
+	assert.Equal(t, aws.FIPSEndpointStateEnabled, ec2.NewFromConfig(cfg).Options().EndpointOptions.UseFIPSEndpoint)
+
+	_, err = awsProvider.LoadConfig(&config.AWSConfig{UseFIPS: true}, awsProvider.WithRegion("cn-north-1"))
+	assert.ErrorContains(t, err, "FIPS endpoints are not available in the aws-cn partition")
+}
@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/services/secrets"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/pkg/errors"
+)
+
+// SSMAPI is the part of the SSM API used by the provider, to resolve the parameters
+// referenced by the state. It is implemented by *ssm.Client, and by awstest.FakeSSM
+// for tests that do not reach AWS.
+type SSMAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// defaultValueCacheTTL is how long resolved values are kept when no cache TTL is
+// configured, so that a parameter referenced by many resources is read once per scan.
+const defaultValueCacheTTL = 5 * time.Minute
+
+func valueCacheTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return defaultValueCacheTTL
+	}
+	return ttl
+}
+
+var (
+	ssmParameterARN = regexp.MustCompile(`^arn:aws[a-z-]*:ssm:[a-z0-9-]*:[0-9]*:parameter/.+$`)
+	secretARN       = regexp.MustCompile(`^arn:aws[a-z-]*:secretsmanager:[a-z0-9-]+:[0-9]+:secret:.+$`)
+)
+
+// valueReference is a reference to an SSM parameter or a Secrets Manager secret.
+type valueReference struct {
+	// parameter is the name or ARN of the parameter, with an optional :version or
+	// :label suffix as GetParameter accepts it.
+	parameter string
+	// secure marks ssm-secure references, whose parameter is a SecureString.
+	secure bool
+
+	// secret is the name or ARN of the secret.
+	secret string
+	// key selects a key of a secret holding a JSON object.
+	key          string
+	versionStage string
+	versionId    string
+}
+
+// parseValueReference reads the references the state may hold in place of a value:
+// the ARN of an SSM parameter or a secret, the resolve:ssm:NAME form EC2 accepts for
+// the AMI of instances and launch templates, and CloudFormation dynamic references
+// such as {{resolve:ssm:NAME}}, {{resolve:ssm-secure:NAME}} and
+// {{resolve:secretsmanager:ID:SecretString:KEY}}. ok is false for any other value.
+func parseValueReference(value string) (ref valueReference, ok bool, err error) {
+	value = strings.TrimSpace(value)
+	if inner, isDynamic := strings.CutPrefix(value, "{{resolve:"); isDynamic && strings.HasSuffix(inner, "}}") {
+		service, target, _ := strings.Cut(strings.TrimSuffix(inner, "}}"), ":")
+		switch service {
+		case "ssm":
+			return valueReference{parameter: target}, target != "", nil
+		case "ssm-secure":
+			return valueReference{parameter: target, secure: true}, target != "", nil
+		case "secretsmanager":
+			ref, err := parseSecretReference(target, true)
+			return ref, true, err
+		}
+		return valueReference{}, false, nil
+	}
+	if parameter, isEC2 := strings.CutPrefix(value, "resolve:ssm:"); isEC2 && parameter != "" {
+		return valueReference{parameter: parameter}, true, nil
+	}
+	if ssmParameterARN.MatchString(value) {
+		return valueReference{parameter: value}, true, nil
+	}
+	if secretARN.MatchString(value) {
+		ref, err := parseSecretReference(value, false)
+		return ref, true, err
+	}
+	return valueReference{}, false, nil
+}
+
+// parseSecretReference reads ID[:SecretString[:KEY[:VERSION_STAGE[:VERSION_ID]]]], as
+// in dynamic references, or ID[:KEY[:VERSION_STAGE[:VERSION_ID]]] without
+// secretString, as in the valueFrom of ECS container secrets. ID is the name or the
+// ARN of a secret.
+func parseSecretReference(ref string, secretString bool) (valueReference, error) {
+	parts := strings.Split(ref, ":")
+	idParts := 1
+	if strings.HasPrefix(ref, "arn:") {
+		// arn:PARTITION:secretsmanager:REGION:ACCOUNT:secret:NAME
+		idParts = min(7, len(parts))
+	}
+	out := valueReference{secret: strings.Join(parts[:idParts], ":")}
+	options := append(parts[idParts:], "", "", "", "")
+	if out.secret == "" {
+		return out, fmt.Errorf("secret reference %q names no secret", ref)
+	}
+	if secretString {
+		if options[0] != "" && options[0] != "SecretString" {
+			return out, fmt.Errorf("secret reference %q selects %s, only SecretString is supported", ref, options[0])
+		}
+		options = options[1:]
+	}
+	out.key, out.versionStage, out.versionId = options[0], options[1], options[2]
+	return out, nil
+}
+
+// ResolveValue resolves a reference the state holds in place of a value to the value
+// it refers to: the value of an SSM parameter, decrypted for SecureString parameters,
+// or the value of a Secrets Manager secret or of one key of it. Values of
+// SecureString parameters and secrets are sensitive. Resolved values are cached in
+// memory for the cache TTL, or five minutes without one, and never on disk.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - value: The value held by the state
+//
+// Returns:
+//   - *provider.ResolvedValue: The value referred to, or nil if value is not a reference
+//   - error: Any error encountered parsing the reference or during the AWS API calls
+func (a *AWSProvider) ResolveValue(ctx context.Context, value string) (*provider.ResolvedValue, error) {
+	ref, ok, err := parseValueReference(value)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	key := a.cacheKey("reference", value)
+	var resolved provider.ResolvedValue
+	if a.values.Get(ctx, key, &resolved) {
+		return &resolved, nil
+	}
+	if ref.parameter != "" {
+		resolved, err = a.parameterValue(ctx, ref)
+	} else {
+		resolved, err = a.secretValue(ctx, ref)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve %s", value)
+	}
+	if err := a.values.Set(ctx, key, resolved); err != nil {
+		logger(ctx).Debug("failed to cache resolved value", "reference", value, "error", err)
+	}
+	return &resolved, nil
+}
+
+func (a *AWSProvider) parameterValue(ctx context.Context, ref valueReference) (provider.ResolvedValue, error) {
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*ssm.GetParameterOutput, error) {
+		return a.ssm().GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(ref.parameter), WithDecryption: aws.Bool(true)})
+	})
+	if err != nil {
+		return provider.ResolvedValue{}, err
+	}
+	if output.Parameter == nil {
+		return provider.ResolvedValue{}, fmt.Errorf("parameter %s has no value", ref.parameter)
+	}
+	return provider.ResolvedValue{
+		Value:     aws.ToString(output.Parameter.Value),
+		Sensitive: ref.secure || output.Parameter.Type == types.ParameterTypeSecureString,
+	}, nil
+}
+
+func (a *AWSProvider) secretValue(ctx context.Context, ref valueReference) (provider.ResolvedValue, error) {
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(ref.secret)}
+	if ref.versionStage != "" {
+		input.VersionStage = aws.String(ref.versionStage)
+	}
+	if ref.versionId != "" {
+		input.VersionId = aws.String(ref.versionId)
+	}
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*secretsmanager.GetSecretValueOutput, error) {
+		return a.secretsManager().GetSecretValue(ctx, input)
+	})
+	if err != nil {
+		return provider.ResolvedValue{}, err
+	}
+
+	value := aws.ToString(output.SecretString)
+	if output.SecretString == nil {
+		value = string(output.SecretBinary)
+	}
+	if ref.key != "" {
+		if value, err = secrets.SelectKey(ref.secret, value, ref.key); err != nil {
+			return provider.ResolvedValue{}, err
+		}
+	}
+	return provider.ResolvedValue{Value: value, Sensitive: true}, nil
+}
+
+func (a *AWSProvider) ssm() SSMAPI {
+	if a.SSM != nil {
+		return a.SSM
+	}
+	return ssm.NewFromConfig(a.Config)
+}
+
+func (a *AWSProvider) secretsManager() secrets.SecretsManagerAPI {
+	if a.SecretsManager != nil {
+		return a.SecretsManager
+	}
+	return secretsmanager.NewFromConfig(a.Config)
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"drift-watcher/config"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Partitions the provider can reach. Each partition has its own regions, endpoints
+// and credentials, so an account of one partition cannot be scanned in another.
+const (
+	PartitionStandard = "aws"
+	PartitionGovCloud = "aws-us-gov"
+	PartitionChina    = "aws-cn"
+)
+
+// regionPattern matches region names such as us-east-1, us-gov-west-1 or
+// cn-northwest-1.
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// PartitionOf returns the partition region belongs to. Regions of the isolated
+// partitions, such as us-iso-east-1, are rejected as they are not supported.
+func PartitionOf(region string) (string, error) {
+	if !regionPattern.MatchString(region) {
+		return "", fmt.Errorf("invalid AWS region %q", region)
+	}
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionGovCloud, nil
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionChina, nil
+	case strings.HasPrefix(region, "us-iso"), strings.HasPrefix(region, "eu-isoe-"):
+		return "", fmt.Errorf("region %q belongs to an isolated AWS partition, which is not supported", region)
+	default:
+		return PartitionStandard, nil
+	}
+}
+
+// checkPartition validates region against the partition and FIPS settings of cfg.
+// The region must belong to the configured partition, when one is set, and FIPS
+// endpoints are only available in the standard and GovCloud partitions.
+func checkPartition(cfg *config.AWSConfig, region string) error {
+	switch cfg.Partition {
+	case "", PartitionStandard, PartitionGovCloud, PartitionChina:
+	default:
+		return fmt.Errorf("unsupported AWS partition %q, expected one of: %s, %s, %s", cfg.Partition, PartitionStandard, PartitionGovCloud, PartitionChina)
+	}
+	if region == "" {
+		if cfg.Partition != "" && cfg.Partition != PartitionStandard {
+			return fmt.Errorf("a region is required for the %s partition", cfg.Partition)
+		}
+		return nil
+	}
+
+	partition, err := PartitionOf(region)
+	if err != nil {
+		return err
+	}
+	if cfg.Partition != "" && partition != cfg.Partition {
+		return fmt.Errorf("region %s belongs to the %s partition, not %s", region, partition, cfg.Partition)
+	}
+	if cfg.UseFIPS && partition == PartitionChina {
+		return fmt.Errorf("FIPS endpoints are not available in the %s partition", partition)
+	}
+	return nil
+}
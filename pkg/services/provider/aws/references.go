@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/paginate"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+)
+
+// referenceTypes maps the attributes that hold identifiers of other resources to the
+// type of the referenced resource.
+var referenceTypes = map[string]string{
+	string(EC2SUBNETID):         "aws_subnet",
+	string(EC2SecurityGroupIDs): "aws_security_group",
+	string(SGVPCID):             "aws_vpc",
+	string(EC2AMIID):            "aws_ami",
+}
+
+// ResolveReferences looks up human-readable names for the subnets, security groups,
+// VPCs and AMIs referenced by an attribute. A resource is named after its Name tag;
+// default subnets and VPCs and security groups without a Name tag fall back to a
+// description of what they are, and AMIs are named with their name and creation date.
+// Attributes that do not reference other resources resolve to nil.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The type of the resource the attribute belongs to
+//   - attribute: The attribute holding the identifiers
+//   - ids: The identifiers to resolve
+//
+// Returns:
+//   - map[string]string: Names keyed by identifier; identifiers that no longer exist are omitted
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) ResolveReferences(ctx context.Context, resourceType string, attribute string, ids []string) (map[string]string, error) {
+	referenced, ok := referenceTypes[canonicalAttribute(resourceType, attribute)]
+	if !ok || len(ids) == 0 {
+		return nil, nil
+	}
+
+	names := map[string]string{}
+	var missing []string
+	for _, id := range ids {
+		var name string
+		if a.cache.Get(ctx, a.cacheKey(referenced, id), &name) {
+			names[id] = name
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return names, nil
+	}
+
+	resolved, err := a.describeReferences(ctx, referenced, missing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve %s references", referenced)
+	}
+
+	for id, name := range resolved {
+		names[id] = name
+		if err := a.cache.Set(ctx, a.cacheKey(referenced, id), name); err != nil {
+			logger(ctx).Debug("failed to cache reference name", "id", id, "error", err)
+		}
+	}
+	return names, nil
+}
+
+// describeReferenceBatch is the number of identifiers described per call, the most
+// values EC2 accepts in a filter.
+const describeReferenceBatch = 200
+
+// describeReferences names the resources of the given type, describing them in batches
+// of ids and requesting every page of each. Filters are used rather than ids so that
+// deleted resources are omitted instead of failing the whole call.
+func (a *AWSProvider) describeReferences(ctx context.Context, resourceType string, ids []string) (map[string]string, error) {
+	names := map[string]string{}
+	for batch := range slices.Chunk(ids, describeReferenceBatch) {
+		if err := a.describeReferenceBatch(ctx, resourceType, batch, names); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+func (a *AWSProvider) describeReferenceBatch(ctx context.Context, resourceType string, ids []string, names map[string]string) error {
+	ec2Client := a.ec2()
+
+	switch resourceType {
+	case "aws_subnet":
+		paginator := ec2.NewDescribeSubnetsPaginator(ec2Client, &ec2.DescribeSubnetsInput{
+			Filters: []types.Filter{{Name: aws.String("subnet-id"), Values: ids}},
+		}, func(o *ec2.DescribeSubnetsPaginatorOptions) {
+			o.Limit = a.PageSize
+		})
+		return paginate.Pages(ctx, paginator, a.calls(), func(page *ec2.DescribeSubnetsOutput) bool {
+			for _, subnet := range page.Subnets {
+				name := nameTag(subnet.Tags)
+				if aws.ToBool(subnet.DefaultForAz) {
+					name = withDefault(name, "default subnet in "+aws.ToString(subnet.AvailabilityZone))
+				}
+				names[aws.ToString(subnet.SubnetId)] = name
+			}
+			return true
+		})
+	case "aws_security_group":
+		paginator := ec2.NewDescribeSecurityGroupsPaginator(ec2Client, &ec2.DescribeSecurityGroupsInput{
+			Filters: []types.Filter{{Name: aws.String("group-id"), Values: ids}},
+		}, func(o *ec2.DescribeSecurityGroupsPaginatorOptions) {
+			o.Limit = a.PageSize
+		})
+		return paginate.Pages(ctx, paginator, a.calls(), func(page *ec2.DescribeSecurityGroupsOutput) bool {
+			for _, group := range page.SecurityGroups {
+				name := nameTag(group.Tags)
+				if name == "" {
+					name = aws.ToString(group.GroupName)
+				}
+				names[aws.ToString(group.GroupId)] = name
+			}
+			return true
+		})
+	case "aws_vpc":
+		paginator := ec2.NewDescribeVpcsPaginator(ec2Client, &ec2.DescribeVpcsInput{
+			Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: ids}},
+		}, func(o *ec2.DescribeVpcsPaginatorOptions) {
+			o.Limit = a.PageSize
+		})
+		return paginate.Pages(ctx, paginator, a.calls(), func(page *ec2.DescribeVpcsOutput) bool {
+			for _, vpc := range page.Vpcs {
+				name := nameTag(vpc.Tags)
+				if aws.ToBool(vpc.IsDefault) {
+					name = withDefault(name, "default VPC")
+				}
+				names[aws.ToString(vpc.VpcId)] = name
+			}
+			return true
+		})
+	case "aws_ami":
+		// deprecated and disabled images are still named, as instances keep running them
+		paginator := ec2.NewDescribeImagesPaginator(ec2Client, &ec2.DescribeImagesInput{
+			Filters:           []types.Filter{{Name: aws.String("image-id"), Values: ids}},
+			IncludeDeprecated: aws.Bool(true),
+			IncludeDisabled:   aws.Bool(true),
+		}, func(o *ec2.DescribeImagesPaginatorOptions) {
+			o.Limit = a.PageSize
+		})
+		return paginate.Pages(ctx, paginator, a.calls(), func(page *ec2.DescribeImagesOutput) bool {
+			for _, image := range page.Images {
+				names[aws.ToString(image.ImageId)] = imageName(image)
+			}
+			return true
+		})
+	default:
+		return fmt.Errorf("%s references not yet supported for AWS provider", resourceType)
+	}
+}
+
+// nameTag returns the value of the Name tag, or an empty string if there is none.
+func nameTag(tags []types.Tag) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == "Name" {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// withDefault marks a default subnet or VPC, keeping its Name tag if it has one.
+func withDefault(name string, description string) string {
+	if name == "" {
+		return description
+	}
+	return name + " (" + description + ")"
+}
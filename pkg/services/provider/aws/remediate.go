@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instanceStateTimeout bounds how long remediation waits for an instance to stop.
+const instanceStateTimeout = 10 * time.Minute
+
+// CanRemediate reports whether the attribute is on the allowlist of attributes that
+// can be reverted safely: tags, security group attachment and instance type.
+func (a *AWSProvider) CanRemediate(resourceType string, attr string) bool {
+	if resourceType != "aws_instance" {
+		return false
+	}
+
+	switch EC2Attributes(canonicalAttribute(resourceType, attr)) {
+	case EC2INSTANCETYPE, EC2SecurityGroupIDs:
+		return true
+	default:
+		_, ok := tagKey(attr)
+		return ok
+	}
+}
+
+// tagKey returns the key of the tag a tags.KEY or tags["KEY"] attribute names.
+func tagKey(attr string) (string, bool) {
+	p, err := attrpath.Parse(attr)
+	if err != nil || len(p) < 2 || p[0].IsIndex || p[0].Key != "tags" {
+		return "", false
+	}
+	return p[1:].Dotted()
+}
+
+// Remediate applies a single change to a live resource so that it matches the
+// value recorded in the state file.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resource: The Terraform state resource being remediated
+//   - change: The attribute to change and the value it should be set to
+//
+// Returns:
+//   - error: Any error encountered while applying the change
+func (a *AWSProvider) Remediate(ctx context.Context, resource statemanager.StateResource, change provider.Change) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AWSProvider.Remediate", attribute.String("drift.attribute", change.Attribute))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	if !a.CanRemediate(resource.ResourceType(), change.Attribute) {
+		return fmt.Errorf("%s attribute cannot be remediated for %s", change.Attribute, resource.ResourceType())
+	}
+
+	instanceId, err := resource.AttributeValue("id")
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse resource identifier from parsed state object")
+	}
+	if instanceId == "" {
+		return fmt.Errorf("resource Id not parsed from state file")
+	}
+
+	if err := a.breaker.Allow(); err != nil {
+		return errors.Wrap(err, "Failed to remediate instance")
+	}
+	// remediation works from fresh metadata and leaves the cached metadata stale
+	cacheKey := a.cacheKey(resource.ResourceType(), instanceId)
+	a.cache.Delete(ctx, cacheKey)
+	defer func() {
+		a.breaker.Record(err)
+		a.cache.Delete(ctx, cacheKey)
+	}()
+
+	ec2Client := a.ec2()
+	switch canonicalAttribute(resource.ResourceType(), change.Attribute) {
+	case string(EC2INSTANCETYPE):
+		return a.remediateInstanceType(ctx, ec2Client, instanceId, change.DesiredValue)
+	case string(EC2SecurityGroupIDs):
+		groups := strings.Split(change.DesiredValue, ",")
+		_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId: aws.String(instanceId),
+			Groups:     groups,
+		})
+		return errors.Wrap(err, "Failed to modify instance security groups")
+	default:
+		tagName, _ := tagKey(change.Attribute)
+		if change.DesiredValue == "" {
+			_, err := ec2Client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+				Resources: []string{instanceId},
+				Tags:      []types.Tag{{Key: aws.String(tagName)}},
+			})
+			return errors.Wrap(err, "Failed to delete instance tag")
+		}
+		_, err := ec2Client.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{instanceId},
+			Tags:      []types.Tag{{Key: aws.String(tagName), Value: aws.String(change.DesiredValue)}},
+		})
+		return errors.Wrap(err, "Failed to update instance tag")
+	}
+}
+
+// remediateInstanceType changes the instance type, which requires the instance to
+// be stopped. A running instance is stopped, modified and started again, whether the
+// change succeeded or not, so that a failed change or an expired deadline never leaves
+// it stopped; a stopped instance is left stopped.
+func (a *AWSProvider) remediateInstanceType(ctx context.Context, ec2Client EC2API, instanceId string, instanceType string) (err error) {
+	instance, err := a.HandleEC2Metadata(ctx, instanceId)
+	if err != nil {
+		return err
+	}
+	wasRunning := instance.Instance.State != nil && instance.Instance.State.Name == types.InstanceStateNameRunning
+
+	if wasRunning {
+		defer func() {
+			if startErr := startInstance(ctx, ec2Client, instanceId); startErr != nil {
+				if err != nil {
+					err = fmt.Errorf("%w; %w", err, startErr)
+				} else {
+					err = startErr
+				}
+			}
+		}()
+		logger(ctx).Info("Stopping instance to change instance type", "instance_id", instanceId)
+		if _, err := ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{instanceId}}); err != nil {
+			return errors.Wrap(err, "Failed to stop instance")
+		}
+		waiter := ec2.NewInstanceStoppedWaiter(ec2Client)
+		if err := waiter.Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceId}}, instanceStateTimeout); err != nil {
+			return errors.Wrap(err, "Failed waiting for instance to stop")
+		}
+	}
+
+	_, err = ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:   aws.String(instanceId),
+		InstanceType: &types.AttributeValue{Value: aws.String(instanceType)},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to modify instance type")
+	}
+	return nil
+}
+
+// startInstance starts an instance stopped by remediation and waits until it runs.
+// It is not cancelled with ctx, whose deadline may have expired while the instance
+// was stopping, and is bounded by instanceStateTimeout instead.
+func startInstance(ctx context.Context, ec2Client EC2API, instanceId string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), instanceStateTimeout)
+	defer cancel()
+
+	logger(ctx).Info("Starting instance after changing instance type", "instance_id", instanceId)
+	if _, err := ec2Client.StartInstances(ctx, &ec2.StartInstancesInput{InstanceIds: []string{instanceId}}); err != nil {
+		return errors.Wrap(err, "Failed to start instance")
+	}
+	waiter := ec2.NewInstanceRunningWaiter(ec2Client)
+	if err := waiter.Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceId}}, instanceStateTimeout); err != nil {
+		return errors.Wrap(err, "Failed waiting for instance to start")
+	}
+	return nil
+}
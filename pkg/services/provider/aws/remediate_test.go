@@ -0,0 +1,32 @@
+package aws_test
+
+import (
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAWSProvider_CanRemediate(t *testing.T) {
+	p := &awsProvider.AWSProvider{}
+
+	assert.True(t, p.CanRemediate("aws_instance", "instance_type"))
+	assert.True(t, p.CanRemediate("aws_instance", "security_group_ids"))
+	assert.True(t, p.CanRemediate("aws_instance", "vpc_security_group_ids"))
+	assert.True(t, p.CanRemediate("aws_instance", "tags.Name"))
+	assert.False(t, p.CanRemediate("aws_instance", "ami"))
+	assert.False(t, p.CanRemediate("aws_instance", "subnet_id"))
+	assert.False(t, p.CanRemediate("aws_s3_bucket", "tags.Name"))
+}
+
+func TestAWSProvider_SupportedAttributes(t *testing.T) {
+	p := &awsProvider.AWSProvider{}
+
+	attributes, err := p.SupportedAttributes("aws_instance")
+	assert.NoError(t, err)
+	assert.Contains(t, attributes, "instance_type")
+	assert.Contains(t, attributes, "tags.*")
+
+	_, err = p.SupportedAttributes("aws_s3_bucket")
+	assert.ErrorContains(t, err, "not yet supported")
+}
@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider/breaker"
+	"drift-watcher/pkg/services/provider/paginate"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"golang.org/x/time/rate"
+)
+
+// Retry modes supported by the provider.
+const (
+	RetryModeStandard = "standard"
+	RetryModeAdaptive = "adaptive"
+)
+
+// Defaults applied when the AWS config leaves a retry, breaker or pagination setting
+// unset.
+const (
+	DefaultRetryMode        = RetryModeAdaptive
+	DefaultMaxAttempts      = 5
+	DefaultMaxBackoff       = 20 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+	// DefaultRequestRate stays below the sustained rate EC2 allows describe calls
+	// per account and region.
+	DefaultRequestRate = 20
+	// DefaultPageSize is the largest page EC2 describe calls return.
+	DefaultPageSize = 1000
+)
+
+// newRetryer builds the SDK retryer for the configured retry mode. Throttling errors
+// such as RequestLimitExceeded are retried with backoff; adaptive mode additionally
+// slows the client down while AWS keeps throttling. The client side retry quota is
+// disabled so a long scan isn't aborted once enough throttled calls have been seen.
+func newRetryer(cfg *config.AWSConfig) (func() aws.Retryer, error) {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	maxBackoff := cfg.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	standard := func(o *retry.StandardOptions) {
+		o.MaxAttempts = maxAttempts
+		o.MaxBackoff = maxBackoff
+		o.RateLimiter = ratelimit.None
+	}
+
+	switch cfg.RetryMode {
+	case "", RetryModeAdaptive:
+		return func() aws.Retryer {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, standard)
+			})
+		}, nil
+	case RetryModeStandard:
+		return func() aws.Retryer {
+			return retry.NewStandard(standard)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported retry mode %q, expected one of: %s, %s", cfg.RetryMode, RetryModeStandard, RetryModeAdaptive)
+	}
+}
+
+// newBreaker builds the circuit breaker guarding calls to the configured region.
+// A negative threshold disables it.
+func newBreaker(cfg *config.AWSConfig) *breaker.Breaker {
+	threshold := cfg.BreakerThreshold
+	if threshold == 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if threshold < 0 {
+		return nil
+	}
+	cooldown := cfg.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return breaker.New(threshold, cooldown, isUnreachable)
+}
+
+// newLimiter builds the rate limiter shared by the list and describe calls of the
+// provider. A negative rate disables it.
+func newLimiter(cfg *config.AWSConfig) *rate.Limiter {
+	requestRate := cfg.RequestRate
+	if requestRate == 0 {
+		requestRate = DefaultRequestRate
+	}
+	return paginate.NewLimiter(requestRate)
+}
+
+// pageSize returns the number of results requested per page, which EC2 accepts
+// between 5 and 1000.
+func pageSize(cfg *config.AWSConfig) int32 {
+	if cfg.PageSize <= 0 {
+		return DefaultPageSize
+	}
+	return max(5, min(cfg.PageSize, DefaultPageSize))
+}
+
+// isUnreachable reports whether err means the region could not be reached at all,
+// after the retryer has given up. Errors returned by the API itself, such as a
+// missing instance, show the region is reachable and don't trip the breaker.
+func isUnreachable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr smithy.APIError
+	return !errors.As(err, &apiErr)
+}
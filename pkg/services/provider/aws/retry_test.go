@@ -0,0 +1,46 @@
+package aws_test
+
+import (
+	"drift-watcher/config"
+	awsProvider "drift-watcher/pkg/services/provider/aws"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAWSProvider_RetryMode(t *testing.T) {
+	for _, mode := range []string{"", awsProvider.RetryModeStandard, awsProvider.RetryModeAdaptive} {
+		p, err := awsProvider.NewAWSProvider(&config.AWSConfig{RetryMode: mode, MaxAttempts: 7})
+		require.NoError(t, err, mode)
+
+		retryer := p.(*awsProvider.AWSProvider).Config.Retryer()
+		assert.Equal(t, 7, retryer.MaxAttempts(), mode)
+		if mode != awsProvider.RetryModeStandard {
+			assert.IsType(t, &retry.AdaptiveMode{}, retryer, mode)
+		}
+	}
+}
+
+func TestNewAWSProvider_InvalidRetryMode(t *testing.T) {
+	_, err := awsProvider.NewAWSProvider(&config.AWSConfig{RetryMode: "aggressive"})
+	assert.ErrorContains(t, err, "unsupported retry mode")
+}
+
+func TestNewAWSProvider_PageSize(t *testing.T) {
+	tests := []struct {
+		pageSize int32
+		want     int32
+	}{
+		{0, awsProvider.DefaultPageSize},
+		{2, 5},
+		{200, 200},
+		{5000, awsProvider.DefaultPageSize},
+	}
+	for _, tt := range tests {
+		p, err := awsProvider.NewAWSProvider(&config.AWSConfig{PageSize: tt.pageSize})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, p.(*awsProvider.AWSProvider).PageSize, tt.pageSize)
+	}
+}
@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Attributes of aws_security_group groups, named after the Terraform schema.
+const (
+	SecurityGroupName        = "name"
+	SecurityGroupArn         = "arn"
+	SecurityGroupDescription = "description"
+	SecurityGroupVPCID       = "vpc_id"
+	SecurityGroupOwnerID     = "owner_id"
+	SecurityGroupIngress     = "ingress"
+	SecurityGroupEgress      = "egress"
+)
+
+// securityGroupValues maps a security group and its rules to the attributes of an
+// aws_security_group. The rules are written as the inline ingress and egress blocks
+// of the state and normalized like the state's, so that a group whose rules are split
+// into rule resources compares equal.
+func securityGroupValues(group types.SecurityGroup, rules []types.SecurityGroupRule) map[string]any {
+	id := aws.ToString(group.GroupId)
+	blocks := map[bool][]any{}
+	for _, rule := range rules {
+		blocks[aws.ToBool(rule.IsEgress)] = append(blocks[aws.ToBool(rule.IsEgress)], securityGroupRuleBlock(rule))
+	}
+	tags := map[string]string{}
+	for _, tag := range group.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return map[string]any{
+		SecurityGroupName:        aws.ToString(group.GroupName),
+		SecurityGroupArn:         aws.ToString(group.SecurityGroupArn),
+		SecurityGroupDescription: aws.ToString(group.Description),
+		SecurityGroupVPCID:       aws.ToString(group.VpcId),
+		SecurityGroupOwnerID:     aws.ToString(group.OwnerId),
+		SecurityGroupIngress:     terraform.NormalizeSecurityGroupRules(id, blocks[false]),
+		SecurityGroupEgress:      terraform.NormalizeSecurityGroupRules(id, blocks[true]),
+		"tags":                   userTags(tags),
+	}
+}
+
+// securityGroupRuleBlock converts a rule returned by DescribeSecurityGroupRules, which
+// has a single source, to an inline rule block.
+func securityGroupRuleBlock(rule types.SecurityGroupRule) map[string]any {
+	block := map[string]any{
+		"description": aws.ToString(rule.Description),
+		"protocol":    aws.ToString(rule.IpProtocol),
+		"from_port":   int(aws.ToInt32(rule.FromPort)),
+		"to_port":     int(aws.ToInt32(rule.ToPort)),
+	}
+	switch {
+	case rule.CidrIpv4 != nil:
+		block["cidr_blocks"] = []any{aws.ToString(rule.CidrIpv4)}
+	case rule.CidrIpv6 != nil:
+		block["ipv6_cidr_blocks"] = []any{aws.ToString(rule.CidrIpv6)}
+	case rule.PrefixListId != nil:
+		block["prefix_list_ids"] = []any{aws.ToString(rule.PrefixListId)}
+	case rule.ReferencedGroupInfo != nil:
+		block["security_groups"] = []any{aws.ToString(rule.ReferencedGroupInfo.GroupId)}
+	}
+	return block
+}
+
+// HandleSecurityGroupMetadata retrieves the security group with the given id and its
+// rules.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - id: The id of the security group, the id of the resource in the state
+//
+// Returns:
+//   - *provider.GenericInfraResource: The live security group data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleSecurityGroupMetadata(ctx context.Context, id string) (*provider.GenericInfraResource, error) {
+	ctx, span := telemetry.StartSpan(ctx, "EC2.DescribeSecurityGroups", attribute.String("aws.ec2.security_group_id", id))
+	defer span.End()
+
+	out := &provider.GenericInfraResource{}
+	cacheKey := a.cacheKey("aws_security_group", id)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*ec2.DescribeSecurityGroupsOutput, error) {
+		return a.ec2().DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []string{id}})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe security group")
+	}
+	if len(output.SecurityGroups) == 0 {
+		return nil, fmt.Errorf("security group %s not found", id)
+	}
+
+	paginator := ec2.NewDescribeSecurityGroupRulesPaginator(a.ec2(), &ec2.DescribeSecurityGroupRulesInput{
+		Filters: []types.Filter{{Name: aws.String("group-id"), Values: []string{id}}},
+	}, func(o *ec2.DescribeSecurityGroupRulesPaginatorOptions) {
+		o.Limit = a.PageSize
+	})
+	rules, err := paginate.Collect(ctx, paginator, a.calls(), func(page *ec2.DescribeSecurityGroupRulesOutput) []types.SecurityGroupRule {
+		return page.SecurityGroupRules
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to describe security group rules")
+	}
+	out, err = provider.NewGenericInfraResource("aws_security_group", securityGroupValues(output.SecurityGroups[0], rules))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache security group metadata", "security_group_id", id, "error", err)
+	}
+	return out, nil
+}
+
+// listSecurityGroups returns the ids of every security group of the region, the
+// default group of each VPC included.
+func (a *AWSProvider) listSecurityGroups(ctx context.Context) ([]string, error) {
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(a.ec2(), &ec2.DescribeSecurityGroupsInput{}, func(o *ec2.DescribeSecurityGroupsPaginatorOptions) {
+		o.Limit = a.PageSize
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *ec2.DescribeSecurityGroupsOutput) []string {
+		var ids []string
+		for _, group := range page.SecurityGroups {
+			ids = append(ids, aws.ToString(group.GroupId))
+		}
+		return ids
+	})
+}
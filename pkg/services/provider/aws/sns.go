@@ -0,0 +1,251 @@
+package aws
+
+import (
+	"cmp"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SNSAPI is the part of the SNS API used by the provider, for topics and their
+// subscriptions. It is implemented by *sns.Client, and by awstest.FakeSNS for tests
+// that do not reach AWS.
+type SNSAPI interface {
+	GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error)
+	ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error)
+	ListTagsForResource(ctx context.Context, params *sns.ListTagsForResourceInput, optFns ...func(*sns.Options)) (*sns.ListTagsForResourceOutput, error)
+	GetSubscriptionAttributes(ctx context.Context, params *sns.GetSubscriptionAttributesInput, optFns ...func(*sns.Options)) (*sns.GetSubscriptionAttributesOutput, error)
+	ListSubscriptions(ctx context.Context, params *sns.ListSubscriptionsInput, optFns ...func(*sns.Options)) (*sns.ListSubscriptionsOutput, error)
+}
+
+// Attributes of aws_sns_topic topics, named after the Terraform schema.
+const (
+	SNSName                      = "name"
+	SNSArn                       = "arn"
+	SNSDisplayName               = "display_name"
+	SNSPolicy                    = "policy"
+	SNSDeliveryPolicy            = "delivery_policy"
+	SNSKMSMasterKeyID            = "kms_master_key_id"
+	SNSFifoTopic                 = "fifo_topic"
+	SNSContentBasedDeduplication = "content_based_deduplication"
+	SNSOwner                     = "owner"
+	SNSSignatureVersion          = "signature_version"
+	SNSTracingConfig             = "tracing_config"
+)
+
+// Attributes of aws_sns_topic_subscription subscriptions, named after the Terraform
+// schema.
+const (
+	SubscriptionArn                 = "arn"
+	SubscriptionTopicArn            = "topic_arn"
+	SubscriptionProtocol            = "protocol"
+	SubscriptionEndpoint            = "endpoint"
+	SubscriptionOwnerID             = "owner_id"
+	SubscriptionRawMessageDelivery  = "raw_message_delivery"
+	SubscriptionFilterPolicy        = "filter_policy"
+	SubscriptionFilterPolicyScope   = "filter_policy_scope"
+	SubscriptionRedrivePolicy       = "redrive_policy"
+	SubscriptionRoleArn             = "subscription_role_arn"
+	SubscriptionDeliveryPolicy      = "delivery_policy"
+	SubscriptionPendingConfirmation = "pending_confirmation"
+)
+
+// snsTopicAttributes maps the attributes of a topic to the names of the topic
+// attributes returned by GetTopicAttributes.
+var snsTopicAttributes = map[string]string{
+	SNSArn:                       "TopicArn",
+	SNSDisplayName:               "DisplayName",
+	SNSPolicy:                    "Policy",
+	SNSDeliveryPolicy:            "DeliveryPolicy",
+	SNSKMSMasterKeyID:            "KmsMasterKeyId",
+	SNSFifoTopic:                 "FifoTopic",
+	SNSContentBasedDeduplication: "ContentBasedDeduplication",
+	SNSOwner:                     "Owner",
+	SNSSignatureVersion:          "SignatureVersion",
+	SNSTracingConfig:             "TracingConfig",
+}
+
+// snsSubscriptionAttributes maps the attributes of a subscription to the names of
+// the subscription attributes returned by GetSubscriptionAttributes.
+var snsSubscriptionAttributes = map[string]string{
+	SubscriptionArn:                 "SubscriptionArn",
+	SubscriptionTopicArn:            "TopicArn",
+	SubscriptionProtocol:            "Protocol",
+	SubscriptionEndpoint:            "Endpoint",
+	SubscriptionOwnerID:             "Owner",
+	SubscriptionRawMessageDelivery:  "RawMessageDelivery",
+	SubscriptionFilterPolicy:        "FilterPolicy",
+	SubscriptionFilterPolicyScope:   "FilterPolicyScope",
+	SubscriptionRedrivePolicy:       "RedrivePolicy",
+	SubscriptionRoleArn:             "SubscriptionRoleArn",
+	SubscriptionDeliveryPolicy:      "DeliveryPolicy",
+	SubscriptionPendingConfirmation: "PendingConfirmation",
+}
+
+func init() {
+	// SNS returns the policies with its own key order and whitespace
+	driftchecker.Register("aws_sns_topic", driftchecker.ForAttributes(driftchecker.JSONEqual,
+		SNSPolicy, SNSDeliveryPolicy))
+	driftchecker.Register("aws_sns_topic_subscription", driftchecker.ForAttributes(driftchecker.JSONEqual,
+		SubscriptionFilterPolicy, SubscriptionRedrivePolicy, SubscriptionDeliveryPolicy))
+}
+
+// snsTopicValues maps the attributes returned by GetTopicAttributes and the tags of a
+// topic to the attributes of an aws_sns_topic. The FIFO attributes SNS only returns
+// for FIFO topics read as false.
+func snsTopicValues(settings map[string]string, tags map[string]string) map[string]any {
+	arn := settings[snsTopicAttributes[SNSArn]]
+	values := map[string]any{
+		SNSName: arn[strings.LastIndex(arn, ":")+1:],
+		"tags":  userTags(tags),
+	}
+	for attribute, name := range snsTopicAttributes {
+		values[attribute] = settings[name]
+	}
+	values[SNSFifoTopic] = cmp.Or(settings[snsTopicAttributes[SNSFifoTopic]], "false")
+	values[SNSContentBasedDeduplication] = cmp.Or(settings[snsTopicAttributes[SNSContentBasedDeduplication]], "false")
+	return values
+}
+
+// snsSubscriptionValues maps the attributes returned by GetSubscriptionAttributes to
+// the attributes of an aws_sns_topic_subscription. raw_message_delivery, which SNS
+// only returns for some protocols, reads as false.
+func snsSubscriptionValues(settings map[string]string) map[string]any {
+	values := map[string]any{}
+	for attribute, name := range snsSubscriptionAttributes {
+		values[attribute] = settings[name]
+	}
+	values[SubscriptionRawMessageDelivery] = cmp.Or(settings[snsSubscriptionAttributes[SubscriptionRawMessageDelivery]], "false")
+	return values
+}
+
+// HandleSNSTopicMetadata retrieves the SNS topic with the given ARN and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - arn: The ARN of the topic, the id of the resource in the state
+//
+// Returns:
+//   - *provider.GenericInfraResource: The live topic data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleSNSTopicMetadata(ctx context.Context, arn string) (*provider.GenericInfraResource, error) {
+	ctx, span := telemetry.StartSpan(ctx, "SNS.GetTopicAttributes", attribute.String("aws.sns.topic_arn", arn))
+	defer span.End()
+
+	out := &provider.GenericInfraResource{}
+	cacheKey := a.cacheKey("aws_sns_topic", arn)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*sns.GetTopicAttributesOutput, error) {
+		return a.sns().GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(arn)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to get SNS topic attributes")
+	}
+
+	tags, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*sns.ListTagsForResourceOutput, error) {
+		return a.sns().ListTagsForResource(ctx, &sns.ListTagsForResourceInput{ResourceArn: aws.String(arn)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to list SNS topic tags")
+	}
+	tagMap := map[string]string{}
+	for _, tag := range tags.Tags {
+		tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	out, err = provider.NewGenericInfraResource("aws_sns_topic", snsTopicValues(output.Attributes, tagMap))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache SNS topic metadata", "topic_arn", arn, "error", err)
+	}
+	return out, nil
+}
+
+// HandleSNSSubscriptionMetadata retrieves the SNS subscription with the given ARN.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - arn: The ARN of the subscription, the id of the resource in the state
+//
+// Returns:
+//   - *provider.GenericInfraResource: The live subscription data
+//   - error: Any error encountered during the AWS API call
+func (a *AWSProvider) HandleSNSSubscriptionMetadata(ctx context.Context, arn string) (*provider.GenericInfraResource, error) {
+	ctx, span := telemetry.StartSpan(ctx, "SNS.GetSubscriptionAttributes", attribute.String("aws.sns.subscription_arn", arn))
+	defer span.End()
+
+	out := &provider.GenericInfraResource{}
+	cacheKey := a.cacheKey("aws_sns_topic_subscription", arn)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*sns.GetSubscriptionAttributesOutput, error) {
+		return a.sns().GetSubscriptionAttributes(ctx, &sns.GetSubscriptionAttributesInput{SubscriptionArn: aws.String(arn)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to get SNS subscription attributes")
+	}
+	out, err = provider.NewGenericInfraResource("aws_sns_topic_subscription", snsSubscriptionValues(output.Attributes))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache SNS subscription metadata", "subscription_arn", arn, "error", err)
+	}
+	return out, nil
+}
+
+// listTopics returns the ARNs of every SNS topic of the region.
+func (a *AWSProvider) listTopics(ctx context.Context) ([]string, error) {
+	paginator := sns.NewListTopicsPaginator(a.sns(), &sns.ListTopicsInput{})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *sns.ListTopicsOutput) []string {
+		var arns []string
+		for _, topic := range page.Topics {
+			arns = append(arns, aws.ToString(topic.TopicArn))
+		}
+		return arns
+	})
+}
+
+// listSubscriptions returns the ARNs of every confirmed SNS subscription of the
+// region. Subscriptions pending confirmation have no ARN yet.
+func (a *AWSProvider) listSubscriptions(ctx context.Context) ([]string, error) {
+	paginator := sns.NewListSubscriptionsPaginator(a.sns(), &sns.ListSubscriptionsInput{})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *sns.ListSubscriptionsOutput) []string {
+		var arns []string
+		for _, subscription := range page.Subscriptions {
+			if arn := aws.ToString(subscription.SubscriptionArn); strings.HasPrefix(arn, "arn:") {
+				arns = append(arns, arn)
+			}
+		}
+		return arns
+	})
+}
+
+// sns returns the SNS client of the provider.
+func (a *AWSProvider) sns() SNSAPI {
+	if a.SNS != nil {
+		return a.SNS
+	}
+	return sns.NewFromConfig(a.Config)
+}
@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"cmp"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/paginate"
+	"drift-watcher/pkg/telemetry"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SQSAPI is the part of the SQS API used by the provider. It is implemented by
+// *sqs.Client, and by awstest.FakeSQS for tests that do not reach AWS.
+type SQSAPI interface {
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	ListQueues(ctx context.Context, params *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error)
+	ListQueueTags(ctx context.Context, params *sqs.ListQueueTagsInput, optFns ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error)
+}
+
+// Attributes of aws_sqs_queue queues, named after the Terraform schema.
+const (
+	SQSName                      = "name"
+	SQSArn                       = "arn"
+	SQSURL                       = "url"
+	SQSVisibilityTimeout         = "visibility_timeout_seconds"
+	SQSMessageRetention          = "message_retention_seconds"
+	SQSMaxMessageSize            = "max_message_size"
+	SQSDelaySeconds              = "delay_seconds"
+	SQSReceiveWaitTime           = "receive_wait_time_seconds"
+	SQSRedrivePolicy             = "redrive_policy"
+	SQSRedriveAllowPolicy        = "redrive_allow_policy"
+	SQSPolicy                    = "policy"
+	SQSKMSMasterKeyID            = "kms_master_key_id"
+	SQSKMSDataKeyReusePeriod     = "kms_data_key_reuse_period_seconds"
+	SQSManagedSSEEnabled         = "sqs_managed_sse_enabled"
+	SQSFifoQueue                 = "fifo_queue"
+	SQSContentBasedDeduplication = "content_based_deduplication"
+	SQSDeduplicationScope        = "deduplication_scope"
+	SQSFifoThroughputLimit       = "fifo_throughput_limit"
+)
+
+// sqsQueueAttributes maps the attributes of a queue to the names of the queue
+// attributes returned by GetQueueAttributes.
+var sqsQueueAttributes = map[string]sqstypes.QueueAttributeName{
+	SQSArn:                       sqstypes.QueueAttributeNameQueueArn,
+	SQSVisibilityTimeout:         sqstypes.QueueAttributeNameVisibilityTimeout,
+	SQSMessageRetention:          sqstypes.QueueAttributeNameMessageRetentionPeriod,
+	SQSMaxMessageSize:            sqstypes.QueueAttributeNameMaximumMessageSize,
+	SQSDelaySeconds:              sqstypes.QueueAttributeNameDelaySeconds,
+	SQSReceiveWaitTime:           sqstypes.QueueAttributeNameReceiveMessageWaitTimeSeconds,
+	SQSRedrivePolicy:             sqstypes.QueueAttributeNameRedrivePolicy,
+	SQSRedriveAllowPolicy:        sqstypes.QueueAttributeNameRedriveAllowPolicy,
+	SQSPolicy:                    sqstypes.QueueAttributeNamePolicy,
+	SQSKMSMasterKeyID:            sqstypes.QueueAttributeNameKmsMasterKeyId,
+	SQSKMSDataKeyReusePeriod:     sqstypes.QueueAttributeNameKmsDataKeyReusePeriodSeconds,
+	SQSManagedSSEEnabled:         sqstypes.QueueAttributeNameSqsManagedSseEnabled,
+	SQSFifoQueue:                 sqstypes.QueueAttributeNameFifoQueue,
+	SQSContentBasedDeduplication: sqstypes.QueueAttributeNameContentBasedDeduplication,
+	SQSDeduplicationScope:        sqstypes.QueueAttributeNameDeduplicationScope,
+	SQSFifoThroughputLimit:       sqstypes.QueueAttributeNameFifoThroughputLimit,
+}
+
+func init() {
+	// SQS returns the policies with its own key order and whitespace
+	driftchecker.Register("aws_sqs_queue", driftchecker.ForAttributes(driftchecker.JSONEqual,
+		SQSRedrivePolicy, SQSRedriveAllowPolicy, SQSPolicy))
+}
+
+// sqsQueueValues maps the attributes returned by GetQueueAttributes and the tags of
+// the queue with the given URL to the attributes of an aws_sqs_queue. The FIFO
+// attributes SQS only returns for FIFO queues read as false.
+func sqsQueueValues(url string, settings map[string]string, tags map[string]string) map[string]any {
+	values := map[string]any{
+		SQSName: path.Base(url),
+		SQSURL:  url,
+		"tags":  userTags(tags),
+	}
+	for attribute, name := range sqsQueueAttributes {
+		values[attribute] = settings[string(name)]
+	}
+	values[SQSFifoQueue] = cmp.Or(settings[string(sqstypes.QueueAttributeNameFifoQueue)], "false")
+	values[SQSContentBasedDeduplication] = cmp.Or(settings[string(sqstypes.QueueAttributeNameContentBasedDeduplication)], "false")
+	return values
+}
+
+// HandleSQSQueueMetadata retrieves the SQS queue with the given URL and its tags.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - url: The URL of the queue, the id of the resource in the state
+//
+// Returns:
+//   - *provider.GenericInfraResource: The live queue data
+//   - error: Any error encountered during the AWS API calls
+func (a *AWSProvider) HandleSQSQueueMetadata(ctx context.Context, url string) (*provider.GenericInfraResource, error) {
+	ctx, span := telemetry.StartSpan(ctx, "SQS.GetQueueAttributes", attribute.String("aws.sqs.queue_url", url))
+	defer span.End()
+
+	out := &provider.GenericInfraResource{}
+	cacheKey := a.cacheKey("aws_sqs_queue", url)
+	if a.cache.Get(ctx, cacheKey, out) {
+		span.SetAttributes(attribute.Bool("drift.cache_hit", true))
+		return out, nil
+	}
+
+	output, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*sqs.GetQueueAttributesOutput, error) {
+		return a.sqs().GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(url),
+			AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameAll},
+		})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to get SQS queue attributes")
+	}
+	tags, err := paginate.Call(ctx, a.calls(), func(ctx context.Context) (*sqs.ListQueueTagsOutput, error) {
+		return a.sqs().ListQueueTags(ctx, &sqs.ListQueueTagsInput{QueueUrl: aws.String(url)})
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, errors.Wrap(err, "Failed to list SQS queue tags")
+	}
+	out, err = provider.NewGenericInfraResource("aws_sqs_queue", sqsQueueValues(url, output.Attributes, tags.Tags))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.cache.Set(ctx, cacheKey, out); err != nil {
+		logger(ctx).Debug("failed to cache SQS queue metadata", "queue_url", url, "error", err)
+	}
+	return out, nil
+}
+
+// listQueues returns the URLs of every SQS queue of the region.
+func (a *AWSProvider) listQueues(ctx context.Context) ([]string, error) {
+	paginator := sqs.NewListQueuesPaginator(a.sqs(), &sqs.ListQueuesInput{
+		MaxResults: a.sqsPageSize(),
+	})
+	return paginate.Collect(ctx, paginator, a.calls(), func(page *sqs.ListQueuesOutput) []string {
+		return page.QueueUrls
+	})
+}
+
+// sqsPageSize returns the page size of ListQueues, which accepts at most 1000
+// results per page, or nil for the API default when PageSize is not set.
+func (a *AWSProvider) sqsPageSize() *int32 {
+	if a.PageSize <= 0 {
+		return nil
+	}
+	return aws.Int32(min(a.PageSize, 1000))
+}
+
+// sqs returns the SQS client of the provider.
+func (a *AWSProvider) sqs() SQSAPI {
+	if a.SQS != nil {
+		return a.SQS
+	}
+	return sqs.NewFromConfig(a.Config)
+}
@@ -0,0 +1,15 @@
+package aws
+
+import "strings"
+
+// userTags returns tags without the tags with the reserved aws: prefix, which are
+// added by AWS and never in the state.
+func userTags(tags map[string]string) map[string]string {
+	user := map[string]string{}
+	for key, value := range tags {
+		if !strings.HasPrefix(key, "aws:") {
+			user[key] = value
+		}
+	}
+	return user
+}
@@ -1,9 +1,9 @@
 package aws_test
 
 import (
-	"bytes"
+	"context"
+	"drift-watcher/pkg/logging/loggingtest"
 	awsProvider "drift-watcher/pkg/services/provider/aws"
-	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
@@ -28,14 +28,6 @@ func createAwsConfigFiles(t *testing.T, dir string, credsContent, configContent
 	return credsPath, configPath
 }
 
-// Helper to capture slog output
-func captureSlogOutput() *bytes.Buffer {
-	var buf bytes.Buffer
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
-	return &buf
-}
-
 func TestCheckAWSConfig_DefaultPathsFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	homeDir := tmpDir // Use temp dir as home dir for this test
@@ -48,7 +40,7 @@ func TestCheckAWSConfig_DefaultPathsFound(t *testing.T) {
 
 	createAwsConfigFiles(t, filepath.Join(homeDir, ".aws"), "[default]\naws_access_key_id = test", "[profile default]\nregion = us-east-1")
 
-	cfg, err := awsProvider.CheckAWSConfig(homeDir, "")
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), homeDir, "")
 	require.NoError(t, err)
 
 	assert.Len(t, cfg.CredentialPath, 1)
@@ -73,8 +65,8 @@ func TestCheckAWSConfig_EnvVarsOverride(t *testing.T) {
 	defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
 	defer os.Unsetenv("AWS_CONFIG_FILE")
 
-	buf := captureSlogOutput()
-	cfg, err := awsProvider.CheckAWSConfig("/nonexistent/home", "my-profile") // Use non-existent home to ensure env vars are picked
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, "/nonexistent/home", "my-profile") // Use non-existent home to ensure env vars are picked
 	require.NoError(t, err)
 
 	assert.Len(t, cfg.CredentialPath, 1)
@@ -87,13 +79,27 @@ func TestCheckAWSConfig_EnvVarsOverride(t *testing.T) {
 	assert.Contains(t, buf.String(), "AWS config file found via AWS_CONFIG_FILE")
 }
 
+func TestCheckAWSConfig_EnvVarsRelativeToHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	createAwsConfigFiles(t, home, "[default]\naws_access_key_id = env_test", "[profile default]\nregion = us-east-1")
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "~/credentials")
+	t.Setenv("AWS_CONFIG_FILE", `%USERPROFILE%\config`)
+
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), "/nonexistent/home", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(home, "credentials")}, cfg.CredentialPath)
+	assert.Equal(t, []string{filepath.Join(home, "config")}, cfg.ConfigPath)
+}
+
 func TestCheckAWSConfig_HomeDirError(t *testing.T) {
 	dir := os.TempDir()
 
-	buf := captureSlogOutput()
-	cfg, err := awsProvider.CheckAWSConfig(dir, "")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Either configuration or credential path is missing")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, dir, "")
+	require.NoError(t, err, "credentials fall back to the default chain")
 	assert.Empty(t, cfg.CredentialPath)
 	assert.Empty(t, cfg.ConfigPath)
 	assert.Contains(t, buf.String(), "Default AWS config file not found")
@@ -107,11 +113,12 @@ func TestCheckAWSConfig_DefaultCredsNotFound(t *testing.T) {
 	// Only create config file, not creds
 	createAwsConfigFiles(t, filepath.Join(homeDir, ".aws"), "", "[profile default]\nregion = us-east-1")
 
-	buf := captureSlogOutput()
-	_, err := awsProvider.CheckAWSConfig(homeDir, "")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Either configuration or credential path is missing")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, homeDir, "")
+	require.NoError(t, err, "SSO profiles have a config file but no credentials file")
+	assert.Len(t, cfg.ConfigPath, 1)
 	assert.Contains(t, buf.String(), "Default AWS credentials file not found")
+	assert.Contains(t, buf.String(), "using the default credential chain")
 }
 
 func TestCheckAWSConfig_DefaultConfigNotFound(t *testing.T) {
@@ -122,10 +129,10 @@ func TestCheckAWSConfig_DefaultConfigNotFound(t *testing.T) {
 	// Only create creds file, not config
 	createAwsConfigFiles(t, filepath.Join(homeDir, ".aws"), "[default]\naws_access_key_id = test", "")
 
-	buf := captureSlogOutput()
-	_, err := awsProvider.CheckAWSConfig(homeDir, "")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Either configuration or credential path is missing")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, homeDir, "")
+	require.NoError(t, err)
+	assert.Len(t, cfg.CredentialPath, 1)
 	assert.Contains(t, buf.String(), "Default AWS config file not found")
 }
 
@@ -139,8 +146,8 @@ func TestCheckAWSConfig_EnvCredsFileNotExist(t *testing.T) {
 	os.MkdirAll(awsDir, 0755)
 	createAwsConfigFiles(t, awsDir, "[default]\naws_access_key_id = test", "[profile default]\nregion = us-east-1")
 
-	buf := captureSlogOutput()
-	cfg, err := awsProvider.CheckAWSConfig(homeDir, "")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, homeDir, "")
 	require.NoError(t, err) // Should still succeed if default files exist
 	assert.Contains(t, buf.String(), "AWS_SHARED_CREDENTIALS_FILE environment variable points to a non-existent file")
 	assert.Len(t, cfg.CredentialPath, 1) // Should still have the default path
@@ -156,8 +163,8 @@ func TestCheckAWSConfig_EnvConfigFileNotExist(t *testing.T) {
 	os.MkdirAll(awsDir, 0755)
 	createAwsConfigFiles(t, awsDir, "[default]\naws_access_key_id = test", "[profile default]\nregion = us-east-1")
 
-	buf := captureSlogOutput()
-	cfg, err := awsProvider.CheckAWSConfig(homeDir, "")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, homeDir, "")
 	require.NoError(t, err) // Should still succeed if default files exist
 	assert.Contains(t, buf.String(), "AWS_CONFIG_FILE environment variable points to a non-existent file")
 	assert.Len(t, cfg.ConfigPath, 1) // Should still have the default path
@@ -171,10 +178,10 @@ func TestCheckAWSConfig_NoPathsFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	// Do not create .aws directory or any files
 
-	buf := captureSlogOutput()
-	cfg, err := awsProvider.CheckAWSConfig(tmpDir, "")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Either configuration or credential path is missing")
+	ctx, buf := loggingtest.Capture()
+	cfg, err := awsProvider.CheckAWSConfig(ctx, tmpDir, "")
+	require.NoError(t, err, "instance and container roles need no files")
+	assert.Equal(t, "default", cfg.ProfileName)
 	assert.Contains(t, buf.String(), "Default AWS credentials file not found")
 	assert.Contains(t, buf.String(), "Default AWS config file not found")
 	assert.Empty(t, cfg.CredentialPath)
@@ -188,7 +195,72 @@ func TestCheckAWSConfig_CustomProfileName(t *testing.T) {
 	os.MkdirAll(awsDir, 0755)
 	createAwsConfigFiles(t, awsDir, "[default]\naws_access_key_id = test", "[profile default]\nregion = us-east-1")
 
-	cfg, err := awsProvider.CheckAWSConfig(homeDir, "my-custom-profile")
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), homeDir, "my-custom-profile")
 	require.NoError(t, err)
 	assert.Equal(t, "my-custom-profile", cfg.ProfileName)
 }
+
+func TestCheckAWSConfig_ProfileFromEnvironment(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "sso-admin")
+
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "sso-admin", cfg.ProfileName)
+
+	cfg, err = awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", cfg.ProfileName)
+	assert.True(t, cfg.ExplicitProfile)
+
+	t.Setenv("AWS_PROFILE", "")
+	cfg, err = awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "default", cfg.ProfileName)
+	assert.False(t, cfg.ExplicitProfile)
+}
+
+func TestLoadConfig_ExplicitDefaultProfile(t *testing.T) {
+	dir := t.TempDir()
+	createAwsConfigFiles(t, dir,
+		"[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = secret\n[sso-admin]\naws_access_key_id = AKIDADMIN\naws_secret_access_key = secret",
+		"[default]\nregion = us-east-1\n[profile sso-admin]\nregion = eu-west-1")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_PROFILE", "sso-admin")
+
+	// --awsprofile default is not overridden by AWS_PROFILE
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "default")
+	require.NoError(t, err)
+	sdkConfig, err := awsProvider.LoadConfig(&cfg)
+	require.NoError(t, err)
+	creds, err := sdkConfig.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDDEFAULT", creds.AccessKeyID)
+
+	cfg, err = awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "")
+	require.NoError(t, err)
+	sdkConfig, err = awsProvider.LoadConfig(&cfg)
+	require.NoError(t, err)
+	creds, err = sdkConfig.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDADMIN", creds.AccessKeyID)
+}
+
+func TestLoadConfig_CredentialsFromEnvironment(t *testing.T) {
+	// without shared files, the default chain reads the environment
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-central-1")
+
+	cfg, err := awsProvider.CheckAWSConfig(context.Background(), t.TempDir(), "")
+	require.NoError(t, err)
+	sdkConfig, err := awsProvider.LoadConfig(&cfg)
+	require.NoError(t, err)
+
+	creds, err := sdkConfig.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDEXAMPLE", creds.AccessKeyID)
+	assert.Equal(t, "eu-central-1", sdkConfig.Region)
+}
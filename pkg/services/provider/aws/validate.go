@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// CheckCredentials verifies that AWS credentials can be resolved for the configured
+// profile. No AWS API is called other than what the credential chain itself needs,
+// such as SSO or the instance metadata service.
+func (a *AWSProvider) CheckCredentials(ctx context.Context) error {
+	if a.Config.Credentials == nil {
+		return fmt.Errorf("no AWS credentials configured")
+	}
+	creds, err := a.Config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve AWS credentials")
+	}
+	if !creds.HasKeys() {
+		return fmt.Errorf("AWS credentials resolved without an access key")
+	}
+	if a.Config.Region == "" {
+		return fmt.Errorf("no AWS region configured, set one in the profile or with AWS_REGION")
+	}
+	return nil
+}
+
+// SupportedAttributes returns the attributes that can be checked for resourceType.
+func (a *AWSProvider) SupportedAttributes(resourceType string) ([]string, error) {
+	attributes, ok := supportedAttributes[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("%s resource not yet supported for AWS provider", resourceType)
+	}
+	return attributes, nil
+}
+
+// ResourceTypes returns the resource types the AWS provider supports, sorted. It
+// needs no credentials, for shell completion and documentation.
+func ResourceTypes() []string {
+	return slices.Sorted(maps.Keys(supportedAttributes))
+}
+
+// ResourceAttributes returns the attributes that can be checked for resourceType, or
+// nil if the type is not supported. Like ResourceTypes, it needs no credentials.
+func ResourceAttributes(resourceType string) []string {
+	return supportedAttributes[resourceType]
+}
@@ -0,0 +1,85 @@
+// Package breaker provides a circuit breaker for calls to a platform API. After a
+// number of consecutive failures the breaker opens and calls fail fast for a cooldown
+// period, so an unreachable region degrades a scan gracefully instead of making every
+// remaining resource wait for its own timeouts.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open: the platform API is unreachable, skipping call")
+
+// Breaker counts consecutive failures and opens once Threshold is reached. A nil
+// Breaker allows every call.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	// Trips reports whether an error counts as a failure. Every error counts when nil.
+	Trips func(error) bool
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+// New creates a new Breaker instance.
+// threshold: The number of consecutive failures that open the breaker.
+// cooldown: How long the breaker stays open before a call is let through again.
+// trips: Reports whether an error counts as a failure; nil counts every error.
+func New(threshold int, cooldown time.Duration, trips func(error) bool) *Breaker {
+	return &Breaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		Trips:     trips,
+		now:       time.Now,
+	}
+}
+
+// Allow returns ErrOpen while the breaker is open and nil otherwise. Once the
+// cooldown has passed, calls are let through again; a further failure reopens the
+// breaker immediately.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.clock().Before(b.openUntil) {
+		return ErrOpen
+	}
+	return nil
+}
+
+// Record records the outcome of a call that Allow let through.
+func (b *Breaker) Record(err error) {
+	if b == nil || b.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || (b.Trips != nil && !b.Trips(err)) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openUntil = b.clock().Add(b.Cooldown)
+		// keep the count at the threshold so the first failure after the cooldown reopens it
+		b.failures = b.Threshold - 1
+	}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
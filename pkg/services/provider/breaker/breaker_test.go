@@ -0,0 +1,51 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := New(3, time.Minute, nil)
+	b.now = func() time.Time { return now }
+
+	failure := errors.New("dial tcp: no such host")
+	for range 2 {
+		assert.NoError(t, b.Allow())
+		b.Record(failure)
+	}
+	assert.NoError(t, b.Allow(), "breaker stays closed below the threshold")
+
+	b.Record(failure)
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow(), "a call is let through after the cooldown")
+	b.Record(failure)
+	assert.ErrorIs(t, b.Allow(), ErrOpen, "a failure after the cooldown reopens the breaker")
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	b := New(2, time.Minute, nil)
+	b.Record(errors.New("timeout"))
+	b.Record(nil)
+	b.Record(errors.New("timeout"))
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_IgnoresErrorsThatDoNotTrip(t *testing.T) {
+	apiErr := errors.New("InvalidInstanceID.NotFound")
+	b := New(1, time.Minute, func(err error) bool { return !errors.Is(err, apiErr) })
+	b.Record(apiErr)
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_Nil(t *testing.T) {
+	var b *Breaker
+	b.Record(errors.New("boom"))
+	assert.NoError(t, b.Allow())
+}
@@ -0,0 +1,173 @@
+// Package cache provides a TTL cache for live infrastructure metadata. Entries are
+// kept in memory and, when a directory is configured, on disk so that repeated runs
+// within the TTL don't query the platform API again for the same resource.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"drift-watcher/pkg/logging"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is the stored form of a cached value.
+type entry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// Cache is a TTL cache of JSON encodable values. A nil Cache never holds a value,
+// so callers can use it unconditionally.
+type Cache struct {
+	TTL time.Duration
+	// Dir is the directory entries are persisted to. Entries are only held in memory
+	// when it is empty.
+	Dir string
+
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+// New creates a new Cache instance.
+// ttl: How long an entry is served after it was stored. A ttl of zero or less
+// disables caching and New returns nil.
+// dir: The directory entries are persisted to, or empty to keep them in memory only.
+func New(ttl time.Duration, dir string) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Cache{
+		TTL:     ttl,
+		Dir:     dir,
+		entries: map[string]entry{},
+		now:     time.Now,
+	}
+}
+
+// Get decodes the value stored under key into v. It reports whether a value that
+// has not expired was found.
+func (c *Cache) Get(ctx context.Context, key string, v any) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		e, ok = c.readFile(key)
+		if !ok {
+			return false
+		}
+		c.entries[key] = e
+	}
+	if c.clock().Sub(e.StoredAt) >= c.TTL {
+		c.delete(ctx, key)
+		return false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		logger(ctx).Debug("discarding unreadable cache entry", "key", key, "error", err)
+		c.delete(ctx, key)
+		return false
+	}
+	return true
+}
+
+// Set stores v under key.
+func (c *Cache) Set(ctx context.Context, key string, v any) error {
+	if c == nil {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := entry{StoredAt: c.clock(), Value: value}
+	c.entries[key] = e
+	return c.writeFile(key, e)
+}
+
+// Delete removes the value stored under key, e.g. after the resource was changed.
+func (c *Cache) Delete(ctx context.Context, key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delete(ctx, key)
+}
+
+func (c *Cache) delete(ctx context.Context, key string) {
+	delete(c.entries, key)
+	if c.Dir == "" {
+		return
+	}
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger(ctx).Debug("failed to remove cache entry", "key", key, "error", err)
+	}
+}
+
+func (c *Cache) readFile(key string) (entry, bool) {
+	if c.Dir == "" {
+		return entry{}, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (c *Cache) writeFile(key string, e entry) error {
+	if c.Dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache folder: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// path returns the file an entry is persisted to. Keys are hashed so that any
+// key, whatever characters it contains, maps to a valid file name.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
+
+// logger returns the logger carried by ctx for the cache module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "cache")
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type instance struct {
+	ID   string
+	Type string
+}
+
+func TestCache_GetSet(t *testing.T) {
+	c := New(time.Minute, "")
+	require.NoError(t, c.Set(context.Background(), "us-east-1/i-123", instance{ID: "i-123", Type: "t2.micro"}))
+
+	var got instance
+	assert.True(t, c.Get(context.Background(), "us-east-1/i-123", &got))
+	assert.Equal(t, instance{ID: "i-123", Type: "t2.micro"}, got)
+	assert.False(t, c.Get(context.Background(), "eu-west-1/i-123", &got))
+}
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := New(time.Minute, "")
+	c.now = func() time.Time { return now }
+	require.NoError(t, c.Set(context.Background(), "key", instance{ID: "i-123"}))
+
+	now = now.Add(time.Minute)
+	var got instance
+	assert.False(t, c.Get(context.Background(), "key", &got))
+}
+
+func TestCache_PersistsToDisk(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, New(time.Minute, dir).Set(context.Background(), "us-east-1/i-123", instance{ID: "i-123"}))
+
+	var got instance
+	assert.True(t, New(time.Minute, dir).Get(context.Background(), "us-east-1/i-123", &got), "a new cache reads entries persisted by an earlier run")
+	assert.Equal(t, "i-123", got.ID)
+}
+
+func TestCache_Delete(t *testing.T) {
+	dir := t.TempDir()
+	c := New(time.Minute, dir)
+	require.NoError(t, c.Set(context.Background(), "key", instance{ID: "i-123"}))
+	c.Delete(context.Background(), "key")
+
+	var got instance
+	assert.False(t, c.Get(context.Background(), "key", &got))
+	assert.False(t, New(time.Minute, dir).Get(context.Background(), "key", &got))
+}
+
+func TestCache_Disabled(t *testing.T) {
+	c := New(0, "")
+	assert.Nil(t, c)
+	assert.NoError(t, c.Set(context.Background(), "key", instance{}))
+	var got instance
+	assert.False(t, c.Get(context.Background(), "key", &got))
+}
@@ -0,0 +1,220 @@
+// Package chaos wraps a provider to inject faults into its calls, so tests can check
+// how a scan copes with a slow, throttling or misbehaving platform API without
+// depending on one. Faults are picked by call number, resource type or address, so a
+// test injects the same faults on every run:
+//
+//	p := chaos.Wrap(next).
+//		Inject(chaos.OnCalls(2, 3), chaos.Throttle()).
+//		Inject(chaos.ForAddress("aws_instance.web"), chaos.Latency(time.Second)).
+//		Inject(chaos.ForType("aws_instance"), chaos.Malformed())
+//
+// It is a test support package and is not used by the drift-watcher binary.
+package chaos
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrMalformed is returned when an attribute of a resource returned by a Malformed
+// call is read.
+var ErrMalformed = errors.New("malformed API response")
+
+// Call describes a call to the provider. N counts the calls made through the
+// wrapper, from 1.
+type Call struct {
+	N            int
+	ResourceType string
+	Resource     statemanager.StateResource
+}
+
+// Matcher selects the calls a fault is injected into.
+type Matcher func(call Call) bool
+
+// Always matches every call.
+func Always() Matcher {
+	return func(Call) bool { return true }
+}
+
+// OnCalls matches the calls with the given numbers.
+func OnCalls(n ...int) Matcher {
+	return func(call Call) bool { return slices.Contains(n, call.N) }
+}
+
+// FirstCalls matches the first n calls.
+func FirstCalls(n int) Matcher {
+	return func(call Call) bool { return call.N <= n }
+}
+
+// EveryNth matches every nth call: n, 2n, 3n...
+func EveryNth(n int) Matcher {
+	return func(call Call) bool { return n > 0 && call.N%n == 0 }
+}
+
+// ForType matches the calls for resources of resourceType.
+func ForType(resourceType string) Matcher {
+	return func(call Call) bool { return call.ResourceType == resourceType }
+}
+
+// ForAddress matches the calls for the resource with the given address, e.g.
+// aws_instance.web or module.app.aws_instance.web[0].
+func ForAddress(address string) Matcher {
+	return func(call Call) bool { return call.Resource.Address() == address }
+}
+
+// Fault is injected into the calls its matcher selects. The call waits for Latency,
+// then fails with Err, or else is made and its resource replaced with Malform's.
+type Fault struct {
+	Latency time.Duration
+	Err     error
+	Malform func(resource provider.InfrastructureResourceI) provider.InfrastructureResourceI
+}
+
+// Latency delays the call by d. A call whose context ends first fails with the
+// context's error, like a call to a slow API.
+func Latency(d time.Duration) Fault {
+	return Fault{Latency: d}
+}
+
+// Fail fails the call with err.
+func Fail(err error) Fault {
+	return Fault{Err: err}
+}
+
+// Throttle fails the call with an API throttling error, as AWS returns once the
+// request rate of an account is exceeded.
+func Throttle() Fault {
+	return Fault{Err: &smithy.GenericAPIError{
+		Code:    "ThrottlingException",
+		Message: "Rate exceeded",
+		Fault:   smithy.FaultServer,
+	}}
+}
+
+// Malformed makes the call, but every attribute read from the resource it returns
+// fails with ErrMalformed, as when the API returns a response that cannot be parsed.
+func Malformed() Fault {
+	return Fault{Malform: func(resource provider.InfrastructureResourceI) provider.InfrastructureResourceI {
+		return malformedResource{resourceType: resource.ResourceType()}
+	}}
+}
+
+type injection struct {
+	match Matcher
+	fault Fault
+}
+
+// Provider implements provider.ProviderI by injecting faults into the calls made to
+// Next. Every fault whose matcher selects a call is injected, in the order they were
+// added: their latencies add up and the first error is returned. It is safe for
+// concurrent use.
+type Provider struct {
+	Next provider.ProviderI
+
+	mu         sync.Mutex
+	injections []injection
+	calls      []Call
+	injected   int
+}
+
+// Wrap creates a Provider injecting faults into the calls made to next.
+func Wrap(next provider.ProviderI) *Provider {
+	return &Provider{Next: next}
+}
+
+// Inject injects fault into the calls selected by match, and returns p so injections
+// can be chained.
+func (p *Provider) Inject(match Matcher, fault Fault) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.injections = append(p.injections, injection{match: match, fault: fault})
+	return p
+}
+
+// InfrastructreMetadata injects the faults selected for the call into a call to Next.
+func (p *Provider) InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (provider.InfrastructureResourceI, error) {
+	p.mu.Lock()
+	call := Call{N: len(p.calls) + 1, ResourceType: resourceType, Resource: resource}
+	p.calls = append(p.calls, call)
+	var faults []Fault
+	for _, injection := range p.injections {
+		if injection.match(call) {
+			faults = append(faults, injection.fault)
+		}
+	}
+	if len(faults) > 0 {
+		p.injected++
+	}
+	p.mu.Unlock()
+
+	var latency time.Duration
+	var err error
+	for _, fault := range faults {
+		latency += fault.Latency
+		if err == nil {
+			err = fault.Err
+		}
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := p.Next.InfrastructreMetadata(ctx, resourceType, resource)
+	if err != nil {
+		return nil, err
+	}
+	for _, fault := range faults {
+		if fault.Malform != nil {
+			live = fault.Malform(live)
+		}
+	}
+	return live, nil
+}
+
+// Calls returns the calls made through p, in order.
+func (p *Provider) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.calls)
+}
+
+// Injected returns the number of calls a fault was injected into.
+func (p *Provider) Injected() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.injected
+}
+
+// malformedResource is a resource read from a response that could not be parsed.
+type malformedResource struct {
+	resourceType string
+}
+
+func (r malformedResource) ResourceType() string {
+	return r.resourceType
+}
+
+func (r malformedResource) AttributeValue(attribute string) (string, error) {
+	return "", fmt.Errorf("failed to read %s: %w", attribute, ErrMalformed)
+}
+
+func (r malformedResource) Attributes() (map[string]string, error) {
+	return nil, ErrMalformed
+}
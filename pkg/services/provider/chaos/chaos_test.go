@@ -0,0 +1,126 @@
+package chaos_test
+
+import (
+	"context"
+	"drift-watcher/pkg/driftwatcher"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/driftchecker/driftcheckerfakes"
+	"drift-watcher/pkg/services/provider/chaos"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/statemanagerfakes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resource(name string) statemanager.StateResource {
+	return statemanager.StateResource{Type: "aws_instance", Name: name}
+}
+
+func liveProvider() *providerfakes.FakeProviderI {
+	next := &providerfakes.FakeProviderI{}
+	live := &providerfakes.FakeInfrastructureResourceI{}
+	live.ResourceTypeReturns("aws_instance")
+	live.AttributeValueReturns("t2.micro", nil)
+	next.InfrastructreMetadataReturns(live, nil)
+	return next
+}
+
+func TestProvider_Throttle(t *testing.T) {
+	next := liveProvider()
+	p := chaos.Wrap(next).Inject(chaos.OnCalls(2), chaos.Throttle())
+
+	_, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	require.NoError(t, err)
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	var apiErr smithy.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ThrottlingException", apiErr.ErrorCode())
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, next.InfrastructreMetadataCallCount(), "a failed call does not reach the provider")
+	assert.Len(t, p.Calls(), 3)
+	assert.Equal(t, 1, p.Injected())
+}
+
+func TestProvider_Latency(t *testing.T) {
+	p := chaos.Wrap(liveProvider()).Inject(chaos.ForAddress("aws_instance.slow"), chaos.Latency(time.Hour))
+
+	_, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource("fast"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.InfrastructreMetadata(ctx, "aws_instance", resource("slow"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProvider_Malformed(t *testing.T) {
+	p := chaos.Wrap(liveProvider()).Inject(chaos.ForType("aws_instance"), chaos.Malformed())
+
+	live, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	require.NoError(t, err)
+	assert.Equal(t, "aws_instance", live.ResourceType())
+	_, err = live.AttributeValue("instance_type")
+	assert.ErrorIs(t, err, chaos.ErrMalformed)
+	_, err = live.Attributes()
+	assert.ErrorIs(t, err, chaos.ErrMalformed)
+}
+
+func TestProvider_FaultsCombine(t *testing.T) {
+	failure := errors.New("connection reset")
+	p := chaos.Wrap(liveProvider()).
+		Inject(chaos.EveryNth(2), chaos.Fail(failure)).
+		Inject(chaos.FirstCalls(2), chaos.Throttle())
+
+	_, err := p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	var apiErr smithy.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	_, err = p.InfrastructreMetadata(context.Background(), "aws_instance", resource("web"))
+	assert.ErrorIs(t, err, failure, "the first matching fault's error is returned")
+}
+
+func TestProvider_ScanContinuesPastFaults(t *testing.T) {
+	stateManager := &statemanagerfakes.FakeStateManagerI{}
+	stateManager.RetrieveResourcesReturns([]statemanager.StateResource{
+		resource("a"), resource("b"), resource("c"), resource("d"), resource("e"), resource("f"),
+	}, nil)
+	checker := &driftcheckerfakes.FakeDriftChecker{}
+	checker.CompareStatesReturns(reporter.CreateDummyDriftReport(false), nil)
+	output := &reporterfakes.FakeOutputWriter{}
+
+	p := chaos.Wrap(liveProvider()).
+		Inject(chaos.ForAddress("aws_instance.b"), chaos.Throttle()).
+		Inject(chaos.ForAddress("aws_instance.d"), chaos.Latency(time.Hour))
+
+	err := driftwatcher.RunDriftDetection(context.Background(), "state.tfstate", "aws_instance", []string{"instance_type"},
+		stateManager, p, checker, output,
+		driftwatcher.WithConcurrency(2),
+		driftwatcher.WithResourceTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	assert.Len(t, p.Calls(), 6)
+	assert.Equal(t, 2, p.Injected())
+	assert.Equal(t, 4, checker.CompareStatesCallCount(), "the throttled and the hung resource are not compared")
+	assert.Equal(t, 6, output.WriteReportCallCount(), "the throttled and the hung resource are reported as failed checks")
+	failures := map[string]string{}
+	for i := range output.WriteReportCallCount() {
+		_, report := output.WriteReportArgsForCall(i)
+		assert.NotEqual(t, driftchecker.Partial, report.Status)
+		if report.Status == driftchecker.CheckFailed {
+			failures[report.ResourceAddress] = report.Failure.Class
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"aws_instance.b": driftchecker.FailureThrottled,
+		"aws_instance.d": driftchecker.FailureTimeout,
+	}, failures)
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GenericInfraResource is an InfrastructureResourceI over a map of live attributes,
+// for resource types whose attributes are a plain mapping of what the API returns.
+// A provider only has to describe the resource and fill the map, keyed by the
+// attribute names of the state:
+//
+//	resource, err := provider.NewGenericInfraResource("aws_sqs_queue", map[string]any{
+//		"visibility_timeout_seconds": attributes["VisibilityTimeout"],
+//		"tags":                       tags,
+//	})
+//
+// Nested blocks are stored as lists of objects, as in the state, and looked up with
+// attribute paths such as scaling_config.desired_size. Maps, such as tags, are read
+// one key at a time, e.g. tags.Name.
+type GenericInfraResource struct {
+	Type   string         `json:"type"`
+	Values map[string]any `json:"values"`
+}
+
+// NewGenericInfraResource creates a GenericInfraResource of resourceType from values.
+// Values are normalized to their JSON form, so that any number, slice or map type can
+// be stored and values read back from the cache compare the same.
+func NewGenericInfraResource(resourceType string, values map[string]any) (*GenericInfraResource, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s attributes: %w", resourceType, err)
+	}
+	normalized := map[string]any{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to decode %s attributes: %w", resourceType, err)
+	}
+	return &GenericInfraResource{Type: resourceType, Values: normalized}, nil
+}
+
+func (r *GenericInfraResource) ResourceType() string {
+	return r.Type
+}
+
+// AttributeValue retrieves the string value of an attribute path, rendered with
+// attrpath.Format. The first key of the path must be an attribute of the resource;
+// a key or list element missing below it reads as an empty string, like a tag that
+// is not set. A map key holding dots, such as tags.kubernetes.io/role, is read whole.
+func (r *GenericInfraResource) AttributeValue(attribute string) (string, error) {
+	p, err := attrpath.Parse(attribute)
+	if err != nil {
+		return "", err
+	}
+	value, ok := r.Values[p[0].Key]
+	if p[0].IsIndex || !ok {
+		return "", fmt.Errorf("'%s' attribute is not supported for %s or is an invalid attribute name", attribute, r.Type)
+	}
+	rest := p[1:]
+	if object, ok := value.(map[string]any); ok && len(rest) > 0 {
+		if key, ok := rest.Dotted(); ok {
+			if found, ok := object[key]; ok {
+				return attrpath.Format(found)
+			}
+		}
+	}
+	found, ok := attrpath.Lookup(value, rest)
+	if !ok {
+		return "", nil
+	}
+	return attrpath.Format(found)
+}
+
+// Attributes returns every attribute of the resource, with one KEY.SUBKEY attribute
+// per key of a map such as tags.
+func (r *GenericInfraResource) Attributes() (map[string]string, error) {
+	attributes := map[string]string{}
+	var errs []error
+	add := func(name string, value any) {
+		formatted, err := attrpath.Format(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		attributes[name] = formatted
+	}
+	for name, value := range r.Values {
+		if object, ok := value.(map[string]any); ok {
+			for key, value := range object {
+				add(name+"."+key, value)
+			}
+			continue
+		}
+		add(name, value)
+	}
+	return attributes, errors.Join(errs...)
+}
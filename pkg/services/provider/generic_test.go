@@ -0,0 +1,60 @@
+package provider_test
+
+import (
+	"drift-watcher/pkg/services/provider"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNodeGroup(t *testing.T) *provider.GenericInfraResource {
+	resource, err := provider.NewGenericInfraResource("aws_eks_node_group", map[string]any{
+		"instance_types": []string{"m5.large", "m5a.large"},
+		"disk_size":      int32(20),
+		"scaling_config": []map[string]any{{"desired_size": 6, "max_size": 10, "min_size": 2}},
+		"tags":           map[string]string{"Name": "workers", "kubernetes.io/role": "node"},
+	})
+	require.NoError(t, err)
+	return resource
+}
+
+func TestGenericInfraResource_AttributeValue(t *testing.T) {
+	resource := newNodeGroup(t)
+	assert.Equal(t, "aws_eks_node_group", resource.ResourceType())
+
+	for attribute, expected := range map[string]string{
+		"instance_types":              "m5.large,m5a.large",
+		"disk_size":                   "20",
+		"scaling_config.desired_size": "6",
+		"scaling_config[0].max_size":  "10",
+		"tags.Name":                   "workers",
+		"tags.kubernetes.io/role":     "node",
+		`tags["kubernetes.io/role"]`:  "node",
+		"tags.Missing":                "",
+	} {
+		value, err := resource.AttributeValue(attribute)
+		require.NoError(t, err, attribute)
+		assert.Equal(t, expected, value, attribute)
+	}
+
+	_, err := resource.AttributeValue("ami_type")
+	assert.ErrorContains(t, err, "not supported for aws_eks_node_group")
+}
+
+func TestGenericInfraResource_Attributes(t *testing.T) {
+	attributes, err := newNodeGroup(t).Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"instance_types":          "m5.large,m5a.large",
+		"disk_size":               "20",
+		"scaling_config":          `[{"desired_size":6,"max_size":10,"min_size":2}]`,
+		"tags.Name":               "workers",
+		"tags.kubernetes.io/role": "node",
+	}, attributes)
+}
+
+func TestNewGenericInfraResource_Unencodable(t *testing.T) {
+	_, err := provider.NewGenericInfraResource("aws_sqs_queue", map[string]any{"policy": func() {}})
+	assert.ErrorContains(t, err, "failed to encode aws_sqs_queue attributes")
+}
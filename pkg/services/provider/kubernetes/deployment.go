@@ -0,0 +1,152 @@
+package kubernetes
+
+import (
+	"drift-watcher/pkg/services/attrpath"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Deployment attribute paths, named after the Terraform kubernetes_deployment schema so
+// that the same path reads the desired value from the state.
+const (
+	DeploymentName               = "metadata.name"
+	DeploymentNamespace          = "metadata.namespace"
+	DeploymentReplicas           = "spec.replicas"
+	DeploymentServiceAccountName = "spec.template.spec.service_account_name"
+	DeploymentContainerName      = "spec.template.spec.container.name"
+	DeploymentContainerImage     = "spec.template.spec.container.image"
+	DeploymentLimitsCPU          = "spec.template.spec.container.resources.limits.cpu"
+	DeploymentLimitsMemory       = "spec.template.spec.container.resources.limits.memory"
+	DeploymentRequestsCPU        = "spec.template.spec.container.resources.requests.cpu"
+	DeploymentRequestsMemory     = "spec.template.spec.container.resources.requests.memory"
+
+	deploymentLabels         = "metadata.labels."
+	deploymentAnnotations    = "metadata.annotations."
+	deploymentTemplateLabels = "spec.template.metadata.labels."
+)
+
+// Deployment is the live state of a kubernetes_deployment resource.
+type Deployment struct {
+	// Type is the Terraform resource type the deployment was looked up for, either
+	// kubernetes_deployment or kubernetes_deployment_v1.
+	Type       string
+	Deployment appsv1.Deployment
+}
+
+func (d *Deployment) ResourceType() string {
+	return d.Type
+}
+
+// AttributeValue retrieves the string value of a deployment attribute. Container
+// attributes hold one value per container of the pod template, joined with commas in
+// container order; containers without the value are left out. Labels and annotations
+// are read with metadata.labels.KEY, metadata.annotations.KEY and
+// spec.template.metadata.labels.KEY, or metadata.labels["app.kubernetes.io/name"]
+// for keys holding dots. A missing label, annotation or resource quantity is returned
+// as an empty string.
+func (d *Deployment) AttributeValue(attribute string) (string, error) {
+	return attrpath.Resolve(attribute, d.attributeValue)
+}
+
+// attributeValue reads an attribute by its flat name.
+func (d *Deployment) attributeValue(attribute string) (string, error) {
+	spec := d.Deployment.Spec
+	switch attribute {
+	case DeploymentName:
+		return d.Deployment.Name, nil
+	case DeploymentNamespace:
+		return d.Deployment.Namespace, nil
+	case DeploymentReplicas:
+		if spec.Replicas == nil {
+			// the API server defaults an unset replica count to one
+			return "1", nil
+		}
+		return strconv.Itoa(int(*spec.Replicas)), nil
+	case DeploymentServiceAccountName:
+		return spec.Template.Spec.ServiceAccountName, nil
+	case DeploymentContainerName:
+		return d.containers(func(c corev1.Container) string { return c.Name }), nil
+	case DeploymentContainerImage:
+		return d.containers(func(c corev1.Container) string { return c.Image }), nil
+	case DeploymentLimitsCPU:
+		return d.containers(quantity(func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits }, corev1.ResourceCPU)), nil
+	case DeploymentLimitsMemory:
+		return d.containers(quantity(func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits }, corev1.ResourceMemory)), nil
+	case DeploymentRequestsCPU:
+		return d.containers(quantity(func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests }, corev1.ResourceCPU)), nil
+	case DeploymentRequestsMemory:
+		return d.containers(quantity(func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests }, corev1.ResourceMemory)), nil
+	}
+
+	switch {
+	case strings.HasPrefix(attribute, deploymentLabels):
+		return d.Deployment.Labels[strings.TrimPrefix(attribute, deploymentLabels)], nil
+	case strings.HasPrefix(attribute, deploymentAnnotations):
+		return d.Deployment.Annotations[strings.TrimPrefix(attribute, deploymentAnnotations)], nil
+	case strings.HasPrefix(attribute, deploymentTemplateLabels):
+		return spec.Template.Labels[strings.TrimPrefix(attribute, deploymentTemplateLabels)], nil
+	}
+
+	return "", fmt.Errorf("'%s' attribute is not supported for kubernetes deployments or is an invalid attribute name", attribute)
+}
+
+// Attributes returns every deployment attribute path and one attribute per label,
+// annotation and pod template label.
+func (d *Deployment) Attributes() (map[string]string, error) {
+	attributes := map[string]string{}
+	for _, attribute := range []string{
+		DeploymentName,
+		DeploymentNamespace,
+		DeploymentReplicas,
+		DeploymentServiceAccountName,
+		DeploymentContainerName,
+		DeploymentContainerImage,
+		DeploymentLimitsCPU,
+		DeploymentLimitsMemory,
+		DeploymentRequestsCPU,
+		DeploymentRequestsMemory,
+	} {
+		value, err := d.AttributeValue(attribute)
+		if err != nil {
+			return nil, err
+		}
+		attributes[attribute] = value
+	}
+	for key, value := range d.Deployment.Labels {
+		attributes[deploymentLabels+key] = value
+	}
+	for key, value := range d.Deployment.Annotations {
+		attributes[deploymentAnnotations+key] = value
+	}
+	for key, value := range d.Deployment.Spec.Template.Labels {
+		attributes[deploymentTemplateLabels+key] = value
+	}
+	return attributes, nil
+}
+
+// containers joins the non-empty values of every container of the pod template.
+func (d *Deployment) containers(value func(corev1.Container) string) string {
+	var values []string
+	for _, container := range d.Deployment.Spec.Template.Spec.Containers {
+		if v := value(container); v != "" {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// quantity returns a function reading the named resource quantity from the resource
+// list selected by list.
+func quantity(list func(corev1.Container) corev1.ResourceList, name corev1.ResourceName) func(corev1.Container) string {
+	return func(c corev1.Container) string {
+		q, ok := list(c)[name]
+		if !ok {
+			return ""
+		}
+		return q.String()
+	}
+}
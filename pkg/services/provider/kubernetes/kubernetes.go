@@ -0,0 +1,125 @@
+// Package kubernetes provides a Kubernetes implementation of the infrastructure provider
+// interface. It reads live workloads from the cluster API to detect drift in resources
+// managed with the Terraform kubernetes provider.
+package kubernetes
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubernetesProvider implements the ProviderI interface for Kubernetes clusters.
+type KubernetesProvider struct {
+	Client k8s.Interface
+}
+
+// NewKubernetesProvider creates a new KubernetesProvider connected to the cluster
+// selected by cfg.
+//
+// Parameters:
+//   - cfg: Kubernetes configuration containing the kubeconfig path and context
+//
+// Returns:
+//   - provider.ProviderI: A configured Kubernetes provider instance
+//   - error: Any error encountered while loading the kubeconfig
+func NewKubernetesProvider(cfg *config.KubernetesConfig) (provider.ProviderI, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cfg.Kubeconfig != "" {
+		rules.ExplicitPath = cfg.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.Context}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load kubeconfig")
+	}
+	client, err := k8s.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create kubernetes client")
+	}
+	return &KubernetesProvider{Client: client}, nil
+}
+
+// InfrastructreMetadata retrieves the live object for a Terraform-managed Kubernetes
+// resource. The object is located by the metadata.namespace and metadata.name of the
+// resource in the state.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resourceType: The type of the resource (e.g., "kubernetes_deployment")
+//   - resource: The Terraform state resource
+//
+// Returns:
+//   - provider.InfrastructureResourceI: Live data for the resource
+//   - error: Any error encountered while reading the object
+func (k *KubernetesProvider) InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (_ provider.InfrastructureResourceI, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "KubernetesProvider.InfrastructreMetadata", attribute.String("drift.resource_type", resourceType))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	switch resourceType {
+	case "kubernetes_deployment", "kubernetes_deployment_v1":
+		namespace, name, err := objectKey(resource)
+		if err != nil {
+			return nil, err
+		}
+		deployment, err := k.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// a nil live resource is reported as missing in infrastructure
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get deployment %s/%s", namespace, name)
+		}
+		return &Deployment{Type: resourceType, Deployment: *deployment}, nil
+
+	default:
+		return nil, fmt.Errorf("%s resource not yet supported for kubernetes provider", resourceType)
+	}
+}
+
+// objectKey returns the namespace and name of the object a state resource manages.
+// The metadata block is preferred; the "namespace/name" id is used as a fallback.
+func objectKey(resource statemanager.StateResource) (string, string, error) {
+	name, err := resource.AttributeValue("metadata.name")
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed to parse resource name from parsed state object")
+	}
+	namespace, err := resource.AttributeValue("metadata.namespace")
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed to parse resource namespace from parsed state object")
+	}
+
+	if name == "" {
+		id, err := resource.AttributeValue("id")
+		if err != nil {
+			return "", "", errors.Wrap(err, "Failed to parse resource identifier from parsed state object")
+		}
+		var ok bool
+		namespace, name, ok = strings.Cut(id, "/")
+		if !ok {
+			namespace, name = "", id
+		}
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("resource name not parsed from state file")
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	return namespace, name, nil
+}
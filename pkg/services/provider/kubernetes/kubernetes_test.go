@@ -0,0 +1,227 @@
+package kubernetes_test
+
+import (
+	"context"
+	"drift-watcher/config"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider/kubernetes"
+	"drift-watcher/pkg/services/statemanager"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func liveDeployment() *appsv1.Deployment {
+	replicas := int32(5)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "shop",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "web",
+							Image: "nginx:1.27",
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("500m"),
+									corev1.ResourceMemory: resource.MustParse("512Mi"),
+								},
+							},
+						},
+						{Name: "envoy", Image: "envoy:1.30"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func stateDeployment() statemanager.StateResource {
+	return statemanager.StateResource{
+		Type: "kubernetes_deployment",
+		Name: "web",
+		Instances: []statemanager.ResourceInstance{{
+			Attributes: map[string]any{
+				"id": "shop/web",
+				"metadata": []any{map[string]any{
+					"name":      "web",
+					"namespace": "shop",
+					"labels":    map[string]any{"app": "web"},
+				}},
+				"spec": []any{map[string]any{
+					"replicas": "3",
+					"template": []any{map[string]any{
+						"spec": []any{map[string]any{
+							"container": []any{
+								map[string]any{
+									"name":  "web",
+									"image": "nginx:1.27",
+									"resources": []any{map[string]any{
+										"limits": map[string]any{"cpu": "500m", "memory": "512Mi"},
+									}},
+								},
+								map[string]any{"name": "envoy", "image": "envoy:1.29"},
+							},
+						}},
+					}},
+				}},
+			},
+		}},
+	}
+}
+
+func TestInfrastructreMetadata_Deployment(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset(liveDeployment())}
+
+	live, err := p.InfrastructreMetadata(context.Background(), "kubernetes_deployment", stateDeployment())
+	require.NoError(t, err)
+	require.NotNil(t, live)
+	assert.Equal(t, "kubernetes_deployment", live.ResourceType())
+
+	tests := []struct {
+		attribute string
+		want      string
+	}{
+		{kubernetes.DeploymentReplicas, "5"},
+		{kubernetes.DeploymentContainerImage, "nginx:1.27,envoy:1.30"},
+		{kubernetes.DeploymentContainerName, "web,envoy"},
+		{kubernetes.DeploymentLimitsCPU, "500m"},
+		{kubernetes.DeploymentLimitsMemory, "512Mi"},
+		{kubernetes.DeploymentRequestsCPU, ""},
+		{"metadata.labels.app", "web"},
+		{"metadata.labels.missing", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			got, err := live.AttributeValue(tt.attribute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = live.AttributeValue("spec.strategy")
+	assert.Error(t, err)
+}
+
+func TestDeployment_Attributes(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset(liveDeployment())}
+	live, err := p.InfrastructreMetadata(context.Background(), "kubernetes_deployment", stateDeployment())
+	require.NoError(t, err)
+
+	attributes, err := live.Attributes()
+	require.NoError(t, err)
+	assert.Equal(t, "5", attributes[kubernetes.DeploymentReplicas])
+	assert.Equal(t, "nginx:1.27,envoy:1.30", attributes[kubernetes.DeploymentContainerImage])
+	assert.Equal(t, "web", attributes["metadata.labels.app"])
+	assert.Contains(t, attributes, kubernetes.DeploymentRequestsCPU)
+}
+
+func TestInfrastructreMetadata_DeploymentDrift(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset(liveDeployment())}
+	desired := stateDeployment()
+
+	live, err := p.InfrastructreMetadata(context.Background(), "kubernetes_deployment", desired)
+	require.NoError(t, err)
+
+	report, err := driftchecker.NewDefaultDriftChecker().CompareStates(context.Background(), live, desired, []string{
+		kubernetes.DeploymentReplicas,
+		kubernetes.DeploymentContainerImage,
+		kubernetes.DeploymentLimitsCPU,
+		"metadata.labels.app",
+	})
+	require.NoError(t, err)
+	assert.True(t, report.HasDrift)
+	assert.Equal(t, "shop/web", report.ResourceId)
+
+	drifted := map[string]driftchecker.DriftItem{}
+	for _, item := range report.DriftDetails {
+		if item.DriftType != driftchecker.Match {
+			drifted[item.Field] = item
+		}
+	}
+	require.Len(t, drifted, 2)
+	assert.Equal(t, "3", drifted[kubernetes.DeploymentReplicas].TerraformValue)
+	assert.Equal(t, "5", drifted[kubernetes.DeploymentReplicas].ActualValue)
+	assert.Equal(t, "nginx:1.27,envoy:1.29", drifted[kubernetes.DeploymentContainerImage].TerraformValue)
+	assert.Equal(t, "nginx:1.27,envoy:1.30", drifted[kubernetes.DeploymentContainerImage].ActualValue)
+}
+
+func TestInfrastructreMetadata_DeploymentNotFound(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset()}
+
+	live, err := p.InfrastructreMetadata(context.Background(), "kubernetes_deployment", stateDeployment())
+	require.NoError(t, err)
+	assert.Nil(t, live, "a deleted deployment is reported as missing in infrastructure")
+}
+
+func TestInfrastructreMetadata_IdFallback(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset(liveDeployment())}
+	desired := statemanager.StateResource{
+		Type:      "kubernetes_deployment_v1",
+		Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "shop/web"}}},
+	}
+
+	live, err := p.InfrastructreMetadata(context.Background(), "kubernetes_deployment_v1", desired)
+	require.NoError(t, err)
+	require.NotNil(t, live)
+	assert.Equal(t, "kubernetes_deployment_v1", live.ResourceType())
+}
+
+func TestInfrastructreMetadata_Unsupported(t *testing.T) {
+	p := &kubernetes.KubernetesProvider{Client: fake.NewClientset()}
+
+	_, err := p.InfrastructreMetadata(context.Background(), "kubernetes_service", statemanager.StateResource{})
+	assert.EqualError(t, err, "kubernetes_service resource not yet supported for kubernetes provider")
+
+	_, err = p.SupportedAttributes("kubernetes_service")
+	assert.Error(t, err)
+	attributes, err := p.SupportedAttributes("kubernetes_deployment")
+	require.NoError(t, err)
+	assert.Contains(t, attributes, kubernetes.DeploymentReplicas)
+}
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://127.0.0.1:6443
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+contexts:
+- name: dev
+  context: {cluster: dev, user: me}
+- name: prod
+  context: {cluster: prod, user: me}
+current-context: dev
+users:
+- name: me
+  user: {token: secret}
+`
+
+func TestNewKubernetesProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte(kubeconfig), 0600))
+
+	p, err := kubernetes.NewKubernetesProvider(&config.KubernetesConfig{Kubeconfig: path, Context: "prod"})
+	require.NoError(t, err)
+	require.IsType(t, &kubernetes.KubernetesProvider{}, p)
+
+	_, err = kubernetes.NewKubernetesProvider(&config.KubernetesConfig{Kubeconfig: path, Context: "staging"})
+	assert.Error(t, err)
+}
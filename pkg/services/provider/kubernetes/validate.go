@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// supportedAttributes lists, per resource type, the attributes AttributeValue can read
+// from live data. Entries are glob patterns so that metadata.labels.* covers every label.
+var supportedAttributes = map[string][]string{
+	"kubernetes_deployment":    deploymentAttributes,
+	"kubernetes_deployment_v1": deploymentAttributes,
+}
+
+var deploymentAttributes = []string{
+	DeploymentName,
+	DeploymentNamespace,
+	DeploymentReplicas,
+	DeploymentServiceAccountName,
+	DeploymentContainerName,
+	DeploymentContainerImage,
+	DeploymentLimitsCPU,
+	DeploymentLimitsMemory,
+	DeploymentRequestsCPU,
+	DeploymentRequestsMemory,
+	deploymentLabels + "*",
+	deploymentAnnotations + "*",
+	deploymentTemplateLabels + "*",
+}
+
+// CheckCredentials verifies that the cluster is reachable with the configured
+// credentials by requesting the server version, which reads no workload.
+func (k *KubernetesProvider) CheckCredentials(ctx context.Context) error {
+	if _, err := k.Client.Discovery().ServerVersion(); err != nil {
+		return errors.Wrap(err, "Failed to reach the kubernetes API server")
+	}
+	return nil
+}
+
+// SupportedAttributes returns the attributes that can be checked for resourceType.
+func (k *KubernetesProvider) SupportedAttributes(resourceType string) ([]string, error) {
+	attributes, ok := supportedAttributes[resourceType]
+	if !ok {
+		return nil, fmt.Errorf("%s resource not yet supported for kubernetes provider", resourceType)
+	}
+	return attributes, nil
+}
+
+// ResourceTypes returns the resource types the kubernetes provider supports, sorted. It
+// needs no credentials, for shell completion and documentation.
+func ResourceTypes() []string {
+	return slices.Sorted(maps.Keys(supportedAttributes))
+}
+
+// ResourceAttributes returns the attributes that can be checked for resourceType, or
+// nil if the type is not supported. Like ResourceTypes, it needs no credentials.
+func ResourceAttributes(resourceType string) []string {
+	return supportedAttributes[resourceType]
+}
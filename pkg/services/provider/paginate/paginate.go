@@ -0,0 +1,87 @@
+// Package paginate requests every page of the list and describe operations of a
+// platform API. Page requests share a rate limiter, so that scanning an account with
+// thousands of resources stays below the API's request rate limits instead of being
+// throttled until its retries run out, and a circuit breaker, so that an unreachable
+// region fails fast.
+package paginate
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/breaker"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// Paginator is the paginator of a list or describe operation returning pages of type
+// T, as created by the AWS SDK, e.g. ec2.NewDescribeInstancesPaginator. O is the type
+// of the client options of the operation.
+type Paginator[T any, O any] interface {
+	HasMorePages() bool
+	NextPage(ctx context.Context, optFns ...func(*O)) (T, error)
+}
+
+// Options controls how the calls of an operation are made.
+type Options struct {
+	// Limiter is waited on before every call. Calls are not rate limited when nil.
+	Limiter *rate.Limiter
+	// Breaker is checked before every call and records its outcome. Every call is let
+	// through when nil.
+	Breaker *breaker.Breaker
+}
+
+// NewLimiter returns a limiter allowing perSecond calls per second on average, with
+// bursts of up to a second's worth of calls, or nil when perSecond is not positive.
+func NewLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), int(math.Max(1, math.Ceil(perSecond))))
+}
+
+// Call makes a single call of an operation, waiting for the limiter and checking the
+// breaker first.
+func Call[T any](ctx context.Context, opts Options, call func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if opts.Limiter != nil {
+		if err := opts.Limiter.Wait(ctx); err != nil {
+			return zero, err
+		}
+	}
+	if err := opts.Breaker.Allow(); err != nil {
+		return zero, err
+	}
+	out, err := call(ctx)
+	opts.Breaker.Record(err)
+	return out, err
+}
+
+// Pages calls fn with every page of p, requesting each page through Call. It stops at
+// the first error, or when fn returns false.
+func Pages[T any, O any](ctx context.Context, p Paginator[T, O], opts Options, fn func(page T) bool) error {
+	for p.HasMorePages() {
+		page, err := Call(ctx, opts, func(ctx context.Context) (T, error) {
+			return p.NextPage(ctx)
+		})
+		if err != nil {
+			return err
+		}
+		if !fn(page) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Collect returns the items of every page of p, as returned by items.
+func Collect[T any, O any, I any](ctx context.Context, p Paginator[T, O], opts Options, items func(page T) []I) ([]I, error) {
+	var all []I
+	err := Pages(ctx, p, opts, func(page T) bool {
+		all = append(all, items(page)...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
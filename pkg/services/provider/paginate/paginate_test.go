@@ -0,0 +1,98 @@
+package paginate_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/breaker"
+	"drift-watcher/pkg/services/provider/paginate"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type options struct{}
+
+// pager serves pages of ints, failing at the page index failAt.
+type pager struct {
+	pages  [][]int
+	next   int
+	failAt int
+}
+
+func (p *pager) HasMorePages() bool {
+	return p.next < len(p.pages)
+}
+
+func (p *pager) NextPage(ctx context.Context, optFns ...func(*options)) ([]int, error) {
+	if p.next == p.failAt {
+		return nil, errors.New("dial tcp: i/o timeout")
+	}
+	p.next++
+	return p.pages[p.next-1], nil
+}
+
+func identity(page []int) []int {
+	return page
+}
+
+func TestCollect(t *testing.T) {
+	p := &pager{pages: [][]int{{1, 2}, {3}, {}, {4, 5}}, failAt: -1}
+	items, err := paginate.Collect(context.Background(), p, paginate.Options{}, identity)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+
+	p = &pager{pages: [][]int{{1, 2}, {3}}, failAt: 1}
+	_, err = paginate.Collect(context.Background(), p, paginate.Options{}, identity)
+	assert.ErrorContains(t, err, "i/o timeout")
+}
+
+func TestPages_Stop(t *testing.T) {
+	p := &pager{pages: [][]int{{1}, {2}, {3}}, failAt: -1}
+	var seen []int
+	require.NoError(t, paginate.Pages(context.Background(), p, paginate.Options{}, func(page []int) bool {
+		seen = append(seen, page...)
+		return len(seen) < 2
+	}))
+	assert.Equal(t, []int{1, 2}, seen)
+	assert.Equal(t, 2, p.next, "no page is requested after fn returns false")
+}
+
+func TestCall_Breaker(t *testing.T) {
+	b := breaker.New(1, time.Minute, nil)
+	p := &pager{pages: [][]int{{1}, {2}}, failAt: 0}
+	opts := paginate.Options{Breaker: b}
+
+	_, err := paginate.Collect(context.Background(), p, opts, identity)
+	assert.ErrorContains(t, err, "i/o timeout")
+
+	p.failAt = -1
+	_, err = paginate.Collect(context.Background(), p, opts, identity)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, 0, p.next)
+}
+
+func TestCall_Limiter(t *testing.T) {
+	limiter := paginate.NewLimiter(50)
+	require.NotNil(t, limiter)
+	assert.Equal(t, 50, limiter.Burst())
+
+	p := &pager{pages: make([][]int, 60), failAt: -1}
+	start := time.Now()
+	_, err := paginate.Collect(context.Background(), p, paginate.Options{Limiter: limiter}, identity)
+	require.NoError(t, err)
+	// the burst is served at once, the 10 pages after it at 50 per second
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = paginate.Call(ctx, paginate.Options{Limiter: paginate.NewLimiter(0.001)}, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	assert.Error(t, err)
+
+	assert.Nil(t, paginate.NewLimiter(0))
+	assert.Nil(t, paginate.NewLimiter(-1))
+	assert.Equal(t, 1, paginate.NewLimiter(0.5).Burst())
+}
@@ -22,6 +22,41 @@ import (
 type InfrastructureResourceI interface {
 	ResourceType() string
 	AttributeValue(attribute string) (string, error)
+	// Attributes returns every attribute the resource knows, keyed by the names
+	// AttributeValue accepts, for checks that diff the whole resource. Attributes
+	// that could not be read are left out and reported in the error, alongside the
+	// attributes that could.
+	Attributes() (map[string]string, error)
+}
+
+// ContextResourceI is implemented by live resources that read some attributes with
+// API calls of their own, such as the user data of an EC2 instance, so that those
+// calls honour the deadline and cancellation of the check reading them. Checks read
+// live attributes with LiveAttributeValue and LiveAttributes, which use it when it is
+// implemented.
+//
+//counterfeiter:generate . ContextResourceI
+type ContextResourceI interface {
+	AttributeValueContext(ctx context.Context, attribute string) (string, error)
+	AttributesContext(ctx context.Context) (map[string]string, error)
+}
+
+// LiveAttributeValue reads an attribute of a live resource with ctx, when the resource
+// implements ContextResourceI, or with AttributeValue otherwise.
+func LiveAttributeValue(ctx context.Context, resource InfrastructureResourceI, attribute string) (string, error) {
+	if contextResource, ok := resource.(ContextResourceI); ok {
+		return contextResource.AttributeValueContext(ctx, attribute)
+	}
+	return resource.AttributeValue(attribute)
+}
+
+// LiveAttributes reads every attribute of a live resource with ctx, when the resource
+// implements ContextResourceI, or with Attributes otherwise.
+func LiveAttributes(ctx context.Context, resource InfrastructureResourceI) (map[string]string, error) {
+	if contextResource, ok := resource.(ContextResourceI); ok {
+		return contextResource.AttributesContext(ctx)
+	}
+	return resource.Attributes()
 }
 
 // ProviderI defines the interface for cloud infrastructure providers.
@@ -36,3 +71,118 @@ type InfrastructureResourceI interface {
 type ProviderI interface {
 	InfrastructreMetadata(ctx context.Context, resourceType string, resource statemanager.StateResource) (InfrastructureResourceI, error)
 }
+
+// ResourceListerI is implemented by providers that can enumerate the live resources
+// of a type, which allows resources that are not managed by the state file to be found.
+//
+//counterfeiter:generate . ResourceListerI
+type ResourceListerI interface {
+	ListResourceIds(ctx context.Context, resourceType string) ([]string, error)
+}
+
+// ValidatorI is implemented by providers that can check a scan configuration
+// without querying any live resource, so misconfiguration surfaces before a long run.
+//
+//counterfeiter:generate . ValidatorI
+type ValidatorI interface {
+	// CheckCredentials verifies that credentials for the provider can be resolved.
+	CheckCredentials(ctx context.Context) error
+	// SupportedAttributes returns glob patterns (path.Match syntax) of the attributes
+	// that can be checked for resourceType, or an error if the type is not supported.
+	SupportedAttributes(resourceType string) ([]string, error)
+}
+
+// ReferenceResolverI is implemented by providers that can name the resources an
+// attribute refers to by identifier, such as the subnet or security groups of an
+// instance, so that drift in those attributes can be read without console lookups.
+//
+//counterfeiter:generate . ReferenceResolverI
+type ReferenceResolverI interface {
+	// ResolveReferences returns names keyed by identifier for the ids held by attribute.
+	// It returns nil for attributes that do not reference other resources.
+	ResolveReferences(ctx context.Context, resourceType string, attribute string, ids []string) (map[string]string, error)
+}
+
+// ResolvedValue is the value a reference held by the state refers to.
+type ResolvedValue struct {
+	Value string
+	// Sensitive marks values read from a secret store, such as SecureString parameters
+	// and secrets, which must be redacted wherever they are reported.
+	Sensitive bool
+}
+
+// ValueResolverI is implemented by providers that can resolve references held by the
+// state to the values they refer to, such as the ARN of an SSM parameter whose value
+// the live resource holds, so that the reference is not reported as drift.
+//
+//counterfeiter:generate . ValueResolverI
+type ValueResolverI interface {
+	// ResolveValue returns the value value refers to, or nil if value is not a
+	// reference.
+	ResolveValue(ctx context.Context, value string) (*ResolvedValue, error)
+}
+
+// Advisory points out a change worth making to a resource that matches its state,
+// such as moving an instance to a newer image than the one it was launched from.
+type Advisory struct {
+	// Attribute is the attribute the advisory is about.
+	Attribute string `json:"attribute"`
+	Message   string `json:"message"`
+	// Suggested is the value the attribute could be changed to, when there is one.
+	Suggested string `json:"suggested,omitempty"`
+}
+
+// AdvisorI is implemented by providers that can point out updates available for a
+// resource beyond its drift, such as a newer image matching the filters of the data
+// source the image of an instance was selected with.
+//
+//counterfeiter:generate . AdvisorI
+type AdvisorI interface {
+	// Advise returns the advisories for resource, whose live state is live. data holds
+	// the data sources of the state the resource was read from.
+	Advise(ctx context.Context, live InfrastructureResourceI, resource statemanager.StateResource, data []statemanager.StateResource) ([]Advisory, error)
+}
+
+// Field documents an attribute a provider reads for a resource type: its name in the
+// Terraform schema, the other names it is accepted under and the API field its live
+// value is read from, e.g. vpc_security_group_ids read from the
+// SecurityGroups[].GroupId of DescribeInstances.
+type Field struct {
+	// Attribute is the name of the attribute, or a glob pattern such as tags.* for
+	// the keys of a map.
+	Attribute string `json:"attribute"`
+	// Aliases are names the attribute is also tracked under, such as the names
+	// earlier releases used. They read the same value as Attribute.
+	Aliases []string `json:"aliases,omitempty"`
+	// API is the API call and the field of its response the value is read from.
+	API string `json:"api"`
+}
+
+// AttributeAliasesI is implemented by providers that accept attributes under names
+// other than those of the Terraform schema, so that a tracked alias is looked up in
+// the state under the name the state holds it under.
+//
+//counterfeiter:generate . AttributeAliasesI
+type AttributeAliasesI interface {
+	// CanonicalAttribute returns the Terraform name of an attribute path expression
+	// that starts with an alias, and any other expression unchanged.
+	CanonicalAttribute(resourceType string, attribute string) string
+}
+
+// Change describes a single attribute that should be brought back in line with
+// the desired state during remediation.
+type Change struct {
+	Attribute    string
+	DesiredValue string
+	ActualValue  string
+}
+
+// RemediatorI is implemented by providers that can revert drift on live resources.
+// Remediation is limited to an allowlist of attributes the provider knows how to
+// change safely; CanRemediate reports whether an attribute is on that list.
+//
+//counterfeiter:generate . RemediatorI
+type RemediatorI interface {
+	CanRemediate(resourceType string, attribute string) bool
+	Remediate(ctx context.Context, resource statemanager.StateResource, change Change) error
+}
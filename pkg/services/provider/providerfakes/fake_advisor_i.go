@@ -0,0 +1,128 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"sync"
+)
+
+type FakeAdvisorI struct {
+	AdviseStub        func(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []statemanager.StateResource) ([]provider.Advisory, error)
+	adviseMutex       sync.RWMutex
+	adviseArgsForCall []struct {
+		arg1 context.Context
+		arg2 provider.InfrastructureResourceI
+		arg3 statemanager.StateResource
+		arg4 []statemanager.StateResource
+	}
+	adviseReturns struct {
+		result1 []provider.Advisory
+		result2 error
+	}
+	adviseReturnsOnCall map[int]struct {
+		result1 []provider.Advisory
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAdvisorI) Advise(arg1 context.Context, arg2 provider.InfrastructureResourceI, arg3 statemanager.StateResource, arg4 []statemanager.StateResource) ([]provider.Advisory, error) {
+	var arg4Copy []statemanager.StateResource
+	if arg4 != nil {
+		arg4Copy = make([]statemanager.StateResource, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.adviseMutex.Lock()
+	ret, specificReturn := fake.adviseReturnsOnCall[len(fake.adviseArgsForCall)]
+	fake.adviseArgsForCall = append(fake.adviseArgsForCall, struct {
+		arg1 context.Context
+		arg2 provider.InfrastructureResourceI
+		arg3 statemanager.StateResource
+		arg4 []statemanager.StateResource
+	}{arg1, arg2, arg3, arg4Copy})
+	stub := fake.AdviseStub
+	fakeReturns := fake.adviseReturns
+	fake.recordInvocation("Advise", []interface{}{arg1, arg2, arg3, arg4Copy})
+	fake.adviseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAdvisorI) AdviseCallCount() int {
+	fake.adviseMutex.RLock()
+	defer fake.adviseMutex.RUnlock()
+	return len(fake.adviseArgsForCall)
+}
+
+func (fake *FakeAdvisorI) AdviseCalls(stub func(context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []statemanager.StateResource) ([]provider.Advisory, error)) {
+	fake.adviseMutex.Lock()
+	defer fake.adviseMutex.Unlock()
+	fake.AdviseStub = stub
+}
+
+func (fake *FakeAdvisorI) AdviseArgsForCall(i int) (context.Context, provider.InfrastructureResourceI, statemanager.StateResource, []statemanager.StateResource) {
+	fake.adviseMutex.RLock()
+	defer fake.adviseMutex.RUnlock()
+	argsForCall := fake.adviseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeAdvisorI) AdviseReturns(result1 []provider.Advisory, result2 error) {
+	fake.adviseMutex.Lock()
+	defer fake.adviseMutex.Unlock()
+	fake.AdviseStub = nil
+	fake.adviseReturns = struct {
+		result1 []provider.Advisory
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAdvisorI) AdviseReturnsOnCall(i int, result1 []provider.Advisory, result2 error) {
+	fake.adviseMutex.Lock()
+	defer fake.adviseMutex.Unlock()
+	fake.AdviseStub = nil
+	if fake.adviseReturnsOnCall == nil {
+		fake.adviseReturnsOnCall = make(map[int]struct {
+			result1 []provider.Advisory
+			result2 error
+		})
+	}
+	fake.adviseReturnsOnCall[i] = struct {
+		result1 []provider.Advisory
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAdvisorI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.adviseMutex.RLock()
+	defer fake.adviseMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAdvisorI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.AdvisorI = new(FakeAdvisorI)
@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeAttributeAliasesI struct {
+	CanonicalAttributeStub        func(string, string) string
+	canonicalAttributeMutex       sync.RWMutex
+	canonicalAttributeArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	canonicalAttributeReturns struct {
+		result1 string
+	}
+	canonicalAttributeReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttribute(arg1 string, arg2 string) string {
+	fake.canonicalAttributeMutex.Lock()
+	ret, specificReturn := fake.canonicalAttributeReturnsOnCall[len(fake.canonicalAttributeArgsForCall)]
+	fake.canonicalAttributeArgsForCall = append(fake.canonicalAttributeArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CanonicalAttributeStub
+	fakeReturns := fake.canonicalAttributeReturns
+	fake.recordInvocation("CanonicalAttribute", []interface{}{arg1, arg2})
+	fake.canonicalAttributeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttributeCallCount() int {
+	fake.canonicalAttributeMutex.RLock()
+	defer fake.canonicalAttributeMutex.RUnlock()
+	return len(fake.canonicalAttributeArgsForCall)
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttributeCalls(stub func(string, string) string) {
+	fake.canonicalAttributeMutex.Lock()
+	defer fake.canonicalAttributeMutex.Unlock()
+	fake.CanonicalAttributeStub = stub
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttributeArgsForCall(i int) (string, string) {
+	fake.canonicalAttributeMutex.RLock()
+	defer fake.canonicalAttributeMutex.RUnlock()
+	argsForCall := fake.canonicalAttributeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttributeReturns(result1 string) {
+	fake.canonicalAttributeMutex.Lock()
+	defer fake.canonicalAttributeMutex.Unlock()
+	fake.CanonicalAttributeStub = nil
+	fake.canonicalAttributeReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeAttributeAliasesI) CanonicalAttributeReturnsOnCall(i int, result1 string) {
+	fake.canonicalAttributeMutex.Lock()
+	defer fake.canonicalAttributeMutex.Unlock()
+	fake.CanonicalAttributeStub = nil
+	if fake.canonicalAttributeReturnsOnCall == nil {
+		fake.canonicalAttributeReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.canonicalAttributeReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeAttributeAliasesI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.canonicalAttributeMutex.RLock()
+	defer fake.canonicalAttributeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAttributeAliasesI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.AttributeAliasesI = new(FakeAttributeAliasesI)
@@ -0,0 +1,197 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeContextResourceI struct {
+	AttributeValueContextStub        func(context.Context, string) (string, error)
+	attributeValueContextMutex       sync.RWMutex
+	attributeValueContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	attributeValueContextReturns struct {
+		result1 string
+		result2 error
+	}
+	attributeValueContextReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	AttributesContextStub        func(context.Context) (map[string]string, error)
+	attributesContextMutex       sync.RWMutex
+	attributesContextArgsForCall []struct {
+		arg1 context.Context
+	}
+	attributesContextReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	attributesContextReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeContextResourceI) AttributeValueContext(arg1 context.Context, arg2 string) (string, error) {
+	fake.attributeValueContextMutex.Lock()
+	ret, specificReturn := fake.attributeValueContextReturnsOnCall[len(fake.attributeValueContextArgsForCall)]
+	fake.attributeValueContextArgsForCall = append(fake.attributeValueContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.AttributeValueContextStub
+	fakeReturns := fake.attributeValueContextReturns
+	fake.recordInvocation("AttributeValueContext", []interface{}{arg1, arg2})
+	fake.attributeValueContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeContextResourceI) AttributeValueContextCallCount() int {
+	fake.attributeValueContextMutex.RLock()
+	defer fake.attributeValueContextMutex.RUnlock()
+	return len(fake.attributeValueContextArgsForCall)
+}
+
+func (fake *FakeContextResourceI) AttributeValueContextCalls(stub func(context.Context, string) (string, error)) {
+	fake.attributeValueContextMutex.Lock()
+	defer fake.attributeValueContextMutex.Unlock()
+	fake.AttributeValueContextStub = stub
+}
+
+func (fake *FakeContextResourceI) AttributeValueContextArgsForCall(i int) (context.Context, string) {
+	fake.attributeValueContextMutex.RLock()
+	defer fake.attributeValueContextMutex.RUnlock()
+	argsForCall := fake.attributeValueContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeContextResourceI) AttributeValueContextReturns(result1 string, result2 error) {
+	fake.attributeValueContextMutex.Lock()
+	defer fake.attributeValueContextMutex.Unlock()
+	fake.AttributeValueContextStub = nil
+	fake.attributeValueContextReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContextResourceI) AttributeValueContextReturnsOnCall(i int, result1 string, result2 error) {
+	fake.attributeValueContextMutex.Lock()
+	defer fake.attributeValueContextMutex.Unlock()
+	fake.AttributeValueContextStub = nil
+	if fake.attributeValueContextReturnsOnCall == nil {
+		fake.attributeValueContextReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.attributeValueContextReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContextResourceI) AttributesContext(arg1 context.Context) (map[string]string, error) {
+	fake.attributesContextMutex.Lock()
+	ret, specificReturn := fake.attributesContextReturnsOnCall[len(fake.attributesContextArgsForCall)]
+	fake.attributesContextArgsForCall = append(fake.attributesContextArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.AttributesContextStub
+	fakeReturns := fake.attributesContextReturns
+	fake.recordInvocation("AttributesContext", []interface{}{arg1})
+	fake.attributesContextMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeContextResourceI) AttributesContextCallCount() int {
+	fake.attributesContextMutex.RLock()
+	defer fake.attributesContextMutex.RUnlock()
+	return len(fake.attributesContextArgsForCall)
+}
+
+func (fake *FakeContextResourceI) AttributesContextCalls(stub func(context.Context) (map[string]string, error)) {
+	fake.attributesContextMutex.Lock()
+	defer fake.attributesContextMutex.Unlock()
+	fake.AttributesContextStub = stub
+}
+
+func (fake *FakeContextResourceI) AttributesContextArgsForCall(i int) context.Context {
+	fake.attributesContextMutex.RLock()
+	defer fake.attributesContextMutex.RUnlock()
+	argsForCall := fake.attributesContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeContextResourceI) AttributesContextReturns(result1 map[string]string, result2 error) {
+	fake.attributesContextMutex.Lock()
+	defer fake.attributesContextMutex.Unlock()
+	fake.AttributesContextStub = nil
+	fake.attributesContextReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContextResourceI) AttributesContextReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.attributesContextMutex.Lock()
+	defer fake.attributesContextMutex.Unlock()
+	fake.AttributesContextStub = nil
+	if fake.attributesContextReturnsOnCall == nil {
+		fake.attributesContextReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.attributesContextReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContextResourceI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.attributeValueContextMutex.RLock()
+	defer fake.attributeValueContextMutex.RUnlock()
+	fake.attributesContextMutex.RLock()
+	defer fake.attributesContextMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeContextResourceI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.ContextResourceI = new(FakeContextResourceI)
@@ -20,6 +20,18 @@ type FakeInfrastructureResourceI struct {
 		result1 string
 		result2 error
 	}
+	AttributesStub        func() (map[string]string, error)
+	attributesMutex       sync.RWMutex
+	attributesArgsForCall []struct {
+	}
+	attributesReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	attributesReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
 	ResourceTypeStub        func() string
 	resourceTypeMutex       sync.RWMutex
 	resourceTypeArgsForCall []struct {
@@ -98,6 +110,62 @@ func (fake *FakeInfrastructureResourceI) AttributeValueReturnsOnCall(i int, resu
 	}{result1, result2}
 }
 
+func (fake *FakeInfrastructureResourceI) Attributes() (map[string]string, error) {
+	fake.attributesMutex.Lock()
+	ret, specificReturn := fake.attributesReturnsOnCall[len(fake.attributesArgsForCall)]
+	fake.attributesArgsForCall = append(fake.attributesArgsForCall, struct {
+	}{})
+	stub := fake.AttributesStub
+	fakeReturns := fake.attributesReturns
+	fake.recordInvocation("Attributes", []interface{}{})
+	fake.attributesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeInfrastructureResourceI) AttributesCallCount() int {
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
+	return len(fake.attributesArgsForCall)
+}
+
+func (fake *FakeInfrastructureResourceI) AttributesCalls(stub func() (map[string]string, error)) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = stub
+}
+
+func (fake *FakeInfrastructureResourceI) AttributesReturns(result1 map[string]string, result2 error) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	fake.attributesReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeInfrastructureResourceI) AttributesReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.attributesMutex.Lock()
+	defer fake.attributesMutex.Unlock()
+	fake.AttributesStub = nil
+	if fake.attributesReturnsOnCall == nil {
+		fake.attributesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.attributesReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeInfrastructureResourceI) ResourceType() string {
 	fake.resourceTypeMutex.Lock()
 	ret, specificReturn := fake.resourceTypeReturnsOnCall[len(fake.resourceTypeArgsForCall)]
@@ -156,6 +224,8 @@ func (fake *FakeInfrastructureResourceI) Invocations() map[string][][]interface{
 	defer fake.invocationsMutex.RUnlock()
 	fake.attributeValueMutex.RLock()
 	defer fake.attributeValueMutex.RUnlock()
+	fake.attributesMutex.RLock()
+	defer fake.attributesMutex.RUnlock()
 	fake.resourceTypeMutex.RLock()
 	defer fake.resourceTypeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
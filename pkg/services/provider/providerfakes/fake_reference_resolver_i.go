@@ -0,0 +1,127 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeReferenceResolverI struct {
+	ResolveReferencesStub        func(context.Context, string, string, []string) (map[string]string, error)
+	resolveReferencesMutex       sync.RWMutex
+	resolveReferencesArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 []string
+	}
+	resolveReferencesReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	resolveReferencesReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferences(arg1 context.Context, arg2 string, arg3 string, arg4 []string) (map[string]string, error) {
+	var arg4Copy []string
+	if arg4 != nil {
+		arg4Copy = make([]string, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.resolveReferencesMutex.Lock()
+	ret, specificReturn := fake.resolveReferencesReturnsOnCall[len(fake.resolveReferencesArgsForCall)]
+	fake.resolveReferencesArgsForCall = append(fake.resolveReferencesArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 []string
+	}{arg1, arg2, arg3, arg4Copy})
+	stub := fake.ResolveReferencesStub
+	fakeReturns := fake.resolveReferencesReturns
+	fake.recordInvocation("ResolveReferences", []interface{}{arg1, arg2, arg3, arg4Copy})
+	fake.resolveReferencesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferencesCallCount() int {
+	fake.resolveReferencesMutex.RLock()
+	defer fake.resolveReferencesMutex.RUnlock()
+	return len(fake.resolveReferencesArgsForCall)
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferencesCalls(stub func(context.Context, string, string, []string) (map[string]string, error)) {
+	fake.resolveReferencesMutex.Lock()
+	defer fake.resolveReferencesMutex.Unlock()
+	fake.ResolveReferencesStub = stub
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferencesArgsForCall(i int) (context.Context, string, string, []string) {
+	fake.resolveReferencesMutex.RLock()
+	defer fake.resolveReferencesMutex.RUnlock()
+	argsForCall := fake.resolveReferencesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferencesReturns(result1 map[string]string, result2 error) {
+	fake.resolveReferencesMutex.Lock()
+	defer fake.resolveReferencesMutex.Unlock()
+	fake.ResolveReferencesStub = nil
+	fake.resolveReferencesReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReferenceResolverI) ResolveReferencesReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.resolveReferencesMutex.Lock()
+	defer fake.resolveReferencesMutex.Unlock()
+	fake.ResolveReferencesStub = nil
+	if fake.resolveReferencesReturnsOnCall == nil {
+		fake.resolveReferencesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.resolveReferencesReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReferenceResolverI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resolveReferencesMutex.RLock()
+	defer fake.resolveReferencesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReferenceResolverI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.ReferenceResolverI = new(FakeReferenceResolverI)
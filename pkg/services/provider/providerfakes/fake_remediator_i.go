@@ -0,0 +1,192 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/statemanager"
+	"sync"
+)
+
+type FakeRemediatorI struct {
+	CanRemediateStub        func(string, string) bool
+	canRemediateMutex       sync.RWMutex
+	canRemediateArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	canRemediateReturns struct {
+		result1 bool
+	}
+	canRemediateReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	RemediateStub        func(context.Context, statemanager.StateResource, provider.Change) error
+	remediateMutex       sync.RWMutex
+	remediateArgsForCall []struct {
+		arg1 context.Context
+		arg2 statemanager.StateResource
+		arg3 provider.Change
+	}
+	remediateReturns struct {
+		result1 error
+	}
+	remediateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRemediatorI) CanRemediate(arg1 string, arg2 string) bool {
+	fake.canRemediateMutex.Lock()
+	ret, specificReturn := fake.canRemediateReturnsOnCall[len(fake.canRemediateArgsForCall)]
+	fake.canRemediateArgsForCall = append(fake.canRemediateArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CanRemediateStub
+	fakeReturns := fake.canRemediateReturns
+	fake.recordInvocation("CanRemediate", []interface{}{arg1, arg2})
+	fake.canRemediateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRemediatorI) CanRemediateCallCount() int {
+	fake.canRemediateMutex.RLock()
+	defer fake.canRemediateMutex.RUnlock()
+	return len(fake.canRemediateArgsForCall)
+}
+
+func (fake *FakeRemediatorI) CanRemediateCalls(stub func(string, string) bool) {
+	fake.canRemediateMutex.Lock()
+	defer fake.canRemediateMutex.Unlock()
+	fake.CanRemediateStub = stub
+}
+
+func (fake *FakeRemediatorI) CanRemediateArgsForCall(i int) (string, string) {
+	fake.canRemediateMutex.RLock()
+	defer fake.canRemediateMutex.RUnlock()
+	argsForCall := fake.canRemediateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRemediatorI) CanRemediateReturns(result1 bool) {
+	fake.canRemediateMutex.Lock()
+	defer fake.canRemediateMutex.Unlock()
+	fake.CanRemediateStub = nil
+	fake.canRemediateReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRemediatorI) CanRemediateReturnsOnCall(i int, result1 bool) {
+	fake.canRemediateMutex.Lock()
+	defer fake.canRemediateMutex.Unlock()
+	fake.CanRemediateStub = nil
+	if fake.canRemediateReturnsOnCall == nil {
+		fake.canRemediateReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.canRemediateReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRemediatorI) Remediate(arg1 context.Context, arg2 statemanager.StateResource, arg3 provider.Change) error {
+	fake.remediateMutex.Lock()
+	ret, specificReturn := fake.remediateReturnsOnCall[len(fake.remediateArgsForCall)]
+	fake.remediateArgsForCall = append(fake.remediateArgsForCall, struct {
+		arg1 context.Context
+		arg2 statemanager.StateResource
+		arg3 provider.Change
+	}{arg1, arg2, arg3})
+	stub := fake.RemediateStub
+	fakeReturns := fake.remediateReturns
+	fake.recordInvocation("Remediate", []interface{}{arg1, arg2, arg3})
+	fake.remediateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRemediatorI) RemediateCallCount() int {
+	fake.remediateMutex.RLock()
+	defer fake.remediateMutex.RUnlock()
+	return len(fake.remediateArgsForCall)
+}
+
+func (fake *FakeRemediatorI) RemediateCalls(stub func(context.Context, statemanager.StateResource, provider.Change) error) {
+	fake.remediateMutex.Lock()
+	defer fake.remediateMutex.Unlock()
+	fake.RemediateStub = stub
+}
+
+func (fake *FakeRemediatorI) RemediateArgsForCall(i int) (context.Context, statemanager.StateResource, provider.Change) {
+	fake.remediateMutex.RLock()
+	defer fake.remediateMutex.RUnlock()
+	argsForCall := fake.remediateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRemediatorI) RemediateReturns(result1 error) {
+	fake.remediateMutex.Lock()
+	defer fake.remediateMutex.Unlock()
+	fake.RemediateStub = nil
+	fake.remediateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRemediatorI) RemediateReturnsOnCall(i int, result1 error) {
+	fake.remediateMutex.Lock()
+	defer fake.remediateMutex.Unlock()
+	fake.RemediateStub = nil
+	if fake.remediateReturnsOnCall == nil {
+		fake.remediateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.remediateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRemediatorI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.canRemediateMutex.RLock()
+	defer fake.canRemediateMutex.RUnlock()
+	fake.remediateMutex.RLock()
+	defer fake.remediateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRemediatorI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.RemediatorI = new(FakeRemediatorI)
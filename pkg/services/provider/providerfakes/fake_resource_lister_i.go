@@ -0,0 +1,118 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeResourceListerI struct {
+	ListResourceIdsStub        func(context.Context, string) ([]string, error)
+	listResourceIdsMutex       sync.RWMutex
+	listResourceIdsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listResourceIdsReturns struct {
+		result1 []string
+		result2 error
+	}
+	listResourceIdsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeResourceListerI) ListResourceIds(arg1 context.Context, arg2 string) ([]string, error) {
+	fake.listResourceIdsMutex.Lock()
+	ret, specificReturn := fake.listResourceIdsReturnsOnCall[len(fake.listResourceIdsArgsForCall)]
+	fake.listResourceIdsArgsForCall = append(fake.listResourceIdsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListResourceIdsStub
+	fakeReturns := fake.listResourceIdsReturns
+	fake.recordInvocation("ListResourceIds", []interface{}{arg1, arg2})
+	fake.listResourceIdsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeResourceListerI) ListResourceIdsCallCount() int {
+	fake.listResourceIdsMutex.RLock()
+	defer fake.listResourceIdsMutex.RUnlock()
+	return len(fake.listResourceIdsArgsForCall)
+}
+
+func (fake *FakeResourceListerI) ListResourceIdsCalls(stub func(context.Context, string) ([]string, error)) {
+	fake.listResourceIdsMutex.Lock()
+	defer fake.listResourceIdsMutex.Unlock()
+	fake.ListResourceIdsStub = stub
+}
+
+func (fake *FakeResourceListerI) ListResourceIdsArgsForCall(i int) (context.Context, string) {
+	fake.listResourceIdsMutex.RLock()
+	defer fake.listResourceIdsMutex.RUnlock()
+	argsForCall := fake.listResourceIdsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeResourceListerI) ListResourceIdsReturns(result1 []string, result2 error) {
+	fake.listResourceIdsMutex.Lock()
+	defer fake.listResourceIdsMutex.Unlock()
+	fake.ListResourceIdsStub = nil
+	fake.listResourceIdsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeResourceListerI) ListResourceIdsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.listResourceIdsMutex.Lock()
+	defer fake.listResourceIdsMutex.Unlock()
+	fake.ListResourceIdsStub = nil
+	if fake.listResourceIdsReturnsOnCall == nil {
+		fake.listResourceIdsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.listResourceIdsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeResourceListerI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.listResourceIdsMutex.RLock()
+	defer fake.listResourceIdsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeResourceListerI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.ResourceListerI = new(FakeResourceListerI)
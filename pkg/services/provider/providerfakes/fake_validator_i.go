@@ -0,0 +1,190 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeValidatorI struct {
+	CheckCredentialsStub        func(context.Context) error
+	checkCredentialsMutex       sync.RWMutex
+	checkCredentialsArgsForCall []struct {
+		arg1 context.Context
+	}
+	checkCredentialsReturns struct {
+		result1 error
+	}
+	checkCredentialsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SupportedAttributesStub        func(string) ([]string, error)
+	supportedAttributesMutex       sync.RWMutex
+	supportedAttributesArgsForCall []struct {
+		arg1 string
+	}
+	supportedAttributesReturns struct {
+		result1 []string
+		result2 error
+	}
+	supportedAttributesReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeValidatorI) CheckCredentials(arg1 context.Context) error {
+	fake.checkCredentialsMutex.Lock()
+	ret, specificReturn := fake.checkCredentialsReturnsOnCall[len(fake.checkCredentialsArgsForCall)]
+	fake.checkCredentialsArgsForCall = append(fake.checkCredentialsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.CheckCredentialsStub
+	fakeReturns := fake.checkCredentialsReturns
+	fake.recordInvocation("CheckCredentials", []interface{}{arg1})
+	fake.checkCredentialsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeValidatorI) CheckCredentialsCallCount() int {
+	fake.checkCredentialsMutex.RLock()
+	defer fake.checkCredentialsMutex.RUnlock()
+	return len(fake.checkCredentialsArgsForCall)
+}
+
+func (fake *FakeValidatorI) CheckCredentialsCalls(stub func(context.Context) error) {
+	fake.checkCredentialsMutex.Lock()
+	defer fake.checkCredentialsMutex.Unlock()
+	fake.CheckCredentialsStub = stub
+}
+
+func (fake *FakeValidatorI) CheckCredentialsArgsForCall(i int) context.Context {
+	fake.checkCredentialsMutex.RLock()
+	defer fake.checkCredentialsMutex.RUnlock()
+	argsForCall := fake.checkCredentialsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeValidatorI) CheckCredentialsReturns(result1 error) {
+	fake.checkCredentialsMutex.Lock()
+	defer fake.checkCredentialsMutex.Unlock()
+	fake.CheckCredentialsStub = nil
+	fake.checkCredentialsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeValidatorI) CheckCredentialsReturnsOnCall(i int, result1 error) {
+	fake.checkCredentialsMutex.Lock()
+	defer fake.checkCredentialsMutex.Unlock()
+	fake.CheckCredentialsStub = nil
+	if fake.checkCredentialsReturnsOnCall == nil {
+		fake.checkCredentialsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkCredentialsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeValidatorI) SupportedAttributes(arg1 string) ([]string, error) {
+	fake.supportedAttributesMutex.Lock()
+	ret, specificReturn := fake.supportedAttributesReturnsOnCall[len(fake.supportedAttributesArgsForCall)]
+	fake.supportedAttributesArgsForCall = append(fake.supportedAttributesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SupportedAttributesStub
+	fakeReturns := fake.supportedAttributesReturns
+	fake.recordInvocation("SupportedAttributes", []interface{}{arg1})
+	fake.supportedAttributesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeValidatorI) SupportedAttributesCallCount() int {
+	fake.supportedAttributesMutex.RLock()
+	defer fake.supportedAttributesMutex.RUnlock()
+	return len(fake.supportedAttributesArgsForCall)
+}
+
+func (fake *FakeValidatorI) SupportedAttributesCalls(stub func(string) ([]string, error)) {
+	fake.supportedAttributesMutex.Lock()
+	defer fake.supportedAttributesMutex.Unlock()
+	fake.SupportedAttributesStub = stub
+}
+
+func (fake *FakeValidatorI) SupportedAttributesArgsForCall(i int) string {
+	fake.supportedAttributesMutex.RLock()
+	defer fake.supportedAttributesMutex.RUnlock()
+	argsForCall := fake.supportedAttributesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeValidatorI) SupportedAttributesReturns(result1 []string, result2 error) {
+	fake.supportedAttributesMutex.Lock()
+	defer fake.supportedAttributesMutex.Unlock()
+	fake.SupportedAttributesStub = nil
+	fake.supportedAttributesReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeValidatorI) SupportedAttributesReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.supportedAttributesMutex.Lock()
+	defer fake.supportedAttributesMutex.Unlock()
+	fake.SupportedAttributesStub = nil
+	if fake.supportedAttributesReturnsOnCall == nil {
+		fake.supportedAttributesReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.supportedAttributesReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeValidatorI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkCredentialsMutex.RLock()
+	defer fake.checkCredentialsMutex.RUnlock()
+	fake.supportedAttributesMutex.RLock()
+	defer fake.supportedAttributesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeValidatorI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.ValidatorI = new(FakeValidatorI)
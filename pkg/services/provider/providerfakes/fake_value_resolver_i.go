@@ -0,0 +1,118 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package providerfakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider"
+	"sync"
+)
+
+type FakeValueResolverI struct {
+	ResolveValueStub        func(context.Context, string) (*provider.ResolvedValue, error)
+	resolveValueMutex       sync.RWMutex
+	resolveValueArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	resolveValueReturns struct {
+		result1 *provider.ResolvedValue
+		result2 error
+	}
+	resolveValueReturnsOnCall map[int]struct {
+		result1 *provider.ResolvedValue
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeValueResolverI) ResolveValue(arg1 context.Context, arg2 string) (*provider.ResolvedValue, error) {
+	fake.resolveValueMutex.Lock()
+	ret, specificReturn := fake.resolveValueReturnsOnCall[len(fake.resolveValueArgsForCall)]
+	fake.resolveValueArgsForCall = append(fake.resolveValueArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ResolveValueStub
+	fakeReturns := fake.resolveValueReturns
+	fake.recordInvocation("ResolveValue", []interface{}{arg1, arg2})
+	fake.resolveValueMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeValueResolverI) ResolveValueCallCount() int {
+	fake.resolveValueMutex.RLock()
+	defer fake.resolveValueMutex.RUnlock()
+	return len(fake.resolveValueArgsForCall)
+}
+
+func (fake *FakeValueResolverI) ResolveValueCalls(stub func(context.Context, string) (*provider.ResolvedValue, error)) {
+	fake.resolveValueMutex.Lock()
+	defer fake.resolveValueMutex.Unlock()
+	fake.ResolveValueStub = stub
+}
+
+func (fake *FakeValueResolverI) ResolveValueArgsForCall(i int) (context.Context, string) {
+	fake.resolveValueMutex.RLock()
+	defer fake.resolveValueMutex.RUnlock()
+	argsForCall := fake.resolveValueArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeValueResolverI) ResolveValueReturns(result1 *provider.ResolvedValue, result2 error) {
+	fake.resolveValueMutex.Lock()
+	defer fake.resolveValueMutex.Unlock()
+	fake.ResolveValueStub = nil
+	fake.resolveValueReturns = struct {
+		result1 *provider.ResolvedValue
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeValueResolverI) ResolveValueReturnsOnCall(i int, result1 *provider.ResolvedValue, result2 error) {
+	fake.resolveValueMutex.Lock()
+	defer fake.resolveValueMutex.Unlock()
+	fake.ResolveValueStub = nil
+	if fake.resolveValueReturnsOnCall == nil {
+		fake.resolveValueReturnsOnCall = make(map[int]struct {
+			result1 *provider.ResolvedValue
+			result2 error
+		})
+	}
+	fake.resolveValueReturnsOnCall[i] = struct {
+		result1 *provider.ResolvedValue
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeValueResolverI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resolveValueMutex.RLock()
+	defer fake.resolveValueMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeValueResolverI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ provider.ValueResolverI = new(FakeValueResolverI)
@@ -0,0 +1,165 @@
+// Package redact hides the values of sensitive attributes, such as passwords or
+// user data scripts, before drift reports are written, so that secrets do not leak
+// into JSON, CSV or any other report output.
+package redact
+
+import (
+	"crypto/sha256"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Mode selects how sensitive values are redacted.
+type Mode string
+
+const (
+	// ModeMask replaces sensitive values with a fixed placeholder.
+	ModeMask Mode = "mask"
+	// ModeHash replaces sensitive values with a truncated SHA-256 digest, so a changed
+	// value can still be told apart from an unchanged one without revealing it.
+	ModeHash Mode = "hash"
+	// ModeNone disables redaction.
+	ModeNone Mode = "none"
+)
+
+// Masked is the placeholder written in place of a masked value.
+const Masked = "(sensitive)"
+
+// DefaultPatterns match attributes that commonly hold secrets.
+var DefaultPatterns = []string{
+	"*password*",
+	"*secret*",
+	"*token*",
+	"*private_key*",
+	"user_data",
+	"user_data_base64",
+}
+
+// Redactor hides the values of attributes that are marked sensitive in the state or
+// whose name matches one of its patterns.
+type Redactor struct {
+	Patterns []string
+	Mode     Mode
+}
+
+// NewRedactor creates a new Redactor instance.
+// patterns: Case-insensitive glob patterns (path.Match syntax) of attribute names to redact.
+// mode: How sensitive values are redacted.
+func NewRedactor(patterns []string, mode Mode) (*Redactor, error) {
+	switch mode {
+	case ModeMask, ModeHash, ModeNone:
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q, expected mask, hash or none", mode)
+	}
+
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		normalized = append(normalized, pattern)
+	}
+
+	return &Redactor{
+		Patterns: normalized,
+		Mode:     mode,
+	}, nil
+}
+
+// IsSensitive reports whether attribute should be redacted. sensitive holds the
+// attributes the state marks as sensitive; nested attributes such as tags.Password
+// are sensitive when their parent is.
+func (r *Redactor) IsSensitive(attribute string, sensitive []string) bool {
+	if r == nil || r.Mode == ModeNone {
+		return false
+	}
+
+	for _, name := range sensitive {
+		if attribute == name || strings.HasPrefix(attribute, name+".") {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(attribute)
+	for _, pattern := range r.Patterns {
+		if ok, _ := path.Match(pattern, lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the redacted form of value. Empty values are kept so that a missing
+// attribute is still reported as missing.
+func (r *Redactor) Value(value any) any {
+	if value == nil {
+		return nil
+	}
+	text := fmt.Sprintf("%v", value)
+	if text == "" {
+		return value
+	}
+
+	if r.Mode == ModeHash {
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	}
+	return Masked
+}
+
+// RedactReport replaces the values of every sensitive drift item in report and drops
+// their excerpts, which quote the values. Items holding values resolved from a secret
+// are sensitive whatever their attribute, but keep the reference they were resolved
+// from.
+//
+// Parameters:
+//   - report: The drift report to redact in place
+//   - sensitive: The attributes the state marks as sensitive for the resource
+func (r *Redactor) RedactReport(report *driftchecker.DriftReport, sensitive []string) {
+	if r == nil || report == nil {
+		return
+	}
+
+	for i, item := range report.DriftDetails {
+		if !r.IsSensitive(item.Field, sensitive) && !(item.Sensitive && r.Mode != ModeNone) {
+			continue
+		}
+		report.DriftDetails[i].TerraformValue = r.Value(item.TerraformValue)
+		report.DriftDetails[i].ActualValue = r.Value(item.ActualValue)
+		report.DriftDetails[i].Excerpt = ""
+	}
+}
+
+// RedactAttributes returns a copy of attributes with the values of sensitive
+// attributes replaced, descending into nested maps so that tags.Password is
+// redacted as well. attributes itself is not modified.
+func (r *Redactor) RedactAttributes(attributes map[string]any, sensitive []string) map[string]any {
+	if r == nil || r.Mode == ModeNone {
+		return attributes
+	}
+	return r.redactMap("", attributes, sensitive)
+}
+
+func (r *Redactor) redactMap(prefix string, attributes map[string]any, sensitive []string) map[string]any {
+	redacted := make(map[string]any, len(attributes))
+	for key, value := range attributes {
+		name := prefix + key
+		nested, isMap := value.(map[string]any)
+		switch {
+		case r.IsSensitive(name, sensitive):
+			redacted[key] = r.Value(value)
+		case isMap:
+			redacted[key] = r.redactMap(name+".", nested, sensitive)
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,116 @@
+package redact_test
+
+import (
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/redact"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedactor_InvalidInput(t *testing.T) {
+	_, err := redact.NewRedactor(nil, "scramble")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown redaction mode")
+
+	_, err = redact.NewRedactor([]string{"[password"}, redact.ModeMask)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid redaction pattern")
+}
+
+func TestRedactor_IsSensitive(t *testing.T) {
+	r, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+
+	assert.True(t, r.IsSensitive("user_data", nil))
+	assert.True(t, r.IsSensitive("master_PASSWORD", nil), "patterns are case-insensitive")
+	assert.True(t, r.IsSensitive("tags.api_token", nil))
+	assert.True(t, r.IsSensitive("connection_string", []string{"connection_string"}))
+	assert.True(t, r.IsSensitive("credentials.key", []string{"credentials"}), "children of sensitive attributes are sensitive")
+	assert.False(t, r.IsSensitive("instance_type", nil))
+
+	none, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeNone)
+	require.NoError(t, err)
+	assert.False(t, none.IsSensitive("user_data", []string{"user_data"}))
+}
+
+func TestRedactor_RedactReport(t *testing.T) {
+	report := &driftchecker.DriftReport{
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "user_data", TerraformValue: "echo secret", ActualValue: "echo other", DriftType: driftchecker.AttributeValueChanged, Excerpt: "@@ line 1 @@\n- echo secret\n+ echo other"},
+			{Field: "db_password", TerraformValue: "hunter2", ActualValue: "", DriftType: driftchecker.AttributeMissingInInfrastructure},
+		},
+	}
+
+	r, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+	r.RedactReport(report, nil)
+
+	assert.Equal(t, "t2.micro", report.DriftDetails[0].TerraformValue)
+	assert.Equal(t, redact.Masked, report.DriftDetails[1].TerraformValue)
+	assert.Equal(t, redact.Masked, report.DriftDetails[1].ActualValue)
+	assert.Empty(t, report.DriftDetails[1].Excerpt, "excerpts quote the values")
+	assert.Equal(t, redact.Masked, report.DriftDetails[2].TerraformValue)
+	assert.Equal(t, "", report.DriftDetails[2].ActualValue, "missing values stay empty")
+}
+
+func TestRedactor_RedactReport_ResolvedSecret(t *testing.T) {
+	newReport := func() *driftchecker.DriftReport {
+		return &driftchecker.DriftReport{
+			DriftDetails: []driftchecker.DriftItem{
+				{Field: "environment.DB_URL", TerraformValue: "postgres://db", ActualValue: "postgres://old", DriftType: driftchecker.AttributeValueChanged, Reference: "{{resolve:secretsmanager:db:SecretString:url}}", Sensitive: true},
+				{Field: "image_id", TerraformValue: "ami-1", ActualValue: "ami-1", Reference: "resolve:ssm:/ami/latest"},
+			},
+		}
+	}
+
+	r, err := redact.NewRedactor(nil, redact.ModeMask)
+	require.NoError(t, err)
+	report := newReport()
+	r.RedactReport(report, nil)
+	assert.Equal(t, redact.Masked, report.DriftDetails[0].TerraformValue)
+	assert.Equal(t, redact.Masked, report.DriftDetails[0].ActualValue)
+	assert.Equal(t, "{{resolve:secretsmanager:db:SecretString:url}}", report.DriftDetails[0].Reference, "references are not secret")
+	assert.Equal(t, "ami-1", report.DriftDetails[1].TerraformValue, "plain parameters are not sensitive")
+
+	r, err = redact.NewRedactor(nil, redact.ModeNone)
+	require.NoError(t, err)
+	report = newReport()
+	r.RedactReport(report, nil)
+	assert.Equal(t, "postgres://db", report.DriftDetails[0].TerraformValue)
+}
+
+func TestRedactor_HashMode(t *testing.T) {
+	r, err := redact.NewRedactor(nil, redact.ModeHash)
+	require.NoError(t, err)
+
+	first := r.Value("hunter2")
+	assert.True(t, strings.HasPrefix(first.(string), "sha256:"))
+	assert.NotContains(t, first, "hunter2")
+	assert.Equal(t, first, r.Value("hunter2"), "hashes are stable")
+	assert.NotEqual(t, first, r.Value("hunter3"))
+}
+
+func TestRedactor_RedactAttributes(t *testing.T) {
+	attributes := map[string]any{
+		"instance_type": "t2.micro",
+		"user_data":     "echo secret",
+		"credentials":   "key",
+		"tags":          map[string]any{"Env": "prod", "api_token": "abc"},
+	}
+
+	r, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+	redacted := r.RedactAttributes(attributes, []string{"credentials"})
+
+	assert.Equal(t, map[string]any{
+		"instance_type": "t2.micro",
+		"user_data":     redact.Masked,
+		"credentials":   redact.Masked,
+		"tags":          map[string]any{"Env": "prod", "api_token": redact.Masked},
+	}, redacted)
+	assert.Equal(t, "echo secret", attributes["user_data"], "the attributes are not modified")
+}
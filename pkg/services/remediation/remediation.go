@@ -0,0 +1,131 @@
+// Package remediation reverts drift on live infrastructure by applying the values
+// recorded in the state file. Only attributes the provider marks as safe to change
+// are remediated, and every change can be confirmed individually before it is applied.
+package remediation
+
+import (
+	"bufio"
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ConfirmFunc asks whether a change should be applied and returns the answer.
+type ConfirmFunc func(resource statemanager.StateResource, change provider.Change) (bool, error)
+
+// Engine applies remediations for drifted attributes through a provider.
+// Confirmation prompts are serialised so that concurrent workers never interleave them.
+type Engine struct {
+	Remediator provider.RemediatorI
+	Confirm    ConfirmFunc
+	// Redactor, when set, hides sensitive values in log output.
+	Redactor *redact.Redactor
+
+	mu sync.Mutex
+}
+
+// NewEngine creates a new Engine instance.
+// remediator: The provider used to apply changes.
+// confirm: Called before each change; a nil confirm applies every change without asking.
+func NewEngine(remediator provider.RemediatorI, confirm ConfirmFunc) *Engine {
+	return &Engine{
+		Remediator: remediator,
+		Confirm:    confirm,
+	}
+}
+
+// Remediate reverts every drifted attribute of report that the provider can remediate.
+// Items that were changed successfully are marked as remediated on the report.
+// A failed change is logged and does not stop the remaining changes.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - resource: The desired state of the resource from the state file
+//   - report: The drift report produced for the resource
+//
+// Returns:
+//   - int: The number of attributes that were remediated
+//   - error: An error if confirmation could not be obtained
+func (e *Engine) Remediate(ctx context.Context, resource statemanager.StateResource, report *driftchecker.DriftReport) (int, error) {
+	if report == nil || !report.HasDrift {
+		return 0, nil
+	}
+
+	remediated := 0
+	for i, item := range report.DriftDetails {
+		if item.DriftType == driftchecker.Match {
+			continue
+		}
+		if !e.Remediator.CanRemediate(resource.ResourceType(), item.Field) {
+			logger(ctx).Debug("Attribute is not on the remediation allowlist", "resource", resource.Name, "attribute", item.Field)
+			continue
+		}
+
+		change := provider.Change{
+			Attribute:    item.Field,
+			DesiredValue: fmt.Sprintf("%v", item.TerraformValue),
+			ActualValue:  fmt.Sprintf("%v", item.ActualValue),
+		}
+
+		if e.Confirm != nil {
+			e.mu.Lock()
+			ok, err := e.Confirm(resource, change)
+			e.mu.Unlock()
+			if err != nil {
+				return remediated, err
+			}
+			if !ok {
+				logger(ctx).Info("Skipping remediation", "resource", resource.Name, "attribute", item.Field)
+				continue
+			}
+		}
+
+		if err := e.Remediator.Remediate(ctx, resource, change); err != nil {
+			logger(ctx).Error("Failed to remediate attribute", "resource", resource.Name, "attribute", item.Field, "error", err)
+			continue
+		}
+		var value any = change.DesiredValue
+		if e.Redactor.IsSensitive(item.Field, resource.SensitiveAttributes()) {
+			value = e.Redactor.Value(value)
+		}
+		logger(ctx).Info("Remediated attribute", "resource", resource.Name, "attribute", item.Field, "value", value)
+		report.DriftDetails[i].Remediated = true
+		remediated++
+	}
+
+	return remediated, nil
+}
+
+// PromptConfirm returns a ConfirmFunc that asks on out and reads a y/N answer from in.
+// The values of sensitive attributes are shown redacted by redactor, which may be nil
+// to show every value.
+func PromptConfirm(in io.Reader, out io.Writer, redactor *redact.Redactor) ConfirmFunc {
+	reader := bufio.NewReader(in)
+	return func(resource statemanager.StateResource, change provider.Change) (bool, error) {
+		actual, desired := change.ActualValue, change.DesiredValue
+		if redactor.IsSensitive(change.Attribute, resource.SensitiveAttributes()) {
+			actual, desired = fmt.Sprint(redactor.Value(actual)), fmt.Sprint(redactor.Value(desired))
+		}
+		fmt.Fprintf(out, "Remediate %s.%s: change %s from %q to %q? [y/N] ",
+			resource.ResourceType(), resource.Name, change.Attribute, actual, desired)
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	}
+}
+
+// logger returns the logger carried by ctx for the remediation module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "remediation")
+}
@@ -0,0 +1,128 @@
+package remediation_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/provider/providerfakes"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/remediation"
+	"drift-watcher/pkg/services/statemanager"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func driftReport() *driftchecker.DriftReport {
+	return &driftchecker.DriftReport{
+		HasDrift: true,
+		Status:   driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: "t2.large", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "ami", TerraformValue: "ami-1", ActualValue: "ami-2", DriftType: driftchecker.AttributeValueChanged},
+			{Field: "tags.Name", TerraformValue: "web", ActualValue: "web", DriftType: driftchecker.Match},
+		},
+	}
+}
+
+func allowInstanceType(resourceType string, attribute string) bool {
+	return attribute == "instance_type"
+}
+
+func TestEngine_Remediate_AllowlistedOnly(t *testing.T) {
+	fake := &providerfakes.FakeRemediatorI{}
+	fake.CanRemediateCalls(allowInstanceType)
+	resource := statemanager.StateResource{Type: "aws_instance", Name: "web"}
+	report := driftReport()
+
+	count, err := remediation.NewEngine(fake, nil).Remediate(context.Background(), resource, report)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.Equal(t, 1, fake.RemediateCallCount())
+	_, gotResource, change := fake.RemediateArgsForCall(0)
+	assert.Equal(t, resource, gotResource)
+	assert.Equal(t, provider.Change{Attribute: "instance_type", DesiredValue: "t2.micro", ActualValue: "t2.large"}, change)
+
+	assert.True(t, report.DriftDetails[0].Remediated)
+	assert.False(t, report.DriftDetails[1].Remediated)
+}
+
+func TestEngine_Remediate_DeclinedAndFailed(t *testing.T) {
+	fake := &providerfakes.FakeRemediatorI{}
+	fake.CanRemediateReturns(true)
+	fake.RemediateReturns(errors.New("api error"))
+
+	confirmed := 0
+	confirm := func(resource statemanager.StateResource, change provider.Change) (bool, error) {
+		confirmed++
+		return change.Attribute == "ami", nil
+	}
+
+	report := driftReport()
+	count, err := remediation.NewEngine(fake, confirm).Remediate(context.Background(), statemanager.StateResource{Type: "aws_instance"}, report)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "declined and failed changes are not counted")
+	assert.Equal(t, 2, confirmed, "matching attributes are never offered")
+	assert.Equal(t, 1, fake.RemediateCallCount())
+	assert.False(t, report.DriftDetails[1].Remediated)
+}
+
+func TestEngine_Remediate_NoDrift(t *testing.T) {
+	fake := &providerfakes.FakeRemediatorI{}
+	count, err := remediation.NewEngine(fake, nil).Remediate(context.Background(), statemanager.StateResource{}, &driftchecker.DriftReport{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Zero(t, fake.CanRemediateCallCount())
+}
+
+func TestPromptConfirm(t *testing.T) {
+	resource := statemanager.StateResource{Type: "aws_instance", Name: "web"}
+	change := provider.Change{Attribute: "instance_type", DesiredValue: "t2.micro", ActualValue: "t2.large"}
+
+	var out bytes.Buffer
+	confirm := remediation.PromptConfirm(strings.NewReader("y\nno\n"), &out, nil)
+
+	ok, err := confirm(resource, change)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, out.String(), `Remediate aws_instance.web: change instance_type from "t2.large" to "t2.micro"? [y/N]`)
+
+	ok, err = confirm(resource, change)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// end of input declines
+	ok, err = confirm(resource, change)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPromptConfirm_RedactsSensitiveValues(t *testing.T) {
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+	resource := statemanager.StateResource{
+		Type: "aws_instance",
+		Name: "web",
+		Instances: []statemanager.ResourceInstance{
+			{SensitiveAttributes: []string{"tags"}},
+		},
+	}
+
+	var out bytes.Buffer
+	confirm := remediation.PromptConfirm(strings.NewReader("n\nn\n"), &out, redactor)
+	_, err = confirm(resource, provider.Change{Attribute: "tags.ApiToken", DesiredValue: "tok-desired", ActualValue: "tok-actual"})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `change tags.ApiToken from "(sensitive)" to "(sensitive)"?`)
+
+	// attributes the state marks as sensitive are redacted whatever their name
+	resource.Instances[0].SensitiveAttributes = []string{"instance_type"}
+	_, err = confirm(resource, provider.Change{Attribute: "instance_type", DesiredValue: "t2.micro", ActualValue: "t2.large"})
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "tok-")
+	assert.NotContains(t, out.String(), "t2.")
+}
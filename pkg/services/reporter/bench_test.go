@@ -0,0 +1,87 @@
+package reporter_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchmarkReports is the number of reports written per benchmark iteration, one per
+// resource of a large production workspace.
+const benchmarkReports = 5000
+
+// benchmarkRun returns the n reports of a run in which one resource in ten has
+// drifted.
+func benchmarkRun(n int) []*driftchecker.DriftReport {
+	run := &driftchecker.RunMetadata{RunId: "run-bench", StartedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	reports := make([]*driftchecker.DriftReport, n)
+	for i := range n {
+		report := &driftchecker.DriftReport{
+			ResourceId:      fmt.Sprintf("i-%017x", i),
+			ResourceType:    "aws_instance",
+			ResourceName:    fmt.Sprintf("web_%d", i),
+			ResourceAddress: fmt.Sprintf("module.service_%d.aws_instance.web_%d", i%50, i),
+			GeneratedAt:     run.StartedAt,
+			Status:          driftchecker.Match,
+			Run:             run,
+			DriftDetails: []driftchecker.DriftItem{
+				{Field: "instance_type", TerraformValue: "t3.micro", ActualValue: "t3.micro", DriftType: driftchecker.Match},
+				{Field: "ami", TerraformValue: "ami-0c55b159cbfafe1f0", ActualValue: "ami-0c55b159cbfafe1f0", DriftType: driftchecker.Match},
+			},
+		}
+		if i%10 == 0 {
+			report.HasDrift, report.Status = true, driftchecker.Drift
+			report.DriftDetails = append(report.DriftDetails,
+				driftchecker.DriftItem{Field: "tags.Environment", TerraformValue: "production", ActualValue: "staging", DriftType: driftchecker.AttributeValueChanged},
+				driftchecker.DriftItem{Field: "vpc_security_group_ids", TerraformValue: "sg-0000000a,sg-0000000b", ActualValue: "sg-0000000a", DriftType: driftchecker.AttributeValueChanged},
+			)
+			report.Dependents = []string{"aws_eip.web", "aws_route53_record.web"}
+		}
+		reports[i] = report
+	}
+	return reports
+}
+
+// benchmarkWriter writes the reports to the writer returned by newWriter, then
+// flushes it, once per iteration.
+func benchmarkWriter(b *testing.B, newWriter func() reporter.OutputWriter) {
+	ctx := context.Background()
+	reports := benchmarkRun(benchmarkReports)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		w := newWriter()
+		for _, report := range reports {
+			if err := w.WriteReport(ctx, report); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := reporter.FlushWriter(ctx, w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNDJSONReporter(b *testing.B) {
+	benchmarkWriter(b, func() reporter.OutputWriter {
+		return reporter.NewNDJSONReporter(io.Discard)
+	})
+}
+
+func BenchmarkCsvReporter(b *testing.B) {
+	outputFile := filepath.Join(b.TempDir(), "reports.csv")
+	benchmarkWriter(b, func() reporter.OutputWriter {
+		return reporter.NewCsvReporter(outputFile)
+	})
+}
+
+func BenchmarkDiffReporter(b *testing.B) {
+	benchmarkWriter(b, func() reporter.OutputWriter {
+		return reporter.NewDiffReporter(io.Discard, false)
+	})
+}
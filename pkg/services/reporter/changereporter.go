@@ -0,0 +1,114 @@
+package reporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// ChangeReporter implements OutputWriter by forwarding a report to the next writer
+// only when a resource's drift changed since the last report seen for it: a new
+// attribute drifted, a drifted value changed, or the drift was resolved. Resolved
+// drift is forwarded as a report with the DriftResolved status. This keeps repeated
+// checks, such as watch mode polls, from notifying about the same drift every time.
+type ChangeReporter struct {
+	Next OutputWriter
+
+	mu      sync.Mutex
+	last    map[string]string
+	drifted map[string]bool
+}
+
+// NewChangeReporter creates a new ChangeReporter instance.
+// next: The OutputWriter that receives the reports that changed.
+func NewChangeReporter(next OutputWriter) *ChangeReporter {
+	return &ChangeReporter{
+		Next:    next,
+		last:    map[string]string{},
+		drifted: map[string]bool{},
+	}
+}
+
+// WriteReport forwards the report when its drift differs from the previous report for
+// the same resource. Resources that have never drifted are not reported. Failed
+// checks are always forwarded and leave the last drift seen for the resource as is.
+func (c *ChangeReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	if report.Status == driftchecker.CheckFailed {
+		return c.Next.WriteReport(ctx, report)
+	}
+	key := resourceLabel(report)
+	fingerprint := Fingerprint(report)
+
+	c.mu.Lock()
+	previous, seen := c.last[key]
+	wasDrifted := c.drifted[key]
+	c.last[key] = fingerprint
+	c.drifted[key] = report.HasDrift
+	c.mu.Unlock()
+
+	switch {
+	case !report.HasDrift && wasDrifted:
+		resolved := *report
+		resolved.Status = driftchecker.DriftResolved
+		return c.Next.WriteReport(ctx, &resolved)
+	case !report.HasDrift:
+		return nil
+	case seen && previous == fingerprint:
+		return nil
+	default:
+		return c.Next.WriteReport(ctx, report)
+	}
+}
+
+// Begin begins the run of the next writer. The drift seen in earlier runs is kept, so
+// that only changes are forwarded.
+func (c *ChangeReporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	return BeginWriter(ctx, c.Next, run)
+}
+
+// Flush flushes the next writer if it buffers output.
+func (c *ChangeReporter) Flush(ctx context.Context) error {
+	return FlushWriter(ctx, c.Next)
+}
+
+// Close closes the next writer.
+func (c *ChangeReporter) Close() error {
+	return CloseWriter(c.Next)
+}
+
+// Fingerprint returns a digest of the drift in report that ignores attributes that
+// match and the time the report was generated, so that two reports of the same drift
+// share a fingerprint.
+func Fingerprint(report *driftchecker.DriftReport) string {
+	type drifted struct {
+		Field          string `json:"field"`
+		DriftType      string `json:"drift_type"`
+		TerraformValue any    `json:"terraform_value"`
+		ActualValue    any    `json:"actual_value"`
+	}
+
+	var items []drifted
+	for _, item := range report.DriftDetails {
+		if item.DriftType == driftchecker.Match {
+			continue
+		}
+		items = append(items, drifted{
+			Field:          item.Field,
+			DriftType:      item.DriftType,
+			TerraformValue: item.TerraformValue,
+			ActualValue:    item.ActualValue,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Field < items[j].Field })
+
+	encoded, _ := json.Marshal(struct {
+		Status string    `json:"status"`
+		Items  []drifted `json:"items"`
+	}{Status: report.Status, Items: items})
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package reporter_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func driftReport(actual string) *driftchecker.DriftReport {
+	report := &driftchecker.DriftReport{
+		ResourceId:   "i-123",
+		ResourceType: "aws_instance",
+		GeneratedAt:  time.Now(),
+		Status:       driftchecker.Match,
+	}
+	if actual != "" {
+		report.HasDrift = true
+		report.Status = driftchecker.Drift
+		report.DriftDetails = []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: actual, DriftType: driftchecker.AttributeValueChanged},
+		}
+	}
+	return report
+}
+
+func TestChangeReporter_OnlyForwardsChanges(t *testing.T) {
+	ctx := context.Background()
+	next := &reporterfakes.FakeOutputWriter{}
+	r := reporter.NewChangeReporter(next)
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("")))
+	assert.Equal(t, 0, next.WriteReportCallCount(), "resources without drift are not reported")
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("t2.large")))
+	require.NoError(t, r.WriteReport(ctx, driftReport("t2.large")))
+	assert.Equal(t, 1, next.WriteReportCallCount(), "unchanged drift is reported once")
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("t2.xlarge")))
+	assert.Equal(t, 2, next.WriteReportCallCount(), "a changed drift value is reported")
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("")))
+	require.Equal(t, 3, next.WriteReportCallCount())
+	_, resolved := next.WriteReportArgsForCall(2)
+	assert.Equal(t, driftchecker.DriftResolved, resolved.Status)
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("")))
+	assert.Equal(t, 3, next.WriteReportCallCount(), "resolution is reported once")
+}
+
+func TestChangeReporter_CheckFailedKeepsDrift(t *testing.T) {
+	ctx := context.Background()
+	next := &reporterfakes.FakeOutputWriter{}
+	r := reporter.NewChangeReporter(next)
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("t2.large")))
+	failed := &driftchecker.DriftReport{
+		ResourceId:   "i-123",
+		ResourceType: "aws_instance",
+		Status:       driftchecker.CheckFailed,
+		Failure:      &driftchecker.CheckFailure{Class: driftchecker.FailureThrottled, Error: "Rate exceeded"},
+	}
+	require.NoError(t, r.WriteReport(ctx, failed))
+	require.Equal(t, 2, next.WriteReportCallCount(), "failed checks are reported")
+	_, forwarded := next.WriteReportArgsForCall(1)
+	assert.Equal(t, driftchecker.CheckFailed, forwarded.Status, "a failed check does not resolve the drift")
+
+	require.NoError(t, r.WriteReport(ctx, driftReport("t2.large")))
+	assert.Equal(t, 2, next.WriteReportCallCount(), "the drift seen before the failed check is unchanged")
+}
+
+func TestFingerprint_IgnoresMatchesAndTime(t *testing.T) {
+	a := driftReport("t2.large")
+	b := driftReport("t2.large")
+	b.GeneratedAt = a.GeneratedAt.Add(time.Hour)
+	b.DriftDetails = append(b.DriftDetails, driftchecker.DriftItem{Field: "ami", DriftType: driftchecker.Match})
+
+	assert.Equal(t, reporter.Fingerprint(a), reporter.Fingerprint(b))
+	assert.NotEqual(t, reporter.Fingerprint(a), reporter.Fingerprint(driftReport("t2.xlarge")))
+}
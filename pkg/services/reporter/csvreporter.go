@@ -3,16 +3,33 @@ package reporter
 import (
 	"context"
 	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
 // CsvReporter implements OutputWriter to write reports to a CSV file.
+// The file is opened when the first run begins, or on its first report, and kept
+// open until Close, so every resource of a multi-resource run, and every run of watch
+// mode, ends up in the same file. With Append set, rows are added to an existing file
+// instead of replacing it, and the RunId column tells the runs apart. Without a RunId
+// the run id stamped on the report is used.
 type CsvReporter struct {
 	OutputFile string
+	Append     bool
+	RunId      string
+
+	mu      sync.Mutex
+	file    *os.File
+	writer  *csv.Writer
+	started bool
 }
 
 // NewCsvReporter creates a new CsvReporter instance.
@@ -23,9 +40,25 @@ func NewCsvReporter(outputFile string) *CsvReporter {
 	}
 }
 
-// WriteReport converts the DriftReport into CSV format and writes it to the configured file.
-// Each row in the CSV represents a single DriftItem, or a summary row if no drift.
-func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+// csvHeader is the header row written at the top of every new CSV file.
+var csvHeader = []string{
+	"GeneratedAt",
+	"ResourceId",
+	"ResourceType",
+	"ResourceName", // Corrected typo in comments, assuming 'resource_name'
+	"HasDrift",
+	"ReportStatus", // Overall report status (MATCH/DRIFT)
+	"DriftField",
+	"TerraformValue",
+	"ActualValue",
+	"DriftType", // Specific drift item type
+	"RunId",
+	"ResourceAddress",
+}
+
+// open opens the output file and writes the header when the file is new. Once a run
+// has started, reopening after a Close always appends so earlier rows survive.
+func (c *CsvReporter) open() error {
 	// Ensure the output directory exists
 	outputDir := filepath.Dir(c.OutputFile)
 	if outputDir != "" {
@@ -34,30 +67,124 @@ func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.Drif
 		}
 	}
 
-	file, err := os.Create(c.OutputFile)
+	appendRows := c.Append || c.started
+	if c.Append && !c.started {
+		if err := upgradeCsvHeader(c.OutputFile); err != nil {
+			return err
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendRows {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(c.OutputFile, flags, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV output file %s: %w", c.OutputFile, err)
 	}
-	defer file.Close() // Ensure the file is closed after function returns
 
-	csvWriter := csv.NewWriter(file)
-	defer csvWriter.Flush() // Ensure all buffered data is written to the file
+	writeHeader := true
+	if appendRows {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to stat CSV output file %s: %w", c.OutputFile, err)
+		}
+		writeHeader = info.Size() == 0
+	}
+
+	c.file = file
+	c.writer = csv.NewWriter(file)
+	c.started = true
+
+	if writeHeader {
+		if err := c.writer.Write(csvHeader); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		c.writer.Flush()
+	}
+	return nil
+}
+
+// upgradeCsvHeader prepares an existing file for appending rows with csvHeader. A file
+// written by an earlier release, whose header lacks the columns added since, such as
+// RunId, is rewritten with the current header and its rows padded with empty values,
+// so every row has as many columns as the header. A file with any other header is
+// left alone and reported as an error, as appending would mix unrelated columns.
+func upgradeCsvHeader(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open CSV output file %s: %w", path, err)
+	}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV output file %s: %w", path, err)
+	}
+	if len(records) == 0 || slices.Equal(records[0], csvHeader) {
+		return nil
+	}
+	header := records[0]
+	if len(header) > len(csvHeader) || !slices.Equal(header, csvHeader[:len(header)]) {
+		return fmt.Errorf("cannot append to CSV output file %s: its columns %s do not match %s, write to a new file or run without --append", path, strings.Join(header, ","), strings.Join(csvHeader, ","))
+	}
+
+	upgraded, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to upgrade the header of CSV output file %s: %w", path, err)
+	}
+	defer os.Remove(upgraded.Name())
+	writer := csv.NewWriter(upgraded)
+	_ = writer.Write(csvHeader)
+	for _, record := range records[1:] {
+		_ = writer.Write(append(record, make([]string, max(0, len(csvHeader)-len(record)))...))
+	}
+	writer.Flush()
+	if err := errors.Join(writer.Error(), upgraded.Chmod(0644), upgraded.Close()); err != nil {
+		return fmt.Errorf("failed to upgrade the header of CSV output file %s: %w", path, err)
+	}
+	if err := os.Rename(upgraded.Name(), path); err != nil {
+		return fmt.Errorf("failed to upgrade the header of CSV output file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Begin opens the output file, unless an earlier run left it open.
+func (c *CsvReporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file != nil {
+		return nil
+	}
+	return c.open()
+}
+
+// WriteReport converts the DriftReport into CSV format and writes it to the configured file.
+// Each row in the CSV represents a single DriftItem, or a summary row if no drift.
+// Rows are flushed to disk after every report; the file itself stays open until Close.
+func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "CsvReporter.WriteReport")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Define CSV header
-	header := []string{
-		"GeneratedAt",
-		"ResourceId",
-		"ResourceType",
-		"ResourceName", // Corrected typo in comments, assuming 'resource_name'
-		"HasDrift",
-		"ReportStatus", // Overall report status (MATCH/DRIFT)
-		"DriftField",
-		"TerraformValue",
-		"ActualValue",
-		"DriftType", // Specific drift item type
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			return err
+		}
 	}
-	if err := csvWriter.Write(header); err != nil {
-		return fmt.Errorf("failed to write CSV header: %w", err)
+	csvWriter := c.writer
+	defer csvWriter.Flush() // Ensure all buffered data is written to the file
+
+	runId := c.RunId
+	if runId == "" && report.Run != nil {
+		runId = report.Run.RunId
 	}
 
 	// Handle the case where there is no specific drift details but we still want a record
@@ -66,13 +193,15 @@ func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.Drif
 			report.GeneratedAt.Format(time.RFC3339),
 			report.ResourceId,
 			report.ResourceType,
-			report.ResourceName,                // Using the field name from your struct. If this is `resource_nae`, it might still be a typo.
+			report.ResourceName,
 			fmt.Sprintf("%t", report.HasDrift), // Convert bool to string
 			report.Status,
 			"", // DriftField (empty for no drift)
 			"", // TerraformValue (empty for no drift)
 			"", // ActualValue (empty for no drift)
 			"", // DriftType (empty for no drift)
+			runId,
+			report.ResourceAddress,
 		}
 		if err := csvWriter.Write(row); err != nil {
 			return fmt.Errorf("failed to write no-drift summary row to CSV: %w", err)
@@ -84,13 +213,15 @@ func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.Drif
 				report.GeneratedAt.Format(time.RFC3339),
 				report.ResourceId,
 				report.ResourceType,
-				report.ResourceName, // Using the field name from your struct. If this is `resource_nae`, it might still be a typo.
+				report.ResourceName,
 				fmt.Sprintf("%t", report.HasDrift),
 				report.Status,
 				item.Field,
 				fmt.Sprintf("%v", item.TerraformValue), // Convert any to string
 				fmt.Sprintf("%v", item.ActualValue),    // Convert any to string
 				string(item.DriftType),                 // Convert custom type to string
+				runId,
+				report.ResourceAddress,
 			}
 			if err := csvWriter.Write(row); err != nil {
 				return fmt.Errorf("failed to write drift item row to CSV: %w", err)
@@ -98,6 +229,47 @@ func (c *CsvReporter) WriteReport(ctx context.Context, report *driftchecker.Drif
 		}
 	}
 
+	return nil
+}
+
+// Flush writes any buffered rows at the end of a run. The file stays open for the
+// next run.
+func (c *CsvReporter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV output file %s: %w", c.OutputFile, err)
+	}
+
 	fmt.Printf("Drift report successfully written to: %s (CSV format)\n", c.OutputFile)
 	return nil
 }
+
+// Close writes any buffered rows and closes the output file.
+func (c *CsvReporter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	c.writer.Flush()
+	writeErr := c.writer.Error()
+	closeErr := c.file.Close()
+	c.file = nil
+	c.writer = nil
+	if writeErr != nil {
+		return fmt.Errorf("failed to flush CSV output file %s: %w", c.OutputFile, writeErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close CSV output file %s: %w", c.OutputFile, closeErr)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/csv"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -143,6 +144,9 @@ func TestCsvReporter_WriteReport_WithDrift(t *testing.T) {
 }
 
 func TestCsvReporter_WriteReport_CreateFileError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions cannot deny access on Windows")
+	}
 	// Create a directory that exists but is not writable
 	tmpDir := t.TempDir()
 	nonWritableFile := filepath.Join(tmpDir, "non_writable.csv")
@@ -220,6 +224,151 @@ func TestCsvReporter_WriteReport_NoOutputDir(t *testing.T) {
 	assert.Contains(t, string(data), "GeneratedAt,ResourceId") // Check header
 }
 
+func readCsvRecords(t *testing.T, path string) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestCsvReporter_WriteReport_AggregatesRun(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	ctx := context.Background()
+
+	r := reporter.NewCsvReporter(outputFile)
+	r.RunId = "run-1"
+	require.NoError(t, r.WriteReport(ctx, createDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, createDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+
+	records := readCsvRecords(t, outputFile)
+	assert.Len(t, records, 4) // Header + 2 drift rows + 1 summary row
+	assert.Equal(t, "RunId", records[0][10])
+	for _, record := range records[1:] {
+		assert.Equal(t, "run-1", record[10])
+	}
+}
+
+func TestCsvReporter_WriteReport_AppendAcrossRuns(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	ctx := context.Background()
+
+	first := reporter.NewCsvReporter(outputFile)
+	first.RunId = "run-1"
+	require.NoError(t, first.WriteReport(ctx, createDummyDriftReport(false)))
+	require.NoError(t, first.Flush(ctx))
+
+	second := reporter.NewCsvReporter(outputFile)
+	second.Append = true
+	second.RunId = "run-2"
+	require.NoError(t, second.WriteReport(ctx, createDummyDriftReport(false)))
+	require.NoError(t, second.Flush(ctx))
+
+	records := readCsvRecords(t, outputFile)
+	require.Len(t, records, 3) // a single header followed by one row per run
+	assert.Equal(t, "GeneratedAt", records[0][0])
+	assert.Equal(t, "run-1", records[1][10])
+	assert.Equal(t, "run-2", records[2][10])
+
+	// without append a new run replaces the file
+	third := reporter.NewCsvReporter(outputFile)
+	third.RunId = "run-3"
+	require.NoError(t, third.WriteReport(ctx, createDummyDriftReport(false)))
+	require.NoError(t, third.Flush(ctx))
+
+	records = readCsvRecords(t, outputFile)
+	require.Len(t, records, 2)
+	assert.Equal(t, "run-3", records[1][10])
+}
+
+func TestCsvReporter_WriteReport_AppendUpgradesHeader(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	ctx := context.Background()
+	// a file written before the RunId and ResourceAddress columns were added
+	older := "GeneratedAt,ResourceId,ResourceType,ResourceName,HasDrift,ReportStatus,DriftField,TerraformValue,ActualValue,DriftType\n" +
+		"2023-01-01T00:00:00Z,res-1,aws_instance,web,false,MATCH,,,,\n"
+	require.NoError(t, os.WriteFile(outputFile, []byte(older), 0644))
+
+	r := reporter.NewCsvReporter(outputFile)
+	r.Append = true
+	r.RunId = "run-2"
+	require.NoError(t, r.WriteReport(ctx, createDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+
+	records := readCsvRecords(t, outputFile)
+	require.Len(t, records, 3)
+	assert.Equal(t, "RunId", records[0][10], "the header is upgraded")
+	for _, record := range records {
+		assert.Len(t, record, len(records[0]), "every row has as many columns as the header")
+	}
+	assert.Equal(t, "res-1", records[1][1])
+	assert.Equal(t, "", records[1][10])
+	assert.Equal(t, "run-2", records[2][10])
+}
+
+func TestCsvReporter_WriteReport_AppendForeignHeader(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, os.WriteFile(outputFile, []byte("id,name\n1,web\n"), 0644))
+
+	r := reporter.NewCsvReporter(outputFile)
+	r.Append = true
+	err := r.WriteReport(context.Background(), createDummyDriftReport(false))
+	assert.ErrorContains(t, err, "do not match")
+
+	content, readErr := os.ReadFile(outputFile)
+	require.NoError(t, readErr)
+	assert.Equal(t, "id,name\n1,web\n", string(content), "the file is left alone")
+}
+
+func TestCsvReporter_WriteReport_RunIdFromReport(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	ctx := context.Background()
+
+	r := reporter.NewCsvReporter(outputFile)
+	report := createDummyDriftReport(false)
+	report.Run = &driftchecker.RunMetadata{RunId: "run-1"}
+	require.NoError(t, r.WriteReport(ctx, report))
+	require.NoError(t, r.Flush(ctx))
+
+	records := readCsvRecords(t, outputFile)
+	require.Len(t, records, 2)
+	assert.Equal(t, "run-1", records[1][10])
+}
+
+func TestCsvReporter_Lifecycle(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	ctx := context.Background()
+
+	r := reporter.NewCsvReporter(outputFile)
+	require.NoError(t, r.Begin(ctx, &driftchecker.RunMetadata{RunId: "run-1"}))
+	records := readCsvRecords(t, outputFile)
+	require.Len(t, records, 1, "the file is created with its header when the run begins")
+
+	// the runs of watch mode end up in the same file until it is closed
+	for _, runId := range []string{"run-1", "run-2"} {
+		require.NoError(t, r.Begin(ctx, &driftchecker.RunMetadata{RunId: runId}))
+		report := createDummyDriftReport(false)
+		report.Run = &driftchecker.RunMetadata{RunId: runId}
+		require.NoError(t, r.WriteReport(ctx, report))
+		require.NoError(t, r.Flush(ctx))
+	}
+	require.NoError(t, r.Close())
+
+	records = readCsvRecords(t, outputFile)
+	require.Len(t, records, 3)
+	assert.Equal(t, "run-1", records[1][10])
+	assert.Equal(t, "run-2", records[2][10])
+}
+
+func TestCsvReporter_Flush_WithoutReports(t *testing.T) {
+	r := reporter.NewCsvReporter(filepath.Join(t.TempDir(), "never-written.csv"))
+	assert.NoError(t, r.Flush(context.Background()))
+	_, err := os.Stat(r.OutputFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
 // Mocking os.Create to simulate an error after successful directory creation
 type errorWriter struct{}
 
@@ -0,0 +1,369 @@
+package reporter
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBold   = "\033[1m"
+)
+
+// DiffReporter implements OutputWriter with a human friendly, unified-diff style
+// rendering of each report, followed by a compact summary table when the run is flushed.
+type DiffReporter struct {
+	Out   io.Writer
+	Color bool
+
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+	partial *driftchecker.ScanSummary
+}
+
+// NewDiffReporter creates a new DiffReporter instance.
+// out: The writer the diff is rendered to, typically os.Stdout.
+// color: Whether ANSI colors should be used.
+func NewDiffReporter(out io.Writer, color bool) *DiffReporter {
+	return &DiffReporter{
+		Out:   out,
+		Color: color,
+	}
+}
+
+// ColorEnabled reports whether colored output should be written to f. Color is
+// disabled when noColor is set, when the NO_COLOR environment variable is present,
+// or when f is not a terminal.
+func ColorEnabled(noColor bool, f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// WriteReport renders the report as a diff block. Matching attributes are omitted
+// so that only drift stands out.
+func (d *DiffReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "DiffReporter.WriteReport")
+	defer span.End()
+
+	if report.Status == driftchecker.Partial {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.partial = report.Summary
+		return nil
+	}
+
+	var b strings.Builder
+	label := resourceLabel(report)
+	switch {
+	case report.ImportSuggestion != nil:
+		b.WriteString(d.paint(ansiBold+ansiYellow, "+ "+label+"  "+report.Status) + "\n")
+		for _, line := range strings.Split(strings.TrimRight(report.ImportSuggestion.Block, "\n"), "\n") {
+			b.WriteString("    " + line + "\n")
+		}
+	case report.Status == driftchecker.DriftResolved:
+		b.WriteString(d.paint(ansiGreen, "  "+label+"  drift resolved") + "\n")
+	case report.Status == driftchecker.Skipped:
+		b.WriteString("  " + label + "  skipped\n")
+	case report.Status == driftchecker.CheckFailed:
+		b.WriteString(d.paint(ansiBold+ansiRed, "! "+label+"  check failed"+failureClass(report)) + "\n")
+		if report.Failure != nil {
+			b.WriteString("    " + report.Failure.Error + "\n")
+		}
+	case !report.HasDrift:
+		b.WriteString(d.paint(ansiGreen, "  "+label+"  no drift") + "\n")
+	default:
+		b.WriteString(d.paint(ansiBold+ansiYellow, "~ "+label+"  "+report.Status) + maintenanceNote(report) + "\n")
+		for _, item := range report.DriftDetails {
+			desired := withReferences(item.TerraformValue, item.References)
+			actual := withReferences(item.ActualValue, item.References)
+			switch item.DriftType {
+			case driftchecker.AttributeValueChanged:
+				b.WriteString(d.paint(ansiRed, fmt.Sprintf("  - %s = %s", item.Field, desired)) + "\n")
+				b.WriteString(d.paint(ansiGreen, fmt.Sprintf("  + %s = %s", item.Field, actual)) + costNote(item.Cost) + "\n")
+				d.writeExcerpt(&b, item.Excerpt)
+			case driftchecker.AttributeMissingInTerraform:
+				b.WriteString(d.paint(ansiGreen, fmt.Sprintf("  + %s = %s", item.Field, actual)) + "  (not in state)\n")
+			case driftchecker.AttributeMissingInInfrastructure:
+				b.WriteString(d.paint(ansiRed, fmt.Sprintf("  - %s = %s", item.Field, desired)) + "  (missing in infrastructure)\n")
+			case driftchecker.DefaultTagMissingInInfrastructure:
+				b.WriteString(d.paint(ansiRed, fmt.Sprintf("  - %s = %s", item.Field, desired)) + "  (default tag missing in infrastructure)\n")
+			}
+		}
+	}
+	for _, violation := range report.Violations {
+		b.WriteString(d.paint(ansiBold+ansiRed, fmt.Sprintf("  ! [%s] %s", violation.Severity, violation.Message)) + "\n")
+	}
+	for _, advisory := range report.Advisories {
+		b.WriteString(d.paint(ansiYellow, fmt.Sprintf("  i [advisory] %s: %s", advisory.Attribute, advisory.Message)) + "\n")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reports = append(d.reports, report)
+
+	if _, err := io.WriteString(d.Out, b.String()); err != nil {
+		return fmt.Errorf("failed to write drift diff: %w", err)
+	}
+	return nil
+}
+
+// Flush writes a summary table of every report seen since the last flush.
+func (d *DiffReporter) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	partial := d.partial
+	d.partial = nil
+	if len(d.reports) == 0 && partial == nil {
+		return nil
+	}
+
+	drifted, expected, skipped, failed, violations, advisories := 0, 0, 0, 0, 0, 0
+	var costs *driftchecker.CostDelta
+	fmt.Fprintln(d.Out)
+	tw := tabwriter.NewWriter(d.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESOURCE\tSTATUS\tDRIFTED ATTRIBUTES")
+	for _, report := range d.reports {
+		var fields []string
+		for _, item := range report.DriftDetails {
+			if item.DriftType != driftchecker.Match {
+				fields = append(fields, item.Field)
+			}
+			if item.Cost != nil {
+				if costs == nil {
+					costs = &driftchecker.CostDelta{Currency: item.Cost.Currency}
+				}
+				costs.Monthly += item.Cost.Monthly
+			}
+		}
+		if report.HasDrift {
+			drifted++
+		}
+		status := report.Status
+		if report.Expected() {
+			expected++
+			status += " (expected)"
+		}
+		if report.Status == driftchecker.Skipped {
+			skipped++
+		}
+		if report.Status == driftchecker.CheckFailed {
+			failed++
+			status += failureClass(report)
+		}
+		violations += len(report.Violations)
+		advisories += len(report.Advisories)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", resourceLabel(report), status, strings.Join(fields, ","))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write drift summary: %w", err)
+	}
+	d.writeDependencies(driftchecker.GroupByDependency(d.reports))
+	// the snapshot tells findings of environments sharing resource names apart
+	for _, snapshot := range stateSnapshots(d.reports) {
+		fmt.Fprintf(d.Out, "state: %s\n", snapshot.StateSnapshot())
+		if git := snapshot.Git; git != nil {
+			fmt.Fprintf(d.Out, "code: %s\n", git.String())
+			if git.StateCommitMissing {
+				fmt.Fprintln(d.Out, d.paint(ansiYellow, fmt.Sprintf("warning: serial %d was applied from commit %s, which is not in the checked out branch", snapshot.Serial, git.StateCommit)))
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d resource(s) checked, %d drifted", len(d.reports)-skipped-failed, drifted)
+	if expected > 0 {
+		summary += fmt.Sprintf(" (%d expected during maintenance)", expected)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d check(s) failed", failed)
+	}
+	if violations > 0 {
+		summary += fmt.Sprintf(", %d policy violation(s)", violations)
+	}
+	if advisories > 0 {
+		summary += fmt.Sprintf(", %d advisory notice(s)", advisories)
+	}
+	if costs != nil {
+		summary += ", estimated cost " + costAmount(costs)
+	}
+	if partial != nil {
+		summary += fmt.Sprintf(" (partial: scan interrupted after %d of %d resources)", partial.Checked, partial.Total)
+	}
+	sample := sampleSummary(d.reports)
+	if sample != nil {
+		summary += "; sampled " + sample.String()
+	}
+	if incremental := incrementalSummary(d.reports); incremental != nil {
+		summary += "; changed only: " + incremental.String()
+	}
+	if drifted > 0 || failed > 0 || violations > 0 || partial != nil || sample != nil {
+		summary = d.paint(ansiYellow, summary)
+	} else {
+		summary = d.paint(ansiGreen, summary)
+	}
+	_, err := fmt.Fprintln(d.Out, summary)
+	d.reports = nil
+	return err
+}
+
+// writeDependencies writes the resources depending on every drifted resource, marking
+// the dependents that drifted too, so related drift is read together.
+func (d *DiffReporter) writeDependencies(groups []driftchecker.DependencyGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintln(d.Out, "\nDEPENDENCIES")
+	for _, group := range groups {
+		fmt.Fprintln(d.Out, d.paint(ansiYellow, fmt.Sprintf("~ %s  %d dependent resource(s)", resourceLabel(group.Report), len(group.Dependents))))
+		for _, address := range group.Dependents {
+			drifted := false
+			for _, report := range group.Drifted {
+				if statemanager.ConfigAddress(report.ResourceAddress) == address {
+					fmt.Fprintln(d.Out, d.paint(ansiYellow, "    ~ "+resourceLabel(report)+"  drifted"))
+					drifted = true
+				}
+			}
+			if !drifted {
+				fmt.Fprintln(d.Out, "      "+address)
+			}
+		}
+	}
+}
+
+// writeExcerpt writes the excerpt of a drift item below its values, indented and with
+// its removed and added lines colored.
+func (d *DiffReporter) writeExcerpt(b *strings.Builder, excerpt string) {
+	if excerpt == "" {
+		return
+	}
+	for _, line := range strings.Split(excerpt, "\n") {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			line = d.paint(ansiRed, line)
+		case strings.HasPrefix(line, "+ "):
+			line = d.paint(ansiGreen, line)
+		}
+		b.WriteString("      " + line + "\n")
+	}
+}
+
+func (d *DiffReporter) paint(color string, text string) string {
+	if !d.Color {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// resourceLabel renders a report's resource as type.name (id), falling back to
+// whichever identifiers are present. Resources of a Terragrunt stack are prefixed with
+// the stack path.
+func resourceLabel(report *driftchecker.DriftReport) string {
+	if report.Stack != "" {
+		stackless := *report
+		stackless.Stack = ""
+		return "[" + report.Stack + "] " + resourceLabel(&stackless)
+	}
+	label := report.ResourceType
+	if report.ResourceAddress != "" {
+		label = report.ResourceAddress
+	} else if report.ResourceName != "" {
+		if label != "" {
+			label += "."
+		}
+		label += report.ResourceName
+	}
+	if report.ResourceId != "" {
+		if label == "" {
+			return report.ResourceId
+		}
+		label += " (" + report.ResourceId + ")"
+	}
+	return label
+}
+
+// withReferences renders value with the name of every referenced resource after its
+// identifier, e.g. subnet-0abc (public-a).
+func withReferences(value any, references map[string]string) string {
+	rendered := fmt.Sprintf("%v", value)
+	if len(references) == 0 {
+		return rendered
+	}
+
+	// Longer identifiers go first so that an identifier that is a prefix of another
+	// does not match inside it.
+	ids := make([]string, 0, len(references))
+	for id := range references {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+
+	pairs := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		pairs = append(pairs, id, id+" ("+references[id]+")")
+	}
+	return strings.NewReplacer(pairs...).Replace(rendered)
+}
+
+// maintenanceNote marks the drift of a report found during a maintenance window as
+// expected, with the reason of the window when it has one.
+func maintenanceNote(report *driftchecker.DriftReport) string {
+	if !report.Expected() {
+		return ""
+	}
+	if report.Maintenance.Reason == "" {
+		return "  expected (maintenance)"
+	}
+	return "  expected (maintenance: " + report.Maintenance.Reason + ")"
+}
+
+// failureClass renders the class of the failed check of a report, e.g. (throttled).
+func failureClass(report *driftchecker.DriftReport) string {
+	if report.Failure == nil {
+		return ""
+	}
+	return " (" + report.Failure.Class + ")"
+}
+
+// costNote renders the estimated monthly cost delta of a drifted attribute, e.g.
+// (+$70.08/month).
+func costNote(cost *driftchecker.CostDelta) string {
+	if cost == nil {
+		return ""
+	}
+	return "  (" + costAmount(cost) + ")"
+}
+
+// costAmount renders a monthly cost delta with its sign, e.g. -$5.00/month.
+func costAmount(cost *driftchecker.CostDelta) string {
+	sign := "+"
+	if cost.Monthly < 0 {
+		sign = "-"
+	}
+	amount := fmt.Sprintf("%.2f", math.Abs(cost.Monthly))
+	if cost.Currency == "USD" {
+		return sign + "$" + amount + "/month"
+	}
+	return sign + amount + " " + cost.Currency + "/month"
+}
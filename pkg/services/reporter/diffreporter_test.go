@@ -0,0 +1,362 @@
+package reporter_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/provider"
+	"drift-watcher/pkg/services/reporter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffReporter_WriteReport_Drift(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.DriftDetails = append(report.DriftDetails,
+		driftchecker.DriftItem{Field: "versioning", TerraformValue: "", ActualValue: "enabled", DriftType: driftchecker.AttributeMissingInTerraform},
+		driftchecker.DriftItem{Field: "policy", TerraformValue: "p", ActualValue: "", DriftType: driftchecker.AttributeMissingInInfrastructure},
+		driftchecker.DriftItem{Field: "region", TerraformValue: "us-east-1", ActualValue: "us-east-1", DriftType: driftchecker.Match},
+	)
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	got := out.String()
+	assert.Contains(t, got, "~ aws_s3_bucket.my-bucket-name (res-123)  DRIFT")
+	assert.Contains(t, got, "  - bucket_acl = private\n")
+	assert.Contains(t, got, "  + bucket_acl = public-read\n")
+	assert.Contains(t, got, "  + versioning = enabled  (not in state)")
+	assert.Contains(t, got, "  - policy = p  (missing in infrastructure)")
+	assert.NotContains(t, got, "region", "matching attributes are not rendered")
+	assert.NotContains(t, got, "\033[", "no ANSI codes when color is disabled")
+}
+
+func TestDiffReporter_WriteReport_References(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := &driftchecker.DriftReport{
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		HasDrift:     true,
+		Status:       driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{
+			{
+				Field:          "security_group_ids",
+				TerraformValue: "sg-1",
+				ActualValue:    "sg-1,sg-12",
+				DriftType:      driftchecker.AttributeValueChanged,
+				References:     map[string]string{"sg-1": "web", "sg-12": "default"},
+			},
+		},
+	}
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	got := out.String()
+	assert.Contains(t, got, "  - security_group_ids = sg-1 (web)\n")
+	assert.Contains(t, got, "  + security_group_ids = sg-1 (web),sg-12 (default)\n")
+}
+
+func TestDiffReporter_WriteReport_Violations(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.Violations = []driftchecker.PolicyViolation{{Severity: "high", Message: "bucket ACL changed on a prod bucket"}}
+	require.NoError(t, r.WriteReport(context.Background(), report))
+	require.NoError(t, r.Flush(context.Background()))
+
+	got := out.String()
+	assert.Contains(t, got, "  ! [high] bucket ACL changed on a prod bucket\n")
+	assert.Contains(t, got, "1 resource(s) checked, 1 drifted, 1 policy violation(s)")
+}
+
+func TestDiffReporter_WriteReport_Stack(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := reporter.CreateDummyDriftReport(false)
+	report.Stack = "prod/vpc"
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	assert.Contains(t, out.String(), "  [prod/vpc] aws_s3_bucket.my-bucket-name (res-123)  no drift")
+}
+
+func TestDiffReporter_WriteReport_NoDriftColored(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, true)
+
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(false)))
+	assert.Contains(t, out.String(), "aws_s3_bucket.my-bucket-name (res-123)  no drift")
+	assert.Contains(t, out.String(), "\033[32m")
+}
+
+func TestDiffReporter_Flush_Summary(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	out.Reset()
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	got := out.String()
+	assert.Contains(t, got, "RESOURCE")
+	assert.Contains(t, got, "bucket_acl,tags.Environment")
+	assert.Contains(t, got, "2 resource(s) checked, 1 drifted")
+
+	// a second flush has nothing left to summarise
+	out.Reset()
+	require.NoError(t, r.Flush(ctx))
+	assert.Empty(t, out.String())
+}
+
+func TestDiffReporter_Flush_StateSnapshots(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	prod := &driftchecker.RunMetadata{StatePath: "prod.tfstate", Lineage: "lineage-prod", Serial: 42, TerraformVersion: "1.8.5"}
+	staging := &driftchecker.RunMetadata{StatePath: "staging.tfstate", Lineage: "lineage-staging", Serial: 7}
+	for _, run := range []*driftchecker.RunMetadata{prod, prod, staging} {
+		report := reporter.CreateDummyDriftReport(true)
+		report.Run = run
+		require.NoError(t, r.WriteReport(ctx, report))
+	}
+	out.Reset()
+
+	require.NoError(t, r.Flush(ctx))
+	got := out.String()
+	assert.Equal(t, 1, strings.Count(got, "state: prod.tfstate (lineage lineage-prod, serial 42, terraform 1.8.5)\n"))
+	assert.Contains(t, got, "state: staging.tfstate (lineage lineage-staging, serial 7)\n")
+}
+
+func TestDiffReporter_Flush_GitContext(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.Run = &driftchecker.RunMetadata{StatePath: "prod.tfstate", Serial: 42, Git: &driftchecker.GitContext{
+		Commit:             "3f2c1a9e5b7d",
+		Branch:             "main",
+		Dirty:              true,
+		StateCommit:        "9b8c7d6",
+		StateCommitMissing: true,
+	}}
+	require.NoError(t, r.WriteReport(ctx, report))
+	out.Reset()
+
+	require.NoError(t, r.Flush(ctx))
+	got := out.String()
+	assert.Contains(t, got, "state: prod.tfstate (serial 42)\ncode: main@3f2c1a9 (uncommitted .tf changes)\n")
+	assert.Contains(t, got, "warning: serial 42 was applied from commit 9b8c7d6, which is not in the checked out branch\n")
+}
+
+func TestDiffReporter_Flush_Dependencies(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	group := &driftchecker.DriftReport{ResourceAddress: "aws_security_group.web", ResourceId: "sg-1", HasDrift: true, Status: "drifted",
+		Dependents: []string{"aws_eip.web", "aws_instance.web"}}
+	instance := &driftchecker.DriftReport{ResourceAddress: "aws_instance.web[0]", ResourceId: "i-1", HasDrift: true, Status: "drifted"}
+	clean := &driftchecker.DriftReport{ResourceAddress: "aws_instance.web[1]", ResourceId: "i-2", Status: "no drift"}
+	for _, report := range []*driftchecker.DriftReport{group, instance, clean} {
+		require.NoError(t, r.WriteReport(ctx, report))
+	}
+	out.Reset()
+
+	require.NoError(t, r.Flush(ctx))
+	assert.Contains(t, out.String(), "DEPENDENCIES\n"+
+		"~ aws_security_group.web (sg-1)  2 dependent resource(s)\n"+
+		"      aws_eip.web\n"+
+		"    ~ aws_instance.web[0] (i-1)  drifted\n")
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.False(t, reporter.ColorEnabled(true, os.Stdout))
+	assert.False(t, reporter.ColorEnabled(false, f), "regular files are not terminals")
+	assert.False(t, reporter.ColorEnabled(false, nil))
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, reporter.ColorEnabled(false, os.Stdout))
+}
+
+func TestDiffReporter_WriteReport_ImportSuggestion(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := &driftchecker.DriftReport{
+		ResourceId:   "i-stray",
+		ResourceType: "aws_instance",
+		HasDrift:     true,
+		Status:       driftchecker.ResourceMissingInTerraform,
+		ImportSuggestion: &driftchecker.ImportSuggestion{
+			Block: "import {\n  to = aws_instance.unmanaged_i-stray\n  id = \"i-stray\"\n}\n",
+		},
+	}
+	require.NoError(t, r.WriteReport(context.Background(), report))
+	assert.Contains(t, out.String(), "+ aws_instance (i-stray)  MISSING_IN_TERRAFORM\n")
+	assert.Contains(t, out.String(), "    import {\n      to = aws_instance.unmanaged_i-stray\n")
+}
+
+func TestDiffReporter_Skipped(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{
+		ResourceId:   "i-scratch",
+		ResourceType: "aws_instance",
+		ResourceName: "scratch",
+		Status:       driftchecker.Skipped,
+	}))
+	assert.Contains(t, out.String(), "  aws_instance.scratch (i-scratch)  skipped\n")
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 0 drifted, 1 skipped")
+}
+
+func TestDiffReporter_CheckFailed(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{
+		ResourceId:   "i-slow",
+		ResourceType: "aws_instance",
+		ResourceName: "slow",
+		Status:       driftchecker.CheckFailed,
+		Failure:      &driftchecker.CheckFailure{Class: driftchecker.FailureTimeout, Error: "resource check timed out after 5s: context deadline exceeded"},
+	}))
+	assert.Contains(t, out.String(), "! aws_instance.slow (i-slow)  check failed (timeout)\n    resource check timed out after 5s: context deadline exceeded\n")
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "CHECK_FAILED (timeout)")
+	assert.Contains(t, out.String(), "1 resource(s) checked, 0 drifted, 1 check(s) failed")
+}
+
+func TestDiffReporter_Maintenance(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.Maintenance = &driftchecker.MaintenanceWindow{Resource: "aws_s3_bucket.*", Reason: "CHG-1042"}
+	require.NoError(t, r.WriteReport(ctx, report))
+	assert.Contains(t, out.String(), "DRIFT  expected (maintenance: CHG-1042)\n")
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "DRIFT (expected)")
+	assert.Contains(t, out.String(), "1 resource(s) checked, 1 drifted (1 expected during maintenance)")
+}
+
+func TestDiffReporter_Cost(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		HasDrift:     true,
+		Status:       driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t3.micro", ActualValue: "m5.large", DriftType: driftchecker.AttributeValueChanged, Cost: &driftchecker.CostDelta{Monthly: 62.49, Currency: "USD"}},
+			{Field: "root_block_device.volume_size", TerraformValue: 100, ActualValue: 50, DriftType: driftchecker.AttributeValueChanged, Cost: &driftchecker.CostDelta{Monthly: -5, Currency: "USD"}},
+		},
+	}))
+	assert.Contains(t, out.String(), "  + instance_type = m5.large  (+$62.49/month)\n")
+	assert.Contains(t, out.String(), "  + root_block_device.volume_size = 50  (-$5.00/month)\n")
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 1 drifted, estimated cost +$57.49/month")
+}
+
+func TestDiffReporter_Excerpt(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	require.NoError(t, r.WriteReport(context.Background(), &driftchecker.DriftReport{
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		HasDrift:     true,
+		Status:       driftchecker.Drift,
+		DriftDetails: []driftchecker.DriftItem{{
+			Field:          "user_data",
+			TerraformValue: "sha1:0a1b",
+			ActualValue:    "sha1:2c3d",
+			DriftType:      driftchecker.AttributeValueChanged,
+			Excerpt:        "@@ line 2 @@\n- yum install -y nginx\n+ yum install -y httpd",
+		}},
+	}))
+	assert.Contains(t, out.String(), "  + user_data = sha1:2c3d\n      @@ line 2 @@\n      - yum install -y nginx\n      + yum install -y httpd\n")
+}
+
+func TestDiffReporter_Advisories(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		Status:       driftchecker.Match,
+		Advisories:   []provider.Advisory{{Attribute: "ami", Message: "newer AMI ami-new (al2023, created 2024-04-01) matches data.aws_ami.al2023", Suggested: "ami-new"}},
+	}))
+	assert.Contains(t, out.String(), "  aws_instance.web  no drift\n  i [advisory] ami: newer AMI ami-new (al2023, created 2024-04-01) matches data.aws_ami.al2023\n")
+
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 0 drifted, 1 advisory notice(s)")
+}
+
+func TestDiffReporter_Partial(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{
+		Status:  driftchecker.Partial,
+		Summary: &driftchecker.ScanSummary{Checked: 1, Total: 10},
+	}))
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 0 drifted (partial: scan interrupted after 1 of 10 resources)")
+}
+
+func TestDiffReporter_Sample(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+	ctx := context.Background()
+
+	report := reporter.CreateDummyDriftReport(false)
+	report.Run = &driftchecker.RunMetadata{Sample: &driftchecker.SampleSummary{Percent: 10, Seed: 7, Sampled: 1, Total: 10}}
+	require.NoError(t, r.WriteReport(ctx, report))
+	require.NoError(t, reporter.FlushWriter(ctx, r))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 0 drifted; sampled 1 of 10 resources, 10% sample with seed 7")
+}
+
+func TestDiffReporter_WriteReport_ResourceAddress(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewDiffReporter(&out, false)
+
+	report := reporter.CreateDummyDriftReport(false)
+	report.ResourceAddress = `module.storage.aws_s3_bucket.logs["eu"]`
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	assert.Contains(t, out.String(), `  module.storage.aws_s3_bucket.logs["eu"] (res-123)  no drift`)
+}
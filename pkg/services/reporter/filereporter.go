@@ -3,14 +3,22 @@ package reporter
 import (
 	"context"
 	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
+// FileReporter implements OutputWriter by writing the reports of a run to a JSON file
+// when the run is flushed, as an array of reports whatever their number. The file is
+// replaced on every run.
 type FileReporter struct {
 	OutputFile string
+
+	mu      sync.Mutex
+	reports []json.RawMessage
 }
 
 // NewFileReporter creates a new FileReporter instance.
@@ -21,9 +29,45 @@ func NewFileReporter(outputFile string) *FileReporter {
 	}
 }
 
-// WriteReport marshals the DriftReport to JSON and writes it to the configured file.
-// If the file does not exist, it will be created. If it exists, its content will be truncated.
+// Begin discards the reports of a previous run that was not flushed.
+func (f *FileReporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports = nil
+	return nil
+}
+
+// WriteReport marshals the DriftReport to JSON and keeps it until the run is flushed.
 func (f *FileReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "FileReporter.WriteReport")
+	defer span.End()
+
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal drift report to JSON: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports = append(f.reports, reportBytes)
+	return nil
+}
+
+// Flush writes the reports of the run to the configured file. If the file does not
+// exist, it will be created. If it exists, its content will be truncated. A run that
+// produced no report is written as an empty array.
+func (f *FileReporter) Flush(ctx context.Context) error {
+	_, span := telemetry.StartSpan(ctx, "FileReporter.Flush")
+	defer span.End()
+
+	f.mu.Lock()
+	reports := f.reports
+	f.reports = nil
+	f.mu.Unlock()
+	if reports == nil {
+		reports = []json.RawMessage{}
+	}
+
 	// Ensure the output directory exists
 	outputDir := filepath.Dir(f.OutputFile)
 	if outputDir != "" {
@@ -32,12 +76,12 @@ func (f *FileReporter) WriteReport(ctx context.Context, report *driftchecker.Dri
 		}
 	}
 
-	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	content, err := json.MarshalIndent(reports, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal drift report to JSON: %w", err)
+		return fmt.Errorf("failed to marshal drift reports to JSON: %w", err)
 	}
 
-	err = os.WriteFile(f.OutputFile, reportBytes, 0644)
+	err = os.WriteFile(f.OutputFile, content, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write drift report to file %s: %w", f.OutputFile, err)
 	}
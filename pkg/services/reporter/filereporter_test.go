@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -63,16 +64,19 @@ func TestFileReporter_WriteReport_Success(t *testing.T) {
 
 	err = reporter.WriteReport(ctx, report)
 	require.NoError(t, err)
+	require.NoError(t, reporter.Flush(ctx))
 
 	// Read the content and verify
 	data, err := os.ReadFile(tmpFile.Name())
 	require.NoError(t, err)
 	assert.True(t, len(data) > 0)
 
-	var writtenReport driftchecker.DriftReport
-	err = json.Unmarshal(data, &writtenReport)
+	var written []driftchecker.DriftReport
+	err = json.Unmarshal(data, &written)
 	require.NoError(t, err)
+	require.Len(t, written, 1)
 
+	writtenReport := written[0]
 	assert.Equal(t, report.ResourceId, writtenReport.ResourceId)
 	assert.Equal(t, report.HasDrift, writtenReport.HasDrift)
 	assert.Len(t, writtenReport.DriftDetails, 2)
@@ -80,6 +84,9 @@ func TestFileReporter_WriteReport_Success(t *testing.T) {
 }
 
 func TestFileReporter_WriteReport_WriteFileError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions cannot deny access on Windows")
+	}
 	tmpDir := t.TempDir()
 	nonWritableFile := filepath.Join(tmpDir, "non_writable.json")
 
@@ -95,7 +102,8 @@ func TestFileReporter_WriteReport_WriteFileError(t *testing.T) {
 	report := createDummyDriftReportForFile(false)
 	ctx := context.Background()
 
-	err = reporter.WriteReport(ctx, report)
+	require.NoError(t, reporter.WriteReport(ctx, report))
+	err = reporter.Flush(ctx)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to write drift report to file")
 	assert.Contains(t, err.Error(), "permission denied")
@@ -147,6 +155,7 @@ func TestFileReporter_WriteReport_NoOutputDir(t *testing.T) {
 
 	err = reporter.WriteReport(ctx, report)
 	require.NoError(t, err)
+	require.NoError(t, reporter.Flush(ctx))
 
 	// Verify file exists and has content
 	data, err := os.ReadFile(tmpFile.Name())
@@ -154,3 +163,50 @@ func TestFileReporter_WriteReport_NoOutputDir(t *testing.T) {
 	assert.True(t, len(data) > 0)
 	assert.Contains(t, string(data), report.ResourceId)
 }
+
+func TestFileReporter_Flush_KeepsEveryReportOfTheRun(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	r := reporter.NewFileReporter(outputFile)
+	ctx := context.Background()
+
+	require.NoError(t, r.Begin(ctx, &driftchecker.RunMetadata{RunId: "run-1"}))
+	first := createDummyDriftReportForFile(true)
+	second := createDummyDriftReportForFile(false)
+	second.ResourceId = "file-res-789"
+	require.NoError(t, r.WriteReport(ctx, first))
+	require.NoError(t, r.WriteReport(ctx, second))
+	_, err := os.Stat(outputFile)
+	assert.True(t, os.IsNotExist(err), "nothing is written before the run is flushed")
+	require.NoError(t, r.Flush(ctx))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var reports []driftchecker.DriftReport
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, 2)
+	assert.Equal(t, "file-res-456", reports[0].ResourceId)
+	assert.Equal(t, "file-res-789", reports[1].ResourceId)
+
+	// the next run replaces the file
+	require.NoError(t, r.Begin(ctx, &driftchecker.RunMetadata{RunId: "run-2"}))
+	require.NoError(t, r.WriteReport(ctx, second))
+	require.NoError(t, r.Flush(ctx))
+	data, err = os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "file-res-789", reports[0].ResourceId)
+}
+
+func TestFileReporter_Flush_NoReports(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	r := reporter.NewFileReporter(outputFile)
+	ctx := context.Background()
+
+	require.NoError(t, r.Begin(ctx, &driftchecker.RunMetadata{RunId: "run-1"}))
+	require.NoError(t, r.Flush(ctx))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(data))
+}
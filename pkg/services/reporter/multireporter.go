@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"errors"
+)
+
+// MultiReporter implements OutputWriter by fanning every report out to several
+// OutputWriters, so that a single run can write a JSON file, a CSV file and a chat
+// notification at once.
+type MultiReporter struct {
+	Writers []OutputWriter
+}
+
+// NewMultiReporter creates a new MultiReporter instance.
+// writers: The OutputWriters every report is written to, in order. Nil writers are
+// left out.
+func NewMultiReporter(writers ...OutputWriter) *MultiReporter {
+	m := &MultiReporter{}
+	for _, w := range writers {
+		if w != nil {
+			m.Writers = append(m.Writers, w)
+		}
+	}
+	return m
+}
+
+// WriteReport writes the report to every writer. A writer that fails does not keep
+// the report from the others; the errors of all failed writers are returned together.
+func (m *MultiReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	var errs []error
+	for _, w := range m.Writers {
+		if err := w.WriteReport(ctx, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Begin begins the run of every writer, returning the errors of all failed writers
+// together.
+func (m *MultiReporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	var errs []error
+	for _, w := range m.Writers {
+		if err := BeginWriter(ctx, w, run); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes every writer that buffers output, returning the errors of all failed
+// flushes together.
+func (m *MultiReporter) Flush(ctx context.Context) error {
+	var errs []error
+	for _, w := range m.Writers {
+		if err := FlushWriter(ctx, w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every writer, returning the errors of all failed writers together.
+func (m *MultiReporter) Close() error {
+	var errs []error
+	for _, w := range m.Writers {
+		if err := CloseWriter(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,31 @@
+package reporter_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiReporter_WritesToEverySink(t *testing.T) {
+	var out bytes.Buffer
+	failing := &reporterfakes.FakeOutputWriter{}
+	failing.WriteReportReturns(errors.New("disk full"))
+	other := &reporterfakes.FakeOutputWriter{}
+	diff := reporter.NewDiffReporter(&out, false)
+	m := reporter.NewMultiReporter(failing, nil, other, diff)
+	require.Len(t, m.Writers, 3)
+
+	err := m.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true))
+	assert.ErrorContains(t, err, "disk full")
+	assert.Equal(t, 1, other.WriteReportCallCount(), "a failing sink does not keep the report from the others")
+	assert.Contains(t, out.String(), "~ aws_s3_bucket.my-bucket-name (res-123)  DRIFT")
+
+	require.NoError(t, m.Flush(context.Background()))
+	assert.Contains(t, out.String(), "1 resource(s) checked, 1 drifted")
+}
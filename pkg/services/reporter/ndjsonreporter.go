@@ -0,0 +1,48 @@
+package reporter
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// NDJSONReporter implements OutputWriter by streaming every report as a single line of
+// JSON (newline delimited JSON) as soon as it is written, so downstream tools can
+// process the results of long scans while they run. Reports are never buffered.
+type NDJSONReporter struct {
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+// NewNDJSONReporter creates a new NDJSONReporter instance.
+// out: The writer reports are streamed to, typically os.Stdout.
+func NewNDJSONReporter(out io.Writer) *NDJSONReporter {
+	return &NDJSONReporter{
+		Out: out,
+	}
+}
+
+// WriteReport writes the report as one line of compact JSON. Reports written
+// concurrently never interleave.
+func (n *NDJSONReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "NDJSONReporter.WriteReport")
+	defer span.End()
+
+	line, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal drift report to JSON: %w", err)
+	}
+	line = append(line, '\n')
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := n.Out.Write(line); err != nil {
+		return fmt.Errorf("failed to write drift report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package reporter_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONReporter_WriteReport(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewNDJSONReporter(&out)
+
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true)))
+	// every report is written as soon as it is produced
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("\n")))
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(false)))
+
+	scanner := bufio.NewScanner(&out)
+	var reports []driftchecker.DriftReport
+	for scanner.Scan() {
+		var report driftchecker.DriftReport
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &report))
+		reports = append(reports, report)
+	}
+	require.Len(t, reports, 2)
+	assert.True(t, reports[0].HasDrift)
+	assert.Equal(t, "bucket_acl", reports[0].DriftDetails[0].Field)
+	assert.False(t, reports[1].HasDrift)
+}
+
+func TestNDJSONReporter_ConcurrentWrites(t *testing.T) {
+	var out bytes.Buffer
+	r := reporter.NewNDJSONReporter(&out)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report := reporter.CreateDummyDriftReport(true)
+			report.ResourceId = fmt.Sprintf("res-%d", i)
+			assert.NoError(t, r.WriteReport(context.Background(), report))
+		}()
+	}
+	wg.Wait()
+
+	scanner := bufio.NewScanner(&out)
+	lines := 0
+	for scanner.Scan() {
+		var report driftchecker.DriftReport
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &report), "lines must not interleave")
+		lines++
+	}
+	assert.Equal(t, 20, lines)
+}
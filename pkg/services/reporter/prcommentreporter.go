@@ -0,0 +1,298 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PR comment platforms.
+const (
+	PlatformGitHub = "github"
+	PlatformGitLab = "gitlab"
+)
+
+// Default API endpoints of the PR comment platforms.
+const (
+	DefaultGitHubAPIURL = "https://api.github.com"
+	DefaultGitLabAPIURL = "https://gitlab.com/api/v4"
+)
+
+// prCommentMarker identifies the comment driftwatcher posted on a pull request, so
+// that the next run updates it instead of posting another.
+const prCommentMarker = "<!-- driftwatcher -->"
+
+// prCommentMaxDiffs is the number of resources whose diff is included in a comment;
+// the rest are only listed in the table, keeping the comment under the size limits
+// of the platforms.
+const prCommentMaxDiffs = 50
+
+// PRCommentReporter implements OutputWriter by posting the drift of a run as a comment
+// on a GitHub pull request or GitLab merge request when the run is flushed: a summary
+// table followed by a collapsible diff per drifted resource. The comment of a previous
+// run is updated rather than a new one posted, also once the drift is gone; a run
+// without drift posts nothing when there is no comment to update.
+type PRCommentReporter struct {
+	// Platform is PlatformGitHub or PlatformGitLab.
+	Platform string
+	APIURL   string
+	// Repo is the owner/name of a GitHub repository, or the id or path of a GitLab
+	// project.
+	Repo   string
+	Number int
+	Token  string
+	Client *http.Client
+
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+}
+
+// NewPRCommentReporter creates a new PRCommentReporter instance.
+// platform: The platform hosting the repository, github or gitlab.
+// apiURL: The API endpoint of the platform, the public one when empty.
+// repo: The GitHub owner/name, or GitLab project id or path, of the repository.
+// number: The number of the pull request, or iid of the merge request.
+// token: The token the comment is posted with.
+func NewPRCommentReporter(platform, apiURL, repo string, number int, token string) (*PRCommentReporter, error) {
+	switch platform {
+	case PlatformGitHub:
+		if apiURL == "" {
+			apiURL = DefaultGitHubAPIURL
+		}
+	case PlatformGitLab:
+		if apiURL == "" {
+			apiURL = DefaultGitLabAPIURL
+		}
+	default:
+		return nil, fmt.Errorf("%s pull request comments not currently supported", platform)
+	}
+	if repo == "" || number <= 0 || token == "" {
+		return nil, fmt.Errorf("%s pull request comments require a repository, a pull request number and a token", platform)
+	}
+	return &PRCommentReporter{
+		Platform: platform,
+		APIURL:   strings.TrimRight(apiURL, "/"),
+		Repo:     repo,
+		Number:   number,
+		Token:    token,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// WriteReport records the report for the comment posted on Flush.
+func (p *PRCommentReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	if report.Status == driftchecker.Partial {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, report)
+	return nil
+}
+
+// Flush posts the reports recorded since the last flush as a comment, or updates the
+// comment of a previous run.
+func (p *PRCommentReporter) Flush(ctx context.Context) error {
+	ctx, span := telemetry.StartSpan(ctx, "PRCommentReporter.Flush")
+	defer span.End()
+
+	p.mu.Lock()
+	reports := p.reports
+	p.reports = nil
+	p.mu.Unlock()
+	if len(reports) == 0 {
+		return nil
+	}
+
+	existing, err := p.findComment(ctx)
+	if err != nil {
+		return err
+	}
+	_, drifted, _, failed := countReports(reports)
+	if existing == 0 && drifted == 0 && failed == 0 {
+		return nil
+	}
+
+	body := prCommentBody(reports)
+	if existing != 0 {
+		return p.send(ctx, p.updateMethod(), p.commentURL(existing), map[string]string{"body": body}, nil)
+	}
+	return p.send(ctx, http.MethodPost, p.commentsURL(), map[string]string{"body": body}, nil)
+}
+
+// findComment returns the id of the comment posted by a previous run, or 0 when there
+// is none.
+func (p *PRCommentReporter) findComment(ctx context.Context) (int64, error) {
+	for page := 1; ; page++ {
+		var comments []struct {
+			Id   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		pageURL := fmt.Sprintf("%s?per_page=100&page=%d", p.commentsURL(), page)
+		if err := p.send(ctx, http.MethodGet, pageURL, nil, &comments); err != nil {
+			return 0, err
+		}
+		for _, comment := range comments {
+			if strings.HasPrefix(comment.Body, prCommentMarker) {
+				return comment.Id, nil
+			}
+		}
+		if len(comments) < 100 {
+			return 0, nil
+		}
+	}
+}
+
+// commentsURL returns the URL the comments of the pull request are listed and
+// created at.
+func (p *PRCommentReporter) commentsURL() string {
+	if p.Platform == PlatformGitLab {
+		return fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", p.APIURL, url.PathEscape(p.Repo), p.Number)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/%d/comments", p.APIURL, p.Repo, p.Number)
+}
+
+// commentURL returns the URL of the comment id.
+func (p *PRCommentReporter) commentURL(id int64) string {
+	if p.Platform == PlatformGitLab {
+		return fmt.Sprintf("%s/%d", p.commentsURL(), id)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/comments/%d", p.APIURL, p.Repo, id)
+}
+
+// updateMethod returns the HTTP method a comment is updated with.
+func (p *PRCommentReporter) updateMethod() string {
+	if p.Platform == PlatformGitLab {
+		return http.MethodPut
+	}
+	return http.MethodPatch
+}
+
+// send sends payload, if any, as JSON to target and decodes the response into out,
+// if any.
+func (p *PRCommentReporter) send(ctx context.Context, method, target string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode pull request comment: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", p.Platform, err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.Platform == PlatformGitLab {
+		req.Header.Set("PRIVATE-TOKEN", p.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s API: %w", p.Platform, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to %s %s: %s: %s", method, target, resp.Status, bytes.TrimSpace(message))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s API response: %w", p.Platform, err)
+	}
+	return nil
+}
+
+// prCommentBody renders the reports of a run as GitHub and GitLab flavored markdown:
+// a summary line, a table of the drifted and failed resources and a collapsible diff
+// per drifted resource.
+func prCommentBody(reports []*driftchecker.DriftReport) string {
+	checked, drifted, skipped, failed := countReports(reports)
+	var b strings.Builder
+	b.WriteString(prCommentMarker + "\n")
+	if drifted == 0 && failed == 0 {
+		fmt.Fprintf(&b, "### :white_check_mark: No drift\n\n%d resource(s) checked, no drift detected.\n", checked)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "### :warning: Drift detected\n\n%d resource(s) checked, %d drifted", checked, drifted)
+	if skipped > 0 {
+		fmt.Fprintf(&b, ", %d skipped", skipped)
+	}
+	if failed > 0 {
+		fmt.Fprintf(&b, ", %d check(s) failed", failed)
+	}
+	b.WriteString(".\n\n| Resource | Status | Drifted attributes |\n| --- | --- | --- |\n")
+
+	var diffs []*driftchecker.DriftReport
+	for _, report := range reports {
+		status := report.Status
+		switch {
+		case report.Status == driftchecker.CheckFailed:
+			status += failureClass(report)
+		case report.HasDrift && report.Status != driftchecker.DriftResolved:
+			if report.Expected() {
+				status += " (expected)"
+			}
+			diffs = append(diffs, report)
+		default:
+			continue
+		}
+		var fields []string
+		for _, item := range report.DriftDetails {
+			if item.DriftType != driftchecker.Match {
+				fields = append(fields, "`"+item.Field+"`")
+			}
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", markdownCell(resourceLabel(report)), status, markdownCell(strings.Join(fields, ", ")))
+	}
+
+	for i, report := range diffs {
+		if i == prCommentMaxDiffs {
+			fmt.Fprintf(&b, "\n…and the diff of %d more resource(s), see the job output.\n", len(diffs)-prCommentMaxDiffs)
+			break
+		}
+		fmt.Fprintf(&b, "\n<details><summary><code>%s</code></summary>\n\n```diff\n", html.EscapeString(resourceLabel(report)))
+		for _, item := range report.DriftDetails {
+			desired := withReferences(item.TerraformValue, item.References)
+			actual := withReferences(item.ActualValue, item.References)
+			switch item.DriftType {
+			case driftchecker.AttributeValueChanged:
+				fmt.Fprintf(&b, "- %s = %s\n+ %s = %s%s\n", item.Field, desired, item.Field, actual, costNote(item.Cost))
+			case driftchecker.AttributeMissingInTerraform:
+				fmt.Fprintf(&b, "+ %s = %s  (not in state)\n", item.Field, actual)
+			case driftchecker.AttributeMissingInInfrastructure, driftchecker.DefaultTagMissingInInfrastructure:
+				fmt.Fprintf(&b, "- %s = %s  (missing in infrastructure)\n", item.Field, desired)
+			}
+		}
+		b.WriteString("```\n\n</details>\n")
+	}
+	return b.String()
+}
+
+// markdownCell escapes the pipes of a markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,125 @@
+package reporter_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/reporter"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commentServer fakes the comment API of a pull request, keeping the comments posted
+// and recording every request as "METHOD path".
+type commentServer struct {
+	mu       sync.Mutex
+	comments []map[string]any
+	requests []string
+	headers  http.Header
+}
+
+func (c *commentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, r.Method+" "+r.URL.EscapedPath())
+	c.headers = r.Header.Clone()
+
+	var body map[string]any
+	if r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(c.comments)
+	case http.MethodPost:
+		body["id"] = len(c.comments) + 100
+		c.comments = append(c.comments, body)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	default:
+		c.comments[len(c.comments)-1]["body"] = body["body"]
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func TestPRCommentReporter_GitHub(t *testing.T) {
+	fake := &commentServer{comments: []map[string]any{{"id": 7, "body": "LGTM"}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	r, err := reporter.NewPRCommentReporter(reporter.PlatformGitHub, server.URL, "acme/infra", 42, "ghp_token")
+	require.NoError(t, err)
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+
+	assert.Equal(t, []string{
+		"GET /repos/acme/infra/issues/42/comments",
+		"POST /repos/acme/infra/issues/42/comments",
+	}, fake.requests)
+	assert.Equal(t, "Bearer ghp_token", fake.headers.Get("Authorization"))
+	require.Len(t, fake.comments, 2)
+	body := fake.comments[1]["body"].(string)
+	assert.True(t, strings.HasPrefix(body, "<!-- driftwatcher -->\n### :warning: Drift detected\n\n2 resource(s) checked, 1 drifted.\n"), body)
+	assert.Contains(t, body, "| `aws_s3_bucket.my-bucket-name (res-123)` | DRIFT | `bucket_acl`, `tags.Environment` |\n")
+	assert.Contains(t, body, "<details><summary><code>aws_s3_bucket.my-bucket-name (res-123)</code></summary>\n\n```diff\n- bucket_acl = private\n+ bucket_acl = public-read\n")
+
+	// the next run updates the comment, also once the drift is gone
+	fake.requests = nil
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+	assert.Equal(t, []string{
+		"GET /repos/acme/infra/issues/42/comments",
+		"PATCH /repos/acme/infra/issues/comments/101",
+	}, fake.requests)
+	require.Len(t, fake.comments, 2)
+	assert.Equal(t, "<!-- driftwatcher -->\n### :white_check_mark: No drift\n\n1 resource(s) checked, no drift detected.\n", fake.comments[1]["body"])
+}
+
+func TestPRCommentReporter_GitLab(t *testing.T) {
+	fake := &commentServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	r, err := reporter.NewPRCommentReporter(reporter.PlatformGitLab, server.URL+"/api/v4", "acme/infra", 9, "glpat-token")
+	require.NoError(t, err)
+
+	// runs without drift post nothing when there is no comment to update
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+	assert.Empty(t, fake.comments)
+
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.Flush(ctx))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.Flush(ctx))
+
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/acme%2Finfra/merge_requests/9/notes",
+		"GET /api/v4/projects/acme%2Finfra/merge_requests/9/notes",
+		"POST /api/v4/projects/acme%2Finfra/merge_requests/9/notes",
+		"GET /api/v4/projects/acme%2Finfra/merge_requests/9/notes",
+		"PUT /api/v4/projects/acme%2Finfra/merge_requests/9/notes/100",
+	}, fake.requests)
+	assert.Equal(t, "glpat-token", fake.headers.Get("PRIVATE-TOKEN"))
+	assert.Len(t, fake.comments, 1)
+}
+
+func TestNewPRCommentReporter_Invalid(t *testing.T) {
+	_, err := reporter.NewPRCommentReporter("bitbucket", "", "acme/infra", 1, "token")
+	assert.EqualError(t, err, "bitbucket pull request comments not currently supported")
+
+	_, err = reporter.NewPRCommentReporter(reporter.PlatformGitHub, "", "acme/infra", 0, "token")
+	assert.EqualError(t, err, "github pull request comments require a repository, a pull request number and a token")
+}
@@ -0,0 +1,174 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// QueryInput is the aggregated report of a run a query is applied to, encoded with
+// the same field names as the JSON output.
+type QueryInput struct {
+	// SchemaVersion is the driftchecker.SchemaVersion of the reports.
+	SchemaVersion int       `json:"schema_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	// Checked, Drifted and Skipped count the resources of the run. Failed counts the
+	// resources whose check failed, which are not counted as checked.
+	Checked int `json:"checked"`
+	Drifted int `json:"drifted"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+	// Partial is only set when the scan was interrupted before every resource was checked.
+	Partial *driftchecker.ScanSummary `json:"partial,omitempty"`
+	// Sample is only set when a sample of the resources was checked.
+	Sample  *driftchecker.SampleSummary `json:"sample,omitempty"`
+	States  []*driftchecker.RunMetadata `json:"states,omitempty"`
+	Reports []*driftchecker.DriftReport `json:"reports"`
+}
+
+// QueryReporter implements OutputWriter by applying a jq query to the aggregated
+// report of a run, so only the fields needed are output, e.g. the ids of the drifted
+// resources with '.reports[] | select(.has_drift) | .resource_id'. Reports are
+// collected and the query is run once per run, when the reporter is flushed. Every
+// result is written on its own line, strings as is and other values as JSON, to
+// OutputFile, or to Out when no file is set.
+type QueryReporter struct {
+	Query      *gojq.Code
+	Out        io.Writer
+	OutputFile string
+
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+	partial *driftchecker.ScanSummary
+}
+
+// NewQueryReporter creates a new QueryReporter instance.
+// query: The jq query applied to the aggregated report, see QueryInput.
+// out: The writer the results are written to when outputFile is empty.
+// outputFile: The file the results are written to, replacing its content on every run.
+func NewQueryReporter(query string, out io.Writer, outputFile string) (*QueryReporter, error) {
+	code, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryReporter{
+		Query:      code,
+		Out:        out,
+		OutputFile: outputFile,
+	}, nil
+}
+
+// ParseQuery parses and compiles a jq query.
+func ParseQuery(query string) (*gojq.Code, error) {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query %q: %w", query, err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile query %q: %w", query, err)
+	}
+	return code, nil
+}
+
+// WriteReport collects the report for the next flush.
+func (q *QueryReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "QueryReporter.WriteReport")
+	defer span.End()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if report.Status == driftchecker.Partial {
+		q.partial = report.Summary
+		return nil
+	}
+	q.reports = append(q.reports, report)
+	return nil
+}
+
+// Flush runs the query over every report seen since the last flush and writes its
+// results. Nothing is written when no report was written.
+func (q *QueryReporter) Flush(ctx context.Context) error {
+	ctx, span := telemetry.StartSpan(ctx, "QueryReporter.Flush")
+	defer span.End()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.reports) == 0 && q.partial == nil {
+		return nil
+	}
+
+	aggregated := QueryInput{
+		SchemaVersion: driftchecker.SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Partial:       q.partial,
+		Sample:        sampleSummary(q.reports),
+		States:        stateSnapshots(q.reports),
+		Reports:       q.reports,
+	}
+	aggregated.Checked, aggregated.Drifted, aggregated.Skipped, aggregated.Failed = countReports(q.reports)
+	q.reports = nil
+	q.partial = nil
+
+	// the query runs over the JSON encoding of the reports, so it sees the field
+	// names of the JSON output
+	encoded, err := json.Marshal(aggregated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal drift reports to JSON: %w", err)
+	}
+	var input any
+	if err := json.Unmarshal(encoded, &input); err != nil {
+		return fmt.Errorf("failed to decode drift reports: %w", err)
+	}
+
+	var results bytes.Buffer
+	iter := q.Query.RunWithContext(ctx, input)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			if halt, ok := err.(*gojq.HaltError); ok && halt.Value() == nil {
+				break
+			}
+			return fmt.Errorf("failed to run query: %w", err)
+		}
+		if s, ok := result.(string); ok {
+			results.WriteString(s + "\n")
+			continue
+		}
+		rendered, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal query result to JSON: %w", err)
+		}
+		results.Write(append(rendered, '\n'))
+	}
+
+	if q.OutputFile == "" {
+		if _, err := q.Out.Write(results.Bytes()); err != nil {
+			return fmt.Errorf("failed to write query results: %w", err)
+		}
+		return nil
+	}
+
+	outputDir := filepath.Dir(q.OutputFile)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+	if err := os.WriteFile(q.OutputFile, results.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write query results to file %s: %w", q.OutputFile, err)
+	}
+	return nil
+}
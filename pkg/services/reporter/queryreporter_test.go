@@ -0,0 +1,78 @@
+package reporter_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryReporter_Flush(t *testing.T) {
+	var out bytes.Buffer
+	r, err := reporter.NewQueryReporter(`.reports[] | select(.has_drift) | .resource_id`, &out, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	matching := reporter.CreateDummyDriftReport(false)
+	matching.ResourceId = "res-456"
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, matching))
+	assert.Empty(t, out.String(), "nothing is written before the run is flushed")
+
+	require.NoError(t, r.Flush(ctx))
+	assert.Equal(t, "res-123\n", out.String())
+
+	// an empty run writes nothing
+	out.Reset()
+	require.NoError(t, r.Flush(ctx))
+	assert.Empty(t, out.String())
+}
+
+func TestQueryReporter_Aggregates(t *testing.T) {
+	var out bytes.Buffer
+	r, err := reporter.NewQueryReporter(`{checked, drifted, fields: [.reports[].drift_details[]?.field]}`, &out, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.WriteReport(ctx, &driftchecker.DriftReport{Status: driftchecker.Partial, Summary: &driftchecker.ScanSummary{Checked: 2, Total: 3}}))
+	require.NoError(t, r.Flush(ctx))
+
+	assert.JSONEq(t, `{"checked": 2, "drifted": 1, "fields": ["bucket_acl", "tags.Environment"]}`, out.String())
+}
+
+func TestQueryReporter_OutputFile(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "reports", "ids.txt")
+	r, err := reporter.NewQueryReporter(`.reports[].resource_type`, nil, outputFile)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.Flush(ctx))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "aws_s3_bucket\n", string(content))
+}
+
+func TestQueryReporter_Errors(t *testing.T) {
+	_, err := reporter.NewQueryReporter(`.reports[`, nil, "")
+	assert.ErrorContains(t, err, "failed to parse query")
+
+	_, err = reporter.NewQueryReporter(`undefined_function`, nil, "")
+	assert.ErrorContains(t, err, "failed to compile query")
+
+	var out bytes.Buffer
+	r, err := reporter.NewQueryReporter(`.reports[] | error("boom")`, &out, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	assert.ErrorContains(t, r.Flush(ctx), "failed to run query")
+}
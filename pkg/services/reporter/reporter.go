@@ -1,6 +1,16 @@
 // Package reporter provides functionality to output and format drift reports
 // generated by the drift checker. It defines interfaces for writing reports
 // to various output destinations such as files, databases, or external services.
+//
+// An OutputWriter takes part in as many phases of the lifecycle of a run as it needs,
+// by implementing the matching optional interface:
+//
+//   - Begin (Beginner), once at the start of every run, before its first report;
+//   - WriteReport, for every report of the run;
+//   - Flush (Flusher), once at the end of every run, after its last report;
+//   - Close (Closer), once after the last run, such as the last poll of watch mode.
+//
+// Writers that wrap another writer implement every phase and pass it on.
 package reporter
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -17,3 +27,106 @@ import (
 type OutputWriter interface {
 	WriteReport(ctx context.Context, report *driftchecker.DriftReport) error
 }
+
+// Beginner is implemented by OutputWriters that prepare their output at the start of
+// a run, such as opening the file its reports are written to.
+type Beginner interface {
+	Begin(ctx context.Context, run *driftchecker.RunMetadata) error
+}
+
+// Flusher is implemented by OutputWriters that buffer reports during a run and
+// need to emit output once every report has been written, such as a summary.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by OutputWriters that hold resources across runs, such as an
+// open file, released once no more runs follow.
+type Closer interface {
+	Close() error
+}
+
+// BeginWriter begins a run of w if it implements Beginner and is a no-op otherwise.
+func BeginWriter(ctx context.Context, w OutputWriter, run *driftchecker.RunMetadata) error {
+	if b, ok := w.(Beginner); ok {
+		return b.Begin(ctx, run)
+	}
+	return nil
+}
+
+// FlushWriter flushes w if it implements Flusher and is a no-op otherwise.
+func FlushWriter(ctx context.Context, w OutputWriter) error {
+	if f, ok := w.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// CloseWriter closes w if it implements Closer and is a no-op otherwise.
+func CloseWriter(w OutputWriter) error {
+	if c, ok := w.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// countReports counts the resources of a run that were checked, that drifted, that
+// were skipped and whose check failed. Skipped resources and failed checks are not
+// counted as checked.
+func countReports(reports []*driftchecker.DriftReport) (checked, drifted, skipped, failed int) {
+	for _, report := range reports {
+		switch {
+		case report.Status == driftchecker.Skipped:
+			skipped++
+			continue
+		case report.Status == driftchecker.CheckFailed:
+			failed++
+			continue
+		case report.HasDrift:
+			drifted++
+		}
+		checked++
+	}
+	return checked, drifted, skipped, failed
+}
+
+// stateSnapshots returns the distinct state snapshots the reports were read from, in
+// the order they first appear. Reports without run metadata are left out.
+func stateSnapshots(reports []*driftchecker.DriftReport) []*driftchecker.RunMetadata {
+	var snapshots []*driftchecker.RunMetadata
+	seen := map[string]bool{}
+	for _, report := range reports {
+		if report.Run == nil {
+			continue
+		}
+		key := report.Run.StateSnapshot()
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		snapshots = append(snapshots, report.Run)
+	}
+	return snapshots
+}
+
+// sampleSummary returns the sample the reports were checked from, or nil when every
+// resource was checked.
+func sampleSummary(reports []*driftchecker.DriftReport) *driftchecker.SampleSummary {
+	for _, report := range reports {
+		if report.Run != nil && report.Run.Sample != nil {
+			return report.Run.Sample
+		}
+	}
+	return nil
+}
+
+// incrementalSummary returns the summary of a scan of the changed resources the
+// reports were checked in, or nil when every resource was checked.
+func incrementalSummary(reports []*driftchecker.DriftReport) *driftchecker.IncrementalSummary {
+	for _, report := range reports {
+		if report.Run != nil && report.Run.Incremental != nil {
+			return report.Run.Incremental
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slackMaxResources is the number of drifted resources listed in a Slack message; the
+// rest are only counted.
+const slackMaxResources = 20
+
+// SlackReporter implements OutputWriter by posting a summary of the drift of a run to
+// a Slack incoming webhook when the run is flushed. Runs without drift, and drift
+// expected during a maintenance window, post nothing.
+type SlackReporter struct {
+	WebhookURL string
+	Client     *http.Client
+
+	mu      sync.Mutex
+	checked int
+	drifted []*driftchecker.DriftReport
+}
+
+// NewSlackReporter creates a new SlackReporter instance.
+// webhookURL: The URL of the Slack incoming webhook messages are posted to.
+func NewSlackReporter(webhookURL string) *SlackReporter {
+	return &SlackReporter{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WriteReport records the report for the summary posted on Flush.
+func (s *SlackReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	if report.Status == driftchecker.Partial {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if report.Status != driftchecker.Skipped && report.Status != driftchecker.CheckFailed {
+		s.checked++
+	}
+	if report.HasDrift && report.Status != driftchecker.DriftResolved && !report.Expected() {
+		s.drifted = append(s.drifted, report)
+	}
+	return nil
+}
+
+// Flush posts the drifted resources recorded since the last flush to the webhook.
+func (s *SlackReporter) Flush(ctx context.Context) error {
+	ctx, span := telemetry.StartSpan(ctx, "SlackReporter.Flush")
+	defer span.End()
+
+	s.mu.Lock()
+	checked, drifted := s.checked, s.drifted
+	s.checked, s.drifted = 0, nil
+	s.mu.Unlock()
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(map[string]string{"text": slackMessage(checked, drifted)})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to post Slack message: %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// slackMessage renders the drifted resources of a run as Slack mrkdwn, one resource
+// and its drifted attributes per line.
+func slackMessage(checked int, drifted []*driftchecker.DriftReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Drift detected:* %d of %d resource(s) drifted\n", len(drifted), checked)
+	for i, report := range drifted {
+		if i == slackMaxResources {
+			fmt.Fprintf(&b, "…and %d more\n", len(drifted)-slackMaxResources)
+			break
+		}
+		var fields []string
+		for _, item := range report.DriftDetails {
+			if item.DriftType != driftchecker.Match {
+				fields = append(fields, item.Field)
+			}
+		}
+		fmt.Fprintf(&b, "• `%s` %s\n", resourceLabel(report), strings.Join(fields, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,52 @@
+package reporter_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackReporter_Flush(t *testing.T) {
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		messages = append(messages, body["text"])
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	r := reporter.NewSlackReporter(server.URL)
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	expected := reporter.CreateDummyDriftReport(true)
+	expected.Maintenance = &driftchecker.MaintenanceWindow{Resource: "*"}
+	require.NoError(t, r.WriteReport(ctx, expected))
+	require.NoError(t, r.Flush(ctx))
+
+	require.Len(t, messages, 1)
+	assert.Equal(t, "*Drift detected:* 1 of 3 resource(s) drifted\n• `aws_s3_bucket.my-bucket-name (res-123)` bucket_acl, tags.Environment", messages[0])
+
+	// runs without drift post nothing
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	require.NoError(t, r.Flush(ctx))
+	assert.Len(t, messages, 1)
+}
+
+func TestSlackReporter_Flush_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	r := reporter.NewSlackReporter(server.URL)
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true)))
+	assert.ErrorContains(t, r.Flush(context.Background()), "403 Forbidden: invalid_token")
+}
@@ -3,6 +3,7 @@ package reporter
 import (
 	"context"
 	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -18,6 +19,9 @@ func NewStdoutReporter() *StdoutReporter {
 
 // WriteReport marshals the DriftReport to JSON and prints it to os.Stdout.
 func (s *StdoutReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "StdoutReporter.WriteReport")
+	defer span.End()
+
 	// Marshal the report struct to JSON bytes
 	// We use json.MarshalIndent for pretty-printed JSON, which is easier to read.
 	reportBytes, err := json.MarshalIndent(report, "", "  ")
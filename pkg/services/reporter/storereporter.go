@@ -0,0 +1,69 @@
+package reporter
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/telemetry"
+	"fmt"
+)
+
+// StoreReporter implements OutputWriter by persisting every report to a ReportStore
+// before handing it on to the next OutputWriter, so history is recorded without
+// changing what the user sees.
+type StoreReporter struct {
+	Store store.ReportStore
+	RunId string
+	Next  OutputWriter
+}
+
+// NewStoreReporter creates a new StoreReporter instance.
+// reportStore: The store reports are persisted to.
+// runId: The identifier of the run the reports belong to.
+// next: An optional OutputWriter that receives every report after it is stored.
+func NewStoreReporter(reportStore store.ReportStore, runId string, next OutputWriter) *StoreReporter {
+	return &StoreReporter{
+		Store: reportStore,
+		RunId: runId,
+		Next:  next,
+	}
+}
+
+// WriteReport saves the report to the store and then forwards it to the next writer.
+func (s *StoreReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	ctx, span := telemetry.StartSpan(ctx, "StoreReporter.WriteReport")
+	defer span.End()
+
+	if err := s.Store.SaveReport(ctx, s.RunId, report); err != nil {
+		return fmt.Errorf("failed to persist drift report: %w", err)
+	}
+
+	if s.Next == nil {
+		return nil
+	}
+	return s.Next.WriteReport(ctx, report)
+}
+
+// Begin begins the run of the next writer.
+func (s *StoreReporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	if s.Next == nil {
+		return nil
+	}
+	return BeginWriter(ctx, s.Next, run)
+}
+
+// Flush flushes the next writer if it buffers output.
+func (s *StoreReporter) Flush(ctx context.Context) error {
+	if s.Next == nil {
+		return nil
+	}
+	return FlushWriter(ctx, s.Next)
+}
+
+// Close closes the next writer. The store is left open, it is owned by the caller.
+func (s *StoreReporter) Close() error {
+	if s.Next == nil {
+		return nil
+	}
+	return CloseWriter(s.Next)
+}
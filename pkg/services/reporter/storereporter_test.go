@@ -0,0 +1,49 @@
+package reporter_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/reporter/reporterfakes"
+	"drift-watcher/pkg/services/store/storefakes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreReporter_WriteReport(t *testing.T) {
+	fakeStore := &storefakes.FakeReportStore{}
+	next := &reporterfakes.FakeOutputWriter{}
+	report := reporter.CreateDummyDriftReport(true)
+
+	r := reporter.NewStoreReporter(fakeStore, "run-1", next)
+	require.NoError(t, r.WriteReport(context.Background(), report))
+
+	require.Equal(t, 1, fakeStore.SaveReportCallCount())
+	_, runId, saved := fakeStore.SaveReportArgsForCall(0)
+	assert.Equal(t, "run-1", runId)
+	assert.Equal(t, report, saved)
+
+	require.Equal(t, 1, next.WriteReportCallCount())
+	_, forwarded := next.WriteReportArgsForCall(0)
+	assert.Equal(t, report, forwarded)
+}
+
+func TestStoreReporter_WriteReport_StoreError(t *testing.T) {
+	fakeStore := &storefakes.FakeReportStore{}
+	fakeStore.SaveReportReturns(errors.New("disk full"))
+	next := &reporterfakes.FakeOutputWriter{}
+
+	r := reporter.NewStoreReporter(fakeStore, "run-1", next)
+	err := r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(false))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Equal(t, 0, next.WriteReportCallCount())
+}
+
+func TestStoreReporter_WriteReport_NoNext(t *testing.T) {
+	fakeStore := &storefakes.FakeReportStore{}
+	r := reporter.NewStoreReporter(fakeStore, "run-1", nil)
+	assert.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(false)))
+}
@@ -0,0 +1,227 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/telemetry"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data a report template is executed with.
+type TemplateData struct {
+	// Reports holds every report of the run, in the order they were written.
+	Reports     []*driftchecker.DriftReport
+	GeneratedAt time.Time
+	// Checked, Drifted and Skipped count the resources of the run. Failed counts the
+	// resources whose check failed, which are not counted as checked.
+	Checked int
+	Drifted int
+	Skipped int
+	Failed  int
+	// Partial is only set when the scan was interrupted before every resource was checked.
+	Partial *driftchecker.ScanSummary
+	// Sample is only set when a sample of the resources was checked.
+	Sample *driftchecker.SampleSummary
+	// States holds the run metadata of every state snapshot the reports were read
+	// from, with its path, lineage, serial and Terraform version.
+	States []*driftchecker.RunMetadata
+}
+
+// TemplateReporter implements OutputWriter by rendering the reports of a run through
+// a user supplied text/template, so teams can produce their own formats. Reports are
+// collected and the template is executed once per run, when the reporter is flushed.
+// The output goes to OutputFile, or to Out when no file is set.
+type TemplateReporter struct {
+	Template   *template.Template
+	Out        io.Writer
+	OutputFile string
+
+	mu      sync.Mutex
+	reports []*driftchecker.DriftReport
+	partial *driftchecker.ScanSummary
+}
+
+// NewTemplateReporter creates a new TemplateReporter instance.
+// templatePath: The path to the Go text/template file.
+// out: The writer the output is rendered to when outputFile is empty.
+// outputFile: The file the output is written to, replacing its content on every run.
+func NewTemplateReporter(templatePath string, out io.Writer, outputFile string) (*TemplateReporter, error) {
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output template: %w", err)
+	}
+	tmpl, err := ParseTemplate(filepath.Base(templatePath), string(content))
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateReporter{
+		Template:   tmpl,
+		Out:        out,
+		OutputFile: outputFile,
+	}, nil
+}
+
+// ParseTemplate parses a report template with the helper functions available to it:
+//
+//   - drifted: the items of a report, or the reports of a run, that drifted
+//   - value: formats an attribute value, encoding maps and lists as JSON
+//   - json / jsonIndent: encodes any value as JSON
+//   - label: the type.name (id) label of a report's resource
+//   - join, upper, lower, trim, replace, contains: the strings functions
+//   - default: a fallback for an empty value, e.g. {{ default "-" .Stack }}
+//   - formatTime: formats a time with a Go layout, e.g. {{ formatTime "2006-01-02" .GeneratedAt }}
+func ParseTemplate(name string, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output template: %w", err)
+	}
+	return tmpl, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"drifted":    drifted,
+	"value":      formatTemplateValue,
+	"json":       toJSON,
+	"jsonIndent": toJSONIndent,
+	"label":      resourceLabel,
+	"join":       strings.Join,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"replace":    strings.ReplaceAll,
+	"contains":   strings.Contains,
+	"default": func(fallback any, value any) any {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+	"formatTime": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// WriteReport collects the report for the next flush.
+func (t *TemplateReporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	_, span := telemetry.StartSpan(ctx, "TemplateReporter.WriteReport")
+	defer span.End()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if report.Status == driftchecker.Partial {
+		t.partial = report.Summary
+		return nil
+	}
+	t.reports = append(t.reports, report)
+	return nil
+}
+
+// Flush renders the template with every report seen since the last flush. Nothing
+// is rendered when no report was written.
+func (t *TemplateReporter) Flush(ctx context.Context) error {
+	_, span := telemetry.StartSpan(ctx, "TemplateReporter.Flush")
+	defer span.End()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.reports) == 0 && t.partial == nil {
+		return nil
+	}
+
+	data := TemplateData{
+		Reports:     t.reports,
+		GeneratedAt: time.Now().UTC(),
+		Partial:     t.partial,
+		Sample:      sampleSummary(t.reports),
+		States:      stateSnapshots(t.reports),
+	}
+	data.Checked, data.Drifted, data.Skipped, data.Failed = countReports(t.reports)
+	t.reports = nil
+	t.partial = nil
+
+	var rendered bytes.Buffer
+	if err := t.Template.Execute(&rendered, data); err != nil {
+		return fmt.Errorf("failed to render output template: %w", err)
+	}
+
+	if t.OutputFile == "" {
+		if _, err := t.Out.Write(rendered.Bytes()); err != nil {
+			return fmt.Errorf("failed to write rendered report: %w", err)
+		}
+		return nil
+	}
+
+	outputDir := filepath.Dir(t.OutputFile)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+	if err := os.WriteFile(t.OutputFile, rendered.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write rendered report to file %s: %w", t.OutputFile, err)
+	}
+	return nil
+}
+
+// drifted returns the drifted items of a report, or the drifted reports of a run.
+func drifted(value any) (any, error) {
+	switch value := value.(type) {
+	case []driftchecker.DriftItem:
+		var items []driftchecker.DriftItem
+		for _, item := range value {
+			if item.DriftType != driftchecker.Match {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case []*driftchecker.DriftReport:
+		var reports []*driftchecker.DriftReport
+		for _, report := range value {
+			if report.HasDrift {
+				reports = append(reports, report)
+			}
+		}
+		return reports, nil
+	default:
+		return nil, fmt.Errorf("drifted expects drift items or reports, got %T", value)
+	}
+}
+
+// formatTemplateValue renders an attribute value: strings as is, missing values as
+// null and maps and lists as JSON.
+func formatTemplateValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value
+	case map[string]any, []any:
+		return toJSON(value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func toJSON(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+func toJSONIndent(value any) string {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
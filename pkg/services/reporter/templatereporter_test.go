@@ -0,0 +1,99 @@
+package reporter_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0644))
+	return path
+}
+
+func TestTemplateReporter_Flush(t *testing.T) {
+	path := writeTemplate(t, `{{ .Drifted }}/{{ .Checked }} drifted
+{{ range drifted .Reports }}{{ label . }}
+{{ range drifted .DriftDetails }}- {{ .Field }}: {{ value .TerraformValue }} -> {{ value .ActualValue }}
+{{ end }}{{ end }}`)
+	var out bytes.Buffer
+	r, err := reporter.NewTemplateReporter(path, &out, "")
+	require.NoError(t, err)
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.DriftDetails = append(report.DriftDetails,
+		driftchecker.DriftItem{Field: "tags", TerraformValue: map[string]any{"Env": "prod"}, ActualValue: nil, DriftType: driftchecker.AttributeMissingInInfrastructure},
+		driftchecker.DriftItem{Field: "region", TerraformValue: "us-east-1", ActualValue: "us-east-1", DriftType: driftchecker.Match},
+	)
+	ctx := context.Background()
+	require.NoError(t, r.WriteReport(ctx, report))
+	require.NoError(t, r.WriteReport(ctx, reporter.CreateDummyDriftReport(false)))
+	assert.Empty(t, out.String(), "nothing is rendered before the run is flushed")
+
+	require.NoError(t, r.Flush(ctx))
+	assert.Equal(t, `1/2 drifted
+aws_s3_bucket.my-bucket-name (res-123)
+- bucket_acl: private -> public-read
+- tags.Environment: dev -> prod
+- tags: {"Env":"prod"} -> null
+`, out.String())
+
+	// an empty run renders nothing
+	out.Reset()
+	require.NoError(t, r.Flush(ctx))
+	assert.Empty(t, out.String())
+}
+
+func TestTemplateReporter_States(t *testing.T) {
+	path := writeTemplate(t, `{{ range .States }}{{ .StatePath }} {{ .Lineage }} {{ .Serial }} {{ .TerraformVersion }}
+{{ end }}`)
+	var out bytes.Buffer
+	r, err := reporter.NewTemplateReporter(path, &out, "")
+	require.NoError(t, err)
+
+	report := reporter.CreateDummyDriftReport(true)
+	report.Run = &driftchecker.RunMetadata{StatePath: "prod.tfstate", Lineage: "lineage-prod", Serial: 42, TerraformVersion: "1.8.5"}
+	ctx := context.Background()
+	require.NoError(t, r.WriteReport(ctx, report))
+	require.NoError(t, r.WriteReport(ctx, report))
+	require.NoError(t, r.Flush(ctx))
+
+	assert.Equal(t, "prod.tfstate lineage-prod 42 1.8.5\n", out.String())
+}
+
+func TestTemplateReporter_OutputFile(t *testing.T) {
+	path := writeTemplate(t, `{{ range .Reports }}{{ upper .ResourceType }} {{ default "-" .Stack }}{{ end }}`)
+	outputFile := filepath.Join(t.TempDir(), "out", "report.txt")
+	r, err := reporter.NewTemplateReporter(path, nil, outputFile)
+	require.NoError(t, err)
+
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, r.Flush(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "AWS_S3_BUCKET -", string(content))
+}
+
+func TestTemplateReporter_Errors(t *testing.T) {
+	_, err := reporter.NewTemplateReporter(filepath.Join(t.TempDir(), "missing.tmpl"), nil, "")
+	assert.ErrorContains(t, err, "failed to read output template")
+
+	_, err = reporter.NewTemplateReporter(writeTemplate(t, "{{ .Reports"), nil, "")
+	assert.ErrorContains(t, err, "failed to parse output template")
+
+	var out bytes.Buffer
+	r, err := reporter.NewTemplateReporter(writeTemplate(t, "{{ drifted .GeneratedAt }}"), &out, "")
+	require.NoError(t, err)
+	require.NoError(t, r.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true)))
+	assert.ErrorContains(t, r.Flush(context.Background()), "drifted expects drift items or reports")
+}
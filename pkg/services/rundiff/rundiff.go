@@ -0,0 +1,239 @@
+// Package rundiff compares the reports of two drift detection runs, e.g. last week's
+// scan and today's, listing the resources that newly drifted, those whose drift was
+// resolved and those whose drift changed in between.
+package rundiff
+
+import (
+	"bytes"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// Changes of a resource, or of one of its attributes, between two runs.
+const (
+	Drifted  = "DRIFTED"
+	Resolved = "RESOLVED"
+	Changed  = "CHANGED"
+)
+
+// AttributeChange is an attribute whose drift differs between the runs. Before or
+// After is nil when the attribute only drifted in the other run.
+type AttributeChange struct {
+	Attribute string                  `json:"attribute"`
+	Change    string                  `json:"change"`
+	Before    *driftchecker.DriftItem `json:"before,omitempty"`
+	After     *driftchecker.DriftItem `json:"after,omitempty"`
+}
+
+// ResourceChange is a resource whose drift differs between the runs.
+type ResourceChange struct {
+	// Resource is the address of the resource, prefixed with its Terragrunt stack.
+	Resource string `json:"resource"`
+	Type     string `json:"type,omitempty"`
+	Change   string `json:"change"`
+	// StatusBefore and StatusAfter are the statuses of the reports of the resource, or
+	// empty when the resource was not checked by the run.
+	StatusBefore string            `json:"status_before,omitempty"`
+	StatusAfter  string            `json:"status_after,omitempty"`
+	Attributes   []AttributeChange `json:"attributes,omitempty"`
+}
+
+// Diff is the difference between two runs. Resources are sorted by address and those
+// whose drift is the same in both runs are only counted, as Unchanged.
+type Diff struct {
+	Resources []ResourceChange `json:"resources"`
+	Drifted   int              `json:"drifted"`
+	Resolved  int              `json:"resolved"`
+	Changed   int              `json:"changed"`
+	// Unchanged counts the resources that drifted the same way in both runs.
+	Unchanged int `json:"unchanged"`
+}
+
+// Compare returns the difference between the reports of the before and after runs.
+// Reports are matched by the address of their resource. A resource that only has a
+// report in the after run and drifted there newly drifted; a resource that drifted in
+// the before run but was not checked by the after run is left out, as whether its
+// drift was resolved is not known. Skipped resources, failed checks and the summaries
+// of interrupted scans are not compared.
+func Compare(before, after []*driftchecker.DriftReport) *Diff {
+	previous, current := byResource(before), byResource(after)
+	diff := &Diff{Resources: []ResourceChange{}}
+	for _, resource := range sortedKeys(previous, current) {
+		old, checked := previous[resource], current[resource]
+		if checked == nil {
+			continue
+		}
+		change := ResourceChange{Resource: resource, Type: checked.ResourceType, StatusAfter: checked.Status}
+		wasDrifted := old != nil && drifted(old)
+		if old != nil {
+			change.StatusBefore = old.Status
+		}
+
+		switch {
+		case !wasDrifted && !drifted(checked):
+			continue
+		case !wasDrifted:
+			change.Change = Drifted
+			change.Attributes = compareItems(nil, checked)
+			diff.Drifted++
+		case !drifted(checked):
+			change.Change = Resolved
+			change.Attributes = compareItems(old, nil)
+			diff.Resolved++
+		default:
+			change.Attributes = compareItems(old, checked)
+			if len(change.Attributes) == 0 && old.Status == checked.Status {
+				diff.Unchanged++
+				continue
+			}
+			change.Change = Changed
+			diff.Changed++
+		}
+		diff.Resources = append(diff.Resources, change)
+	}
+	return diff
+}
+
+// compareItems returns the attributes whose drift differs between the reports. A nil
+// report has no drift.
+func compareItems(before, after *driftchecker.DriftReport) []AttributeChange {
+	previous, current := driftedItems(before), driftedItems(after)
+	var changes []AttributeChange
+	for _, field := range sortedKeys(previous, current) {
+		old, item := previous[field], current[field]
+		change := AttributeChange{Attribute: field, Before: old, After: item}
+		switch {
+		case old == nil:
+			change.Change = Drifted
+		case item == nil:
+			change.Change = Resolved
+		case old.DriftType != item.DriftType || !reflect.DeepEqual(old.TerraformValue, item.TerraformValue) || !reflect.DeepEqual(old.ActualValue, item.ActualValue):
+			change.Change = Changed
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// driftedItems returns the drifted attributes of report by name.
+func driftedItems(report *driftchecker.DriftReport) map[string]*driftchecker.DriftItem {
+	items := map[string]*driftchecker.DriftItem{}
+	if report == nil {
+		return items
+	}
+	for i := range report.DriftDetails {
+		if item := &report.DriftDetails[i]; item.DriftType != driftchecker.Match {
+			items[item.Field] = item
+		}
+	}
+	return items
+}
+
+// drifted reports whether report holds drift that has not been resolved.
+func drifted(report *driftchecker.DriftReport) bool {
+	return report.HasDrift && report.Status != driftchecker.DriftResolved
+}
+
+// byResource returns the reports of the checked resources by address. When a run
+// reported a resource more than once, as watch mode does, its latest report is kept.
+func byResource(reports []*driftchecker.DriftReport) map[string]*driftchecker.DriftReport {
+	resources := map[string]*driftchecker.DriftReport{}
+	for _, report := range reports {
+		if report.Status == driftchecker.Skipped || report.Status == driftchecker.Partial || report.Status == driftchecker.CheckFailed {
+			continue
+		}
+		key := Address(report)
+		if latest, ok := resources[key]; !ok || !report.GeneratedAt.Before(latest.GeneratedAt) {
+			resources[key] = report
+		}
+	}
+	return resources
+}
+
+// Address returns the address a report is matched by: the full state address of its
+// resource, or type.name, prefixed with the stack of Terragrunt resources. The resource
+// id is used for resources without a name, such as unmanaged resources.
+func Address(report *driftchecker.DriftReport) string {
+	address := report.ResourceAddress
+	switch {
+	case address != "":
+	case report.ResourceName != "":
+		address = report.ResourceType + "." + report.ResourceName
+	case report.ResourceType != "":
+		address = report.ResourceType + "." + report.ResourceId
+	default:
+		address = report.ResourceId
+	}
+	if report.Stack != "" {
+		address = report.Stack + "/" + address
+	}
+	return address
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Read decodes the reports of a run from r. It accepts what detect writes: a single
+// report, a JSON array of reports, or one report per line as written with --format
+// ndjson.
+func Read(r io.Reader) ([]*driftchecker.DriftReport, error) {
+	decoder := json.NewDecoder(r)
+	var reports []*driftchecker.DriftReport
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return reports, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode drift reports: %w", err)
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			var list []*driftchecker.DriftReport
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, fmt.Errorf("failed to decode drift reports: %w", err)
+			}
+			reports = append(reports, list...)
+			continue
+		}
+		report := &driftchecker.DriftReport{}
+		if err := json.Unmarshal(raw, report); err != nil {
+			return nil, fmt.Errorf("failed to decode drift report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+}
+
+// ReadFile decodes the reports of a run from the file at filePath, as Read does.
+func ReadFile(filePath string) ([]*driftchecker.DriftReport, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report file: %w", err)
+	}
+	defer f.Close()
+
+	reports, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return reports, nil
+}
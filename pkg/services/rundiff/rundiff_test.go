@@ -0,0 +1,114 @@
+package rundiff_test
+
+import (
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/rundiff"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func report(name string, items ...driftchecker.DriftItem) *driftchecker.DriftReport {
+	r := &driftchecker.DriftReport{ResourceType: "aws_instance", ResourceName: name, Status: driftchecker.Match, DriftDetails: items}
+	for _, item := range items {
+		if item.DriftType != driftchecker.Match {
+			r.HasDrift, r.Status = true, driftchecker.Drift
+		}
+	}
+	return r
+}
+
+func changed(field string, actual any) driftchecker.DriftItem {
+	return driftchecker.DriftItem{Field: field, TerraformValue: "want", ActualValue: actual, DriftType: driftchecker.AttributeValueChanged}
+}
+
+func TestCompare(t *testing.T) {
+	before := []*driftchecker.DriftReport{
+		report("web", changed("instance_type", "t3.large")),
+		report("db", changed("instance_type", "r5.large"), changed("tags.Owner", "ops")),
+		report("cache", changed("instance_type", "t3.small")),
+		report("api"),
+		report("retired", changed("instance_type", "t3.nano")),
+	}
+	after := []*driftchecker.DriftReport{
+		report("web", changed("instance_type", "t3.large")),
+		report("db", changed("instance_type", "r5.xlarge"), changed("ami", "ami-2")),
+		report("cache"),
+		report("api", changed("tags.Env", "dev")),
+		report("new", changed("instance_type", "t3.micro")),
+	}
+
+	diff := rundiff.Compare(before, after)
+	assert.Equal(t, 2, diff.Drifted)
+	assert.Equal(t, 1, diff.Resolved)
+	assert.Equal(t, 1, diff.Changed)
+	assert.Equal(t, 1, diff.Unchanged, "web drifted the same way in both runs")
+
+	require.Len(t, diff.Resources, 4, "retired was not checked by the second run")
+	api, cache, db, added := diff.Resources[0], diff.Resources[1], diff.Resources[2], diff.Resources[3]
+
+	assert.Equal(t, "aws_instance.api", api.Resource)
+	assert.Equal(t, rundiff.Drifted, api.Change)
+	assert.Equal(t, driftchecker.Match, api.StatusBefore)
+	require.Len(t, api.Attributes, 1)
+	assert.Nil(t, api.Attributes[0].Before)
+	assert.Equal(t, "dev", api.Attributes[0].After.ActualValue)
+
+	assert.Equal(t, rundiff.Resolved, cache.Change)
+	assert.Equal(t, []rundiff.AttributeChange{
+		{Attribute: "instance_type", Change: rundiff.Resolved, Before: &before[2].DriftDetails[0]},
+	}, cache.Attributes)
+
+	assert.Equal(t, rundiff.Changed, db.Change)
+	var changes []string
+	for _, attribute := range db.Attributes {
+		changes = append(changes, attribute.Attribute+":"+attribute.Change)
+	}
+	assert.Equal(t, []string{"ami:DRIFTED", "instance_type:CHANGED", "tags.Owner:RESOLVED"}, changes)
+
+	assert.Equal(t, "aws_instance.new", added.Resource)
+	assert.Equal(t, rundiff.Drifted, added.Change)
+	assert.Empty(t, added.StatusBefore)
+}
+
+func TestCompare_LatestReportAndSkipped(t *testing.T) {
+	now := time.Now()
+	first := report("web", changed("instance_type", "t3.large"))
+	first.GeneratedAt = now
+	fixed := report("web")
+	fixed.GeneratedAt = now.Add(time.Minute)
+	skipped := &driftchecker.DriftReport{ResourceType: "aws_instance", ResourceName: "db", Status: driftchecker.Skipped}
+
+	diff := rundiff.Compare(
+		[]*driftchecker.DriftReport{report("web", changed("instance_type", "t3.large")), report("db", changed("ami", "ami-2"))},
+		[]*driftchecker.DriftReport{fixed, first, skipped},
+	)
+	require.Len(t, diff.Resources, 1, "the skipped resource was not checked")
+	assert.Equal(t, rundiff.Resolved, diff.Resources[0].Change, "the latest report of a resource is compared")
+}
+
+func TestAddress(t *testing.T) {
+	assert.Equal(t, "module.app.aws_instance.web[0]", rundiff.Address(&driftchecker.DriftReport{ResourceAddress: "module.app.aws_instance.web[0]", ResourceType: "aws_instance", ResourceName: "web"}))
+	assert.Equal(t, "prod/vpc/aws_instance.web", rundiff.Address(&driftchecker.DriftReport{ResourceType: "aws_instance", ResourceName: "web", Stack: "prod/vpc"}))
+	assert.Equal(t, "aws_instance.i-stray", rundiff.Address(&driftchecker.DriftReport{ResourceType: "aws_instance", ResourceId: "i-stray"}))
+}
+
+func TestRead(t *testing.T) {
+	single := `{"resource_type": "aws_instance", "resource_name": "web", "status": "MATCH"}`
+	for name, input := range map[string]string{
+		"report": single,
+		"array":  "[" + single + "," + single + "]",
+		"ndjson": single + "\n" + single + "\n",
+	} {
+		reports, err := rundiff.Read(strings.NewReader(input))
+		require.NoError(t, err, name)
+		require.NotEmpty(t, reports, name)
+		assert.Equal(t, "web", reports[0].ResourceName, name)
+	}
+
+	_, err := rundiff.Read(strings.NewReader(`{"resource_name": `))
+	assert.ErrorContains(t, err, "failed to decode drift reports")
+}
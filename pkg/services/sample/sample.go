@@ -0,0 +1,63 @@
+// Package sample selects a subset of the resources of a state, a random share of them
+// or the first few, so a very large state can be smoke checked quickly.
+package sample
+
+import (
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sampling describes the subset of resources a scan checks.
+type Sampling struct {
+	// Percent is the share of the resources checked, picked at random. Zero checks
+	// every resource.
+	Percent float64
+	// Limit is the maximum number of resources checked, taken in state order after
+	// sampling. Zero sets no limit.
+	Limit int
+	// Seed seeds the random selection, so a sample can be checked again.
+	Seed uint64
+}
+
+// ParsePercent parses a sample size written as a percentage, e.g. 10% or 2.5%. The
+// percent sign may be left out.
+func ParsePercent(value string) (float64, error) {
+	number := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	percent, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsNaN(percent) || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid sample %q, expected a percentage between 0 and 100 such as 10%%", value)
+	}
+	return percent, nil
+}
+
+// Enabled reports whether s selects a subset of the resources.
+func (s Sampling) Enabled() bool {
+	return (s.Percent > 0 && s.Percent < 100) || s.Limit > 0
+}
+
+// Select returns the resources of the sample, in state order. A random sample holds
+// Percent of the resources, rounded up so that a sample is never empty, and is the
+// same for the same Seed and resources. The sample is then cut to Limit resources.
+func (s Sampling) Select(resources []statemanager.StateResource) []statemanager.StateResource {
+	selected := resources
+	if s.Percent > 0 && s.Percent < 100 && len(resources) > 0 {
+		size := int(math.Ceil(float64(len(resources)) * s.Percent / 100))
+		random := rand.New(rand.NewPCG(s.Seed, s.Seed))
+		picked := random.Perm(len(resources))[:size]
+		slices.Sort(picked)
+
+		selected = make([]statemanager.StateResource, 0, size)
+		for _, i := range picked {
+			selected = append(selected, resources[i])
+		}
+	}
+	if s.Limit > 0 && len(selected) > s.Limit {
+		selected = selected[:s.Limit]
+	}
+	return selected
+}
@@ -0,0 +1,70 @@
+package sample_test
+
+import (
+	"drift-watcher/pkg/services/sample"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resources(n int) []statemanager.StateResource {
+	var out []statemanager.StateResource
+	for i := range n {
+		out = append(out, statemanager.StateResource{Type: "aws_instance", Name: fmt.Sprintf("web-%02d", i)})
+	}
+	return out
+}
+
+func names(resources []statemanager.StateResource) []string {
+	var out []string
+	for _, r := range resources {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestParsePercent(t *testing.T) {
+	for value, want := range map[string]float64{"10%": 10, "2.5%": 2.5, " 50 ": 50, "100%": 100} {
+		got, err := sample.ParsePercent(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "%", "0%", "-5%", "101%", "ten%", "NaN"} {
+		_, err := sample.ParsePercent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSelect_Percent(t *testing.T) {
+	all := resources(40)
+	s := sample.Sampling{Percent: 10, Seed: 7}
+	assert.True(t, s.Enabled())
+
+	picked := s.Select(all)
+	require.Len(t, picked, 4)
+	assert.IsIncreasing(t, names(picked), "the sample keeps the state order")
+	assert.Equal(t, names(picked), names(s.Select(all)), "the same seed picks the same sample")
+
+	// a sample is never empty
+	assert.Len(t, sample.Sampling{Percent: 1}.Select(resources(3)), 1)
+}
+
+func TestSelect_Limit(t *testing.T) {
+	all := resources(10)
+	assert.Equal(t, []string{"web-00", "web-01", "web-02"}, names(sample.Sampling{Limit: 3}.Select(all)))
+	assert.Len(t, sample.Sampling{Limit: 20}.Select(all), 10)
+
+	// the random sample is cut to the limit
+	assert.Len(t, sample.Sampling{Percent: 50, Limit: 2, Seed: 1}.Select(all), 2)
+}
+
+func TestSelect_Disabled(t *testing.T) {
+	all := resources(5)
+	for _, s := range []sample.Sampling{{}, {Percent: 100}} {
+		assert.False(t, s.Enabled())
+		assert.Equal(t, all, s.Select(all))
+	}
+}
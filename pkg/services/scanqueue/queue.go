@@ -0,0 +1,268 @@
+// Package scanqueue runs the scans of state files uploaded to the serve command in the
+// background, so that a client does not hold a request open for the length of a scan
+// but polls, or follows, the job its upload returned.
+package scanqueue
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownTarget is returned for a scan of a target the queue does not serve,
+// ErrQueueFull when a scan is submitted while as many scans wait for a worker as the
+// queue holds, and ErrUnknownJob for a job id that is not known, or no longer.
+var (
+	ErrUnknownTarget = errors.New("unknown target")
+	ErrQueueFull     = errors.New("scan queue full")
+	ErrUnknownJob    = errors.New("unknown scan")
+)
+
+// Statuses of a job.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+)
+
+// Job is a scan of an uploaded state file.
+type Job struct {
+	Id          string    `json:"id"`
+	Target      string    `json:"target"`
+	Status      string    `json:"status"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	StartedAt   time.Time `json:"started_at,omitzero"`
+	FinishedAt  time.Time `json:"finished_at,omitzero"`
+	Error       string    `json:"error,omitempty"`
+	// Drifted is the number of drifted resources, and Reports the reports of the
+	// scan, as far as it got.
+	Drifted int                         `json:"drifted"`
+	Reports []*driftchecker.DriftReport `json:"reports,omitempty"`
+	// StatePath is the uploaded state file, removed when the scan finishes.
+	StatePath string `json:"-"`
+
+	// changed is closed, and replaced, whenever the job changes.
+	changed chan struct{}
+}
+
+// Finished reports whether the scan of the job is over.
+func (j Job) Finished() bool {
+	return j.Status == StatusOK || j.Status == StatusFailed
+}
+
+// RunFunc scans the state file of job as its target, passing every report to report
+// as soon as it is produced.
+type RunFunc func(ctx context.Context, job Job, report func(*driftchecker.DriftReport)) error
+
+// Queue runs submitted scans on a fixed number of workers. Jobs are kept in memory
+// for a retention period after they finish; their reports are expected to be saved
+// by the RunFunc for later.
+type Queue struct {
+	targets   []string
+	run       RunFunc
+	workers   int
+	depth     int
+	retention time.Duration
+
+	pending chan *Job
+	mu      sync.Mutex
+	jobs    map[string]*Job
+}
+
+// Option configures a Queue.
+type Option func(*Queue)
+
+// WithWorkers runs n scans at the same time, 1 by default.
+func WithWorkers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// WithDepth refuses new scans while n scans wait for a worker, 10 by default.
+func WithDepth(n int) Option {
+	return func(q *Queue) {
+		q.depth = n
+	}
+}
+
+// WithRetention keeps finished jobs for d, an hour by default.
+func WithRetention(d time.Duration) Option {
+	return func(q *Queue) {
+		q.retention = d
+	}
+}
+
+// New creates a queue scanning state files as one of targets with run.
+func New(targets []string, run RunFunc, opts ...Option) *Queue {
+	q := &Queue{
+		targets:   targets,
+		run:       run,
+		workers:   1,
+		depth:     10,
+		retention: time.Hour,
+		jobs:      map[string]*Job{},
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.pending = make(chan *Job, max(q.depth, 1))
+	return q
+}
+
+// Submit queues the scan of the state file at statePath as target, which may be empty
+// when the queue serves a single target, and returns its job. Once submitted, the
+// state file belongs to the queue, which removes it when the scan finishes.
+func (q *Queue) Submit(ctx context.Context, target string, statePath string) (Job, error) {
+	if target == "" && len(q.targets) == 1 {
+		target = q.targets[0]
+	}
+	if !slices.Contains(q.targets, target) {
+		if target == "" {
+			return Job{}, fmt.Errorf("%w: a target is required, one of %s", ErrUnknownTarget, strings.Join(q.targets, ", "))
+		}
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownTarget, target)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	q.sweep(now)
+	job := &Job{Id: uuid.NewString(), Target: target, Status: StatusQueued, SubmittedAt: now, StatePath: statePath, changed: make(chan struct{})}
+	select {
+	case q.pending <- job:
+	default:
+		return Job{}, fmt.Errorf("%w: %d scans waiting", ErrQueueFull, len(q.pending))
+	}
+	q.jobs[job.Id] = job
+	logger(ctx).Info("Scan queued", "scan_id", job.Id, "target", target)
+	return *job, nil
+}
+
+// Get returns the job id.
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownJob, id)
+	}
+	return *job, nil
+}
+
+// Watch returns the job id and a channel closed when it next changes, so that its
+// reports can be followed while it runs.
+func (q *Queue) Watch(id string) (Job, <-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, nil, fmt.Errorf("%w: %s", ErrUnknownJob, id)
+	}
+	return *job, job.changed, nil
+}
+
+// Start runs the queued scans until ctx is cancelled, then waits for the scans in
+// progress, which are cancelled with ctx, to return. Scans still queued then fail.
+func (q *Queue) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range q.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.pending:
+					q.process(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for {
+		select {
+		case job := <-q.pending:
+			q.finish(ctx, job, fmt.Errorf("server stopped before the scan started"))
+		default:
+			return
+		}
+	}
+}
+
+// process runs the scan of job.
+func (q *Queue) process(ctx context.Context, job *Job) {
+	q.mu.Lock()
+	job.Status, job.StartedAt = StatusRunning, time.Now()
+	running := *job
+	q.changedLocked(job)
+	q.mu.Unlock()
+
+	logger(ctx).Info("Starting scan", "scan_id", job.Id, "target", job.Target)
+	err := q.run(ctx, running, func(report *driftchecker.DriftReport) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		job.Reports = append(job.Reports, report)
+		if report.HasDrift {
+			job.Drifted++
+		}
+		q.changedLocked(job)
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	q.finish(ctx, job, err)
+	if err != nil {
+		logger(ctx).Error("Scan failed", "scan_id", job.Id, "target", job.Target, "error", err)
+	} else {
+		logger(ctx).Info("Scan finished", "scan_id", job.Id, "target", job.Target, "drifted", job.Drifted)
+	}
+}
+
+// finish records the outcome of job and removes its state file.
+func (q *Queue) finish(ctx context.Context, job *Job, err error) {
+	if removeErr := os.Remove(job.StatePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+		logger(ctx).Warn("Failed to remove uploaded state file", "path", job.StatePath, "error", removeErr)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.FinishedAt, job.Status = time.Now(), StatusOK
+	if err != nil {
+		job.Status, job.Error = StatusFailed, err.Error()
+	}
+	q.changedLocked(job)
+}
+
+// changedLocked wakes the watchers of job. q.mu must be held.
+func (q *Queue) changedLocked(job *Job) {
+	close(job.changed)
+	job.changed = make(chan struct{})
+}
+
+// sweep drops the jobs that finished longer than the retention period before now.
+func (q *Queue) sweep(now time.Time) {
+	for id, job := range q.jobs {
+		if !job.FinishedAt.IsZero() && now.Sub(job.FinishedAt) > q.retention {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// logger returns the logger carried by ctx for the scanqueue module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "scanqueue")
+}
@@ -0,0 +1,132 @@
+package scanqueue_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/scanqueue"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeState(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "upload.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 4}`), 0600))
+	return path
+}
+
+func waitFor(t *testing.T, q *scanqueue.Queue, id string, status string) scanqueue.Job {
+	t.Helper()
+	var job scanqueue.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = q.Get(id)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestQueue_RunsSubmittedScans(t *testing.T) {
+	release := make(chan struct{})
+	q := scanqueue.New([]string{"prod"}, func(ctx context.Context, job scanqueue.Job, report func(*driftchecker.DriftReport)) error {
+		assert.FileExists(t, job.StatePath)
+		report(&driftchecker.DriftReport{ResourceId: "i-1", HasDrift: true})
+		<-release
+		report(&driftchecker.DriftReport{ResourceId: "i-2"})
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Start(ctx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	state := writeState(t)
+	job, err := q.Submit(ctx, "", state)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", job.Target, "the only target is the default")
+	assert.Equal(t, scanqueue.StatusQueued, job.Status)
+
+	// reports are published while the scan runs
+	var running scanqueue.Job
+	for {
+		var changed <-chan struct{}
+		running, changed, err = q.Watch(job.Id)
+		require.NoError(t, err)
+		if len(running.Reports) > 0 {
+			break
+		}
+		<-changed
+	}
+	assert.Equal(t, "i-1", running.Reports[0].ResourceId)
+	assert.Equal(t, 1, running.Drifted)
+	assert.Equal(t, scanqueue.StatusRunning, running.Status)
+	assert.False(t, running.Finished())
+
+	close(release)
+	finished := waitFor(t, q, job.Id, scanqueue.StatusOK)
+	assert.True(t, finished.Finished())
+	assert.Equal(t, 1, finished.Drifted)
+	assert.Len(t, finished.Reports, 2)
+	assert.False(t, finished.FinishedAt.Before(finished.StartedAt))
+	assert.NoFileExists(t, state, "the uploaded state is removed once scanned")
+
+	_, err = q.Submit(ctx, "staging", writeState(t))
+	assert.ErrorIs(t, err, scanqueue.ErrUnknownTarget)
+	_, err = q.Get("missing")
+	assert.ErrorIs(t, err, scanqueue.ErrUnknownJob)
+}
+
+func TestQueue_Failures(t *testing.T) {
+	q := scanqueue.New([]string{"prod", "staging"}, func(ctx context.Context, job scanqueue.Job, report func(*driftchecker.DriftReport)) error {
+		return errors.New("failed to parse state file")
+	}, scanqueue.WithDepth(1))
+	ctx := context.Background()
+
+	_, err := q.Submit(ctx, "", writeState(t))
+	assert.EqualError(t, err, "unknown target: a target is required, one of prod, staging")
+
+	// nothing is taken off the queue until it is started
+	queued, err := q.Submit(ctx, "prod", writeState(t))
+	require.NoError(t, err)
+	_, err = q.Submit(ctx, "staging", writeState(t))
+	assert.ErrorIs(t, err, scanqueue.ErrQueueFull)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		q.Start(runCtx)
+		close(stopped)
+	}()
+	failed := waitFor(t, q, queued.Id, scanqueue.StatusFailed)
+	assert.Equal(t, "failed to parse state file", failed.Error)
+	cancel()
+	<-stopped
+}
+
+func TestQueue_FailsQueuedScansOnStop(t *testing.T) {
+	q := scanqueue.New([]string{"prod"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := q.Submit(ctx, "prod", writeState(t))
+	require.NoError(t, err)
+
+	cancel()
+	q.Start(ctx)
+	stopped, err := q.Get(job.Id)
+	require.NoError(t, err)
+	assert.Equal(t, scanqueue.StatusFailed, stopped.Status)
+	assert.Equal(t, "server stopped before the scan started", stopped.Error)
+	assert.NoFileExists(t, job.StatePath)
+}
@@ -0,0 +1,179 @@
+// Package schedule runs named drift scans on cron schedules, as done by the serve
+// command. Schedules can be paused and resumed while the server runs, and every run
+// they start is recorded in the report store.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the shorthands accepted in place of the five fields of a cron
+// expression.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField is the range of values of a field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Cron is a parsed cron expression of five fields: minute, hour, day of month, month
+// and day of week. Times are matched in the location of the time given.
+type Cron struct {
+	expr string
+	// fields holds, per field, a bit per allowed value.
+	fields [5]uint64
+	// anyDayOfMonth and anyDayOfWeek report whether the day fields are *, as the two
+	// are combined with OR when both are restricted.
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// ParseCron parses a cron expression such as "*/15 * * * *" or "0 6 * * 1-5". Each
+// field is *, a value, a range a-b, or a comma-separated list of them, optionally
+// followed by a step /n. Day of week 7 is Sunday, like 0. The macros @hourly, @daily,
+// @midnight, @weekly, @monthly, @yearly and @annually are accepted too.
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(parts))
+	}
+
+	c := &Cron{expr: expr, anyDayOfMonth: parts[2] == "*", anyDayOfWeek: parts[4] == "*"}
+	for i, part := range parts {
+		field := cronFields[i]
+		if i == 4 {
+			// 7 is accepted for Sunday
+			field.max = 7
+		}
+		bits, err := parseCronField(part, field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		if i == 4 && bits&(1<<7) != 0 {
+			bits = bits&^(1<<7) | 1
+		}
+		c.fields[i] = bits
+	}
+	return c, nil
+}
+
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, field.name)
+			}
+			step = n
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = cronValue(from, field); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(to, field); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, field.name)
+			}
+		default:
+			value, err := cronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(value string, field cronField) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", value, field.name, field.min, field.max)
+	}
+	return n, nil
+}
+
+// String returns the expression c was parsed from.
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Matches reports whether t, truncated to the minute, is a time of the schedule.
+func (c *Cron) Matches(t time.Time) bool {
+	return c.has(0, t.Minute()) && c.has(1, t.Hour()) && c.has(3, int(t.Month())) && c.dayMatches(t)
+}
+
+// Next returns the first time of the schedule after t, or the zero time when there is
+// none within five years, e.g. for 0 0 30 2 *.
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case !c.has(3, int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !c.has(1, next.Hour()):
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !c.has(0, next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t is a day of the schedule.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dayOfMonth, dayOfWeek := c.has(2, t.Day()), c.has(4, int(t.Weekday()))
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func (c *Cron) has(field, value int) bool {
+	return c.fields[field]&(1<<value) != 0
+}
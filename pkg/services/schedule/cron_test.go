@@ -0,0 +1,91 @@
+package schedule_test
+
+import (
+	"drift-watcher/pkg/services/schedule"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"* * * *", "expected 5 fields, got 4"},
+		{"60 * * * *", `invalid value "60" in minute field, expected 0-59`},
+		{"* 24 * * *", "hour field"},
+		{"* * 0 * *", "day of month field"},
+		{"* * * 13 *", "month field"},
+		{"* * * * 8", "day of week field"},
+		{"*/0 * * * *", `invalid step "0"`},
+		{"10-5 * * * *", `invalid range "10-5"`},
+		{"@every 5m", "expected 5 fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := schedule.ParseCron(tt.expr)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestCron_Matches(t *testing.T) {
+	// 2026-03-02 is a Monday
+	monday := time.Date(2026, 3, 2, 6, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr  string
+		t     time.Time
+		match bool
+	}{
+		{"* * * * *", monday, true},
+		{"*/15 * * * *", monday, true},
+		{"*/20 * * * *", monday, false},
+		{"30 6 * * 1-5", monday, true},
+		{"30 6 * * 0,6", monday, false},
+		{"30 6 * * 7", monday.AddDate(0, 0, 6), true},
+		{"5-40/5 * * * *", monday, true},
+		{"0,30 6-8 2 3 *", monday, true},
+		// both day fields restricted: either one matching is enough
+		{"30 6 15 * 1", monday, true},
+		{"30 6 2 * 0", monday, true},
+		{"30 6 15 * 0", monday, false},
+		{"@hourly", monday, false},
+		{"@daily", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := schedule.ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, cron.Matches(tt.t))
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	from := time.Date(2026, 3, 2, 6, 30, 15, 0, time.UTC)
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 2, 6, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 2, 6, 45, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)},
+		{"0 6 * * 1-5", time.Date(2026, 3, 3, 6, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := schedule.ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.next, cron.Next(from))
+			assert.Equal(t, tt.expr, cron.String())
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package schedule
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/lock"
+	"drift-watcher/pkg/services/store"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownSchedule is returned for a schedule name that is not served,
+// ErrRunInProgress when a schedule is started while its previous run is in progress,
+// ErrLockHeld when the run is taken by another server sharing the locker, and
+// ErrTooManyRuns when a run is triggered while the scheduler runs as many as it may.
+var (
+	ErrUnknownSchedule = errors.New("unknown schedule")
+	ErrRunInProgress   = errors.New("run in progress")
+	ErrLockHeld        = errors.New("lock held by another server")
+	ErrTooManyRuns     = errors.New("too many runs in progress")
+)
+
+// Entry is a named schedule.
+type Entry struct {
+	Name string
+	Cron *Cron
+}
+
+// RunFunc runs the scan of the schedule name, storing its reports under runId, and
+// returns the number of drifted resources.
+type RunFunc func(ctx context.Context, name string, runId string) (drifted int, err error)
+
+// Status describes a schedule as listed by the server.
+type Status struct {
+	Name    string              `json:"name"`
+	Cron    string              `json:"cron"`
+	Paused  bool                `json:"paused"`
+	Running bool                `json:"running"`
+	NextRun time.Time           `json:"next_run,omitzero"`
+	LastRun *store.ScheduledRun `json:"last_run,omitempty"`
+}
+
+// Scheduler starts the runs of its schedules when they are due. Whether a schedule is
+// paused is read from the store before every run, so a schedule paused by another
+// process, e.g. with 'driftwatcher schedule pause', is honoured too. A schedule is
+// not started again while its previous run is in progress.
+type Scheduler struct {
+	entries map[string]Entry
+	order   []string
+	store   store.ScheduleStore
+	run     RunFunc
+	locker  lock.Locker
+	lockTTL time.Duration
+	maxRuns int
+
+	mu      sync.Mutex
+	running map[string]bool
+	// base is the context the scheduler was started with, which runs started by
+	// Trigger are cancelled with.
+	base context.Context
+	wg   sync.WaitGroup
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithLocker makes the scheduler take a lock in locker before every run, so that of
+// several servers running the same schedules for high availability only one runs each
+// due scan, and a schedule never runs on two servers at the same time. Locks are held
+// for ttl and renewed while the run is in progress; a run whose lock is lost is
+// cancelled.
+func WithLocker(locker lock.Locker, ttl time.Duration) Option {
+	return func(s *Scheduler) {
+		s.locker, s.lockTTL = locker, ttl
+	}
+}
+
+// WithMaxRuns refuses to trigger a run while max runs, due or triggered, are in
+// progress, so that runs started by hand cannot pile up and exhaust the API quotas of
+// the scanned accounts. Due runs are always started. A max of 0 means no limit.
+func WithMaxRuns(max int) Option {
+	return func(s *Scheduler) {
+		s.maxRuns = max
+	}
+}
+
+// New creates a scheduler running entries with run, recording the runs in st.
+func New(entries []Entry, st store.ScheduleStore, run RunFunc, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		entries: make(map[string]Entry, len(entries)),
+		store:   st,
+		run:     run,
+		running: map[string]bool{},
+	}
+	for _, entry := range entries {
+		s.entries[entry.Name] = entry
+		s.order = append(s.order, entry.Name)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start runs the schedules until ctx is cancelled, then waits for the runs in
+// progress, which are cancelled with ctx, to return.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.base = ctx
+	s.mu.Unlock()
+	logger(ctx).Info("Scheduler started", "schedules", len(s.entries))
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.Wait()
+			logger(ctx).Info("Scheduler stopped")
+			return
+		case <-timer.C:
+			s.RunDue(ctx, next)
+		}
+	}
+}
+
+// RunDue starts the runs of the schedules due at now that are not paused. The runs
+// continue in the background; Wait waits for them.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) {
+	paused, err := s.store.PausedSchedules(ctx)
+	if err != nil {
+		// without knowing which schedules are paused, none is run
+		logger(ctx).Error("Failed to read paused schedules", "error", err)
+		return
+	}
+	for _, name := range s.order {
+		if !s.entries[name].Cron.Matches(now) {
+			continue
+		}
+		if paused[name] {
+			logger(ctx).Debug("Skipping paused schedule", "schedule", name)
+			continue
+		}
+		_, err := s.start(ctx, name, now, true)
+		switch {
+		case errors.Is(err, ErrLockHeld):
+			logger(ctx).Info("Scheduled run taken by another server", "schedule", name)
+		case err != nil:
+			logger(ctx).Warn("Scheduled run not started", "schedule", name, "error", err)
+		}
+	}
+}
+
+// Trigger starts a run of the schedule name now, whether or not it is paused, and
+// returns its run id. The run outlives ctx, e.g. the request that triggered it, and
+// is cancelled with the context the scheduler was started with instead.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (string, error) {
+	if _, ok := s.entries[name]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownSchedule, name)
+	}
+	s.mu.Lock()
+	runCtx := s.base
+	s.mu.Unlock()
+	if runCtx == nil {
+		runCtx = context.WithoutCancel(ctx)
+	}
+	return s.start(runCtx, name, time.Now(), false)
+}
+
+// Wait waits for the runs in progress to return.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// start records a new run of the schedule name and runs it in the background. due
+// reports whether the run is the one the schedule is due for at now, as opposed to a
+// run triggered by hand.
+func (s *Scheduler) start(ctx context.Context, name string, now time.Time, due bool) (string, error) {
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return "", fmt.Errorf("%w: the previous run of %s has not finished", ErrRunInProgress, name)
+	}
+	if !due && s.maxRuns > 0 && len(s.running) >= s.maxRuns {
+		s.mu.Unlock()
+		return "", fmt.Errorf("%w: %d of %d", ErrTooManyRuns, len(s.running), s.maxRuns)
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+
+	if err := s.lock(ctx, name, now, due); err != nil {
+		s.finish(name)
+		return "", err
+	}
+	run := store.ScheduledRun{RunId: uuid.NewString(), Schedule: name, StartedAt: now, Status: store.RunRunning}
+	if err := s.store.RecordScheduledRun(ctx, run); err != nil {
+		s.unlock(ctx, name)
+		s.finish(name)
+		return "", err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.finish(name)
+		defer s.unlock(ctx, name)
+
+		runCtx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if s.locker != nil {
+			go s.renew(runCtx, cancel, name)
+		}
+
+		logger(ctx).Info("Starting scheduled run", "schedule", name, "run_id", run.RunId)
+		drifted, err := s.run(runCtx, name, run.RunId)
+		if cause := context.Cause(runCtx); err != nil && errors.Is(cause, ErrLockHeld) {
+			err = fmt.Errorf("%w: %w", err, cause)
+		}
+		run.FinishedAt, run.Drifted, run.Status = time.Now(), drifted, store.RunOK
+		if err != nil {
+			run.Status, run.Error = store.RunFailed, err.Error()
+			logger(ctx).Error("Scheduled run failed", "schedule", name, "run_id", run.RunId, "error", err)
+		} else {
+			logger(ctx).Info("Scheduled run finished", "schedule", name, "run_id", run.RunId, "drifted", drifted)
+		}
+		// the outcome is recorded even when the run was cancelled by a shutdown
+		if err := s.store.RecordScheduledRun(context.WithoutCancel(ctx), run); err != nil {
+			logger(ctx).Error("Failed to record scheduled run", "schedule", name, "run_id", run.RunId, "error", err)
+		}
+	}()
+	return run.RunId, nil
+}
+
+// lock takes the locks of a run of the schedule name when the scheduler has a locker:
+// the lock of the schedule, held until the run returns, and for a due run the lock of
+// its occurrence, which is left to expire so that a server whose clock runs late does
+// not start the occurrence again after the run returned.
+func (s *Scheduler) lock(ctx context.Context, name string, now time.Time, due bool) error {
+	if s.locker == nil {
+		return nil
+	}
+	if due {
+		acquired, err := s.locker.Acquire(ctx, occurrenceLockKey(name, now), s.lockTTL)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			return fmt.Errorf("%w: the %s run of %s", ErrLockHeld, now.UTC().Format(time.RFC3339), name)
+		}
+	}
+	acquired, err := s.locker.Acquire(ctx, scheduleLockKey(name), s.lockTTL)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return fmt.Errorf("%w: %s is running on another server", ErrLockHeld, name)
+	}
+	return nil
+}
+
+// renew extends the lock of the schedule name until ctx is done, cancelling the run
+// when the lock is lost or could not be renewed before it expired.
+func (s *Scheduler) renew(ctx context.Context, cancel context.CancelCauseFunc, name string) {
+	ticker := time.NewTicker(s.lockTTL / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		acquired, err := s.locker.Acquire(ctx, scheduleLockKey(name), s.lockTTL)
+		switch {
+		case err == nil && acquired:
+			renewed = time.Now()
+		case err == nil:
+			logger(ctx).Error("Lock of scheduled run lost, cancelling the run", "schedule", name)
+			cancel(fmt.Errorf("%w: lock of %s lost", ErrLockHeld, name))
+			return
+		case time.Since(renewed) >= s.lockTTL:
+			logger(ctx).Error("Lock of scheduled run expired, cancelling the run", "schedule", name, "error", err)
+			cancel(fmt.Errorf("%w: lock of %s expired: %w", ErrLockHeld, name, err))
+			return
+		default:
+			logger(ctx).Warn("Failed to renew lock of scheduled run", "schedule", name, "error", err)
+		}
+	}
+}
+
+func (s *Scheduler) unlock(ctx context.Context, name string) {
+	if s.locker == nil {
+		return
+	}
+	if err := s.locker.Release(context.WithoutCancel(ctx), scheduleLockKey(name)); err != nil {
+		logger(ctx).Warn("Failed to release lock of scheduled run", "schedule", name, "error", err)
+	}
+}
+
+func scheduleLockKey(name string) string {
+	return "schedule/" + name
+}
+
+func occurrenceLockKey(name string, now time.Time) string {
+	return "schedule/" + name + "/" + now.UTC().Truncate(time.Minute).Format(time.RFC3339)
+}
+
+func (s *Scheduler) finish(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+// Pause stops the schedule name from starting new runs until it is resumed. A run in
+// progress is not interrupted.
+func (s *Scheduler) Pause(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, true)
+}
+
+// Resume lets a paused schedule start runs again.
+func (s *Scheduler) Resume(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, false)
+}
+
+func (s *Scheduler) setPaused(ctx context.Context, name string, paused bool) error {
+	if _, ok := s.entries[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSchedule, name)
+	}
+	return s.store.SetSchedulePaused(ctx, name, paused)
+}
+
+// Status returns the status of every schedule, in the order they were given, with
+// the next run after now.
+func (s *Scheduler) Status(ctx context.Context, now time.Time) ([]Status, error) {
+	paused, err := s.store.PausedSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(s.order))
+	for _, name := range s.order {
+		entry := s.entries[name]
+		status := Status{Name: name, Cron: entry.Cron.String(), Paused: paused[name], NextRun: entry.Cron.Next(now)}
+		s.mu.Lock()
+		status.Running = s.running[name]
+		s.mu.Unlock()
+		runs, err := s.store.ScheduledRuns(ctx, name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			status.LastRun = &runs[0]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Runs returns the runs of the schedule name, most recent first, at most limit of
+// them unless limit is zero.
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]store.ScheduledRun, error) {
+	if _, ok := s.entries[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchedule, name)
+	}
+	return s.store.ScheduledRuns(ctx, name, limit)
+}
+
+// logger returns the logger carried by ctx for the schedule module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "schedule")
+}
@@ -0,0 +1,239 @@
+package schedule_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/lock"
+	"drift-watcher/pkg/services/lock/lockfakes"
+	"drift-watcher/pkg/services/schedule"
+	"drift-watcher/pkg/services/store"
+	"drift-watcher/pkg/services/store/storefakes"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScheduler(t *testing.T, st store.ScheduleStore, run schedule.RunFunc) *schedule.Scheduler {
+	t.Helper()
+	return schedule.New([]schedule.Entry{{Name: "hourly", Cron: mustParseCron(t, "@hourly")}, {Name: "nightly", Cron: mustParseCron(t, "0 2 * * *")}}, st, run)
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	fakeStore.PausedSchedulesReturns(map[string]bool{}, nil)
+	var ran []string
+	scheduler := newScheduler(t, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		ran = append(ran, name)
+		return 3, nil
+	})
+
+	scheduler.RunDue(context.Background(), time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC))
+	scheduler.Wait()
+	assert.Empty(t, ran)
+
+	scheduler.RunDue(context.Background(), time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC))
+	scheduler.Wait()
+	assert.Equal(t, []string{"hourly"}, ran)
+
+	require.Equal(t, 2, fakeStore.RecordScheduledRunCallCount())
+	_, started := fakeStore.RecordScheduledRunArgsForCall(0)
+	_, finished := fakeStore.RecordScheduledRunArgsForCall(1)
+	assert.Equal(t, "hourly", started.Schedule)
+	assert.Equal(t, store.RunRunning, started.Status)
+	assert.Equal(t, started.RunId, finished.RunId)
+	assert.Equal(t, store.RunOK, finished.Status)
+	assert.Equal(t, 3, finished.Drifted)
+	assert.False(t, finished.FinishedAt.IsZero())
+}
+
+func TestScheduler_RunDue_SkipsPaused(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	fakeStore.PausedSchedulesReturns(map[string]bool{"hourly": true}, nil)
+	var ran []string
+	scheduler := newScheduler(t, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		ran = append(ran, name)
+		return 0, nil
+	})
+
+	scheduler.RunDue(context.Background(), time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC))
+	scheduler.Wait()
+	assert.Equal(t, []string{"nightly"}, ran)
+
+	// nothing is run when the paused schedules cannot be read
+	fakeStore.PausedSchedulesReturns(nil, errors.New("database is locked"))
+	scheduler.RunDue(context.Background(), time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC))
+	scheduler.Wait()
+	assert.Equal(t, []string{"nightly"}, ran)
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	release := make(chan struct{})
+	scheduler := newScheduler(t, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		<-release
+		return 0, errors.New("failed to assume role")
+	})
+
+	_, err := scheduler.Trigger(context.Background(), "weekly")
+	assert.ErrorIs(t, err, schedule.ErrUnknownSchedule)
+
+	runId, err := scheduler.Trigger(context.Background(), "hourly")
+	require.NoError(t, err)
+	assert.NotEmpty(t, runId)
+
+	_, err = scheduler.Trigger(context.Background(), "hourly")
+	assert.ErrorIs(t, err, schedule.ErrRunInProgress)
+
+	close(release)
+	scheduler.Wait()
+	require.Equal(t, 2, fakeStore.RecordScheduledRunCallCount())
+	_, finished := fakeStore.RecordScheduledRunArgsForCall(1)
+	assert.Equal(t, runId, finished.RunId)
+	assert.Equal(t, store.RunFailed, finished.Status)
+	assert.Equal(t, "failed to assume role", finished.Error)
+
+	// the schedule can be run again once the previous run finished
+	_, err = scheduler.Trigger(context.Background(), "hourly")
+	require.NoError(t, err)
+	scheduler.Wait()
+}
+
+func TestScheduler_WithMaxRuns(t *testing.T) {
+	fakeStore := &storefakes.FakeScheduleStore{}
+	fakeStore.PausedSchedulesReturns(map[string]bool{}, nil)
+	release := make(chan struct{})
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: mustParseCron(t, "@hourly")}, {Name: "nightly", Cron: mustParseCron(t, "0 2 * * *")}}, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		<-release
+		return 0, nil
+	}, schedule.WithMaxRuns(1))
+
+	_, err := scheduler.Trigger(context.Background(), "hourly")
+	require.NoError(t, err)
+	_, err = scheduler.Trigger(context.Background(), "nightly")
+	assert.ErrorIs(t, err, schedule.ErrTooManyRuns)
+	assert.EqualError(t, err, "too many runs in progress: 1 of 1")
+
+	// due runs are started regardless
+	scheduler.RunDue(context.Background(), time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC))
+	close(release)
+	scheduler.Wait()
+	assert.Equal(t, 4, fakeStore.RecordScheduledRunCallCount())
+
+	_, err = scheduler.Trigger(context.Background(), "nightly")
+	require.NoError(t, err)
+	scheduler.Wait()
+}
+
+func TestScheduler_PauseAndStatus(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewSQLStore(ctx, store.SQLiteDriver, filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	defer st.Close()
+	scheduler := newScheduler(t, st, func(ctx context.Context, name string, runId string) (int, error) {
+		return 1, nil
+	})
+
+	assert.ErrorIs(t, scheduler.Pause(ctx, "weekly"), schedule.ErrUnknownSchedule)
+	require.NoError(t, scheduler.Pause(ctx, "nightly"))
+	_, err = scheduler.Trigger(ctx, "hourly")
+	require.NoError(t, err)
+	scheduler.Wait()
+
+	now := time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC)
+	statuses, err := scheduler.Status(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "hourly", statuses[0].Name)
+	assert.False(t, statuses[0].Paused)
+	assert.Equal(t, time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), statuses[0].NextRun)
+	require.NotNil(t, statuses[0].LastRun)
+	assert.Equal(t, store.RunOK, statuses[0].LastRun.Status)
+	assert.Equal(t, "nightly", statuses[1].Name)
+	assert.True(t, statuses[1].Paused)
+	assert.Nil(t, statuses[1].LastRun)
+
+	require.NoError(t, scheduler.Resume(ctx, "nightly"))
+	runs, err := scheduler.Runs(ctx, "hourly", 0)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+	_, err = scheduler.Runs(ctx, "weekly", 0)
+	assert.ErrorIs(t, err, schedule.ErrUnknownSchedule)
+}
+
+func TestScheduler_WithLocker_RunsDueRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var ran []string
+	newServer := func(owner string) *schedule.Scheduler {
+		locker, err := lock.NewFileLocker(dir, owner)
+		require.NoError(t, err)
+		fakeStore := &storefakes.FakeScheduleStore{}
+		fakeStore.PausedSchedulesReturns(map[string]bool{}, nil)
+		return schedule.New([]schedule.Entry{{Name: "hourly", Cron: mustParseCron(t, "@hourly")}}, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, owner)
+			return 0, nil
+		}, schedule.WithLocker(locker, time.Minute))
+	}
+	first, second := newServer("server-1"), newServer("server-2")
+
+	due := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	first.RunDue(context.Background(), due)
+	first.Wait()
+	// the second server gets to the same occurrence after the first one finished it
+	second.RunDue(context.Background(), due.Add(20*time.Second))
+	second.Wait()
+	assert.Equal(t, []string{"server-1"}, ran)
+
+	// the next occurrence is run again, by whichever server gets to it first
+	second.RunDue(context.Background(), due.Add(time.Hour))
+	second.Wait()
+	assert.Equal(t, []string{"server-1", "server-2"}, ran)
+}
+
+func TestScheduler_WithLocker_Trigger(t *testing.T) {
+	locker := &lockfakes.FakeLocker{}
+	locker.AcquireReturns(false, nil)
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: mustParseCron(t, "@hourly")}}, &storefakes.FakeScheduleStore{}, nil, schedule.WithLocker(locker, time.Minute))
+
+	_, err := scheduler.Trigger(context.Background(), "hourly")
+	assert.ErrorIs(t, err, schedule.ErrLockHeld)
+	assert.ErrorContains(t, err, "hourly is running on another server")
+	require.Equal(t, 1, locker.AcquireCallCount())
+	_, key, _ := locker.AcquireArgsForCall(0)
+	assert.Equal(t, "schedule/hourly", key)
+}
+
+func TestScheduler_WithLocker_CancelsRunWhenLockLost(t *testing.T) {
+	locker := &lockfakes.FakeLocker{}
+	locker.AcquireReturns(true, nil)
+	locker.AcquireReturnsOnCall(1, false, nil)
+	fakeStore := &storefakes.FakeScheduleStore{}
+	scheduler := schedule.New([]schedule.Entry{{Name: "hourly", Cron: mustParseCron(t, "@hourly")}}, fakeStore, func(ctx context.Context, name string, runId string) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, schedule.WithLocker(locker, 30*time.Millisecond))
+
+	_, err := scheduler.Trigger(context.Background(), "hourly")
+	require.NoError(t, err)
+	scheduler.Wait()
+
+	_, finished := fakeStore.RecordScheduledRunArgsForCall(1)
+	assert.Equal(t, store.RunFailed, finished.Status)
+	assert.Contains(t, finished.Error, "lock of hourly lost")
+	require.Equal(t, 1, locker.ReleaseCallCount())
+	_, key := locker.ReleaseArgsForCall(0)
+	assert.Equal(t, "schedule/hourly", key)
+}
+
+func mustParseCron(t *testing.T, expr string) *schedule.Cron {
+	t.Helper()
+	cron, err := schedule.ParseCron(expr)
+	require.NoError(t, err)
+	return cron
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// SecretsManagerScheme is the scheme of references to AWS Secrets Manager secrets.
+const SecretsManagerScheme = "aws-sm"
+
+// SecretsManagerAPI is the subset of the AWS Secrets Manager client used to resolve
+// references.
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManager resolves aws-sm://<secret-id>[#<key>] references to the current
+// value of a Secrets Manager secret, where the secret id is its name or ARN. With a
+// key, the secret is a JSON object, as written by the console for key/value secrets,
+// and the value of that key is returned.
+type SecretsManager struct {
+	newClient func(ctx context.Context) (SecretsManagerAPI, error)
+
+	once      sync.Once
+	client    SecretsManagerAPI
+	clientErr error
+}
+
+// NewSecretsManager creates a provider calling Secrets Manager with the client
+// newClient returns. The client is only created once a reference is resolved, so
+// configurations without aws-sm:// references need no AWS credentials.
+func NewSecretsManager(newClient func(ctx context.Context) (SecretsManagerAPI, error)) *SecretsManager {
+	return &SecretsManager{newClient: newClient}
+}
+
+func (s *SecretsManager) Lookup(ctx context.Context, ref string) (string, error) {
+	id, key, hasKey := strings.Cut(ref, "#")
+	s.once.Do(func() {
+		s.client, s.clientErr = s.newClient(ctx)
+	})
+	if s.clientErr != nil {
+		return "", fmt.Errorf("failed to create Secrets Manager client: %w", s.clientErr)
+	}
+
+	output, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", err
+	}
+	value := aws.ToString(output.SecretString)
+	if output.SecretString == nil {
+		value = string(output.SecretBinary)
+	}
+	if !hasKey {
+		return value, nil
+	}
+	return SelectKey(id, value, key)
+}
+
+// SelectKey returns the value of key in secret, the value of the secret id holding a
+// JSON object, as written by the console for key/value secrets.
+func SelectKey(id, secret, key string) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, cannot select key %s", id, key)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", id, key)
+	}
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(field), nil
+}
@@ -0,0 +1,117 @@
+// Package secrets resolves references to secrets held outside of the configuration,
+// such as env://SLACK_WEBHOOK_URL or aws-sm://prod/driftwatcher#pagerduty_key, so
+// that webhook URLs, tokens and passwords never have to be written in plaintext to a
+// config file, project file or command line.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider looks up the secret a reference of its scheme points to. ref is the
+// reference without the scheme, e.g. SLACK_WEBHOOK_URL for env://SLACK_WEBHOOK_URL.
+type Provider interface {
+	Lookup(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ProviderFunc) Lookup(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Resolver resolves the references of the schemes registered with it. Values without
+// a registered scheme are plain values and returned unchanged. Resolved secrets are
+// kept for the lifetime of the Resolver, so a secret referenced by several settings is
+// looked up once.
+type Resolver struct {
+	mu        sync.Mutex
+	providers map[string]Provider
+	resolved  map[string]string
+}
+
+// NewResolver creates a resolver of the env:// and file:// schemes. Schemes backed by
+// a secret store, such as aws-sm://, are added with Register.
+func NewResolver() *Resolver {
+	r := &Resolver{providers: map[string]Provider{}, resolved: map[string]string{}}
+	r.Register("env", ProviderFunc(lookupEnv))
+	r.Register("file", ProviderFunc(lookupFile))
+	return r
+}
+
+// Register resolves the references of scheme with provider, replacing the provider
+// registered for it before.
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = provider
+}
+
+// IsReference reports whether value is a reference of a registered scheme.
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.provider(value)
+	return ok
+}
+
+// Resolve returns the secret value refers to, or value itself when it is not a
+// reference. Errors name the reference but never the secret.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	provider, ref, ok := r.provider(value)
+	if !ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	secret, found := r.resolved[value]
+	r.mu.Unlock()
+	if found {
+		return secret, nil
+	}
+
+	secret, err := provider.Lookup(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", value, err)
+	}
+	r.mu.Lock()
+	r.resolved[value] = secret
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// provider returns the provider of the scheme of value and the reference without the
+// scheme.
+func (r *Resolver) provider(value string) (Provider, string, bool) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok || ref == "" {
+		return nil, "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider, ok := r.providers[scheme]
+	return provider, ref, ok
+}
+
+// lookupEnv resolves env://NAME to the value of the environment variable NAME. An
+// unset variable is an error, a variable set to the empty string is not.
+func lookupEnv(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// lookupFile resolves file://PATH to the content of the file at PATH, without the
+// trailing newline editors and `echo` add, e.g. a Docker or Kubernetes secret mount.
+func lookupFile(ctx context.Context, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
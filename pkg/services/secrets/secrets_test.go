@@ -0,0 +1,106 @@
+package secrets_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/secrets"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("DRIFT_TEST_WEBHOOK", "https://hooks.slack.com/services/T000/B000/XXX")
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("s3cr3t\n"), 0600))
+	r := secrets.NewResolver()
+
+	for value, expected := range map[string]string{
+		"env://DRIFT_TEST_WEBHOOK":  "https://hooks.slack.com/services/T000/B000/XXX",
+		"file://" + path:            "s3cr3t",
+		"https://hooks.example.com": "https://hooks.example.com",
+		"plain-token":               "plain-token",
+		"env://":                    "env://",
+		"":                          "",
+	} {
+		resolved, err := r.Resolve(ctx, value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, resolved, value)
+	}
+	assert.True(t, r.IsReference("env://DRIFT_TEST_WEBHOOK"))
+	assert.False(t, r.IsReference("postgres://drift@db/drift"), "unregistered schemes are plain values")
+
+	_, err := r.Resolve(ctx, "env://DRIFT_TEST_UNSET")
+	assert.EqualError(t, err, "failed to resolve secret env://DRIFT_TEST_UNSET: environment variable DRIFT_TEST_UNSET is not set")
+	_, err = r.Resolve(ctx, "file://"+filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestResolver_ResolveOnce(t *testing.T) {
+	lookups := 0
+	r := secrets.NewResolver()
+	r.Register("vault", secrets.ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		lookups++
+		return "value of " + ref, nil
+	}))
+
+	for range 2 {
+		resolved, err := r.Resolve(context.Background(), "vault://kv/driftwatcher")
+		require.NoError(t, err)
+		assert.Equal(t, "value of kv/driftwatcher", resolved)
+	}
+	assert.Equal(t, 1, lookups)
+}
+
+type fakeSecretsManager map[string]string
+
+func (f fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func TestSecretsManager_Lookup(t *testing.T) {
+	ctx := context.Background()
+	clients := 0
+	r := secrets.NewResolver()
+	r.Register(secrets.SecretsManagerScheme, secrets.NewSecretsManager(func(ctx context.Context) (secrets.SecretsManagerAPI, error) {
+		clients++
+		return fakeSecretsManager{
+			"prod/slack-webhook": "https://hooks.slack.com/services/T000/B000/XXX",
+			"prod/driftwatcher":  `{"pagerduty_key": "R0UT1NG", "port": 5432}`,
+		}, nil
+	}))
+
+	resolved, err := r.Resolve(ctx, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", resolved)
+	assert.Zero(t, clients, "no client is created until a reference is resolved")
+
+	resolved, err = r.Resolve(ctx, "aws-sm://prod/slack-webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/services/T000/B000/XXX", resolved)
+
+	resolved, err = r.Resolve(ctx, "aws-sm://prod/driftwatcher#pagerduty_key")
+	require.NoError(t, err)
+	assert.Equal(t, "R0UT1NG", resolved)
+	resolved, err = r.Resolve(ctx, "aws-sm://prod/driftwatcher#port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", resolved)
+	assert.Equal(t, 1, clients)
+
+	_, err = r.Resolve(ctx, "aws-sm://prod/driftwatcher#opsgenie_key")
+	assert.EqualError(t, err, "failed to resolve secret aws-sm://prod/driftwatcher#opsgenie_key: secret prod/driftwatcher has no key opsgenie_key")
+	_, err = r.Resolve(ctx, "aws-sm://prod/slack-webhook#url")
+	assert.ErrorContains(t, err, "secret prod/slack-webhook is not a JSON object")
+	_, err = r.Resolve(ctx, "aws-sm://prod/missing")
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
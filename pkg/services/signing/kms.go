@@ -0,0 +1,121 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// kmsAlgorithmPrefix marks the algorithm of a signature made with AWS KMS.
+const kmsAlgorithmPrefix = "kms:"
+
+// DefaultKMSAlgorithm is the KMS signing algorithm used when none is configured. It
+// requires an ECC_NIST_P256 signing key.
+const DefaultKMSAlgorithm = string(types.SigningAlgorithmSpecEcdsaSha256)
+
+// KMSAPI is the subset of the AWS KMS client used to sign and verify reports.
+type KMSAPI interface {
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+	Verify(ctx context.Context, params *kms.VerifyInput, optFns ...func(*kms.Options)) (*kms.VerifyOutput, error)
+}
+
+// KMSSigner signs reports with an asymmetric AWS KMS key, so the private key never
+// leaves KMS.
+type KMSSigner struct {
+	client    KMSAPI
+	keyID     string
+	algorithm types.SigningAlgorithmSpec
+}
+
+// NewKMSSigner creates a signer using the KMS key keyID, which may be a key id, key
+// ARN, alias name or alias ARN. algorithm is a KMS signing algorithm with a SHA-256
+// digest, such as ECDSA_SHA_256 or RSASSA_PSS_SHA_256; DefaultKMSAlgorithm is used
+// when it is empty.
+func NewKMSSigner(client KMSAPI, keyID string, algorithm string) (*KMSSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("a KMS key id is required")
+	}
+	if algorithm == "" {
+		algorithm = DefaultKMSAlgorithm
+	}
+	spec, err := kmsAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &KMSSigner{client: client, keyID: keyID, algorithm: spec}, nil
+}
+
+func (s *KMSSigner) Algorithm() string { return kmsAlgorithmPrefix + string(s.algorithm) }
+
+func (s *KMSSigner) KeyID() string { return s.keyID }
+
+func (s *KMSSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	output, err := s.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: s.algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms sign: %w", err)
+	}
+	return output.Signature, nil
+}
+
+// KMSVerifier verifies signatures made with AWS KMS by calling KMS, using the key
+// and algorithm recorded in the signature envelope.
+type KMSVerifier struct {
+	client KMSAPI
+}
+
+// NewKMSVerifier creates a verifier calling KMS with client.
+func NewKMSVerifier(client KMSAPI) *KMSVerifier {
+	return &KMSVerifier{client: client}
+}
+
+func (v *KMSVerifier) Verify(ctx context.Context, envelope *Envelope, digest []byte) error {
+	algorithm, ok := strings.CutPrefix(envelope.Algorithm, kmsAlgorithmPrefix)
+	if !ok {
+		return fmt.Errorf("%s signature was not made with KMS", envelope.Algorithm)
+	}
+	spec, err := kmsAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+
+	output, err := v.client.Verify(ctx, &kms.VerifyInput{
+		KeyId:            aws.String(envelope.KeyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		Signature:        envelope.Signature,
+		SigningAlgorithm: spec,
+	})
+	var invalid *types.KMSInvalidSignatureException
+	if errors.As(err, &invalid) {
+		return ErrInvalidSignature
+	}
+	if err != nil {
+		return fmt.Errorf("kms verify: %w", err)
+	}
+	if !output.SignatureValid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// kmsAlgorithm validates a KMS signing algorithm. Only algorithms over a SHA-256
+// digest are accepted since the report digest is SHA-256.
+func kmsAlgorithm(algorithm string) (types.SigningAlgorithmSpec, error) {
+	spec := types.SigningAlgorithmSpec(algorithm)
+	for _, known := range spec.Values() {
+		if known == spec && strings.HasSuffix(algorithm, "_SHA_256") {
+			return spec, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported KMS signing algorithm %q, expected one using SHA-256 such as %s", algorithm, DefaultKMSAlgorithm)
+}
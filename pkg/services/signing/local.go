@@ -0,0 +1,168 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// Local signature algorithms.
+const (
+	AlgorithmEd25519   = "ed25519"
+	AlgorithmECDSAP256 = "ecdsa-p256-sha256"
+)
+
+// LocalSigner signs reports with a private key read from a PEM file.
+type LocalSigner struct {
+	algorithm string
+	keyID     string
+	key       crypto.Signer
+}
+
+// NewLocalSigner creates a signer from a PKCS #8 PEM private key. Ed25519 and ECDSA
+// P-256 keys are supported, e.g. as generated with
+// `openssl genpkey -algorithm ed25519 -out report-signing.pem`.
+func NewLocalSigner(keyPath string) (*LocalSigner, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", keyPath, err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type %T", keyPath, parsed)
+	}
+	algorithm, err := keyAlgorithm(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+	keyID, err := fingerprint(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{algorithm: algorithm, keyID: keyID, key: key}, nil
+}
+
+func (s *LocalSigner) Algorithm() string { return s.algorithm }
+
+func (s *LocalSigner) KeyID() string { return s.keyID }
+
+func (s *LocalSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	switch key := s.key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, digest), nil
+	case *ecdsa.PrivateKey:
+		return ecdsa.SignASN1(rand.Reader, key, digest)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", s.key)
+	}
+}
+
+// LocalVerifier verifies signatures with a public key read from a PEM file.
+type LocalVerifier struct {
+	algorithm string
+	keyID     string
+	key       crypto.PublicKey
+}
+
+// NewLocalVerifier creates a verifier from a PKIX PEM public key, or from the PKCS #8
+// private key the reports were signed with.
+func NewLocalVerifier(keyPath string) (*LocalVerifier, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.PublicKey
+	if block.Type == "PUBLIC KEY" {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", keyPath, err)
+		}
+	} else {
+		signer, err := NewLocalSigner(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		key = signer.key.Public()
+	}
+
+	algorithm, err := keyAlgorithm(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+	keyID, err := fingerprint(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalVerifier{algorithm: algorithm, keyID: keyID, key: key}, nil
+}
+
+func (v *LocalVerifier) Verify(ctx context.Context, envelope *Envelope, digest []byte) error {
+	if envelope.Algorithm != v.algorithm {
+		return fmt.Errorf("%w: signed with %s, key is %s", ErrInvalidSignature, envelope.Algorithm, v.algorithm)
+	}
+	if envelope.KeyID != "" && envelope.KeyID != v.keyID {
+		return fmt.Errorf("%w: signed with key %s, not %s", ErrInvalidSignature, envelope.KeyID, v.keyID)
+	}
+
+	var valid bool
+	switch key := v.key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, digest, envelope.Signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest, envelope.Signature)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// keyAlgorithm returns the signature algorithm used with a public key.
+func keyAlgorithm(key crypto.PublicKey) (string, error) {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return AlgorithmEd25519, nil
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().Name != "P-256" {
+			return "", fmt.Errorf("unsupported ECDSA curve %s, expected P-256", key.Curve.Params().Name)
+		}
+		return AlgorithmECDSAP256, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %T, expected an Ed25519 or ECDSA P-256 key", key)
+	}
+}
+
+// fingerprint identifies a public key by the SHA-256 hash of its PKIX encoding.
+func fingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func readPEM(keyPath string) (*pem.Block, error) {
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", keyPath)
+	}
+	return block, nil
+}
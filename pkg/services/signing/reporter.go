@@ -0,0 +1,57 @@
+package signing
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/reporter"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// Reporter passes reports on to a file writer and signs the file once the writer
+// has been flushed, so the signature covers the complete report of a run.
+type Reporter struct {
+	Next   reporter.OutputWriter
+	File   string
+	Signer Signer
+}
+
+// NewReporter creates a Reporter signing file, the output file of next, with signer.
+func NewReporter(next reporter.OutputWriter, file string, signer Signer) *Reporter {
+	return &Reporter{Next: next, File: file, Signer: signer}
+}
+
+// Begin begins the run of the next writer.
+func (r *Reporter) Begin(ctx context.Context, run *driftchecker.RunMetadata) error {
+	return reporter.BeginWriter(ctx, r.Next, run)
+}
+
+func (r *Reporter) WriteReport(ctx context.Context, report *driftchecker.DriftReport) error {
+	return r.Next.WriteReport(ctx, report)
+}
+
+// Flush flushes the next writer and signs the file it wrote. Nothing is signed when
+// no report was written.
+func (r *Reporter) Flush(ctx context.Context) error {
+	if err := reporter.FlushWriter(ctx, r.Next); err != nil {
+		return err
+	}
+	if _, err := os.Stat(r.File); errors.Is(err, fs.ErrNotExist) {
+		logger(ctx).Debug("No report file to sign", "path", r.File)
+		return nil
+	}
+	return SignFile(ctx, r.Signer, r.File)
+}
+
+// Close closes the next writer.
+func (r *Reporter) Close() error {
+	return reporter.CloseWriter(r.Next)
+}
+
+// logger returns the logger carried by ctx for the signing module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "signing")
+}
@@ -0,0 +1,128 @@
+// Package signing signs report files and verifies their signatures, so that drift
+// evidence kept for compliance audits can be shown to be unmodified. A signature is
+// written next to the report as a detached JSON envelope holding the SHA-256 digest
+// of the file and the signature over that digest.
+package signing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SignatureExt is appended to the path of a report file to name its signature.
+const SignatureExt = ".sig"
+
+// envelopeVersion is the version of the signature envelope format.
+const envelopeVersion = 1
+
+// ErrInvalidSignature is returned when a signature does not match the report.
+var ErrInvalidSignature = errors.New("signature verification failed")
+
+// Signer signs the SHA-256 digest of a report file.
+type Signer interface {
+	// Algorithm names the signature algorithm, e.g. ed25519 or kms:ECDSA_SHA_256.
+	Algorithm() string
+	// KeyID identifies the key the signature is made with.
+	KeyID() string
+	// Sign returns the signature of digest.
+	Sign(ctx context.Context, digest []byte) ([]byte, error)
+}
+
+// Verifier checks the signature of the SHA-256 digest of a report file.
+type Verifier interface {
+	// Verify returns ErrInvalidSignature when signature is not a valid signature of
+	// digest made with the key and algorithm recorded in the envelope.
+	Verify(ctx context.Context, envelope *Envelope, digest []byte) error
+}
+
+// Envelope is the detached signature of a report file.
+type Envelope struct {
+	Version   int       `json:"version"`
+	File      string    `json:"file"`
+	Algorithm string    `json:"algorithm"`
+	KeyID     string    `json:"key_id,omitempty"`
+	Digest    string    `json:"digest"`
+	Signature []byte    `json:"signature"`
+	SignedAt  time.Time `json:"signed_at"`
+}
+
+// SignaturePath returns the path the signature of the report at filePath is
+// written to.
+func SignaturePath(filePath string) string {
+	return filePath + SignatureExt
+}
+
+// SignFile signs the report at filePath and writes the signature envelope to
+// SignaturePath(filePath), replacing an earlier signature.
+func SignFile(ctx context.Context, signer Signer, filePath string) error {
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(ctx, digest)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", filePath, err)
+	}
+
+	envelope := Envelope{
+		Version:   envelopeVersion,
+		File:      filePath,
+		Algorithm: signer.Algorithm(),
+		KeyID:     signer.KeyID(),
+		Digest:    "sha256:" + hex.EncodeToString(digest),
+		Signature: signature,
+		SignedAt:  time.Now().UTC(),
+	}
+	envelopeBytes, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal signature: %w", err)
+	}
+	if err := os.WriteFile(SignaturePath(filePath), envelopeBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write signature of %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// VerifyFile checks the report at filePath against the signature envelope at
+// signaturePath. It returns the envelope when the report is unmodified and an
+// error wrapping ErrInvalidSignature when the report or signature was tampered with.
+func VerifyFile(ctx context.Context, verifier Verifier, filePath string, signaturePath string) (*Envelope, error) {
+	envelopeBytes, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	envelope := &Envelope{}
+	if err := json.Unmarshal(envelopeBytes, envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse signature %s: %w", signaturePath, err)
+	}
+	if envelope.Version != envelopeVersion {
+		return nil, fmt.Errorf("unsupported signature version %d", envelope.Version)
+	}
+
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if envelope.Digest != "sha256:"+hex.EncodeToString(digest) {
+		return nil, fmt.Errorf("%w: %s does not match the signed digest", ErrInvalidSignature, filePath)
+	}
+	if err := verifier.Verify(ctx, envelope, digest); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+func fileDigest(filePath string) ([]byte, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return sum[:], nil
+}
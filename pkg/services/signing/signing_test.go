@@ -0,0 +1,211 @@
+package signing_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"drift-watcher/pkg/services/reporter"
+	"drift-watcher/pkg/services/signing"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeys writes key as a PKCS #8 private key and its PKIX public key, returning
+// both paths.
+func writeKeys(t *testing.T, key any, public any) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, "signing.pem")
+	publicPath := filepath.Join(dir, "signing.pub.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+	return privatePath, publicPath
+}
+
+func writeReport(t *testing.T) string {
+	t.Helper()
+	reportPath := filepath.Join(t.TempDir(), "drift.json")
+	require.NoError(t, os.WriteFile(reportPath, []byte(`{"resource_id":"i-1","has_drift":true}`), 0644))
+	return reportPath
+}
+
+func TestLocalSigner_RoundTrip(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		key       any
+		public    any
+		algorithm string
+	}{
+		{"ed25519", private, public, signing.AlgorithmEd25519},
+		{"ecdsa", ecKey, &ecKey.PublicKey, signing.AlgorithmECDSAP256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privatePath, publicPath := writeKeys(t, tt.key, tt.public)
+			reportPath := writeReport(t)
+
+			signer, err := signing.NewLocalSigner(privatePath)
+			require.NoError(t, err)
+			require.NoError(t, signing.SignFile(context.Background(), signer, reportPath))
+
+			verifier, err := signing.NewLocalVerifier(publicPath)
+			require.NoError(t, err)
+			envelope, err := signing.VerifyFile(context.Background(), verifier, reportPath, signing.SignaturePath(reportPath))
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, envelope.Algorithm)
+			assert.Equal(t, signer.KeyID(), envelope.KeyID)
+			assert.Equal(t, reportPath, envelope.File)
+
+			// the private key verifies its own signatures
+			verifier, err = signing.NewLocalVerifier(privatePath)
+			require.NoError(t, err)
+			_, err = signing.VerifyFile(context.Background(), verifier, reportPath, signing.SignaturePath(reportPath))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifyFile_Tampered(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, publicPath := writeKeys(t, private, public)
+	reportPath := writeReport(t)
+
+	signer, err := signing.NewLocalSigner(privatePath)
+	require.NoError(t, err)
+	require.NoError(t, signing.SignFile(context.Background(), signer, reportPath))
+	verifier, err := signing.NewLocalVerifier(publicPath)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(reportPath, []byte(`{"resource_id":"i-1","has_drift":false}`), 0644))
+	_, err = signing.VerifyFile(context.Background(), verifier, reportPath, signing.SignaturePath(reportPath))
+	require.ErrorIs(t, err, signing.ErrInvalidSignature)
+	assert.Contains(t, err.Error(), "does not match the signed digest")
+}
+
+func TestVerifyFile_WrongKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPublic, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKeys(t, private, private.Public())
+	_, otherPublicPath := writeKeys(t, otherPrivate, otherPublic)
+	reportPath := writeReport(t)
+
+	signer, err := signing.NewLocalSigner(privatePath)
+	require.NoError(t, err)
+	require.NoError(t, signing.SignFile(context.Background(), signer, reportPath))
+
+	verifier, err := signing.NewLocalVerifier(otherPublicPath)
+	require.NoError(t, err)
+	_, err = signing.VerifyFile(context.Background(), verifier, reportPath, signing.SignaturePath(reportPath))
+	require.ErrorIs(t, err, signing.ErrInvalidSignature)
+}
+
+func TestNewLocalSigner_Invalid(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyPath, []byte("not a key"), 0600))
+	_, err := signing.NewLocalSigner(keyPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a PEM encoded key")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	privatePath, _ := writeKeys(t, ecKey, &ecKey.PublicKey)
+	_, err = signing.NewLocalSigner(privatePath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported ECDSA curve P-384")
+}
+
+// fakeKMS signs with a local ECDSA key the way KMS signs a digest.
+type fakeKMS struct {
+	key *ecdsa.PrivateKey
+}
+
+func (f *fakeKMS) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	signature, err := ecdsa.SignASN1(rand.Reader, f.key, params.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: params.KeyId, Signature: signature, SigningAlgorithm: params.SigningAlgorithm}, nil
+}
+
+func (f *fakeKMS) Verify(ctx context.Context, params *kms.VerifyInput, optFns ...func(*kms.Options)) (*kms.VerifyOutput, error) {
+	if !ecdsa.VerifyASN1(&f.key.PublicKey, params.Message, params.Signature) {
+		return nil, &types.KMSInvalidSignatureException{}
+	}
+	return &kms.VerifyOutput{SignatureValid: true}, nil
+}
+
+func TestKMSSigner_RoundTrip(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	client := &fakeKMS{key: key}
+	reportPath := writeReport(t)
+
+	signer, err := signing.NewKMSSigner(client, "alias/drift-reports", "")
+	require.NoError(t, err)
+	require.NoError(t, signing.SignFile(context.Background(), signer, reportPath))
+
+	envelope, err := signing.VerifyFile(context.Background(), signing.NewKMSVerifier(client), reportPath, signing.SignaturePath(reportPath))
+	require.NoError(t, err)
+	assert.Equal(t, "kms:ECDSA_SHA_256", envelope.Algorithm)
+	assert.Equal(t, "alias/drift-reports", envelope.KeyID)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = signing.VerifyFile(context.Background(), signing.NewKMSVerifier(&fakeKMS{key: other}), reportPath, signing.SignaturePath(reportPath))
+	require.ErrorIs(t, err, signing.ErrInvalidSignature)
+}
+
+func TestNewKMSSigner_Invalid(t *testing.T) {
+	_, err := signing.NewKMSSigner(&fakeKMS{}, "", "")
+	require.Error(t, err)
+
+	_, err = signing.NewKMSSigner(&fakeKMS{}, "alias/drift-reports", "ECDSA_SHA_384")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported KMS signing algorithm")
+}
+
+func TestReporter_SignsOnFlush(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privatePath, publicPath := writeKeys(t, private, public)
+	signer, err := signing.NewLocalSigner(privatePath)
+	require.NoError(t, err)
+
+	reportPath := filepath.Join(t.TempDir(), "drift.json")
+	signed := signing.NewReporter(reporter.NewFileReporter(reportPath), reportPath, signer)
+
+	// a run without reports writes an empty array, which is signed as well
+	require.NoError(t, signed.Flush(context.Background()))
+	assert.FileExists(t, signing.SignaturePath(reportPath))
+
+	require.NoError(t, signed.WriteReport(context.Background(), reporter.CreateDummyDriftReport(true)))
+	require.NoError(t, signed.Flush(context.Background()))
+
+	verifier, err := signing.NewLocalVerifier(publicPath)
+	require.NoError(t, err)
+	_, err = signing.VerifyFile(context.Background(), verifier, reportPath, signing.SignaturePath(reportPath))
+	require.NoError(t, err)
+}
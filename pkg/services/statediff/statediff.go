@@ -0,0 +1,220 @@
+// Package statediff compares two versions of a state, e.g. the state before and
+// after an apply, reporting the resources added and removed and the attributes
+// changed. Attributes are compared with the drift checker, the old state standing
+// in for the desired state and the new one for the live infrastructure, so values
+// are compared the same way drift is. A state can also be compared with the Terraform
+// configuration it was applied from, to find edits of the code that were never
+// applied.
+package statediff
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Changes of a resource between two states.
+const (
+	Added   = "ADDED"
+	Removed = "REMOVED"
+	Changed = "CHANGED"
+)
+
+// AttributeChange is an attribute whose value differs between the states. Old or New
+// is empty when the attribute is only set in the other state.
+type AttributeChange struct {
+	Attribute string `json:"attribute"`
+	Old       any    `json:"old"`
+	New       any    `json:"new"`
+}
+
+// ResourceChange is a resource instance added, removed or changed between the states.
+type ResourceChange struct {
+	Address    string            `json:"address"`
+	Type       string            `json:"type"`
+	Change     string            `json:"change"`
+	Attributes []AttributeChange `json:"attributes,omitempty"`
+}
+
+// Diff is the difference between two states. Resources are sorted by address and
+// unchanged resources are left out.
+type Diff struct {
+	Resources []ResourceChange `json:"resources"`
+	Added     int              `json:"added"`
+	Removed   int              `json:"removed"`
+	Changed   int              `json:"changed"`
+}
+
+// Compare returns the difference between the old and new states. Resources are matched
+// by the address of their instances. Values of sensitive attributes, those marked in
+// either state or matching the patterns of redactor, are redacted; a nil redactor only
+// masks the attributes marked in the states.
+func Compare(ctx context.Context, checker driftchecker.DriftChecker, old, new statemanager.StateContent, redactor *redact.Redactor) (*Diff, error) {
+	return compare(ctx, checker, byAddress(old.Resource), byAddress(new.Resource), redactor, false)
+}
+
+// CompareConfig returns the changes applying config, the resources of a Terraform
+// configuration as returned by terraform.LoadConfigResources, would make to state:
+// the resources declared but not in the state are Added, those in the state but no
+// longer declared are Removed, and those whose arguments were edited are Changed.
+// This is drift between the code and the state, such as an edit that was never
+// applied, as opposed to drift between the state and the infrastructure.
+//
+// Only the arguments set in the configuration are compared, as the state also records
+// the attributes computed by the provider. Data sources and the resources of child
+// modules in the state are left out, as are the instances of a configured resource
+// whose count or for_each could not be evaluated.
+func CompareConfig(ctx context.Context, checker driftchecker.DriftChecker, config []statemanager.StateResource, state statemanager.StateContent, redactor *redact.Redactor) (*Diff, error) {
+	unexpanded := map[string]bool{}
+	for _, resource := range config {
+		if len(resource.Instances) == 0 {
+			unexpanded[statemanager.ConfigAddress(resource.Address())] = true
+		}
+	}
+	var managed []statemanager.StateResource
+	for _, resource := range state.Resource {
+		if resource.Mode == "managed" && resource.Module == "" && !unexpanded[statemanager.ConfigAddress(resource.Address())] {
+			managed = append(managed, resource)
+		}
+	}
+	return compare(ctx, checker, byAddress(managed), byAddress(config), redactor, true)
+}
+
+// compare returns the difference between the old and new resource instances, keyed by
+// address. With newOnly set, only the attributes of the new instances are compared.
+func compare(ctx context.Context, checker driftchecker.DriftChecker, oldResources, newResources map[string]statemanager.StateResource, redactor *redact.Redactor, newOnly bool) (*Diff, error) {
+	if redactor == nil {
+		redactor = &redact.Redactor{Mode: redact.ModeMask}
+	}
+	addresses := slices.Sorted(maps.Keys(oldResources))
+	for address := range newResources {
+		if _, ok := oldResources[address]; !ok {
+			addresses = append(addresses, address)
+		}
+	}
+	slices.Sort(addresses)
+
+	diff := &Diff{Resources: []ResourceChange{}}
+	for _, address := range addresses {
+		before, inOld := oldResources[address]
+		after, inNew := newResources[address]
+		switch {
+		case !inOld:
+			diff.Resources = append(diff.Resources, ResourceChange{Address: address, Type: after.Type, Change: Added})
+			diff.Added++
+		case !inNew:
+			diff.Resources = append(diff.Resources, ResourceChange{Address: address, Type: before.Type, Change: Removed})
+			diff.Removed++
+		default:
+			attributes, err := compareResource(ctx, checker, before, after, redactor, newOnly)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare %s: %w", address, err)
+			}
+			if len(attributes) == 0 {
+				continue
+			}
+			diff.Resources = append(diff.Resources, ResourceChange{Address: address, Type: after.Type, Change: Changed, Attributes: attributes})
+			diff.Changed++
+		}
+	}
+	return diff, nil
+}
+
+// compareResource returns the attributes of a resource instance that changed from
+// before to after. With newOnly set, the attributes only set before are not compared.
+func compareResource(ctx context.Context, checker driftchecker.DriftChecker, before, after statemanager.StateResource, redactor *redact.Redactor, newOnly bool) ([]AttributeChange, error) {
+	oldAttributes := flatten(before.Instances[0].Attributes)
+	newAttributes := flatten(after.Instances[0].Attributes)
+	tracked := slices.Sorted(maps.Keys(newAttributes))
+	if !newOnly {
+		for name := range oldAttributes {
+			if _, ok := newAttributes[name]; !ok {
+				tracked = append(tracked, name)
+			}
+		}
+		slices.Sort(tracked)
+	}
+
+	desired := before
+	desired.Instances = []statemanager.ResourceInstance{{Attributes: oldAttributes, IndexKey: before.IndexKey()}}
+	live := &instance{resource: after}
+	live.resource.Instances = []statemanager.ResourceInstance{{Attributes: newAttributes, IndexKey: after.IndexKey()}}
+
+	report, err := checker.CompareStates(ctx, live, desired, tracked)
+	if err != nil {
+		return nil, err
+	}
+	redactor.RedactReport(report, append(before.SensitiveAttributes(), after.SensitiveAttributes()...))
+
+	var changes []AttributeChange
+	for _, item := range report.DriftDetails {
+		if item.DriftType == driftchecker.Match {
+			continue
+		}
+		changes = append(changes, AttributeChange{Attribute: item.Field, Old: item.TerraformValue, New: item.ActualValue})
+	}
+	return changes, nil
+}
+
+// byAddress indexes the instances of resources by their address.
+func byAddress(resources []statemanager.StateResource) map[string]statemanager.StateResource {
+	indexed := map[string]statemanager.StateResource{}
+	for _, resource := range statemanager.ExpandInstances(resources) {
+		if len(resource.Instances) == 0 {
+			continue
+		}
+		indexed[resource.Address()] = resource
+	}
+	return indexed
+}
+
+// flatten returns attributes keyed by the names the drift checker compares: nested
+// maps such as tags are expanded to tags.KEY, lists are kept whole and null values
+// are left out, so an attribute set to null is the same as an unset one.
+func flatten(attributes map[string]any) map[string]any {
+	flat := map[string]any{}
+	var walk func(prefix string, attributes map[string]any)
+	walk = func(prefix string, attributes map[string]any) {
+		for key, value := range attributes {
+			switch v := value.(type) {
+			case nil:
+			case map[string]any:
+				walk(prefix+key+".", v)
+			default:
+				flat[prefix+key] = v
+			}
+		}
+	}
+	walk("", attributes)
+	return flat
+}
+
+// instance is a resource instance of the new state, standing in for the live
+// resource of the drift checker.
+type instance struct {
+	resource statemanager.StateResource
+}
+
+func (i *instance) ResourceType() string {
+	return i.resource.ResourceType()
+}
+
+func (i *instance) AttributeValue(attribute string) (string, error) {
+	return i.resource.AttributeValue(attribute)
+}
+
+func (i *instance) Attributes() (map[string]string, error) {
+	values := map[string]string{}
+	for name := range i.resource.Instances[0].Attributes {
+		value, err := i.resource.AttributeValue(name)
+		if err != nil {
+			return values, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,149 @@
+package statediff_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/redact"
+	"drift-watcher/pkg/services/statediff"
+	"drift-watcher/pkg/services/statemanager"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resource(name string, attributes map[string]any, sensitive ...string) statemanager.StateResource {
+	return statemanager.StateResource{
+		Mode: "managed",
+		Type: "aws_instance",
+		Name: name,
+		Instances: []statemanager.ResourceInstance{{
+			Attributes:          attributes,
+			SensitiveAttributes: sensitive,
+		}},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := statemanager.StateContent{Resource: []statemanager.StateResource{
+		resource("web", map[string]any{
+			"id":            "i-1",
+			"instance_type": "t2.micro",
+			"monitoring":    false,
+			"key_name":      nil,
+			"tags":          map[string]any{"Name": "web", "Team": "core"},
+		}),
+		resource("unchanged", map[string]any{"id": "i-2", "tags": map[string]any{}}),
+		resource("legacy", map[string]any{"id": "i-3"}),
+	}}
+	new := statemanager.StateContent{Resource: []statemanager.StateResource{
+		resource("web", map[string]any{
+			"id":            "i-1",
+			"instance_type": "t3.micro",
+			"monitoring":    false,
+			"key_name":      "deployer",
+			"tags":          map[string]any{"Name": "web"},
+		}),
+		resource("unchanged", map[string]any{"id": "i-2", "tags": nil}),
+		resource("api", map[string]any{"id": "i-4"}),
+	}}
+
+	diff, err := statediff.Compare(context.Background(), driftchecker.NewDefaultDriftChecker(), old, new, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, &statediff.Diff{
+		Resources: []statediff.ResourceChange{
+			{Address: "aws_instance.api", Type: "aws_instance", Change: statediff.Added},
+			{Address: "aws_instance.legacy", Type: "aws_instance", Change: statediff.Removed},
+			{Address: "aws_instance.web", Type: "aws_instance", Change: statediff.Changed, Attributes: []statediff.AttributeChange{
+				{Attribute: "instance_type", Old: "t2.micro", New: "t3.micro"},
+				{Attribute: "key_name", Old: "", New: "deployer"},
+				{Attribute: "tags.Team", Old: "core", New: ""},
+			}},
+		},
+		Added:   1,
+		Removed: 1,
+		Changed: 1,
+	}, diff)
+}
+
+func TestCompare_Instances(t *testing.T) {
+	instances := func(ids ...string) statemanager.StateContent {
+		web := resource("web", nil)
+		web.Instances = nil
+		for i, id := range ids {
+			web.Instances = append(web.Instances, statemanager.ResourceInstance{IndexKey: float64(i), Attributes: map[string]any{"id": id}})
+		}
+		return statemanager.StateContent{Resource: []statemanager.StateResource{web}}
+	}
+
+	diff, err := statediff.Compare(context.Background(), driftchecker.NewDefaultDriftChecker(), instances("i-0", "i-1"), instances("i-0", "i-9", "i-2"), nil)
+	require.NoError(t, err)
+
+	require.Len(t, diff.Resources, 2)
+	assert.Equal(t, "aws_instance.web[1]", diff.Resources[0].Address)
+	assert.Equal(t, []statediff.AttributeChange{{Attribute: "id", Old: "i-1", New: "i-9"}}, diff.Resources[0].Attributes)
+	assert.Equal(t, statediff.ResourceChange{Address: "aws_instance.web[2]", Type: "aws_instance", Change: statediff.Added}, diff.Resources[1])
+}
+
+func TestCompare_Redaction(t *testing.T) {
+	old := statemanager.StateContent{Resource: []statemanager.StateResource{
+		resource("db", map[string]any{"password": "old-secret", "user_data": "a", "port": float64(5432)}, "user_data"),
+	}}
+	new := statemanager.StateContent{Resource: []statemanager.StateResource{
+		resource("db", map[string]any{"password": "new-secret", "user_data": "b", "port": float64(5433)}),
+	}}
+
+	redactor, err := redact.NewRedactor(redact.DefaultPatterns, redact.ModeMask)
+	require.NoError(t, err)
+	diff, err := statediff.Compare(context.Background(), driftchecker.NewDefaultDriftChecker(), old, new, redactor)
+	require.NoError(t, err)
+
+	require.Len(t, diff.Resources, 1)
+	assert.Equal(t, []statediff.AttributeChange{
+		{Attribute: "password", Old: redact.Masked, New: redact.Masked},
+		{Attribute: "port", Old: "5432", New: "5433"},
+		{Attribute: "user_data", Old: redact.Masked, New: redact.Masked},
+	}, diff.Resources[0].Attributes)
+}
+
+func TestCompareConfig(t *testing.T) {
+	config := []statemanager.StateResource{
+		resource("web", map[string]any{"instance_type": "t3.micro", "tags": map[string]any{"Name": "web"}}),
+		resource("api", map[string]any{"instance_type": "t3.micro"}),
+		{Mode: "managed", Type: "aws_instance", Name: "workers"},
+	}
+	workers := resource("workers", map[string]any{"id": "i-5"})
+	workers.Instances[0].IndexKey = float64(0)
+	child := resource("db", map[string]any{"id": "i-6"})
+	child.Module = "module.data"
+	ami := resource("ubuntu", map[string]any{"id": "ami-1"})
+	ami.Mode, ami.Type = "data", "aws_ami"
+	state := statemanager.StateContent{Resource: []statemanager.StateResource{
+		resource("web", map[string]any{
+			"id":            "i-1",
+			"instance_type": "t2.micro",
+			"tags":          map[string]any{"Name": "web", "Owner": "ops"},
+		}),
+		resource("legacy", map[string]any{"id": "i-3"}),
+		workers,
+		child,
+		ami,
+	}}
+
+	diff, err := statediff.CompareConfig(context.Background(), driftchecker.NewDefaultDriftChecker(), config, state, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, &statediff.Diff{
+		Resources: []statediff.ResourceChange{
+			{Address: "aws_instance.api", Type: "aws_instance", Change: statediff.Added},
+			{Address: "aws_instance.legacy", Type: "aws_instance", Change: statediff.Removed},
+			{Address: "aws_instance.web", Type: "aws_instance", Change: statediff.Changed, Attributes: []statediff.AttributeChange{
+				{Attribute: "instance_type", Old: "t2.micro", New: "t3.micro"},
+			}},
+		},
+		Added:   1,
+		Removed: 1,
+		Changed: 1,
+	}, diff)
+}
@@ -0,0 +1,89 @@
+package statemanager
+
+import (
+	"slices"
+	"strings"
+)
+
+// DependencyGraph records which resources of a state depend on which, from the
+// dependencies recorded in the instances of the state. Resources are identified by
+// their address without instance keys, e.g. module.network.aws_instance.web, the form
+// Terraform records dependencies in.
+type DependencyGraph struct {
+	// dependents maps the address of a resource to the addresses of the resources
+	// depending on it directly.
+	dependents map[string][]string
+}
+
+// NewDependencyGraph builds the dependency graph of resources, the resources of a
+// parsed state.
+func NewDependencyGraph(resources []StateResource) *DependencyGraph {
+	g := &DependencyGraph{dependents: map[string][]string{}}
+	for _, resource := range resources {
+		dependent := ConfigAddress(resource.Address())
+		for _, instance := range resource.Instances {
+			for _, dependency := range instance.Dependencies {
+				dependency = ConfigAddress(dependency)
+				if !slices.Contains(g.dependents[dependency], dependent) {
+					g.dependents[dependency] = append(g.dependents[dependency], dependent)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Dependents returns the addresses of the resources that depend on the resource at
+// address, directly or through other resources, sorted. Instance keys of address are
+// ignored, as dependencies are recorded per resource.
+func (g *DependencyGraph) Dependents(address string) []string {
+	if g == nil {
+		return nil
+	}
+	root := ConfigAddress(address)
+	seen := map[string]bool{root: true}
+	queue := []string{root}
+	var dependents []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range g.dependents[current] {
+			if seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			dependents = append(dependents, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+	slices.Sort(dependents)
+	return dependents
+}
+
+// ConfigAddress returns address without the count index or for_each key of the
+// resource and of its modules, e.g. module.network.aws_instance.web for
+// module.network["a"].aws_instance.web[0].
+func ConfigAddress(address string) string {
+	var b strings.Builder
+	depth, quoted := 0, false
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+		switch {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"' && depth > 0:
+			quoted = true
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
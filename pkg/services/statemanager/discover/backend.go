@@ -0,0 +1,136 @@
+package discover
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+)
+
+// backend is a backend type and the settings it was configured with.
+type backend struct {
+	Type   string         `json:"type"`
+	Config map[string]any `json:"config"`
+}
+
+// declaredBackend returns the backend declared in the terraform block of one of the .tf
+// files in dir, or nil if none declares one. Files that fail to parse are logged and
+// skipped, so a single broken file does not stop the discovery of a whole repository.
+func declaredBackend(ctx context.Context, dir string) (*backend, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list terraform configuration files")
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read terraform configuration file")
+		}
+		parsed, diags := hclsyntax.ParseConfig(src, file, hcl.InitialPos)
+		if diags.HasErrors() {
+			logger(ctx).Warn("Skipping terraform configuration file that failed to parse", "file", file, "error", diags.Error())
+			continue
+		}
+		for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+			if block.Type != "terraform" {
+				continue
+			}
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "backend" && len(nested.Labels) > 0 {
+					return &backend{Type: nested.Labels[0], Config: literalAttributes(nested.Body)}, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// literalAttributes evaluates the attributes of body that hold literal values. Backend
+// blocks cannot reference variables, so any other attribute is left out.
+func literalAttributes(body *hclsyntax.Body) map[string]any {
+	config := map[string]any{}
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			continue
+		}
+		if goValue, err := terraform.CtyValueToGo(value); err == nil {
+			config[name] = goValue
+		}
+	}
+	return config
+}
+
+// initializedBackend returns the backend 'terraform init' recorded in the .terraform
+// directory of dir, or nil if dir was not initialized with a backend.
+func initializedBackend(dir string) (*backend, error) {
+	content, err := os.ReadFile(filepath.Join(dir, dataDir, "terraform.tfstate"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recorded struct {
+		Backend *backend `json:"backend"`
+	}
+	if err := json.Unmarshal(content, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dataDir, "terraform.tfstate"), err)
+	}
+	if recorded.Backend == nil || recorded.Backend.Type == "" {
+		return nil, nil
+	}
+	return recorded.Backend, nil
+}
+
+// location returns the state path or URI the backend stores the default workspace at.
+// Relative local paths are resolved against the root directory dir.
+func (b *backend) location(dir string) (string, error) {
+	setting := func(name string) string {
+		value, _ := b.Config[name].(string)
+		return value
+	}
+
+	switch b.Type {
+	case "s3":
+		bucket, key := setting("bucket"), setting("key")
+		if bucket == "" || key == "" {
+			return "", fmt.Errorf("s3 backend requires bucket and key, run terraform init to record partial backend configuration")
+		}
+		return "s3://" + bucket + "/" + strings.TrimPrefix(key, "/"), nil
+	case "gcs":
+		bucket := setting("bucket")
+		if bucket == "" {
+			return "", fmt.Errorf("gcs backend requires a bucket, run terraform init to record partial backend configuration")
+		}
+		object := "default.tfstate"
+		if prefix := strings.Trim(setting("prefix"), "/"); prefix != "" {
+			object = prefix + "/" + object
+		}
+		return "gs://" + bucket + "/" + object, nil
+	case "http":
+		address := setting("address")
+		if address == "" {
+			return "", fmt.Errorf("http backend requires an address, run terraform init to record partial backend configuration")
+		}
+		return address, nil
+	case "local":
+		path := setting("path")
+		if path == "" {
+			path = "terraform.tfstate"
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("%s backend not currently supported", b.Type)
+	}
+}
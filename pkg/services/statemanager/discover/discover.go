@@ -0,0 +1,136 @@
+// Package discover finds the Terraform roots of a repository and locates the state of
+// each root from its backend configuration, so that drift detection can be run over a
+// monorepo without listing every state by hand.
+package discover
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// dataDir is the directory 'terraform init' creates in a root.
+const dataDir = ".terraform"
+
+// skippedDirs are directories that hold copies of modules rather than roots of their own.
+var skippedDirs = map[string]bool{
+	".terragrunt-cache": true,
+	"node_modules":      true,
+}
+
+// Root is a Terraform root module and the location of its state.
+type Root struct {
+	// Path is the directory of the root relative to the searched directory, using
+	// forward slashes, e.g. "envs/prod". The searched directory itself is ".".
+	Path string `json:"path"`
+	// Dir is the absolute directory of the root.
+	Dir string `json:"dir"`
+	// Backend is the backend type, e.g. "s3", or "local" when the root configures none.
+	Backend string `json:"backend"`
+	// StatePath is the local path or remote URI of the root's state, as accepted by the
+	// terraform state manager. It is empty when the state could not be located.
+	StatePath string `json:"state_path,omitempty"`
+	// Initialized is set when the root holds a .terraform directory.
+	Initialized bool `json:"initialized"`
+	// Error explains why the state could not be located, e.g. a partial backend
+	// configuration of a root that was never initialized.
+	Error string `json:"error,omitempty"`
+}
+
+// Discover returns the Terraform roots under dir, sorted by path. A directory is a root
+// when one of its .tf files declares a backend in its terraform block, or when it holds
+// a .terraform directory; directories with neither are taken to be modules. Hidden
+// directories, the Terragrunt cache and node_modules are not searched.
+//
+// The backend recorded by 'terraform init' in .terraform/terraform.tfstate is preferred
+// over the backend block, as it includes settings passed with -backend-config. A root
+// whose state cannot be located is still returned, with Error set.
+//
+// Parameters:
+//   - ctx: Context carrying the logger
+//   - dir: The directory to search
+//
+// Returns:
+//   - []Root: The roots found under dir
+//   - error: Any error encountered while walking dir
+func Discover(ctx context.Context, dir string) ([]Root, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve discovery directory")
+	}
+
+	var roots []Root
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir && (skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		root, ok, err := inspect(ctx, dir, path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			roots = append(roots, root)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to search discovery directory")
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Path < roots[j].Path })
+	return roots, nil
+}
+
+// inspect returns the root in path, and false when path is not a root.
+func inspect(ctx context.Context, base string, path string) (Root, bool, error) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return Root{}, false, errors.Wrap(err, "Failed to resolve root path")
+	}
+	root := Root{Path: filepath.ToSlash(rel), Dir: path}
+	if info, err := os.Stat(filepath.Join(path, dataDir)); err == nil && info.IsDir() {
+		root.Initialized = true
+	}
+
+	declared, err := declaredBackend(ctx, path)
+	if err != nil {
+		return Root{}, false, err
+	}
+	if declared == nil && !root.Initialized {
+		return Root{}, false, nil
+	}
+
+	b := declared
+	if recorded, err := initializedBackend(path); err != nil {
+		logger(ctx).Warn("Failed to read the backend recorded by terraform init", "root", root.Path, "error", err)
+	} else if recorded != nil {
+		b = recorded
+	}
+	if b == nil {
+		b = &backend{Type: "local"}
+	}
+
+	root.Backend = b.Type
+	if root.StatePath, err = b.location(path); err != nil {
+		root.Error = err.Error()
+		logger(ctx).Warn("Failed to locate the state of terraform root", "root", root.Path, "error", err)
+	}
+	return root, true, nil
+}
+
+// logger returns the logger carried by ctx for the discover module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "discover")
+}
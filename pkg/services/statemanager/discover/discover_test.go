@@ -0,0 +1,118 @@
+package discover_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager/discover"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRepo creates the files of a repository under a temporary directory.
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+const s3Backend = `
+terraform {
+  required_version = ">= 1.5"
+  backend "s3" {
+    bucket = "acme-tfstate"
+    key    = "/prod/network.tfstate"
+    region = "us-east-1"
+  }
+}
+`
+
+// backendBlock returns a terraform block holding backend.
+func backendBlock(backend string) string {
+	return "terraform {\n  " + backend + "\n}\n"
+}
+
+const module = `
+variable "cidr" {}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+}
+`
+
+func TestDiscover_Roots(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"envs/prod/main.tf":                        s3Backend,
+		"envs/prod/vpc.tf":                         module,
+		"modules/vpc/main.tf":                      module,
+		"envs/dev/main.tf":                         module,
+		"envs/dev/.terraform/x":                    "",
+		"sandbox/main.tf":                          backendBlock(`backend "local" { path = "state/sandbox.tfstate" }`),
+		"envs/prod/.terraform/modules/vpc/main.tf": s3Backend,
+		".git/main.tf":                             s3Backend,
+		"node_modules/pkg/main.tf":                 s3Backend,
+	})
+
+	roots, err := discover.Discover(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.Len(t, roots, 3, "modules, hidden directories and node_modules hold no roots")
+	assert.Equal(t, discover.Root{
+		Path: "envs/dev", Dir: filepath.Join(dir, "envs", "dev"), Backend: "local",
+		StatePath: filepath.Join(dir, "envs", "dev", "terraform.tfstate"), Initialized: true,
+	}, roots[0])
+	assert.Equal(t, discover.Root{
+		Path: "envs/prod", Dir: filepath.Join(dir, "envs", "prod"), Backend: "s3",
+		StatePath: "s3://acme-tfstate/prod/network.tfstate", Initialized: true,
+	}, roots[1])
+	assert.Equal(t, discover.Root{
+		Path: "sandbox", Dir: filepath.Join(dir, "sandbox"), Backend: "local",
+		StatePath: filepath.Join(dir, "sandbox", "state", "sandbox.tfstate"),
+	}, roots[2])
+}
+
+func TestDiscover_InitializedBackendCompletesPartialConfiguration(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"main.tf": backendBlock(`backend "s3" {}`),
+		".terraform/terraform.tfstate": `{
+  "version": 3,
+  "backend": {
+    "type": "s3",
+    "config": {"bucket": "acme-tfstate", "key": "app.tfstate", "region": "eu-west-1"}
+  }
+}`,
+	})
+
+	roots, err := discover.Discover(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.Len(t, roots, 1)
+	assert.Equal(t, ".", roots[0].Path)
+	assert.Equal(t, "s3://acme-tfstate/app.tfstate", roots[0].StatePath)
+	assert.Empty(t, roots[0].Error)
+}
+
+func TestDiscover_UnresolvedBackend(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"partial/main.tf": backendBlock(`backend "s3" {}`),
+		"cloud/main.tf":   backendBlock(`backend "azurerm" { container_name = "tfstate" }`),
+		"broken/main.tf":  backendBlock(`backend "s3" {`),
+	})
+
+	roots, err := discover.Discover(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.Len(t, roots, 2, "a file that fails to parse is skipped")
+	assert.Equal(t, "cloud", roots[0].Path)
+	assert.Equal(t, "azurerm backend not currently supported", roots[0].Error)
+	assert.Equal(t, "partial", roots[1].Path)
+	assert.Empty(t, roots[1].StatePath)
+	assert.Contains(t, roots[1].Error, "s3 backend requires bucket and key")
+}
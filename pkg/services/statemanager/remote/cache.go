@@ -0,0 +1,64 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// etagCache stores fetched content alongside its ETag so that unchanged state is
+// not downloaded again. A nil cache stores nothing.
+type etagCache struct {
+	dir string
+}
+
+func newETagCache(dir string) *etagCache {
+	if dir == "" {
+		return nil
+	}
+	return &etagCache{dir: dir}
+}
+
+func (c *etagCache) paths(uri string) (string, string) {
+	sum := sha256.Sum256([]byte(uri))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key+".etag"), filepath.Join(c.dir, key+".body")
+}
+
+// lookup returns the cached ETag and content for uri, if any.
+func (c *etagCache) lookup(uri string) (string, []byte, bool) {
+	if c == nil {
+		return "", nil, false
+	}
+	etagPath, bodyPath := c.paths(uri)
+	etag, err := os.ReadFile(etagPath)
+	if err != nil {
+		return "", nil, false
+	}
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return "", nil, false
+	}
+	return string(etag), body, true
+}
+
+// store caches body under etag. Failures only disable caching for this fetch.
+func (c *etagCache) store(ctx context.Context, uri, etag string, body []byte) {
+	if c == nil || etag == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		logger(ctx).Debug("Failed to create state cache folder", "path", c.dir, "error", err)
+		return
+	}
+	etagPath, bodyPath := c.paths(uri)
+	if err := os.WriteFile(bodyPath, body, 0600); err != nil {
+		logger(ctx).Debug("Failed to cache state", "uri", uri, "error", err)
+		return
+	}
+	if err := os.WriteFile(etagPath, []byte(etag), 0600); err != nil {
+		logger(ctx).Debug("Failed to cache state ETag", "uri", uri, "error", err)
+	}
+}
@@ -0,0 +1,109 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// gcsTokenEnv holds an OAuth access token used for gs:// URIs when no Authorization
+// header is configured, e.g. the output of `gcloud auth print-access-token`.
+const gcsTokenEnv = "GOOGLE_OAUTH_ACCESS_TOKEN"
+
+const defaultRetryDelay = 500 * time.Millisecond
+
+// HTTPFetcher implements Fetcher for http and https URIs. Responses are cached by
+// ETag, and network errors, 429 and 5xx responses are retried with exponential backoff.
+type HTTPFetcher struct {
+	Client     *http.Client
+	Headers    map[string]string
+	MaxRetries int
+	RetryDelay time.Duration
+
+	cache *etagCache
+}
+
+// Fetch retrieves the content at uri, revalidating a cached copy when one exists.
+func (h *HTTPFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	delay := h.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	etag, cached, hasCache := h.cache.lookup(uri)
+
+	var lastErr error
+	for attempt := 0; attempt <= h.MaxRetries; attempt++ {
+		if attempt > 0 {
+			logger(ctx).Debug("Retrying state download", "uri", uri, "attempt", attempt, "error", lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request for %s: %w", uri, err)
+		}
+		h.setHeaders(req)
+		if hasCache {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		body, status, respETag, err := h.do(client, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch {
+		case status == http.StatusNotModified && hasCache:
+			logger(ctx).Debug("Using cached state", "uri", uri)
+			return cached, nil
+		case status == http.StatusOK:
+			h.cache.store(ctx, uri, respETag, body)
+			return body, nil
+		case status == http.StatusTooManyRequests || status >= 500:
+			lastErr = fmt.Errorf("failed to fetch state from %s: %s", uri, http.StatusText(status))
+		default:
+			return nil, fmt.Errorf("failed to fetch state from %s: %s", uri, http.StatusText(status))
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (h *HTTPFetcher) do(client *http.Client, req *http.Request) ([]byte, int, string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to fetch state from %s: %w", req.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to read state from %s: %w", req.URL, err)
+	}
+	return body, resp.StatusCode, resp.Header.Get("ETag"), nil
+}
+
+func (h *HTTPFetcher) setHeaders(req *http.Request) {
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+	if req.URL.Host == "storage.googleapis.com" && req.Header.Get("Authorization") == "" {
+		if token := os.Getenv(gcsTokenEnv); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package remote fetches state files that are stored behind a URL rather than on the
+// local file system, such as internal artifact servers (http, https), S3 buckets (s3)
+// and Google Cloud Storage buckets (gs).
+package remote
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Fetcher retrieves the content stored at a remote state URI.
+//
+//counterfeiter:generate . Fetcher
+type Fetcher interface {
+	Fetch(ctx context.Context, uri string) ([]byte, error)
+}
+
+// Options configures the fetchers created by NewFetcher.
+type Options struct {
+	// Headers are sent with every http, https and gs request, e.g. Authorization.
+	Headers map[string]string
+	// CacheDir is where responses are cached by ETag. Caching is disabled when empty.
+	CacheDir string
+	// MaxRetries is the number of times a failed http request is retried.
+	MaxRetries int
+	// RetryDelay is the delay before the first retry; it doubles with every attempt.
+	RetryDelay time.Duration
+	// HTTPClient is used for http, https and gs requests. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// S3Client is used for s3 requests. A client is created from the default AWS configuration when nil.
+	S3Client S3API
+}
+
+// IsRemote reports whether path is a URI handled by this package rather than a local path.
+func IsRemote(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "s3", "gs":
+		return true
+	}
+	return false
+}
+
+// SchemeFetcher implements Fetcher by dispatching to a fetcher for the URI's scheme.
+type SchemeFetcher struct {
+	HTTP *HTTPFetcher
+	S3   *S3Fetcher
+}
+
+// NewFetcher creates a new SchemeFetcher instance.
+// opts: Authentication headers, caching and retry settings shared by every scheme.
+func NewFetcher(opts Options) *SchemeFetcher {
+	cache := newETagCache(opts.CacheDir)
+	return &SchemeFetcher{
+		HTTP: &HTTPFetcher{
+			Client:     opts.HTTPClient,
+			Headers:    opts.Headers,
+			MaxRetries: opts.MaxRetries,
+			RetryDelay: opts.RetryDelay,
+			cache:      cache,
+		},
+		S3: &S3Fetcher{
+			Client: opts.S3Client,
+			cache:  cache,
+		},
+	}
+}
+
+// Fetch retrieves the content at uri.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - uri: An http, https, s3 or gs URI
+//
+// Returns:
+//   - []byte: The content stored at uri
+//   - error: An error if the scheme is unsupported or the content could not be retrieved
+func (f *SchemeFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state URI %s: %w", uri, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return f.HTTP.Fetch(ctx, uri)
+	case "gs":
+		return f.HTTP.Fetch(ctx, GCSObjectURL(u))
+	case "s3":
+		return f.S3.Fetch(ctx, uri)
+	default:
+		return nil, fmt.Errorf("%s state URIs are not currently supported", u.Scheme)
+	}
+}
+
+// GCSObjectURL converts a gs://bucket/object URI to its Cloud Storage download URL.
+func GCSObjectURL(u *url.URL) string {
+	return "https://storage.googleapis.com/" + u.Host + "/" + trimSlash(u.Path)
+}
+
+func trimSlash(path string) string {
+	for len(path) > 0 && path[0] == '/' {
+		path = path[1:]
+	}
+	return path
+}
+
+// logger returns the logger carried by ctx for the remote module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "remote")
+}
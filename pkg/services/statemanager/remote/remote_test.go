@@ -0,0 +1,122 @@
+package remote_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"drift-watcher/pkg/services/statemanager/remote/remotefakes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRemote(t *testing.T) {
+	assert.True(t, remote.IsRemote("https://example.com/terraform.tfstate"))
+	assert.True(t, remote.IsRemote("http://example.com/terraform.tfstate"))
+	assert.True(t, remote.IsRemote("s3://bucket/terraform.tfstate"))
+	assert.True(t, remote.IsRemote("gs://bucket/terraform.tfstate"))
+	assert.False(t, remote.IsRemote("./terraform.tfstate"))
+	assert.False(t, remote.IsRemote("/tmp/terraform.tfstate"))
+	assert.False(t, remote.IsRemote("-"))
+}
+
+func TestSchemeFetcher_HTTP_HeadersAndETagCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `{"version": 4}`)
+	}))
+	defer server.Close()
+
+	fetcher := remote.NewFetcher(remote.Options{
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+		CacheDir: t.TempDir(),
+	})
+
+	body, err := fetcher.Fetch(context.Background(), server.URL+"/prod.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, `{"version": 4}`, string(body))
+
+	body, err = fetcher.Fetch(context.Background(), server.URL+"/prod.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, `{"version": 4}`, string(body), "a 304 response is served from the cache")
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestSchemeFetcher_HTTP_RetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"version": 4}`)
+	}))
+	defer server.Close()
+
+	fetcher := remote.NewFetcher(remote.Options{MaxRetries: 2, RetryDelay: time.Millisecond})
+	body, err := fetcher.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version": 4}`, string(body))
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestSchemeFetcher_HTTP_ClientErrorIsNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	fetcher := remote.NewFetcher(remote.Options{MaxRetries: 3, RetryDelay: time.Millisecond})
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Forbidden")
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestSchemeFetcher_S3(t *testing.T) {
+	client := &remotefakes.FakeS3API{}
+	client.GetObjectReturns(&s3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader(`{"version": 4}`)),
+		ETag: aws.String(`"abc"`),
+	}, nil)
+
+	fetcher := remote.NewFetcher(remote.Options{S3Client: client})
+	body, err := fetcher.Fetch(context.Background(), "s3://state-bucket/prod/terraform.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, `{"version": 4}`, string(body))
+
+	_, input, _ := client.GetObjectArgsForCall(0)
+	assert.Equal(t, "state-bucket", aws.ToString(input.Bucket))
+	assert.Equal(t, "prod/terraform.tfstate", aws.ToString(input.Key))
+}
+
+func TestSchemeFetcher_UnsupportedScheme(t *testing.T) {
+	fetcher := remote.NewFetcher(remote.Options{})
+	_, err := fetcher.Fetch(context.Background(), "ftp://example.com/terraform.tfstate")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ftp state URIs are not currently supported")
+}
+
+func TestGCSObjectURL(t *testing.T) {
+	u, err := url.Parse("gs://state-bucket/envs/prod.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.googleapis.com/state-bucket/envs/prod.tfstate", remote.GCSObjectURL(u))
+}
@@ -0,0 +1,122 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package remotefakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type FakeS3API struct {
+	GetObjectStub        func(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	getObjectMutex       sync.RWMutex
+	getObjectArgsForCall []struct {
+		arg1 context.Context
+		arg2 *s3.GetObjectInput
+		arg3 []func(*s3.Options)
+	}
+	getObjectReturns struct {
+		result1 *s3.GetObjectOutput
+		result2 error
+	}
+	getObjectReturnsOnCall map[int]struct {
+		result1 *s3.GetObjectOutput
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeS3API) GetObject(arg1 context.Context, arg2 *s3.GetObjectInput, arg3 ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	fake.getObjectMutex.Lock()
+	ret, specificReturn := fake.getObjectReturnsOnCall[len(fake.getObjectArgsForCall)]
+	fake.getObjectArgsForCall = append(fake.getObjectArgsForCall, struct {
+		arg1 context.Context
+		arg2 *s3.GetObjectInput
+		arg3 []func(*s3.Options)
+	}{arg1, arg2, arg3})
+	stub := fake.GetObjectStub
+	fakeReturns := fake.getObjectReturns
+	fake.recordInvocation("GetObject", []interface{}{arg1, arg2, arg3})
+	fake.getObjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeS3API) GetObjectCallCount() int {
+	fake.getObjectMutex.RLock()
+	defer fake.getObjectMutex.RUnlock()
+	return len(fake.getObjectArgsForCall)
+}
+
+func (fake *FakeS3API) GetObjectCalls(stub func(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)) {
+	fake.getObjectMutex.Lock()
+	defer fake.getObjectMutex.Unlock()
+	fake.GetObjectStub = stub
+}
+
+func (fake *FakeS3API) GetObjectArgsForCall(i int) (context.Context, *s3.GetObjectInput, []func(*s3.Options)) {
+	fake.getObjectMutex.RLock()
+	defer fake.getObjectMutex.RUnlock()
+	argsForCall := fake.getObjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeS3API) GetObjectReturns(result1 *s3.GetObjectOutput, result2 error) {
+	fake.getObjectMutex.Lock()
+	defer fake.getObjectMutex.Unlock()
+	fake.GetObjectStub = nil
+	fake.getObjectReturns = struct {
+		result1 *s3.GetObjectOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeS3API) GetObjectReturnsOnCall(i int, result1 *s3.GetObjectOutput, result2 error) {
+	fake.getObjectMutex.Lock()
+	defer fake.getObjectMutex.Unlock()
+	fake.GetObjectStub = nil
+	if fake.getObjectReturnsOnCall == nil {
+		fake.getObjectReturnsOnCall = make(map[int]struct {
+			result1 *s3.GetObjectOutput
+			result2 error
+		})
+	}
+	fake.getObjectReturnsOnCall[i] = struct {
+		result1 *s3.GetObjectOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeS3API) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getObjectMutex.RLock()
+	defer fake.getObjectMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeS3API) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ remote.S3API = new(FakeS3API)
@@ -0,0 +1,85 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	aConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3API is the subset of the S3 client used to download state objects.
+//
+//counterfeiter:generate . S3API
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Fetcher implements Fetcher for s3://bucket/key URIs. Retries are handled by the
+// AWS SDK and responses are cached by ETag.
+type S3Fetcher struct {
+	Client S3API
+
+	cache *etagCache
+}
+
+// Fetch downloads the object at uri, revalidating a cached copy when one exists.
+func (s *S3Fetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state URI %s: %w", uri, err)
+	}
+
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(trimSlash(u.Path)),
+	}
+	etag, cached, hasCache := s.cache.lookup(uri)
+	if hasCache {
+		input.IfNoneMatch = aws.String(etag)
+	}
+
+	out, err := client.GetObject(ctx, input)
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if hasCache && errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("failed to fetch state from %s: %w", uri, err)
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state from %s: %w", uri, err)
+	}
+	s.cache.store(ctx, uri, aws.ToString(out.ETag), body)
+	return body, nil
+}
+
+// client returns the configured client, creating one from the default AWS
+// configuration when none is set. Set Client to read state from another endpoint,
+// such as LocalStack.
+func (s *S3Fetcher) client(ctx context.Context) (S3API, error) {
+	if s.Client != nil {
+		return s.Client, nil
+	}
+
+	awsConfig, err := aConfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	s.Client = s3.NewFromConfig(awsConfig)
+	return s.Client, nil
+}
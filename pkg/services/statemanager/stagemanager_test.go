@@ -97,8 +97,24 @@ func TestStateResource_AttributeValue_AttributeNotString(t *testing.T) {
 		},
 	}
 
-	// Test attribute that is not a string
+	// Numbers are rendered as strings so they can be compared with live values
 	val, err := s.AttributeValue("count")
+	require.NoError(t, err)
+	assert.Equal(t, "123", val)
+}
+
+func TestStateResource_AttributeValue_AttributeNotScalar(t *testing.T) {
+	s := statemanager.StateResource{
+		Instances: []statemanager.ResourceInstance{
+			{
+				Attributes: map[string]any{
+					"metadata_options": map[string]any{"http_tokens": "required"},
+				},
+			},
+		},
+	}
+
+	val, err := s.AttributeValue("metadata_options")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "attribute value cannot be parsed to string")
 	assert.Empty(t, val)
@@ -259,3 +275,134 @@ func TestResourceInstance_JSONMarshalling(t *testing.T) {
 	assert.ElementsMatch(t, ri.Attributes["tags"].([]string), unmarshaledRi.Attributes["tags"].([]any))
 	assert.ElementsMatch(t, ri.Dependencies, unmarshaledRi.Dependencies)
 }
+
+func TestStateResource_AttributeValue_List(t *testing.T) {
+	s := statemanager.StateResource{
+		Instances: []statemanager.ResourceInstance{
+			{
+				Attributes: map[string]any{
+					"vpc_security_group_ids": []any{"sg-1", "sg-2"},
+					"ingress":                []any{map[string]any{"from_port": float64(22)}},
+				},
+			},
+		},
+	}
+
+	val, err := s.AttributeValue("vpc_security_group_ids")
+	require.NoError(t, err)
+	assert.Equal(t, "sg-1,sg-2", val)
+
+	val, err = s.AttributeValue("ingress")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"from_port":22}]`, val)
+}
+
+func TestStateResource_AttributeValue_NestedPath(t *testing.T) {
+	s := statemanager.StateResource{
+		Instances: []statemanager.ResourceInstance{
+			{
+				Attributes: map[string]any{
+					"tags": map[string]any{"Name": "web"},
+					"spec": []any{map[string]any{
+						"replicas": "3",
+						"template": []any{map[string]any{
+							"spec": []any{map[string]any{
+								"container": []any{
+									map[string]any{"image": "nginx:1.27"},
+									map[string]any{"image": "envoy:1.30"},
+								},
+							}},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		attribute string
+		want      string
+	}{
+		{"tags.Name", "web"},
+		{"tags.Missing", ""},
+		{"spec.replicas", "3"},
+		{"spec.0.replicas", "3"},
+		{"spec.template.spec.container.image", "nginx:1.27,envoy:1.30"},
+		{"spec.template.spec.container.1.image", "envoy:1.30"},
+		{"spec.template.spec.container.5.image", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attribute, func(t *testing.T) {
+			val, err := s.AttributeValue(tt.attribute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, val)
+		})
+	}
+}
+
+func TestExpandInstances(t *testing.T) {
+	resources := []statemanager.StateResource{
+		{Name: "web", Instances: []statemanager.ResourceInstance{
+			{IndexKey: float64(0), Attributes: map[string]any{"id": "i-1"}},
+			{IndexKey: float64(1), Attributes: map[string]any{"id": "i-2"}},
+		}},
+		{Name: "api", Instances: []statemanager.ResourceInstance{{Attributes: map[string]any{"id": "i-3"}}}},
+		{Name: "empty"},
+	}
+
+	expanded := statemanager.ExpandInstances(resources)
+	require.Len(t, expanded, 4)
+	for i, id := range []string{"i-1", "i-2", "i-3"} {
+		value, err := expanded[i].AttributeValue("id")
+		require.NoError(t, err)
+		assert.Equal(t, id, value)
+		assert.Len(t, expanded[i].Instances, 1)
+	}
+	assert.Equal(t, "web", expanded[1].Name)
+	assert.Equal(t, float64(1), expanded[1].IndexKey())
+	assert.Nil(t, expanded[2].IndexKey())
+	assert.Nil(t, expanded[3].IndexKey())
+	assert.Len(t, resources[0].Instances, 2, "the resources are not modified")
+}
+
+func TestStateResource_Address(t *testing.T) {
+	resource := statemanager.StateResource{Mode: "managed", Module: "module.network", Type: "aws_instance", Name: "web"}
+	assert.Equal(t, "module.network.aws_instance.web", resource.Address())
+
+	resource.Instances = []statemanager.ResourceInstance{{IndexKey: float64(0)}}
+	assert.Equal(t, "module.network.aws_instance.web[0]", resource.Address())
+
+	resource.Instances = []statemanager.ResourceInstance{{IndexKey: "blue"}}
+	assert.Equal(t, `module.network.aws_instance.web["blue"]`, resource.Address())
+
+	data := statemanager.StateResource{Mode: "data", Type: "aws_ami", Name: "ubuntu"}
+	assert.Equal(t, "data.aws_ami.ubuntu", data.Address())
+}
+
+func TestDependencyGraph_Dependents(t *testing.T) {
+	resources := []statemanager.StateResource{
+		{Module: "module.network", Type: "aws_vpc", Name: "main", Instances: []statemanager.ResourceInstance{{}}},
+		{Type: "aws_security_group", Name: "web", Instances: []statemanager.ResourceInstance{
+			{Dependencies: []string{"module.network.aws_vpc.main"}},
+		}},
+		{Type: "aws_instance", Name: "web", Instances: []statemanager.ResourceInstance{
+			{IndexKey: float64(0), Dependencies: []string{"aws_security_group.web"}},
+			{IndexKey: float64(1), Dependencies: []string{"aws_security_group.web"}},
+		}},
+		{Type: "aws_eip", Name: "web", Instances: []statemanager.ResourceInstance{
+			{Dependencies: []string{"aws_instance.web", "aws_security_group.web"}},
+		}},
+	}
+
+	graph := statemanager.NewDependencyGraph(resources)
+	assert.Equal(t, []string{"aws_eip.web", "aws_instance.web"}, graph.Dependents("aws_security_group.web"))
+	assert.Equal(t, []string{"aws_eip.web", "aws_instance.web", "aws_security_group.web"}, graph.Dependents(`module.network["a"].aws_vpc.main`), "dependents are transitive")
+	assert.Equal(t, []string{"aws_eip.web"}, graph.Dependents("aws_instance.web[1]"))
+	assert.Empty(t, graph.Dependents("aws_eip.web"))
+}
+
+func TestConfigAddress(t *testing.T) {
+	assert.Equal(t, "aws_instance.web", statemanager.ConfigAddress("aws_instance.web[0]"))
+	assert.Equal(t, "module.network.aws_subnet.private", statemanager.ConfigAddress(`module.network["eu-west-1"].aws_subnet.private["a]b"]`))
+	assert.Equal(t, "data.aws_ami.ubuntu", statemanager.ConfigAddress("data.aws_ami.ubuntu"))
+}
@@ -7,10 +7,17 @@ package statemanager
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 import (
 	"context"
+	"drift-watcher/pkg/services/attrpath"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 )
 
+// StdinStatePath is the state path that denotes reading the state from standard input,
+// e.g. `terraform state pull | driftwatcher detect --configfile -`.
+const StdinStatePath = "-"
+
 // StateContent represents the parsed content of an Infrastructure as Code state file.
 // It contains metadata about the IaC tool, schema version, and the actual resources
 // defined in the state, along with backend configuration information.
@@ -22,10 +29,19 @@ type StateContent struct {
 	SchemaVersion string          `json:"schema_version,omitempty"`
 	StateId       string          `json:"state_id,omitempty"`
 	Resource      []StateResource `json:"resource,omitempty"`
+	// Outputs are the root module outputs recorded in the state, sorted by name.
+	Outputs       []StateOutput   `json:"outputs,omitempty"`
 	RawState      json.RawMessage `json:"raw_state,omitempty"`
 	BackendConfig BackendConfig   `json:"backend_config"`
 }
 
+// StateOutput is an output value recorded in the state.
+type StateOutput struct {
+	Name      string `json:"name"`
+	Value     any    `json:"value"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
 // ConfigDetails contains the specific configuration parameters for a backend.
 // These details vary depending on the backend type (e.g., S3, local, etc.).
 // NOTE: only local backend is supported currently
@@ -54,7 +70,9 @@ type StateResource struct {
 	Name     string       `json:"name,omitempty"`
 	Type     string       `json:"type,omitempty"`
 	Provider ProviderType `json:"provider,omitempty"`
-	// NOTE: assuming only one instance exists for a resource
+	// Instances holds one instance per count index or for_each key. The attribute
+	// methods read the first instance; ExpandInstances splits a resource into one
+	// resource per instance so that every instance is checked.
 	Instances []ResourceInstance `json:"instances,omitempty"`
 	ToolData  map[string]any     `json:"tool_data,omitempty"`
 }
@@ -65,30 +83,103 @@ func (s StateResource) ResourceType() string {
 	return s.Type
 }
 
+// ExpandInstances returns one resource per instance of resources, each holding a single
+// instance, so that every instance of a resource created with count or for_each is
+// compared with its own live counterpart. Resources without instances are kept as
+// they are.
+func ExpandInstances(resources []StateResource) []StateResource {
+	expanded := make([]StateResource, 0, len(resources))
+	for _, resource := range resources {
+		if len(resource.Instances) <= 1 {
+			expanded = append(expanded, resource)
+			continue
+		}
+		for _, instance := range resource.Instances {
+			single := resource
+			single.Instances = []ResourceInstance{instance}
+			expanded = append(expanded, single)
+		}
+	}
+	return expanded
+}
+
+// IndexKey returns the count index or for_each key of the resource's first instance,
+// or nil when the resource uses neither.
+func (s StateResource) IndexKey() any {
+	if len(s.Instances) == 0 {
+		return nil
+	}
+	return s.Instances[0].IndexKey
+}
+
+// Address returns the address of the resource's first instance, e.g.
+// module.network.aws_instance.web[0] or data.aws_ami.ubuntu. The index is only
+// included for resources created with count or for_each.
+func (s StateResource) Address() string {
+	address := s.Type + "." + s.Name
+	if s.Mode == "data" {
+		address = "data." + address
+	}
+	if s.Module != "" {
+		address = s.Module + "." + address
+	}
+	switch key := s.IndexKey().(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	case float64:
+		address += fmt.Sprintf("[%d]", int(key))
+	case int:
+		address += fmt.Sprintf("[%d]", key)
+	}
+	return address
+}
+
+// SensitiveAttributes returns the attributes of the resource's first instance that
+// are marked as sensitive in the state.
+func (s StateResource) SensitiveAttributes() []string {
+	if len(s.Instances) == 0 {
+		return nil
+	}
+	return s.Instances[0].SensitiveAttributes
+}
+
 // AttributeValue retrieves the value of a specific attribute from the resource's
 // first instance. It returns an error if no instances exist or if the attribute
-// value cannot be converted to a string.
+// value cannot be converted to a string. Values are rendered by attrpath.Format:
+// numbers and booleans as strings, lists of scalars as comma-separated strings and
+// lists of objects as JSON. An attribute that is not a top-level key is looked up as
+// an attribute path expression into nested blocks, such as tags.Name or
+// root_block_device[0].volume_size, as described by attrpath.Lookup.
 //
 // Parameters:
-//   - attribute: The name of the attribute to retrieve
+//   - attribute: The name or path expression of the attribute to retrieve
 //
 // Returns:
 //   - The string value of the attribute, or empty string if not found
-//   - An error if no instances exist or if type conversion fails
+//   - An error if no instances exist, the path expression is invalid, or if type
+//     conversion fails
 func (s StateResource) AttributeValue(attribute string) (string, error) {
 	if len(s.Instances) == 0 {
 		return "", fmt.Errorf("No Instance for resource")
 	}
 
 	data, ok := s.Instances[0].Attributes[attribute]
+	if !ok && strings.ContainsAny(attribute, ".[") {
+		p, err := attrpath.Parse(attribute)
+		if err != nil {
+			return "", err
+		}
+		data, ok = attrpath.Lookup(s.Instances[0].Attributes, p)
+	}
 	if !ok {
 		return "", nil
 	}
-	value, ok := data.(string)
-	if !ok {
+	switch data.(type) {
+	case string, []any, float64, int, bool:
+		return attrpath.Format(data)
+	default:
 		return "", fmt.Errorf("attribute value cannot be parsed to string")
 	}
-	return value, nil
 }
 
 // ResourceInstance represents a single instance of a resource.
@@ -98,6 +189,11 @@ type ResourceInstance struct {
 	ScheamVersion int            `json:"scheam_version,omitempty"`
 	Attributes    map[string]any `json:"attributes,omitempty"`
 	Dependencies  []string       `json:"dependencies,omitempty"`
+	// IndexKey is the count index, a number, or the for_each key, a string, of the
+	// instance. It is nil for resources using neither.
+	IndexKey any `json:"index_key,omitempty"`
+	// SensitiveAttributes names the top-level attributes the IaC tool marks as sensitive.
+	SensitiveAttributes []string `json:"sensitive_attributes,omitempty"`
 }
 
 // StateManagerI defines the interface for parsing and managing IaC state files.
@@ -107,5 +203,6 @@ type ResourceInstance struct {
 //counterfeiter:generate . StateManagerI
 type StateManagerI interface {
 	ParseStateFile(ctx context.Context, statePath string) (StateContent, error)
+	ParseState(ctx context.Context, r io.Reader) (StateContent, error)
 	RetrieveResources(ctx context.Context, content StateContent, resourceType string) ([]StateResource, error)
 }
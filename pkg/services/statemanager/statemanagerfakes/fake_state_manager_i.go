@@ -4,10 +4,25 @@ package statemanagerfakes
 import (
 	"context"
 	"drift-watcher/pkg/services/statemanager"
+	"io"
 	"sync"
 )
 
 type FakeStateManagerI struct {
+	ParseStateStub        func(context.Context, io.Reader) (statemanager.StateContent, error)
+	parseStateMutex       sync.RWMutex
+	parseStateArgsForCall []struct {
+		arg1 context.Context
+		arg2 io.Reader
+	}
+	parseStateReturns struct {
+		result1 statemanager.StateContent
+		result2 error
+	}
+	parseStateReturnsOnCall map[int]struct {
+		result1 statemanager.StateContent
+		result2 error
+	}
 	ParseStateFileStub        func(context.Context, string) (statemanager.StateContent, error)
 	parseStateFileMutex       sync.RWMutex
 	parseStateFileArgsForCall []struct {
@@ -41,6 +56,71 @@ type FakeStateManagerI struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeStateManagerI) ParseState(arg1 context.Context, arg2 io.Reader) (statemanager.StateContent, error) {
+	fake.parseStateMutex.Lock()
+	ret, specificReturn := fake.parseStateReturnsOnCall[len(fake.parseStateArgsForCall)]
+	fake.parseStateArgsForCall = append(fake.parseStateArgsForCall, struct {
+		arg1 context.Context
+		arg2 io.Reader
+	}{arg1, arg2})
+	stub := fake.ParseStateStub
+	fakeReturns := fake.parseStateReturns
+	fake.recordInvocation("ParseState", []interface{}{arg1, arg2})
+	fake.parseStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStateManagerI) ParseStateCallCount() int {
+	fake.parseStateMutex.RLock()
+	defer fake.parseStateMutex.RUnlock()
+	return len(fake.parseStateArgsForCall)
+}
+
+func (fake *FakeStateManagerI) ParseStateCalls(stub func(context.Context, io.Reader) (statemanager.StateContent, error)) {
+	fake.parseStateMutex.Lock()
+	defer fake.parseStateMutex.Unlock()
+	fake.ParseStateStub = stub
+}
+
+func (fake *FakeStateManagerI) ParseStateArgsForCall(i int) (context.Context, io.Reader) {
+	fake.parseStateMutex.RLock()
+	defer fake.parseStateMutex.RUnlock()
+	argsForCall := fake.parseStateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStateManagerI) ParseStateReturns(result1 statemanager.StateContent, result2 error) {
+	fake.parseStateMutex.Lock()
+	defer fake.parseStateMutex.Unlock()
+	fake.ParseStateStub = nil
+	fake.parseStateReturns = struct {
+		result1 statemanager.StateContent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStateManagerI) ParseStateReturnsOnCall(i int, result1 statemanager.StateContent, result2 error) {
+	fake.parseStateMutex.Lock()
+	defer fake.parseStateMutex.Unlock()
+	fake.ParseStateStub = nil
+	if fake.parseStateReturnsOnCall == nil {
+		fake.parseStateReturnsOnCall = make(map[int]struct {
+			result1 statemanager.StateContent
+			result2 error
+		})
+	}
+	fake.parseStateReturnsOnCall[i] = struct {
+		result1 statemanager.StateContent
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeStateManagerI) ParseStateFile(arg1 context.Context, arg2 string) (statemanager.StateContent, error) {
 	fake.parseStateFileMutex.Lock()
 	ret, specificReturn := fake.parseStateFileReturnsOnCall[len(fake.parseStateFileArgsForCall)]
@@ -175,6 +255,8 @@ func (fake *FakeStateManagerI) RetrieveResourcesReturnsOnCall(i int, result1 []s
 func (fake *FakeStateManagerI) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.parseStateMutex.RLock()
+	defer fake.parseStateMutex.RUnlock()
 	fake.parseStateFileMutex.RLock()
 	defer fake.parseStateFileMutex.RUnlock()
 	fake.retrieveResourcesMutex.RLock()
@@ -0,0 +1,122 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// benchmarkResources is the number of resource instances of the benchmark state,
+// the size of a large production workspace.
+const benchmarkResources = 5000
+
+// benchmarkState returns the JSON of a state holding n aws_instance instances, spread
+// over modules and count indexes, with the nested blocks, tags and sensitive
+// attributes a real instance carries.
+func benchmarkState(b *testing.B, n int) []byte {
+	b.Helper()
+	const perResource = 10
+	var resources []terraform.Resource
+	for r := 0; r*perResource < n; r++ {
+		resource := terraform.Resource{
+			Mode:     "managed",
+			Type:     "aws_instance",
+			Name:     fmt.Sprintf("web_%d", r),
+			Provider: `provider["registry.terraform.io/hashicorp/aws"]`,
+		}
+		if r%2 == 1 {
+			resource.Module = fmt.Sprintf("module.service_%d", r%50)
+		}
+		for i := 0; i < perResource && r*perResource+i < n; i++ {
+			id := r*perResource + i
+			resource.Instances = append(resource.Instances, terraform.Instance{
+				SchemaVersion: 1,
+				IndexKey:      float64(i),
+				Attributes: map[string]any{
+					"id":                     fmt.Sprintf("i-%017x", id),
+					"ami":                    "ami-0c55b159cbfafe1f0",
+					"instance_type":          "t3.micro",
+					"availability_zone":      "us-east-1a",
+					"subnet_id":              fmt.Sprintf("subnet-%08x", id%64),
+					"vpc_security_group_ids": []any{fmt.Sprintf("sg-%08x", id%32), "sg-0000000a"},
+					"private_ip":             fmt.Sprintf("10.0.%d.%d", id/256%256, id%256),
+					"monitoring":             false,
+					"ebs_optimized":          true,
+					"user_data":              "2b4b6aaaf5e4f8a2d4f8f0f0e2d0c2a4b6c8d0e2",
+					"password_data":          "",
+					"tags":                   map[string]any{"Name": fmt.Sprintf("web-%d", id), "Environment": "production", "Team": "platform"},
+					"tags_all":               map[string]any{"Name": fmt.Sprintf("web-%d", id), "Environment": "production", "Team": "platform", "ManagedBy": "terraform"},
+					"root_block_device": []any{map[string]any{
+						"volume_size": float64(20),
+						"volume_type": "gp3",
+						"encrypted":   true,
+						"iops":        float64(3000),
+					}},
+					"metadata_options": []any{map[string]any{
+						"http_endpoint": "enabled",
+						"http_tokens":   "required",
+					}},
+				},
+				SensitiveAttributes: []any{[]any{map[string]any{"type": "get_attr", "value": "password_data"}}},
+				Dependencies:        []string{"aws_security_group.web", "aws_subnet.private"},
+			})
+		}
+		resources = append(resources, resource)
+	}
+	data, err := json.Marshal(terraform.TerraformState{
+		Version:          4,
+		TerraformVersion: "1.9.5",
+		Serial:           42,
+		Lineage:          "3c1c8e7a-6f0a-4d3c-9b7e-2a1f5d6e8b90",
+		Resources:        resources,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkParseState(b *testing.B) {
+	data := benchmarkState(b, benchmarkResources)
+	ctx := context.Background()
+	manager := terraform.NewTerraformManager()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := manager.ParseState(ctx, bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRetrieveResources(b *testing.B) {
+	ctx := context.Background()
+	manager := terraform.NewTerraformManager()
+	content, err := manager.ParseState(ctx, bytes.NewReader(benchmarkState(b, benchmarkResources)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := manager.RetrieveResources(ctx, content, "aws_instance"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateState(b *testing.B) {
+	data := benchmarkState(b, benchmarkResources)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := terraform.ValidateState(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
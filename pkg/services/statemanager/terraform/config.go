@@ -0,0 +1,365 @@
+package terraform
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// configSchema holds the top-level blocks of a Terraform configuration that are
+// evaluated for the desired state. Other blocks are ignored.
+var configSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "locals"},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+	},
+}
+
+// resourceMetaArguments are the arguments of a resource block that configure
+// Terraform rather than the resource, and are not recorded in its attributes.
+var resourceMetaArguments = []string{"count", "for_each", "provider", "depends_on"}
+
+// configFunctions are the functions available to expressions of the configuration,
+// the subset of the Terraform functions implemented by the cty standard library.
+var configFunctions = map[string]function.Function{
+	"abs":        stdlib.AbsoluteFunc,
+	"ceil":       stdlib.CeilFunc,
+	"chomp":      stdlib.ChompFunc,
+	"coalesce":   stdlib.CoalesceFunc,
+	"compact":    stdlib.CompactFunc,
+	"concat":     stdlib.ConcatFunc,
+	"contains":   stdlib.ContainsFunc,
+	"distinct":   stdlib.DistinctFunc,
+	"element":    stdlib.ElementFunc,
+	"flatten":    stdlib.FlattenFunc,
+	"floor":      stdlib.FloorFunc,
+	"format":     stdlib.FormatFunc,
+	"formatlist": stdlib.FormatListFunc,
+	"indent":     stdlib.IndentFunc,
+	"join":       stdlib.JoinFunc,
+	"jsondecode": stdlib.JSONDecodeFunc,
+	"jsonencode": stdlib.JSONEncodeFunc,
+	"keys":       stdlib.KeysFunc,
+	"length":     stdlib.LengthFunc,
+	"lookup":     stdlib.LookupFunc,
+	"lower":      stdlib.LowerFunc,
+	"max":        stdlib.MaxFunc,
+	"merge":      stdlib.MergeFunc,
+	"min":        stdlib.MinFunc,
+	"range":      stdlib.RangeFunc,
+	"replace":    stdlib.ReplaceFunc,
+	"reverse":    stdlib.ReverseListFunc,
+	"setunion":   stdlib.SetUnionFunc,
+	"sort":       stdlib.SortFunc,
+	"split":      stdlib.SplitFunc,
+	"substr":     stdlib.SubstrFunc,
+	"title":      stdlib.TitleFunc,
+	"tolist":     stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":      stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber":   stdlib.MakeToFunc(cty.Number),
+	"toset":      stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring":   stdlib.MakeToFunc(cty.String),
+	"trim":       stdlib.TrimFunc,
+	"trimprefix": stdlib.TrimPrefixFunc,
+	"trimspace":  stdlib.TrimSpaceFunc,
+	"trimsuffix": stdlib.TrimSuffixFunc,
+	"upper":      stdlib.UpperFunc,
+	"values":     stdlib.ValuesFunc,
+	"zipmap":     stdlib.ZipmapFunc,
+}
+
+// ConfigOptions sets the input variables of a configuration, on top of the defaults
+// of its variable blocks and the terraform.tfvars and *.auto.tfvars files of its
+// directory.
+type ConfigOptions struct {
+	// VarFiles are .tfvars files read after the files of the directory.
+	VarFiles []string
+	// Vars are string values of variables, as given with -var NAME=VALUE, which take
+	// precedence over the variable files.
+	Vars map[string]string
+}
+
+// LoadConfigResources evaluates the managed resources declared in the root module of
+// the Terraform configuration in dir, giving one resource per resource block with one
+// instance per count index or for_each key, as they would be recorded in the state
+// once applied.
+//
+// Only the top-level arguments of a resource are evaluated; nested blocks are left
+// out, as the state records them with the computed defaults of the provider. Arguments
+// that cannot be evaluated before an apply, such as references to other resources or
+// to variables without a value, are left out too. A resource whose count or for_each
+// cannot be evaluated is returned without instances. Child modules are not loaded.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - dir: Directory holding the .tf files of the root module
+//   - opts: Input variables of the configuration
+//
+// Returns:
+//   - []statemanager.StateResource: The resources of the configuration, sorted by address
+//   - error: Any error encountered while reading or parsing the configuration
+func LoadConfigResources(ctx context.Context, dir string, opts ConfigOptions) ([]statemanager.StateResource, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configuration files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .tf files found in %s", dir)
+	}
+
+	parser := hclparse.NewParser()
+	var blocks hcl.Blocks
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		parsed, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, diags)
+		}
+		content, _, diags := parsed.Body.PartialContent(configSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, diags)
+		}
+		blocks = append(blocks, content.Blocks...)
+	}
+
+	variables, err := configVariables(parser, dir, blocks, opts)
+	if err != nil {
+		return nil, err
+	}
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":       cty.ObjectVal(variables),
+			"path":      cty.ObjectVal(map[string]cty.Value{"module": cty.StringVal(dir), "root": cty.StringVal(dir), "cwd": cty.StringVal(dir)}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{"workspace": cty.StringVal("default")}),
+		},
+		Functions: configFunctions,
+	}
+	evalCtx.Variables["local"] = cty.ObjectVal(configLocals(ctx, evalCtx, blocks))
+
+	var resources []statemanager.StateResource
+	for _, block := range blocks {
+		if block.Type != "resource" {
+			continue
+		}
+		resource, err := configResource(ctx, evalCtx, block)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	slices.SortFunc(resources, func(a, b statemanager.StateResource) int {
+		return strings.Compare(a.Address(), b.Address())
+	})
+	return resources, nil
+}
+
+// configVariables returns the values of the input variables declared in blocks.
+// Variables without a value are unknown, so the arguments using them are not
+// evaluated.
+func configVariables(parser *hclparse.Parser, dir string, blocks hcl.Blocks, opts ConfigOptions) (map[string]cty.Value, error) {
+	variables := map[string]cty.Value{}
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+		name := block.Labels[0]
+		variables[name] = cty.DynamicVal
+		if def, ok := blockArguments(block.Body)["default"]; ok {
+			if value, diags := def.Expr.Value(nil); !diags.HasErrors() {
+				variables[name] = value
+			}
+		}
+	}
+
+	varFiles, err := filepath.Glob(filepath.Join(dir, "*.auto.tfvars"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variable files: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "terraform.tfvars")); err == nil {
+		varFiles = append([]string{filepath.Join(dir, "terraform.tfvars")}, varFiles...)
+	}
+	for _, file := range append(varFiles, opts.VarFiles...) {
+		parsed, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse variable file %s: %w", file, diags)
+		}
+		attributes, diags := parsed.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse variable file %s: %w", file, diags)
+		}
+		for name, attribute := range attributes {
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("failed to evaluate variable %s in %s: %w", name, file, diags)
+			}
+			variables[name] = value
+		}
+	}
+	for name, value := range opts.Vars {
+		variables[name] = cty.StringVal(value)
+	}
+	return variables, nil
+}
+
+// configLocals evaluates the locals declared in blocks. Locals may refer to each
+// other, so they are evaluated in passes until no more can be; those left are
+// unknown.
+func configLocals(ctx context.Context, evalCtx *hcl.EvalContext, blocks hcl.Blocks) map[string]cty.Value {
+	pending := map[string]*hcl.Attribute{}
+	for _, block := range blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attributes, _ := block.Body.JustAttributes()
+		maps.Copy(pending, attributes)
+	}
+
+	locals := map[string]cty.Value{}
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		scope := evalCtx.NewChild()
+		scope.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+		for name, attribute := range pending {
+			value, diags := attribute.Expr.Value(scope)
+			if diags.HasErrors() {
+				continue
+			}
+			locals[name] = value
+			delete(pending, name)
+			progress = true
+		}
+	}
+	for name := range pending {
+		logger(ctx).Debug("Local value not evaluated", "local", name)
+		locals[name] = cty.DynamicVal
+	}
+	return locals
+}
+
+// configResource evaluates a resource block into a resource with one instance per
+// count index or for_each key.
+func configResource(ctx context.Context, evalCtx *hcl.EvalContext, block *hcl.Block) (statemanager.StateResource, error) {
+	resource := statemanager.StateResource{Mode: "managed", Type: block.Labels[0], Name: block.Labels[1]}
+	attributes := blockArguments(block.Body)
+
+	keys, ok := instanceKeys(evalCtx, attributes)
+	if !ok {
+		logger(ctx).Debug("Instances of resource not evaluated", "resource", resource.Address())
+		return resource, nil
+	}
+	for _, key := range keys {
+		scope := evalCtx.NewChild()
+		scope.Variables = key.variables
+		instance := statemanager.ResourceInstance{IndexKey: key.index, Attributes: map[string]any{}}
+		for name, attribute := range attributes {
+			if slices.Contains(resourceMetaArguments, name) {
+				continue
+			}
+			value, diags := attribute.Expr.Value(scope)
+			if diags.HasErrors() || !value.IsWhollyKnown() {
+				continue
+			}
+			converted, err := CtyValueToGo(value)
+			if err != nil {
+				return resource, fmt.Errorf("failed to convert %s.%s: %w", resource.Address(), name, err)
+			}
+			instance.Attributes[name] = stateValue(converted)
+		}
+		resource.Instances = append(resource.Instances, instance)
+	}
+	return resource, nil
+}
+
+// blockArguments returns the arguments of a block body, without its nested blocks,
+// which JustAttributes would reject.
+func blockArguments(body hcl.Body) hcl.Attributes {
+	attributes := hcl.Attributes{}
+	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
+		for name, attribute := range syntaxBody.Attributes {
+			attributes[name] = attribute.AsHCLAttribute()
+		}
+	}
+	return attributes
+}
+
+// instanceKey is an instance of a resource: its count index or for_each key, and the
+// count or each object its arguments are evaluated with.
+type instanceKey struct {
+	index     any
+	variables map[string]cty.Value
+}
+
+// instanceKeys evaluates the count or for_each argument of a resource. ok is false
+// when it cannot be evaluated.
+func instanceKeys(evalCtx *hcl.EvalContext, attributes hcl.Attributes) (keys []instanceKey, ok bool) {
+	if count, found := attributes["count"]; found {
+		value, diags := count.Expr.Value(evalCtx)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || value.Type() != cty.Number {
+			return nil, false
+		}
+		n, _ := value.AsBigFloat().Int64()
+		for i := range n {
+			keys = append(keys, instanceKey{
+				index:     float64(i),
+				variables: map[string]cty.Value{"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(i)})},
+			})
+		}
+		return keys, true
+	}
+
+	if forEach, found := attributes["for_each"]; found {
+		value, diags := forEach.Expr.Value(evalCtx)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || !value.CanIterateElements() {
+			return nil, false
+		}
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			if value.Type().IsSetType() || value.Type().IsListType() || value.Type().IsTupleType() {
+				key = element
+			}
+			if key.Type() != cty.String {
+				return nil, false
+			}
+			keys = append(keys, instanceKey{
+				index:     key.AsString(),
+				variables: map[string]cty.Value{"each": cty.ObjectVal(map[string]cty.Value{"key": key, "value": element})},
+			})
+		}
+		return keys, true
+	}
+
+	return []instanceKey{{}}, true
+}
+
+// stateValue converts a value evaluated from the configuration to the form the state
+// records it in, where every number is a float64.
+func stateValue(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case []any:
+		for i := range v {
+			v[i] = stateValue(v[i])
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = stateValue(v[key])
+		}
+	}
+	return value
+}
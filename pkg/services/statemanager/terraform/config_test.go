@@ -0,0 +1,123 @@
+package terraform_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadConfigResources(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"main.tf": `
+variable "environment" {
+  default = "dev"
+  validation {
+    condition     = length(var.environment) > 0
+    error_message = "required"
+  }
+}
+
+variable "instance_type" {}
+
+variable "ami" {}
+
+locals {
+  name = "${local.prefix}-web"
+  prefix = upper(var.environment)
+}
+
+resource "aws_instance" "web" {
+  ami           = var.ami
+  instance_type = var.instance_type
+  subnet_id     = aws_subnet.main.id
+  monitoring    = true
+  volume_size   = 20
+  tags = {
+    Name        = local.name
+    Environment = var.environment
+  }
+
+  root_block_device {
+    volume_size = 8
+  }
+
+  depends_on = [aws_subnet.main]
+}
+`,
+		"network.tf": `
+resource "aws_subnet" "main" {
+  count      = 2
+  cidr_block = "10.0.${count.index}.0/24"
+}
+
+resource "aws_s3_bucket" "logs" {
+  for_each = toset(["app", "audit"])
+  bucket   = "logs-${each.key}"
+}
+
+resource "aws_eip" "web" {
+  count    = length(aws_subnet.main[*].id)
+}
+`,
+		"terraform.tfvars":     `environment = "prod"`,
+		"override.auto.tfvars": `instance_type = "t3.micro"`,
+	})
+
+	resources, err := terraform.LoadConfigResources(context.Background(), dir, terraform.ConfigOptions{
+		Vars: map[string]string{"instance_type": "t3.large"},
+	})
+	require.NoError(t, err)
+
+	addresses := []string{}
+	for _, resource := range statemanager.ExpandInstances(resources) {
+		addresses = append(addresses, resource.Address())
+	}
+	assert.Equal(t, []string{
+		"aws_eip.web",
+		"aws_instance.web",
+		`aws_s3_bucket.logs["app"]`,
+		`aws_s3_bucket.logs["audit"]`,
+		"aws_subnet.main[0]",
+		"aws_subnet.main[1]",
+	}, addresses)
+
+	assert.Empty(t, resources[0].Instances, "count depends on other resources")
+	assert.Equal(t, map[string]any{
+		"instance_type": "t3.large",
+		"monitoring":    true,
+		"volume_size":   float64(20),
+		"tags":          map[string]any{"Name": "PROD-web", "Environment": "prod"},
+	}, resources[1].Instances[0].Attributes)
+	assert.Equal(t, "logs-audit", resources[2].Instances[1].Attributes["bucket"])
+	assert.Equal(t, "audit", resources[2].Instances[1].IndexKey)
+	assert.Equal(t, "10.0.1.0/24", resources[3].Instances[1].Attributes["cidr_block"])
+	assert.Equal(t, float64(1), resources[3].Instances[1].IndexKey)
+}
+
+func TestLoadConfigResources_Errors(t *testing.T) {
+	_, err := terraform.LoadConfigResources(context.Background(), t.TempDir(), terraform.ConfigOptions{})
+	assert.ErrorContains(t, err, "no .tf files found")
+
+	dir := writeConfig(t, map[string]string{"main.tf": `resource "aws_instance" {`})
+	_, err = terraform.LoadConfigResources(context.Background(), dir, terraform.ConfigOptions{})
+	assert.ErrorContains(t, err, "failed to parse")
+
+	dir = writeConfig(t, map[string]string{"main.tf": `variable "name" {}`})
+	_, err = terraform.LoadConfigResources(context.Background(), dir, terraform.ConfigOptions{VarFiles: []string{filepath.Join(dir, "missing.tfvars")}})
+	assert.ErrorContains(t, err, "failed to parse variable file")
+}
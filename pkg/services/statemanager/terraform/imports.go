@@ -0,0 +1,31 @@
+package terraform
+
+import (
+	"drift-watcher/pkg/services/driftchecker"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ImportAddress builds a valid Terraform resource address for an unmanaged resource,
+// deriving the resource name from its live identifier.
+func ImportAddress(resourceType string, resourceId string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, resourceId)
+	return fmt.Sprintf("%s.unmanaged_%s", resourceType, name)
+}
+
+// SuggestImport returns an import block (Terraform 1.5+) and the equivalent
+// `terraform import` command for a live resource that is missing from the state.
+func SuggestImport(resourceType string, resourceId string) *driftchecker.ImportSuggestion {
+	address := ImportAddress(resourceType, resourceId)
+	return &driftchecker.ImportSuggestion{
+		Address: address,
+		Block:   fmt.Sprintf("import {\n  to = %s\n  id = %q\n}\n", address, resourceId),
+		Command: fmt.Sprintf("terraform import %s %s", address, resourceId),
+	}
+}
@@ -0,0 +1,21 @@
+package terraform_test
+
+import (
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportAddress(t *testing.T) {
+	assert.Equal(t, "aws_instance.unmanaged_i-0abc123", terraform.ImportAddress("aws_instance", "i-0abc123"))
+	assert.Equal(t, "aws_s3_bucket.unmanaged_my_bucket_logs", terraform.ImportAddress("aws_s3_bucket", "my.bucket/logs"))
+}
+
+func TestSuggestImport(t *testing.T) {
+	suggestion := terraform.SuggestImport("aws_instance", "i-0abc123")
+
+	assert.Equal(t, "aws_instance.unmanaged_i-0abc123", suggestion.Address)
+	assert.Equal(t, "import {\n  to = aws_instance.unmanaged_i-0abc123\n  id = \"i-0abc123\"\n}\n", suggestion.Block)
+	assert.Equal(t, "terraform import aws_instance.unmanaged_i-0abc123 i-0abc123", suggestion.Command)
+}
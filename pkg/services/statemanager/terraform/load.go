@@ -0,0 +1,68 @@
+package terraform
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// LoadStateParser reads the state at statePath into a new StateParser. statePath may be
+// a local .tfstate or .tf file, a remote URI fetched with fetcher, or
+// statemanager.StdinStatePath to read the state from stdin.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - statePath: Path or URI of the state, or "-" for stdin
+//   - stdin: Reader the state is read from when statePath is "-"
+//   - fetcher: Fetcher used for remote URIs
+//
+// Returns:
+//   - *StateParser: A parser holding the parsed state
+//   - error: Any error encountered while reading or parsing the state
+func LoadStateParser(ctx context.Context, statePath string, stdin io.Reader, fetcher remote.Fetcher) (*StateParser, error) {
+	parser := NewStateParser()
+
+	switch {
+	case statePath == statemanager.StdinStatePath:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read state")
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("state is empty")
+		}
+		if err := parser.ParseBytes(data); err != nil {
+			return nil, err
+		}
+	case remote.IsRemote(statePath):
+		data, err := fetcher.Fetch(ctx, statePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.ParseBytes(data); err != nil {
+			return nil, err
+		}
+	default:
+		if err := parser.ParseFile(ctx, statePath); err != nil {
+			return nil, err
+		}
+	}
+	return parser, nil
+}
+
+// InstanceAddress returns the address of a resource instance, e.g.
+// module.network.aws_instance.web[0] or data.aws_ami.ubuntu. The index is only
+// included for resources created with count or for_each.
+func InstanceAddress(resource Resource, instance Instance) string {
+	return statemanager.StateResource{
+		Mode:      resource.Mode,
+		Module:    resource.Module,
+		Type:      resource.Type,
+		Name:      resource.Name,
+		Instances: []statemanager.ResourceInstance{{IndexKey: instance.IndexKey}},
+	}.Address()
+}
@@ -0,0 +1,202 @@
+package terraform
+
+import (
+	"drift-watcher/pkg/services/statemanager"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Resource types that hold security group rules outside of their group.
+const (
+	securityGroupType            = "aws_security_group"
+	securityGroupRuleType        = "aws_security_group_rule"
+	securityGroupIngressRuleType = "aws_vpc_security_group_ingress_rule"
+	securityGroupEgressRuleType  = "aws_vpc_security_group_egress_rule"
+)
+
+// mergeSecurityGroupRules adds the rules of the aws_security_group_rule,
+// aws_vpc_security_group_ingress_rule and aws_vpc_security_group_egress_rule resources
+// of the state to the ingress and egress attributes of the groups they belong to, and
+// normalizes every rule with NormalizeSecurityGroupRules. Moving rules between inline
+// blocks and rule resources therefore leaves the rules of a group unchanged. The
+// attributes of groups are copied, the parsed state is not modified.
+func mergeSecurityGroupRules(groups []statemanager.StateResource, state []Resource) []statemanager.StateResource {
+	rules := map[string]map[string][]any{}
+	for _, resource := range state {
+		for _, instance := range resource.Instances {
+			direction, rule, ok := securityGroupRule(resource.Type, instance.Attributes)
+			if !ok {
+				continue
+			}
+			groupId, _ := instance.Attributes["security_group_id"].(string)
+			if rules[groupId] == nil {
+				rules[groupId] = map[string][]any{}
+			}
+			rules[groupId][direction] = append(rules[groupId][direction], rule)
+		}
+	}
+
+	for i, group := range groups {
+		instances := slices.Clone(group.Instances)
+		for j, instance := range instances {
+			attributes := maps.Clone(instance.Attributes)
+			groupId, _ := attributes["id"].(string)
+			for _, direction := range []string{"ingress", "egress"} {
+				inline, _ := attributes[direction].([]any)
+				merged := append(slices.Clone(inline), rules[groupId][direction]...)
+				if _, ok := attributes[direction]; ok || len(merged) > 0 {
+					attributes[direction] = NormalizeSecurityGroupRules(groupId, merged)
+				}
+			}
+			instances[j].Attributes = attributes
+		}
+		groups[i].Instances = instances
+	}
+	return groups
+}
+
+// securityGroupRule converts the attributes of a rule resource to an inline rule
+// block, and returns whether it is an ingress or egress rule. ok is false for
+// resources that are not rules.
+func securityGroupRule(resourceType string, attributes map[string]any) (direction string, rule map[string]any, ok bool) {
+	switch resourceType {
+	case securityGroupRuleType:
+		direction, _ = attributes["type"].(string)
+		rule = map[string]any{
+			"cidr_blocks":      attributes["cidr_blocks"],
+			"ipv6_cidr_blocks": attributes["ipv6_cidr_blocks"],
+			"prefix_list_ids":  attributes["prefix_list_ids"],
+			"security_groups":  optionalList(attributes["source_security_group_id"]),
+			"self":             attributes["self"],
+			"description":      attributes["description"],
+			"protocol":         attributes["protocol"],
+			"from_port":        attributes["from_port"],
+			"to_port":          attributes["to_port"],
+		}
+	case securityGroupIngressRuleType, securityGroupEgressRuleType:
+		direction = "ingress"
+		if resourceType == securityGroupEgressRuleType {
+			direction = "egress"
+		}
+		rule = map[string]any{
+			"cidr_blocks":      optionalList(attributes["cidr_ipv4"]),
+			"ipv6_cidr_blocks": optionalList(attributes["cidr_ipv6"]),
+			"prefix_list_ids":  optionalList(attributes["prefix_list_id"]),
+			"security_groups":  optionalList(attributes["referenced_security_group_id"]),
+			"description":      attributes["description"],
+			"protocol":         attributes["ip_protocol"],
+			"from_port":        attributes["from_port"],
+			"to_port":          attributes["to_port"],
+		}
+	default:
+		return "", nil, false
+	}
+	return direction, rule, direction == "ingress" || direction == "egress"
+}
+
+// NormalizeSecurityGroupRules returns the rules of the security group groupId, given
+// as inline rule blocks, in a canonical form: every rule is split into one rule per
+// source (CIDR block, IPv6 CIDR block, prefix list, security group or self), a source
+// naming the group itself is written as self, protocols are lower-cased with all and
+// -1 both written as -1, ports of rules for all protocols are zero, and duplicates are
+// removed. Rules are sorted, so equal sets of rules are equal lists whether they were
+// written as a few rules with many sources or as many rules with one source each. The
+// AWS provider normalizes the live rules of a group the same way.
+func NormalizeSecurityGroupRules(groupId string, rules []any) []any {
+	seen := map[string]bool{}
+	var normalized []any
+	for _, item := range rules {
+		rule, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		protocol := strings.ToLower(stringValue(rule["protocol"]))
+		fromPort, toPort := numberValue(rule["from_port"]), numberValue(rule["to_port"])
+		if protocol == "all" || protocol == "-1" {
+			protocol, fromPort, toPort = "-1", 0, 0
+		}
+		base := map[string]any{
+			"cidr_blocks":      []any{},
+			"ipv6_cidr_blocks": []any{},
+			"prefix_list_ids":  []any{},
+			"security_groups":  []any{},
+			"self":             false,
+			"description":      stringValue(rule["description"]),
+			"protocol":         protocol,
+			"from_port":        fromPort,
+			"to_port":          toPort,
+		}
+
+		var atomic []map[string]any
+		self, _ := rule["self"].(bool)
+		for _, key := range []string{"cidr_blocks", "ipv6_cidr_blocks", "prefix_list_ids", "security_groups"} {
+			for _, source := range listValue(rule[key]) {
+				if key == "security_groups" && groupId != "" && source == groupId {
+					self = true
+					continue
+				}
+				single := maps.Clone(base)
+				single[key] = []any{source}
+				atomic = append(atomic, single)
+			}
+		}
+		if self {
+			single := maps.Clone(base)
+			single["self"] = true
+			atomic = append(atomic, single)
+		}
+
+		for _, single := range atomic {
+			encoded, _ := json.Marshal(single)
+			if seen[string(encoded)] {
+				continue
+			}
+			seen[string(encoded)] = true
+			normalized = append(normalized, single)
+		}
+	}
+
+	slices.SortFunc(normalized, func(a, b any) int {
+		encodedA, _ := json.Marshal(a)
+		encodedB, _ := json.Marshal(b)
+		return strings.Compare(string(encodedA), string(encodedB))
+	})
+	if normalized == nil {
+		normalized = []any{}
+	}
+	return normalized
+}
+
+// optionalList wraps a single optional value in a list, so that it reads like the
+// list attributes of inline rules.
+func optionalList(value any) []any {
+	if s, ok := value.(string); ok && s != "" {
+		return []any{s}
+	}
+	return nil
+}
+
+func listValue(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// numberValue returns a port as a JSON number. Rules of all protocols may leave
+// their ports unset.
+func numberValue(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return 0
+}
@@ -4,27 +4,53 @@
 package terraform
 
 import (
+	"bytes"
 	"context"
+	"drift-watcher/pkg/logging"
 	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/remote"
+	"drift-watcher/pkg/telemetry"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TerraformStateManager implements the StateManagerI interface for Terraform state files.
 // It provides functionality to parse Terraform state files and extract resource information
 // in a standardized format for drift detection.
 type TerraformStateManager struct {
-	parser *StateParser
+	parser  *StateParser
+	fetcher remote.Fetcher
 }
 
-func NewTerraformManager() *TerraformStateManager {
-	return &TerraformStateManager{
-		parser: NewStateParser(),
+// ManagerOption configures a TerraformStateManager.
+type ManagerOption func(*TerraformStateManager)
+
+// WithFetcher sets the fetcher used for state paths that are remote URIs
+// (http, https, s3 and gs).
+func WithFetcher(fetcher remote.Fetcher) ManagerOption {
+	return func(t *TerraformStateManager) {
+		t.fetcher = fetcher
+	}
+}
+
+func NewTerraformManager(opts ...ManagerOption) *TerraformStateManager {
+	t := &TerraformStateManager{
+		parser:  NewStateParser(),
+		fetcher: remote.NewFetcher(remote.Options{}),
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
+	return t
 }
 
 // ParseStateFile parses a Terraform state file from the specified path and converts it
@@ -33,14 +59,32 @@ func NewTerraformManager() *TerraformStateManager {
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout control
-//   - statePath: File system path to the Terraform state file (.tfstate)
+//   - statePath: File system path to the Terraform state file (.tfstate), or a remote
+//     http, https, s3 or gs URI
 //
 // Returns:
 //   - statemanager.StateContent: Parsed and standardized state content
 //   - error: Any error encountered during file reading, parsing, or conversion
-func (t *TerraformStateManager) ParseStateFile(ctx context.Context, statePath string) (statemanager.StateContent, error) {
-	var out statemanager.StateContent
-	_, err := os.Stat(statePath)
+func (t *TerraformStateManager) ParseStateFile(ctx context.Context, statePath string) (out statemanager.StateContent, err error) {
+	_, span := telemetry.StartSpan(ctx, "TerraformStateManager.ParseStateFile", attribute.String("drift.state_path", statePath))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return out, err
+	}
+
+	if remote.IsRemote(statePath) {
+		data, err := t.fetcher.Fetch(ctx, statePath)
+		if err != nil {
+			return out, err
+		}
+		return t.ParseState(ctx, bytes.NewReader(data))
+	}
+
+	_, err = os.Stat(statePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return out, errors.Wrap(err, "state file does not exist")
@@ -48,7 +92,7 @@ func (t *TerraformStateManager) ParseStateFile(ctx context.Context, statePath st
 		return out, errors.Wrap(err, "Failed to retrieve file info for tfstate file")
 	}
 
-	if err := t.parser.ParseFile(statePath); err != nil {
+	if err := t.parser.ParseFile(ctx, statePath); err != nil {
 		return out, err
 	}
 
@@ -60,6 +104,43 @@ func (t *TerraformStateManager) ParseStateFile(ctx context.Context, statePath st
 	return statecontent, nil
 }
 
+// ParseState parses Terraform state JSON read from r, such as the output of
+// `terraform state pull` piped to standard input, and converts it to a standardized
+// StateContent format.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - r: Reader supplying the Terraform state JSON
+//
+// Returns:
+//   - statemanager.StateContent: Parsed and standardized state content
+//   - error: Any error encountered during reading, parsing, or conversion
+func (t *TerraformStateManager) ParseState(ctx context.Context, r io.Reader) (out statemanager.StateContent, err error) {
+	_, span := telemetry.StartSpan(ctx, "TerraformStateManager.ParseState")
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return out, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return out, errors.Wrap(err, "failed to read state")
+	}
+	if len(data) == 0 {
+		return out, fmt.Errorf("state is empty")
+	}
+
+	if err := t.parser.ParseBytes(data); err != nil {
+		return out, err
+	}
+
+	return ConvertTerraformStateToStateContent(*t.parser.State)
+}
+
 // ConvertTerraformStateToStateContent converts a TerraformState object to a StateContent object.
 // This function maps Terraform-specific state structure to the standardized StateContent format
 // used throughout the drift detection system. It handles version conversion, resource mapping,
@@ -104,15 +185,22 @@ func ConvertTerraformStateToStateContent(tfState TerraformState) (statemanager.S
 		// Convert Instances
 		for _, inst := range res.Instances {
 			stateInst := statemanager.ResourceInstance{
-				ScheamVersion: inst.SchemaVersion,
-				Attributes:    inst.Attributes,
-				Dependencies:  inst.Dependencies,
+				ScheamVersion:       inst.SchemaVersion,
+				Attributes:          inst.Attributes,
+				Dependencies:        inst.Dependencies,
+				IndexKey:            inst.IndexKey,
+				SensitiveAttributes: sensitiveAttributeNames(inst.SensitiveAttributes),
 			}
 			stateRes.Instances = append(stateRes.Instances, stateInst)
 		}
 		newState.Resource = append(newState.Resource, stateRes)
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(tfState.Outputs)) {
+		output := tfState.Outputs[name]
+		newState.Outputs = append(newState.Outputs, statemanager.StateOutput{Name: name, Value: output.Value, Sensitive: output.Sensitive})
+	}
+
 	// Marshal the original state (or parts of it) into RawState
 	// For simplicity, we'll marshal the entire original state into RawState.
 	// You might want to selectively include parts like Outputs, CheckResults, Modules here.
@@ -125,6 +213,26 @@ func ConvertTerraformStateToStateContent(tfState TerraformState) (statemanager.S
 	return newState, nil
 }
 
+// sensitiveAttributeNames extracts the top-level attribute names from Terraform's
+// sensitive_attributes paths, e.g. [{"type": "get_attr", "value": "password"}].
+func sensitiveAttributeNames(paths []any) []string {
+	var names []string
+	for _, p := range paths {
+		steps, ok := p.([]any)
+		if !ok || len(steps) == 0 {
+			continue
+		}
+		step, ok := steps[0].(map[string]any)
+		if !ok || step["type"] != "get_attr" {
+			continue
+		}
+		if name, ok := step["value"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // RetrieveResources retrieves all resources of a specific type from the parsed state content.
 // This method filters the parsed state to return only resources matching the specified type,
 // which is useful for targeted drift detection on specific resource types.
@@ -138,9 +246,22 @@ func ConvertTerraformStateToStateContent(tfState TerraformState) (statemanager.S
 //   - []statemanager.StateResource: List of resources matching the specified type
 //   - error: Any error encountered during resource retrieval
 func (t *TerraformStateManager) RetrieveResources(ctx context.Context, content statemanager.StateContent, resourceType string) ([]statemanager.StateResource, error) {
+	_, span := telemetry.StartSpan(ctx, "TerraformStateManager.RetrieveResources", attribute.String("drift.resource_type", resourceType))
+	defer span.End()
+
 	if t.parser == nil {
 		return nil, fmt.Errorf("")
 	}
 	resources := t.parser.GetResourcesByType(resourceType)
+	if resourceType == securityGroupType {
+		// rules split into rule resources are compared as part of their group
+		resources = mergeSecurityGroupRules(resources, t.parser.GetResources())
+	}
+	span.SetAttributes(attribute.Int("drift.resource_count", len(resources)))
 	return resources, nil
 }
+
+// logger returns the logger carried by ctx for the terraform module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "terraform")
+}
@@ -3,9 +3,11 @@ package terraform_test
 import (
 	"context"
 	"drift-watcher/pkg/services/statemanager"
+	"drift-watcher/pkg/services/statemanager/remote/remotefakes"
 	"drift-watcher/pkg/services/statemanager/terraform"
 	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -155,6 +157,84 @@ func TestParseStateFile_DirectoryPath(t *testing.T) {
 	assert.Contains(t, err.Error(), "Terraform state directories are not currently supported")
 }
 
+func TestParseState_FromReader(t *testing.T) {
+	state := `{
+		"version": 4,
+		"terraform_version": "1.5.0",
+		"serial": 3,
+		"lineage": "piped-lineage",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{"schema_version": 1, "attributes": {"id": "i-123", "instance_type": "t2.micro"}}]
+			}
+		]
+	}`
+
+	manager := terraform.NewTerraformManager()
+	content, err := manager.ParseState(context.Background(), strings.NewReader(state))
+	require.NoError(t, err)
+	assert.Equal(t, "piped-lineage", content.StateId)
+	require.Len(t, content.Resource, 1)
+	assert.Equal(t, "web", content.Resource[0].Name)
+
+	resources, err := manager.RetrieveResources(context.Background(), content, "aws_instance")
+	require.NoError(t, err)
+	assert.Len(t, resources, 1)
+}
+
+func TestParseStateFile_RemoteURI(t *testing.T) {
+	fetcher := &remotefakes.FakeFetcher{}
+	fetcher.FetchReturns([]byte(`{"version": 4, "lineage": "remote-lineage"}`), nil)
+
+	manager := terraform.NewTerraformManager(terraform.WithFetcher(fetcher))
+	content, err := manager.ParseStateFile(context.Background(), "https://artifacts.example.com/prod.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, "remote-lineage", content.StateId)
+
+	_, uri := fetcher.FetchArgsForCall(0)
+	assert.Equal(t, "https://artifacts.example.com/prod.tfstate", uri)
+}
+
+func TestParseState_SensitiveAttributes(t *testing.T) {
+	state := `{
+		"version": 4,
+		"lineage": "db-lineage",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_db_instance",
+				"name": "db",
+				"instances": [{
+					"attributes": {"id": "db-1", "password": "hunter2"},
+					"sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]
+				}]
+			}
+		]
+	}`
+
+	content, err := terraform.NewTerraformManager().ParseState(context.Background(), strings.NewReader(state))
+	require.NoError(t, err)
+	require.Len(t, content.Resource, 1)
+	assert.Equal(t, []string{"password"}, content.Resource[0].SensitiveAttributes())
+}
+
+func TestParseState_EmptyReader(t *testing.T) {
+	manager := terraform.NewTerraformManager()
+	_, err := manager.ParseState(context.Background(), strings.NewReader(""))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "state is empty")
+}
+
+func TestParseState_InvalidJSON(t *testing.T) {
+	manager := terraform.NewTerraformManager()
+	_, err := manager.ParseState(context.Background(), strings.NewReader("not json"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unmarshal JSON")
+}
+
 func TestConvertTerraformStateToStateContent_MarshalError(t *testing.T) {
 	// Create a TerraformState that will cause json.Marshal to fail (e.g., a channel)
 	type BadStruct struct {
@@ -170,6 +250,23 @@ func TestConvertTerraformStateToStateContent_MarshalError(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to marshal raw state")
 }
 
+func TestConvertTerraformStateToStateContent_Outputs(t *testing.T) {
+	tfState := terraform.TerraformState{
+		Version: 4,
+		Outputs: map[string]terraform.Output{
+			"web_ip":   {Value: "203.0.113.10"},
+			"db_token": {Value: "secret", Sensitive: true},
+		},
+	}
+
+	content, err := terraform.ConvertTerraformStateToStateContent(tfState)
+	require.NoError(t, err)
+	assert.Equal(t, []statemanager.StateOutput{
+		{Name: "db_token", Value: "secret", Sensitive: true},
+		{Name: "web_ip", Value: "203.0.113.10"},
+	}, content.Outputs)
+}
+
 func TestRetrieveResources_Success(t *testing.T) {
 	// Setup a dummy state file with resources
 	dummyStateContent := `{
@@ -277,3 +374,98 @@ func TestRetrieveResources_EmptyState(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, resources)
 }
+
+func TestRetrieveResources_SecurityGroupRules(t *testing.T) {
+	inline := `{
+		"version": 4,
+		"lineage": "inline",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_security_group",
+				"name": "web",
+				"instances": [{"attributes": {
+					"id": "sg-1",
+					"ingress": [{"protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_blocks": ["10.0.0.0/8", "0.0.0.0/0"], "description": "", "self": false}],
+					"egress": [{"protocol": "-1", "from_port": 0, "to_port": 0, "cidr_blocks": ["0.0.0.0/0"], "self": false}]
+				}}]
+			}
+		]
+	}`
+	split := `{
+		"version": 4,
+		"lineage": "split",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_security_group",
+				"name": "web",
+				"instances": [{"attributes": {"id": "sg-1", "ingress": [], "egress": []}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_security_group_rule",
+				"name": "https",
+				"instances": [{"attributes": {"security_group_id": "sg-1", "type": "ingress", "protocol": "TCP", "from_port": 443, "to_port": 443, "cidr_blocks": ["0.0.0.0/0"]}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_vpc_security_group_ingress_rule",
+				"name": "https_internal",
+				"instances": [{"attributes": {"security_group_id": "sg-1", "ip_protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_ipv4": "10.0.0.0/8"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_vpc_security_group_egress_rule",
+				"name": "all",
+				"instances": [{"attributes": {"security_group_id": "sg-1", "ip_protocol": "-1", "from_port": null, "to_port": null, "cidr_ipv4": "0.0.0.0/0"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_security_group_rule",
+				"name": "other",
+				"instances": [{"attributes": {"security_group_id": "sg-2", "type": "ingress", "protocol": "tcp", "from_port": 22, "to_port": 22, "cidr_blocks": ["10.0.0.0/8"]}}]
+			}
+		]
+	}`
+
+	retrieve := func(state string) statemanager.StateResource {
+		manager := terraform.NewTerraformManager()
+		content, err := manager.ParseState(context.Background(), strings.NewReader(state))
+		require.NoError(t, err)
+		groups, err := manager.RetrieveResources(context.Background(), content, "aws_security_group")
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		return groups[0]
+	}
+	inlineGroup, splitGroup := retrieve(inline), retrieve(split)
+
+	for _, attribute := range []string{"ingress", "egress"} {
+		inlineRules, err := inlineGroup.AttributeValue(attribute)
+		require.NoError(t, err)
+		splitRules, err := splitGroup.AttributeValue(attribute)
+		require.NoError(t, err)
+		assert.Equal(t, inlineRules, splitRules, attribute)
+	}
+
+	ingress := splitGroup.Instances[0].Attributes["ingress"].([]any)
+	require.Len(t, ingress, 2)
+	assert.Equal(t, []any{"0.0.0.0/0"}, ingress[0].(map[string]any)["cidr_blocks"])
+	assert.Equal(t, []any{"10.0.0.0/8"}, ingress[1].(map[string]any)["cidr_blocks"])
+}
+
+func TestNormalizeSecurityGroupRules(t *testing.T) {
+	rules := terraform.NormalizeSecurityGroupRules("sg-1", []any{
+		map[string]any{"protocol": "all", "from_port": 0.0, "to_port": 65535.0, "self": true, "security_groups": []any{"sg-2"}},
+		map[string]any{"protocol": "-1", "security_groups": []any{"sg-2", "sg-1"}},
+	})
+	require.Len(t, rules, 2)
+	for _, rule := range rules {
+		assert.Equal(t, "-1", rule.(map[string]any)["protocol"])
+		assert.Equal(t, 0.0, rule.(map[string]any)["to_port"])
+	}
+	assert.Equal(t, []any{"sg-2"}, rules[0].(map[string]any)["security_groups"])
+	assert.Equal(t, true, rules[1].(map[string]any)["self"])
+
+	assert.Equal(t, []any{}, terraform.NormalizeSecurityGroupRules("sg-1", nil))
+}
@@ -1,6 +1,8 @@
 package terraform_test
 
 import (
+	"context"
+	"drift-watcher/pkg/logging"
 	"drift-watcher/pkg/services/statemanager/terraform"
 	"log/slog"
 	"os"
@@ -35,7 +37,7 @@ func TestStateFileFromConfig_LocalBackend(t *testing.T) {
 	configFilePath := createTempHCLFile(t, configContent)
 	defer os.Remove(configFilePath)
 
-	statePath, err := terraform.StateFileFromConfig(configFilePath)
+	statePath, err := terraform.StateFileFromConfig(context.Background(), configFilePath)
 	require.NoError(t, err)
 	assert.Equal(t, "path/to/my/terraform.tfstate", statePath)
 }
@@ -50,8 +52,8 @@ func TestStateFileFromConfig_NoLocalBackend(t *testing.T) {
 	configFilePath := createTempHCLFile(t, configContent)
 	defer os.Remove(configFilePath)
 
-	expectedDefaultPath := filepath.Dir(configFilePath) + "/terraform.tfstate"
-	statePath, err := terraform.StateFileFromConfig(configFilePath)
+	expectedDefaultPath := filepath.Join(filepath.Dir(configFilePath), "terraform.tfstate")
+	statePath, err := terraform.StateFileFromConfig(context.Background(), configFilePath)
 	require.NoError(t, err)
 	assert.Equal(t, expectedDefaultPath, statePath)
 }
@@ -64,8 +66,8 @@ func TestStateFileFromConfig_NoBackendBlock(t *testing.T) {
 	configFilePath := createTempHCLFile(t, configContent)
 	defer os.Remove(configFilePath)
 
-	expectedDefaultPath := filepath.Dir(configFilePath) + "/terraform.tfstate"
-	statePath, err := terraform.StateFileFromConfig(configFilePath)
+	expectedDefaultPath := filepath.Join(filepath.Dir(configFilePath), "terraform.tfstate")
+	statePath, err := terraform.StateFileFromConfig(context.Background(), configFilePath)
 	require.NoError(t, err)
 	assert.Equal(t, expectedDefaultPath, statePath)
 }
@@ -80,14 +82,14 @@ func TestStateFileFromConfig_InvalidHCL(t *testing.T) {
 	configFilePath := createTempHCLFile(t, configContent)
 	defer os.Remove(configFilePath)
 
-	_, err := terraform.StateFileFromConfig(configFilePath)
+	_, err := terraform.StateFileFromConfig(context.Background(), configFilePath)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to parse terraform hcl file")
 }
 
 func TestStateFileFromConfig_NonExistentFile(t *testing.T) {
 	path := "/path/to/nonexistent/file.tf"
-	_, err := terraform.StateFileFromConfig(path)
+	_, err := terraform.StateFileFromConfig(context.Background(), path)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to read file; The configuration file \"/path/to/nonexistent/file.tf\" could not be read.")
 }
@@ -276,10 +278,9 @@ func TestStateFileFromConfig_SlogWarning(t *testing.T) {
 
 	// Capture slog output
 	var buf strings.Builder
-	handler := slog.NewTextHandler(&buf, nil)
-	slog.SetDefault(slog.New(handler))
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
 
-	_, err := terraform.StateFileFromConfig(configFilePath)
+	_, err := terraform.StateFileFromConfig(ctx, configFilePath)
 	require.NoError(t, err)
 
 	assert.Contains(t, buf.String(), "level=WARN")
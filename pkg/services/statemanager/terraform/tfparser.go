@@ -1,10 +1,10 @@
 package terraform
 
 import (
+	"context"
 	"drift-watcher/pkg/services/statemanager"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"path/filepath"
 
 	"github.com/hashicorp/hcl/v2"
@@ -14,7 +14,7 @@ import (
 	"github.com/zclconf/go-cty/cty/function"
 )
 
-func StateFileFromConfig(configFilePath string) (string, error) {
+func StateFileFromConfig(ctx context.Context, configFilePath string) (string, error) {
 	defaultStatePath := ""
 	parser := hclparse.NewParser()
 
@@ -69,20 +69,13 @@ func StateFileFromConfig(configFilePath string) (string, error) {
 
 	if defaultStatePath == "" {
 		configDir := filepath.Dir(configFilePath)
-		defaultStatePath = configDir + "/terraform.tfstate"
-		slog.Warn("no local backend found in terraform configuration file. Checking or default state file in configuration path " + defaultStatePath)
+		defaultStatePath = filepath.Join(configDir, "terraform.tfstate")
+		logger(ctx).Warn("no local backend found in terraform configuration file. Checking or default state file in configuration path " + defaultStatePath)
 	}
 
 	return defaultStatePath, nil
 }
 
-// BackendConfig represents the parsed backend configuration
-type BackendConfig struct {
-	Type      string         `json:"type"`
-	Config    map[string]any `json:"config"`
-	Workspace string         `json:"workspace,omitempty"`
-}
-
 // ParseBackendBlock parses a specific backend block
 func ParseBackendBlock(backendBlock *hcl.Block) (*statemanager.BackendConfig, error) {
 	if len(backendBlock.Labels) == 0 {
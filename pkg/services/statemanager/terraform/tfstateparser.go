@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"context"
 	"drift-watcher/pkg/services/statemanager"
 	"encoding/json"
 	"fmt"
@@ -46,6 +47,7 @@ type Instance struct {
 	Attributes          map[string]any    `json:"attributes"`
 	AttributesFlat      map[string]string `json:"attributes_flat,omitempty"`
 	Private             string            `json:"private,omitempty"`
+	SensitiveAttributes []any             `json:"sensitive_attributes,omitempty"`
 	Dependencies        []string          `json:"dependencies,omitempty"`
 	IndexKey            any               `json:"index_key,omitempty"`
 	Status              string            `json:"status,omitempty"`
@@ -81,7 +83,7 @@ func NewStateParser() *StateParser {
 }
 
 // ParseFile parses a .tfstate file from the given file path
-func (p *StateParser) ParseFile(filePath string) error {
+func (p *StateParser) ParseFile(ctx context.Context, filePath string) error {
 	fileHandler, err := os.Stat(filePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -95,7 +97,7 @@ func (p *StateParser) ParseFile(filePath string) error {
 	ext := filepath.Ext(filePath)
 	switch ext {
 	case ".tf":
-		filePath, err = StateFileFromConfig(filePath)
+		filePath, err = StateFileFromConfig(ctx, filePath)
 		if err != nil {
 			return err
 		}
@@ -124,11 +126,15 @@ func (p *StateParser) ParseFile(filePath string) error {
 	return p.ParseBytes(data)
 }
 
-// ParseBytes parses .tfstate data from a byte slice
+// ParseBytes parses .tfstate data from a byte slice and validates that it is a
+// usable Terraform state, see ValidateState.
 func (p *StateParser) ParseBytes(data []byte) error {
 	var state TerraformState
 	if err := json.Unmarshal(data, &state); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+		return fmt.Errorf("failed to unmarshal JSON: %w", describeJSONError(data, err))
+	}
+	if err := ValidateState(data); err != nil {
+		return err
 	}
 
 	p.State = &state
@@ -151,6 +157,22 @@ func (p *StateParser) GetStateVersion() int {
 	return p.State.Version
 }
 
+// GetSerial returns the serial number of the state, incremented on every write
+func (p *StateParser) GetSerial() int {
+	if p.State == nil {
+		return 0
+	}
+	return p.State.Serial
+}
+
+// GetLineage returns the unique identifier assigned to the state when it was created
+func (p *StateParser) GetLineage() string {
+	if p.State == nil {
+		return ""
+	}
+	return p.State.Lineage
+}
+
 // GetResources returns all resources in the state
 func (p *StateParser) GetResources() []Resource {
 	if p.State == nil {
@@ -188,6 +210,7 @@ func (p *StateParser) GetResourcesByType(resourceType string) []statemanager.Sta
 				ScheamVersion: instance.SchemaVersion,
 				Attributes:    instance.Attributes,
 				Dependencies:  instance.Dependencies,
+				IndexKey:      instance.IndexKey,
 			}
 		}
 		resources = append(resources, newStateResource)
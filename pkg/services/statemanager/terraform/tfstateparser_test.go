@@ -1,9 +1,11 @@
 package terraform_test
 
 import (
+	"context"
 	"drift-watcher/pkg/services/statemanager/terraform"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,7 +55,7 @@ func TestParseFile_Success_TFState(t *testing.T) {
 	defer os.Remove(stateFilePath)
 
 	parser := terraform.NewStateParser()
-	err := parser.ParseFile(stateFilePath)
+	err := parser.ParseFile(context.Background(), stateFilePath)
 	require.NoError(t, err)
 	assert.NotNil(t, parser.State)
 	assert.Equal(t, 4, parser.State.Version)
@@ -86,7 +88,7 @@ func TestParseFile_Success_TFConfigWithLocalBackend(t *testing.T) {
 	defer os.Remove(configFilePath)
 
 	parser := terraform.NewStateParser()
-	err = parser.ParseFile(configFilePath)
+	err = parser.ParseFile(context.Background(), configFilePath)
 	require.NoError(t, err)
 	assert.NotNil(t, parser.State)
 	assert.Equal(t, 4, parser.State.Version)
@@ -95,7 +97,7 @@ func TestParseFile_Success_TFConfigWithLocalBackend(t *testing.T) {
 
 func TestParseFile_NotExist(t *testing.T) {
 	parser := terraform.NewStateParser()
-	err := parser.ParseFile("/path/to/nonexistent/file.tfstate")
+	err := parser.ParseFile(context.Background(), "/path/to/nonexistent/file.tfstate")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Terraform state file does not exist")
 }
@@ -106,7 +108,7 @@ func TestParseFile_IsDirectory(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	parser := terraform.NewStateParser()
-	err = parser.ParseFile(tmpDir)
+	err = parser.ParseFile(context.Background(), tmpDir)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Terraform state directories are not currently supported")
 }
@@ -118,12 +120,15 @@ func TestParseFile_UnsupportedExtension(t *testing.T) {
 	defer os.Remove(tmpFile.Name())
 
 	parser := terraform.NewStateParser()
-	err = parser.ParseFile(tmpFile.Name())
+	err = parser.ParseFile(context.Background(), tmpFile.Name())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), ".txt file is not currently supported")
 }
 
 func TestParseFile_ReadError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions cannot deny access on Windows")
+	}
 	// Create a file that we can't read (e.g., by changing permissions)
 	tmpFile, err := os.CreateTemp("", "test-*.tfstate")
 	require.NoError(t, err)
@@ -135,7 +140,7 @@ func TestParseFile_ReadError(t *testing.T) {
 	defer os.Chmod(tmpFile.Name(), 0644) // Restore permissions for cleanup
 
 	parser := terraform.NewStateParser()
-	err = parser.ParseFile(tmpFile.Name())
+	err = parser.ParseFile(context.Background(), tmpFile.Name())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read file")
 }
@@ -145,7 +150,7 @@ func TestParseFile_InvalidJSON(t *testing.T) {
 	defer os.Remove(invalidJsonPath)
 
 	parser := terraform.NewStateParser()
-	err := parser.ParseFile(invalidJsonPath)
+	err := parser.ParseFile(context.Background(), invalidJsonPath)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to unmarshal JSON")
 }
@@ -190,6 +195,16 @@ func TestGetStateVersion(t *testing.T) {
 	assert.Equal(t, 4, parser.GetStateVersion())
 }
 
+func TestGetSerialAndLineage(t *testing.T) {
+	parser := terraform.NewStateParser()
+	assert.Equal(t, 0, parser.GetSerial()) // No state loaded
+	assert.Equal(t, "", parser.GetLineage())
+
+	parser.State = &terraform.TerraformState{Serial: 7, Lineage: "abc-123"}
+	assert.Equal(t, 7, parser.GetSerial())
+	assert.Equal(t, "abc-123", parser.GetLineage())
+}
+
 func TestGetResources(t *testing.T) {
 	parser := terraform.NewStateParser()
 	assert.Nil(t, parser.GetResources()) // No state loaded
@@ -375,3 +390,22 @@ func TestGetResourceInstanceCount(t *testing.T) {
 	}
 	assert.Equal(t, 3, parser.GetResourceInstanceCount())
 }
+
+func TestInstanceAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource terraform.Resource
+		instance terraform.Instance
+		want     string
+	}{
+		{"plain", terraform.Resource{Mode: "managed", Type: "aws_instance", Name: "web"}, terraform.Instance{}, "aws_instance.web"},
+		{"count", terraform.Resource{Mode: "managed", Type: "aws_instance", Name: "web"}, terraform.Instance{IndexKey: float64(2)}, "aws_instance.web[2]"},
+		{"for_each", terraform.Resource{Mode: "managed", Type: "aws_instance", Name: "web"}, terraform.Instance{IndexKey: "a"}, `aws_instance.web["a"]`},
+		{"module data", terraform.Resource{Module: "module.net", Mode: "data", Type: "aws_ami", Name: "ubuntu"}, terraform.Instance{}, "module.net.data.aws_ami.ubuntu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, terraform.InstanceAddress(tt.resource, tt.instance))
+		})
+	}
+}
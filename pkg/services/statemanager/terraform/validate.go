@@ -0,0 +1,160 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationProblem describes a single problem found in a state file.
+type ValidationProblem struct {
+	// Line is the 1-based line the problem was found on, or 0 when it is not known.
+	Line    int
+	Field   string
+	Message string
+}
+
+func (p ValidationProblem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Field, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Field, p.Message)
+}
+
+// ValidationError is returned for state files that are valid JSON but are not a
+// usable Terraform state. It lists every problem found rather than only the first.
+type ValidationError struct {
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem.String())
+	}
+	return "invalid Terraform state:\n" + strings.Join(lines, "\n")
+}
+
+// ValidateState checks that data, which must already be valid JSON, has the fields a
+// Terraform state requires: version, lineage (from state version 3), and a type, name
+// and instances for every resource.
+//
+// Parameters:
+//   - data: The raw state file content
+//
+// Returns:
+//   - error: A *ValidationError listing every problem found, or nil if the state is valid
+func ValidateState(data []byte) error {
+	var state struct {
+		Version   *int              `json:"version"`
+		Lineage   string            `json:"lineage"`
+		Resources []json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return describeJSONError(data, err)
+	}
+
+	var problems []ValidationProblem
+	if state.Version == nil {
+		problems = append(problems, ValidationProblem{Line: 1, Field: "version", Message: "is required"})
+	} else if *state.Version >= 3 && state.Lineage == "" {
+		problems = append(problems, ValidationProblem{Line: 1, Field: "lineage", Message: "is required"})
+	}
+
+	offsets := resourceOffsets(data)
+	for i, raw := range state.Resources {
+		line := 0
+		if i < len(offsets) {
+			line = lineAt(data, offsets[i])
+		}
+		field := fmt.Sprintf("resources[%d]", i)
+
+		var resource struct {
+			Type      string            `json:"type"`
+			Name      string            `json:"name"`
+			Instances []json.RawMessage `json:"instances"`
+		}
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			problems = append(problems, ValidationProblem{Line: line, Field: field, Message: "must be an object with type, name and instances"})
+			continue
+		}
+		if resource.Type == "" {
+			problems = append(problems, ValidationProblem{Line: line, Field: field + ".type", Message: "is required"})
+		}
+		if resource.Name == "" {
+			problems = append(problems, ValidationProblem{Line: line, Field: field + ".name", Message: "is required"})
+		}
+		if len(resource.Instances) == 0 {
+			problems = append(problems, ValidationProblem{Line: line, Field: field + ".instances", Message: "must contain at least one instance"})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// describeJSONError adds the line of a syntax or type error in data to err.
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("line %d: %w", lineAt(data, syntaxErr.Offset), err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		// the offset of a type error points just past the offending value
+		return fmt.Errorf("line %d: %s: expected %s, got %s: %w", lineAt(data, typeErr.Offset-1), typeErr.Field, typeErr.Type, typeErr.Value, err)
+	}
+	return err
+}
+
+// resourceOffsets returns the byte offset at which each element of the top-level
+// resources array starts.
+func resourceOffsets(data []byte) []int64 {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "resources" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return nil
+		}
+		var offsets []int64
+		for dec.More() {
+			offsets = append(offsets, dec.InputOffset())
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return offsets
+			}
+		}
+		return offsets
+	}
+	return nil
+}
+
+// lineAt returns the 1-based line of the first significant character at or after offset.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
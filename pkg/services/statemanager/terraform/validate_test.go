@@ -0,0 +1,66 @@
+package terraform_test
+
+import (
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateState_Valid(t *testing.T) {
+	state := []byte(`{
+		"version": 4,
+		"lineage": "abc",
+		"resources": [
+			{"type": "aws_instance", "name": "web", "instances": [{"attributes": {}}]}
+		]
+	}`)
+	assert.NoError(t, terraform.ValidateState(state))
+}
+
+func TestValidateState_ReportsEveryProblemWithLines(t *testing.T) {
+	state := []byte(`{
+  "version": 4,
+  "resources": [
+    {"type": "aws_instance", "name": "web", "instances": [{}]},
+    {"name": "db", "instances": []},
+    {"type": "aws_s3_bucket", "instances": [{}]}
+  ]
+}`)
+
+	err := terraform.ValidateState(state)
+	require.Error(t, err)
+
+	var validationErr *terraform.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []terraform.ValidationProblem{
+		{Line: 1, Field: "lineage", Message: "is required"},
+		{Line: 5, Field: "resources[1].type", Message: "is required"},
+		{Line: 5, Field: "resources[1].instances", Message: "must contain at least one instance"},
+		{Line: 6, Field: "resources[2].name", Message: "is required"},
+	}, validationErr.Problems)
+	assert.Contains(t, err.Error(), "line 5: resources[1].type: is required")
+}
+
+func TestValidateState_MissingVersion(t *testing.T) {
+	err := terraform.ValidateState([]byte(`{"lineage": "abc"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version: is required")
+}
+
+func TestParseBytes_TypeErrorIncludesLine(t *testing.T) {
+	parser := terraform.NewStateParser()
+	err := parser.ParseBytes([]byte("{\n  \"version\": \"four\"\n}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unmarshal JSON")
+	assert.Contains(t, err.Error(), "line 2: version: expected int, got string")
+}
+
+func TestParseBytes_SemanticallyInvalid(t *testing.T) {
+	parser := terraform.NewStateParser()
+	err := parser.ParseBytes([]byte(`{"version": 4, "lineage": "abc", "resources": [{"type": "aws_instance", "instances": [{}]}]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resources[0].name: is required")
+}
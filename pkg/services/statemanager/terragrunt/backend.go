@@ -0,0 +1,52 @@
+package terragrunt
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// location returns the state path or URI the backend stores the default workspace at.
+// Relative local paths are resolved against the stack directory dir.
+func (r *remoteState) location(dir string) (string, error) {
+	setting := func(name string) string {
+		value, _ := r.Config[name].(string)
+		return value
+	}
+
+	switch r.Backend {
+	case "s3":
+		bucket, key := setting("bucket"), setting("key")
+		if bucket == "" || key == "" {
+			return "", fmt.Errorf("s3 remote_state requires bucket and key")
+		}
+		return "s3://" + bucket + "/" + strings.TrimPrefix(key, "/"), nil
+	case "gcs":
+		bucket := setting("bucket")
+		if bucket == "" {
+			return "", fmt.Errorf("gcs remote_state requires a bucket")
+		}
+		object := "default.tfstate"
+		if prefix := strings.Trim(setting("prefix"), "/"); prefix != "" {
+			object = prefix + "/" + object
+		}
+		return "gs://" + bucket + "/" + object, nil
+	case "http":
+		address := setting("address")
+		if address == "" {
+			return "", fmt.Errorf("http remote_state requires an address")
+		}
+		return address, nil
+	case "local":
+		path := setting("path")
+		if path == "" {
+			path = "terraform.tfstate"
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("%s remote_state backend not currently supported", r.Backend)
+	}
+}
@@ -0,0 +1,301 @@
+package terragrunt
+
+import (
+	"drift-watcher/pkg/services/statemanager/terraform"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// config is a parsed terragrunt.hcl, or another configuration file it includes.
+type config struct {
+	file string
+	body *hclsyntax.Body
+	// includes holds the absolute paths of the configurations the file includes.
+	includes []string
+}
+
+// remoteState is an evaluated remote_state block.
+type remoteState struct {
+	Backend string
+	Config  map[string]any
+}
+
+// parseConfig parses file and evaluates the paths of its include blocks.
+func parseConfig(file string) (*config, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read terragrunt configuration")
+	}
+	parsed, diags := hclsyntax.ParseConfig(src, file, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.Wrap(diags, fmt.Sprintf("Failed to parse terragrunt configuration %s", file))
+	}
+	cfg := &config{file: file, body: parsed.Body.(*hclsyntax.Body)}
+
+	evalCtx, err := cfg.evalContext(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range cfg.body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+		attr, ok := block.Body.Attributes["path"]
+		if !ok {
+			return nil, fmt.Errorf("%s: include block without a path", file)
+		}
+		path, err := evalString(attr, evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		cfg.includes = append(cfg.includes, filepath.Clean(path))
+	}
+	return cfg, nil
+}
+
+// remoteState evaluates the remote_state of the configuration as seen from the stack
+// configured by child, which matters for functions such as path_relative_to_include.
+// It returns nil if the configuration has no remote_state.
+func (c *config) remoteState(child string) (*remoteState, error) {
+	var body *hclsyntax.Body
+	for _, block := range c.body.Blocks {
+		if block.Type == "remote_state" {
+			body = block.Body
+		}
+	}
+
+	evalCtx, err := c.evalContext(child)
+	if err != nil {
+		return nil, err
+	}
+
+	var backend, settings hcl.Expression
+	switch {
+	case body != nil:
+		if attr, ok := body.Attributes["backend"]; ok {
+			backend = attr.Expr
+		}
+		if attr, ok := body.Attributes["config"]; ok {
+			settings = attr.Expr
+		}
+	case c.body.Attributes["remote_state"] != nil:
+		// remote_state may also be set as an object attribute
+		value, diags := c.body.Attributes["remote_state"].Expr.Value(evalCtx)
+		if diags.HasErrors() {
+			return nil, errors.Wrap(diags, "Failed to evaluate remote_state")
+		}
+		return objectRemoteState(value)
+	default:
+		return nil, nil
+	}
+
+	if backend == nil {
+		return nil, fmt.Errorf("%s: remote_state without a backend", c.file)
+	}
+	state := &remoteState{}
+	value, diags := backend.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, errors.Wrap(diags, "Failed to evaluate remote_state backend")
+	}
+	if value.Type() != cty.String || value.IsNull() {
+		return nil, fmt.Errorf("%s: remote_state backend must be a string", c.file)
+	}
+	state.Backend = value.AsString()
+
+	state.Config = map[string]any{}
+	if settings != nil {
+		value, diags := settings.Value(evalCtx)
+		if diags.HasErrors() {
+			return nil, errors.Wrap(diags, "Failed to evaluate remote_state config")
+		}
+		if err := decodeObject(value, state.Config); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// objectRemoteState reads a remote_state set as an object attribute.
+func objectRemoteState(value cty.Value) (*remoteState, error) {
+	decoded := map[string]any{}
+	if err := decodeObject(value, decoded); err != nil {
+		return nil, err
+	}
+	backend, _ := decoded["backend"].(string)
+	if backend == "" {
+		return nil, fmt.Errorf("remote_state without a backend")
+	}
+	state := &remoteState{Backend: backend, Config: map[string]any{}}
+	if settings, ok := decoded["config"].(map[string]any); ok {
+		state.Config = settings
+	}
+	return state, nil
+}
+
+func decodeObject(value cty.Value, into map[string]any) error {
+	decoded, err := terraform.CtyValueToGo(value)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode remote_state")
+	}
+	object, ok := decoded.(map[string]any)
+	if !ok {
+		return fmt.Errorf("remote_state config must be an object")
+	}
+	for key, value := range object {
+		into[key] = value
+	}
+	return nil
+}
+
+// evalContext returns the context the configuration is evaluated in: its locals and
+// the Terragrunt functions that locate files, as seen from the stack configured by
+// child. Functions that need cloud credentials, such as get_aws_account_id, are not
+// available.
+func (c *config) evalContext(child string) (*hcl.EvalContext, error) {
+	dir := filepath.Dir(c.file)
+	childDir := filepath.Dir(child)
+
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: map[string]function.Function{
+			"find_in_parent_folders":     findInParentFolders(childDir),
+			"path_relative_to_include":   constant(relativePath(dir, childDir)),
+			"path_relative_from_include": constant(relativePath(childDir, dir)),
+			"get_terragrunt_dir":         constant(childDir),
+			"get_parent_terragrunt_dir":  constant(dir),
+			"get_env":                    getEnv,
+			"format":                     stdlib.FormatFunc,
+			"join":                       stdlib.JoinFunc,
+			"lower":                      stdlib.LowerFunc,
+			"upper":                      stdlib.UpperFunc,
+			"replace":                    stdlib.ReplaceFunc,
+			"trimprefix":                 stdlib.TrimPrefixFunc,
+			"trimsuffix":                 stdlib.TrimSuffixFunc,
+		},
+	}
+
+	var locals hcl.Attributes
+	for _, block := range c.body.Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attributes, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, errors.Wrap(diags, "Failed to read locals")
+		}
+		if locals == nil {
+			locals = hcl.Attributes{}
+		}
+		for name, attr := range attributes {
+			locals[name] = attr
+		}
+	}
+
+	// locals may refer to each other, so they are evaluated until no more resolve
+	values := map[string]cty.Value{}
+	for len(values) < len(locals) {
+		progressed := false
+		var lastDiags hcl.Diagnostics
+		for name, attr := range locals {
+			if _, done := values[name]; done {
+				continue
+			}
+			evalCtx.Variables["local"] = cty.ObjectVal(values)
+			value, diags := attr.Expr.Value(evalCtx)
+			if diags.HasErrors() {
+				lastDiags = diags
+				continue
+			}
+			values[name] = value
+			progressed = true
+		}
+		if !progressed {
+			return nil, errors.Wrap(lastDiags, fmt.Sprintf("Failed to evaluate locals of %s", c.file))
+		}
+	}
+	evalCtx.Variables["local"] = cty.ObjectVal(values)
+	return evalCtx, nil
+}
+
+func evalString(attr *hclsyntax.Attribute, evalCtx *hcl.EvalContext) (string, error) {
+	value, diags := attr.Expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return "", errors.Wrap(diags, fmt.Sprintf("Failed to evaluate %s", attr.Name))
+	}
+	if value.Type() != cty.String || value.IsNull() {
+		return "", fmt.Errorf("%s must be a string", attr.Name)
+	}
+	return value.AsString(), nil
+}
+
+func relativePath(from string, to string) string {
+	rel, err := filepath.Rel(from, to)
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+func constant(value string) function.Function {
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(value), nil
+		},
+	})
+}
+
+// findInParentFolders searches the parents of dir for a file, terragrunt.hcl unless
+// another name is given, and returns its absolute path. A second argument is returned
+// instead of failing when no file is found.
+func findInParentFolders(dir string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := ConfigFile
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			for current := filepath.Dir(dir); ; current = filepath.Dir(current) {
+				candidate := filepath.Join(current, name)
+				if _, err := os.Stat(candidate); err == nil {
+					return cty.StringVal(candidate), nil
+				}
+				if current == filepath.Dir(current) {
+					break
+				}
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("no %s found in the parent folders of %s", name, dir)
+		},
+	})
+}
+
+var getEnv = function.New(&function.Spec{
+	Params:   []function.Parameter{{Name: "name", Type: cty.String}},
+	VarParam: &function.Parameter{Name: "default", Type: cty.String},
+	Type:     function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if value, ok := os.LookupEnv(args[0].AsString()); ok {
+			return cty.StringVal(value), nil
+		}
+		if len(args) > 1 {
+			return args[1], nil
+		}
+		return cty.StringVal(""), nil
+	},
+})
@@ -0,0 +1,169 @@
+// Package terragrunt discovers the stacks of a Terragrunt project and locates the state
+// of each stack from the remote_state configuration in its terragrunt.hcl, so that drift
+// detection can run once per stack.
+package terragrunt
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigFile is the name of the file that marks a Terragrunt stack.
+const ConfigFile = "terragrunt.hcl"
+
+// cacheDir is where Terragrunt copies modules to; it holds no stacks of its own.
+const cacheDir = ".terragrunt-cache"
+
+// Stack is a Terragrunt stack and the location of its state.
+type Stack struct {
+	// Path is the directory of the stack relative to the project root, using forward
+	// slashes, e.g. "prod/vpc".
+	Path string
+	// Dir is the absolute directory of the stack.
+	Dir string
+	// Backend is the remote_state backend type, e.g. "s3", or "local" when the stack
+	// has no remote_state configuration.
+	Backend string
+	// StatePath is the local path or remote URI of the stack's state, as accepted by
+	// the terraform state manager.
+	StatePath string
+}
+
+// Discover returns the stacks under root, sorted by path. Every directory holding a
+// terragrunt.hcl is a stack, except for configuration that other stacks include, such
+// as a root terragrunt.hcl that only holds the shared remote_state block. Hidden
+// directories and the Terragrunt cache are not searched.
+//
+// Parameters:
+//   - ctx: Context carrying the logger
+//   - root: The root directory of the Terragrunt project
+//
+// Returns:
+//   - []Stack: The stacks found under root
+//   - error: Any error encountered while walking root or evaluating a configuration
+func Discover(ctx context.Context, root string) ([]Stack, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve terragrunt root directory")
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (entry.Name() == cacheDir || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() == ConfigFile {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to search terragrunt root directory")
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s found under %s", ConfigFile, root)
+	}
+
+	configs := map[string]*config{}
+	load := func(file string) (*config, error) {
+		if cfg, ok := configs[file]; ok {
+			return cfg, nil
+		}
+		cfg, err := parseConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		configs[file] = cfg
+		return cfg, nil
+	}
+
+	included := map[string]bool{}
+	for _, file := range files {
+		cfg, err := load(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, include := range cfg.includes {
+			included[include] = true
+		}
+	}
+
+	var stacks []Stack
+	for _, file := range files {
+		if included[file] {
+			continue
+		}
+		stack, err := newStack(ctx, root, file, load)
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Path < stacks[j].Path })
+	return stacks, nil
+}
+
+// newStack resolves the state location of the stack configured by file. A remote_state
+// block in the stack itself takes precedence over one in an included configuration.
+func newStack(ctx context.Context, root string, file string, load func(string) (*config, error)) (Stack, error) {
+	dir := filepath.Dir(file)
+	path, err := filepath.Rel(root, dir)
+	if err != nil {
+		return Stack{}, errors.Wrap(err, "Failed to resolve stack path")
+	}
+	stack := Stack{Path: filepath.ToSlash(path), Dir: dir}
+
+	cfg, err := load(file)
+	if err != nil {
+		return Stack{}, err
+	}
+	state, err := cfg.remoteState(file)
+	if err != nil {
+		return Stack{}, err
+	}
+	for _, include := range cfg.includes {
+		if state != nil {
+			break
+		}
+		parent, err := load(include)
+		if err != nil {
+			return Stack{}, err
+		}
+		if state, err = parent.remoteState(file); err != nil {
+			return Stack{}, err
+		}
+	}
+
+	if state == nil {
+		stack.Backend = "local"
+		stack.StatePath = filepath.Join(dir, "terraform.tfstate")
+		logger(ctx).Warn("no remote_state found for terragrunt stack, using the default local state", "stack", stack.Path, "state_path", stack.StatePath)
+		return stack, nil
+	}
+
+	stack.Backend = state.Backend
+	stack.StatePath, err = state.location(dir)
+	if err != nil {
+		return Stack{}, fmt.Errorf("stack %s: %w", stack.Path, err)
+	}
+	return stack, nil
+}
+
+// logger returns the logger carried by ctx for the terragrunt module.
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Module(ctx, "terragrunt")
+}
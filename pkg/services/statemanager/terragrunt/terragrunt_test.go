@@ -0,0 +1,140 @@
+package terragrunt_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/statemanager/terragrunt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProject creates the files of a terragrunt project under a temporary root.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+const rootConfig = `
+locals {
+  env    = "prod"
+  bucket = "${local.env}-tfstate"
+}
+
+remote_state {
+  backend = "s3"
+  config = {
+    bucket = local.bucket
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+`
+
+const childConfig = `
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "../../modules/vpc"
+}
+`
+
+func TestDiscover_IncludedRemoteState(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"terragrunt.hcl":                             rootConfig,
+		"network/vpc/terragrunt.hcl":                 childConfig,
+		"app/web/terragrunt.hcl":                     childConfig,
+		"app/web/.terragrunt-cache/x/terragrunt.hcl": childConfig,
+		".git/terragrunt.hcl":                        childConfig,
+	})
+
+	stacks, err := terragrunt.Discover(context.Background(), root)
+	require.NoError(t, err)
+	require.Len(t, stacks, 2, "the included root configuration, cache and hidden directories are not stacks")
+
+	assert.Equal(t, "app/web", stacks[0].Path)
+	assert.Equal(t, "s3", stacks[0].Backend)
+	assert.Equal(t, "s3://prod-tfstate/app/web/terraform.tfstate", stacks[0].StatePath)
+	assert.Equal(t, filepath.Join(root, "app", "web"), stacks[0].Dir)
+	assert.Equal(t, "network/vpc", stacks[1].Path)
+	assert.Equal(t, "s3://prod-tfstate/network/vpc/terraform.tfstate", stacks[1].StatePath)
+}
+
+func TestDiscover_RootHCLAndOwnRemoteState(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"root.hcl": rootConfig,
+		"vpc/terragrunt.hcl": `
+include {
+  path = find_in_parent_folders("root.hcl")
+}
+`,
+		"dns/terragrunt.hcl": `
+remote_state {
+  backend = "gcs"
+  config = {
+    bucket = "dns-state"
+    prefix = "zones/${get_env("DRIFT_TEST_ZONE", "public")}"
+  }
+}
+`,
+		"scratch/terragrunt.hcl": `
+remote_state {
+  backend = "local"
+  config = {
+    path = "state/terraform.tfstate"
+  }
+}
+`,
+		"legacy/terragrunt.hcl": `terraform {}`,
+	})
+
+	stacks, err := terragrunt.Discover(context.Background(), root)
+	require.NoError(t, err)
+	require.Len(t, stacks, 4)
+
+	byPath := map[string]terragrunt.Stack{}
+	for _, stack := range stacks {
+		byPath[stack.Path] = stack
+	}
+	assert.Equal(t, "s3://prod-tfstate/vpc/terraform.tfstate", byPath["vpc"].StatePath)
+	assert.Equal(t, "gs://dns-state/zones/public/default.tfstate", byPath["dns"].StatePath)
+	assert.Equal(t, filepath.Join(root, "scratch", "state", "terraform.tfstate"), byPath["scratch"].StatePath)
+	assert.Equal(t, "local", byPath["legacy"].Backend)
+	assert.Equal(t, filepath.Join(root, "legacy", "terraform.tfstate"), byPath["legacy"].StatePath)
+}
+
+func TestDiscover_Errors(t *testing.T) {
+	_, err := terragrunt.Discover(context.Background(), t.TempDir())
+	assert.ErrorContains(t, err, "no terragrunt.hcl found")
+
+	root := writeProject(t, map[string]string{
+		"vpc/terragrunt.hcl": `
+remote_state {
+  backend = "azurerm"
+  config = {}
+}
+`,
+	})
+	_, err = terragrunt.Discover(context.Background(), root)
+	assert.EqualError(t, err, "stack vpc: azurerm remote_state backend not currently supported")
+
+	root = writeProject(t, map[string]string{
+		"vpc/terragrunt.hcl": `
+include {
+  path = find_in_parent_folders()
+}
+`,
+	})
+	_, err = terragrunt.Discover(context.Background(), root)
+	assert.ErrorContains(t, err, "no terragrunt.hcl found in the parent folders")
+}
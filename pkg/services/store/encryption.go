@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// encryptedPrefix marks a stored value that was encrypted; it is followed by the
+// base64 encoding of the JSON Envelope. Values without it were stored in clear.
+const encryptedPrefix = "enc:v1:"
+
+// Encryption algorithms recorded in an Envelope.
+const (
+	// AlgorithmAESGCM encrypts with a local 256-bit key.
+	AlgorithmAESGCM = "aes-256-gcm"
+	// AlgorithmKMSAESGCM encrypts with a 256-bit data key generated by AWS KMS; the
+	// data key is stored in the envelope, encrypted under the KMS key.
+	AlgorithmKMSAESGCM = "kms+aes-256-gcm"
+)
+
+// ErrEncrypted is returned when an encrypted value is read from a store opened
+// without a Cipher.
+var ErrEncrypted = errors.New("the report store holds encrypted reports, an encryption key is required to read them")
+
+// Envelope is an encrypted value as it is stored.
+type Envelope struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// DataKey is the data key encrypted under the KMS key, for AlgorithmKMSAESGCM.
+	DataKey    []byte `json:"dk,omitempty"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// Cipher encrypts the report payloads and attribute values of a SQLStore at rest.
+type Cipher interface {
+	Encrypt(ctx context.Context, plaintext []byte) (*Envelope, error)
+	Decrypt(ctx context.Context, envelope *Envelope) ([]byte, error)
+}
+
+// LocalCipher encrypts with AES-256-GCM under a key read from a file.
+type LocalCipher struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewLocalCipher creates a cipher from a file holding a base64 encoded 256-bit key,
+// e.g. as generated with `openssl rand -base64 32 > store.key`.
+func NewLocalCipher(keyPath string) (*LocalCipher, error) {
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s is not a base64 encoded 256-bit key", keyPath)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &LocalCipher{keyID: "sha256:" + hex.EncodeToString(sum[:8]), aead: aead}, nil
+}
+
+func (c *LocalCipher) Encrypt(ctx context.Context, plaintext []byte) (*Envelope, error) {
+	nonce, ciphertext, err := seal(c.aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Algorithm: AlgorithmAESGCM, KeyID: c.keyID, Nonce: nonce, Ciphertext: ciphertext}, nil
+}
+
+func (c *LocalCipher) Decrypt(ctx context.Context, envelope *Envelope) ([]byte, error) {
+	if envelope.Algorithm != AlgorithmAESGCM {
+		return nil, fmt.Errorf("value was encrypted with %s, not with a local key", envelope.Algorithm)
+	}
+	if envelope.KeyID != c.keyID {
+		return nil, fmt.Errorf("value was encrypted with key %s, not %s", envelope.KeyID, c.keyID)
+	}
+	return c.aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+}
+
+// KMSAPI is the subset of the AWS KMS client used for envelope encryption.
+type KMSAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSCipher encrypts with envelope encryption: values are encrypted with AES-256-GCM
+// under a data key generated by AWS KMS, and the data key is stored next to them
+// encrypted under the KMS key. A single data key is generated per KMSCipher, and the
+// data keys decrypted are kept in memory, so KMS is called once per key rather than
+// once per report.
+type KMSCipher struct {
+	client KMSAPI
+	keyID  string
+
+	mu        sync.Mutex
+	dataKey   []byte
+	aead      cipher.AEAD
+	decrypted map[string]cipher.AEAD
+}
+
+// NewKMSCipher creates a cipher using the symmetric KMS key keyID, which may be a key
+// id, key ARN, alias name or alias ARN.
+func NewKMSCipher(client KMSAPI, keyID string) (*KMSCipher, error) {
+	if keyID == "" {
+		return nil, errors.New("a KMS key id is required")
+	}
+	return &KMSCipher{client: client, keyID: keyID, decrypted: map[string]cipher.AEAD{}}, nil
+}
+
+func (c *KMSCipher) Encrypt(ctx context.Context, plaintext []byte) (*Envelope, error) {
+	c.mu.Lock()
+	if c.aead == nil {
+		output, err := c.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+			KeyId:   aws.String(c.keyID),
+			KeySpec: types.DataKeySpecAes256,
+		})
+		if err != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("kms generate data key: %w", err)
+		}
+		aead, err := newAEAD(output.Plaintext)
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.dataKey, c.aead = output.CiphertextBlob, aead
+		c.decrypted[string(output.CiphertextBlob)] = aead
+	}
+	dataKey, aead := c.dataKey, c.aead
+	c.mu.Unlock()
+
+	nonce, ciphertext, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Algorithm: AlgorithmKMSAESGCM, KeyID: c.keyID, DataKey: dataKey, Nonce: nonce, Ciphertext: ciphertext}, nil
+}
+
+// Decrypt decrypts envelope with the KMS key recorded in it, which may differ from the
+// key new values are encrypted with after the key was rotated to another one.
+func (c *KMSCipher) Decrypt(ctx context.Context, envelope *Envelope) ([]byte, error) {
+	if envelope.Algorithm != AlgorithmKMSAESGCM {
+		return nil, fmt.Errorf("value was encrypted with %s, not with KMS", envelope.Algorithm)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	aead, ok := c.decrypted[string(envelope.DataKey)]
+	if !ok {
+		output, err := c.client.Decrypt(ctx, &kms.DecryptInput{
+			KeyId:          aws.String(envelope.KeyID),
+			CiphertextBlob: envelope.DataKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("kms decrypt: %w", err)
+		}
+		aead, err = newAEAD(output.Plaintext)
+		if err != nil {
+			return nil, err
+		}
+		c.decrypted[string(envelope.DataKey)] = aead
+	}
+	return aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+}
+
+// encryptValue encrypts value with c into its stored form, or returns it unchanged
+// when c is nil.
+func encryptValue(ctx context.Context, c Cipher, value []byte) (string, error) {
+	if c == nil {
+		return string(value), nil
+	}
+	envelope, err := c.Encrypt(ctx, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode encrypted value: %w", err)
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// decryptValue returns the plaintext of a stored value. Values stored in clear, such as
+// those stored before encryption was enabled, are returned unchanged.
+func decryptValue(ctx context.Context, c Cipher, stored string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedPrefix)
+	if !ok {
+		return []byte(stored), nil
+	}
+	if c == nil {
+		return nil, ErrEncrypted
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	envelope := &Envelope{}
+	if err := json.Unmarshal(raw, envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	plaintext, err := c.Decrypt(ctx, envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, nil), nil
+}
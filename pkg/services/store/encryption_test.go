@@ -0,0 +1,173 @@
+package store_test
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"drift-watcher/pkg/services/store"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey writes a random base64 encoded 256-bit key and returns its path.
+func writeKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "store.key")
+	require.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600))
+	return path
+}
+
+// storedValues returns every report payload and attribute value stored in the
+// database at dsn.
+func storedValues(t *testing.T, dsn string) string {
+	t.Helper()
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var values []string
+	for _, query := range []string{"SELECT report FROM drift_reports", "SELECT terraform_value || actual_value FROM drift_items"} {
+		rows, err := db.Query(query)
+		require.NoError(t, err)
+		for rows.Next() {
+			var value string
+			require.NoError(t, rows.Scan(&value))
+			values = append(values, value)
+		}
+		require.NoError(t, rows.Close())
+	}
+	return strings.Join(values, "\n")
+}
+
+func TestSQLStore_LocalEncryption(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	now := time.Now()
+
+	// reports stored before encryption was enabled remain readable
+	plain, err := store.NewSQLStore(ctx, store.SQLiteDriver, dsn)
+	require.NoError(t, err)
+	require.NoError(t, plain.SaveReport(ctx, "run-1", report(now.Add(-time.Hour), "t2.micro")))
+	require.NoError(t, plain.Close())
+
+	c, err := store.NewLocalCipher(writeKey(t))
+	require.NoError(t, err)
+	s, err := store.NewSQLStore(ctx, store.SQLiteDriver, dsn, store.WithCipher(c))
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.SaveReport(ctx, "run-2", report(now, "t2.secret-large")))
+
+	assert.NotContains(t, storedValues(t, dsn), "t2.secret-large")
+
+	history, err := s.History(ctx, store.HistoryQuery{Resource: "i-123"})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "t2.secret-large", history[0].Report.DriftDetails[0].ActualValue)
+	assert.Equal(t, "t2.micro", history[1].Report.DriftDetails[0].ActualValue)
+
+	event, err := s.FirstDrift(ctx, "i-123", "instance_type")
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, "t2.micro", event.Item.TerraformValue)
+	assert.Equal(t, "t2.secret-large", event.Item.ActualValue)
+
+	// without the key, or with another one, the encrypted reports cannot be read
+	plain, err = store.NewSQLStore(ctx, store.SQLiteDriver, dsn)
+	require.NoError(t, err)
+	defer plain.Close()
+	_, err = plain.History(ctx, store.HistoryQuery{})
+	assert.ErrorIs(t, err, store.ErrEncrypted)
+
+	other, err := store.NewLocalCipher(writeKey(t))
+	require.NoError(t, err)
+	wrongKey, err := store.NewSQLStore(ctx, store.SQLiteDriver, dsn, store.WithCipher(other))
+	require.NoError(t, err)
+	defer wrongKey.Close()
+	_, err = wrongKey.History(ctx, store.HistoryQuery{})
+	assert.ErrorContains(t, err, "value was encrypted with key sha256:")
+}
+
+func TestNewLocalCipher_InvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.key")
+	require.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString([]byte("too short"))), 0600))
+
+	_, err := store.NewLocalCipher(path)
+	assert.EqualError(t, err, path+" is not a base64 encoded 256-bit key")
+}
+
+// fakeKMS generates data keys and "encrypts" them by prefixing the key id, counting
+// the calls made.
+type fakeKMS struct {
+	mu        sync.Mutex
+	generated int
+	decrypted int
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.generated++
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          params.KeyId,
+		Plaintext:      key,
+		CiphertextBlob: append([]byte(aws.ToString(params.KeyId)+":"), key...),
+	}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decrypted++
+	key, _ := strings.CutPrefix(string(params.CiphertextBlob), aws.ToString(params.KeyId)+":")
+	return &kms.DecryptOutput{KeyId: params.KeyId, Plaintext: []byte(key)}, nil
+}
+
+func TestSQLStore_KMSEncryption(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	client := &fakeKMS{}
+
+	c, err := store.NewKMSCipher(client, "alias/driftwatcher")
+	require.NoError(t, err)
+	s, err := store.NewSQLStore(ctx, store.SQLiteDriver, dsn, store.WithCipher(c))
+	require.NoError(t, err)
+	now := time.Now()
+	for i := range 3 {
+		require.NoError(t, s.SaveReport(ctx, "run-1", report(now.Add(time.Duration(i)*time.Minute), "m5.secret")))
+	}
+	require.NoError(t, s.Close())
+	assert.Equal(t, 1, client.generated, "one data key is generated per cipher")
+	assert.NotContains(t, storedValues(t, dsn), "m5.secret")
+
+	// a new process decrypts the data key once
+	c, err = store.NewKMSCipher(client, "alias/driftwatcher")
+	require.NoError(t, err)
+	s, err = store.NewSQLStore(ctx, store.SQLiteDriver, dsn, store.WithCipher(c))
+	require.NoError(t, err)
+	defer s.Close()
+	history, err := s.History(ctx, store.HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "m5.secret", history[0].Report.DriftDetails[0].ActualValue)
+	assert.Equal(t, 1, client.decrypted)
+
+	_, err = store.NewKMSCipher(client, "")
+	assert.EqualError(t, err, "a KMS key id is required")
+}
@@ -0,0 +1,402 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"drift-watcher/pkg/services/driftchecker"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite"
+)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS drift_runs (
+		run_id        TEXT PRIMARY KEY,
+		started_at    BIGINT NOT NULL,
+		state_path    TEXT,
+		provider      TEXT,
+		resource_type TEXT,
+		attributes    TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS drift_reports (
+		report_id     TEXT PRIMARY KEY,
+		run_id        TEXT NOT NULL,
+		generated_at  BIGINT NOT NULL,
+		resource_id   TEXT,
+		resource_type TEXT,
+		resource_name TEXT,
+		has_drift     BOOLEAN NOT NULL,
+		status        TEXT,
+		report        TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS drift_items (
+		report_id       TEXT NOT NULL,
+		field           TEXT NOT NULL,
+		terraform_value TEXT,
+		actual_value    TEXT,
+		drift_type      TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS drift_scheduled_runs (
+		run_id      TEXT PRIMARY KEY,
+		schedule    TEXT NOT NULL,
+		started_at  BIGINT NOT NULL,
+		finished_at BIGINT,
+		status      TEXT NOT NULL,
+		drifted     INTEGER NOT NULL,
+		error       TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS drift_schedules (
+		name       TEXT PRIMARY KEY,
+		paused     BOOLEAN NOT NULL,
+		updated_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_drift_reports_resource ON drift_reports (resource_id, resource_name, generated_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_drift_items_report ON drift_items (report_id, field)`,
+	`CREATE INDEX IF NOT EXISTS idx_drift_scheduled_runs_schedule ON drift_scheduled_runs (schedule, started_at)`,
+}
+
+// SQLStore implements ReportStore and ScheduleStore on top of database/sql.
+// Timestamps are stored as unix nanoseconds so that the same schema and queries
+// work unchanged on SQLite and Postgres.
+type SQLStore struct {
+	db     *sql.DB
+	driver Driver
+	cipher Cipher
+}
+
+// Option configures a SQLStore.
+type Option func(*SQLStore)
+
+// WithCipher encrypts the report payloads and drifted attribute values stored with c.
+// Values stored in clear before encryption was enabled remain readable. Resource ids,
+// names, types and attribute names are stored in clear so that history can still be
+// queried by them.
+func WithCipher(c Cipher) Option {
+	return func(s *SQLStore) {
+		s.cipher = c
+	}
+}
+
+// NewSQLStore opens the database identified by driver and dsn and makes sure the
+// schema exists. For SQLite the dsn is a file path; for Postgres it is a connection URL.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - driver: The database engine to use (sqlite or postgres)
+//   - dsn: The data source name passed to the database driver
+//   - opts: Options such as WithCipher
+//
+// Returns:
+//   - *SQLStore: A ready to use report store
+//   - error: Any error encountered opening the database or creating the schema
+func NewSQLStore(ctx context.Context, driver Driver, dsn string, opts ...Option) (*SQLStore, error) {
+	var driverName string
+	switch driver {
+	case SQLiteDriver, "":
+		driver = SQLiteDriver
+		driverName = "sqlite"
+	case PostgresDriver:
+		driverName = "postgres"
+	default:
+		return nil, fmt.Errorf("%s store driver not currently supported", driver)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open report store")
+	}
+	if driver == SQLiteDriver {
+		// SQLite only supports a single writer, so serialise access from the worker pool.
+		db.SetMaxOpenConns(1)
+	}
+
+	s := &SQLStore{db: db, driver: driver}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, errors.Wrap(err, "Failed to create report store schema")
+		}
+	}
+
+	return s, nil
+}
+
+// rebind rewrites '?' placeholders into the positional form expected by the driver.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != PostgresDriver {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// StartRun records the metadata of a new run. Starting the same run twice is a no-op.
+func (s *SQLStore) StartRun(ctx context.Context, run RunMetadata) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO drift_runs (run_id, started_at, state_path, provider, resource_type, attributes)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (run_id) DO NOTHING`),
+		run.RunId, run.StartedAt.UnixNano(), run.StatePath, run.Provider, run.ResourceType, strings.Join(run.Attributes, ","))
+	if err != nil {
+		return errors.Wrap(err, "Failed to record run metadata")
+	}
+	return nil
+}
+
+// SaveReport persists report and each of its drift items under runId.
+func (s *SQLStore) SaveReport(ctx context.Context, runId string, report *driftchecker.DriftReport) error {
+	if report == nil {
+		return fmt.Errorf("cannot store a nil drift report")
+	}
+
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal drift report")
+	}
+	payload, err := encryptValue(ctx, s.cipher, raw)
+	if err != nil {
+		return errors.Wrap(err, "Failed to store drift report")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin report store transaction")
+	}
+	defer tx.Rollback()
+
+	reportId := uuid.NewString()
+	_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO drift_reports (report_id, run_id, generated_at, resource_id, resource_type, resource_name, has_drift, status, report)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		reportId, runId, report.GeneratedAt.UnixNano(), report.ResourceId, report.ResourceType, report.ResourceName, report.HasDrift, report.Status, payload)
+	if err != nil {
+		return errors.Wrap(err, "Failed to store drift report")
+	}
+
+	for _, item := range report.DriftDetails {
+		tfValue, err := encryptValue(ctx, s.cipher, fmt.Appendf(nil, "%v", item.TerraformValue))
+		if err != nil {
+			return errors.Wrap(err, "Failed to store drift item")
+		}
+		actualValue, err := encryptValue(ctx, s.cipher, fmt.Appendf(nil, "%v", item.ActualValue))
+		if err != nil {
+			return errors.Wrap(err, "Failed to store drift item")
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO drift_items (report_id, field, terraform_value, actual_value, drift_type)
+			VALUES (?, ?, ?, ?, ?)`),
+			reportId, item.Field, tfValue, actualValue, item.DriftType)
+		if err != nil {
+			return errors.Wrap(err, "Failed to store drift item")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// History returns the stored reports matching query, most recent first. Reports
+// stored by earlier releases are upgraded to the current driftchecker.SchemaVersion.
+func (s *SQLStore) History(ctx context.Context, query HistoryQuery) ([]StoredReport, error) {
+	var (
+		clauses []string
+		args    []any
+	)
+	if query.RunId != "" {
+		clauses = append(clauses, "run_id = ?")
+		args = append(args, query.RunId)
+	}
+	if query.Resource != "" {
+		clauses = append(clauses, "(resource_id = ? OR resource_name = ?)")
+		args = append(args, query.Resource, query.Resource)
+	}
+	if query.ResourceType != "" {
+		clauses = append(clauses, "resource_type = ?")
+		args = append(args, query.ResourceType)
+	}
+	if !query.Since.IsZero() {
+		clauses = append(clauses, "generated_at >= ?")
+		args = append(args, query.Since.UnixNano())
+	}
+	if query.OnlyDrift {
+		clauses = append(clauses, "has_drift = ?")
+		args = append(args, true)
+	}
+
+	stmt := "SELECT run_id, report FROM drift_reports"
+	if len(clauses) > 0 {
+		stmt += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	stmt += " ORDER BY generated_at DESC"
+	if query.Limit > 0 {
+		stmt += " LIMIT " + strconv.Itoa(query.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(stmt), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query report history")
+	}
+	defer rows.Close()
+
+	var out []StoredReport
+	for rows.Next() {
+		var (
+			stored StoredReport
+			raw    string
+		)
+		if err := rows.Scan(&stored.RunId, &raw); err != nil {
+			return nil, errors.Wrap(err, "Failed to read report history")
+		}
+		payload, err := decryptValue(ctx, s.cipher, raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read stored drift report")
+		}
+		if err := json.Unmarshal(payload, &stored.Report); err != nil {
+			return nil, errors.Wrap(err, "Failed to unmarshal stored drift report")
+		}
+		out = append(out, stored)
+	}
+
+	return out, rows.Err()
+}
+
+// FirstDrift returns the earliest recorded drift of field on the given resource,
+// or nil if that attribute has never drifted.
+func (s *SQLStore) FirstDrift(ctx context.Context, resource string, field string) (*DriftEvent, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT r.run_id, r.resource_id, r.resource_name, r.resource_type, r.generated_at,
+			i.field, i.terraform_value, i.actual_value, i.drift_type
+		FROM drift_items i JOIN drift_reports r ON r.report_id = i.report_id
+		WHERE (r.resource_id = ? OR r.resource_name = ?) AND i.field = ? AND i.drift_type <> ?
+		ORDER BY r.generated_at ASC LIMIT 1`),
+		resource, resource, field, driftchecker.Match)
+
+	var (
+		event       DriftEvent
+		generatedAt int64
+		tfValue     string
+		actualValue string
+	)
+	err := row.Scan(&event.RunId, &event.ResourceId, &event.ResourceName, &event.ResourceType, &generatedAt,
+		&event.Item.Field, &tfValue, &actualValue, &event.Item.DriftType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query first drift")
+	}
+	event.GeneratedAt = time.Unix(0, generatedAt).UTC()
+	desired, err := decryptValue(ctx, s.cipher, tfValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read first drift")
+	}
+	actual, err := decryptValue(ctx, s.cipher, actualValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read first drift")
+	}
+	event.Item.TerraformValue = string(desired)
+	event.Item.ActualValue = string(actual)
+
+	return &event, nil
+}
+
+// RecordScheduledRun creates run, or updates its finish time, status, drift count and
+// error when it was already recorded.
+func (s *SQLStore) RecordScheduledRun(ctx context.Context, run ScheduledRun) error {
+	var finishedAt sql.NullInt64
+	if !run.FinishedAt.IsZero() {
+		finishedAt = sql.NullInt64{Int64: run.FinishedAt.UnixNano(), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO drift_scheduled_runs (run_id, schedule, started_at, finished_at, status, drifted, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET finished_at = excluded.finished_at, status = excluded.status,
+			drifted = excluded.drifted, error = excluded.error`),
+		run.RunId, run.Schedule, run.StartedAt.UnixNano(), finishedAt, run.Status, run.Drifted, run.Error)
+	if err != nil {
+		return errors.Wrap(err, "Failed to record scheduled run")
+	}
+	return nil
+}
+
+// ScheduledRuns returns the runs of schedule, most recent first.
+func (s *SQLStore) ScheduledRuns(ctx context.Context, schedule string, limit int) ([]ScheduledRun, error) {
+	stmt := `SELECT run_id, schedule, started_at, finished_at, status, drifted, error
+		FROM drift_scheduled_runs WHERE schedule = ? ORDER BY started_at DESC`
+	if limit > 0 {
+		stmt += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(stmt), schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query scheduled runs")
+	}
+	defer rows.Close()
+
+	var out []ScheduledRun
+	for rows.Next() {
+		var (
+			run        ScheduledRun
+			startedAt  int64
+			finishedAt sql.NullInt64
+			runError   sql.NullString
+		)
+		if err := rows.Scan(&run.RunId, &run.Schedule, &startedAt, &finishedAt, &run.Status, &run.Drifted, &runError); err != nil {
+			return nil, errors.Wrap(err, "Failed to read scheduled runs")
+		}
+		run.StartedAt = time.Unix(0, startedAt).UTC()
+		if finishedAt.Valid {
+			run.FinishedAt = time.Unix(0, finishedAt.Int64).UTC()
+		}
+		run.Error = runError.String
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// SetSchedulePaused pauses or resumes schedule.
+func (s *SQLStore) SetSchedulePaused(ctx context.Context, schedule string, paused bool) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO drift_schedules (name, paused, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET paused = excluded.paused, updated_at = excluded.updated_at`),
+		schedule, paused, time.Now().UnixNano())
+	if err != nil {
+		return errors.Wrap(err, "Failed to update schedule")
+	}
+	return nil
+}
+
+// PausedSchedules returns the names of the paused schedules.
+func (s *SQLStore) PausedSchedules(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT name FROM drift_schedules WHERE paused = ?`), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query schedules")
+	}
+	defer rows.Close()
+
+	paused := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "Failed to read schedules")
+		}
+		paused[name] = true
+	}
+	return paused, rows.Err()
+}
+
+// Close releases the underlying database handle.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
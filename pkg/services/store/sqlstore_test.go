@@ -0,0 +1,158 @@
+package store_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *store.SQLStore {
+	t.Helper()
+	s, err := store.NewSQLStore(context.Background(), store.SQLiteDriver, filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func report(generatedAt time.Time, instanceType string) *driftchecker.DriftReport {
+	r := &driftchecker.DriftReport{
+		ResourceId:   "i-123",
+		ResourceName: "web",
+		ResourceType: "aws_instance",
+		GeneratedAt:  generatedAt,
+		Status:       driftchecker.Match,
+		DriftDetails: []driftchecker.DriftItem{
+			{Field: "instance_type", TerraformValue: "t2.micro", ActualValue: instanceType, DriftType: driftchecker.Match},
+		},
+	}
+	if instanceType != "t2.micro" {
+		r.HasDrift = true
+		r.Status = driftchecker.Drift
+		r.DriftDetails[0].DriftType = driftchecker.AttributeValueChanged
+	}
+	return r
+}
+
+func TestNewSQLStore_UnsupportedDriver(t *testing.T) {
+	s, err := store.NewSQLStore(context.Background(), store.Driver("mysql"), "")
+	assert.Error(t, err)
+	assert.Nil(t, s)
+	assert.Contains(t, err.Error(), "mysql store driver not currently supported")
+}
+
+func TestSQLStore_SaveAndHistory(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	now := time.Now()
+	require.NoError(t, s.StartRun(ctx, store.RunMetadata{RunId: "run-1", StartedAt: now, ResourceType: "aws_instance"}))
+	// starting the same run twice is a no-op
+	require.NoError(t, s.StartRun(ctx, store.RunMetadata{RunId: "run-1", StartedAt: now}))
+
+	require.NoError(t, s.SaveReport(ctx, "run-1", report(now.Add(-40*24*time.Hour), "t2.micro")))
+	require.NoError(t, s.SaveReport(ctx, "run-1", report(now.Add(-10*24*time.Hour), "t2.medium")))
+	require.NoError(t, s.SaveReport(ctx, "run-1", report(now.Add(-1*24*time.Hour), "t2.large")))
+
+	all, err := s.History(ctx, store.HistoryQuery{Resource: "i-123"})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "t2.large", all[0].Report.DriftDetails[0].ActualValue, "history should be most recent first")
+	assert.Equal(t, "run-1", all[0].RunId)
+
+	recent, err := s.History(ctx, store.HistoryQuery{Resource: "web", Since: now.Add(-30 * 24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Len(t, recent, 2)
+
+	limited, err := s.History(ctx, store.HistoryQuery{Resource: "i-123", Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	driftOnly, err := s.History(ctx, store.HistoryQuery{OnlyDrift: true})
+	require.NoError(t, err)
+	assert.Len(t, driftOnly, 2)
+
+	none, err := s.History(ctx, store.HistoryQuery{Resource: "i-unknown"})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	require.NoError(t, s.SaveReport(ctx, "run-2", report(now, "t2.xlarge")))
+	run, err := s.History(ctx, store.HistoryQuery{RunId: "run-2"})
+	require.NoError(t, err)
+	require.Len(t, run, 1)
+	assert.Equal(t, "t2.xlarge", run[0].Report.DriftDetails[0].ActualValue)
+}
+
+func TestSQLStore_FirstDrift(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	now := time.Now()
+	require.NoError(t, s.SaveReport(ctx, "run-1", report(now.Add(-3*time.Hour), "t2.micro")))
+	require.NoError(t, s.SaveReport(ctx, "run-2", report(now.Add(-2*time.Hour), "t2.medium")))
+	require.NoError(t, s.SaveReport(ctx, "run-3", report(now.Add(-1*time.Hour), "t2.large")))
+
+	event, err := s.FirstDrift(ctx, "i-123", "instance_type")
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, "run-2", event.RunId)
+	assert.Equal(t, "t2.medium", event.Item.ActualValue)
+	assert.Equal(t, driftchecker.AttributeValueChanged, event.Item.DriftType)
+	assert.WithinDuration(t, now.Add(-2*time.Hour), event.GeneratedAt, time.Millisecond)
+
+	event, err = s.FirstDrift(ctx, "i-123", "ami")
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestSQLStore_SaveNilReport(t *testing.T) {
+	s := newTestStore(t)
+	err := s.SaveReport(context.Background(), "run-1", nil)
+	assert.Error(t, err)
+}
+
+func TestSQLStore_ScheduledRuns(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	started := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.RecordScheduledRun(ctx, store.ScheduledRun{RunId: "run-1", Schedule: "hourly", StartedAt: started, Status: store.RunOK, Drifted: 2, FinishedAt: started.Add(time.Minute)}))
+	require.NoError(t, s.RecordScheduledRun(ctx, store.ScheduledRun{RunId: "run-2", Schedule: "hourly", StartedAt: started.Add(time.Hour), Status: store.RunRunning}))
+	require.NoError(t, s.RecordScheduledRun(ctx, store.ScheduledRun{RunId: "run-3", Schedule: "nightly", StartedAt: started, Status: store.RunRunning}))
+	// recording a run again updates its outcome
+	require.NoError(t, s.RecordScheduledRun(ctx, store.ScheduledRun{RunId: "run-2", Schedule: "hourly", StartedAt: started.Add(time.Hour), Status: store.RunFailed, Error: "boom", FinishedAt: started.Add(61 * time.Minute)}))
+
+	runs, err := s.ScheduledRuns(ctx, "hourly", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []store.ScheduledRun{
+		{RunId: "run-2", Schedule: "hourly", StartedAt: started.Add(time.Hour), FinishedAt: started.Add(61 * time.Minute), Status: store.RunFailed, Error: "boom"},
+		{RunId: "run-1", Schedule: "hourly", StartedAt: started, FinishedAt: started.Add(time.Minute), Status: store.RunOK, Drifted: 2},
+	}, runs)
+
+	runs, err = s.ScheduledRuns(ctx, "hourly", 1)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run-2", runs[0].RunId)
+}
+
+func TestSQLStore_PausedSchedules(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	paused, err := s.PausedSchedules(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, paused)
+
+	require.NoError(t, s.SetSchedulePaused(ctx, "hourly", true))
+	require.NoError(t, s.SetSchedulePaused(ctx, "nightly", true))
+	require.NoError(t, s.SetSchedulePaused(ctx, "nightly", false))
+
+	paused, err = s.PausedSchedules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"hourly": true}, paused)
+}
@@ -0,0 +1,104 @@
+// Package store provides persistence for drift reports so that drift can be
+// tracked across runs. It defines the ReportStore interface and a database/sql
+// backed implementation that works with SQLite (the default) and Postgres.
+package store
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"time"
+)
+
+// Driver identifies the database engine backing a ReportStore.
+type Driver string
+
+const (
+	SQLiteDriver   Driver = "sqlite"
+	PostgresDriver Driver = "postgres"
+)
+
+// RunMetadata describes a single invocation of the drift detection pipeline.
+// Every report persisted during the run is linked to it through RunId.
+type RunMetadata struct {
+	RunId        string    `json:"run_id"`
+	StartedAt    time.Time `json:"started_at"`
+	StatePath    string    `json:"state_path,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	Attributes   []string  `json:"attributes,omitempty"`
+}
+
+// StoredReport is a drift report as it was persisted, together with the run it belongs to.
+type StoredReport struct {
+	RunId  string                   `json:"run_id"`
+	Report driftchecker.DriftReport `json:"report"`
+}
+
+// DriftEvent records a single attribute drift observed at a point in time.
+type DriftEvent struct {
+	RunId        string                 `json:"run_id"`
+	ResourceId   string                 `json:"resource_id,omitempty"`
+	ResourceName string                 `json:"resource_name,omitempty"`
+	ResourceType string                 `json:"resource_type,omitempty"`
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Item         driftchecker.DriftItem `json:"item"`
+}
+
+// HistoryQuery narrows the reports returned by ReportStore.History.
+// Resource matches either the resource id or the resource name of a report, and
+// RunId limits the reports to those of a single run. Zero values are ignored.
+type HistoryQuery struct {
+	RunId        string
+	Resource     string
+	ResourceType string
+	Since        time.Time
+	OnlyDrift    bool
+	Limit        int
+}
+
+// ReportStore defines the interface for persisting drift reports and querying them later.
+//
+//counterfeiter:generate . ReportStore
+type ReportStore interface {
+	StartRun(ctx context.Context, run RunMetadata) error
+	SaveReport(ctx context.Context, runId string, report *driftchecker.DriftReport) error
+	History(ctx context.Context, query HistoryQuery) ([]StoredReport, error)
+	FirstDrift(ctx context.Context, resource string, field string) (*DriftEvent, error)
+	Close() error
+}
+
+// Statuses of a scheduled run.
+const (
+	RunRunning = "running"
+	RunOK      = "ok"
+	RunFailed  = "failed"
+)
+
+// ScheduledRun is a run started by a schedule of the serve command. Its reports are
+// stored under RunId like those of any other run.
+type ScheduledRun struct {
+	RunId      string    `json:"run_id"`
+	Schedule   string    `json:"schedule"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Status     string    `json:"status"`
+	Drifted    int       `json:"drifted"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ScheduleStore defines the interface for persisting the runs of schedules and
+// whether a schedule is paused, so that both survive a restart of the server and
+// can be changed from another process.
+//
+//counterfeiter:generate . ScheduleStore
+type ScheduleStore interface {
+	// RecordScheduledRun creates run, or updates it when a run with the same id exists.
+	RecordScheduledRun(ctx context.Context, run ScheduledRun) error
+	// ScheduledRuns returns the runs of schedule, most recent first. A limit of zero
+	// returns every run.
+	ScheduledRuns(ctx context.Context, schedule string, limit int) ([]ScheduledRun, error)
+	SetSchedulePaused(ctx context.Context, schedule string, paused bool) error
+	// PausedSchedules returns the names of the paused schedules.
+	PausedSchedules(ctx context.Context) (map[string]bool, error)
+}
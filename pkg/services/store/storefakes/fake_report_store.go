@@ -0,0 +1,421 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package storefakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/driftchecker"
+	"drift-watcher/pkg/services/store"
+	"sync"
+)
+
+type FakeReportStore struct {
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
+	closeReturns struct {
+		result1 error
+	}
+	closeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FirstDriftStub        func(context.Context, string, string) (*store.DriftEvent, error)
+	firstDriftMutex       sync.RWMutex
+	firstDriftArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	firstDriftReturns struct {
+		result1 *store.DriftEvent
+		result2 error
+	}
+	firstDriftReturnsOnCall map[int]struct {
+		result1 *store.DriftEvent
+		result2 error
+	}
+	HistoryStub        func(context.Context, store.HistoryQuery) ([]store.StoredReport, error)
+	historyMutex       sync.RWMutex
+	historyArgsForCall []struct {
+		arg1 context.Context
+		arg2 store.HistoryQuery
+	}
+	historyReturns struct {
+		result1 []store.StoredReport
+		result2 error
+	}
+	historyReturnsOnCall map[int]struct {
+		result1 []store.StoredReport
+		result2 error
+	}
+	SaveReportStub        func(context.Context, string, *driftchecker.DriftReport) error
+	saveReportMutex       sync.RWMutex
+	saveReportArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *driftchecker.DriftReport
+	}
+	saveReportReturns struct {
+		result1 error
+	}
+	saveReportReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StartRunStub        func(context.Context, store.RunMetadata) error
+	startRunMutex       sync.RWMutex
+	startRunArgsForCall []struct {
+		arg1 context.Context
+		arg2 store.RunMetadata
+	}
+	startRunReturns struct {
+		result1 error
+	}
+	startRunReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReportStore) Close() error {
+	fake.closeMutex.Lock()
+	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReportStore) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeReportStore) CloseCalls(stub func() error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *FakeReportStore) CloseReturns(result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) CloseReturnsOnCall(i int, result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	if fake.closeReturnsOnCall == nil {
+		fake.closeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) FirstDrift(arg1 context.Context, arg2 string, arg3 string) (*store.DriftEvent, error) {
+	fake.firstDriftMutex.Lock()
+	ret, specificReturn := fake.firstDriftReturnsOnCall[len(fake.firstDriftArgsForCall)]
+	fake.firstDriftArgsForCall = append(fake.firstDriftArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.FirstDriftStub
+	fakeReturns := fake.firstDriftReturns
+	fake.recordInvocation("FirstDrift", []interface{}{arg1, arg2, arg3})
+	fake.firstDriftMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReportStore) FirstDriftCallCount() int {
+	fake.firstDriftMutex.RLock()
+	defer fake.firstDriftMutex.RUnlock()
+	return len(fake.firstDriftArgsForCall)
+}
+
+func (fake *FakeReportStore) FirstDriftCalls(stub func(context.Context, string, string) (*store.DriftEvent, error)) {
+	fake.firstDriftMutex.Lock()
+	defer fake.firstDriftMutex.Unlock()
+	fake.FirstDriftStub = stub
+}
+
+func (fake *FakeReportStore) FirstDriftArgsForCall(i int) (context.Context, string, string) {
+	fake.firstDriftMutex.RLock()
+	defer fake.firstDriftMutex.RUnlock()
+	argsForCall := fake.firstDriftArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeReportStore) FirstDriftReturns(result1 *store.DriftEvent, result2 error) {
+	fake.firstDriftMutex.Lock()
+	defer fake.firstDriftMutex.Unlock()
+	fake.FirstDriftStub = nil
+	fake.firstDriftReturns = struct {
+		result1 *store.DriftEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReportStore) FirstDriftReturnsOnCall(i int, result1 *store.DriftEvent, result2 error) {
+	fake.firstDriftMutex.Lock()
+	defer fake.firstDriftMutex.Unlock()
+	fake.FirstDriftStub = nil
+	if fake.firstDriftReturnsOnCall == nil {
+		fake.firstDriftReturnsOnCall = make(map[int]struct {
+			result1 *store.DriftEvent
+			result2 error
+		})
+	}
+	fake.firstDriftReturnsOnCall[i] = struct {
+		result1 *store.DriftEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReportStore) History(arg1 context.Context, arg2 store.HistoryQuery) ([]store.StoredReport, error) {
+	fake.historyMutex.Lock()
+	ret, specificReturn := fake.historyReturnsOnCall[len(fake.historyArgsForCall)]
+	fake.historyArgsForCall = append(fake.historyArgsForCall, struct {
+		arg1 context.Context
+		arg2 store.HistoryQuery
+	}{arg1, arg2})
+	stub := fake.HistoryStub
+	fakeReturns := fake.historyReturns
+	fake.recordInvocation("History", []interface{}{arg1, arg2})
+	fake.historyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReportStore) HistoryCallCount() int {
+	fake.historyMutex.RLock()
+	defer fake.historyMutex.RUnlock()
+	return len(fake.historyArgsForCall)
+}
+
+func (fake *FakeReportStore) HistoryCalls(stub func(context.Context, store.HistoryQuery) ([]store.StoredReport, error)) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = stub
+}
+
+func (fake *FakeReportStore) HistoryArgsForCall(i int) (context.Context, store.HistoryQuery) {
+	fake.historyMutex.RLock()
+	defer fake.historyMutex.RUnlock()
+	argsForCall := fake.historyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeReportStore) HistoryReturns(result1 []store.StoredReport, result2 error) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = nil
+	fake.historyReturns = struct {
+		result1 []store.StoredReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReportStore) HistoryReturnsOnCall(i int, result1 []store.StoredReport, result2 error) {
+	fake.historyMutex.Lock()
+	defer fake.historyMutex.Unlock()
+	fake.HistoryStub = nil
+	if fake.historyReturnsOnCall == nil {
+		fake.historyReturnsOnCall = make(map[int]struct {
+			result1 []store.StoredReport
+			result2 error
+		})
+	}
+	fake.historyReturnsOnCall[i] = struct {
+		result1 []store.StoredReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReportStore) SaveReport(arg1 context.Context, arg2 string, arg3 *driftchecker.DriftReport) error {
+	fake.saveReportMutex.Lock()
+	ret, specificReturn := fake.saveReportReturnsOnCall[len(fake.saveReportArgsForCall)]
+	fake.saveReportArgsForCall = append(fake.saveReportArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *driftchecker.DriftReport
+	}{arg1, arg2, arg3})
+	stub := fake.SaveReportStub
+	fakeReturns := fake.saveReportReturns
+	fake.recordInvocation("SaveReport", []interface{}{arg1, arg2, arg3})
+	fake.saveReportMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReportStore) SaveReportCallCount() int {
+	fake.saveReportMutex.RLock()
+	defer fake.saveReportMutex.RUnlock()
+	return len(fake.saveReportArgsForCall)
+}
+
+func (fake *FakeReportStore) SaveReportCalls(stub func(context.Context, string, *driftchecker.DriftReport) error) {
+	fake.saveReportMutex.Lock()
+	defer fake.saveReportMutex.Unlock()
+	fake.SaveReportStub = stub
+}
+
+func (fake *FakeReportStore) SaveReportArgsForCall(i int) (context.Context, string, *driftchecker.DriftReport) {
+	fake.saveReportMutex.RLock()
+	defer fake.saveReportMutex.RUnlock()
+	argsForCall := fake.saveReportArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeReportStore) SaveReportReturns(result1 error) {
+	fake.saveReportMutex.Lock()
+	defer fake.saveReportMutex.Unlock()
+	fake.SaveReportStub = nil
+	fake.saveReportReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) SaveReportReturnsOnCall(i int, result1 error) {
+	fake.saveReportMutex.Lock()
+	defer fake.saveReportMutex.Unlock()
+	fake.SaveReportStub = nil
+	if fake.saveReportReturnsOnCall == nil {
+		fake.saveReportReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveReportReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) StartRun(arg1 context.Context, arg2 store.RunMetadata) error {
+	fake.startRunMutex.Lock()
+	ret, specificReturn := fake.startRunReturnsOnCall[len(fake.startRunArgsForCall)]
+	fake.startRunArgsForCall = append(fake.startRunArgsForCall, struct {
+		arg1 context.Context
+		arg2 store.RunMetadata
+	}{arg1, arg2})
+	stub := fake.StartRunStub
+	fakeReturns := fake.startRunReturns
+	fake.recordInvocation("StartRun", []interface{}{arg1, arg2})
+	fake.startRunMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReportStore) StartRunCallCount() int {
+	fake.startRunMutex.RLock()
+	defer fake.startRunMutex.RUnlock()
+	return len(fake.startRunArgsForCall)
+}
+
+func (fake *FakeReportStore) StartRunCalls(stub func(context.Context, store.RunMetadata) error) {
+	fake.startRunMutex.Lock()
+	defer fake.startRunMutex.Unlock()
+	fake.StartRunStub = stub
+}
+
+func (fake *FakeReportStore) StartRunArgsForCall(i int) (context.Context, store.RunMetadata) {
+	fake.startRunMutex.RLock()
+	defer fake.startRunMutex.RUnlock()
+	argsForCall := fake.startRunArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeReportStore) StartRunReturns(result1 error) {
+	fake.startRunMutex.Lock()
+	defer fake.startRunMutex.Unlock()
+	fake.StartRunStub = nil
+	fake.startRunReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) StartRunReturnsOnCall(i int, result1 error) {
+	fake.startRunMutex.Lock()
+	defer fake.startRunMutex.Unlock()
+	fake.StartRunStub = nil
+	if fake.startRunReturnsOnCall == nil {
+		fake.startRunReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startRunReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReportStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	fake.firstDriftMutex.RLock()
+	defer fake.firstDriftMutex.RUnlock()
+	fake.historyMutex.RLock()
+	defer fake.historyMutex.RUnlock()
+	fake.saveReportMutex.RLock()
+	defer fake.saveReportMutex.RUnlock()
+	fake.startRunMutex.RLock()
+	defer fake.startRunMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReportStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ store.ReportStore = new(FakeReportStore)
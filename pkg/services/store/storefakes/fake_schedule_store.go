@@ -0,0 +1,353 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package storefakes
+
+import (
+	"context"
+	"drift-watcher/pkg/services/store"
+	"sync"
+)
+
+type FakeScheduleStore struct {
+	PausedSchedulesStub        func(context.Context) (map[string]bool, error)
+	pausedSchedulesMutex       sync.RWMutex
+	pausedSchedulesArgsForCall []struct {
+		arg1 context.Context
+	}
+	pausedSchedulesReturns struct {
+		result1 map[string]bool
+		result2 error
+	}
+	pausedSchedulesReturnsOnCall map[int]struct {
+		result1 map[string]bool
+		result2 error
+	}
+	RecordScheduledRunStub        func(context.Context, store.ScheduledRun) error
+	recordScheduledRunMutex       sync.RWMutex
+	recordScheduledRunArgsForCall []struct {
+		arg1 context.Context
+		arg2 store.ScheduledRun
+	}
+	recordScheduledRunReturns struct {
+		result1 error
+	}
+	recordScheduledRunReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ScheduledRunsStub        func(context.Context, string, int) ([]store.ScheduledRun, error)
+	scheduledRunsMutex       sync.RWMutex
+	scheduledRunsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	scheduledRunsReturns struct {
+		result1 []store.ScheduledRun
+		result2 error
+	}
+	scheduledRunsReturnsOnCall map[int]struct {
+		result1 []store.ScheduledRun
+		result2 error
+	}
+	SetSchedulePausedStub        func(context.Context, string, bool) error
+	setSchedulePausedMutex       sync.RWMutex
+	setSchedulePausedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}
+	setSchedulePausedReturns struct {
+		result1 error
+	}
+	setSchedulePausedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeScheduleStore) PausedSchedules(arg1 context.Context) (map[string]bool, error) {
+	fake.pausedSchedulesMutex.Lock()
+	ret, specificReturn := fake.pausedSchedulesReturnsOnCall[len(fake.pausedSchedulesArgsForCall)]
+	fake.pausedSchedulesArgsForCall = append(fake.pausedSchedulesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.PausedSchedulesStub
+	fakeReturns := fake.pausedSchedulesReturns
+	fake.recordInvocation("PausedSchedules", []interface{}{arg1})
+	fake.pausedSchedulesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeScheduleStore) PausedSchedulesCallCount() int {
+	fake.pausedSchedulesMutex.RLock()
+	defer fake.pausedSchedulesMutex.RUnlock()
+	return len(fake.pausedSchedulesArgsForCall)
+}
+
+func (fake *FakeScheduleStore) PausedSchedulesCalls(stub func(context.Context) (map[string]bool, error)) {
+	fake.pausedSchedulesMutex.Lock()
+	defer fake.pausedSchedulesMutex.Unlock()
+	fake.PausedSchedulesStub = stub
+}
+
+func (fake *FakeScheduleStore) PausedSchedulesArgsForCall(i int) context.Context {
+	fake.pausedSchedulesMutex.RLock()
+	defer fake.pausedSchedulesMutex.RUnlock()
+	argsForCall := fake.pausedSchedulesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeScheduleStore) PausedSchedulesReturns(result1 map[string]bool, result2 error) {
+	fake.pausedSchedulesMutex.Lock()
+	defer fake.pausedSchedulesMutex.Unlock()
+	fake.PausedSchedulesStub = nil
+	fake.pausedSchedulesReturns = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScheduleStore) PausedSchedulesReturnsOnCall(i int, result1 map[string]bool, result2 error) {
+	fake.pausedSchedulesMutex.Lock()
+	defer fake.pausedSchedulesMutex.Unlock()
+	fake.PausedSchedulesStub = nil
+	if fake.pausedSchedulesReturnsOnCall == nil {
+		fake.pausedSchedulesReturnsOnCall = make(map[int]struct {
+			result1 map[string]bool
+			result2 error
+		})
+	}
+	fake.pausedSchedulesReturnsOnCall[i] = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRun(arg1 context.Context, arg2 store.ScheduledRun) error {
+	fake.recordScheduledRunMutex.Lock()
+	ret, specificReturn := fake.recordScheduledRunReturnsOnCall[len(fake.recordScheduledRunArgsForCall)]
+	fake.recordScheduledRunArgsForCall = append(fake.recordScheduledRunArgsForCall, struct {
+		arg1 context.Context
+		arg2 store.ScheduledRun
+	}{arg1, arg2})
+	stub := fake.RecordScheduledRunStub
+	fakeReturns := fake.recordScheduledRunReturns
+	fake.recordInvocation("RecordScheduledRun", []interface{}{arg1, arg2})
+	fake.recordScheduledRunMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRunCallCount() int {
+	fake.recordScheduledRunMutex.RLock()
+	defer fake.recordScheduledRunMutex.RUnlock()
+	return len(fake.recordScheduledRunArgsForCall)
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRunCalls(stub func(context.Context, store.ScheduledRun) error) {
+	fake.recordScheduledRunMutex.Lock()
+	defer fake.recordScheduledRunMutex.Unlock()
+	fake.RecordScheduledRunStub = stub
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRunArgsForCall(i int) (context.Context, store.ScheduledRun) {
+	fake.recordScheduledRunMutex.RLock()
+	defer fake.recordScheduledRunMutex.RUnlock()
+	argsForCall := fake.recordScheduledRunArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRunReturns(result1 error) {
+	fake.recordScheduledRunMutex.Lock()
+	defer fake.recordScheduledRunMutex.Unlock()
+	fake.RecordScheduledRunStub = nil
+	fake.recordScheduledRunReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduleStore) RecordScheduledRunReturnsOnCall(i int, result1 error) {
+	fake.recordScheduledRunMutex.Lock()
+	defer fake.recordScheduledRunMutex.Unlock()
+	fake.RecordScheduledRunStub = nil
+	if fake.recordScheduledRunReturnsOnCall == nil {
+		fake.recordScheduledRunReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.recordScheduledRunReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduleStore) ScheduledRuns(arg1 context.Context, arg2 string, arg3 int) ([]store.ScheduledRun, error) {
+	fake.scheduledRunsMutex.Lock()
+	ret, specificReturn := fake.scheduledRunsReturnsOnCall[len(fake.scheduledRunsArgsForCall)]
+	fake.scheduledRunsArgsForCall = append(fake.scheduledRunsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.ScheduledRunsStub
+	fakeReturns := fake.scheduledRunsReturns
+	fake.recordInvocation("ScheduledRuns", []interface{}{arg1, arg2, arg3})
+	fake.scheduledRunsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeScheduleStore) ScheduledRunsCallCount() int {
+	fake.scheduledRunsMutex.RLock()
+	defer fake.scheduledRunsMutex.RUnlock()
+	return len(fake.scheduledRunsArgsForCall)
+}
+
+func (fake *FakeScheduleStore) ScheduledRunsCalls(stub func(context.Context, string, int) ([]store.ScheduledRun, error)) {
+	fake.scheduledRunsMutex.Lock()
+	defer fake.scheduledRunsMutex.Unlock()
+	fake.ScheduledRunsStub = stub
+}
+
+func (fake *FakeScheduleStore) ScheduledRunsArgsForCall(i int) (context.Context, string, int) {
+	fake.scheduledRunsMutex.RLock()
+	defer fake.scheduledRunsMutex.RUnlock()
+	argsForCall := fake.scheduledRunsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeScheduleStore) ScheduledRunsReturns(result1 []store.ScheduledRun, result2 error) {
+	fake.scheduledRunsMutex.Lock()
+	defer fake.scheduledRunsMutex.Unlock()
+	fake.ScheduledRunsStub = nil
+	fake.scheduledRunsReturns = struct {
+		result1 []store.ScheduledRun
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScheduleStore) ScheduledRunsReturnsOnCall(i int, result1 []store.ScheduledRun, result2 error) {
+	fake.scheduledRunsMutex.Lock()
+	defer fake.scheduledRunsMutex.Unlock()
+	fake.ScheduledRunsStub = nil
+	if fake.scheduledRunsReturnsOnCall == nil {
+		fake.scheduledRunsReturnsOnCall = make(map[int]struct {
+			result1 []store.ScheduledRun
+			result2 error
+		})
+	}
+	fake.scheduledRunsReturnsOnCall[i] = struct {
+		result1 []store.ScheduledRun
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScheduleStore) SetSchedulePaused(arg1 context.Context, arg2 string, arg3 bool) error {
+	fake.setSchedulePausedMutex.Lock()
+	ret, specificReturn := fake.setSchedulePausedReturnsOnCall[len(fake.setSchedulePausedArgsForCall)]
+	fake.setSchedulePausedArgsForCall = append(fake.setSchedulePausedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.SetSchedulePausedStub
+	fakeReturns := fake.setSchedulePausedReturns
+	fake.recordInvocation("SetSchedulePaused", []interface{}{arg1, arg2, arg3})
+	fake.setSchedulePausedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeScheduleStore) SetSchedulePausedCallCount() int {
+	fake.setSchedulePausedMutex.RLock()
+	defer fake.setSchedulePausedMutex.RUnlock()
+	return len(fake.setSchedulePausedArgsForCall)
+}
+
+func (fake *FakeScheduleStore) SetSchedulePausedCalls(stub func(context.Context, string, bool) error) {
+	fake.setSchedulePausedMutex.Lock()
+	defer fake.setSchedulePausedMutex.Unlock()
+	fake.SetSchedulePausedStub = stub
+}
+
+func (fake *FakeScheduleStore) SetSchedulePausedArgsForCall(i int) (context.Context, string, bool) {
+	fake.setSchedulePausedMutex.RLock()
+	defer fake.setSchedulePausedMutex.RUnlock()
+	argsForCall := fake.setSchedulePausedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeScheduleStore) SetSchedulePausedReturns(result1 error) {
+	fake.setSchedulePausedMutex.Lock()
+	defer fake.setSchedulePausedMutex.Unlock()
+	fake.SetSchedulePausedStub = nil
+	fake.setSchedulePausedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduleStore) SetSchedulePausedReturnsOnCall(i int, result1 error) {
+	fake.setSchedulePausedMutex.Lock()
+	defer fake.setSchedulePausedMutex.Unlock()
+	fake.SetSchedulePausedStub = nil
+	if fake.setSchedulePausedReturnsOnCall == nil {
+		fake.setSchedulePausedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setSchedulePausedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduleStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.pausedSchedulesMutex.RLock()
+	defer fake.pausedSchedulesMutex.RUnlock()
+	fake.recordScheduledRunMutex.RLock()
+	defer fake.recordScheduledRunMutex.RUnlock()
+	fake.scheduledRunsMutex.RLock()
+	defer fake.scheduledRunsMutex.RUnlock()
+	fake.setSchedulePausedMutex.RLock()
+	defer fake.setSchedulePausedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeScheduleStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ store.ScheduleStore = new(FakeScheduleStore)
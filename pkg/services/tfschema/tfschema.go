@@ -0,0 +1,343 @@
+// Package tfschema checks attribute path expressions against the schemas of
+// Terraform providers, as printed by terraform providers schema -json, so that a typo
+// such as instance_tpye in --attributes or a policy is reported before a scan rather
+// than as drift of an attribute no resource has.
+//
+// The schemas of the AWS resources driftwatcher reads are bundled; the schemas of
+// other providers, or of the exact provider versions a configuration uses, are read
+// from the output of terraform providers schema -json or fetched by running it in an
+// initialized Terraform directory.
+package tfschema
+
+import (
+	"bytes"
+	"context"
+	"drift-watcher/pkg/services/attrpath"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownResourceType is returned when no provider schema declares a resource type.
+var ErrUnknownResourceType = errors.New("resource type not in the provider schemas")
+
+// Schemas is the output of terraform providers schema -json. Only the resource
+// schemas are decoded.
+type Schemas struct {
+	FormatVersion   string               `json:"format_version"`
+	ProviderSchemas map[string]*Provider `json:"provider_schemas"`
+}
+
+// Provider holds the schemas of the resource types of a provider.
+type Provider struct {
+	ResourceSchemas map[string]*Schema `json:"resource_schemas"`
+}
+
+// Schema is the schema of a resource type.
+type Schema struct {
+	Version int64  `json:"version"`
+	Block   *Block `json:"block"`
+}
+
+// Block is a configuration block: its attributes and nested blocks.
+type Block struct {
+	Attributes map[string]*Attribute   `json:"attributes,omitempty"`
+	BlockTypes map[string]*NestedBlock `json:"block_types,omitempty"`
+}
+
+// Attribute is an attribute of a block. Type is its type constraint in the JSON
+// encoding of cty types, e.g. "string", ["list","string"] or ["object",{...}];
+// attributes of plugin protocol 6 providers may have a NestedType instead.
+type Attribute struct {
+	Type       json.RawMessage `json:"type,omitempty"`
+	NestedType *NestedType     `json:"nested_type,omitempty"`
+}
+
+// NestedType is the nested object type of an attribute.
+type NestedType struct {
+	Attributes  map[string]*Attribute `json:"attributes"`
+	NestingMode string                `json:"nesting_mode"`
+}
+
+// NestedBlock is a block type nested in a block, repeated according to its nesting
+// mode: single, group, list, set or map.
+type NestedBlock struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       *Block `json:"block"`
+}
+
+//go:embed schemas
+var bundled embed.FS
+
+// Bundled returns the bundled schemas of the AWS resource types driftwatcher reads.
+// They cover the attributes of version 5 of the hashicorp/aws provider.
+var Bundled = sync.OnceValue(func() *Schemas {
+	data, err := bundled.ReadFile("schemas/aws.json")
+	if err != nil {
+		panic(err)
+	}
+	schemas, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return schemas
+})
+
+// Parse decodes the output of terraform providers schema -json.
+func Parse(data []byte) (*Schemas, error) {
+	var schemas Schemas
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse provider schemas: %w", err)
+	}
+	if schemas.FormatVersion == "" || schemas.ProviderSchemas == nil {
+		return nil, fmt.Errorf("failed to parse provider schemas: not the output of terraform providers schema -json")
+	}
+	return &schemas, nil
+}
+
+// Load reads the provider schemas at path: a file holding the output of terraform
+// providers schema -json, or an initialized Terraform directory in which it is run.
+func Load(ctx context.Context, path string) (*Schemas, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider schemas: %w", err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read provider schemas: %w", err)
+		}
+		return Parse(data)
+	}
+
+	command := exec.CommandContext(ctx, "terraform", "providers", "schema", "-json")
+	command.Dir = path
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, fmt.Errorf("failed to fetch provider schemas in %s: %w", path, err)
+	}
+	return Parse(out)
+}
+
+// Resource returns the schema of resourceType from whichever provider declares it.
+func (s *Schemas) Resource(resourceType string) (*Block, bool) {
+	for _, name := range slices.Sorted(maps.Keys(s.ProviderSchemas)) {
+		if schema, ok := s.ProviderSchemas[name].ResourceSchemas[resourceType]; ok && schema.Block != nil {
+			return schema.Block, true
+		}
+	}
+	return nil, false
+}
+
+// Check checks that the attribute path expression expr names an attribute of
+// resourceType, or an element, key or nested attribute of one. Keys of maps and of
+// dynamic values are not checked. It returns ErrUnknownResourceType when no schema
+// declares resourceType, and an *UnknownAttributeError for a key the schema does not
+// have.
+func (s *Schemas) Check(resourceType, expr string) error {
+	block, ok := s.Resource(resourceType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownResourceType, resourceType)
+	}
+	p, err := attrpath.Parse(expr)
+	if err != nil {
+		return err
+	}
+	if err := block.check(p, nil); err != nil {
+		err.ResourceType, err.Expr = resourceType, expr
+		return err
+	}
+	return nil
+}
+
+// UnknownAttributeError reports a key of an attribute path that the schema of the
+// resource type does not have, with the closest key it does have, if any.
+type UnknownAttributeError struct {
+	ResourceType string
+	Expr         string
+	// Parent is the path to the object missing Key, empty for the resource itself.
+	Parent     string
+	Key        string
+	Suggestion string
+}
+
+func (e *UnknownAttributeError) Error() string {
+	message := fmt.Sprintf("%s is not an attribute of %s", e.Key, e.ResourceType)
+	if e.Parent != "" {
+		message = fmt.Sprintf("%s is not an attribute of %s of %s", e.Key, e.Parent, e.ResourceType)
+	}
+	if e.Expr != e.Key {
+		message = e.Expr + ": " + message
+	}
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", e.Suggestion)
+	}
+	return message
+}
+
+// unknown returns the error of key missing from the object at parent, whose keys are
+// known.
+func unknown(parent attrpath.Path, key string, known []string) *UnknownAttributeError {
+	return &UnknownAttributeError{Parent: parent.String(), Key: key, Suggestion: suggest(key, known)}
+}
+
+func (b *Block) check(p, parent attrpath.Path) *UnknownAttributeError {
+	if len(p) == 0 {
+		return nil
+	}
+	segment := p[0]
+	if segment.IsIndex {
+		return &UnknownAttributeError{Parent: parent.String(), Key: fmt.Sprintf("[%d]", segment.Index)}
+	}
+	here := slices.Concat(parent, attrpath.Path{segment})
+	if attribute, ok := b.Attributes[segment.Key]; ok {
+		return attribute.check(p[1:], here)
+	}
+	if nested, ok := b.BlockTypes[segment.Key]; ok {
+		rest := skipElement(p[1:], nested.NestingMode)
+		return nested.Block.check(rest, slices.Concat(here, p[1:len(p)-len(rest)]))
+	}
+	known := slices.Concat(slices.Collect(maps.Keys(b.Attributes)), slices.Collect(maps.Keys(b.BlockTypes)))
+	return unknown(parent, segment.Key, known)
+}
+
+func (a *Attribute) check(p, parent attrpath.Path) *UnknownAttributeError {
+	if len(p) == 0 {
+		return nil
+	}
+	if a.NestedType != nil {
+		rest := skipElement(p, a.NestedType.NestingMode)
+		parent = slices.Concat(parent, p[:len(p)-len(rest)])
+		nested := &Block{Attributes: a.NestedType.Attributes}
+		return nested.check(rest, parent)
+	}
+	return checkType(a.Type, p, parent)
+}
+
+// skipElement skips the segment of p selecting an element of a repeated block, if
+// any: the index of a list or set, or the key of a map. As with attrpath.Lookup, a
+// key applied to a list or set is applied to each of its elements.
+func skipElement(p attrpath.Path, nestingMode string) attrpath.Path {
+	if len(p) == 0 {
+		return p
+	}
+	switch nestingMode {
+	case "list", "set":
+		if isIndex(p[0]) {
+			return p[1:]
+		}
+	case "map":
+		return p[1:]
+	}
+	return p
+}
+
+// isIndex reports whether segment selects an element of a list: an index, or a
+// numeric key as in flat Terraform state attributes.
+func isIndex(segment attrpath.Segment) bool {
+	if segment.IsIndex {
+		return true
+	}
+	return segment.Key != "" && strings.Trim(segment.Key, "0123456789") == ""
+}
+
+// checkType follows p through the cty type constraint t.
+func checkType(t json.RawMessage, p, parent attrpath.Path) *UnknownAttributeError {
+	if len(p) == 0 {
+		return nil
+	}
+	var primitive string
+	if json.Unmarshal(t, &primitive) == nil {
+		if primitive == "dynamic" {
+			return nil
+		}
+		return &UnknownAttributeError{Parent: parent.String(), Key: attrpath.Path{p[0]}.String()}
+	}
+	var composite []json.RawMessage
+	if json.Unmarshal(t, &composite) != nil || len(composite) != 2 {
+		// a type this version does not know is not checked
+		return nil
+	}
+	var kind string
+	_ = json.Unmarshal(composite[0], &kind)
+	switch kind {
+	case "list", "set":
+		if isIndex(p[0]) {
+			return checkType(composite[1], p[1:], slices.Concat(parent, p[:1]))
+		}
+		return checkType(composite[1], p, parent)
+	case "map":
+		var element string
+		if json.Unmarshal(composite[1], &element) == nil {
+			// the rest is a key holding dots, such as the tag kubernetes.io/role in the
+			// flat form tags.kubernetes.io/role
+			return nil
+		}
+		return checkType(composite[1], p[1:], slices.Concat(parent, p[:1]))
+	case "tuple":
+		var elements []json.RawMessage
+		_ = json.Unmarshal(composite[1], &elements)
+		if p[0].IsIndex && p[0].Index < len(elements) {
+			return checkType(elements[p[0].Index], p[1:], slices.Concat(parent, p[:1]))
+		}
+		return nil
+	case "object":
+		var attributes map[string]json.RawMessage
+		_ = json.Unmarshal(composite[1], &attributes)
+		if attribute, ok := attributes[p[0].Key]; ok && !p[0].IsIndex {
+			return checkType(attribute, p[1:], slices.Concat(parent, p[:1]))
+		}
+		return unknown(parent, attrpath.Path{p[0]}.String(), slices.Collect(maps.Keys(attributes)))
+	}
+	return nil
+}
+
+// suggest returns the key of known closest to key, if it is close enough to be a
+// typo of it.
+func suggest(key string, known []string) string {
+	best, bestDistance := "", max(1, len(key)/3)+1
+	for _, candidate := range slices.Sorted(slices.Values(known)) {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Damerau-Levenshtein distance between a and b, counting
+// the transposition of adjacent characters, the most common typo, as one edit.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,124 @@
+package tfschema_test
+
+import (
+	"context"
+	"drift-watcher/pkg/services/provider/aws"
+	"drift-watcher/pkg/services/tfschema"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const customSchema = `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/example/custom": {
+      "resource_schemas": {
+        "custom_service": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "name": {"type": "string"},
+              "settings": {"type": "dynamic"},
+              "ports": {"type": ["list", ["object", {"port": "number", "protocol": "string"}]]},
+              "endpoint": {"nested_type": {"nesting_mode": "single", "attributes": {"url": {"type": "string"}}}}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestSchemas_Check(t *testing.T) {
+	schemas := tfschema.Bundled()
+
+	for _, expr := range []string{
+		"instance_type",
+		"tags.Name",
+		`tags["kubernetes.io/role"]`,
+		"tags.kubernetes.io/role",
+		"root_block_device.volume_size",
+		"root_block_device[0].volume_size",
+		"root_block_device.0.volume_size",
+		"metadata_options.http_tokens",
+		"ebs_block_device.volume_id",
+		"vpc_security_group_ids[1]",
+	} {
+		assert.NoError(t, schemas.Check("aws_instance", expr), expr)
+	}
+
+	for expr, message := range map[string]string{
+		"instance_tpye":                 "instance_tpye is not an attribute of aws_instance, did you mean instance_type?",
+		"metadata_options.http_tokenz":  "metadata_options.http_tokenz: http_tokenz is not an attribute of metadata_options of aws_instance, did you mean http_tokens?",
+		"root_block_device[0].size":     "root_block_device[0].size: size is not an attribute of root_block_device[0] of aws_instance",
+		"instance_type.family":          "instance_type.family: family is not an attribute of instance_type of aws_instance",
+		"not_an_attribute_of_instances": "not_an_attribute_of_instances is not an attribute of aws_instance",
+	} {
+		err := schemas.Check("aws_instance", expr)
+		var unknown *tfschema.UnknownAttributeError
+		require.ErrorAs(t, err, &unknown, expr)
+		assert.EqualError(t, err, message)
+	}
+
+	assert.ErrorIs(t, schemas.Check("kubernetes_deployment", "spec"), tfschema.ErrUnknownResourceType)
+	assert.Error(t, schemas.Check("aws_instance", "tags..Name"), "an invalid path")
+}
+
+func TestSchemas_Check_Types(t *testing.T) {
+	schemas, err := tfschema.Parse([]byte(customSchema))
+	require.NoError(t, err)
+
+	for _, expr := range []string{"settings.anything.at.all", "ports[0].port", "ports.protocol", "endpoint.url"} {
+		assert.NoError(t, schemas.Check("custom_service", expr), expr)
+	}
+	assert.EqualError(t, schemas.Check("custom_service", "ports.prot"), "ports.prot: prot is not an attribute of ports of custom_service, did you mean port?")
+	assert.EqualError(t, schemas.Check("custom_service", "endpoint.uri"), "endpoint.uri: uri is not an attribute of endpoint of custom_service, did you mean url?")
+}
+
+func TestBundled_CoversProviderResourceTypes(t *testing.T) {
+	for _, resourceType := range aws.ResourceTypes() {
+		_, ok := tfschema.Bundled().Resource(resourceType)
+		assert.True(t, ok, resourceType)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(customSchema), 0600))
+	schemas, err := tfschema.Load(context.Background(), path)
+	require.NoError(t, err)
+	_, ok := schemas.Resource("custom_service")
+	assert.True(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"resources": []}`), 0600))
+	_, err = tfschema.Load(context.Background(), path)
+	assert.EqualError(t, err, "failed to parse provider schemas: not the output of terraform providers schema -json")
+
+	_, err = tfschema.Load(context.Background(), filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read provider schemas")
+}
+
+func TestLoad_TerraformDirectory(t *testing.T) {
+	// a terraform on the PATH printing the schema of the directory it is run in
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$*\" = \"providers schema -json\" ] || exit 2\ncat schema.json || { echo 'Error: run terraform init' >&2; exit 1; }\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "terraform"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	workdir := t.TempDir()
+	_, err := tfschema.Load(context.Background(), workdir)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to fetch provider schemas in "+workdir), err.Error())
+	assert.ErrorContains(t, err, "Error: run terraform init")
+
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, "schema.json"), []byte(customSchema), 0600))
+	schemas, err := tfschema.Load(context.Background(), workdir)
+	require.NoError(t, err)
+	assert.NoError(t, schemas.Check("custom_service", "name"))
+}
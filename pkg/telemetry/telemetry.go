@@ -0,0 +1,107 @@
+// Package telemetry configures OpenTelemetry tracing for the drift watcher.
+// Tracing is opt-in and driven entirely by the standard OTEL_* environment
+// variables, so that slow scans can be inspected in any OTLP compatible backend
+// without changing how the CLI is invoked.
+package telemetry
+
+import (
+	"context"
+	"drift-watcher/pkg/logging"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name under which every span of the drift watcher is recorded.
+const InstrumentationName = "drift-watcher"
+
+// ShutdownFunc flushes any pending spans and releases the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Enabled reports whether the environment requests trace export.
+// Tracing is enabled when OTEL_TRACES_EXPORTER is "otlp", or when it is unset
+// and an OTLP endpoint has been configured. OTEL_SDK_DISABLED=true always wins.
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case "otlp":
+		return true
+	case "":
+		return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+	default:
+		return false
+	}
+}
+
+// Setup installs a global tracer provider and context propagator.
+// When tracing is not enabled through the environment it leaves the default no-op
+// provider in place and returns a no-op ShutdownFunc, so callers can always defer it.
+//
+// The OTLP/HTTP exporter reads its endpoint, headers, timeout and TLS settings from
+// the standard OTEL_EXPORTER_OTLP_* variables, and the resource honours
+// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES.
+func Setup(ctx context.Context, version string) (ShutdownFunc, error) {
+	noop := func(context.Context) error { return nil }
+	if !Enabled() {
+		return noop, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return noop, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(
+			semconv.ServiceVersion(version),
+		),
+	)
+	if err != nil {
+		return noop, err
+	}
+	if os.Getenv("OTEL_SERVICE_NAME") == "" {
+		res, _ = resource.Merge(res, resource.NewSchemaless(semconv.ServiceName("driftwatcher")))
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logging.Module(ctx, "telemetry").Debug("OpenTelemetry tracing enabled")
+
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the drift watcher tracer from the globally registered provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// StartSpan starts a span named name as a child of any span carried by ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError marks span as failed with err. It is a no-op for a nil error.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,55 @@
+package telemetry_test
+
+import (
+	"context"
+	"drift-watcher/pkg/telemetry"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{"nothing configured", map[string]string{}, false},
+		{"otlp exporter", map[string]string{"OTEL_TRACES_EXPORTER": "otlp"}, true},
+		{"endpoint only", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, true},
+		{"traces endpoint only", map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4318/v1/traces"}, true},
+		{"exporter none", map[string]string{"OTEL_TRACES_EXPORTER": "none", "OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, false},
+		{"sdk disabled", map[string]string{"OTEL_SDK_DISABLED": "true", "OTEL_TRACES_EXPORTER": "otlp"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"} {
+				t.Setenv(key, tt.env[key])
+			}
+			assert.Equal(t, tt.expected, telemetry.Enabled())
+		})
+	}
+}
+
+func TestSetup_DisabledIsNoop(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+
+	shutdown, err := telemetry.Setup(context.Background(), "test")
+	require.NoError(t, err)
+	require.NotNil(t, shutdown)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestStartSpan_RecordError(t *testing.T) {
+	ctx, span := telemetry.StartSpan(context.Background(), "test-span")
+	assert.NotNil(t, ctx)
+	assert.NotNil(t, span)
+
+	// recording on the default no-op span must not panic
+	telemetry.RecordError(span, nil)
+	telemetry.RecordError(span, errors.New("boom"))
+	span.End()
+}